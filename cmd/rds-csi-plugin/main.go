@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,6 +19,9 @@ import (
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/driver"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/reconciler"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 const (
@@ -31,14 +35,52 @@ var (
 	nodeID     = flag.String("node-id", "", "Node ID (required for node service)")
 	driverName = flag.String("driver-name", "rds.csi.srvlab.io", "Name of the CSI driver")
 
+	// gRPC endpoint security (tcp:// endpoints only)
+	endpointTLSCertFile = flag.String("endpoint-tls-cert-file", "", "Path to TLS certificate for a tcp:// endpoint (requires --endpoint-tls-key-file)")
+	endpointTLSKeyFile  = flag.String("endpoint-tls-key-file", "", "Path to TLS private key for a tcp:// endpoint (requires --endpoint-tls-cert-file)")
+	endpointTLSCAFile   = flag.String("endpoint-tls-ca-file", "", "Path to CA bundle for verifying client certificates on a tcp:// endpoint (enables mTLS)")
+	allowInsecureTCP    = flag.Bool("allow-insecure-tcp", false, "Allow a tcp:// endpoint without TLS (INSECURE - for testing only)")
+
+	// Slow CSI call reporting
+	slowOperationThreshold = flag.Duration("slow-operation-threshold", driver.DefaultSlowOperationThreshold, "Log a warning at -v=1 and count CSI calls still running after this long, without cancelling them (0 disables)")
+	slowOperationOverrides = flag.String("slow-operation-overrides", "", "Per-method slow operation thresholds, e.g. CreateVolume=5m,NodeGetVolumeStats=10s (0 disables the method)")
+
 	// RDS configuration
 	rdsAddress        = flag.String("rds-address", "", "RDS server IP address (required for controller)")
 	rdsPort           = flag.Int("rds-port", 22, "RDS SSH port")
 	rdsUser           = flag.String("rds-user", "admin", "RDS SSH user")
 	rdsKeyFile        = flag.String("rds-key-file", "/etc/rds-csi/ssh-key/id_rsa", "Path to RDS SSH private key")
-	rdsHostKey        = flag.String("rds-host-key", "", "Path to RDS SSH host public key (required for secure verification)")
+	rdsKeySecret      = flag.String("rds-credentials-secret", "", "Secret (namespace/name) the --rds-key-file is mounted from, named in error hints for rejected SSH logins (optional)")
+	rdsHostKey        = flag.String("rds-host-key", "", "Path to RDS SSH host public key(s), one per line in authorized_keys or known_hosts format (required for secure verification)")
+	rdsHostKeyFPs     = flag.String("rds-host-key-fingerprints", "", "Comma-separated SHA256 fingerprints of accepted RDS SSH host keys (alternative to --rds-host-key)")
 	rdsInsecure       = flag.Bool("rds-insecure-skip-verify", false, "Skip SSH host key verification (INSECURE - for testing only)")
+	rdsSSHCiphers     = flag.String("rds-ssh-ciphers", "", "Comma-separated SSH ciphers offered to RDS, e.g. for FIPS (default: x/crypto/ssh defaults)")
+	rdsSSHKex         = flag.String("rds-ssh-kex", "", "Comma-separated SSH key exchange algorithms offered to RDS (default: x/crypto/ssh defaults)")
+	rdsSSHMACs        = flag.String("rds-ssh-macs", "", "Comma-separated SSH MAC algorithms offered to RDS (default: x/crypto/ssh defaults)")
 	rdsVolumeBasePath = flag.String("rds-volume-base-path", "", "Base path for volumes on RDS (e.g., /storage-pool/metal-csi, required for file orphan detection)")
+	rdsExtraBasePaths = flag.String("rds-additional-base-paths", "", "Comma-separated base paths of other storage pools that StorageClasses may select with the volumePath parameter (e.g., /bulk-pool/metal-csi)")
+	slotPrefix        = flag.String("slot-prefix", "", "Additional accepted disk slot prefix for volumes created outside Kubernetes (e.g., infra-; pvc- is always accepted)")
+	volumeNamePrefix  = flag.String("volume-name-prefix", "", "Prefix embedded in new volume slot names as pvc-<prefix>-<uuid>; orphan detection only considers volumes with this prefix (set a unique value per cluster sharing an RDS base path)")
+	rdsBackendsConfig = flag.String("rds-backends-config", "", "Path to a YAML file of additional named RDS backends, selected by the StorageClass 'backend' parameter (optional)")
+	strictCompat      = flag.Bool("strict-compat", false, "Refuse to start if the RouterOS version of an RDS backend cannot be read or parsed (default: log a warning)")
+
+	// RouterOS command audit log
+	rdsAuditLog             = flag.String("rds-audit-log", "", "Append one JSON line per mutating RouterOS command to this file, e.g. /var/log/rds-csi/audit.jsonl (empty to disable)")
+	rdsAuditLogMaxSizeMB    = flag.Int("rds-audit-log-max-size-mb", rds.DefaultAuditMaxSizeBytes/(1024*1024), "Size in MiB at which the audit log is rotated")
+	rdsAuditLogMaxBackups   = flag.Int("rds-audit-log-max-backups", rds.DefaultAuditMaxBackups, "Number of rotated audit log files to keep")
+	rdsAuditLogIncludeReads = flag.Bool("rds-audit-log-include-reads", false, "Also record read-only RouterOS commands (print, monitor-traffic) in the audit log")
+	logRawRDSIO             = flag.Bool("log-raw-rds-io", false, "Log every RouterOS command and its output at -v=5, with secret values redacted")
+
+	// RouterOS command retry budget
+	rdsRetryBudgetRate  = flag.Float64("rds-retry-budget-rate", rds.DefaultRetryBudgetRate, "RouterOS command retries per second all operations together may make; once the budget is used up, failed commands fail fast with Unavailable (0 for unlimited)")
+	rdsRetryBudgetBurst = flag.Int("rds-retry-budget-burst", rds.DefaultRetryBudgetBurst, "RouterOS command retries that may be made at once before -rds-retry-budget-rate applies")
+
+	// RouterOS command scheduling
+	rdsMaxSessions = flag.Int("rds-max-sessions", rds.DefaultMaxSessions, "RouterOS commands run at once on each RDS, shared fairly between provisioning, deletion, query and monitoring commands (0 for unlimited)")
+
+	// RouterOS command timeouts
+	rdsCommandTimeout     = flag.Duration("rds-command-timeout", rds.DefaultCommandTimeout, "How long a RouterOS command may run before it is given up on (0 for no limit)")
+	rdsLongCommandTimeout = flag.Duration("rds-long-command-timeout", rds.DefaultLongCommandTimeout, "How long a /disk add creating a volume, snapshot or restore may run before it is given up on (0 for no limit)")
 
 	// Mode flags
 	controllerMode = flag.Bool("controller", false, "Run in controller mode")
@@ -50,6 +92,38 @@ var (
 	orphanGracePeriod      = flag.Duration("orphan-grace-period", 5*time.Minute, "Minimum age before considering a volume orphaned")
 	orphanDryRun           = flag.Bool("orphan-dry-run", true, "Dry-run mode for orphan cleanup (only log, don't delete)")
 
+	// Retained backing file flags
+	deleteRetainFiles   = flag.Bool("delete-retain-files", false, "On DeleteVolume, move backing files to .trash/ under the volume directory instead of deleting them")
+	trashRetention      = flag.Duration("trash-retention", reconciler.DefaultTrashRetention, "Age after which the orphan reconciler purges files in .trash/ (0 keeps them forever)")
+	deleteBatchWindow   = flag.Duration("delete-batch-window", driver.DefaultDeleteBatchWindow, "How long DeleteVolume waits to remove volumes together with other deletions in one RDS command (0 deletes one at a time)")
+	allowDeleteAttached = flag.Bool("allow-delete-attached", false, "Let DeleteVolume remove volumes still attached to a node instead of failing with FailedPrecondition")
+
+	// Renamed slot flags
+	healRenamedSlots = flag.Bool("heal-renamed-slots", false, "Record the slot a volume renamed on RDS is found under as an annotation on its PV")
+
+	// PV annotation flags
+	annotateBackendDetails = flag.Bool("annotate-backend-details", false, "Record the slot, NQN, RDS address and base path of a volume as informational annotations on its PV when it is attached")
+
+	// Metadata size flags
+	metadataSoftLimit = flag.Int("metadata-soft-limit", utils.DefaultMetadataSoftLimit, "Size in bytes of a volume context or PV annotations above which a warning is logged")
+	metadataHardLimit = flag.Int("metadata-hard-limit", utils.DefaultMetadataHardLimit, "Size in bytes of a volume context or PV annotations above which optional entries are dropped, then CreateVolume or the attachment annotation fails")
+
+	// Provisioning policy flags
+	minVolumeSize  = flag.Int64("min-volume-size", 0, "Smallest volume size in bytes, between 1 GiB and 16 TiB; smaller requests are grown to it if their limit allows (0 for the built-in 1 GiB, there is no unlimited setting)")
+	maxVolumeSize  = flag.Int64("max-volume-size", 0, "Largest size in bytes volumes can be created or expanded to, between 1 GiB and 16 TiB; larger requests fail with OutOfRange (0 for the built-in 16 TiB, there is no unlimited setting)")
+	allowedFSTypes = flag.String("allowed-fstypes", "", "Comma-separated fsTypes CreateVolume accepts, e.g. xfs; others are rejected with InvalidArgument (empty allows all supported)")
+
+	// Capacity monitor flags
+	capacityCheckInterval  = flag.Duration("capacity-check-interval", driver.DefaultCapacityCheckInterval, "Interval between RDS free space checks exported as rds_csi_pool_*_bytes (0 to disable)")
+	capacityBasePaths      = flag.String("capacity-base-paths", "", "Comma-separated volume base paths to monitor (default: --rds-volume-base-path)")
+	lowCapacityThreshold   = flag.Float64("low-capacity-threshold-percent", driver.DefaultLowCapacityThresholdPercent, "Post a LowCapacity event when free space drops below this percentage (0 to disable)")
+	capacityEventConfigMap = flag.String("capacity-event-configmap", "", "ConfigMap (namespace/name) to post LowCapacity events on, e.g. rds-csi/rds-csi-config (empty to only log)")
+
+	// CSIStorageCapacity publisher flags
+	publishStorageCapacity   = flag.Bool("publish-storage-capacity", false, "Publish a CSIStorageCapacity object per StorageClass of this driver from the controller, instead of the external-provisioner's --enable-capacity")
+	storageCapacityNamespace = flag.String("storage-capacity-namespace", "", "Namespace of the published CSIStorageCapacity objects (default: $POD_NAMESPACE)")
+	storageCapacityInterval  = flag.Duration("storage-capacity-interval", driver.DefaultStorageCapacityInterval, "Interval between CSIStorageCapacity updates")
+
 	// Attachment management flags
 	attachmentGracePeriod       = flag.Duration("attachment-grace-period", 30*time.Second, "Grace period for attachment handoff during live migration")
 	attachmentReconcileInterval = flag.Duration("attachment-reconcile-interval", 5*time.Minute, "Interval between attachment reconciliation checks")
@@ -58,14 +132,52 @@ var (
 	enableVMISerialization = flag.Bool("enable-vmi-serialization", false, "Enable per-VMI operation serialization to mitigate kubevirt concurrency issues")
 	vmiCacheTTL            = flag.Duration("vmi-cache-ttl", 60*time.Second, "Cache TTL for PVC-to-VMI mapping lookups")
 
+	// CSI socket watchdog flags
+	socketCheckInterval    = flag.Duration("socket-check-interval", driver.DefaultSocketCheckInterval, "Interval between checks that the CSI socket still exists; a missing socket is re-created (0 to disable)")
+	registrationSocketPath = flag.String("registration-socket-path", "", "node-driver-registrar registration socket to include in the registration health metric (optional, e.g. /var/lib/kubelet/plugins_registry/rds.csi.srvlab.io-reg.sock)")
+
+	// Node NVMe/TCP flags
+	nvmeTCPModprobe       = flag.Bool("nvme-tcp-modprobe", true, "Run modprobe nvme_tcp at node startup if the module is not loaded (needs CAP_SYS_MODULE and the host's /lib/modules)")
+	blockStageMetadata    = flag.Bool("block-stage-metadata", false, "Record the NQN of staged block volumes in a file at the staging path, so block volumes whose NQN cannot be derived from the volume ID (static volumes) can be published")
+	readProbeInterval     = flag.Duration("volume-read-probe-interval", 0, "Interval between O_DIRECT reads of the first 4KiB of every staged volume; failures mark the volume abnormal in NodeGetVolumeStats (0 to disable)")
+	nvmeConnectRate       = flag.Float64("nvme-connect-rate", driver.DefaultNVMeConnectRate, "NVMe connects per second the node may start across all volumes; stages that get no slot before their deadline fail with Unavailable (0 for no limit)")
+	drainBeforeDisconnect = flag.Bool("drain-before-disconnect", false, "Sync filesystem volumes and wait for their device's in-flight I/O to reach zero before NodeUnstageVolume unmounts and disconnects them")
+	drainTimeout          = flag.Duration("drain-timeout", driver.DefaultDrainTimeout, "Maximum time NodeUnstageVolume waits for a device to drain with -drain-before-disconnect; unstage fails with Internal after it")
+	enableProjectQuota    = flag.Bool("enable-project-quota", false, "Enforce the quotaBytes StorageClass parameter of ext4 and XFS volumes with a filesystem project quota; staging a volume with quotaBytes fails without it")
+	strictVolumeContext   = flag.Bool("strict-volume-context", false, "Fail NodeStageVolume and NodePublishVolume with InvalidArgument for volume contexts lacking keys (PVs of early driver versions) instead of inferring them")
+	forceUnmountOnBusy    = flag.Bool("force-unmount-on-busy", false, "Force unmount (lazily, if no process holds it) an unstage or unpublish target still busy after the unmount retries, instead of failing with Internal")
+	stagePhaseBudgets     = flag.String("stage-phase-budgets", "", "Percent of the NodeStageVolume deadline each phase may use, e.g. connect=50,format=20 (default connect=40,device_wait=20,format=30,mount=10; must total 100)")
+
+	// Node staging directory janitor flags
+	enableStagingJanitor      = flag.Bool("enable-staging-janitor", false, "Remove staging directories kubelet left behind for volumes that are no longer mounted, connected or attached to the node")
+	stagingJanitorInterval    = flag.Duration("staging-janitor-interval", driver.DefaultStagingJanitorInterval, "Interval between staging directory janitor scans")
+	stagingJanitorGracePeriod = flag.Duration("staging-janitor-grace-period", driver.DefaultStagingJanitorGracePeriod, "Minimum time a staging directory must be untouched before the janitor removes it")
+	kubeletRoot               = flag.String("kubelet-root", "/var/lib/kubelet", "Kubelet root directory (kubelet --root-dir), as mounted in the node plugin. The staging janitor scans plugins/kubernetes.io/csi/<driver>/ below it, and unpublish and unstage only remove leftover files below it")
+	kubeletDirDeprecated      = flag.String("kubelet-dir", "/var/lib/kubelet", "DEPRECATED: use --kubelet-root")
+
 	// Kubernetes configuration
 	kubeconfig = flag.String("kubeconfig", "", "Path to kubeconfig file (optional, uses in-cluster config if not specified)")
 
 	// Metrics configuration
-	metricsAddr = flag.String("metrics-address", ":9809", "Address for Prometheus metrics endpoint (empty to disable)")
+	metricsAddr           = flag.String("metrics-bind-address", ":9809", "Address for Prometheus metrics endpoint (empty to disable)")
+	metricsAddrDeprecated = flag.String("metrics-address", ":9809", "DEPRECATED: use --metrics-bind-address")
+	metricsTokenFile      = flag.String("metrics-bearer-token-file", "", "Path to file containing a bearer token required to scrape metrics (optional)")
+	metricsTLSCertFile    = flag.String("metrics-tls-cert-file", "", "Path to TLS certificate for the metrics endpoint (optional, requires --metrics-tls-key-file)")
+	metricsTLSKeyFile     = flag.String("metrics-tls-key-file", "", "Path to TLS private key for the metrics endpoint (optional, requires --metrics-tls-cert-file)")
+	debugAllowRemote      = flag.Bool("debug-allow-remote", false, "Serve the controller's /debug/rds/ endpoints on the metrics server to other hosts, not just localhost")
+
+	// Admin endpoint configuration
+	adminAddr      = flag.String("admin-bind-address", "", "Address for the controller admin endpoint (empty to disable, requires --admin-bearer-token-file)")
+	adminTokenFile = flag.String("admin-bearer-token-file", "", "Path to file containing the bearer token required for admin requests")
 
 	// Version flag
 	version = flag.Bool("version", false, "Print version and exit")
+
+	// Volume import flags
+	importVolumes      = flag.Bool("import-volumes", false, "Print PersistentVolume manifests for the RDS volumes no PV references, then exit (uses the --rds-* and --kubeconfig flags, changes nothing)")
+	importFSType       = flag.String("import-fstype", "ext4", "fsType of the imported PVs; RDS does not know the filesystem on a volume, so check it before applying")
+	importStorageClass = flag.String("import-storage-class", "", "storageClassName of the imported PVs (empty for none)")
+	importNVMEAddress  = flag.String("import-nvme-address", "", "NVMe/TCP address recorded in the imported PVs (default: --rds-address)")
 )
 
 func main() {
@@ -78,6 +190,20 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *importVolumes {
+		if err := runImportVolumes(); err != nil {
+			klog.Fatalf("Volume import failed: %v", err)
+		}
+		os.Exit(0)
+	}
+
+	if flag.NArg() > 0 {
+		if err := runSubcommand(flag.Args()); err != nil {
+			klog.Fatalf("%s failed: %v", strings.Join(flag.Args(), " "), err)
+		}
+		os.Exit(0)
+	}
+
 	// Validate mode flags
 	if !*controllerMode && !*nodeMode {
 		klog.Fatal("Must specify at least one of --controller or --node")
@@ -95,6 +221,10 @@ func main() {
 	// Read SSH private key and host key if controller mode
 	var privateKey []byte
 	var hostKey []byte
+	var hostKeyFingerprints []string
+	var rdsBackends map[string]rds.BackendConfig
+	var auditLog *rds.AuditLog
+	var sshAlgorithms rds.SSHAlgorithms
 	var err error
 	if *controllerMode {
 		privateKey, err = os.ReadFile(*rdsKeyFile)
@@ -104,8 +234,8 @@ func main() {
 		klog.V(4).Infof("Loaded SSH key from %s", *rdsKeyFile)
 
 		// Enforce host key verification in production
-		if *rdsHostKey == "" && !*rdsInsecure {
-			klog.Fatal("SECURITY: --rds-host-key is required for production use. Use --rds-insecure-skip-verify ONLY for testing.")
+		if *rdsHostKey == "" && *rdsHostKeyFPs == "" && !*rdsInsecure {
+			klog.Fatal("SECURITY: --rds-host-key or --rds-host-key-fingerprints is required for production use. Use --rds-insecure-skip-verify ONLY for testing.")
 		}
 
 		// Read host key if provided
@@ -115,19 +245,114 @@ func main() {
 				klog.Fatalf("Failed to read SSH host key from %s: %v", *rdsHostKey, err)
 			}
 			klog.V(4).Infof("Loaded SSH host key from %s", *rdsHostKey)
-		} else if *rdsInsecure {
+		} else if *rdsInsecure && *rdsHostKeyFPs == "" {
 			klog.Warning("SECURITY WARNING: SSH host key verification is disabled. This is INSECURE and should only be used for testing!")
 		}
+
+		sshAlgorithms = rds.SSHAlgorithms{
+			Ciphers:      rds.ParseSSHAlgorithmList(*rdsSSHCiphers),
+			KeyExchanges: rds.ParseSSHAlgorithmList(*rdsSSHKex),
+			MACs:         rds.ParseSSHAlgorithmList(*rdsSSHMACs),
+		}
+		if err := sshAlgorithms.Validate(); err != nil {
+			klog.Fatalf("Invalid --rds-ssh-ciphers, --rds-ssh-kex or --rds-ssh-macs: %v", err)
+		}
+
+		for _, fp := range strings.Split(*rdsHostKeyFPs, ",") {
+			if fp = strings.TrimSpace(fp); fp != "" {
+				hostKeyFingerprints = append(hostKeyFingerprints, fp)
+			}
+		}
+
+		if *rdsBackendsConfig != "" {
+			rdsBackends, err = rds.LoadBackendsConfig(*rdsBackendsConfig)
+			if err != nil {
+				klog.Fatalf("Failed to load RDS backends: %v", err)
+			}
+			klog.Infof("Loaded %d additional RDS backend(s) from %s", len(rdsBackends), *rdsBackendsConfig)
+		}
+
+		if *rdsAuditLog != "" {
+			auditLog, err = rds.NewAuditLog(rds.AuditConfig{
+				Path:            *rdsAuditLog,
+				MaxSizeBytes:    int64(*rdsAuditLogMaxSizeMB) * 1024 * 1024,
+				MaxBackups:      *rdsAuditLogMaxBackups,
+				IncludeReadOnly: *rdsAuditLogIncludeReads,
+			})
+			if err != nil {
+				klog.Fatalf("Failed to open RDS audit log: %v", err)
+			}
+			klog.Infof("Recording RouterOS commands in audit log %s", *rdsAuditLog)
+		}
 	}
 
 	// Create Kubernetes client if needed (for orphan reconciler, attachment tracking, or VMI serialization)
 	var k8sClient kubernetes.Interface
-	if *controllerMode && (*enableOrphanReconciler || *enableVMISerialization) {
+	if *controllerMode && (*enableOrphanReconciler || *enableVMISerialization || *adminAddr != "" || *capacityEventConfigMap != "" || *publishStorageCapacity) {
 		k8sClient, err = createKubernetesClient(*kubeconfig)
 		if err != nil {
 			klog.Fatalf("Failed to create Kubernetes client: %v", err)
 		}
 		klog.Info("Kubernetes client initialized")
+	} else if *nodeMode {
+		// Optional on nodes: used to post events and find the volumes attached to the node
+		k8sClient, err = createKubernetesClient(*kubeconfig)
+		if err != nil {
+			klog.Warningf("Kubernetes client unavailable, node will not post events: %v", err)
+			k8sClient = nil
+		} else {
+			klog.Info("Kubernetes client initialized")
+		}
+	}
+
+	// Honour the deprecated flag names so existing manifests keep working
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "metrics-address":
+			klog.Warning("--metrics-address is deprecated, use --metrics-bind-address")
+			*metricsAddr = *metricsAddrDeprecated
+		case "kubelet-dir":
+			klog.Warning("--kubelet-dir is deprecated, use --kubelet-root")
+			*kubeletRoot = *kubeletDirDeprecated
+		}
+	})
+
+	if (*metricsTLSCertFile == "") != (*metricsTLSKeyFile == "") {
+		klog.Fatal("--metrics-tls-cert-file and --metrics-tls-key-file must be specified together")
+	}
+
+	if (*endpointTLSCertFile == "") != (*endpointTLSKeyFile == "") {
+		klog.Fatal("--endpoint-tls-cert-file and --endpoint-tls-key-file must be specified together")
+	}
+	if *endpointTLSCAFile != "" && *endpointTLSCertFile == "" {
+		klog.Fatal("--endpoint-tls-ca-file requires --endpoint-tls-cert-file and --endpoint-tls-key-file")
+	}
+	slowOverrides, err := driver.ParseSlowOperationOverrides(*slowOperationOverrides)
+	if err != nil {
+		klog.Fatalf("Invalid --slow-operation-overrides: %v", err)
+	}
+
+	var metricsToken string
+	if *metricsTokenFile != "" {
+		metricsToken, err = observability.LoadBearerToken(*metricsTokenFile)
+		if err != nil {
+			klog.Fatalf("Failed to load metrics bearer token: %v", err)
+		}
+	}
+
+	// The admin endpoint deletes files on RDS, so it is never served unauthenticated
+	var adminToken string
+	if *adminAddr != "" {
+		if !*controllerMode {
+			klog.Fatal("--admin-bind-address requires --controller")
+		}
+		if *adminTokenFile == "" {
+			klog.Fatal("--admin-bind-address requires --admin-bearer-token-file")
+		}
+		adminToken, err = observability.LoadBearerToken(*adminTokenFile)
+		if err != nil {
+			klog.Fatalf("Failed to load admin bearer token: %v", err)
+		}
 	}
 
 	// Create Prometheus metrics
@@ -140,6 +365,59 @@ func main() {
 	// Read managed NQN prefix for node plugin
 	managedNQNPrefix := os.Getenv(nvme.EnvManagedNQNPrefix)
 
+	phaseBudgets, err := driver.ParsePhaseBudgets(*stagePhaseBudgets, driver.DefaultStagePhaseBudgets())
+	if err != nil {
+		klog.Fatalf("Invalid --stage-phase-budgets: %v", err)
+	}
+
+	fsTypes, err := driver.ParseAllowedFSTypes(*allowedFSTypes)
+	if err != nil {
+		klog.Fatalf("Invalid --allowed-fstypes: %v", err)
+	}
+
+	var additionalBasePaths []string
+	for _, path := range strings.Split(*rdsExtraBasePaths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			additionalBasePaths = append(additionalBasePaths, path)
+		}
+	}
+
+	var capacityPaths []string
+	for _, path := range strings.Split(*capacityBasePaths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			capacityPaths = append(capacityPaths, path)
+		}
+	}
+	var capacityEventNamespace, capacityEventName string
+	if *capacityEventConfigMap != "" {
+		var ok bool
+		capacityEventNamespace, capacityEventName, ok = strings.Cut(*capacityEventConfigMap, "/")
+		if !ok || capacityEventNamespace == "" || capacityEventName == "" {
+			klog.Fatalf("Invalid --capacity-event-configmap %q: expected namespace/name", *capacityEventConfigMap)
+		}
+	}
+	if *lowCapacityThreshold < 0 || *lowCapacityThreshold >= 100 {
+		klog.Fatalf("Invalid --low-capacity-threshold-percent %v: must be at least 0 and below 100", *lowCapacityThreshold)
+	}
+
+	capacityNamespace := *storageCapacityNamespace
+	if capacityNamespace == "" {
+		capacityNamespace = os.Getenv("POD_NAMESPACE")
+	}
+	if *publishStorageCapacity && *controllerMode && capacityNamespace == "" {
+		klog.Fatal("--publish-storage-capacity needs --storage-capacity-namespace or POD_NAMESPACE")
+	}
+
+	serverOptions := driver.ServerOptions{
+		TLSCertFile:      *endpointTLSCertFile,
+		TLSKeyFile:       *endpointTLSKeyFile,
+		TLSClientCAFile:  *endpointTLSCAFile,
+		AllowInsecureTCP: *allowInsecureTCP,
+
+		SlowOperationThreshold: *slowOperationThreshold,
+		SlowOperationOverrides: slowOverrides,
+	}
+
 	// Create driver configuration
 	config := driver.DriverConfig{
 		DriverName:                  *driverName,
@@ -148,21 +426,70 @@ func main() {
 		RDSPort:                     *rdsPort,
 		RDSUser:                     *rdsUser,
 		RDSPrivateKey:               privateKey,
+		RDSCredentialsSecret:        *rdsKeySecret,
 		RDSHostKey:                  hostKey,
+		RDSHostKeyFingerprints:      hostKeyFingerprints,
 		RDSInsecureSkipVerify:       *rdsInsecure,
+		RDSSSHAlgorithms:            sshAlgorithms,
 		RDSVolumeBasePath:           *rdsVolumeBasePath,
+		RDSAdditionalBasePaths:      additionalBasePaths,
+		RDSBackends:                 rdsBackends,
+		StrictCompat:                *strictCompat,
+		RDSAuditLog:                 auditLog,
+		LogRawRDSIO:                 *logRawRDSIO,
+		RDSRetryBudget:              rds.NewRetryBudget(*rdsRetryBudgetRate, *rdsRetryBudgetBurst),
+		RDSMaxSessions:              *rdsMaxSessions,
+		RDSCommandTimeout:           commandTimeoutFlag(*rdsCommandTimeout),
+		RDSLongCommandTimeout:       commandTimeoutFlag(*rdsLongCommandTimeout),
+		SlotPrefix:                  *slotPrefix,
+		VolumeNamePrefix:            *volumeNamePrefix,
 		K8sClient:                   k8sClient,
 		Metrics:                     promMetrics,
 		EnableOrphanReconciler:      *enableOrphanReconciler,
 		OrphanCheckInterval:         *orphanCheckInterval,
 		OrphanGracePeriod:           *orphanGracePeriod,
 		OrphanDryRun:                *orphanDryRun,
+		DeleteRetainFiles:           *deleteRetainFiles,
+		TrashRetention:              *trashRetention,
+		DeleteBatchWindow:           *deleteBatchWindow,
+		AllowDeleteAttached:         *allowDeleteAttached,
+		HealRenamedSlots:            *healRenamedSlots,
+		AnnotateBackendDetails:      *annotateBackendDetails,
+		MetadataLimits:              utils.MetadataLimits{Soft: *metadataSoftLimit, Hard: *metadataHardLimit},
+		AllowedFSTypes:              fsTypes,
+		MinVolumeSize:               *minVolumeSize,
+		MaxVolumeSize:               *maxVolumeSize,
+		CapacityCheckInterval:       *capacityCheckInterval,
+		CapacityBasePaths:           capacityPaths,
+		LowCapacityThresholdPercent: *lowCapacityThreshold,
+		CapacityEventNamespace:      capacityEventNamespace,
+		CapacityEventConfigMap:      capacityEventName,
+		PublishStorageCapacity:      *publishStorageCapacity,
+		StorageCapacityNamespace:    capacityNamespace,
+		StorageCapacityInterval:     *storageCapacityInterval,
+		SocketCheckInterval:         *socketCheckInterval,
+		RegistrationSocketPath:      *registrationSocketPath,
+		ServerOptions:               serverOptions,
 		EnableAttachmentReconciler:  true, // Always enable attachment reconciler in controller mode
 		AttachmentGracePeriod:       *attachmentGracePeriod,
 		AttachmentReconcileInterval: *attachmentReconcileInterval,
 		EnableVMISerialization:      *enableVMISerialization,
 		VMICacheTTL:                 *vmiCacheTTL,
 		ManagedNQNPrefix:            managedNQNPrefix,
+		NVMeTCPModprobe:             *nvmeTCPModprobe,
+		StagePhaseBudgets:           phaseBudgets,
+		BlockStageMetadata:          *blockStageMetadata,
+		VolumeReadProbeInterval:     *readProbeInterval,
+		NVMeConnectRate:             *nvmeConnectRate,
+		DrainBeforeDisconnect:       *drainBeforeDisconnect,
+		DrainTimeout:                *drainTimeout,
+		EnableProjectQuota:          *enableProjectQuota,
+		StrictVolumeContext:         *strictVolumeContext,
+		ForceUnmountOnBusy:          *forceUnmountOnBusy,
+		EnableStagingJanitor:        *enableStagingJanitor,
+		KubeletDir:                  *kubeletRoot,
+		StagingJanitorInterval:      *stagingJanitorInterval,
+		StagingJanitorGracePeriod:   *stagingJanitorGracePeriod,
 		EnableController:            *controllerMode,
 		EnableNode:                  *nodeMode,
 	}
@@ -200,15 +527,36 @@ func main() {
 	if promMetrics != nil {
 		go func() {
 			mux := http.NewServeMux()
-			mux.Handle("/metrics", promMetrics.Handler())
+			mux.Handle("/metrics", observability.BearerTokenHandler(promMetrics.Handler(), metricsToken))
+			if *controllerMode {
+				mux.Handle("/debug/rds/", observability.BearerTokenHandler(drv.DebugHandler(*debugAllowRemote), metricsToken))
+			}
 
-			klog.Infof("Starting metrics server on %s", *metricsAddr)
-			if err := http.ListenAndServe(*metricsAddr, mux); err != nil && err != http.ErrServerClosed {
+			var err error
+			if *metricsTLSCertFile != "" {
+				klog.Infof("Starting metrics server on %s (TLS, auth=%v)", *metricsAddr, metricsToken != "")
+				err = http.ListenAndServeTLS(*metricsAddr, *metricsTLSCertFile, *metricsTLSKeyFile, mux)
+			} else {
+				klog.Infof("Starting metrics server on %s (auth=%v)", *metricsAddr, metricsToken != "")
+				err = http.ListenAndServe(*metricsAddr, mux)
+			}
+			if err != nil && err != http.ErrServerClosed {
 				klog.Errorf("Metrics server failed: %v", err)
 			}
 		}()
 	}
 
+	// Start admin HTTP server
+	if *adminAddr != "" {
+		go func() {
+			klog.Infof("Starting admin server on %s", *adminAddr)
+			err := http.ListenAndServe(*adminAddr, observability.BearerTokenHandler(drv.AdminHandler(), adminToken))
+			if err != nil && err != http.ErrServerClosed {
+				klog.Errorf("Admin server failed: %v", err)
+			}
+		}()
+	}
+
 	// Handle shutdown gracefully with timeout
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -272,3 +620,107 @@ func createKubernetesClient(kubeconfigPath string) (kubernetes.Interface, error)
 
 	return clientset, nil
 }
+
+// commandTimeoutFlag returns the RDS client timeout of a command timeout flag, whose 0
+// means no limit
+func commandTimeoutFlag(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return rds.NoTimeout
+	}
+	return timeout
+}
+
+// runImportVolumes prints static PV manifests for the RDS volumes under
+// --rds-volume-base-path that no PV references. It only reads from RDS and Kubernetes.
+func runImportVolumes() error {
+	if *rdsAddress == "" {
+		return fmt.Errorf("--rds-address is required")
+	}
+	if *rdsHostKey == "" && *rdsHostKeyFPs == "" && !*rdsInsecure {
+		return fmt.Errorf("--rds-host-key or --rds-host-key-fingerprints is required (or --rds-insecure-skip-verify for testing)")
+	}
+	if err := utils.SetSlotPrefix(*slotPrefix); err != nil {
+		return fmt.Errorf("invalid --slot-prefix: %w", err)
+	}
+	if err := utils.SetVolumeNamePrefix(*volumeNamePrefix); err != nil {
+		return fmt.Errorf("invalid --volume-name-prefix: %w", err)
+	}
+
+	privateKey, err := os.ReadFile(*rdsKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read SSH key from %s: %w", *rdsKeyFile, err)
+	}
+	var hostKey []byte
+	if *rdsHostKey != "" {
+		if hostKey, err = os.ReadFile(*rdsHostKey); err != nil {
+			return fmt.Errorf("failed to read SSH host key from %s: %w", *rdsHostKey, err)
+		}
+	}
+	var hostKeyFingerprints []string
+	for _, fp := range strings.Split(*rdsHostKeyFPs, ",") {
+		if fp = strings.TrimSpace(fp); fp != "" {
+			hostKeyFingerprints = append(hostKeyFingerprints, fp)
+		}
+	}
+
+	rdsClient, err := rds.NewClient(rds.ClientConfig{
+		Address:             *rdsAddress,
+		Port:                *rdsPort,
+		User:                *rdsUser,
+		PrivateKey:          privateKey,
+		HostKey:             hostKey,
+		HostKeyFingerprints: hostKeyFingerprints,
+		InsecureSkipVerify:  *rdsInsecure,
+		Algorithms: rds.SSHAlgorithms{
+			Ciphers:      rds.ParseSSHAlgorithmList(*rdsSSHCiphers),
+			KeyExchanges: rds.ParseSSHAlgorithmList(*rdsSSHKex),
+			MACs:         rds.ParseSSHAlgorithmList(*rdsSSHMACs),
+		},
+		CommandTimeout: commandTimeoutFlag(*rdsCommandTimeout),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create RDS client: %w", err)
+	}
+	if err := rdsClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to RDS: %w", err)
+	}
+	defer func() { _ = rdsClient.Close() }()
+
+	k8sClient, err := createKubernetesClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	report, err := driver.ImportVolumes(context.Background(), rdsClient, k8sClient, driver.ImportConfig{
+		DriverName:       *driverName,
+		BasePath:         *rdsVolumeBasePath,
+		RDSAddress:       *rdsAddress,
+		NVMEAddress:      *importNVMEAddress,
+		FSType:           *importFSType,
+		StorageClassName: *importStorageClass,
+	})
+	if err != nil {
+		return err
+	}
+	return report.WriteYAML(os.Stdout)
+}
+
+// runSubcommand runs the command given after the flags. The only one is
+// `inspect pv-context <pv>`, which prints whether the node can stage the PV with the
+// volume context it has (uses the --kubeconfig and --driver-name flags).
+func runSubcommand(args []string) error {
+	if len(args) != 3 || args[0] != "inspect" || args[1] != "pv-context" {
+		return fmt.Errorf("unknown command, expected: inspect pv-context <pv>")
+	}
+	pvName := args[2]
+
+	k8sClient, err := createKubernetesClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	report, err := driver.InspectPVContext(context.Background(), k8sClient, *driverName, pvName)
+	if err != nil {
+		return err
+	}
+	return report.Write(os.Stdout, pvName)
+}
@@ -0,0 +1,208 @@
+package attachment
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// AttachmentEventType is the kind of attachment change an event reports
+type AttachmentEventType string
+
+const (
+	// AttachmentEventAttach reports a volume attached to its first node
+	AttachmentEventAttach AttachmentEventType = "attach"
+
+	// AttachmentEventDetach reports a node detached from a volume. AttachedVolumes tells
+	// whether the volume is still attached elsewhere.
+	AttachmentEventDetach AttachmentEventType = "detach"
+
+	// AttachmentEventSecondaryAdd reports a migration target attached next to the source
+	AttachmentEventSecondaryAdd AttachmentEventType = "secondary-add"
+
+	// AttachmentEventMigrationComplete reports a migration whose source detached; NodeID
+	// is the node that remains
+	AttachmentEventMigrationComplete AttachmentEventType = "migration-complete"
+
+	// AttachmentEventMigrationAbort reports a migration reverted to its source; NodeID is
+	// the source
+	AttachmentEventMigrationAbort AttachmentEventType = "migration-abort"
+
+	// AttachmentEventRebuild reports the state rebuilt from the cluster, replacing all
+	// earlier attachments
+	AttachmentEventRebuild AttachmentEventType = "rebuild"
+)
+
+// eventBufferSize is how many events a subscriber may fall behind before events to it
+// are dropped
+const eventBufferSize = 256
+
+// AttachmentEvent is a change of the attachment state. Events of one volume arrive in
+// the order of its changes. Across volumes they may arrive out of order; Seq gives the
+// order in which the changes were made.
+type AttachmentEvent struct {
+	Type     AttachmentEventType
+	Seq      uint64
+	Time     time.Time
+	VolumeID string // Empty for rebuild events
+	NodeID   string
+
+	// AttachedVolumes is the number of attached volumes right after the change
+	AttachedVolumes int
+
+	// MigrationStartedAt and MigrationTimeout are set on secondary-add events
+	MigrationStartedAt time.Time
+	MigrationTimeout   time.Duration
+}
+
+// eventHub fans attachment events out to subscribers. Sends never block: an event for a
+// subscriber whose channel is full is dropped and counted.
+type eventHub struct {
+	mu          sync.RWMutex
+	subscribers map[<-chan AttachmentEvent]chan AttachmentEvent
+	dropped     uint64 // Protected by mu (write lock)
+}
+
+// Subscribe returns a channel receiving every attachment change from now on. The
+// channel holds eventBufferSize events; a consumer falling further behind misses events
+// (see DroppedEvents), so consumers that must not miss a change resync from
+// ListAttachments. Call Unsubscribe to stop receiving.
+func (am *AttachmentManager) Subscribe() <-chan AttachmentEvent {
+	ch := make(chan AttachmentEvent, eventBufferSize)
+	am.events.mu.Lock()
+	defer am.events.mu.Unlock()
+	if am.events.subscribers == nil {
+		am.events.subscribers = make(map[<-chan AttachmentEvent]chan AttachmentEvent)
+	}
+	am.events.subscribers[ch] = ch
+	return ch
+}
+
+// Unsubscribe stops the events to a channel returned by Subscribe and closes it
+func (am *AttachmentManager) Unsubscribe(events <-chan AttachmentEvent) {
+	am.events.mu.Lock()
+	defer am.events.mu.Unlock()
+	if ch, ok := am.events.subscribers[events]; ok {
+		delete(am.events.subscribers, events)
+		close(ch)
+	}
+}
+
+// DroppedEvents returns how many events were dropped because a subscriber fell behind
+func (am *AttachmentManager) DroppedEvents() uint64 {
+	am.events.mu.RLock()
+	defer am.events.mu.RUnlock()
+	return am.events.dropped
+}
+
+// newEventLocked returns an event of the change just made. Caller must hold am.mu.
+func (am *AttachmentManager) newEventLocked(eventType AttachmentEventType, volumeID, nodeID string) AttachmentEvent {
+	am.eventSeq++
+	return AttachmentEvent{
+		Type:            eventType,
+		Seq:             am.eventSeq,
+		Time:            time.Now(),
+		VolumeID:        volumeID,
+		NodeID:          nodeID,
+		AttachedVolumes: len(am.attachments),
+	}
+}
+
+// publish hands events to the migration watcher and the subscribers. Callers publish
+// after releasing am.mu and before releasing the volume lock, so that the events of a
+// volume go out in order. Events with no type are skipped.
+func (am *AttachmentManager) publish(events ...AttachmentEvent) {
+	for _, event := range events {
+		if event.Type == "" {
+			continue
+		}
+		am.migrationWatcher.handle(event)
+
+		am.events.mu.RLock()
+		full := 0
+		for _, ch := range am.events.subscribers {
+			select {
+			case ch <- event:
+			default:
+				full++
+			}
+		}
+		am.events.mu.RUnlock()
+
+		if full > 0 {
+			am.events.mu.Lock()
+			am.events.dropped += uint64(full)
+			am.events.mu.Unlock()
+			klog.V(4).Infof("Dropped %s event of volume %s for %d slow subscriber(s)", event.Type, event.VolumeID, full)
+		}
+	}
+}
+
+// migrationWatcher aborts migrations that exceed their timeout. It follows the
+// attachment events: a secondary-add arms a timer for the volume, and the end of the
+// migration (completion, abort or detach) stops it. A timer that fires for a migration
+// that has since ended or restarted does nothing.
+type migrationWatcher struct {
+	am     *AttachmentManager
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// handle arms or stops the timer of the event's volume
+func (w *migrationWatcher) handle(event AttachmentEvent) {
+	switch event.Type {
+	case AttachmentEventSecondaryAdd:
+		w.arm(event.VolumeID, event.MigrationStartedAt, event.MigrationTimeout)
+	case AttachmentEventMigrationComplete, AttachmentEventMigrationAbort, AttachmentEventDetach:
+		w.stop(event.VolumeID)
+	}
+}
+
+// arm schedules an abort of the migration of volumeID started at startedAt for when it
+// exceeds timeout
+func (w *migrationWatcher) arm(volumeID string, startedAt time.Time, timeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.timers[volumeID]; ok {
+		timer.Stop()
+		delete(w.timers, volumeID)
+	}
+	if timeout <= 0 {
+		return
+	}
+	w.timers[volumeID] = time.AfterFunc(timeout, func() {
+		w.am.handleMigrationTimeout(volumeID, startedAt)
+	})
+}
+
+// stop cancels the pending migration timeout of volumeID, if any
+func (w *migrationWatcher) stop(volumeID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.timers[volumeID]; ok {
+		timer.Stop()
+		delete(w.timers, volumeID)
+	}
+}
+
+// handleMigrationTimeout aborts the migration if the one that armed the timer
+// is still in progress. A newer migration or a completed one is left alone.
+func (am *AttachmentManager) handleMigrationTimeout(volumeID string, startedAt time.Time) {
+	am.mu.RLock()
+	state, exists := am.attachments[volumeID]
+	stillMigrating := exists && state.MigrationStartedAt != nil && state.MigrationStartedAt.Equal(startedAt)
+	am.mu.RUnlock()
+
+	if !stillMigrating {
+		return
+	}
+
+	// The migration may finish or restart before the abort takes the locks, so the
+	// abort checks again that it is the same migration
+	klog.Warningf("Migration for volume %s exceeded timeout, aborting", volumeID)
+	if _, err := am.abortMigration(context.Background(), volumeID, &startedAt); err != nil {
+		klog.Errorf("Failed to abort timed out migration for volume %s: %v", volumeID, err)
+	}
+}
@@ -0,0 +1,187 @@
+package attachment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// receiveEvents reads count events from events, failing the test if they do not arrive
+func receiveEvents(t *testing.T, events <-chan AttachmentEvent, count int) []AttachmentEvent {
+	t.Helper()
+	received := make([]AttachmentEvent, 0, count)
+	for len(received) < count {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d events, want %d: %+v", len(received), count, received)
+		}
+	}
+	return received
+}
+
+// assertNoEvent fails the test if an event is waiting on events
+func assertNoEvent(t *testing.T, events <-chan AttachmentEvent) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	default:
+	}
+}
+
+func TestSubscribe_MigrationLifecycle(t *testing.T) {
+	am := NewAttachmentManager(nil)
+	ctx := context.Background()
+	events := am.Subscribe()
+	defer am.Unsubscribe(events)
+
+	if err := am.TrackAttachmentWithMode(ctx, "vol-1", "node-1", "RWX"); err != nil {
+		t.Fatalf("TrackAttachmentWithMode failed: %v", err)
+	}
+	if err := am.AddSecondaryAttachment(ctx, "vol-1", "node-2", time.Minute); err != nil {
+		t.Fatalf("AddSecondaryAttachment failed: %v", err)
+	}
+	if err := am.SetActiveWriter(ctx, "vol-1", "node-2"); err != nil {
+		t.Fatalf("SetActiveWriter failed: %v", err)
+	}
+	if _, err := am.RemoveNodeAttachment(ctx, "vol-1", "node-1"); err != nil {
+		t.Fatalf("RemoveNodeAttachment(node-1) failed: %v", err)
+	}
+	if _, err := am.RemoveNodeAttachment(ctx, "vol-1", "node-2"); err != nil {
+		t.Fatalf("RemoveNodeAttachment(node-2) failed: %v", err)
+	}
+
+	want := []struct {
+		eventType AttachmentEventType
+		nodeID    string
+		attached  int
+	}{
+		{AttachmentEventAttach, "node-1", 1},
+		{AttachmentEventSecondaryAdd, "node-2", 1},
+		{AttachmentEventMigrationComplete, "node-2", 1},
+		{AttachmentEventDetach, "node-2", 0},
+	}
+	received := receiveEvents(t, events, len(want))
+	for i, w := range want {
+		got := received[i]
+		if got.Type != w.eventType || got.NodeID != w.nodeID || got.AttachedVolumes != w.attached || got.VolumeID != "vol-1" {
+			t.Errorf("event %d = %+v, want %s of node %s with %d attached", i, got, w.eventType, w.nodeID, w.attached)
+		}
+		if got.Seq != uint64(i+1) {
+			t.Errorf("event %d has Seq %d, want %d", i, got.Seq, i+1)
+		}
+	}
+	if received[1].MigrationTimeout != time.Minute || received[1].MigrationStartedAt.IsZero() {
+		t.Errorf("secondary-add event lacks the migration: %+v", received[1])
+	}
+	assertNoEvent(t, events)
+
+	// The completed migration left no timer behind
+	am.migrationWatcher.mu.Lock()
+	timers := len(am.migrationWatcher.timers)
+	am.migrationWatcher.mu.Unlock()
+	if timers != 0 {
+		t.Errorf("%d migration timers left after the migration completed", timers)
+	}
+}
+
+func TestSubscribe_MigrationAbort(t *testing.T) {
+	am := NewAttachmentManager(nil)
+	ctx := context.Background()
+	if err := am.TrackAttachmentWithMode(ctx, "vol-1", "node-1", "RWX"); err != nil {
+		t.Fatalf("TrackAttachmentWithMode failed: %v", err)
+	}
+	events := am.Subscribe()
+	defer am.Unsubscribe(events)
+
+	// The migration watcher aborts the migration on its timeout
+	if err := am.AddSecondaryAttachment(ctx, "vol-1", "node-2", 20*time.Millisecond); err != nil {
+		t.Fatalf("AddSecondaryAttachment failed: %v", err)
+	}
+	received := receiveEvents(t, events, 2)
+	if received[0].Type != AttachmentEventSecondaryAdd {
+		t.Errorf("first event = %+v, want secondary-add", received[0])
+	}
+	if received[1].Type != AttachmentEventMigrationAbort || received[1].NodeID != "node-1" {
+		t.Errorf("second event = %+v, want migration-abort back to node-1", received[1])
+	}
+	if nodes := am.GetNodeCount("vol-1"); nodes != 1 {
+		t.Errorf("volume attached to %d nodes after the abort, want 1", nodes)
+	}
+
+	// Clearing migration state of a volume that is not migrating reports nothing
+	am.ClearMigrationState("vol-1")
+	assertNoEvent(t, events)
+}
+
+func TestSubscribe_SlowConsumer(t *testing.T) {
+	am := NewAttachmentManager(nil)
+	ctx := context.Background()
+	slow := am.Subscribe()
+	fast := am.Subscribe()
+	defer am.Unsubscribe(slow)
+
+	// The fast subscriber drains while the slow one does not read at all
+	received := make(chan int)
+	go func() {
+		count := 0
+		for range fast {
+			count++
+		}
+		received <- count
+	}()
+
+	for i := 0; i < eventBufferSize+10; i++ {
+		if err := am.TrackAttachment(ctx, "vol-1", "node-1"); err != nil {
+			t.Fatalf("TrackAttachment failed: %v", err)
+		}
+		if err := am.UntrackAttachment(ctx, "vol-1"); err != nil {
+			t.Fatalf("UntrackAttachment failed: %v", err)
+		}
+	}
+	am.Unsubscribe(fast)
+
+	// Every event beyond its buffer was dropped for the slow subscriber; any other drop
+	// was the fast one's
+	total := 2 * (eventBufferSize + 10)
+	slowDropped := total - eventBufferSize
+	count := <-received
+	if fastDropped := int(am.DroppedEvents()) - slowDropped; fastDropped < 0 || count+fastDropped != total {
+		t.Errorf("fast subscriber received %d events with %d dropped, want %d in all", count, fastDropped, total)
+	}
+	if len(slow) != eventBufferSize {
+		t.Errorf("slow subscriber holds %d events, want a full buffer of %d", len(slow), eventBufferSize)
+	}
+
+	// An unsubscribed channel is closed and receives nothing more
+	if _, open := <-fast; open {
+		t.Error("channel still open after Unsubscribe")
+	}
+	am.Unsubscribe(fast)
+}
+
+func TestSubscribe_Rebuild(t *testing.T) {
+	am := NewAttachmentManager(nil)
+	ctx := context.Background()
+	if err := am.TrackAttachment(ctx, "vol-1", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	// The rebuild replaces the attachment of vol-1 with the one VolumeAttachment
+	am.k8sClient = fake.NewSimpleClientset(createTestPV("pv-2", "node-b"),
+		createTestVolumeAttachment("va-2", "rds.csi.srvlab.io", "pv-2", "node-b", true))
+	events := am.Subscribe()
+	defer am.Unsubscribe(events)
+
+	if err := am.RebuildStateFromVolumeAttachments(ctx); err != nil {
+		t.Fatalf("RebuildStateFromVolumeAttachments failed: %v", err)
+	}
+	event := receiveEvents(t, events, 1)[0]
+	if event.Type != AttachmentEventRebuild || event.AttachedVolumes != 1 || event.Seq != 2 {
+		t.Errorf("event = %+v, want rebuild with 1 attached volume as change 2", event)
+	}
+}
@@ -4,7 +4,7 @@ import "sync"
 
 // VolumeLockManager provides per-volume mutex management for serializing
 // operations on individual volumes while allowing concurrent operations
-// on different volumes.
+// on different volumes. The zero value is ready to use.
 type VolumeLockManager struct {
 	// mu protects the locks map itself
 	mu sync.Mutex
@@ -26,6 +26,9 @@ func NewVolumeLockManager() *VolumeLockManager {
 func (vlm *VolumeLockManager) Lock(volumeID string) {
 	// Acquire manager lock to get/create the per-volume lock
 	vlm.mu.Lock()
+	if vlm.locks == nil {
+		vlm.locks = make(map[string]*sync.Mutex)
+	}
 	lock, exists := vlm.locks[volumeID]
 	if !exists {
 		lock = &sync.Mutex{}
@@ -9,10 +9,13 @@ import (
 	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 // AttachmentManager tracks which volumes are attached to which nodes
@@ -35,16 +38,41 @@ type AttachmentManager struct {
 
 	// metrics for recording migration operations (optional, can be nil)
 	metrics *observability.Metrics
+
+	// eventPoster for posting migration lifecycle events (optional, can be nil)
+	eventPoster EventPoster
+
+	// migrationWatcher fires AbortMigration when a migration exceeds its timeout
+	migrationWatcher *migrationWatcher
+
+	// events delivers attachment changes to subscribers (see Subscribe)
+	events eventHub
+
+	// eventSeq numbers the attachment changes. Protected by mu.
+	eventSeq uint64
+
+	// clock for detach timestamps and grace period checks (injectable for tests)
+	clock clock.PassiveClock
+
+	// persistQueue defers PV annotation writes while the API is unavailable
+	persistQueue *persistQueue
+
+	// metadataLimits bounds the size of PV annotations after an attachment write
+	metadataLimits utils.MetadataLimits
 }
 
 // NewAttachmentManager creates a new AttachmentManager
 func NewAttachmentManager(k8sClient kubernetes.Interface) *AttachmentManager {
-	return &AttachmentManager{
+	am := &AttachmentManager{
 		attachments:      make(map[string]*AttachmentState),
 		detachTimestamps: make(map[string]time.Time),
 		volumeLocks:      NewVolumeLockManager(),
 		k8sClient:        k8sClient,
+		clock:            clock.RealClock{},
+		persistQueue:     newPersistQueue(defaultPersistQueueConfig()),
 	}
+	am.migrationWatcher = &migrationWatcher{am: am, timers: make(map[string]*time.Timer)}
+	return am
 }
 
 // TrackAttachment records that a volume is attached to a node.
@@ -85,24 +113,28 @@ func (am *AttachmentManager) TrackAttachmentWithMode(ctx context.Context, volume
 		Nodes: []NodeAttachment{
 			{NodeID: nodeID, AttachedAt: now},
 		},
-		AttachedAt: now,
-		AccessMode: accessMode,
+		AttachedAt:   now,
+		AccessMode:   accessMode,
+		ActiveWriter: nodeID,
 	}
 
 	am.mu.Lock()
 	am.attachments[volumeID] = state
+	event := am.newEventLocked(AttachmentEventAttach, volumeID, nodeID)
 	am.mu.Unlock()
+	am.publish(event)
 
 	klog.V(2).Infof("Tracked attachment: volume=%s, node=%s, accessMode=%s (primary)", volumeID, nodeID, accessMode)
 
 	// Persist to PV annotations for debugging/observability (informational only)
-	// Note: If persistence fails, we rollback in-memory state because the
-	// annotation write is part of the operation contract, even though
-	// annotations are not authoritative.
-	if err := am.persistAttachment(ctx, volumeID, nodeID); err != nil {
+	// Note: API unavailability does not surface here - the write is queued and
+	// retried in the background. Any other error rolls back in-memory state.
+	if err := am.persistAttachment(ctx, volumeID, state.Nodes[0], nodeID); err != nil {
 		am.mu.Lock()
 		delete(am.attachments, volumeID)
+		event := am.newEventLocked(AttachmentEventDetach, volumeID, nodeID)
 		am.mu.Unlock()
+		am.publish(event)
 		return fmt.Errorf("failed to persist attachment: %w", err)
 	}
 
@@ -110,12 +142,17 @@ func (am *AttachmentManager) TrackAttachmentWithMode(ctx context.Context, volume
 }
 
 // AddSecondaryAttachment adds a second node attachment for RWX volumes during migration.
-// Records migration start time for timeout tracking.
+// Records migration start time for timeout tracking. The secondary does not become the
+// active writer; see SetActiveWriter and RemoveNodeAttachment.
 // Returns error if volume not attached, not RWX, or already has 2 nodes.
 func (am *AttachmentManager) AddSecondaryAttachment(ctx context.Context, volumeID, nodeID string, migrationTimeout time.Duration) error {
 	am.volumeLocks.Lock(volumeID)
 	defer am.volumeLocks.Unlock(volumeID)
 
+	// Published once am.mu is released
+	var event AttachmentEvent
+	defer func() { am.publish(event) }()
+
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
@@ -145,17 +182,61 @@ func (am *AttachmentManager) AddSecondaryAttachment(ctx context.Context, volumeI
 	now := time.Now()
 	existing.MigrationStartedAt = &now
 	existing.MigrationTimeout = migrationTimeout
+	event = am.newEventLocked(AttachmentEventSecondaryAdd, volumeID, nodeID)
+	event.MigrationStartedAt = now
+	event.MigrationTimeout = migrationTimeout
 
 	// Record metric: migration started
 	if am.metrics != nil {
 		am.metrics.RecordMigrationStarted()
 	}
 
-	klog.V(2).Infof("Tracked secondary attachment: volume=%s, node=%s, timeout=%v (migration target)",
-		volumeID, nodeID, migrationTimeout)
+	klog.V(2).Infof("Tracked secondary attachment: volume=%s, node=%s, timeout=%v (migration target, active writer stays %s)",
+		volumeID, nodeID, migrationTimeout, existing.ActiveWriter)
 	return nil
 }
 
+// SetActiveWriter hands write access to nodeID, which must be attached to the volume.
+// Migration callers use it to move the writer to the target before the source detaches.
+func (am *AttachmentManager) SetActiveWriter(ctx context.Context, volumeID, nodeID string) error {
+	am.volumeLocks.Lock(volumeID)
+	defer am.volumeLocks.Unlock(volumeID)
+
+	am.mu.Lock()
+	existing, exists := am.attachments[volumeID]
+	if !exists {
+		am.mu.Unlock()
+		return fmt.Errorf("volume %s not attached", volumeID)
+	}
+	if !existing.IsAttachedToNode(nodeID) {
+		am.mu.Unlock()
+		return fmt.Errorf("volume %s not attached to node %s", volumeID, nodeID)
+	}
+	if existing.ActiveWriter == nodeID {
+		am.mu.Unlock()
+		return nil
+	}
+	previous := existing.ActiveWriter
+	existing.ActiveWriter = nodeID
+	primary := existing.Nodes[0]
+	am.mu.Unlock()
+
+	klog.V(2).Infof("Active writer of volume %s moved from %s to %s", volumeID, previous, nodeID)
+	return am.persistAttachment(ctx, volumeID, primary, nodeID)
+}
+
+// GetActiveWriter returns the node that should have write access to a tracked volume,
+// or "" if the volume is not tracked.
+func (am *AttachmentManager) GetActiveWriter(volumeID string) string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	if state, exists := am.attachments[volumeID]; exists {
+		return state.ActiveWriter
+	}
+	return ""
+}
+
 // UntrackAttachment removes the attachment record for a volume.
 // This method is idempotent - if the volume is not tracked, it returns nil.
 func (am *AttachmentManager) UntrackAttachment(ctx context.Context, volumeID string) error {
@@ -165,7 +246,7 @@ func (am *AttachmentManager) UntrackAttachment(ctx context.Context, volumeID str
 
 	// Check if exists before deleting
 	am.mu.RLock()
-	_, exists := am.attachments[volumeID]
+	existing, exists := am.attachments[volumeID]
 	am.mu.RUnlock()
 
 	if !exists {
@@ -176,9 +257,11 @@ func (am *AttachmentManager) UntrackAttachment(ctx context.Context, volumeID str
 	// Delete under write lock and record detach timestamp
 	am.mu.Lock()
 	// Record detach timestamp for grace period tracking
-	am.detachTimestamps[volumeID] = time.Now()
+	am.detachTimestamps[volumeID] = am.clock.Now()
 	delete(am.attachments, volumeID)
+	event := am.newEventLocked(AttachmentEventDetach, volumeID, existing.NodeID)
 	am.mu.Unlock()
+	am.publish(event)
 
 	klog.V(2).Infof("Untracked attachment: volume=%s", volumeID)
 
@@ -230,7 +313,7 @@ func (am *AttachmentManager) IsWithinGracePeriod(volumeID string, gracePeriod ti
 		return false
 	}
 
-	return time.Since(detachTime) < gracePeriod
+	return am.clock.Since(detachTime) < gracePeriod
 }
 
 // GetDetachTimestamp returns the last detach timestamp for a volume.
@@ -251,6 +334,22 @@ func (am *AttachmentManager) ClearDetachTimestamp(volumeID string) {
 	delete(am.detachTimestamps, volumeID)
 }
 
+// PruneDetachTimestamps removes detach timestamps at least maxAge old and returns how
+// many were removed and how many remain. Callers pass a maxAge no shorter than the grace
+// period, so a timestamp still inside a grace window is never removed.
+func (am *AttachmentManager) PruneDetachTimestamps(maxAge time.Duration) (pruned, remaining int) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for volumeID, detachTime := range am.detachTimestamps {
+		if am.clock.Since(detachTime) >= maxAge {
+			delete(am.detachTimestamps, volumeID)
+			pruned++
+		}
+	}
+	return pruned, len(am.detachTimestamps)
+}
+
 // GetNodeCount returns the number of nodes a volume is attached to.
 func (am *AttachmentManager) GetNodeCount(volumeID string) int {
 	am.mu.RLock()
@@ -287,13 +386,15 @@ func (am *AttachmentManager) GetAccessMode(volumeID string) string {
 // ClearMigrationState clears migration tracking fields.
 // Called when source node detaches, completing migration.
 func (am *AttachmentManager) ClearMigrationState(volumeID string) {
+	var event AttachmentEvent
 	am.mu.Lock()
-	defer am.mu.Unlock()
-
-	if state, exists := am.attachments[volumeID]; exists {
+	if state, exists := am.attachments[volumeID]; exists && state.MigrationStartedAt != nil {
 		state.MigrationStartedAt = nil
 		state.MigrationTimeout = 0
+		event = am.newEventLocked(AttachmentEventMigrationComplete, volumeID, state.NodeID)
 	}
+	am.mu.Unlock()
+	am.publish(event)
 }
 
 // SetMetrics sets the Prometheus metrics for recording migration operations.
@@ -301,6 +402,23 @@ func (am *AttachmentManager) SetMetrics(m *observability.Metrics) {
 	am.metrics = m
 }
 
+// SetClock replaces the clock used for detach timestamps and grace period checks.
+// Intended for tests that need to advance time without sleeping.
+func (am *AttachmentManager) SetClock(c clock.PassiveClock) {
+	am.clock = c
+}
+
+// SetMetadataLimits sets the size limits for the annotations of a PV the attachment
+// annotations are written to. Zero values use the defaults.
+func (am *AttachmentManager) SetMetadataLimits(limits utils.MetadataLimits) {
+	am.metadataLimits = limits
+}
+
+// SetEventPoster sets the EventPoster used to post migration lifecycle events.
+func (am *AttachmentManager) SetEventPoster(ep EventPoster) {
+	am.eventPoster = ep
+}
+
 // RemoveNodeAttachment removes a specific node's attachment from a volume.
 // For RWX during migration, this removes one node while keeping the other.
 // Returns true if this was the last node (volume now fully detached).
@@ -308,11 +426,14 @@ func (am *AttachmentManager) RemoveNodeAttachment(ctx context.Context, volumeID,
 	am.volumeLocks.Lock(volumeID)
 	defer am.volumeLocks.Unlock(volumeID)
 
-	am.mu.Lock()
-	defer am.mu.Unlock()
+	// Published once am.mu is released
+	var event AttachmentEvent
+	defer func() { am.publish(event) }()
 
+	am.mu.Lock()
 	existing, exists := am.attachments[volumeID]
 	if !exists {
+		am.mu.Unlock()
 		klog.V(2).Infof("Volume %s not tracked, nothing to remove (idempotent)", volumeID)
 		return false, nil
 	}
@@ -336,14 +457,17 @@ func (am *AttachmentManager) RemoveNodeAttachment(ctx context.Context, volumeID,
 	}
 
 	if !found {
+		am.mu.Unlock()
 		klog.V(2).Infof("Volume %s not attached to node %s (idempotent)", volumeID, nodeID)
 		return false, nil
 	}
 
 	if len(newNodes) == 0 {
 		// Last node removed - fully detach
-		am.detachTimestamps[volumeID] = time.Now()
+		am.detachTimestamps[volumeID] = am.clock.Now()
 		delete(am.attachments, volumeID)
+		event = am.newEventLocked(AttachmentEventDetach, volumeID, nodeID)
+		am.mu.Unlock()
 		klog.V(2).Infof("Removed last node attachment for volume %s, volume now detached", volumeID)
 
 		// Clear PV annotations to keep them accurate for debugging
@@ -373,8 +497,129 @@ func (am *AttachmentManager) RemoveNodeAttachment(ctx context.Context, volumeID,
 	}
 
 	// Update with remaining nodes
+	primaryRemoved := existing.Nodes[0].NodeID == nodeID
 	existing.Nodes = newNodes
 	existing.NodeID = newNodes[0].NodeID // Update primary for backward compat
+	if wasMigrating {
+		event = am.newEventLocked(AttachmentEventMigrationComplete, volumeID, existing.NodeID)
+	} else {
+		event = am.newEventLocked(AttachmentEventDetach, volumeID, nodeID)
+	}
 	klog.V(2).Infof("Removed node %s from volume %s, %d node(s) remaining", nodeID, volumeID, len(newNodes))
+
+	// The writer detached (the migration source completing a migration): promote the
+	// node that remains
+	promoted := existing.ActiveWriter == nodeID || existing.ActiveWriter == ""
+	if promoted {
+		existing.ActiveWriter = newNodes[0].NodeID
+		klog.V(2).Infof("Promoted node %s to active writer of volume %s", existing.ActiveWriter, volumeID)
+	}
+	activeWriter := existing.ActiveWriter
+	am.mu.Unlock()
+
+	// The volume lock, still held, keeps the record from changing before it is persisted
+	if promoted || primaryRemoved {
+		if err := am.persistAttachment(ctx, volumeID, newNodes[0], activeWriter); err != nil {
+			klog.Warningf("Failed to persist attachment of volume %s: %v", volumeID, err)
+		}
+	}
 	return false, nil
 }
+
+// AbortMigration reverts a dual-attach migration back to its source node.
+// The secondary (migration target) node is removed, migration state is cleared,
+// the migration is recorded as timed out and a MigrationFailed event is posted.
+// Returns the node the volume remains attached to. If the volume is not
+// migrating, this is a no-op that returns the current primary node.
+func (am *AttachmentManager) AbortMigration(ctx context.Context, volumeID string) (string, error) {
+	return am.abortMigration(ctx, volumeID, nil)
+}
+
+// abortMigration implements AbortMigration. If startedAt is set, only the migration
+// started then is aborted; a volume that since finished it or started another one is
+// left alone.
+func (am *AttachmentManager) abortMigration(ctx context.Context, volumeID string, startedAt *time.Time) (string, error) {
+	am.volumeLocks.Lock(volumeID)
+	defer am.volumeLocks.Unlock(volumeID)
+
+	am.mu.Lock()
+	existing, exists := am.attachments[volumeID]
+	if !exists {
+		am.mu.Unlock()
+		return "", fmt.Errorf("volume %s not attached", volumeID)
+	}
+	if len(existing.Nodes) == 0 {
+		am.mu.Unlock()
+		return "", fmt.Errorf("volume %s has empty node list", volumeID)
+	}
+
+	sourceNode := existing.Nodes[0].NodeID
+	if existing.MigrationStartedAt == nil {
+		am.mu.Unlock()
+		klog.V(2).Infof("Volume %s not migrating, nothing to abort (idempotent)", volumeID)
+		return sourceNode, nil
+	}
+	if startedAt != nil && !existing.MigrationStartedAt.Equal(*startedAt) {
+		am.mu.Unlock()
+		klog.V(2).Infof("Volume %s started another migration, not aborting it", volumeID)
+		return sourceNode, nil
+	}
+
+	var targetNode string
+	if len(existing.Nodes) > 1 {
+		targetNode = existing.Nodes[1].NodeID
+	}
+	elapsed := time.Since(*existing.MigrationStartedAt)
+
+	// Revert to the source node only
+	existing.Nodes = existing.Nodes[:1]
+	existing.NodeID = sourceNode
+	existing.MigrationStartedAt = nil
+	existing.MigrationTimeout = 0
+	writerMoved := existing.ActiveWriter != sourceNode
+	existing.ActiveWriter = sourceNode
+	source := existing.Nodes[0]
+	event := am.newEventLocked(AttachmentEventMigrationAbort, volumeID, sourceNode)
+	am.mu.Unlock()
+	am.publish(event)
+
+	if writerMoved {
+		if err := am.persistAttachment(ctx, volumeID, source, sourceNode); err != nil {
+			klog.Warningf("Failed to persist active writer of volume %s: %v", volumeID, err)
+		}
+	}
+
+	klog.Warningf("Aborted migration for volume %s after %v: removed target node %s, reverted to source node %s",
+		volumeID, elapsed.Round(time.Second), targetNode, sourceNode)
+
+	if am.metrics != nil {
+		am.metrics.RecordMigrationResult("timeout", elapsed)
+	}
+
+	am.postMigrationFailedEvent(ctx, volumeID, sourceNode, targetNode, elapsed)
+
+	return sourceNode, nil
+}
+
+// postMigrationFailedEvent posts a MigrationFailed event to the volume's PVC.
+// Best effort - failures are logged but don't affect the abort.
+func (am *AttachmentManager) postMigrationFailedEvent(ctx context.Context, volumeID, sourceNode, targetNode string, elapsed time.Duration) {
+	if am.eventPoster == nil || am.k8sClient == nil {
+		return
+	}
+
+	pv, err := am.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if err != nil {
+		klog.V(4).Infof("Cannot get PV %s for migration failed event: %v", volumeID, err)
+		return
+	}
+	if pv.Spec.ClaimRef == nil {
+		klog.V(4).Infof("PV %s has no claimRef for migration failed event", volumeID)
+		return
+	}
+
+	if err := am.eventPoster.PostMigrationFailed(ctx, pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name,
+		volumeID, sourceNode, targetNode, "timeout", elapsed); err != nil {
+		klog.Warningf("Failed to post migration failed event for volume %s: %v", volumeID, err)
+	}
+}
@@ -9,7 +9,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 func TestAttachmentManager_TrackAttachment(t *testing.T) {
@@ -307,6 +311,54 @@ func TestAttachmentManager_PersistAttachment(t *testing.T) {
 	}
 }
 
+func TestAttachmentManager_PersistAttachmentMetadataLimits(t *testing.T) {
+	// Another controller's annotation takes up most of the limit
+	const otherSize = 1000
+	tests := []struct {
+		name         string
+		hard         int
+		wantNode     bool
+		wantAttachAt bool
+	}{
+		{name: "within limit", hard: 2000, wantNode: true, wantAttachAt: true},
+		{name: "attached-at dropped", hard: otherSize + 80, wantNode: true},
+		{name: "not written", hard: otherSize + 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volumeID := "pv-vol-1"
+			pv := createTestPV(volumeID, "")
+			pv.Annotations = map[string]string{"example.com/other": strings.Repeat("x", otherSize-len("example.com/other"))}
+			fakeClient := fake.NewSimpleClientset(pv)
+			am := NewAttachmentManager(fakeClient)
+			am.SetMetadataLimits(utils.MetadataLimits{Soft: 1, Hard: tt.hard})
+			ctx := context.Background()
+
+			if err := am.TrackAttachment(ctx, volumeID, "node-1"); err != nil {
+				t.Fatalf("TrackAttachment failed: %v", err)
+			}
+			if am.PendingPersistCount() != 0 {
+				t.Error("expected nothing queued for retry")
+			}
+
+			updatedPV, err := fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get PV: %v", err)
+			}
+			if got := updatedPV.Annotations[AnnotationAttachedNode] == "node-1"; got != tt.wantNode {
+				t.Errorf("attached-node written = %v, want %v", got, tt.wantNode)
+			}
+			if got := updatedPV.Annotations[AnnotationAttachedAt] != ""; got != tt.wantAttachAt {
+				t.Errorf("attached-at written = %v, want %v", got, tt.wantAttachAt)
+			}
+			if len(updatedPV.Annotations["example.com/other"]) != otherSize-len("example.com/other") {
+				t.Error("expected other annotations to be kept")
+			}
+		})
+	}
+}
+
 func TestAttachmentManager_ClearAttachment(t *testing.T) {
 	volumeID := "pv-vol-1"
 	nodeID := "node-1"
@@ -785,6 +837,121 @@ func TestAttachmentManager_RemoveNodeAttachment(t *testing.T) {
 	}
 }
 
+func TestActiveWriter_Migration(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+	volumeID := "pvc-test-active-writer"
+
+	if err := am.TrackAttachmentWithMode(ctx, volumeID, "node-1", "RWX"); err != nil {
+		t.Fatalf("TrackAttachmentWithMode failed: %v", err)
+	}
+	if got := am.GetActiveWriter(volumeID); got != "node-1" {
+		t.Errorf("expected node-1 to be the active writer, got %q", got)
+	}
+
+	// The migration target attaches as a non-writer
+	if err := am.AddSecondaryAttachment(ctx, volumeID, "node-2", 5*time.Minute); err != nil {
+		t.Fatalf("AddSecondaryAttachment failed: %v", err)
+	}
+	state, _ := am.GetAttachment(volumeID)
+	if state.IsActiveWriter("node-2") {
+		t.Error("secondary attachment must not be the active writer")
+	}
+	if !state.IsActiveWriter("node-1") {
+		t.Errorf("expected node-1 to stay the active writer, got %q", state.ActiveWriter)
+	}
+
+	// Detaching the source promotes the target
+	if _, err := am.RemoveNodeAttachment(ctx, volumeID, "node-1"); err != nil {
+		t.Fatalf("RemoveNodeAttachment failed: %v", err)
+	}
+	if got := am.GetActiveWriter(volumeID); got != "node-2" {
+		t.Errorf("expected node-2 to be promoted to active writer, got %q", got)
+	}
+}
+
+func TestSetActiveWriter(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+	volumeID := "pvc-test-set-writer"
+
+	if err := am.SetActiveWriter(ctx, volumeID, "node-1"); err == nil || !strings.Contains(err.Error(), "not attached") {
+		t.Errorf("expected error for untracked volume, got %v", err)
+	}
+
+	_ = am.TrackAttachmentWithMode(ctx, volumeID, "node-1", "RWX")
+	_ = am.AddSecondaryAttachment(ctx, volumeID, "node-2", 5*time.Minute)
+
+	if err := am.SetActiveWriter(ctx, volumeID, "node-3"); err == nil {
+		t.Error("expected error for node that is not attached")
+	}
+	if err := am.SetActiveWriter(ctx, volumeID, "node-2"); err != nil {
+		t.Fatalf("SetActiveWriter failed: %v", err)
+	}
+	if got := am.GetActiveWriter(volumeID); got != "node-2" {
+		t.Errorf("expected node-2 to be the active writer, got %q", got)
+	}
+
+	// Detaching the old writer keeps the writer that was handed over
+	if _, err := am.RemoveNodeAttachment(ctx, volumeID, "node-1"); err != nil {
+		t.Fatalf("RemoveNodeAttachment failed: %v", err)
+	}
+	if got := am.GetActiveWriter(volumeID); got != "node-2" {
+		t.Errorf("expected node-2 to stay the active writer, got %q", got)
+	}
+}
+
+func TestAbortMigration_RevertsActiveWriter(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+	volumeID := "pvc-test-abort-writer"
+
+	_ = am.TrackAttachmentWithMode(ctx, volumeID, "node-primary", "RWX")
+	_ = am.AddSecondaryAttachment(ctx, volumeID, "node-secondary", 5*time.Minute)
+	if err := am.SetActiveWriter(ctx, volumeID, "node-secondary"); err != nil {
+		t.Fatalf("SetActiveWriter failed: %v", err)
+	}
+
+	if _, err := am.AbortMigration(ctx, volumeID); err != nil {
+		t.Fatalf("AbortMigration failed: %v", err)
+	}
+	if got := am.GetActiveWriter(volumeID); got != "node-primary" {
+		t.Errorf("expected active writer to revert to node-primary, got %q", got)
+	}
+}
+
+func TestAttachmentManager_PersistActiveWriter(t *testing.T) {
+	volumeID := "pv-vol-writer"
+	fakeClient := fake.NewSimpleClientset(createTestPV(volumeID, ""))
+	am := NewAttachmentManager(fakeClient)
+	ctx := context.Background()
+
+	_ = am.TrackAttachmentWithMode(ctx, volumeID, "node-1", "RWX")
+	_ = am.AddSecondaryAttachment(ctx, volumeID, "node-2", 5*time.Minute)
+	if err := am.SetActiveWriter(ctx, volumeID, "node-2"); err != nil {
+		t.Fatalf("SetActiveWriter failed: %v", err)
+	}
+
+	pv, err := fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get PV: %v", err)
+	}
+	if pv.Annotations[AnnotationActiveWriter] != "node-2" {
+		t.Errorf("expected annotation %s=node-2, got %q", AnnotationActiveWriter, pv.Annotations[AnnotationActiveWriter])
+	}
+	if pv.Annotations[AnnotationAttachedNode] != "node-1" {
+		t.Errorf("expected annotation %s=node-1, got %q", AnnotationAttachedNode, pv.Annotations[AnnotationAttachedNode])
+	}
+
+	if err := am.UntrackAttachment(ctx, volumeID); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	pv, _ = fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if _, ok := pv.Annotations[AnnotationActiveWriter]; ok {
+		t.Error("expected active writer annotation to be removed on detach")
+	}
+}
+
 func TestAttachmentState_GetNodeIDs(t *testing.T) {
 	state := &AttachmentState{
 		VolumeID: "vol-1",
@@ -917,6 +1084,55 @@ func TestAddSecondaryAttachment_MigrationTracking(t *testing.T) {
 	}
 }
 
+// TestRemoveNodeAttachment_PersistsWithoutGlobalLock tests that other volumes are not
+// held up while the remaining attachment of one is written to its PV
+func TestRemoveNodeAttachment_PersistsWithoutGlobalLock(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(createTestPV("vol-1", ""))
+	am := NewAttachmentManager(client)
+	if err := am.TrackAttachmentWithMode(ctx, "vol-1", "node-1", "RWX"); err != nil {
+		t.Fatalf("TrackAttachmentWithMode failed: %v", err)
+	}
+	if err := am.AddSecondaryAttachment(ctx, "vol-1", "node-2", 5*time.Minute); err != nil {
+		t.Fatalf("AddSecondaryAttachment failed: %v", err)
+	}
+
+	persisting := make(chan struct{})
+	release := make(chan struct{})
+	client.PrependReactor("get", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		close(persisting)
+		<-release
+		return false, nil, nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		// Removing the primary promotes node-2 and persists it
+		_, err := am.RemoveNodeAttachment(ctx, "vol-1", "node-1")
+		done <- err
+	}()
+	<-persisting
+
+	looked := make(chan struct{})
+	go func() {
+		am.GetAttachment("vol-2")
+		close(looked)
+	}()
+	select {
+	case <-looked:
+	case <-time.After(time.Second):
+		t.Error("GetAttachment blocked while RemoveNodeAttachment persisted")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("RemoveNodeAttachment failed: %v", err)
+	}
+	if got := am.GetActiveWriter("vol-1"); got != "node-2" {
+		t.Errorf("active writer = %q, want node-2", got)
+	}
+}
+
 func TestRemoveNodeAttachment_ClearsMigrationState(t *testing.T) {
 	ctx := context.Background()
 	am := NewAttachmentManager(nil)
@@ -979,3 +1195,155 @@ func TestRemoveNodeAttachment_ClearsMigrationState(t *testing.T) {
 		t.Errorf("Remaining node should be %s, got %s", secondaryNode, state.Nodes[0].NodeID)
 	}
 }
+
+func TestAbortMigration_TimeoutTriggersAbort(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+
+	volumeID := "pvc-test-abort-timeout"
+	primaryNode := "node-primary"
+	secondaryNode := "node-secondary"
+
+	if err := am.TrackAttachmentWithMode(ctx, volumeID, primaryNode, "RWX"); err != nil {
+		t.Fatalf("Failed to track primary attachment: %v", err)
+	}
+
+	if err := am.AddSecondaryAttachment(ctx, volumeID, secondaryNode, 50*time.Millisecond); err != nil {
+		t.Fatalf("Failed to add secondary attachment: %v", err)
+	}
+
+	// Wait for the background watcher to abort the migration
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if am.GetNodeCount(volumeID) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	state, exists := am.GetAttachment(volumeID)
+	if !exists {
+		t.Fatal("Volume should still be tracked after abort")
+	}
+
+	if len(state.Nodes) != 1 {
+		t.Fatalf("Expected 1 node after abort, got %d", len(state.Nodes))
+	}
+
+	if state.Nodes[0].NodeID != primaryNode {
+		t.Errorf("Source node should remain attached, got %s", state.Nodes[0].NodeID)
+	}
+
+	if am.IsAttachedToNode(volumeID, secondaryNode) {
+		t.Error("Secondary node should be removed after abort")
+	}
+
+	if state.MigrationStartedAt != nil || state.MigrationTimeout != 0 {
+		t.Error("Migration state should be cleared after abort")
+	}
+}
+
+func TestAbortMigration_Manual(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+
+	volumeID := "pvc-test-abort-manual"
+
+	if err := am.TrackAttachmentWithMode(ctx, volumeID, "node-primary", "RWX"); err != nil {
+		t.Fatalf("Failed to track primary attachment: %v", err)
+	}
+	if err := am.AddSecondaryAttachment(ctx, volumeID, "node-secondary", 5*time.Minute); err != nil {
+		t.Fatalf("Failed to add secondary attachment: %v", err)
+	}
+
+	revertedTo, err := am.AbortMigration(ctx, volumeID)
+	if err != nil {
+		t.Fatalf("AbortMigration failed: %v", err)
+	}
+	if revertedTo != "node-primary" {
+		t.Errorf("Expected revert to node-primary, got %s", revertedTo)
+	}
+
+	state, _ := am.GetAttachment(volumeID)
+	if state.IsMigrating() {
+		t.Error("IsMigrating() should return false after abort")
+	}
+	if state.NodeID != "node-primary" {
+		t.Errorf("Primary NodeID should be node-primary, got %s", state.NodeID)
+	}
+
+	// Aborting again is a no-op
+	revertedTo, err = am.AbortMigration(ctx, volumeID)
+	if err != nil {
+		t.Fatalf("Second AbortMigration failed: %v", err)
+	}
+	if revertedTo != "node-primary" {
+		t.Errorf("Expected idempotent revert to node-primary, got %s", revertedTo)
+	}
+}
+
+func TestAbortMigration_NotTracked(t *testing.T) {
+	am := NewAttachmentManager(nil)
+
+	if _, err := am.AbortMigration(context.Background(), "pvc-unknown"); err == nil {
+		t.Error("Expected error aborting migration for untracked volume")
+	}
+}
+
+func TestAbortMigration_CompletedMigrationNotAborted(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+
+	volumeID := "pvc-test-abort-completed"
+
+	if err := am.TrackAttachmentWithMode(ctx, volumeID, "node-primary", "RWX"); err != nil {
+		t.Fatalf("Failed to track primary attachment: %v", err)
+	}
+	if err := am.AddSecondaryAttachment(ctx, volumeID, "node-secondary", 50*time.Millisecond); err != nil {
+		t.Fatalf("Failed to add secondary attachment: %v", err)
+	}
+
+	// Migration completes before the timeout fires
+	if _, err := am.RemoveNodeAttachment(ctx, volumeID, "node-primary"); err != nil {
+		t.Fatalf("Failed to remove primary attachment: %v", err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	if !am.IsAttachedToNode(volumeID, "node-secondary") {
+		t.Error("Migration target should remain attached after completed migration")
+	}
+}
+
+func TestAbortMigration_TimeoutOfEarlierMigration(t *testing.T) {
+	ctx := context.Background()
+	am := NewAttachmentManager(nil)
+
+	volumeID := "pvc-test-abort-restarted"
+
+	if err := am.TrackAttachmentWithMode(ctx, volumeID, "node-primary", "RWX"); err != nil {
+		t.Fatalf("Failed to track primary attachment: %v", err)
+	}
+	if err := am.AddSecondaryAttachment(ctx, volumeID, "node-secondary", time.Hour); err != nil {
+		t.Fatalf("Failed to add secondary attachment: %v", err)
+	}
+
+	// The timeout of an earlier migration, firing after this one started, aborts nothing
+	earlier := time.Now().Add(-time.Hour)
+	am.handleMigrationTimeout(volumeID, earlier)
+	if _, err := am.abortMigration(ctx, volumeID, &earlier); err != nil {
+		t.Fatalf("abortMigration failed: %v", err)
+	}
+	if !am.IsAttachedToNode(volumeID, "node-secondary") {
+		t.Error("Current migration aborted by the timeout of an earlier one")
+	}
+
+	state, _ := am.GetAttachment(volumeID)
+	startedAt := *state.MigrationStartedAt
+	if _, err := am.abortMigration(ctx, volumeID, &startedAt); err != nil {
+		t.Fatalf("abortMigration failed: %v", err)
+	}
+	if am.IsAttachedToNode(volumeID, "node-secondary") {
+		t.Error("Migration not aborted by its own timeout")
+	}
+}
@@ -11,7 +11,7 @@ import (
 )
 
 // NodeWatcher integrates with Kubernetes node informers to trigger attachment
-// reconciliation when nodes become NotReady or are deleted.
+// reconciliation when nodes become NotReady, are marked out-of-service, or are deleted.
 type NodeWatcher struct {
 	reconciler *AttachmentReconciler
 	metrics    *observability.Metrics // Optional, may be nil
@@ -57,6 +57,17 @@ func (nw *NodeWatcher) GetEventHandlers() cache.ResourceEventHandlerFuncs {
 				if nw.metrics != nil {
 					nw.metrics.RecordReconcileAction("node_watcher_trigger")
 				}
+				return
+			}
+
+			// Check if node was marked out-of-service (non-graceful node shutdown)
+			if outOfServiceTaint(oldNode) == nil && outOfServiceTaint(newNode) != nil {
+				klog.Infof("Node %s marked out-of-service, triggering attachment reconciliation", newNode.Name)
+				nw.reconciler.TriggerReconcile()
+
+				if nw.metrics != nil {
+					nw.metrics.RecordReconcileAction("node_watcher_trigger")
+				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
@@ -97,3 +108,15 @@ func isNodeReady(node *corev1.Node) bool {
 	}
 	return false
 }
+
+// outOfServiceTaint returns the node.kubernetes.io/out-of-service taint if present.
+// Operators apply this taint after confirming a node is shut down, signalling that
+// volumes attached to it can safely be moved elsewhere.
+func outOfServiceTaint(node *corev1.Node) *corev1.Taint {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].Key == corev1.TaintNodeOutOfService {
+			return &node.Spec.Taints[i]
+		}
+	}
+	return nil
+}
@@ -374,3 +374,46 @@ func TestIsNodeReady(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeWatcher_UpdateFunc_OutOfServiceTaintAdded(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	nodeLister, pvLister := createTestListers(k8sClient)
+
+	reconciler, err := NewAttachmentReconciler(ReconcilerConfig{
+		Manager:    NewAttachmentManager(nil),
+		K8sClient:  k8sClient,
+		NodeLister: nodeLister,
+		PVLister:   pvLister,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create reconciler: %v", err)
+	}
+
+	// Node stays NotReady throughout so only the taint change can trigger
+	oldNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse},
+			},
+		},
+	}
+	newNode := oldNode.DeepCopy()
+	newNode.Spec.Taints = []corev1.Taint{
+		{Key: corev1.TaintNodeOutOfService, Value: "nodeshutdown", Effect: corev1.TaintEffectNoExecute},
+	}
+
+	nw := NewNodeWatcher(reconciler, nil)
+	nw.GetEventHandlers().UpdateFunc(oldNode, newNode)
+
+	if len(reconciler.triggerCh) != 1 {
+		t.Error("Expected out-of-service taint to trigger reconciliation")
+	}
+
+	// Taint already present - no new trigger
+	<-reconciler.triggerCh
+	nw.GetEventHandlers().UpdateFunc(newNode, newNode.DeepCopy())
+	if len(reconciler.triggerCh) != 0 {
+		t.Error("Expected no trigger when taint was already present")
+	}
+}
@@ -1,27 +1,38 @@
 // persist.go handles PV annotation persistence for attachment state.
 //
-// IMPORTANT: These annotations are INFORMATIONAL ONLY for debugging/observability.
-// They are written during ControllerPublishVolume but NEVER read during state rebuild.
-// VolumeAttachment objects are the authoritative source of truth for attachment state.
+// IMPORTANT: VolumeAttachment objects are the authoritative source of truth for which
+// nodes a volume is attached to. The annotations are written during
+// ControllerPublishVolume and ControllerUnpublishVolume; state rebuild reads only one of
+// them, and only as far as VolumeAttachments confirm it:
+// - attached-node and attached-at are informational and never read during rebuild
+// - active-writer is read during rebuild to restore which attached node may write
 //
-// Why write-only annotations?
-// - Backward compatibility: kubectl describe pv shows attachment info
-// - Debugging: Operators can see which node a volume is attached to
-// - Observability: External tools may read annotations for dashboards
+// Rebuild restores active-writer only when it names a node a VolumeAttachment attaches
+// the volume to; otherwise the earliest attached node becomes the writer.
 //
-// Why NOT read during rebuild?
+// Why not rebuild attachments from annotations?
 // - Annotations can become stale (clearing may fail, manual kubectl edits)
 // - VolumeAttachment objects are managed by external-attacher (authoritative)
 // - Reading annotations would contradict VolumeAttachment state
+// Instead, rebuild schedules the cleanup of annotations that contradict it.
+//
+// The annotations are also kept for:
+// - Backward compatibility: kubectl describe pv shows attachment info
+// - Debugging: Operators can see which node a volume is attached to
+// - Observability: External tools may read annotations for dashboards
 package attachment
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 const (
@@ -32,19 +43,48 @@ const (
 	// AnnotationAttachedAt stores the attachment timestamp for debugging.
 	// Informational only - never read during state rebuild.
 	AnnotationAttachedAt = "rds.csi.srvlab.io/attached-at"
+
+	// AnnotationActiveWriter stores the node that should have write access, for
+	// nodes deciding whether to mount read-only. State rebuild restores it when it
+	// names a node the volume is attached to, and otherwise makes the earliest
+	// attached node the writer.
+	AnnotationActiveWriter = "rds.csi.srvlab.io/active-writer"
 )
 
-// persistAttachment writes attachment metadata to PV annotations for debugging.
-// These annotations are INFORMATIONAL ONLY - they are never read during state rebuild.
+// persistAttachment writes attachment metadata to PV annotations. Rebuild reads back only
+// activeWriter, and only if VolumeAttachments still attach the volume to that node;
 // VolumeAttachment objects are the authoritative source of truth.
-// Uses retry.RetryOnConflict to handle concurrent updates safely.
+// The write is queued and retried in the background if the API is unavailable
+// (see persist_queue.go), so API errors do not fail the attach.
+// Returns nil if k8sClient is nil (allows operation without k8s in tests).
+func (am *AttachmentManager) persistAttachment(ctx context.Context, volumeID string, node NodeAttachment, activeWriter string) error {
+	if am.k8sClient == nil {
+		klog.V(2).Infof("Skipping persistence (no k8s client): volume=%s, node=%s", volumeID, node.NodeID)
+		return nil
+	}
+
+	return am.persist(ctx, persistOp{volumeID: volumeID, nodeID: node.NodeID, attachedAt: node.AttachedAt, activeWriter: activeWriter})
+}
+
+// clearAttachment removes attachment annotations from a PV.
+// This is called when a volume is fully detached to keep annotations accurate.
+// Note: Even if clearing fails, behavior is correct because rebuild ignores an
+// active-writer annotation of a volume no VolumeAttachment attaches, and never reads
+// the others - VolumeAttachment absence is authoritative.
+// Like persistAttachment, the write is queued if the API is unavailable.
 // Returns nil if k8sClient is nil (allows operation without k8s in tests).
-func (am *AttachmentManager) persistAttachment(ctx context.Context, volumeID, nodeID string) error {
+func (am *AttachmentManager) clearAttachment(ctx context.Context, volumeID string) error {
 	if am.k8sClient == nil {
-		klog.V(2).Infof("Skipping persistence (no k8s client): volume=%s, node=%s", volumeID, nodeID)
+		klog.V(2).Infof("Skipping persistence clear (no k8s client): volume=%s", volumeID)
 		return nil
 	}
 
+	return am.persist(ctx, persistOp{volumeID: volumeID})
+}
+
+// writeAttachmentAnnotations sets the attachment annotations on the PV.
+// Uses retry.RetryOnConflict to handle concurrent updates safely.
+func (am *AttachmentManager) writeAttachmentAnnotations(ctx context.Context, volumeID, nodeID string, attachedAt time.Time, activeWriter string) error {
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Get the current PV
 		pv, err := am.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
@@ -59,13 +99,26 @@ func (am *AttachmentManager) persistAttachment(ctx context.Context, volumeID, no
 
 		// Update annotations
 		pv.Annotations[AnnotationAttachedNode] = nodeID
-		pv.Annotations[AnnotationAttachedAt] = metav1.Now().Format(metav1.RFC3339Micro)
+		pv.Annotations[AnnotationAttachedAt] = metav1.NewTime(attachedAt).Format(metav1.RFC3339Micro)
+		if activeWriter != "" {
+			pv.Annotations[AnnotationActiveWriter] = activeWriter
+		} else {
+			delete(pv.Annotations, AnnotationActiveWriter)
+		}
+		if err := am.fitAnnotations(volumeID, pv.Annotations); err != nil {
+			return err
+		}
 
 		// Update the PV
 		_, err = am.k8sClient.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
 		return err
 	})
 
+	if errors.Is(err, utils.ErrMetadataTooLarge) {
+		// Retrying cannot help; the in-memory state stays authoritative
+		klog.Errorf("Not persisting attachment of volume %s to node %s: PV annotations %v", volumeID, nodeID, err)
+		return nil
+	}
 	if err != nil {
 		// Handle "not found" gracefully - PV may be created later
 		if err.Error() == "not found" || isNotFoundError(err) {
@@ -79,18 +132,28 @@ func (am *AttachmentManager) persistAttachment(ctx context.Context, volumeID, no
 	return nil
 }
 
-// clearAttachment removes attachment annotations from a PV.
-// This is called when a volume is fully detached to keep annotations accurate.
-// Note: Even if clearing fails, behavior is correct because annotations are
-// never read during rebuild - VolumeAttachment absence is authoritative.
-// Uses retry.RetryOnConflict to handle concurrent updates safely.
-// Returns nil if k8sClient is nil (allows operation without k8s in tests).
-func (am *AttachmentManager) clearAttachment(ctx context.Context, volumeID string) error {
-	if am.k8sClient == nil {
-		klog.V(2).Infof("Skipping persistence clear (no k8s client): volume=%s", volumeID)
-		return nil
+// fitAnnotations enforces the metadata limits on the annotations of a PV about to be
+// written. The attached-at timestamp is dropped first if they are above the hard limit;
+// other annotations belong to the user or other controllers and are never removed.
+func (am *AttachmentManager) fitAnnotations(volumeID string, annotations map[string]string) error {
+	if am.metrics != nil {
+		am.metrics.RecordMetadataSize("pv_annotations", utils.MetadataSize(annotations))
 	}
+	trimmed, size, err := utils.FitMetadata(annotations, am.metadataLimits, []string{AnnotationAttachedAt})
+	if err != nil {
+		return err
+	}
+	if len(trimmed) > 0 {
+		klog.Warningf("Dropped annotations %v of PV %s to fit the %d byte annotation limit", trimmed, volumeID, am.metadataLimits.HardLimit())
+	} else if size > am.metadataLimits.SoftLimit() {
+		klog.V(1).Infof("Warning: annotations of PV %s are %d bytes, above the %d byte soft limit", volumeID, size, am.metadataLimits.SoftLimit())
+	}
+	return nil
+}
 
+// removeAttachmentAnnotations deletes the attachment annotations from the PV.
+// Uses retry.RetryOnConflict to handle concurrent updates safely.
+func (am *AttachmentManager) removeAttachmentAnnotations(ctx context.Context, volumeID string) error {
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Get the current PV
 		pv, err := am.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
@@ -102,6 +165,11 @@ func (am *AttachmentManager) clearAttachment(ctx context.Context, volumeID strin
 		if pv.Annotations != nil {
 			delete(pv.Annotations, AnnotationAttachedNode)
 			delete(pv.Annotations, AnnotationAttachedAt)
+			delete(pv.Annotations, AnnotationActiveWriter)
+			// A pending node resize cannot be confirmed once detached; the next
+			// attach sees the grown device anyway.
+			delete(pv.Annotations, AnnotationPendingNodeResize)
+			delete(pv.Annotations, AnnotationPendingNodeResizeSince)
 		}
 
 		// Update the PV
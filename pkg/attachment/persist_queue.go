@@ -0,0 +1,331 @@
+// persist_queue.go keeps PV annotation writes off the CSI hot path while the
+// Kubernetes API is unavailable.
+//
+// Annotation writes go through a circuit breaker. While it is closed, writes are
+// made inline (bounded by a short timeout). A failed write, or any write while the
+// breaker is open, is queued and retried in the background with exponential
+// backoff; the in-memory attachment state stays authoritative meanwhile.
+// Only the latest write per volume is kept, so a queued attach annotation is
+// superseded by a later detach. The queue is bounded, and a write is given up on
+// after a number of failed retries. If queued writes are lost this way or on restart,
+// RebuildState recovers from VolumeAttachments, which never depended on these
+// annotations, and schedules the cleanup of annotations it finds stale.
+// StopPersistQueue ends the background retries when the driver stops.
+package attachment
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultPersistCallTimeout bounds an inline annotation write
+	defaultPersistCallTimeout = 5 * time.Second
+
+	// defaultPersistBreakerFailures is the consecutive failures before writes are queued without trying
+	defaultPersistBreakerFailures = 3
+
+	// defaultPersistBreakerTimeout is how long the breaker stays open before probing the API again
+	defaultPersistBreakerTimeout = 30 * time.Second
+
+	// defaultPersistRetryInitial and defaultPersistRetryMax bound the background retry backoff
+	defaultPersistRetryInitial = 1 * time.Second
+	defaultPersistRetryMax     = 1 * time.Minute
+
+	// defaultPersistMaxPending bounds the number of volumes with a queued write
+	defaultPersistMaxPending = 1024
+
+	// defaultPersistMaxAttempts is the background retries of a write before it is given up on
+	defaultPersistMaxAttempts = 20
+)
+
+// persistOp is a pending annotation write for one volume.
+// An empty nodeID means the attachment annotations should be cleared.
+type persistOp struct {
+	volumeID     string
+	nodeID       string
+	attachedAt   time.Time
+	activeWriter string
+	seq          uint64
+	attempts     int // failed background retries
+}
+
+// persistQueueConfig holds the timing knobs for a persistQueue
+type persistQueueConfig struct {
+	callTimeout     time.Duration
+	breakerFailures uint32
+	breakerTimeout  time.Duration
+	retryInitial    time.Duration
+	retryMax        time.Duration
+	maxPending      int
+	maxAttempts     int
+}
+
+// defaultPersistQueueConfig returns production timing for annotation persistence
+func defaultPersistQueueConfig() persistQueueConfig {
+	return persistQueueConfig{
+		callTimeout:     defaultPersistCallTimeout,
+		breakerFailures: defaultPersistBreakerFailures,
+		breakerTimeout:  defaultPersistBreakerTimeout,
+		retryInitial:    defaultPersistRetryInitial,
+		retryMax:        defaultPersistRetryMax,
+		maxPending:      defaultPersistMaxPending,
+		maxAttempts:     defaultPersistMaxAttempts,
+	}
+}
+
+// persistQueue holds annotation writes that could not be made inline
+type persistQueue struct {
+	config  persistQueueConfig
+	breaker *gobreaker.CircuitBreaker
+
+	// stopCtx is cancelled by StopPersistQueue; flushWG waits for the flush to exit
+	stopCtx context.Context
+	stop    context.CancelFunc
+	flushWG sync.WaitGroup
+
+	// mu protects pending, nextSeq, flushing and stopped
+	mu       sync.Mutex
+	pending  map[string]persistOp
+	nextSeq  uint64
+	flushing bool
+	stopped  bool
+}
+
+// newPersistQueue creates an empty queue with a closed breaker. Unset bounds take
+// their defaults.
+func newPersistQueue(config persistQueueConfig) *persistQueue {
+	if config.maxPending <= 0 {
+		config.maxPending = defaultPersistMaxPending
+	}
+	if config.maxAttempts <= 0 {
+		config.maxAttempts = defaultPersistMaxAttempts
+	}
+	settings := gobreaker.Settings{
+		Name:        "pv-annotations",
+		MaxRequests: 1,
+		Timeout:     config.breakerTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= config.breakerFailures
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			klog.Infof("Circuit breaker for %s: %s -> %s", name, from, to)
+		},
+	}
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &persistQueue{
+		config:  config,
+		breaker: gobreaker.NewCircuitBreaker(settings),
+		stopCtx: stopCtx,
+		stop:    stop,
+		pending: make(map[string]persistOp),
+	}
+}
+
+// StopPersistQueue stops the background retries of queued annotation writes and waits
+// for a retry in progress to finish. Writes still queued are dropped; RebuildState
+// recovers from VolumeAttachments on the next start. Safe to call more than once.
+func (am *AttachmentManager) StopPersistQueue() {
+	q := am.persistQueue
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	dropped := len(q.pending)
+	q.mu.Unlock()
+
+	q.stop()
+	q.flushWG.Wait()
+	if dropped > 0 {
+		klog.Warningf("Stopped PV annotation retries with %d updates still queued", dropped)
+	}
+}
+
+// PendingPersistCount returns the number of volumes with queued annotation writes
+func (am *AttachmentManager) PendingPersistCount() int {
+	q := am.persistQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// persist writes op inline when the API looks healthy, otherwise queues it.
+// Never returns an API error: the in-memory state is authoritative and the
+// write is retried in the background.
+func (am *AttachmentManager) persist(ctx context.Context, op persistOp) error {
+	q := am.persistQueue
+
+	q.mu.Lock()
+	q.nextSeq++
+	op.seq = q.nextSeq
+	_, queued := q.pending[op.volumeID]
+	q.mu.Unlock()
+
+	// An older write for this volume is still queued; writing inline could be
+	// overtaken by it, so supersede it instead
+	if queued || q.breaker.State() == gobreaker.StateOpen {
+		am.enqueuePersist(op)
+		return nil
+	}
+
+	if err := am.executePersist(ctx, op); err != nil {
+		klog.Warningf("Deferring PV annotation update for volume %s: %v", op.volumeID, err)
+		am.enqueuePersist(op)
+	}
+	return nil
+}
+
+// executePersist applies op through the circuit breaker with a bounded timeout
+func (am *AttachmentManager) executePersist(ctx context.Context, op persistOp) error {
+	q := am.persistQueue
+	callCtx, cancel := context.WithTimeout(ctx, q.config.callTimeout)
+	defer cancel()
+
+	_, err := q.breaker.Execute(func() (interface{}, error) {
+		if op.nodeID == "" {
+			return nil, am.removeAttachmentAnnotations(callCtx, op.volumeID)
+		}
+		return nil, am.writeAttachmentAnnotations(callCtx, op.volumeID, op.nodeID, op.attachedAt, op.activeWriter)
+	})
+	return err
+}
+
+// schedulePersist queues op for the background flush without trying it inline
+func (am *AttachmentManager) schedulePersist(op persistOp) {
+	q := am.persistQueue
+	q.mu.Lock()
+	q.nextSeq++
+	op.seq = q.nextSeq
+	q.mu.Unlock()
+
+	am.enqueuePersist(op)
+}
+
+// enqueuePersist queues op (replacing any older write for the volume) and
+// starts the background flush if it is not already running or stopped. A write
+// for a new volume is dropped while the queue is full.
+func (am *AttachmentManager) enqueuePersist(op persistOp) {
+	q := am.persistQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, queued := q.pending[op.volumeID]; !queued && len(q.pending) >= q.config.maxPending {
+		klog.Warningf("Dropping PV annotation update for volume %s: %d updates already queued", op.volumeID, len(q.pending))
+		if am.metrics != nil {
+			am.metrics.RecordAnnotationWriteDropped("queue_full")
+		}
+		return
+	}
+	q.pending[op.volumeID] = op
+	am.recordPersistQueueDepthLocked()
+	klog.V(4).Infof("Queued PV annotation update for volume %s (%d pending)", op.volumeID, len(q.pending))
+
+	if !q.flushing && !q.stopped {
+		q.flushing = true
+		q.flushWG.Add(1)
+		go am.flushPersistQueue()
+	}
+}
+
+// flushPersistQueue retries queued writes with exponential backoff until the
+// queue is empty or the queue is stopped. Backoff resets whenever a write succeeds.
+func (am *AttachmentManager) flushPersistQueue() {
+	q := am.persistQueue
+	defer q.flushWG.Done()
+	backoff := q.config.retryInitial
+
+	for {
+		select {
+		case <-q.stopCtx.Done():
+			q.mu.Lock()
+			q.flushing = false
+			q.mu.Unlock()
+			return
+		case <-time.After(backoff):
+		}
+
+		remaining, progressed := am.flushPendingOnce()
+		if remaining == 0 {
+			q.mu.Lock()
+			if len(q.pending) == 0 {
+				q.flushing = false
+				q.mu.Unlock()
+				klog.V(2).Info("Flushed all queued PV annotation updates")
+				return
+			}
+			q.mu.Unlock()
+		}
+
+		if progressed {
+			backoff = q.config.retryInitial
+		} else {
+			backoff *= 2
+			if backoff > q.config.retryMax {
+				backoff = q.config.retryMax
+			}
+		}
+	}
+}
+
+// flushPendingOnce attempts each queued write once, stopping early if the breaker
+// opens, and gives up on writes that failed maxAttempts times. Returns the number
+// still pending and whether any write succeeded.
+func (am *AttachmentManager) flushPendingOnce() (int, bool) {
+	q := am.persistQueue
+
+	q.mu.Lock()
+	ops := make([]persistOp, 0, len(q.pending))
+	for _, op := range q.pending {
+		ops = append(ops, op)
+	}
+	q.mu.Unlock()
+
+	progressed := false
+	for _, op := range ops {
+		if q.breaker.State() == gobreaker.StateOpen || q.stopCtx.Err() != nil {
+			break
+		}
+		err := am.executePersist(q.stopCtx, op)
+		if err == nil {
+			progressed = true
+		}
+
+		// Only update the entry if no newer write replaced it meanwhile
+		q.mu.Lock()
+		if current, ok := q.pending[op.volumeID]; ok && current.seq == op.seq {
+			switch {
+			case err == nil:
+				delete(q.pending, op.volumeID)
+			case current.attempts+1 >= q.config.maxAttempts:
+				delete(q.pending, op.volumeID)
+				klog.Errorf("Giving up on PV annotation update for volume %s after %d retries: %v", op.volumeID, current.attempts+1, err)
+				if am.metrics != nil {
+					am.metrics.RecordAnnotationWriteDropped("retries_exhausted")
+				}
+			default:
+				current.attempts++
+				q.pending[op.volumeID] = current
+				klog.V(4).Infof("Retry of PV annotation update for volume %s failed: %v", op.volumeID, err)
+			}
+			am.recordPersistQueueDepthLocked()
+		}
+		q.mu.Unlock()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), progressed
+}
+
+// recordPersistQueueDepthLocked exports the number of queued writes. Caller must hold q.mu.
+func (am *AttachmentManager) recordPersistQueueDepthLocked() {
+	if am.metrics != nil {
+		am.metrics.SetAnnotationQueueDepth(len(am.persistQueue.pending))
+	}
+}
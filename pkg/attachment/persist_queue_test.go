@@ -0,0 +1,271 @@
+package attachment
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// fastPersistQueueConfig trips after two failures and retries within milliseconds
+func fastPersistQueueConfig() persistQueueConfig {
+	return persistQueueConfig{
+		callTimeout:     time.Second,
+		breakerFailures: 2,
+		breakerTimeout:  50 * time.Millisecond,
+		retryInitial:    10 * time.Millisecond,
+		retryMax:        50 * time.Millisecond,
+	}
+}
+
+// newFlakyClient returns a fake clientset whose PV calls fail while apiDown is set.
+// calls counts PV API requests that reached the fake server.
+func newFlakyClient(apiDown *atomic.Bool, calls *atomic.Int32, volumeIDs ...string) *fake.Clientset {
+	var objects []runtime.Object
+	for _, id := range volumeIDs {
+		objects = append(objects, createTestPV(id, ""))
+	}
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("*", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls.Add(1)
+		if apiDown.Load() {
+			return true, nil, apierrors.NewServiceUnavailable("apiserver down")
+		}
+		return false, nil, nil
+	})
+	return client
+}
+
+func waitForEmptyPersistQueue(t *testing.T, am *AttachmentManager) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for am.PendingPersistCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("persist queue not flushed, %d pending", am.PendingPersistCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPersistQueue_APIUnavailable(t *testing.T) {
+	var apiDown atomic.Bool
+	var calls atomic.Int32
+	apiDown.Store(true)
+
+	volumes := []string{"pv-vol-1", "pv-vol-2", "pv-vol-3", "pv-vol-4"}
+	client := newFlakyClient(&apiDown, &calls, volumes...)
+	am := NewAttachmentManager(client)
+	am.persistQueue = newPersistQueue(fastPersistQueueConfig())
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, vol := range volumes {
+		if err := am.TrackAttachment(ctx, vol, "node-1"); err != nil {
+			t.Fatalf("TrackAttachment(%s) should succeed while API is down, got: %v", vol, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("TrackAttachment blocked for %v while API was down", elapsed)
+	}
+
+	// Once the breaker opens, attaches stop calling the API at all
+	if got := calls.Load(); got > 2 {
+		t.Errorf("expected at most 2 API calls before breaker opened, got %d", got)
+	}
+
+	// In-memory state is authoritative during the outage
+	for _, vol := range volumes {
+		if !am.IsAttachedToNode(vol, "node-1") {
+			t.Errorf("expected %s to be tracked in memory", vol)
+		}
+	}
+
+	// A detach during the outage supersedes the queued attach annotation
+	if err := am.UntrackAttachment(ctx, "pv-vol-4"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	if got := am.PendingPersistCount(); got != len(volumes) {
+		t.Errorf("expected %d pending writes, got %d", len(volumes), got)
+	}
+
+	apiDown.Store(false)
+	waitForEmptyPersistQueue(t, am)
+
+	for _, vol := range volumes[:3] {
+		pv, err := client.CoreV1().PersistentVolumes().Get(ctx, vol, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get PV %s: %v", vol, err)
+		}
+		if pv.Annotations[AnnotationAttachedNode] != "node-1" {
+			t.Errorf("expected %s annotation on %s after recovery, got %q", AnnotationAttachedNode, vol, pv.Annotations[AnnotationAttachedNode])
+		}
+	}
+
+	pv, err := client.CoreV1().PersistentVolumes().Get(ctx, "pv-vol-4", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get PV pv-vol-4: %v", err)
+	}
+	if _, ok := pv.Annotations[AnnotationAttachedNode]; ok {
+		t.Errorf("expected detached volume to have no %s annotation", AnnotationAttachedNode)
+	}
+}
+
+func TestPersistQueue_HealthyAPIWritesInline(t *testing.T) {
+	var apiDown atomic.Bool
+	var calls atomic.Int32
+
+	client := newFlakyClient(&apiDown, &calls, "pv-vol-1")
+	am := NewAttachmentManager(client)
+	am.persistQueue = newPersistQueue(fastPersistQueueConfig())
+	ctx := context.Background()
+
+	if err := am.TrackAttachment(ctx, "pv-vol-1", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	if got := am.PendingPersistCount(); got != 0 {
+		t.Errorf("expected no queued writes with a healthy API, got %d", got)
+	}
+
+	pv, _ := client.CoreV1().PersistentVolumes().Get(ctx, "pv-vol-1", metav1.GetOptions{})
+	if pv.Annotations[AnnotationAttachedNode] != "node-1" {
+		t.Errorf("expected inline annotation write, got %q", pv.Annotations[AnnotationAttachedNode])
+	}
+}
+
+// metricsBody scrapes metrics
+func metricsBody(metrics *observability.Metrics) string {
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestPersistQueue_Bounded(t *testing.T) {
+	var apiDown atomic.Bool
+	var calls atomic.Int32
+	apiDown.Store(true)
+
+	volumes := []string{"pv-vol-1", "pv-vol-2", "pv-vol-3"}
+	client := newFlakyClient(&apiDown, &calls, volumes...)
+	am := NewAttachmentManager(client)
+	config := fastPersistQueueConfig()
+	config.maxPending = 2
+	am.persistQueue = newPersistQueue(config)
+	metrics := observability.NewMetrics()
+	am.SetMetrics(metrics)
+	ctx := context.Background()
+
+	for _, vol := range volumes {
+		if err := am.TrackAttachment(ctx, vol, "node-1"); err != nil {
+			t.Fatalf("TrackAttachment(%s) failed: %v", vol, err)
+		}
+	}
+	if got := am.PendingPersistCount(); got != 2 {
+		t.Errorf("expected the queue to hold its bound of 2 writes, got %d", got)
+	}
+
+	// A newer write for a queued volume still replaces the queued one
+	if err := am.UntrackAttachment(ctx, "pv-vol-1"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	body := metricsBody(metrics)
+	for _, want := range []string{
+		`rds_csi_attachment_annotation_writes_dropped_total{reason="queue_full"} 1`,
+		"rds_csi_attachment_annotation_queue_depth 2",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+
+	apiDown.Store(false)
+	waitForEmptyPersistQueue(t, am)
+	if body := metricsBody(metrics); !strings.Contains(body, "rds_csi_attachment_annotation_queue_depth 0") {
+		t.Error("queue depth not 0 after the flush")
+	}
+	pv, err := client.CoreV1().PersistentVolumes().Get(ctx, "pv-vol-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get PV pv-vol-1: %v", err)
+	}
+	if _, ok := pv.Annotations[AnnotationAttachedNode]; ok {
+		t.Errorf("expected detached volume to have no %s annotation", AnnotationAttachedNode)
+	}
+}
+
+func TestPersistQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	var apiDown atomic.Bool
+	var calls atomic.Int32
+	apiDown.Store(true)
+
+	client := newFlakyClient(&apiDown, &calls, "pv-vol-1")
+	am := NewAttachmentManager(client)
+	config := fastPersistQueueConfig()
+	config.maxAttempts = 3
+	am.persistQueue = newPersistQueue(config)
+	metrics := observability.NewMetrics()
+	am.SetMetrics(metrics)
+	ctx := context.Background()
+
+	if err := am.TrackAttachment(ctx, "pv-vol-1", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	if got := am.PendingPersistCount(); got != 1 {
+		t.Fatalf("expected the failed write to be queued, got %d pending", got)
+	}
+
+	// The API never recovers, so the write is given up on after its retries
+	waitForEmptyPersistQueue(t, am)
+	if !strings.Contains(metricsBody(metrics), `rds_csi_attachment_annotation_writes_dropped_total{reason="retries_exhausted"} 1`) {
+		t.Error("expected one write dropped after exhausting its retries")
+	}
+	if !am.IsAttachedToNode("pv-vol-1", "node-1") {
+		t.Error("in-memory attachment lost with the annotation write")
+	}
+}
+
+func TestPersistQueue_Stop(t *testing.T) {
+	var apiDown atomic.Bool
+	var calls atomic.Int32
+	apiDown.Store(true)
+
+	client := newFlakyClient(&apiDown, &calls, "pv-vol-1")
+	am := NewAttachmentManager(client)
+	am.persistQueue = newPersistQueue(fastPersistQueueConfig())
+	ctx := context.Background()
+
+	if err := am.TrackAttachment(ctx, "pv-vol-1", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	if got := am.PendingPersistCount(); got != 1 {
+		t.Fatalf("expected the failed write to be queued, got %d pending", got)
+	}
+
+	// Stop waits for the flush goroutine, after which nothing is retried
+	am.StopPersistQueue()
+	am.StopPersistQueue()
+	before := calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	if after := calls.Load(); after != before {
+		t.Errorf("expected no retries after stop, got %d more API calls", after-before)
+	}
+
+	// Writes queued after stop do not start a new flush
+	if err := am.UntrackAttachment(ctx, "pv-vol-1"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	am.persistQueue.mu.Lock()
+	flushing := am.persistQueue.flushing
+	am.persistQueue.mu.Unlock()
+	if flushing {
+		t.Error("expected no flush to start after stop")
+	}
+}
@@ -40,6 +40,10 @@ func (am *AttachmentManager) RebuildStateFromAnnotations(ctx context.Context) er
 		return err
 	}
 
+	// Published once am.mu is released
+	var event AttachmentEvent
+	defer func() { am.publish(event) }()
+
 	// Acquire write lock to rebuild state
 	am.mu.Lock()
 	defer am.mu.Unlock()
@@ -90,6 +94,7 @@ func (am *AttachmentManager) RebuildStateFromAnnotations(ctx context.Context) er
 			Nodes: []NodeAttachment{
 				{NodeID: nodeID, AttachedAt: attachedAt},
 			},
+			ActiveWriter: nodeID,
 		}
 		am.attachments[volumeID] = state
 		rebuiltCount++
@@ -97,12 +102,14 @@ func (am *AttachmentManager) RebuildStateFromAnnotations(ctx context.Context) er
 		klog.V(2).Infof("Rebuilt attachment: volume=%s, node=%s", volumeID, nodeID)
 	}
 
+	event = am.newEventLocked(AttachmentEventRebuild, "", "")
 	klog.Infof("State rebuild complete: %d attachments recovered", rebuiltCount)
 	return nil
 }
 
 // lookupAccessMode retrieves the access mode from a PersistentVolume.
-// Returns "RWX" if any access mode contains ReadWriteMany, otherwise "RWO".
+// Prefers the mode CreateVolume granted, recorded in the "accessMode" volume attribute.
+// Otherwise returns "RWX" if any access mode contains ReadWriteMany, else "RWO".
 // Returns "RWO" if PV not found or on error (conservative default).
 func (am *AttachmentManager) lookupAccessMode(ctx context.Context, volumeID string) string {
 	if am.k8sClient == nil {
@@ -115,6 +122,12 @@ func (am *AttachmentManager) lookupAccessMode(ctx context.Context, volumeID stri
 		return "RWO"
 	}
 
+	if pv.Spec.CSI != nil {
+		if granted := pv.Spec.CSI.VolumeAttributes["accessMode"]; granted == "RWO" || granted == "RWX" {
+			return granted
+		}
+	}
+
 	// Check if any access mode is RWX
 	for _, mode := range pv.Spec.AccessModes {
 		if mode == corev1.ReadWriteMany {
@@ -146,6 +159,7 @@ func (am *AttachmentManager) rebuildVolumeState(ctx context.Context, volumeID st
 	// Create AttachmentState with nodes from VAs
 	nodes := make([]NodeAttachment, 0, len(vas))
 	var firstAttachedAt time.Time
+	var firstNode string
 
 	for i, va := range vas {
 		nodeID := va.Spec.NodeName
@@ -158,17 +172,21 @@ func (am *AttachmentManager) rebuildVolumeState(ctx context.Context, volumeID st
 
 		if i == 0 || attachedAt.Before(firstAttachedAt) {
 			firstAttachedAt = attachedAt
+			firstNode = nodeID
 		}
 
 		klog.V(2).Infof("Rebuilt node attachment: volume=%s, node=%s, attachedAt=%v", volumeID, nodeID, attachedAt)
 	}
 
+	// The earliest attached node (the migration source, if migrating) is the active
+	// writer unless the PV annotation restores another one
 	state := &AttachmentState{
-		VolumeID:   volumeID,
-		NodeID:     nodes[0].NodeID, // Primary node for backward compat
-		Nodes:      nodes,
-		AttachedAt: firstAttachedAt,
-		AccessMode: accessMode,
+		VolumeID:     volumeID,
+		NodeID:       nodes[0].NodeID, // Primary node for backward compat
+		Nodes:        nodes,
+		AttachedAt:   firstAttachedAt,
+		AccessMode:   accessMode,
+		ActiveWriter: firstNode,
 	}
 
 	// If multiple VAs, this is migration state
@@ -213,6 +231,18 @@ func (am *AttachmentManager) RebuildStateFromVolumeAttachments(ctx context.Conte
 	// Step 3: Group by volume ID
 	vaByVolume := GroupVolumeAttachmentsByVolume(attachedVAs)
 
+	// Step 4: List PVs to find stale attachment annotations. They are not trusted, so
+	// failing to list them only skips their cleanup.
+	pvList, err := am.k8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Not checking PV attachment annotations after rebuild: %v", err)
+		pvList = &corev1.PersistentVolumeList{}
+	}
+
+	// Published once am.mu is released
+	var event AttachmentEvent
+	defer func() { am.publish(event) }()
+
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
@@ -230,10 +260,73 @@ func (am *AttachmentManager) RebuildStateFromVolumeAttachments(ctx context.Conte
 		rebuiltCount++
 	}
 
+	am.restoreActiveWritersLocked(pvList.Items)
+	for _, op := range am.staleAnnotationOpsLocked(pvList.Items) {
+		am.schedulePersist(op)
+	}
+
+	event = am.newEventLocked(AttachmentEventRebuild, "", "")
 	klog.Infof("State rebuild complete: %d attachments recovered from VolumeAttachment objects", rebuiltCount)
 	return nil
 }
 
+// restoreActiveWritersLocked restores the active writer a PV annotation names, so a
+// writer handed over with SetActiveWriter survives a restart. The annotation is only
+// trusted when the volume is attached to the node it names. Caller must hold am.mu.
+func (am *AttachmentManager) restoreActiveWritersLocked(pvs []corev1.PersistentVolume) {
+	for _, pv := range pvs {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != driverName {
+			continue
+		}
+		writer := pv.Annotations[AnnotationActiveWriter]
+		state, attached := am.attachments[pv.Name]
+		if writer == "" || !attached || writer == state.ActiveWriter {
+			continue
+		}
+		if !state.IsAttachedToNode(writer) {
+			klog.V(2).Infof("PV %s names active writer %s, which the volume is not attached to, keeping %s", pv.Name, writer, state.ActiveWriter)
+			continue
+		}
+		klog.V(2).Infof("Restored active writer %s of volume %s", writer, pv.Name)
+		state.ActiveWriter = writer
+	}
+}
+
+// staleAnnotationOpsLocked cross-checks the attachment annotations of pvs against the
+// rebuilt state. A PV annotated with a node its volume is not attached to, such as one
+// left by a clear that failed during detach, gets its annotations cleared, or rewritten
+// if the volume is attached elsewhere. A PV naming another active writer than the
+// rebuilt one is rewritten too. Caller must hold am.mu.
+func (am *AttachmentManager) staleAnnotationOpsLocked(pvs []corev1.PersistentVolume) []persistOp {
+	var ops []persistOp
+	for _, pv := range pvs {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != driverName {
+			continue
+		}
+		nodeID := pv.Annotations[AnnotationAttachedNode]
+		if nodeID == "" {
+			continue
+		}
+
+		state, attached := am.attachments[pv.Name]
+		switch {
+		case !attached:
+			klog.Infof("PV %s is annotated as attached to node %s without a VolumeAttachment, scheduling annotation cleanup", pv.Name, nodeID)
+			ops = append(ops, persistOp{volumeID: pv.Name})
+		case !state.IsAttachedToNode(nodeID):
+			klog.Infof("PV %s is annotated as attached to node %s but is attached to %s, scheduling annotation update", pv.Name, nodeID, state.NodeID)
+			ops = append(ops, persistOp{volumeID: pv.Name, nodeID: state.Nodes[0].NodeID,
+				attachedAt: state.Nodes[0].AttachedAt, activeWriter: state.ActiveWriter})
+		case pv.Annotations[AnnotationActiveWriter] != state.ActiveWriter:
+			klog.Infof("PV %s is annotated with active writer %q but the writer is %s, scheduling annotation update",
+				pv.Name, pv.Annotations[AnnotationActiveWriter], state.ActiveWriter)
+			ops = append(ops, persistOp{volumeID: pv.Name, nodeID: state.Nodes[0].NodeID,
+				attachedAt: state.Nodes[0].AttachedAt, activeWriter: state.ActiveWriter})
+		}
+	}
+	return ops
+}
+
 // Initialize initializes the AttachmentManager by rebuilding state from VolumeAttachments.
 // This should be called once during driver startup.
 func (am *AttachmentManager) Initialize(ctx context.Context) error {
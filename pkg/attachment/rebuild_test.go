@@ -2,13 +2,17 @@ package attachment
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 // Helper functions for creating test objects
@@ -313,6 +317,40 @@ func TestRebuildStateFromVolumeAttachments_MigrationState(t *testing.T) {
 	}
 }
 
+func TestRebuildStateFromVolumeAttachments_RestoresActiveWriter(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotated  string
+		wantWriter string
+	}{
+		{name: "writer handed to the migration target", annotated: "node-2", wantWriter: "node-2"},
+		{name: "no annotation", wantWriter: "node-1"},
+		{name: "writer the volume is not attached to", annotated: "node-3", wantWriter: "node-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volumeID := "pvc-vol1"
+			now := time.Now()
+			va1 := createFakeVolumeAttachmentWithTime("va1", driverName, volumeID, "node-1", true, now.Add(-5*time.Minute))
+			va2 := createFakeVolumeAttachmentWithTime("va2", driverName, volumeID, "node-2", true, now)
+			pv := createFakePVWithAnnotations(volumeID, "node-1", now.Format(time.RFC3339))
+			pv.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+			if tt.annotated != "" {
+				pv.Annotations[AnnotationActiveWriter] = tt.annotated
+			}
+
+			am := NewAttachmentManager(fake.NewSimpleClientset(va1, va2, pv))
+			if err := am.RebuildStateFromVolumeAttachments(context.Background()); err != nil {
+				t.Fatalf("RebuildStateFromVolumeAttachments failed: %v", err)
+			}
+			if got := am.GetActiveWriter(volumeID); got != tt.wantWriter {
+				t.Errorf("active writer = %q, want %q", got, tt.wantWriter)
+			}
+		})
+	}
+}
+
 func TestRebuildStateFromVolumeAttachments_MigrationTimestamp(t *testing.T) {
 	volumeID := "pvc-vol1"
 
@@ -413,6 +451,28 @@ func TestRebuildStateFromVolumeAttachments_AccessModeFallback(t *testing.T) {
 	// (already verified by err check above)
 }
 
+func TestRebuildStateFromVolumeAttachments_GrantedAccessMode(t *testing.T) {
+	volumeID := "pvc-vol1"
+
+	// A PV edited to ReadWriteMany keeps the mode CreateVolume granted
+	pv := createFakePV(volumeID, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany})
+	pv.Spec.CSI.VolumeAttributes = map[string]string{"accessMode": "RWO"}
+	va := createFakeVolumeAttachment("va1", driverName, volumeID, "node-1", true)
+
+	am := NewAttachmentManager(fake.NewSimpleClientset(pv, va))
+	if err := am.RebuildStateFromVolumeAttachments(context.Background()); err != nil {
+		t.Fatalf("RebuildStateFromVolumeAttachments failed: %v", err)
+	}
+
+	state, exists := am.GetAttachment(volumeID)
+	if !exists {
+		t.Fatal("Expected attachment to exist")
+	}
+	if state.AccessMode != "RWO" {
+		t.Errorf("Expected the granted AccessMode RWO, got %s", state.AccessMode)
+	}
+}
+
 // Task 3: Test backward compatibility with stale annotations
 //
 // These tests verify that VolumeAttachment is the authoritative source of truth,
@@ -544,3 +604,121 @@ func TestRebuildStateFromAnnotations_StillWorks(t *testing.T) {
 
 	// Ensures we didn't break the fallback (even though it's deprecated)
 }
+
+// waitForAnnotatedNode waits until the attached-node annotation of PV volumeID is nodeID
+// ("" for none)
+func waitForAnnotatedNode(t *testing.T, client *fake.Clientset, volumeID, nodeID string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pv, err := client.CoreV1().PersistentVolumes().Get(context.Background(), volumeID, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get PV %s: %v", volumeID, err)
+		}
+		if pv.Annotations[AnnotationAttachedNode] == nodeID {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("PV %s annotated with node %q, want %q", volumeID, pv.Annotations[AnnotationAttachedNode], nodeID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRebuildStateFromVolumeAttachments_CleansUpStaleAnnotations(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		createTestPV("pvc-detached", ""),
+		createTestPV("pvc-moved", "node-1"),
+		createFakeVolumeAttachment("va-moved", driverName, "pvc-moved", "node-2", true),
+	)
+	var updatesDown atomic.Bool
+	client.PrependReactor("update", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if updatesDown.Load() {
+			return true, nil, apierrors.NewServiceUnavailable("apiserver down")
+		}
+		return false, nil, nil
+	})
+
+	// The clear of a detach fails while the API is unavailable. The controller restarts
+	// before its queued retry (whose first backoff outlasts the test) runs.
+	previous := NewAttachmentManager(client)
+	previousConfig := fastPersistQueueConfig()
+	previousConfig.retryInitial = time.Hour
+	previous.persistQueue = newPersistQueue(previousConfig)
+	if err := previous.TrackAttachment(ctx, "pvc-detached", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	updatesDown.Store(true)
+	if err := previous.UntrackAttachment(ctx, "pvc-detached"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	waitForAnnotatedNode(t, client, "pvc-detached", "node-1")
+
+	// Rebuilding while updates still fail leaves the annotations to the queue
+	am := NewAttachmentManager(client)
+	am.persistQueue = newPersistQueue(fastPersistQueueConfig())
+	if err := am.RebuildStateFromVolumeAttachments(ctx); err != nil {
+		t.Fatalf("RebuildStateFromVolumeAttachments failed: %v", err)
+	}
+	if _, exists := am.GetAttachment("pvc-detached"); exists {
+		t.Error("stale annotation trusted: pvc-detached rebuilt as attached")
+	}
+	if state, _ := am.GetAttachment("pvc-moved"); state == nil || state.NodeID != "node-2" {
+		t.Errorf("pvc-moved rebuilt as %+v, want attached to node-2", state)
+	}
+	if got := am.PendingPersistCount(); got != 2 {
+		t.Errorf("expected 2 annotation updates scheduled, got %d", got)
+	}
+
+	// The stale annotation does not block reattaching elsewhere
+	if err := am.TrackAttachment(ctx, "pvc-detached", "node-3"); err != nil {
+		t.Fatalf("reattaching pvc-detached failed: %v", err)
+	}
+	if err := am.UntrackAttachment(ctx, "pvc-detached"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+
+	// Once the API recovers, the annotations converge on the VolumeAttachments
+	updatesDown.Store(false)
+	waitForEmptyPersistQueue(t, am)
+	waitForAnnotatedNode(t, client, "pvc-detached", "")
+	waitForAnnotatedNode(t, client, "pvc-moved", "node-2")
+}
+
+func TestRebuildStateFromVolumeAttachments_UpdatesStaleActiveWriter(t *testing.T) {
+	ctx := context.Background()
+	pv := createTestPV("pvc-vol1", "node-1")
+	// Left by a writer handover whose volume has since moved back
+	pv.Annotations[AnnotationActiveWriter] = "node-2"
+	current := createTestPV("pvc-vol2", "node-1")
+	current.Annotations[AnnotationActiveWriter] = "node-1"
+	client := fake.NewSimpleClientset(pv, current,
+		createFakeVolumeAttachment("va1", driverName, "pvc-vol1", "node-1", true),
+		createFakeVolumeAttachment("va2", driverName, "pvc-vol2", "node-1", true))
+	var updated2 atomic.Int32
+	client.PrependReactor("update", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.UpdateAction).GetObject().(*corev1.PersistentVolume).Name == "pvc-vol2" {
+			updated2.Add(1)
+		}
+		return false, nil, nil
+	})
+
+	am := NewAttachmentManager(client)
+	am.persistQueue = newPersistQueue(fastPersistQueueConfig())
+	if err := am.RebuildStateFromVolumeAttachments(ctx); err != nil {
+		t.Fatalf("RebuildStateFromVolumeAttachments failed: %v", err)
+	}
+
+	waitForEmptyPersistQueue(t, am)
+	if updated2.Load() != 0 {
+		t.Error("PV with the current active writer was rewritten")
+	}
+	updated, err := client.CoreV1().PersistentVolumes().Get(ctx, "pvc-vol1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get PV: %v", err)
+	}
+	if got := updated.Annotations[AnnotationActiveWriter]; got != "node-1" {
+		t.Errorf("active writer annotation = %q, want node-1", got)
+	}
+}
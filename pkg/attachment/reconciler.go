@@ -12,6 +12,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 )
@@ -22,6 +23,9 @@ import (
 type EventPoster interface {
 	// PostStaleAttachmentCleared posts an event when a stale attachment is cleared
 	PostStaleAttachmentCleared(ctx context.Context, pvcNamespace, pvcName, volumeID, staleNodeID string) error
+
+	// PostMigrationFailed posts an event when a migration is aborted
+	PostMigrationFailed(ctx context.Context, pvcNamespace, pvcName, volumeID, sourceNode, targetNode, reason string, duration time.Duration) error
 }
 
 // AttachmentReconciler periodically checks for stale attachments and cleans them up.
@@ -35,6 +39,7 @@ type AttachmentReconciler struct {
 	gracePeriod time.Duration
 	metrics     *observability.Metrics
 	eventPoster EventPoster // Optional, may be nil
+	clock       clock.PassiveClock
 
 	// Control channels
 	stopCh    chan struct{}
@@ -52,7 +57,8 @@ type ReconcilerConfig struct {
 	Interval    time.Duration                        // Default: 5 minutes
 	GracePeriod time.Duration                        // Default: 30 seconds
 	Metrics     *observability.Metrics
-	EventPoster EventPoster // Optional, may be nil - for posting lifecycle events
+	EventPoster EventPoster        // Optional, may be nil - for posting lifecycle events
+	Clock       clock.PassiveClock // Optional, defaults to real clock - injectable for tests
 }
 
 // NewAttachmentReconciler creates a new AttachmentReconciler.
@@ -75,6 +81,9 @@ func NewAttachmentReconciler(config ReconcilerConfig) (*AttachmentReconciler, er
 	if config.GracePeriod <= 0 {
 		config.GracePeriod = 30 * time.Second
 	}
+	if config.Clock == nil {
+		config.Clock = clock.RealClock{}
+	}
 
 	return &AttachmentReconciler{
 		manager:     config.Manager,
@@ -85,6 +94,7 @@ func NewAttachmentReconciler(config ReconcilerConfig) (*AttachmentReconciler, er
 		gracePeriod: config.GracePeriod,
 		metrics:     config.Metrics,
 		eventPoster: config.EventPoster,
+		clock:       config.Clock,
 		triggerCh:   make(chan struct{}, 1), // Buffered size 1 for deduplication
 	}, nil
 }
@@ -217,29 +227,29 @@ func (r *AttachmentReconciler) reconcile(ctx context.Context) {
 			return
 		}
 
-		// Check if node still exists
-		nodeExists, err := r.nodeExists(ctx, state.NodeID)
+		// Check if node still exists and is in service
+		nodeGone, reason, err := r.nodeGone(ctx, state.NodeID)
 		if err != nil {
 			// API error - fail open (don't clear on transient errors)
 			klog.Warningf("Failed to check node %s for volume %s: %v (skipping)", state.NodeID, volumeID, err)
 			continue
 		}
 
-		if nodeExists {
+		if !nodeGone {
 			// Node exists, attachment is valid
 			continue
 		}
 
-		// Node deleted - check if within grace period
+		// Node gone - check if within grace period
 		detachTime := r.manager.GetDetachTimestamp(volumeID)
-		if !detachTime.IsZero() && time.Since(detachTime) < r.gracePeriod {
-			klog.V(4).Infof("Node %s deleted but within grace period for volume %s", state.NodeID, volumeID)
+		if !detachTime.IsZero() && r.clock.Since(detachTime) < r.gracePeriod {
+			klog.V(4).Infof("Node %s %s but within grace period for volume %s", state.NodeID, reason, volumeID)
 			continue
 		}
 
 		// Clear stale attachment
 		staleNodeID := state.NodeID // Capture before clearing
-		klog.Infof("Clearing stale attachment: volume=%s node=%s (node deleted)", volumeID, staleNodeID)
+		klog.Infof("Clearing stale attachment: volume=%s node=%s (%s)", volumeID, staleNodeID, reason)
 		if err := r.manager.UntrackAttachment(ctx, volumeID); err != nil {
 			klog.Errorf("Failed to clear stale attachment for volume %s: %v", volumeID, err)
 			continue
@@ -257,10 +267,18 @@ func (r *AttachmentReconciler) reconcile(ctx context.Context) {
 		r.postStaleAttachmentClearedEvent(ctx, volumeID, staleNodeID)
 	}
 
+	// Detach timestamps are only read within the grace period; drop older ones so volumes
+	// that are never reattached do not accumulate
+	pruned, remaining := r.manager.PruneDetachTimestamps(r.gracePeriod)
+	if pruned > 0 {
+		klog.V(4).Infof("Pruned %d expired detach timestamps (%d remaining)", pruned, remaining)
+	}
+
 	duration := time.Since(startTime)
 
 	// Record reconcile duration
 	if r.metrics != nil {
+		r.metrics.SetDetachTimestamps(remaining)
 		r.metrics.RecordAttachmentOp("reconcile", nil, duration)
 	}
 
@@ -271,18 +289,32 @@ func (r *AttachmentReconciler) reconcile(ctx context.Context) {
 	}
 }
 
-// nodeExists checks if a Kubernetes node exists using the cached node lister.
+// nodeGone checks if a Kubernetes node is gone using the cached node lister.
+// A node is gone if it was deleted, or if it has carried the out-of-service taint
+// (non-graceful node shutdown) for at least the grace period.
 // This avoids direct API calls and prevents throttling during reconciliation.
-func (r *AttachmentReconciler) nodeExists(ctx context.Context, nodeID string) (bool, error) {
+func (r *AttachmentReconciler) nodeGone(ctx context.Context, nodeID string) (bool, string, error) {
 	// Use cached lister instead of API call - this is the key fix for API throttling!
-	_, err := r.nodeLister.Get(nodeID)
+	node, err := r.nodeLister.Get(nodeID)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return false, nil
+			return true, "node deleted", nil
 		}
-		return false, err
+		return false, "", err
 	}
-	return true, nil
+
+	taint := outOfServiceTaint(node)
+	if taint == nil {
+		return false, "", nil
+	}
+
+	// Taints without TimeAdded are treated as just added
+	if taint.TimeAdded == nil || r.clock.Since(taint.TimeAdded.Time) < r.gracePeriod {
+		klog.V(4).Infof("Node %s is out of service but within grace period", nodeID)
+		return false, "", nil
+	}
+
+	return true, "node out of service", nil
 }
 
 // GetGracePeriod returns the configured grace period duration.
@@ -2,6 +2,8 @@ package attachment
 
 import (
 	"context"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +13,9 @@ import (
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 )
 
 // createTestListers creates test node and PV listers from a fake clientset
@@ -315,6 +320,150 @@ func TestReconciler_RespectsGracePeriod(t *testing.T) {
 	}
 }
 
+func TestReconciler_OutOfServiceTaint_ClearsAfterGracePeriod(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+
+	// Node still exists but has been marked out-of-service (non-graceful shutdown)
+	taintedNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "dead-node"},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{
+					Key:       corev1.TaintNodeOutOfService,
+					Value:     "nodeshutdown",
+					Effect:    corev1.TaintEffectNoExecute,
+					TimeAdded: &metav1.Time{Time: fakeClock.Now()},
+				},
+			},
+		},
+	}
+	k8sClient := fake.NewSimpleClientset()
+	nodeLister, pvLister := createTestListers(k8sClient, taintedNode)
+
+	am := NewAttachmentManager(nil)
+	am.SetClock(fakeClock)
+	ctx := context.Background()
+	volumeID := "pvc-test-out-of-service"
+
+	if err := am.TrackAttachment(ctx, volumeID, "dead-node"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	r, err := NewAttachmentReconciler(ReconcilerConfig{
+		Manager:     am,
+		K8sClient:   k8sClient,
+		NodeLister:  nodeLister,
+		PVLister:    pvLister,
+		GracePeriod: 30 * time.Second,
+		Clock:       fakeClock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create reconciler: %v", err)
+	}
+
+	// Taint was just added - attachment must survive
+	r.reconcile(ctx)
+	if _, exists := am.GetAttachment(volumeID); !exists {
+		t.Fatal("Expected attachment to be preserved while taint is within grace period")
+	}
+
+	// Advance past grace period - attachment is now stale
+	fakeClock.Step(31 * time.Second)
+	r.reconcile(ctx)
+	if _, exists := am.GetAttachment(volumeID); exists {
+		t.Error("Expected attachment to be cleared after out-of-service grace period")
+	}
+}
+
+func TestReconciler_PrunesExpiredDetachTimestamps(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	k8sClient := fake.NewSimpleClientset()
+	nodeLister, pvLister := createTestListers(k8sClient)
+
+	am := NewAttachmentManager(nil)
+	am.SetClock(fakeClock)
+	ctx := context.Background()
+	metrics := observability.NewMetrics()
+
+	r, err := NewAttachmentReconciler(ReconcilerConfig{
+		Manager:     am,
+		K8sClient:   k8sClient,
+		NodeLister:  nodeLister,
+		PVLister:    pvLister,
+		GracePeriod: 30 * time.Second,
+		Metrics:     metrics,
+		Clock:       fakeClock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create reconciler: %v", err)
+	}
+
+	for _, volumeID := range []string{"pvc-aged", "pvc-fresh"} {
+		if err := am.TrackAttachment(ctx, volumeID, "node-1"); err != nil {
+			t.Fatalf("TrackAttachment failed: %v", err)
+		}
+	}
+	if err := am.UntrackAttachment(ctx, "pvc-aged"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	fakeClock.Step(31 * time.Second)
+	if err := am.UntrackAttachment(ctx, "pvc-fresh"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	fakeClock.Step(29 * time.Second)
+
+	r.reconcile(ctx)
+
+	if !am.GetDetachTimestamp("pvc-aged").IsZero() {
+		t.Error("Expected detach timestamp past the grace period to be pruned")
+	}
+	if !am.IsWithinGracePeriod("pvc-fresh", 30*time.Second) {
+		t.Error("Expected detach timestamp within the grace period to be retained")
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "rds_csi_attachment_detach_timestamps 1") {
+		t.Errorf("Expected detach_timestamps gauge of 1, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestReconciler_NotReadyNodePreserved(t *testing.T) {
+	// NotReady alone is not proof the node is down (could be a partition)
+	notReadyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "partitioned-node"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse},
+			},
+		},
+	}
+	k8sClient := fake.NewSimpleClientset()
+	nodeLister, pvLister := createTestListers(k8sClient, notReadyNode)
+
+	am := NewAttachmentManager(nil)
+	ctx := context.Background()
+	if err := am.TrackAttachment(ctx, "pvc-test-notready", "partitioned-node"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	r, err := NewAttachmentReconciler(ReconcilerConfig{
+		Manager:     am,
+		K8sClient:   k8sClient,
+		NodeLister:  nodeLister,
+		PVLister:    pvLister,
+		GracePeriod: 1 * time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create reconciler: %v", err)
+	}
+
+	r.reconcile(ctx)
+	if _, exists := am.GetAttachment("pvc-test-notready"); !exists {
+		t.Error("Expected attachment to NotReady node to be preserved")
+	}
+}
+
 func TestReconciler_HandlesAPIErrors(t *testing.T) {
 	// NOTE: With informer-based caching, API errors during reconciliation don't occur
 	// because we use cached listers. API errors would only happen during initial cache sync.
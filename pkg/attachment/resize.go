@@ -0,0 +1,209 @@
+// resize.go tracks expansions of attached volumes that the node has not yet confirmed.
+//
+// Unlike the attached-node annotations in persist.go, the pending-resize annotations
+// are read back: the controller sets them after growing the RDS backing file, and
+// clears them once kubelet reports on the PVC that NodeExpandVolume finished. Only
+// the controller writes PVs; the node's part is reported by kubelet.
+package attachment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// AnnotationPendingNodeResize stores the size in bytes RDS was grown to while
+	// the volume was attached. Removed once the PVC reports that size.
+	AnnotationPendingNodeResize = "rds.csi.srvlab.io/pending-node-resize"
+
+	// AnnotationPendingNodeResizeSince stores when the pending resize was recorded.
+	AnnotationPendingNodeResizeSince = "rds.csi.srvlab.io/pending-node-resize-since"
+)
+
+// MarkPendingNodeResize records that an attached volume was resized on RDS and the
+// node has not yet confirmed the new size. Returns false if the volume is not attached
+// (nothing to confirm - the next attach sees the new size).
+// The in-memory marker is kept even if the PV annotation cannot be written.
+func (am *AttachmentManager) MarkPendingNodeResize(ctx context.Context, volumeID string, requestedBytes int64) (bool, error) {
+	am.volumeLocks.Lock(volumeID)
+	defer am.volumeLocks.Unlock(volumeID)
+
+	am.mu.Lock()
+	state, exists := am.attachments[volumeID]
+	if !exists {
+		am.mu.Unlock()
+		return false, nil
+	}
+	pending := &PendingResize{RequestedBytes: requestedBytes, Since: am.clock.Now()}
+	state.PendingResize = pending
+	am.mu.Unlock()
+
+	klog.V(2).Infof("Volume %s resized to %d bytes while attached to %v - pending node resize",
+		volumeID, requestedBytes, state.GetNodeIDs())
+
+	if err := am.persistPendingResize(ctx, volumeID, pending); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// GetPendingNodeResize returns the outstanding resize for an attached volume, or nil.
+// When a k8s client is available the PV annotation is authoritative. A resize the node
+// has completed, as reported on the bound PVC, is cleared from the PV and from memory.
+func (am *AttachmentManager) GetPendingNodeResize(ctx context.Context, volumeID string) *PendingResize {
+	am.mu.RLock()
+	state, exists := am.attachments[volumeID]
+	var pending *PendingResize
+	if exists {
+		pending = state.PendingResize
+	}
+	am.mu.RUnlock()
+
+	if !exists || am.k8sClient == nil {
+		return pending
+	}
+
+	pv, err := am.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if err != nil {
+		klog.V(4).Infof("Could not read PV %s for pending resize, using in-memory state: %v", volumeID, err)
+		return pending
+	}
+
+	fromPV, ok := parsePendingResize(pv.Annotations)
+	if ok && am.nodeResizeCompleted(ctx, pv, fromPV.RequestedBytes) {
+		if err := am.clearPendingResize(ctx, volumeID, fromPV.RequestedBytes); err != nil {
+			klog.Warningf("Failed to clear pending node resize of volume %s: %v", volumeID, err)
+			return fromPV
+		}
+		klog.V(2).Infof("Node resize of volume %s to %d bytes completed", volumeID, fromPV.RequestedBytes)
+		ok = false
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if current, stillExists := am.attachments[volumeID]; stillExists {
+		if ok {
+			current.PendingResize = fromPV
+		} else {
+			current.PendingResize = nil
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return fromPV
+}
+
+// nodeResizeCompleted reports whether kubelet has finished the node resize of pv to
+// requestedBytes: after a successful NodeExpandVolume it sets the capacity of the bound
+// PVC to that of the PV and drops the FileSystemResizePending condition.
+func (am *AttachmentManager) nodeResizeCompleted(ctx context.Context, pv *corev1.PersistentVolume, requestedBytes int64) bool {
+	claim := pv.Spec.ClaimRef
+	if claim == nil {
+		return false
+	}
+	pvc, err := am.k8sClient.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(ctx, claim.Name, metav1.GetOptions{})
+	if err != nil {
+		klog.V(4).Infof("Could not read PVC %s/%s for pending resize of %s: %v", claim.Namespace, claim.Name, pv.Name, err)
+		return false
+	}
+	if claim.UID != "" && pvc.UID != claim.UID {
+		return false
+	}
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Type == corev1.PersistentVolumeClaimFileSystemResizePending && cond.Status == corev1.ConditionTrue {
+			return false
+		}
+	}
+	capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+	return ok && capacity.Value() >= requestedBytes
+}
+
+// clearPendingResize removes the pending-resize annotations from the PV, unless a later
+// expansion replaced the resize to requestedBytes meanwhile.
+// Returns nil if k8sClient is nil or the PV is gone.
+func (am *AttachmentManager) clearPendingResize(ctx context.Context, volumeID string, requestedBytes int64) error {
+	if am.k8sClient == nil {
+		return nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv, err := am.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if pv.Annotations[AnnotationPendingNodeResize] != strconv.FormatInt(requestedBytes, 10) {
+			return nil
+		}
+
+		delete(pv.Annotations, AnnotationPendingNodeResize)
+		delete(pv.Annotations, AnnotationPendingNodeResizeSince)
+		_, err = am.k8sClient.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+		return err
+	})
+
+	if err != nil && !isNotFoundError(err) {
+		return fmt.Errorf("failed to clear pending resize annotation: %w", err)
+	}
+	return nil
+}
+
+// persistPendingResize writes the pending-resize annotations to the PV.
+// Returns nil if k8sClient is nil (allows operation without k8s in tests).
+func (am *AttachmentManager) persistPendingResize(ctx context.Context, volumeID string, pending *PendingResize) error {
+	if am.k8sClient == nil {
+		return nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv, err := am.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if pv.Annotations == nil {
+			pv.Annotations = make(map[string]string)
+		}
+		pv.Annotations[AnnotationPendingNodeResize] = strconv.FormatInt(pending.RequestedBytes, 10)
+		pv.Annotations[AnnotationPendingNodeResizeSince] = pending.Since.UTC().Format(time.RFC3339)
+
+		_, err = am.k8sClient.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+		return err
+	})
+
+	if err != nil {
+		if isNotFoundError(err) {
+			klog.Warningf("PV not found for volume %s, pending resize tracked in memory only", volumeID)
+			return nil
+		}
+		return fmt.Errorf("failed to persist pending resize annotation: %w", err)
+	}
+	return nil
+}
+
+// parsePendingResize reads the pending-resize annotations. Returns false if absent
+// or malformed (a malformed value cannot be confirmed, so it is treated as absent).
+func parsePendingResize(annotations map[string]string) (*PendingResize, bool) {
+	val, ok := annotations[AnnotationPendingNodeResize]
+	if !ok {
+		return nil, false
+	}
+	requested, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || requested <= 0 {
+		klog.Warningf("Ignoring malformed %s annotation %q", AnnotationPendingNodeResize, val)
+		return nil, false
+	}
+
+	pending := &PendingResize{RequestedBytes: requested}
+	if since, err := time.Parse(time.RFC3339, annotations[AnnotationPendingNodeResizeSince]); err == nil {
+		pending.Since = since
+	}
+	return pending, true
+}
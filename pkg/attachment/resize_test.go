@@ -0,0 +1,179 @@
+package attachment
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// createTestBoundPV returns the PV of volumeID bound to the PVC default/data, and that PVC
+// with capacityBytes and, if fsResizePending, the FileSystemResizePending condition
+func createTestBoundPV(volumeID string, capacityBytes int64, fsResizePending bool) (*corev1.PersistentVolume, *corev1.PersistentVolumeClaim) {
+	pv := createTestPV(volumeID, "")
+	pv.Spec.ClaimRef = &corev1.ObjectReference{Namespace: "default", Name: "data", UID: "pvc-uid"}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data", UID: "pvc-uid"},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: *resource.NewQuantity(capacityBytes, resource.BinarySI)},
+		},
+	}
+	if fsResizePending {
+		pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+			{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+		}
+	}
+	return pv, pvc
+}
+
+// setClaimStatus updates the PVC default/data as kubelet would
+func setClaimStatus(t *testing.T, client *fake.Clientset, capacityBytes int64, fsResizePending bool) {
+	t.Helper()
+	_, pvc := createTestBoundPV("", capacityBytes, fsResizePending)
+	if _, err := client.CoreV1().PersistentVolumeClaims("default").Update(context.Background(), pvc, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update PVC: %v", err)
+	}
+}
+
+func TestMarkPendingNodeResize_NotAttached(t *testing.T) {
+	volumeID := "pv-vol-1"
+	fakeClient := fake.NewSimpleClientset(createTestPV(volumeID, ""))
+	am := NewAttachmentManager(fakeClient)
+	ctx := context.Background()
+
+	marked, err := am.MarkPendingNodeResize(ctx, volumeID, 2<<30)
+	if err != nil {
+		t.Fatalf("MarkPendingNodeResize failed: %v", err)
+	}
+	if marked {
+		t.Error("Expected detached volume not to be marked")
+	}
+
+	pv, _ := fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if _, ok := pv.Annotations[AnnotationPendingNodeResize]; ok {
+		t.Errorf("Expected no %s annotation on detached volume", AnnotationPendingNodeResize)
+	}
+}
+
+func TestPendingNodeResize_TwoPhaseFlow(t *testing.T) {
+	volumeID := "pv-vol-1"
+	pv, pvc := createTestBoundPV(volumeID, 1<<30, false)
+	fakeClient := fake.NewSimpleClientset(pv, pvc)
+	am := NewAttachmentManager(fakeClient)
+	ctx := context.Background()
+
+	if err := am.TrackAttachment(ctx, volumeID, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	// Phase 1: controller grows the volume while attached
+	marked, err := am.MarkPendingNodeResize(ctx, volumeID, 2<<30)
+	if err != nil {
+		t.Fatalf("MarkPendingNodeResize failed: %v", err)
+	}
+	if !marked {
+		t.Fatal("Expected attached volume to be marked")
+	}
+
+	pv, _ = fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if pv.Annotations[AnnotationPendingNodeResize] != "2147483648" {
+		t.Errorf("Expected %s=2147483648, got %q", AnnotationPendingNodeResize, pv.Annotations[AnnotationPendingNodeResize])
+	}
+	if pv.Annotations[AnnotationPendingNodeResizeSince] == "" {
+		t.Errorf("Expected %s to be set", AnnotationPendingNodeResizeSince)
+	}
+
+	pending := am.GetPendingNodeResize(ctx, volumeID)
+	if pending == nil || pending.RequestedBytes != 2<<30 {
+		t.Fatalf("Expected pending resize of %d bytes, got %+v", 2<<30, pending)
+	}
+
+	// A PVC still showing the old size, or waiting for the filesystem resize, does not confirm
+	if am.GetPendingNodeResize(ctx, volumeID) == nil {
+		t.Fatal("Expected resize to still be pending")
+	}
+	setClaimStatus(t, fakeClient, 2<<30, true)
+	if am.GetPendingNodeResize(ctx, volumeID) == nil {
+		t.Fatal("Expected resize to still be pending while the filesystem resize is")
+	}
+
+	// Phase 2: kubelet reports the completed node resize on the PVC
+	setClaimStatus(t, fakeClient, 2<<30, false)
+	if pending := am.GetPendingNodeResize(ctx, volumeID); pending != nil {
+		t.Errorf("Expected no pending resize after the node resize, got %+v", pending)
+	}
+
+	pv, _ = fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if _, ok := pv.Annotations[AnnotationPendingNodeResize]; ok {
+		t.Errorf("Expected %s annotation to be removed", AnnotationPendingNodeResize)
+	}
+
+	state, _ := am.GetAttachment(volumeID)
+	if state.PendingResize != nil {
+		t.Error("Expected in-memory marker to be cleared")
+	}
+}
+
+func TestPendingNodeResize_ClearedOnDetach(t *testing.T) {
+	volumeID := "pv-vol-1"
+	fakeClient := fake.NewSimpleClientset(createTestPV(volumeID, ""))
+	am := NewAttachmentManager(fakeClient)
+	ctx := context.Background()
+
+	_ = am.TrackAttachment(ctx, volumeID, "node-1")
+	if _, err := am.MarkPendingNodeResize(ctx, volumeID, 2<<30); err != nil {
+		t.Fatalf("MarkPendingNodeResize failed: %v", err)
+	}
+	if err := am.UntrackAttachment(ctx, volumeID); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+
+	pv, _ := fakeClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if _, ok := pv.Annotations[AnnotationPendingNodeResize]; ok {
+		t.Errorf("Expected %s annotation to be removed on detach", AnnotationPendingNodeResize)
+	}
+}
+
+func TestPendingNodeResize_NoClient(t *testing.T) {
+	am := NewAttachmentManager(nil)
+	ctx := context.Background()
+
+	_ = am.TrackAttachment(ctx, "pv-vol-1", "node-1")
+	marked, err := am.MarkPendingNodeResize(ctx, "pv-vol-1", 2<<30)
+	if err != nil || !marked {
+		t.Fatalf("Expected in-memory mark without client, got marked=%v err=%v", marked, err)
+	}
+	if am.GetPendingNodeResize(ctx, "pv-vol-1") == nil {
+		t.Error("Expected in-memory pending resize")
+	}
+}
+
+func TestParsePendingResize(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantOK      bool
+		wantBytes   int64
+	}{
+		{"absent", map[string]string{}, false, 0},
+		{"nil map", nil, false, 0},
+		{"valid", map[string]string{AnnotationPendingNodeResize: "1073741824"}, true, 1 << 30},
+		{"malformed", map[string]string{AnnotationPendingNodeResize: "lots"}, false, 0},
+		{"zero", map[string]string{AnnotationPendingNodeResize: "0"}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, ok := parsePendingResize(tt.annotations)
+			if ok != tt.wantOK {
+				t.Fatalf("parsePendingResize() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && pending.RequestedBytes != tt.wantBytes {
+				t.Errorf("RequestedBytes = %d, want %d", pending.RequestedBytes, tt.wantBytes)
+			}
+		})
+	}
+}
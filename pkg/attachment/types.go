@@ -48,6 +48,25 @@ type AttachmentState struct {
 	// Parsed from StorageClass parameter migrationTimeoutSeconds.
 	// Zero value means use default (5 minutes).
 	MigrationTimeout time.Duration
+
+	// PendingResize is set when RDS was resized while attached and the node has
+	// not yet confirmed the new size. nil if no resize is outstanding.
+	PendingResize *PendingResize
+
+	// ActiveWriter is the node that should have write access to the volume.
+	// It is the primary until a migration hands over: the migration target
+	// attaches as a non-writer and is promoted when the source detaches. Other
+	// attached nodes can mount read-only to fence themselves.
+	ActiveWriter string
+}
+
+// PendingResize records an expansion awaiting node confirmation.
+type PendingResize struct {
+	// RequestedBytes is the new size the RDS backing file was grown to
+	RequestedBytes int64
+
+	// Since is when the controller resized the volume on RDS
+	Since time.Time
 }
 
 // GetNodeIDs returns a slice of all attached node IDs.
@@ -69,6 +88,21 @@ func (as *AttachmentState) IsAttachedToNode(nodeID string) bool {
 	return false
 }
 
+// NodeAttachedAt returns when the volume was attached to nodeID, or false if it is not.
+func (as *AttachmentState) NodeAttachedAt(nodeID string) (time.Time, bool) {
+	for _, na := range as.Nodes {
+		if na.NodeID == nodeID {
+			return na.AttachedAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// IsActiveWriter returns true if nodeID is the node that should have write access.
+func (as *AttachmentState) IsActiveWriter(nodeID string) bool {
+	return as.ActiveWriter != "" && as.ActiveWriter == nodeID
+}
+
 // NodeCount returns the number of attached nodes.
 func (as *AttachmentState) NodeCount() int {
 	return len(as.Nodes)
@@ -3,6 +3,7 @@ package circuitbreaker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -26,37 +27,76 @@ const (
 	ResetAnnotation = "rds.csi.srvlab.io/reset-circuit-breaker"
 )
 
+// OpenError is returned when a volume's circuit is open and the operation was not attempted.
+// It carries the failure that tripped the circuit so callers can explain why.
+type OpenError struct {
+	VolumeID   string
+	LastError  error         // Last failure recorded before the circuit opened (may be nil)
+	RetryAfter time.Duration // Time until the circuit half-opens and allows a trial request
+}
+
+// Error returns e.g. "circuit open for volume pvc-x; last error: ...; retrying after 4m10s"
+func (e *OpenError) Error() string {
+	lastErr := "unknown"
+	if e.LastError != nil {
+		lastErr = e.LastError.Error()
+	}
+	return fmt.Sprintf("circuit open for volume %s after %d consecutive failures; last error: %s; retrying after %s "+
+		"(to retry now: add annotation '%s=true' to the PV and delete the pod)",
+		e.VolumeID, DefaultConsecutiveFailures, lastErr, e.RetryAfter.Round(time.Second), ResetAnnotation)
+}
+
+// Unwrap returns the last recorded failure
+func (e *OpenError) Unwrap() error {
+	return e.LastError
+}
+
+// GRPCStatus makes an OpenError convert to a codes.Unavailable status
+func (e *OpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// volumeBreaker is a volume's circuit breaker with the context of its last failure
+type volumeBreaker struct {
+	cb *gobreaker.CircuitBreaker
+
+	mu       sync.Mutex
+	lastErr  error
+	openedAt time.Time
+}
+
 // VolumeCircuitBreaker manages per-volume circuit breakers to prevent retry storms
 type VolumeCircuitBreaker struct {
-	breakers map[string]*gobreaker.CircuitBreaker
+	breakers map[string]*volumeBreaker
 	mu       sync.RWMutex
 }
 
 // NewVolumeCircuitBreaker creates a new per-volume circuit breaker manager
 func NewVolumeCircuitBreaker() *VolumeCircuitBreaker {
 	return &VolumeCircuitBreaker{
-		breakers: make(map[string]*gobreaker.CircuitBreaker),
+		breakers: make(map[string]*volumeBreaker),
 	}
 }
 
 // getBreaker returns or creates a circuit breaker for the given volume
-func (vcb *VolumeCircuitBreaker) getBreaker(volumeID string) *gobreaker.CircuitBreaker {
+func (vcb *VolumeCircuitBreaker) getBreaker(volumeID string) *volumeBreaker {
 	vcb.mu.RLock()
-	cb, exists := vcb.breakers[volumeID]
+	vb, exists := vcb.breakers[volumeID]
 	vcb.mu.RUnlock()
 
 	if exists {
-		return cb
+		return vb
 	}
 
 	vcb.mu.Lock()
 	defer vcb.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if cb, exists := vcb.breakers[volumeID]; exists {
-		return cb
+	if vb, exists := vcb.breakers[volumeID]; exists {
+		return vb
 	}
 
+	vb = &volumeBreaker{}
 	settings := gobreaker.Settings{
 		Name:        volumeID,
 		MaxRequests: 1, // Only 1 request allowed in half-open state
@@ -66,31 +106,50 @@ func (vcb *VolumeCircuitBreaker) getBreaker(volumeID string) *gobreaker.CircuitB
 			return counts.ConsecutiveFailures >= DefaultConsecutiveFailures
 		},
 		OnStateChange: func(name string, from, to gobreaker.State) {
+			if to == gobreaker.StateOpen {
+				vb.mu.Lock()
+				vb.openedAt = time.Now()
+				vb.mu.Unlock()
+			}
 			klog.Infof("Circuit breaker for volume %s: %s -> %s", name, from, to)
 		},
 	}
 
-	cb = gobreaker.NewCircuitBreaker(settings)
-	vcb.breakers[volumeID] = cb
+	vb.cb = gobreaker.NewCircuitBreaker(settings)
+	vcb.breakers[volumeID] = vb
 	klog.V(4).Infof("Created circuit breaker for volume %s", volumeID)
-	return cb
+	return vb
+}
+
+// openError describes why the circuit is open and how long until it half-opens
+func (vb *volumeBreaker) openError(volumeID string) *OpenError {
+	vb.mu.Lock()
+	defer vb.mu.Unlock()
+
+	retryAfter := DefaultTimeout - time.Since(vb.openedAt)
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	return &OpenError{VolumeID: volumeID, LastError: vb.lastErr, RetryAfter: retryAfter}
 }
 
 // Execute runs the given function with circuit breaker protection.
-// Returns gRPC Unavailable error if circuit is open.
+// Returns an *OpenError (a gRPC Unavailable error) if the circuit is open.
 func (vcb *VolumeCircuitBreaker) Execute(ctx context.Context, volumeID string, fn func() error) error {
-	cb := vcb.getBreaker(volumeID)
-
-	_, err := cb.Execute(func() (interface{}, error) {
-		return nil, fn()
+	vb := vcb.getBreaker(volumeID)
+
+	_, err := vb.cb.Execute(func() (interface{}, error) {
+		fnErr := fn()
+		if fnErr != nil {
+			vb.mu.Lock()
+			vb.lastErr = fnErr
+			vb.mu.Unlock()
+		}
+		return nil, fnErr
 	})
 
 	if errors.Is(err, gobreaker.ErrOpenState) {
-		return status.Errorf(codes.Unavailable,
-			"Volume %s circuit breaker is OPEN due to %d consecutive failures. "+
-				"Filesystem may be corrupted. To retry: add annotation '%s=true' to the PV "+
-				"and delete the pod. The circuit will reset on next mount attempt.",
-			volumeID, DefaultConsecutiveFailures, ResetAnnotation)
+		return vb.openError(volumeID)
 	}
 
 	if errors.Is(err, gobreaker.ErrTooManyRequests) {
@@ -127,12 +186,12 @@ func (vcb *VolumeCircuitBreaker) CheckReset(volumeID string, annotations map[str
 // Returns "closed" if no breaker exists (default safe state).
 func (vcb *VolumeCircuitBreaker) State(volumeID string) string {
 	vcb.mu.RLock()
-	cb, exists := vcb.breakers[volumeID]
+	vb, exists := vcb.breakers[volumeID]
 	vcb.mu.RUnlock()
 
 	if !exists {
 		return "closed"
 	}
 
-	return cb.State().String()
+	return vb.cb.State().String()
 }
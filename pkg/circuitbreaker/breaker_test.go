@@ -3,8 +3,10 @@ package circuitbreaker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -56,8 +58,52 @@ func TestVolumeCircuitBreaker_OpensAfterFailures(t *testing.T) {
 		t.Errorf("Expected Unavailable code, got: %v", st.Code())
 	}
 
-	if !strings.Contains(st.Message(), "OPEN") {
-		t.Errorf("Error message should mention OPEN state: %s", st.Message())
+	if !strings.Contains(st.Message(), "circuit open for volume vol-fail") {
+		t.Errorf("Error message should mention the open circuit: %s", st.Message())
+	}
+}
+
+func TestVolumeCircuitBreaker_OpenErrorContext(t *testing.T) {
+	vcb := NewVolumeCircuitBreaker()
+	ctx := context.Background()
+
+	for i := 0; i < DefaultConsecutiveFailures; i++ {
+		_ = vcb.Execute(ctx, "vol-ctx", func() error {
+			return fmt.Errorf("mount failed: attempt %d", i)
+		})
+	}
+
+	called := false
+	err := vcb.Execute(ctx, "vol-ctx", func() error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Fatal("function should not run while the circuit is open")
+	}
+
+	var openErr *OpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Expected *OpenError, got: %v", err)
+	}
+	if openErr.VolumeID != "vol-ctx" {
+		t.Errorf("VolumeID = %q, want vol-ctx", openErr.VolumeID)
+	}
+	if openErr.LastError == nil || openErr.LastError.Error() != "mount failed: attempt 2" {
+		t.Errorf("LastError = %v, want the last recorded failure", openErr.LastError)
+	}
+	if openErr.RetryAfter <= DefaultTimeout-time.Minute || openErr.RetryAfter > DefaultTimeout {
+		t.Errorf("RetryAfter = %v, want just under %v", openErr.RetryAfter, DefaultTimeout)
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"circuit open for volume vol-ctx", "last error: mount failed: attempt 2", "retrying after 5m0s"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Error message %q should contain %q", msg, want)
+		}
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable code, got: %v", status.Code(err))
 	}
 }
 
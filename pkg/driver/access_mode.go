@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// volumeContextAccessMode records the access mode CreateVolume granted a volume.
+// ControllerPublishVolume attaches by it rather than by the capability of the publish
+// request, so a PV edited or copied to ReadWriteMany cannot attach an RWO volume to
+// two nodes.
+const volumeContextAccessMode = "accessMode"
+
+// Access modes granted to volumes, as the attachment manager tracks them
+const (
+	// AccessModeRWO volumes attach to one node at a time
+	AccessModeRWO = "RWO"
+
+	// AccessModeRWX volumes may attach to a second node during a live migration
+	AccessModeRWX = "RWX"
+)
+
+// grantedAccessMode validates the access modes of a CreateVolume request against the
+// driver's policy and returns the mode the volume is granted. MULTI_NODE_MULTI_WRITER is
+// only granted to block volumes whose StorageClass opts into one of the two RWX
+// policies: acknowledgeSharedBlockRisk (one writer at a time, e.g. live migration) or a
+// clustered fsType. A request mixing single and multi-node modes is granted RWX, since
+// the volume must honor all of them. Errors are InvalidArgument status errors naming
+// the rejected mode.
+func (cs *ControllerServer) grantedAccessMode(caps []*csi.VolumeCapability, params map[string]string) (string, error) {
+	if err := cs.validateVolumeCapabilities(caps); err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid volume capabilities: %v", err)
+	}
+	if !hasMultiWriterCapability(caps) {
+		return AccessModeRWO, nil
+	}
+
+	// Several nodes writing one block device corrupt a non-clustered filesystem, so
+	// MULTI_NODE_MULTI_WRITER needs the StorageClass to opt in
+	acknowledged, err := ParseSharedBlockRiskAcknowledged(params)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if !acknowledged {
+		return "", status.Errorf(codes.InvalidArgument,
+			"MULTI_NODE_MULTI_WRITER volumes share one block device between nodes, and a non-clustered "+
+				"filesystem such as ext4 or xfs written from two nodes at once is corrupted. "+
+				"Set StorageClass parameter %s: \"true\" if only one node writes at a time "+
+				"(e.g. KubeVirt live migration), or %s: gfs2 or ocfs2 for a clustered filesystem",
+			paramAcknowledgeSharedBlockRisk, paramFSType)
+	}
+	return AccessModeRWX, nil
+}
+
+// publishAccessMode returns the access mode ControllerPublishVolume attaches a volume
+// with: the one recorded in its volume context at creation. Volumes created before it
+// was recorded fall back to the capability of the publish request. A publish requesting
+// MULTI_NODE_MULTI_WRITER for a volume granted RWO fails with InvalidArgument.
+func publishAccessMode(req *csi.ControllerPublishVolumeRequest) (string, error) {
+	requested := AccessModeRWO
+	if req.GetVolumeCapability().GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER {
+		requested = AccessModeRWX
+	}
+
+	switch granted := req.GetVolumeContext()[volumeContextAccessMode]; granted {
+	case "":
+		return requested, nil
+	case AccessModeRWO:
+		if requested == AccessModeRWX {
+			return "", status.Errorf(codes.InvalidArgument,
+				"volume %s was created with access mode %s and cannot be published with MULTI_NODE_MULTI_WRITER; "+
+					"re-create it from a StorageClass allowing ReadWriteMany", req.GetVolumeId(), granted)
+		}
+		return granted, nil
+	case AccessModeRWX:
+		return granted, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "invalid %s %q in volume context (expected %s or %s)",
+			volumeContextAccessMode, granted, AccessModeRWO, AccessModeRWX)
+	}
+}
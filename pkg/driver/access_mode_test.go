@@ -0,0 +1,202 @@
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+func accessModeCapability(mode csi.VolumeCapability_AccessMode_Mode, block bool) *csi.VolumeCapability {
+	volCap := &csi.VolumeCapability{AccessMode: &csi.VolumeCapability_AccessMode{Mode: mode}}
+	if block {
+		volCap.AccessType = &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}
+	} else {
+		volCap.AccessType = &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}}
+	}
+	return volCap
+}
+
+// TestCreateVolume_AccessModePolicy tests every access mode under both RWX policies:
+// migration (acknowledgeSharedBlockRisk) and a clustered filesystem
+func TestCreateVolume_AccessModePolicy(t *testing.T) {
+	policies := map[string]map[string]string{
+		"migration": {paramAcknowledgeSharedBlockRisk: "true"},
+		"clustered": {paramFSType: "gfs2"},
+	}
+	modes := []struct {
+		mode  csi.VolumeCapability_AccessMode_Mode
+		block bool
+		want  string // granted mode, "" for InvalidArgument
+	}{
+		{mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER, block: true, want: AccessModeRWO},
+		{mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY, block: true, want: AccessModeRWO},
+		{mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, block: true, want: AccessModeRWX},
+		{mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, block: false},
+		{mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY, block: true},
+		{mode: csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER, block: true},
+		{mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER, block: true},
+		{mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER, block: true},
+		{mode: csi.VolumeCapability_AccessMode_UNKNOWN, block: true},
+	}
+
+	for policy, params := range policies {
+		for _, tt := range modes {
+			name := policy + "/" + tt.mode.String()
+			if !tt.block {
+				name += "/filesystem"
+			}
+			t.Run(name, func(t *testing.T) {
+				cs, _ := testControllerServer(t)
+				resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+					Name:               "pvc-11111111-2222-3333-4444-555555555555",
+					Parameters:         params,
+					VolumeCapabilities: []*csi.VolumeCapability{accessModeCapability(tt.mode, tt.block)},
+				})
+
+				if tt.want == "" {
+					if status.Code(err) != codes.InvalidArgument {
+						t.Fatalf("expected InvalidArgument, got %v", err)
+					}
+					if !strings.Contains(err.Error(), tt.mode.String()) {
+						t.Errorf("error does not name the rejected mode: %v", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("CreateVolume failed: %v", err)
+				}
+				if got := resp.Volume.VolumeContext[volumeContextAccessMode]; got != tt.want {
+					t.Errorf("granted access mode = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestCreateVolume_AccessModeWithoutPolicy(t *testing.T) {
+	cs, _ := testControllerServer(t)
+	_, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name: "pvc-11111111-2222-3333-4444-555555555555",
+		VolumeCapabilities: []*csi.VolumeCapability{
+			accessModeCapability(csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, true),
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "MULTI_NODE_MULTI_WRITER") {
+		t.Errorf("expected InvalidArgument naming MULTI_NODE_MULTI_WRITER, got %v", err)
+	}
+}
+
+// TestCreateVolume_MixedAccessModes tests that a volume that must honor single and
+// multi-node modes is granted RWX
+func TestCreateVolume_MixedAccessModes(t *testing.T) {
+	cs, _ := testControllerServer(t)
+	resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:       "pvc-11111111-2222-3333-4444-555555555555",
+		Parameters: map[string]string{paramAcknowledgeSharedBlockRisk: "true"},
+		VolumeCapabilities: []*csi.VolumeCapability{
+			accessModeCapability(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER, true),
+			accessModeCapability(csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, true),
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	if got := resp.Volume.VolumeContext[volumeContextAccessMode]; got != AccessModeRWX {
+		t.Errorf("granted access mode = %q, want %q", got, AccessModeRWX)
+	}
+}
+
+// TestControllerPublishVolume_GrantedAccessMode tests that attachments are tracked with
+// the mode recorded at creation rather than the mode of the publish request
+func TestControllerPublishVolume_GrantedAccessMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		granted   string
+		requested csi.VolumeCapability_AccessMode_Mode
+		wantCode  codes.Code
+		wantMode  string
+	}{
+		{name: "RWO volume", granted: AccessModeRWO, requested: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER, wantMode: AccessModeRWO},
+		{name: "RWO volume published as RWX", granted: AccessModeRWO, requested: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, wantCode: codes.InvalidArgument},
+		{name: "RWX volume", granted: AccessModeRWX, requested: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, wantMode: AccessModeRWX},
+		{name: "RWX volume published as RWO", granted: AccessModeRWX, requested: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER, wantMode: AccessModeRWX},
+		{name: "volume without granted mode", requested: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, wantMode: AccessModeRWX},
+		{name: "invalid granted mode", granted: "RWM", requested: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t, testNode("node-1"))
+			mockRDS.AddVolume(&rds.VolumeInfo{
+				Slot:        testVolumeID1,
+				NVMETCPNQN:  "nqn.2000-02.com.mikrotik:" + testVolumeID1,
+				NVMETCPPort: 4420,
+			})
+			volumeContext := map[string]string{}
+			if tt.granted != "" {
+				volumeContext[volumeContextAccessMode] = tt.granted
+			}
+
+			_, err := cs.ControllerPublishVolume(context.Background(), &csi.ControllerPublishVolumeRequest{
+				VolumeId:         testVolumeID1,
+				NodeId:           "node-1",
+				VolumeContext:    volumeContext,
+				VolumeCapability: accessModeCapability(tt.requested, true),
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("ControllerPublishVolume error = %v, want %v", err, tt.wantCode)
+			}
+			am := cs.driver.GetAttachmentManager()
+			if tt.wantCode != codes.OK {
+				if _, exists := am.GetAttachment(testVolumeID1); exists {
+					t.Error("expected no attachment")
+				}
+				return
+			}
+			if got := am.GetAccessMode(testVolumeID1); got != tt.wantMode {
+				t.Errorf("tracked access mode = %q, want %q", got, tt.wantMode)
+			}
+		})
+	}
+}
+
+// TestControllerPublishVolume_NoDualAttachOfRWOVolume tests that an RWO volume attached
+// to one node cannot be attached to a second by publishing it as RWX
+func TestControllerPublishVolume_NoDualAttachOfRWOVolume(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t, testNode("node-1"), testNode("node-2"))
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:        testVolumeID1,
+		NVMETCPNQN:  "nqn.2000-02.com.mikrotik:" + testVolumeID1,
+		NVMETCPPort: 4420,
+	})
+	volumeContext := map[string]string{volumeContextAccessMode: AccessModeRWO}
+
+	if _, err := cs.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+		VolumeId:         testVolumeID1,
+		NodeId:           "node-1",
+		VolumeContext:    volumeContext,
+		VolumeCapability: accessModeCapability(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER, true),
+	}); err != nil {
+		t.Fatalf("first attach failed: %v", err)
+	}
+
+	_, err := cs.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+		VolumeId:         testVolumeID1,
+		NodeId:           "node-2",
+		VolumeContext:    volumeContext,
+		VolumeCapability: accessModeCapability(csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, true),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if nodes := cs.driver.GetAttachmentManager().GetNodeCount(testVolumeID1); nodes != 1 {
+		t.Errorf("volume attached to %d nodes, want 1", nodes)
+	}
+}
@@ -0,0 +1,227 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// AdminHandler returns the HTTP handler for operator maintenance endpoints.
+// These are never called by Kubernetes; main serves them on a separate, authenticated listener.
+//
+//	POST /admin/housekeeping?dryRun=false
+//	    Removes volume and snapshot backing files that no disk entry references.
+//	    Defaults to a dry run; the JSON report lists the files and bytes reclaimed.
+//	GET /admin/volume?pvc=<namespace>/<name>
+//	GET /admin/volume?volumeID=<volume ID>
+//	    Read-only lookup of a volume's PV, PVC, slot, NQN, backing file and attached nodes.
+//	POST /admin/drain-node?node=<node ID>&force=false
+//	    Detaches every volume attached to the node before maintenance, skipping volumes
+//	    a pod on the node still uses unless force is set. The JSON report lists the
+//	    result per volume; running it again resumes with the volumes left.
+//	GET /admin/describe?volumeID=<volume ID>
+//	    Runs live checks of a volume (RDS disk status and size, NVMe/TCP export and
+//	    host connections, attachment state) and reports each as ok, failed, timeout
+//	    or skipped. Every check is bounded by a timeout.
+func (d *Driver) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/housekeeping", d.handleHousekeeping)
+	mux.HandleFunc("/admin/volume", d.handleVolumeLookup)
+	mux.HandleFunc("/admin/drain-node", d.handleDrainNode)
+	mux.HandleFunc("/admin/describe", d.handleDescribe)
+	return mux
+}
+
+func (d *Driver) handleHousekeeping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if d.housekeeper == nil {
+		http.Error(w, "housekeeping requires controller mode, a Kubernetes client and --rds-volume-base-path", http.StatusServiceUnavailable)
+		return
+	}
+
+	dryRun := true
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "invalid dryRun value: "+value, http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	klog.Infof("Admin request: housekeeping (dry_run=%v, remote=%s)", dryRun, r.RemoteAddr)
+	report, err := d.housekeeper.Run(r.Context(), dryRun)
+	if err != nil {
+		klog.Errorf("Housekeeping failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		klog.Errorf("Failed to write housekeeping report: %v", err)
+	}
+}
+
+// volumeLookup is the /admin/volume response. It joins what the PV spec and the
+// attachment manager know about a volume; device paths are node-local and not included.
+type volumeLookup struct {
+	VolumeID      string   `json:"volumeID"`
+	PV            string   `json:"pv"`
+	PVC           string   `json:"pvc,omitempty"` // <namespace>/<name> of the bound claim
+	Backend       string   `json:"backend,omitempty"`
+	Slot          string   `json:"slot"`
+	NQN           string   `json:"nqn"`
+	FilePath      string   `json:"filePath,omitempty"`
+	AttachedNodes []string `json:"attachedNodes"`
+}
+
+// errVolumeLookupNotFound marks lookups of PVCs or volumes this driver has no PV for
+type errVolumeLookupNotFound struct {
+	what string
+}
+
+func (e *errVolumeLookupNotFound) Error() string {
+	return e.what + " not found"
+}
+
+func (d *Driver) handleVolumeLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if d.k8sClient == nil {
+		http.Error(w, "volume lookup requires a Kubernetes client", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	pvcRef, volumeID := query.Get("pvc"), query.Get("volumeID")
+	var pv *corev1.PersistentVolume
+	var err error
+	switch {
+	case pvcRef != "" && volumeID != "":
+		http.Error(w, "give either pvc or volumeID, not both", http.StatusBadRequest)
+		return
+	case pvcRef != "":
+		namespace, name, ok := strings.Cut(pvcRef, "/")
+		if !ok || namespace == "" || name == "" {
+			http.Error(w, "invalid pvc value "+pvcRef+" (expected <namespace>/<name>)", http.StatusBadRequest)
+			return
+		}
+		pv, err = d.lookupPVByClaim(r.Context(), namespace, name)
+	case volumeID != "":
+		if err := utils.ValidateVolumeID(volumeID); err != nil {
+			http.Error(w, "invalid volumeID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		pv, err = d.lookupPVByHandle(r.Context(), volumeID)
+	default:
+		http.Error(w, "pvc or volumeID is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		var notFound *errVolumeLookupNotFound
+		if errors.As(err, &notFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Volume lookup failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.describeVolume(pv)); err != nil {
+		klog.Errorf("Failed to write volume lookup: %v", err)
+	}
+}
+
+// lookupPVByClaim returns the PV of this driver bound to the PVC namespace/name
+func (d *Driver) lookupPVByClaim(ctx context.Context, namespace, name string) (*corev1.PersistentVolume, error) {
+	pvc, err := d.k8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, &errVolumeLookupNotFound{what: fmt.Sprintf("PVC %s/%s", namespace, name)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PVC %s/%s: %w", namespace, name, err)
+	}
+	if pvc.Spec.VolumeName == "" {
+		return nil, &errVolumeLookupNotFound{what: fmt.Sprintf("bound volume of PVC %s/%s", namespace, name)}
+	}
+
+	pv, err := d.k8sClient.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, &errVolumeLookupNotFound{what: "PV " + pvc.Spec.VolumeName}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PV %s: %w", pvc.Spec.VolumeName, err)
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != d.name {
+		return nil, &errVolumeLookupNotFound{what: fmt.Sprintf("%s volume of PVC %s/%s", d.name, namespace, name)}
+	}
+	return pv, nil
+}
+
+// lookupPVByHandle returns the PV of this driver with volume handle volumeID
+func (d *Driver) lookupPVByHandle(ctx context.Context, volumeID string) (*corev1.PersistentVolume, error) {
+	pvs, err := d.k8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PVs: %w", err)
+	}
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == d.name && pv.Spec.CSI.VolumeHandle == volumeID {
+			return pv, nil
+		}
+	}
+	return nil, &errVolumeLookupNotFound{what: "PV with volume handle " + volumeID}
+}
+
+// describeVolume builds the lookup response for pv. The NQN is derived from the volume
+// ID when the PV predates the nqn volume attribute.
+func (d *Driver) describeVolume(pv *corev1.PersistentVolume) volumeLookup {
+	attrs := pv.Spec.CSI.VolumeAttributes
+	volumeID := pv.Spec.CSI.VolumeHandle
+	lookup := volumeLookup{
+		VolumeID:      volumeID,
+		PV:            pv.Name,
+		Backend:       attrs[paramBackend],
+		Slot:          pvSlot(pv),
+		NQN:           attrs[volumeContextNQN],
+		FilePath:      attrs[paramVolumePath],
+		AttachedNodes: []string{},
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		lookup.PVC = ref.Namespace + "/" + ref.Name
+	}
+	if lookup.NQN == "" {
+		if nqn, err := utils.VolumeIDToNQN(volumeID); err == nil {
+			lookup.NQN = nqn
+		}
+	}
+	if d.attachmentManager != nil {
+		if state, ok := d.attachmentManager.GetAttachment(volumeID); ok {
+			for _, node := range state.Nodes {
+				lookup.AttachedNodes = append(lookup.AttachedNodes, node.NodeID)
+			}
+		}
+	}
+	return lookup
+}
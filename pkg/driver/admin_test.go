@@ -0,0 +1,147 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/reconciler"
+)
+
+func TestAdminHandler_Housekeeping(t *testing.T) {
+	housekeeper, err := reconciler.NewFileHousekeeper(reconciler.FileHousekeeperConfig{
+		RDSClient: rds.NewMockClient(),
+		K8sClient: fake.NewSimpleClientset(),
+		BasePath:  "/storage-pool/metal-csi",
+	})
+	if err != nil {
+		t.Fatalf("NewFileHousekeeper failed: %v", err)
+	}
+	handler := (&Driver{housekeeper: housekeeper}).AdminHandler()
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		wantStatus int
+		wantDryRun bool
+	}{
+		{name: "defaults to dry run", method: http.MethodPost, url: "/admin/housekeeping", wantStatus: http.StatusOK, wantDryRun: true},
+		{name: "delete", method: http.MethodPost, url: "/admin/housekeeping?dryRun=false", wantStatus: http.StatusOK},
+		{name: "GET rejected", method: http.MethodGet, url: "/admin/housekeeping", wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid dryRun", method: http.MethodPost, url: "/admin/housekeeping?dryRun=maybe", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var report reconciler.HousekeepingReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("invalid report: %v", err)
+			}
+			if report.DryRun != tt.wantDryRun {
+				t.Errorf("DryRun = %v, want %v", report.DryRun, tt.wantDryRun)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_HousekeepingUnavailable(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&Driver{}).AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/housekeeping", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestAdminHandler_VolumeLookup(t *testing.T) {
+	const volumeID = "pvc-12345678-1234-1234-1234-123456789012"
+	client := fake.NewSimpleClientset(
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "databases"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-data"},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "databases"},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: "databases", Name: "data"},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:       DriverName,
+					VolumeHandle: volumeID,
+					VolumeAttributes: map[string]string{
+						"volumePath": "/storage-pool/metal-csi/" + volumeID + ".img",
+					},
+				}},
+			},
+		},
+	)
+	am := attachment.NewAttachmentManager(client)
+	if err := am.TrackAttachment(context.Background(), volumeID, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	handler := (&Driver{name: DriverName, k8sClient: client, attachmentManager: am}).AdminHandler()
+
+	want := volumeLookup{
+		VolumeID:      volumeID,
+		PV:            "pv-data",
+		PVC:           "databases/data",
+		Slot:          volumeID,
+		NQN:           "nqn.2000-02.com.mikrotik:" + volumeID,
+		FilePath:      "/storage-pool/metal-csi/" + volumeID + ".img",
+		AttachedNodes: []string{"node-1"},
+	}
+	for _, url := range []string{"/admin/volume?volumeID=" + volumeID, "/admin/volume?pvc=databases/data"} {
+		t.Run(url, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			var got volumeLookup
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("lookup = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		url        string
+		wantStatus int
+	}{
+		{url: "/admin/volume?pvc=databases/missing", wantStatus: http.StatusNotFound},
+		{url: "/admin/volume?pvc=databases/pending", wantStatus: http.StatusNotFound},
+		{url: "/admin/volume?volumeID=pvc-00000000-0000-0000-0000-000000000000", wantStatus: http.StatusNotFound},
+		{url: "/admin/volume?pvc=data", wantStatus: http.StatusBadRequest},
+		{url: "/admin/volume?volumeID=../etc", wantStatus: http.StatusBadRequest},
+		{url: "/admin/volume", wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
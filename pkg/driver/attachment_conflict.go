@@ -0,0 +1,97 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog/v2"
+)
+
+// conflictHolderLookupTimeout bounds the lookup of the workload holding a volume, so
+// identifying it never delays the conflict error for long
+const conflictHolderLookupTimeout = 5 * time.Second
+
+// conflictHolder identifies the pod, and its VMI for KubeVirt, using the volume of req on
+// attachedNode, e.g. "pod default/virt-launcher-vm1-x7k2p (VMI default/vm1)". Returns ""
+// if it cannot be identified: without a Kubernetes client, without the PVC in the volume
+// context, or if the lookup fails.
+func (cs *ControllerServer) conflictHolder(ctx context.Context, req *csi.ControllerPublishVolumeRequest, attachedNode string) string {
+	if cs.driver.k8sClient == nil {
+		return ""
+	}
+	volCtx := req.GetVolumeContext()
+	pvcNamespace := volCtx["csi.storage.k8s.io/pvc/namespace"]
+	pvcName := volCtx["csi.storage.k8s.io/pvc/name"]
+	if pvcNamespace == "" || pvcName == "" {
+		klog.V(4).Infof("Cannot identify holder of volume %s: PVC info not in volume context", req.GetVolumeId())
+		return ""
+	}
+
+	holder, err := cs.driver.claimHolder(ctx, pvcNamespace, pvcName, attachedNode)
+	if err != nil {
+		klog.Warningf("Failed to identify holder of volume %s on node %s: %v", req.GetVolumeId(), attachedNode, err)
+		return ""
+	}
+	return holder
+}
+
+// claimHolder describes the running pod on node using the PVC namespace/pvcName, or
+// returns "" if there is none. It makes a single pod list call, bounded by
+// conflictHolderLookupTimeout.
+func (d *Driver) claimHolder(ctx context.Context, namespace, pvcName, node string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, conflictHolderLookupTimeout)
+	defer cancel()
+	pods, err := d.k8sClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node).String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods on node %s: %w", node, err)
+	}
+	return describeConflictHolder(pods.Items, pvcName, node), nil
+}
+
+// describeConflictHolder picks the running pod on node using pvcName from pods. A KubeVirt
+// hotplug attachment pod is reported with the VMI of the virt-launcher pod owning it.
+func describeConflictHolder(pods []corev1.Pod, pvcName, node string) string {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName != node || !podMountsPVC(pod, pvcName) ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		vmiKey := extractVMIFromPod(pod)
+		if vmiKey == "" {
+			for _, ownerRef := range pod.OwnerReferences {
+				if ownerRef.Kind != "Pod" {
+					continue
+				}
+				for j := range pods {
+					if pods[j].Name == ownerRef.Name {
+						vmiKey = extractVMIFromPod(&pods[j])
+					}
+				}
+			}
+		}
+
+		holder := fmt.Sprintf("pod %s/%s", pod.Namespace, pod.Name)
+		if vmiKey != "" {
+			holder += fmt.Sprintf(" (VMI %s)", vmiKey)
+		}
+		return holder
+	}
+	return ""
+}
+
+// conflictHolderSuffix formats holder for appending to a conflict message
+func conflictHolderSuffix(holder string) string {
+	if holder == "" {
+		return ""
+	}
+	return " (in use by " + holder + ")"
+}
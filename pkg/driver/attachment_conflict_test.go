@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+func attachmentConflicts(t *testing.T, metrics *observability.Metrics, holder string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, `rds_csi_attachment_conflicts_total{holder="`+holder+`"} `); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// conflictTestPod returns a pod on node mounting the PVC "data" in namespace vms
+func conflictTestPod(name, node string, owner *metav1.OwnerReference) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "vms"},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func TestControllerPublishVolume_RWOConflictNamesHolder(t *testing.T) {
+	launcher := conflictTestPod("virt-launcher-vm1-x7k2p", "node-1", &metav1.OwnerReference{Kind: "VirtualMachineInstance", Name: "vm1"})
+	launcher.Spec.Volumes = nil
+	hotplug := conflictTestPod("hp-volume-4mz8q", "node-1", &metav1.OwnerReference{Kind: "Pod", Name: "virt-launcher-vm1-x7k2p"})
+	finished := conflictTestPod("job-1", "node-1", nil)
+	finished.Status.Phase = corev1.PodSucceeded
+
+	tests := []struct {
+		name       string
+		pods       []*corev1.Pod
+		wantHolder string
+		wantLabel  string
+	}{
+		{
+			name:       "pod",
+			pods:       []*corev1.Pod{conflictTestPod("app-0", "node-1", nil)},
+			wantHolder: "in use by pod vms/app-0",
+			wantLabel:  "identified",
+		},
+		{
+			name:       "KubeVirt hotplug pod",
+			pods:       []*corev1.Pod{launcher, hotplug},
+			wantHolder: "in use by pod vms/hp-volume-4mz8q (VMI vms/vm1)",
+			wantLabel:  "identified",
+		},
+		{
+			name:      "pod on another node",
+			pods:      []*corev1.Pod{conflictTestPod("app-0", "node-3", nil)},
+			wantLabel: "unknown",
+		},
+		{
+			name:      "finished pod",
+			pods:      []*corev1.Pod{finished},
+			wantLabel: "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cs, mockRDS := testControllerServer(t, testNode("node-1"), testNode("node-2"))
+			cs.driver.metrics = observability.NewMetrics()
+			recorder := record.NewFakeRecorder(10)
+			cs.driver.getEventPoster().recorder = recorder
+
+			pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "vms"}}
+			if _, err := cs.driver.k8sClient.CoreV1().PersistentVolumeClaims("vms").Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("failed to create PVC: %v", err)
+			}
+			for _, pod := range tt.pods {
+				if _, err := cs.driver.k8sClient.CoreV1().Pods("vms").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("failed to create pod: %v", err)
+				}
+			}
+
+			mockRDS.AddVolume(&rds.VolumeInfo{
+				Slot:        testVolumeID3,
+				NVMETCPPort: 4420,
+				NVMETCPNQN:  "nqn.2000-02.com.mikrotik:" + testVolumeID3,
+			})
+			req := &csi.ControllerPublishVolumeRequest{
+				VolumeId: testVolumeID3,
+				NodeId:   "node-1",
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+					},
+					AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+				},
+				VolumeContext: map[string]string{
+					"csi.storage.k8s.io/pvc/namespace": "vms",
+					"csi.storage.k8s.io/pvc/name":      "data",
+				},
+			}
+			if _, err := cs.ControllerPublishVolume(ctx, req); err != nil {
+				t.Fatalf("first publish failed: %v", err)
+			}
+			drainEvents(recorder)
+
+			req.NodeId = "node-2"
+			_, err := cs.ControllerPublishVolume(ctx, req)
+			if status.Code(err) != codes.FailedPrecondition {
+				t.Fatalf("expected FailedPrecondition, got %v", err)
+			}
+
+			msg := status.Convert(err).Message()
+			events := drainEvents(recorder)
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event, got %q", events)
+			}
+			if tt.wantHolder != "" {
+				if !strings.Contains(msg, tt.wantHolder) {
+					t.Errorf("expected error to name the holder (%q), got %q", tt.wantHolder, msg)
+				}
+				if !strings.Contains(events[0], strings.TrimPrefix(tt.wantHolder, "in use by ")) {
+					t.Errorf("expected event to name the holder, got %q", events[0])
+				}
+			} else if strings.Contains(msg, "in use by") {
+				t.Errorf("expected no holder in the error, got %q", msg)
+			}
+			if got := attachmentConflicts(t, cs.driver.metrics, tt.wantLabel); got != "1" {
+				t.Errorf("expected 1 conflict with holder=%q, got %q", tt.wantLabel, got)
+			}
+		})
+	}
+}
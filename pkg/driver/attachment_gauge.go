@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"sync"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+)
+
+// attachmentGauge follows the attachment events of an AttachmentManager and holds the
+// number of attached volumes for the nvme_connections_active gauge, so that a scrape
+// reads a number instead of copying the attachment state.
+type attachmentGauge struct {
+	manager *attachment.AttachmentManager
+	events  <-chan attachment.AttachmentEvent
+	done    chan struct{}
+
+	mu    sync.Mutex
+	seq   uint64
+	count int
+}
+
+// newAttachmentGauge subscribes to the events of manager and starts following them
+func newAttachmentGauge(manager *attachment.AttachmentManager) *attachmentGauge {
+	g := &attachmentGauge{
+		manager: manager,
+		events:  manager.Subscribe(),
+		done:    make(chan struct{}),
+		count:   len(manager.ListAttachments()),
+	}
+	go g.run()
+	return g
+}
+
+// run records the count of each event until the subscription ends. Every event carries
+// the count right after its change, so a dropped event is made up for by the next one.
+func (g *attachmentGauge) run() {
+	defer close(g.done)
+	for event := range g.events {
+		g.mu.Lock()
+		// Events of different volumes may arrive out of order
+		if event.Seq > g.seq {
+			g.seq, g.count = event.Seq, event.AttachedVolumes
+		}
+		g.mu.Unlock()
+	}
+}
+
+// Count returns the number of attached volumes
+func (g *attachmentGauge) Count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.count
+}
+
+// Stop ends the subscription and waits for the gauge to stop following events
+func (g *attachmentGauge) Stop() {
+	g.manager.Unsubscribe(g.events)
+	<-g.done
+}
@@ -0,0 +1,57 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+)
+
+// waitForCount waits until the gauge counts want attached volumes
+func waitForCount(t *testing.T, gauge *attachmentGauge, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for gauge.Count() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Count() = %d, want %d", gauge.Count(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAttachmentGauge(t *testing.T) {
+	am := attachment.NewAttachmentManager(nil)
+	ctx := context.Background()
+	if err := am.TrackAttachment(ctx, "vol-1", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	gauge := newAttachmentGauge(am)
+	if count := gauge.Count(); count != 1 {
+		t.Errorf("Count() = %d before any event, want the 1 volume already attached", count)
+	}
+
+	if err := am.TrackAttachmentWithMode(ctx, "vol-2", "node-1", "RWX"); err != nil {
+		t.Fatalf("TrackAttachmentWithMode failed: %v", err)
+	}
+	waitForCount(t, gauge, 2)
+
+	// A volume attached to two nodes during a migration counts once
+	if err := am.AddSecondaryAttachment(ctx, "vol-2", "node-2", time.Minute); err != nil {
+		t.Fatalf("AddSecondaryAttachment failed: %v", err)
+	}
+	if err := am.UntrackAttachment(ctx, "vol-1"); err != nil {
+		t.Fatalf("UntrackAttachment failed: %v", err)
+	}
+	waitForCount(t, gauge, 1)
+
+	gauge.Stop()
+	gauge.Stop()
+	if err := am.TrackAttachment(ctx, "vol-3", "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	if count := gauge.Count(); count != 1 {
+		t.Errorf("Count() = %d after Stop, want the last count 1", count)
+	}
+}
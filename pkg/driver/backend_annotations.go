@@ -0,0 +1,121 @@
+package driver
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// Backend detail annotations written with --annotate-backend-details. They are
+// informational only: the driver never reads them.
+const (
+	// AnnotationBackendSlot is the slot of the volume's disk on RDS. Unlike AnnotationSlot,
+	// which renamed-slot lookups and the orphan reconciler rely on, it is never read.
+	AnnotationBackendSlot = "rds.csi.srvlab.io/backend-slot"
+
+	// AnnotationNQN is the NVMe qualified name of the volume's target
+	AnnotationNQN = "rds.csi.srvlab.io/nqn"
+
+	// AnnotationBackendAddress is the address of the RDS the volume is on
+	AnnotationBackendAddress = "rds.csi.srvlab.io/backend-address"
+
+	// AnnotationBasePath is the RDS directory holding the volume's backing file
+	AnnotationBasePath = "rds.csi.srvlab.io/base-path"
+)
+
+// maxBackendAnnotationLength caps a backend detail annotation value
+const maxBackendAnnotationLength = 256
+
+// backendAnnotationUnsafeChars matches characters not expected in slots, NQNs, addresses or paths
+var backendAnnotationUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._:/\[\]-]`)
+
+// sanitizeBackendAnnotation replaces unexpected characters with '_' and truncates the value
+func sanitizeBackendAnnotation(value string) string {
+	value = backendAnnotationUnsafeChars.ReplaceAllString(value, "_")
+	if len(value) > maxBackendAnnotationLength {
+		value = value[:maxBackendAnnotationLength]
+	}
+	return value
+}
+
+// backendAnnotations returns the backend detail annotations of volume on backend
+func backendAnnotations(backend *rds.Backend, volume *rds.VolumeInfo) map[string]string {
+	annotations := map[string]string{
+		AnnotationBackendSlot:    volume.Slot,
+		AnnotationNQN:            volume.NVMETCPNQN,
+		AnnotationBackendAddress: backend.Client.GetAddress(),
+	}
+	if volume.FilePath != "" {
+		annotations[AnnotationBasePath] = filepath.Dir(volume.FilePath)
+	}
+	for key, value := range annotations {
+		annotations[key] = sanitizeBackendAnnotation(value)
+	}
+	return annotations
+}
+
+// annotateBackendDetails records where volumeID lives on its PV when it is attached, so
+// dashboards and support can see it without RDS access. The PV is only updated if a
+// value changed, and not even read if the details are those last found on it. Best
+// effort - failures are logged but don't affect the attach.
+func (cs *ControllerServer) annotateBackendDetails(ctx context.Context, backend *rds.Backend, volumeID string, volume *rds.VolumeInfo) {
+	if !cs.driver.annotateBackendDetails || cs.driver.k8sClient == nil {
+		return
+	}
+
+	annotations := backendAnnotations(backend, volume)
+	if annotated, ok := cs.driver.backendAnnotated.Load(volumeID); ok && maps.Equal(annotated.(map[string]string), annotations) {
+		return
+	}
+	pvs := cs.driver.k8sClient.CoreV1().PersistentVolumes()
+	tooLarge := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv, err := pvs.Get(ctx, volumeID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		tooLarge = false
+		changed := false
+		for key, value := range annotations {
+			if pv.Annotations[key] != value {
+				if pv.Annotations == nil {
+					pv.Annotations = map[string]string{}
+				}
+				pv.Annotations[key] = value
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		if size := utils.MetadataSize(pv.Annotations); size > cs.driver.metadataLimits.HardLimit() {
+			klog.Warningf("Not annotating backend details on PV %s: annotations would be %d bytes, above the %d byte limit",
+				volumeID, size, cs.driver.metadataLimits.HardLimit())
+			tooLarge = true
+			return nil
+		}
+		_, err = pvs.Update(ctx, pv, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Warningf("Failed to annotate backend details on PV %s: %v", volumeID, err)
+		}
+		return
+	}
+	if tooLarge {
+		return
+	}
+	cs.driver.backendAnnotated.Store(volumeID, annotations)
+	klog.V(4).Infof("Backend details of volume %s are on its PV", volumeID)
+}
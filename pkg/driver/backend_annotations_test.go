@@ -0,0 +1,100 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+)
+
+// TestControllerPublishVolume_BackendAnnotations tests that attaching a volume records its
+// backend details on the PV, and that they are not mistaken for attachment state
+func TestControllerPublishVolume_BackendAnnotations(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t, testNode("node-1"))
+	cs.driver.annotateBackendDetails = true
+	createVolumeWithPV(t, cs, testVolumeID1)
+
+	_, err := cs.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+		VolumeId:         testVolumeID1,
+		NodeId:           "node-1",
+		VolumeCapability: createFilesystemVolumeCapability(),
+	})
+	if err != nil {
+		t.Fatalf("ControllerPublishVolume failed: %v", err)
+	}
+
+	volume, err := mockRDS.GetVolume(testVolumeID1)
+	if err != nil {
+		t.Fatalf("volume not created: %v", err)
+	}
+	pv, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Get(ctx, testVolumeID1, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get PV: %v", err)
+	}
+	want := map[string]string{
+		AnnotationBackendSlot:    testVolumeID1,
+		AnnotationNQN:            volume.NVMETCPNQN,
+		AnnotationBackendAddress: "10.0.0.1",
+		AnnotationBasePath:       "/storage-pool/metal-csi",
+	}
+	for key, value := range want {
+		if pv.Annotations[key] != value {
+			t.Errorf("annotation %s = %q, want %q", key, pv.Annotations[key], value)
+		}
+	}
+	// The slot that renamed-slot lookups rely on is left alone
+	if slot, ok := pv.Annotations[AnnotationSlot]; ok {
+		t.Errorf("expected no %s annotation, got %q", AnnotationSlot, slot)
+	}
+
+	// Attaching again with the same details does not read the PV
+	client := cs.driver.k8sClient.(*fake.Clientset)
+	client.ClearActions()
+	cs.annotateBackendDetails(ctx, cs.driver.backendsFor(ctx).Default(), testVolumeID1, volume)
+	for _, action := range client.Actions() {
+		if action.GetResource().Resource == "persistentvolumes" {
+			t.Errorf("expected no PV request for unchanged details, got %s", action.GetVerb())
+		}
+	}
+
+	// Rebuilding from the PV annotations only sees the attachment annotations
+	am := attachment.NewAttachmentManager(cs.driver.k8sClient)
+	if err := am.RebuildStateFromAnnotations(ctx); err != nil {
+		t.Fatalf("RebuildStateFromAnnotations failed: %v", err)
+	}
+	attachments := am.ListAttachments()
+	if len(attachments) != 1 || attachments[testVolumeID1] == nil || attachments[testVolumeID1].NodeID != "node-1" {
+		t.Errorf("expected only %s attached to node-1, got %v", testVolumeID1, attachments)
+	}
+
+	// Without VolumeAttachments there is nothing to rebuild, whatever the annotations say
+	am = attachment.NewAttachmentManager(cs.driver.k8sClient)
+	if err := am.RebuildState(ctx); err != nil {
+		t.Fatalf("RebuildState failed: %v", err)
+	}
+	if attachments := am.ListAttachments(); len(attachments) != 0 {
+		t.Errorf("expected no attachments, got %v", attachments)
+	}
+}
+
+func TestSanitizeBackendAnnotation(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "nqn.2000-02.com.mikrotik:pvc-1", want: "nqn.2000-02.com.mikrotik:pvc-1"},
+		{value: "[fd00::1]", want: "[fd00::1]"},
+		{value: "/storage-pool/metal csi", want: "/storage-pool/metal_csi"},
+		{value: "rds\n\"evil\"", want: "rds__evil_"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeBackendAnnotation(tt.value); got != tt.want {
+			t.Errorf("sanitizeBackendAnnotation(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
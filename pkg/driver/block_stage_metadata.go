@@ -0,0 +1,75 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// blockStageMetadataFile is written to the staging path of block volumes when
+// --block-stage-metadata is set
+const blockStageMetadataFile = "rds-csi-block.json"
+
+// blockStageMetadata records what NodeStageVolume connected for a block volume. Block
+// volumes otherwise leave nothing at the staging path and are found again by the NQN
+// derived from the volume ID, which static volumes with other IDs cannot use.
+type blockStageMetadata struct {
+	VolumeID   string `json:"volumeID"`
+	NQN        string `json:"nqn"`
+	DevicePath string `json:"devicePath"` // Device at staging time, for debugging; publish resolves the NQN again
+}
+
+// writeBlockStageMetadata writes the metadata file atomically, creating the staging path if needed
+func writeBlockStageMetadata(stagingPath string, metadata blockStageMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stagingPath, 0750); err != nil {
+		return fmt.Errorf("failed to create staging path: %w", err)
+	}
+
+	path := filepath.Join(stagingPath, blockStageMetadataFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// readBlockStageMetadata reads the metadata NodeStageVolume wrote for volumeID. It returns
+// an error wrapping os.ErrNotExist if there is none.
+func readBlockStageMetadata(stagingPath, volumeID string) (*blockStageMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(stagingPath, blockStageMetadataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata blockStageMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid block stage metadata: %w", err)
+	}
+	if metadata.VolumeID != volumeID {
+		return nil, fmt.Errorf("block stage metadata is for volume %q, not %q", metadata.VolumeID, volumeID)
+	}
+	if err := utils.ValidateNQN(metadata.NQN); err != nil {
+		return nil, fmt.Errorf("invalid NQN in block stage metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// removeBlockStageMetadata removes the metadata file, if any
+func removeBlockStageMetadata(stagingPath string) error {
+	err := os.Remove(filepath.Join(stagingPath, blockStageMetadataFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
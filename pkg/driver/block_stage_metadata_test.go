@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// staticBlockVolumeID is an imported volume ID from which no NQN can be derived
+const (
+	staticBlockVolumeID = "12345678-1234-1234-1234-123456789012"
+	staticBlockNQN      = "nqn.2000-02.com.mikrotik:legacy-disk-01"
+)
+
+func testBlockMetadataNodeServer(devicePath string, enabled bool) (*NodeServer, *mockNVMEConnector) {
+	connector := &mockNVMEConnector{devicePath: devicePath}
+	return &NodeServer{
+		driver: &Driver{
+			name:               "rds.csi.srvlab.io",
+			version:            "test",
+			metrics:            observability.NewMetrics(),
+			blockStageMetadata: enabled,
+		},
+		mounter:        &mockMounter{},
+		nvmeConn:       connector,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}, connector
+}
+
+func stageStaticBlockVolume(t *testing.T, ns *NodeServer, stagingPath string) {
+	t.Helper()
+	_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          staticBlockVolumeID,
+		StagingTargetPath: stagingPath,
+		VolumeCapability:  createBlockVolumeCapability(),
+		VolumeContext: map[string]string{
+			"nqn":         staticBlockNQN,
+			"nvmeAddress": "10.42.68.1",
+			"nvmePort":    "4420",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+}
+
+func publishStaticBlockVolume(ns *NodeServer, stagingPath, targetPath string) error {
+	// Like the CO does for a static PV without the nqn attribute at publish
+	_, err := ns.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+		VolumeId:          staticBlockVolumeID,
+		StagingTargetPath: stagingPath,
+		TargetPath:        targetPath,
+		VolumeCapability:  createBlockVolumeCapability(),
+	})
+	// Without root, mknod fails after the device was resolved
+	if err != nil && strings.Contains(err.Error(), "operation not permitted") {
+		return nil
+	}
+	return err
+}
+
+func TestBlockStageMetadata_PublishFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	stagingPath := filepath.Join(tmpDir, "staging")
+	devicePath := filepath.Join(tmpDir, "mock-nvme0n1")
+	if err := os.WriteFile(devicePath, []byte{}, 0600); err != nil {
+		t.Fatalf("failed to create mock device: %v", err)
+	}
+	ns, connector := testBlockMetadataNodeServer(devicePath, true)
+
+	stageStaticBlockVolume(t, ns, stagingPath)
+	metadata, err := readBlockStageMetadata(stagingPath, staticBlockVolumeID)
+	if err != nil {
+		t.Fatalf("expected block stage metadata: %v", err)
+	}
+	if metadata.NQN != staticBlockNQN || metadata.DevicePath != devicePath {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+
+	if err := publishStaticBlockVolume(ns, stagingPath, filepath.Join(tmpDir, "target")); err != nil {
+		t.Fatalf("NodePublishVolume failed: %v", err)
+	}
+
+	// Unstage finds the NQN the same way and removes the file
+	if _, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+		VolumeId:          staticBlockVolumeID,
+		StagingTargetPath: stagingPath,
+	}); err != nil {
+		t.Fatalf("NodeUnstageVolume failed: %v", err)
+	}
+	if !connector.disconnectCalled {
+		t.Error("NVMe disconnect should be called")
+	}
+	if _, err := os.Stat(filepath.Join(stagingPath, blockStageMetadataFile)); !os.IsNotExist(err) {
+		t.Errorf("expected metadata file to be removed, stat error: %v", err)
+	}
+}
+
+func TestBlockStageMetadata_DisabledByDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+	stagingPath := filepath.Join(tmpDir, "staging")
+	ns, _ := testBlockMetadataNodeServer("/dev/nvme0n1", false)
+
+	stageStaticBlockVolume(t, ns, stagingPath)
+	if _, err := os.Stat(filepath.Join(stagingPath, blockStageMetadataFile)); !os.IsNotExist(err) {
+		t.Errorf("expected no metadata file without --block-stage-metadata, stat error: %v", err)
+	}
+
+	err := publishStaticBlockVolume(ns, stagingPath, filepath.Join(tmpDir, "target"))
+	if err == nil || !strings.Contains(err.Error(), "no block stage metadata") {
+		t.Errorf("expected publish to fail without metadata, got %v", err)
+	}
+}
+
+func TestReadBlockStageMetadata(t *testing.T) {
+	stagingPath := t.TempDir()
+	if _, err := readBlockStageMetadata(stagingPath, staticBlockVolumeID); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+
+	if err := writeBlockStageMetadata(stagingPath, blockStageMetadata{VolumeID: "other-volume", NQN: staticBlockNQN}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := readBlockStageMetadata(stagingPath, staticBlockVolumeID); err == nil {
+		t.Error("expected metadata of another volume to be rejected")
+	}
+
+	if err := writeBlockStageMetadata(stagingPath, blockStageMetadata{VolumeID: staticBlockVolumeID, NQN: "nqn;rm -rf /"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := readBlockStageMetadata(stagingPath, staticBlockVolumeID); err == nil {
+		t.Error("expected an invalid NQN to be rejected")
+	}
+
+	if err := removeBlockStageMetadata(stagingPath); err != nil {
+		t.Errorf("remove failed: %v", err)
+	}
+	if err := removeBlockStageMetadata(stagingPath); err != nil {
+		t.Errorf("removing a missing file should succeed: %v", err)
+	}
+}
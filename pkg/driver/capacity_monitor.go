@@ -0,0 +1,159 @@
+package driver
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+const (
+	// DefaultCapacityCheckInterval is how often the capacity monitor queries RDS
+	DefaultCapacityCheckInterval = 5 * time.Minute
+
+	// DefaultLowCapacityThresholdPercent is the free space percentage below which a
+	// low capacity event is posted
+	DefaultLowCapacityThresholdPercent = 10.0
+)
+
+// CapacityMonitorConfig configures the controller's capacity monitor
+type CapacityMonitorConfig struct {
+	Backends         *rds.ClientRegistry // Every registered backend is queried
+	BasePaths        []string            // Volume base paths to query, as CreateVolume uses them
+	Interval         time.Duration       // Default: DefaultCapacityCheckInterval
+	ThresholdPercent float64             // Free space percentage to warn below (0 disables events)
+
+	// ConfigMap that low capacity events are posted on. Without it, or without an
+	// event poster, low capacity is only logged.
+	EventNamespace string
+	EventName      string
+	EventPoster    *EventPoster
+
+	Metrics *observability.Metrics // optional
+}
+
+// CapacityMonitor periodically queries the free space of each volume base path on each
+// RDS backend, the same filesystem-level figure CreateVolume and GetCapacity use, and
+// warns before the pool fills. Results are exported as rds_csi_pool_available_bytes and
+// rds_csi_pool_total_bytes; crossing the threshold posts a LowCapacity event, and
+// recovering posts CapacityRecovered.
+type CapacityMonitor struct {
+	config CapacityMonitorConfig
+	low    map[capacityPool]bool // pools currently below the threshold, owned by the check loop
+	cancel context.CancelFunc
+
+	// unregisterMetrics drops the pool gauges the monitor set up (nil without metrics)
+	unregisterMetrics func()
+}
+
+// NewCapacityMonitor creates a capacity monitor
+func NewCapacityMonitor(config CapacityMonitorConfig) *CapacityMonitor {
+	if config.Interval <= 0 {
+		config.Interval = DefaultCapacityCheckInterval
+	}
+	return &CapacityMonitor{
+		config: config,
+		low:    make(map[capacityPool]bool),
+	}
+}
+
+// Start runs the monitor until ctx is cancelled or Stop is called
+func (m *CapacityMonitor) Start(ctx context.Context) {
+	if m.config.Metrics != nil {
+		m.unregisterMetrics = m.config.Metrics.EnableCapacityMetrics()
+	}
+	klog.Infof("Starting capacity monitor for %v on backends %v (interval=%v, threshold=%.1f%%)",
+		m.config.BasePaths, m.config.Backends.Names(), m.config.Interval, m.config.ThresholdPercent)
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(m.config.Interval)
+		defer ticker.Stop()
+
+		m.check(ctx)
+		for {
+			select {
+			case <-ticker.C:
+				m.check(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops a started monitor
+func (m *CapacityMonitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.unregisterMetrics != nil {
+		m.unregisterMetrics()
+	}
+}
+
+// check queries every base path on every backend once, updating the gauges and posting
+// events for pools that crossed the threshold since the previous check
+func (m *CapacityMonitor) check(ctx context.Context) {
+	for _, backend := range m.config.Backends.Backends() {
+		for _, basePath := range m.config.BasePaths {
+			m.checkPool(ctx, capacityPool{backend: backend, basePath: basePath})
+		}
+	}
+}
+
+func (m *CapacityMonitor) checkPool(ctx context.Context, pool capacityPool) {
+	name := pool.String()
+	capacity, err := pool.backend.Client.GetCapacity(pool.basePath)
+	if err != nil {
+		// Keep the previous state so a failed query does not post a recovery
+		klog.Warningf("Capacity monitor: failed to query capacity of %s: %v", name, err)
+		return
+	}
+	if m.config.Metrics != nil {
+		m.config.Metrics.RecordPoolCapacity(pool.backend.Name, pool.basePath, capacity.TotalBytes, capacity.FreeBytes)
+	}
+	klog.V(4).Infof("Capacity monitor: %s total=%d free=%d", name, capacity.TotalBytes, capacity.FreeBytes)
+
+	if m.config.ThresholdPercent <= 0 || capacity.TotalBytes <= 0 {
+		return
+	}
+	low := freePercent(capacity.FreeBytes, capacity.TotalBytes) < m.config.ThresholdPercent
+	switch {
+	case low && !m.low[pool]:
+		klog.Warningf("RDS free space under %s is %.1f GiB of %.1f GiB, below the %.1f%% threshold",
+			name, bytesToGiB(capacity.FreeBytes), bytesToGiB(capacity.TotalBytes), m.config.ThresholdPercent)
+		if m.canPostEvents() {
+			_ = m.config.EventPoster.PostLowCapacity(ctx, m.config.EventNamespace, m.config.EventName,
+				name, capacity.FreeBytes, capacity.TotalBytes, m.config.ThresholdPercent)
+		}
+	case !low && m.low[pool]:
+		klog.Infof("RDS free space under %s recovered to %.1f GiB of %.1f GiB",
+			name, bytesToGiB(capacity.FreeBytes), bytesToGiB(capacity.TotalBytes))
+		if m.canPostEvents() {
+			_ = m.config.EventPoster.PostCapacityRecovered(ctx, m.config.EventNamespace, m.config.EventName,
+				name, capacity.FreeBytes, capacity.TotalBytes)
+		}
+	}
+	m.low[pool] = low
+}
+
+func (m *CapacityMonitor) canPostEvents() bool {
+	return m.config.EventPoster != nil && m.config.EventNamespace != "" && m.config.EventName != ""
+}
+
+// freePercent returns available as a percentage of total
+func freePercent(available, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(available) * 100 / float64(total)
+}
+
+// bytesToGiB converts bytes to GiB for log and event messages
+func bytesToGiB(bytes int64) float64 {
+	return float64(bytes) / (1 << 30)
+}
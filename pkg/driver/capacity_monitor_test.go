@@ -0,0 +1,174 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+const testPoolBytes = 1 << 40 // 1 TiB
+
+// capacityTestMonitor returns a monitor for one base path whose events go to a fake
+// recorder, with the rds-csi/rds-csi-config ConfigMap as the event object
+func capacityTestMonitor(t *testing.T, eventName string) (*CapacityMonitor, *rds.MockClient, *record.FakeRecorder, *observability.Metrics) {
+	t.Helper()
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "rds-csi-config", Namespace: "rds-csi"}}
+	poster := NewEventPoster(fake.NewSimpleClientset(cm))
+	recorder := record.NewFakeRecorder(10)
+	poster.recorder = recorder
+
+	mockRDS := rds.NewMockClient()
+	metrics := observability.NewMetrics()
+	metrics.EnableCapacityMetrics()
+	monitor := NewCapacityMonitor(CapacityMonitorConfig{
+		Backends:         rds.NewClientRegistry(mockRDS),
+		BasePaths:        []string{"/storage-pool/metal-csi"},
+		ThresholdPercent: 10,
+		EventNamespace:   "rds-csi",
+		EventName:        eventName,
+		EventPoster:      poster,
+		Metrics:          metrics,
+	})
+	return monitor, mockRDS, recorder, metrics
+}
+
+// setFreePercent makes the mock report percent of a 1 TiB pool as free
+func setFreePercent(mockRDS *rds.MockClient, percent int64) {
+	free := int64(testPoolBytes) * percent / 100
+	mockRDS.SetCapacity(&rds.CapacityInfo{TotalBytes: testPoolBytes, FreeBytes: free, UsedBytes: testPoolBytes - free})
+}
+
+// drainEvents returns the events recorded since the last call
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestCapacityMonitor_LowCapacityEvents(t *testing.T) {
+	monitor, mockRDS, recorder, _ := capacityTestMonitor(t, "rds-csi-config")
+	ctx := context.Background()
+
+	steps := []struct {
+		name        string
+		freePercent int64
+		queryErr    error
+		wantEvent   string // prefix "<type> <reason>", empty for no event
+	}{
+		{name: "plenty of space", freePercent: 50},
+		{name: "drops below threshold", freePercent: 5, wantEvent: "Warning LowCapacity"},
+		{name: "stays low", freePercent: 4},
+		{name: "query fails while low", freePercent: 4, queryErr: errors.New("ssh: connection lost")},
+		{name: "still low after failed query", freePercent: 6},
+		{name: "recovers", freePercent: 30, wantEvent: "Normal CapacityRecovered"},
+		{name: "stays recovered", freePercent: 30},
+		{name: "drops again", freePercent: 9, wantEvent: "Warning LowCapacity"},
+	}
+	for _, step := range steps {
+		setFreePercent(mockRDS, step.freePercent)
+		if step.queryErr != nil {
+			mockRDS.SetError(step.queryErr)
+		}
+		monitor.check(ctx)
+
+		events := drainEvents(recorder)
+		switch {
+		case step.wantEvent == "" && len(events) > 0:
+			t.Errorf("%s: expected no event, got %v", step.name, events)
+		case step.wantEvent != "" && (len(events) != 1 || !strings.HasPrefix(events[0], step.wantEvent)):
+			t.Errorf("%s: expected one %q event, got %v", step.name, step.wantEvent, events)
+		case step.wantEvent != "" && !strings.Contains(events[0], "/storage-pool/metal-csi"):
+			t.Errorf("%s: event should name the base path: %s", step.name, events[0])
+		}
+	}
+}
+
+func TestCapacityMonitor_RecordsGauges(t *testing.T) {
+	monitor, mockRDS, _, metrics := capacityTestMonitor(t, "rds-csi-config")
+	setFreePercent(mockRDS, 25)
+	monitor.check(context.Background())
+
+	body := scrapeMetrics(t, metrics)
+	for _, want := range []string{
+		`rds_csi_pool_total_bytes{backend="default",basePath="/storage-pool/metal-csi"} 1.099511627776e+12`,
+		`rds_csi_pool_available_bytes{backend="default",basePath="/storage-pool/metal-csi"} 2.74877906944e+11`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
+
+func TestCapacityMonitor_AdditionalBackends(t *testing.T) {
+	monitor, mockRDS, recorder, metrics := capacityTestMonitor(t, "rds-csi-config")
+	array2 := rds.NewMockClient()
+	if err := monitor.config.Backends.Register(&rds.Backend{Name: "array2", Client: array2}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	setFreePercent(mockRDS, 50)
+	setFreePercent(array2, 5)
+	monitor.check(context.Background())
+
+	events := drainEvents(recorder)
+	if len(events) != 1 || !strings.HasPrefix(events[0], "Warning LowCapacity") || !strings.Contains(events[0], "array2:/storage-pool/metal-csi") {
+		t.Errorf("expected one LowCapacity event for array2, got %v", events)
+	}
+	body := scrapeMetrics(t, metrics)
+	for _, want := range []string{
+		`rds_csi_pool_available_bytes{backend="default",basePath="/storage-pool/metal-csi"} 5.49755813888e+11`,
+		`rds_csi_pool_available_bytes{backend="array2",basePath="/storage-pool/metal-csi"} 5.4975581388e+10`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
+
+func TestCapacityMonitor_NoEventWithoutConfigMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventName string
+	}{
+		{name: "no ConfigMap configured", eventName: ""},
+		{name: "ConfigMap does not exist", eventName: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor, mockRDS, recorder, _ := capacityTestMonitor(t, tt.eventName)
+			setFreePercent(mockRDS, 1)
+			monitor.check(context.Background())
+			if events := drainEvents(recorder); len(events) != 0 {
+				t.Errorf("expected no events, got %v", events)
+			}
+			pool := capacityPool{backend: monitor.config.Backends.Default(), basePath: "/storage-pool/metal-csi"}
+			if !monitor.low[pool] {
+				t.Error("low capacity should still be tracked")
+			}
+		})
+	}
+}
+
+func TestCapacityMonitor_ThresholdDisabled(t *testing.T) {
+	monitor, mockRDS, recorder, _ := capacityTestMonitor(t, "rds-csi-config")
+	monitor.config.ThresholdPercent = 0
+	setFreePercent(mockRDS, 1)
+	monitor.check(context.Background())
+	if events := drainEvents(recorder); len(events) != 0 {
+		t.Errorf("expected no events with the threshold disabled, got %v", events)
+	}
+}
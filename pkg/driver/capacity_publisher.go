@@ -0,0 +1,278 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+const (
+	// DefaultStorageCapacityInterval is how often the controller publishes CSIStorageCapacity objects
+	DefaultStorageCapacityInterval = time.Minute
+
+	// Labels of the CSIStorageCapacity objects the controller owns. Objects with the
+	// managed-by label that no StorageClass maps to any more are deleted.
+	capacityManagedByLabel = "app.kubernetes.io/managed-by"
+	capacityManagedByValue = "rds-csi-controller"
+	capacityBackendLabel   = "rds.csi.srvlab.io/backend"
+
+	// capacityNamePrefix starts the name of every published object
+	capacityNamePrefix = "rds-csi-"
+
+	// capacityLeaseName is the Lease in the publisher's namespace that elects the one
+	// controller replica publishing
+	capacityLeaseName = "rds-csi-capacity-publisher"
+
+	// Leader election timing of the publisher, the client-go defaults
+	capacityLeaseDuration = 15 * time.Second
+	capacityRenewDeadline = 10 * time.Second
+	capacityRetryPeriod   = 2 * time.Second
+)
+
+// CapacityPublisherConfig configures the controller's CSIStorageCapacity publisher
+type CapacityPublisherConfig struct {
+	K8sClient  kubernetes.Interface
+	Backends   *rds.ClientRegistry
+	DriverName string
+	Namespace  string        // Namespace the CSIStorageCapacity objects and the lease are in
+	Interval   time.Duration // Default: DefaultStorageCapacityInterval
+	Identity   string        // Holder identity in the lease (default: the hostname, i.e. the pod name)
+}
+
+// CapacityPublisher keeps one CSIStorageCapacity object per StorageClass of this driver
+// up to date with the free space of the backend and base path the class provisions
+// from, so the scheduler's capacity checks work without the external-provisioner's
+// capacity feature. The objects cover all nodes, as every node reaches RDS over
+// NVMe/TCP. Objects of deleted StorageClasses, or of classes naming a backend that is no
+// longer configured, are deleted. Only the controller replica holding the publisher's
+// lease publishes.
+type CapacityPublisher struct {
+	config CapacityPublisherConfig
+	cancel context.CancelFunc
+}
+
+// NewCapacityPublisher creates a CSIStorageCapacity publisher
+func NewCapacityPublisher(config CapacityPublisherConfig) *CapacityPublisher {
+	if config.Interval <= 0 {
+		config.Interval = DefaultStorageCapacityInterval
+	}
+	if config.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = uuid.NewString()
+		}
+		config.Identity = hostname
+	}
+	return &CapacityPublisher{config: config}
+}
+
+// Start runs the publisher until ctx is cancelled or Stop is called. It publishes while
+// this replica holds the lease, and campaigns for it again after losing it.
+func (p *CapacityPublisher) Start(ctx context.Context) {
+	klog.Infof("Starting CSIStorageCapacity publisher in namespace %s (interval=%v, identity=%s)",
+		p.config.Namespace, p.config.Interval, p.config.Identity)
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: capacityLeaseName, Namespace: p.config.Namespace},
+		Client:     p.config.K8sClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: p.config.Identity},
+	}
+	go func() {
+		for ctx.Err() == nil {
+			leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+				Lock:            lock,
+				LeaseDuration:   capacityLeaseDuration,
+				RenewDeadline:   capacityRenewDeadline,
+				RetryPeriod:     capacityRetryPeriod,
+				ReleaseOnCancel: true,
+				Name:            capacityLeaseName,
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStartedLeading: p.run,
+					OnStoppedLeading: func() {
+						klog.Infof("CSIStorageCapacity publisher %s no longer holds lease %s", p.config.Identity, capacityLeaseName)
+					},
+				},
+			})
+		}
+	}()
+}
+
+// run publishes every interval until ctx is cancelled, when the lease is lost
+func (p *CapacityPublisher) run(ctx context.Context) {
+	klog.Infof("CSIStorageCapacity publisher %s holds lease %s, publishing", p.config.Identity, capacityLeaseName)
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	p.publishAndLog(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			p.publishAndLog(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops a started publisher
+func (p *CapacityPublisher) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+func (p *CapacityPublisher) publishAndLog(ctx context.Context) {
+	if err := p.publish(ctx); err != nil {
+		klog.Warningf("CSIStorageCapacity publisher: %v", err)
+	}
+}
+
+// capacityObjectName returns the deterministic name of the object for a StorageClass.
+// StorageClass names may be longer than fits after a prefix, so the name is hashed.
+func capacityObjectName(storageClass string) string {
+	sum := sha256.Sum256([]byte(storageClass))
+	return capacityNamePrefix + hex.EncodeToString(sum[:])[:16]
+}
+
+// capacityPool is a backend base path a StorageClass provisions from
+type capacityPool struct {
+	backend  *rds.Backend
+	basePath string
+}
+
+// String names the pool in logs and events: the base path, prefixed with the backend
+// name for additional backends
+func (p capacityPool) String() string {
+	if p.backend.Name == rds.DefaultBackendName {
+		return p.basePath
+	}
+	return p.backend.Name + ":" + p.basePath
+}
+
+// publish creates, updates and deletes the published objects once. A pool whose capacity
+// cannot be queried keeps its objects as they are.
+func (p *CapacityPublisher) publish(ctx context.Context) error {
+	classes, err := p.config.K8sClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list StorageClasses: %w", err)
+	}
+	selector := capacityManagedByLabel + "=" + capacityManagedByValue
+	existing, err := p.config.K8sClient.StorageV1().CSIStorageCapacities(p.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list CSIStorageCapacities: %w", err)
+	}
+	current := make(map[string]*storagev1.CSIStorageCapacity, len(existing.Items))
+	for i := range existing.Items {
+		current[existing.Items[i].Name] = &existing.Items[i]
+	}
+
+	// Query each pool once, however many classes share it
+	free := make(map[capacityPool]int64)
+	failed := make(map[capacityPool]bool)
+	keep := make(map[string]bool)
+	for _, class := range classes.Items {
+		if class.Provisioner != p.config.DriverName {
+			continue
+		}
+		backend, err := p.config.Backends.Get(class.Parameters[paramBackend])
+		if err != nil {
+			klog.V(2).Infof("CSIStorageCapacity publisher: skipping StorageClass %s: %v", class.Name, err)
+			continue
+		}
+		pool := capacityPool{backend: backend, basePath: defaultVolumeBasePath}
+		if path, ok := class.Parameters[paramVolumePath]; ok {
+			pool.basePath = path
+		}
+
+		name := capacityObjectName(class.Name)
+		keep[name] = true
+		if failed[pool] {
+			continue
+		}
+		if _, ok := free[pool]; !ok {
+			capacity, err := backend.Client.GetCapacity(pool.basePath)
+			if err != nil {
+				klog.Warningf("CSIStorageCapacity publisher: failed to query capacity of %s on backend %s: %v", pool.basePath, backend.Name, err)
+				failed[pool] = true
+				continue
+			}
+			free[pool] = capacity.FreeBytes
+		}
+
+		if err := p.apply(ctx, current[name], name, class.Name, backend.Name, free[pool]); err != nil {
+			klog.Warningf("CSIStorageCapacity publisher: %v", err)
+		}
+	}
+
+	for name := range current {
+		if keep[name] {
+			continue
+		}
+		err := p.config.K8sClient.StorageV1().CSIStorageCapacities(p.config.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("CSIStorageCapacity publisher: failed to delete stale %s: %v", name, err)
+			continue
+		}
+		klog.V(2).Infof("CSIStorageCapacity publisher: deleted stale %s (StorageClass %s)", name, current[name].StorageClassName)
+	}
+	return nil
+}
+
+// apply creates the object for a StorageClass, or updates existing if its capacity or
+// labels changed
+func (p *CapacityPublisher) apply(ctx context.Context, existing *storagev1.CSIStorageCapacity, name, storageClass, backend string, freeBytes int64) error {
+	capacity := resource.NewQuantity(freeBytes, resource.BinarySI)
+	labels := map[string]string{
+		capacityManagedByLabel: capacityManagedByValue,
+		capacityBackendLabel:   backend,
+	}
+	client := p.config.K8sClient.StorageV1().CSIStorageCapacities(p.config.Namespace)
+
+	if existing == nil {
+		object := &storagev1.CSIStorageCapacity{
+			ObjectMeta:       metav1.ObjectMeta{Name: name, Namespace: p.config.Namespace, Labels: labels},
+			StorageClassName: storageClass,
+			NodeTopology:     &metav1.LabelSelector{}, // every node reaches RDS
+			Capacity:         capacity,
+		}
+		if _, err := client.Create(ctx, object, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create %s for StorageClass %s: %w", name, storageClass, err)
+		}
+		klog.V(2).Infof("CSIStorageCapacity publisher: created %s for StorageClass %s (%s free)", name, storageClass, capacity)
+		return nil
+	}
+
+	if existing.Capacity != nil && existing.Capacity.Cmp(*capacity) == 0 &&
+		existing.StorageClassName == storageClass && existing.Labels[capacityBackendLabel] == backend {
+		return nil
+	}
+	if existing.StorageClassName != storageClass {
+		// StorageClassName is immutable; a hash collision is the only way here
+		return fmt.Errorf("%s belongs to StorageClass %s, not %s", name, existing.StorageClassName, storageClass)
+	}
+	updated := existing.DeepCopy()
+	updated.Labels = labels
+	updated.Capacity = capacity
+	if _, err := client.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update %s for StorageClass %s: %w", name, storageClass, err)
+	}
+	klog.V(4).Infof("CSIStorageCapacity publisher: updated %s for StorageClass %s (%s free)", name, storageClass, capacity)
+	return nil
+}
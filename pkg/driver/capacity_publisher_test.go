@@ -0,0 +1,229 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+func testStorageClass(name, provisioner string, params map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: name},
+		Provisioner: provisioner,
+		Parameters:  params,
+	}
+}
+
+// capacityTestPublisher returns a publisher over a default backend and a "bulk" backend
+// whose mocks report defaultFree and bulkFree bytes free
+func capacityTestPublisher(t *testing.T, client *fake.Clientset, defaultFree, bulkFree int64) (*CapacityPublisher, *rds.MockClient, *rds.MockClient) {
+	t.Helper()
+	defaultRDS, bulkRDS := rds.NewMockClient(), rds.NewMockClient()
+	defaultRDS.SetCapacity(&rds.CapacityInfo{TotalBytes: testPoolBytes, FreeBytes: defaultFree})
+	bulkRDS.SetCapacity(&rds.CapacityInfo{TotalBytes: testPoolBytes, FreeBytes: bulkFree})
+	backends := rds.NewClientRegistry(defaultRDS)
+	if err := backends.Register(&rds.Backend{Name: "bulk", Client: bulkRDS}); err != nil {
+		t.Fatalf("failed to register backend: %v", err)
+	}
+	publisher := NewCapacityPublisher(CapacityPublisherConfig{
+		K8sClient:  client,
+		Backends:   backends,
+		DriverName: DriverName,
+		Namespace:  "rds-csi",
+	})
+	return publisher, defaultRDS, bulkRDS
+}
+
+// publishedCapacities returns the free bytes of the objects the publisher owns by StorageClass
+func publishedCapacities(t *testing.T, client *fake.Clientset) map[string]int64 {
+	t.Helper()
+	list, err := client.StorageV1().CSIStorageCapacities("rds-csi").List(context.Background(), metav1.ListOptions{
+		LabelSelector: capacityManagedByLabel + "=" + capacityManagedByValue,
+	})
+	if err != nil {
+		t.Fatalf("failed to list CSIStorageCapacities: %v", err)
+	}
+	capacities := make(map[string]int64)
+	for _, object := range list.Items {
+		if object.Name != capacityObjectName(object.StorageClassName) {
+			t.Errorf("object %s for StorageClass %s has an unexpected name", object.Name, object.StorageClassName)
+		}
+		capacities[object.StorageClassName] = object.Capacity.Value()
+	}
+	return capacities
+}
+
+func TestCapacityPublisher_Create(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		testStorageClass("rds-fast", DriverName, nil),
+		testStorageClass("rds-bulk", DriverName, map[string]string{paramBackend: "bulk"}),
+		testStorageClass("rds-unknown", DriverName, map[string]string{paramBackend: "gone"}),
+		testStorageClass("local-path", "rancher.io/local-path", nil),
+	)
+	publisher, _, _ := capacityTestPublisher(t, client, 100<<30, 500<<30)
+
+	if err := publisher.publish(context.Background()); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	got := publishedCapacities(t, client)
+	want := map[string]int64{"rds-fast": 100 << 30, "rds-bulk": 500 << 30}
+	if len(got) != len(want) || got["rds-fast"] != want["rds-fast"] || got["rds-bulk"] != want["rds-bulk"] {
+		t.Errorf("published %v, want %v", got, want)
+	}
+
+	object, err := client.StorageV1().CSIStorageCapacities("rds-csi").Get(context.Background(), capacityObjectName("rds-bulk"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get object: %v", err)
+	}
+	if object.Labels[capacityManagedByLabel] != capacityManagedByValue || object.Labels[capacityBackendLabel] != "bulk" {
+		t.Errorf("unexpected labels %v", object.Labels)
+	}
+	if object.NodeTopology == nil || len(object.NodeTopology.MatchLabels) != 0 || len(object.NodeTopology.MatchExpressions) != 0 {
+		t.Errorf("expected a topology matching all nodes, got %v", object.NodeTopology)
+	}
+}
+
+func TestCapacityPublisher_Update(t *testing.T) {
+	client := fake.NewSimpleClientset(testStorageClass("rds-fast", DriverName, nil))
+	publisher, defaultRDS, _ := capacityTestPublisher(t, client, 100<<30, 0)
+	ctx := context.Background()
+
+	if err := publisher.publish(ctx); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	defaultRDS.SetCapacity(&rds.CapacityInfo{TotalBytes: testPoolBytes, FreeBytes: 40 << 30})
+	if err := publisher.publish(ctx); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if got := publishedCapacities(t, client)["rds-fast"]; got != 40<<30 {
+		t.Errorf("capacity = %d, want %d", got, int64(40<<30))
+	}
+
+	// An unchanged capacity is not written again
+	client.ClearActions()
+	if err := publisher.publish(ctx); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" || action.GetVerb() == "create" {
+			t.Errorf("unexpected %s of unchanged capacity", action.GetVerb())
+		}
+	}
+
+	// A failed query keeps the last value
+	defaultRDS.SetPersistentError(errors.New("ssh: connection lost"))
+	if err := publisher.publish(ctx); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if got := publishedCapacities(t, client)["rds-fast"]; got != 40<<30 {
+		t.Errorf("capacity after failed query = %d, want %d", got, int64(40<<30))
+	}
+}
+
+func TestCapacityPublisher_GarbageCollect(t *testing.T) {
+	foreign := &storagev1.CSIStorageCapacity{
+		ObjectMeta:       metav1.ObjectMeta{Name: "csisc-abcde", Namespace: "rds-csi", Labels: map[string]string{"csi.storage.k8s.io/managed-by": "external-provisioner"}},
+		StorageClassName: "rds-fast",
+	}
+	client := fake.NewSimpleClientset(
+		testStorageClass("rds-fast", DriverName, nil),
+		testStorageClass("rds-bulk", DriverName, map[string]string{paramBackend: "bulk"}),
+		testStorageClass("rds-old", DriverName, nil),
+		foreign,
+	)
+	publisher, _, _ := capacityTestPublisher(t, client, 100<<30, 500<<30)
+	ctx := context.Background()
+	if err := publisher.publish(ctx); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if got := publishedCapacities(t, client); len(got) != 3 {
+		t.Fatalf("expected 3 objects, got %v", got)
+	}
+
+	// Remove the bulk backend and delete the rds-old StorageClass
+	publisher.config.Backends = rds.NewClientRegistry(rds.NewMockClient())
+	if err := client.StorageV1().StorageClasses().Delete(ctx, "rds-old", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete StorageClass: %v", err)
+	}
+	if err := publisher.publish(ctx); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	list, err := client.StorageV1().CSIStorageCapacities("rds-csi").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list CSIStorageCapacities: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, object := range list.Items {
+		names[object.Name] = true
+	}
+	if len(names) != 2 || !names[capacityObjectName("rds-fast")] || !names["csisc-abcde"] {
+		t.Errorf("expected only rds-fast's object and the foreign object to remain, got %v", names)
+	}
+}
+
+func TestCapacityObjectName(t *testing.T) {
+	name := capacityObjectName("rds-fast")
+	if name != capacityObjectName("rds-fast") {
+		t.Error("name is not deterministic")
+	}
+	if name == capacityObjectName("rds-bulk") {
+		t.Error("different StorageClasses share a name")
+	}
+	if len(name) != len(capacityNamePrefix)+16 {
+		t.Errorf("unexpected name %q", name)
+	}
+}
+
+func TestCapacityPublisher_LeaderOnly(t *testing.T) {
+	client := fake.NewSimpleClientset(testStorageClass("rds-fast", DriverName, nil))
+	leader, _, _ := capacityTestPublisher(t, client, 100<<30, 0)
+	leader.config.Identity = "controller-0"
+	standby, _, _ := capacityTestPublisher(t, client, 200<<30, 0)
+	standby.config.Identity = "controller-1"
+
+	holder := func() string {
+		lease, err := client.CoordinationV1().Leases("rds-csi").Get(context.Background(), capacityLeaseName, metav1.GetOptions{})
+		if err != nil {
+			return ""
+		}
+		return *lease.Spec.HolderIdentity
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(3 * capacityLeaseDuration)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	leader.Start(context.Background())
+	waitFor("the first replica to publish", func() bool { return publishedCapacities(t, client)["rds-fast"] == 100<<30 })
+	standby.Start(context.Background())
+	defer standby.Stop()
+
+	// The standby does not publish while the other replica holds the lease
+	time.Sleep(2 * capacityRetryPeriod)
+	if got := holder(); got != "controller-0" {
+		t.Errorf("lease held by %q, want controller-0", got)
+	}
+	if got := publishedCapacities(t, client)["rds-fast"]; got != 100<<30 {
+		t.Errorf("standby published %d bytes while not leading", got)
+	}
+
+	// Stopping the leader releases the lease and the standby takes over
+	leader.Stop()
+	waitFor("the standby to take over", func() bool { return holder() == "controller-1" })
+	waitFor("the standby to publish", func() bool { return publishedCapacities(t, client)["rds-fast"] == 200<<30 })
+}
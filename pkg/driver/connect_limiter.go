@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// DefaultNVMeConnectRate is the default number of NVMe connects per second a node may
+// start, high enough that it only spreads out mass reconnects
+const DefaultNVMeConnectRate = 10.0
+
+// errConnectRateLimited marks a connect refused because no token was available in time
+var errConnectRateLimited = errors.New("NVMe connect rate limit reached")
+
+// newConnectLimiter returns a token bucket allowing perSecond connects per second with
+// a burst of one, so simultaneous connects are spaced evenly, or nil if perSecond is 0
+func newConnectLimiter(perSecond float64) *rate.Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), 1)
+}
+
+// waitConnectToken blocks until the node's connect rate limit allows another connect.
+// All volumes on the node share the limit, so when the target comes back after a reboot
+// their reconnects reach it spread out instead of all at once. It returns an error
+// wrapping errConnectRateLimited, without waiting, if no token is due before the
+// deadline of ctx.
+func (ns *NodeServer) waitConnectToken(ctx context.Context) error {
+	if ns.connectLimiter == nil {
+		return nil
+	}
+	if err := ns.connectLimiter.Wait(ctx); err != nil {
+		klog.FromContext(ctx).V(2).Info("NVMe connect not started", "err", err)
+		if ns.driver.metrics != nil {
+			ns.driver.metrics.RecordNVMeConnectRateLimited()
+		}
+		return fmt.Errorf("%w (%.3g connects/s): %v", errConnectRateLimited, float64(ns.connectLimiter.Limit()), err)
+	}
+	return nil
+}
+
+// connectWithLimit connects target like ConnectWithRetry, but takes a connect token before
+// every attempt so that the retries of all volumes are spaced out too. A target that is
+// already connected needs no nvme connect and takes no token.
+func (ns *NodeServer) connectWithLimit(ctx context.Context, target nvme.Target, config nvme.ConnectionConfig) (string, error) {
+	if ns.connectLimiter == nil {
+		return ns.nvmeConn.ConnectWithRetry(ctx, target, config)
+	}
+
+	var devicePath string
+	var lastErr error
+	err := utils.RetryWithBackoff(ctx, utils.DefaultBackoffConfig(), func() error {
+		if connected, err := ns.nvmeConn.IsConnectedWithContext(ctx, target.NQN); err != nil || !connected {
+			if err := ns.waitConnectToken(ctx); err != nil {
+				return err
+			}
+		}
+		path, err := ns.nvmeConn.ConnectWithConfig(ctx, target, config)
+		if err != nil {
+			lastErr = err
+			klog.FromContext(ctx).V(2).Info("Connection attempt failed (will retry if transient)", "nqn", target.NQN, "err", err)
+			return err
+		}
+		devicePath = path
+		return nil
+	})
+	if err != nil {
+		if lastErr != nil && !errors.Is(err, errConnectRateLimited) {
+			return "", fmt.Errorf("connection failed after retries: %w", lastErr)
+		}
+		return "", err
+	}
+	return devicePath, nil
+}
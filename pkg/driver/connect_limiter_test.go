@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+func connectsRateLimited(t *testing.T, metrics *observability.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "rds_csi_nvme_connect_rate_limited_total "); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func testConnectLimitedNodeServer(perSecond float64) (*NodeServer, *mockNVMEConnector) {
+	connector := &mockNVMEConnector{devicePath: "/dev/nvme0n1", notConnected: true}
+	return &NodeServer{
+		driver: &Driver{
+			name:    "rds.csi.srvlab.io",
+			version: "test",
+			metrics: observability.NewMetrics(),
+		},
+		mounter:        &mockMounter{},
+		nvmeConn:       connector,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+		connectLimiter: newConnectLimiter(perSecond),
+	}, connector
+}
+
+func TestWaitConnectToken_SerializesSimultaneousConnects(t *testing.T) {
+	const (
+		connects  = 5
+		perSecond = 20.0
+		spacing   = time.Second / time.Duration(perSecond)
+	)
+	ns, _ := testConnectLimitedNodeServer(perSecond)
+
+	var mu sync.Mutex
+	var started []time.Time
+	var wg sync.WaitGroup
+	for i := 0; i < connects; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ns.waitConnectToken(context.Background()); err != nil {
+				t.Errorf("waitConnectToken failed: %v", err)
+				return
+			}
+			mu.Lock()
+			started = append(started, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(started) != connects {
+		t.Fatalf("expected %d connects to start, got %d", connects, len(started))
+	}
+	sort.Slice(started, func(i, j int) bool { return started[i].Before(started[j]) })
+	// Allow for timer granularity; the limiter itself never releases a token early
+	for i := 1; i < connects; i++ {
+		if gap := started[i].Sub(started[i-1]); gap < spacing*8/10 {
+			t.Errorf("connect %d started %v after the previous one, expected about %v", i+1, gap, spacing)
+		}
+	}
+	if total := started[connects-1].Sub(started[0]); total > time.Duration(connects)*spacing*4 {
+		t.Errorf("expected %d connects to start within about %v, took %v", connects, (connects-1)*spacing, total)
+	}
+}
+
+func TestNodeStageVolume_ConnectRateLimited(t *testing.T) {
+	ns, connector := testConnectLimitedNodeServer(0.1)
+
+	// The first connect takes the only token; the next one is due in 10s
+	if _, err := ns.NodeStageVolume(context.Background(), stageRequest(filepath.Join(t.TempDir(), "staging"))); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+
+	connector.connectCalled = false
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	started := time.Now()
+	_, err := ns.NodeStageVolume(ctx, stageRequest(filepath.Join(t.TempDir(), "staging")))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable without a connect token before the deadline, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the stage to fail without waiting for the deadline, took %v", elapsed)
+	}
+	if connector.connectCalled {
+		t.Error("connect should not be attempted without a token")
+	}
+	if got := connectsRateLimited(t, ns.driver.metrics); got != "1" {
+		t.Errorf("expected 1 rate limited connect recorded, got %q", got)
+	}
+}
+
+func TestConnectWithLimit_TokenPerAttempt(t *testing.T) {
+	ns, connector := testConnectLimitedNodeServer(0.1)
+	connector.connectErr = errors.New("connection refused")
+	target := nvme.Target{Transport: "tcp", NQN: "nqn.2000-02.com.mikrotik:pvc-1"}
+
+	// The first attempt takes the only token; its retry must wait 10s for the next one
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, err := ns.connectWithLimit(ctx, target, nvme.DefaultConnectionConfig())
+	if !errors.Is(err, errConnectRateLimited) {
+		t.Fatalf("expected the retry to be rate limited, got %v", err)
+	}
+}
+
+func TestConnectWithLimit_AlreadyConnected(t *testing.T) {
+	ns, connector := testConnectLimitedNodeServer(0.1)
+	connector.notConnected = false
+	target := nvme.Target{Transport: "tcp", NQN: "nqn.2000-02.com.mikrotik:pvc-1"}
+
+	// A connected target needs no token, so repeated stages never wait
+	for i := 0; i < 3; i++ {
+		if _, err := ns.connectWithLimit(context.Background(), target, nvme.DefaultConnectionConfig()); err != nil {
+			t.Fatalf("connect %d failed: %v", i+1, err)
+		}
+	}
+	if ns.connectLimiter.Tokens() < 1 {
+		t.Error("connecting an already connected target took a token")
+	}
+}
+
+func TestNewConnectLimiter_Disabled(t *testing.T) {
+	if newConnectLimiter(0) != nil {
+		t.Error("expected no limiter for a rate of 0")
+	}
+	ns, _ := testConnectLimitedNodeServer(0)
+	for i := 0; i < 100; i++ {
+		if err := ns.waitConnectToken(context.Background()); err != nil {
+			t.Fatalf("expected unlimited connects, got %v", err)
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	stderrors "errors"
 	"fmt"
+	"maps"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -14,8 +16,11 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/security"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
@@ -34,6 +39,16 @@ const (
 	paramFSType      = "fsType"
 	paramVolumePath  = "volumePath"
 	paramNQNPrefix   = "nqnPrefix"
+	paramBackend     = "backend"
+
+	// Keys added by external-provisioner/external-snapshotter --extra-create-metadata
+	paramPVCName             = "csi.storage.k8s.io/pvc/name"
+	paramPVCNamespace        = "csi.storage.k8s.io/pvc/namespace"
+	paramSnapshotName        = "csi.storage.k8s.io/volumesnapshot/name"
+	paramSnapshotNamespace   = "csi.storage.k8s.io/volumesnapshot/namespace"
+	volumeContextDiskComment = "rdsComment"
+	volumeContextQoSTier     = "qosTier"
+	volumeContextFilePath    = "filePath"
 
 	// Minimum/maximum volume sizes
 	minVolumeSizeBytes = 1 * 1024 * 1024 * 1024         // 1 GiB
@@ -44,6 +59,10 @@ const (
 type ControllerServer struct {
 	csi.UnimplementedControllerServer
 	driver *Driver
+
+	// volumeLocks serializes DeleteVolume with ControllerPublishVolume of the same
+	// volume, so no attach slips in between the attachment check and the delete
+	volumeLocks attachment.VolumeLockManager
 }
 
 // NewControllerServer creates a new Controller service
@@ -55,7 +74,8 @@ func NewControllerServer(driver *Driver) *ControllerServer {
 
 // CreateVolume provisions a new volume on RDS
 func (cs *ControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
-	klog.V(4).Infof("CreateVolume CSI call for %s", req.GetName())
+	logger := klog.FromContext(ctx).WithValues("name", req.GetName())
+	logger.V(4).Info("CreateVolume CSI call")
 
 	// Validate request
 	if req.GetName() == "" {
@@ -66,54 +86,173 @@ func (cs *ControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 		return nil, status.Error(codes.InvalidArgument, "volume capabilities are required")
 	}
 
-	// Validate volume capabilities
-	if err := cs.validateVolumeCapabilities(req.GetVolumeCapabilities()); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid volume capabilities: %v", err)
+	// Validate volume capabilities against the access mode policy; the granted mode is
+	// recorded in the volume context for ControllerPublishVolume
+	accessMode, err := cs.grantedAccessMode(req.GetVolumeCapabilities(), req.GetParameters())
+	if err != nil {
+		return nil, err
 	}
 
-	// Get required capacity
-	requiredBytes := req.GetCapacityRange().GetRequiredBytes()
-	if requiredBytes == 0 {
-		requiredBytes = minVolumeSizeBytes
+	// Reject filesystems outside --allowed-fstypes here rather than at NodeStageVolume
+	if allowed := cs.driver.allowedFSTypes; allowed != nil {
+		for _, fsType := range requestedFSTypes(req.GetVolumeCapabilities(), req.GetParameters()) {
+			if !allowed[fsType] {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"fsType %q is not allowed by this driver (allowed: %s)", fsType, formatFSTypes(allowed))
+			}
+		}
 	}
 
-	// Enforce size limits
-	if requiredBytes < minVolumeSizeBytes {
-		requiredBytes = minVolumeSizeBytes
+	// Filesystem options (initialTrim, discard, formatPolicy, quotaBytes) only apply to filesystem volumes
+	fsOpts, err := ParseFilesystemOptions(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filesystem parameters: %v", err)
+	}
+	if fsOpts.IsSet() {
+		for _, volCap := range req.GetVolumeCapabilities() {
+			if volCap.GetBlock() != nil {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"%s, %s, %s and %s parameters are only supported for filesystem volumes, not block",
+					paramInitialTrim, paramDiscard, paramFormatPolicy, paramQuotaBytes)
+			}
+		}
+		if fsOpts.QuotaBytes > 0 {
+			for _, fsType := range requestedFSTypes(req.GetVolumeCapabilities(), req.GetParameters()) {
+				if !supportsProjectQuota(fsType) {
+					return nil, status.Errorf(codes.InvalidArgument,
+						"%s requires an ext4 or xfs filesystem, not %s", paramQuotaBytes, fsType)
+				}
+			}
+		}
+	}
+
+	// Device node options only apply to block volumes
+	blockOpts, err := ParseBlockDeviceOptions(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid block device parameters: %v", err)
+	}
+	if blockOpts.IsSet() {
+		for _, volCap := range req.GetVolumeCapabilities() {
+			if volCap.GetMount() != nil {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"%s and %s parameters are only supported for block volumes, not filesystem",
+					paramBlockDeviceMode, paramBlockDeviceOwner)
+			}
+		}
+	}
+
+	// Adopting a pre-created backing file only makes sense for new empty volumes
+	adoptExisting, err := ParseAdoptExisting(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if adoptExisting && req.GetVolumeContentSource() != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s cannot be combined with a volume content source", paramAdoptExisting)
+	}
+
+	// IO limits are enforced by RDS; the RouterOS version is checked when the volume is created
+	qos, err := ParseVolumeQoS(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid IO limit parameters: %v", err)
+	}
+
+	// A discovery:// nvmeAddress is resolved by the node; reject a malformed one now
+	if _, _, err := utils.ParseDiscoveryAddress(req.GetParameters()[paramNVMEAddress]); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", paramNVMEAddress, err)
+	}
+
+	// Large volumes may need longer than the command timeout for RDS to create
+	opTimeout, err := ParseRDSOpTimeout(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	// Mutable parameters from a VolumeAttributesClass, also changeable later with ControllerModifyVolume
+	mutable, err := ParseMutableParameters(req.GetMutableParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid mutable parameters: %v", err)
+	}
+
+	// Get required capacity
+	requiredBytes := req.GetCapacityRange().GetRequiredBytes()
 	limitBytes := req.GetCapacityRange().GetLimitBytes()
 	if limitBytes > 0 && requiredBytes > limitBytes {
 		return nil, status.Errorf(codes.OutOfRange, "required bytes %d exceeds limit bytes %d", requiredBytes, limitBytes)
 	}
 
-	if requiredBytes > maxVolumeSizeBytes {
-		return nil, status.Errorf(codes.OutOfRange, "required bytes %d exceeds maximum %d", requiredBytes, maxVolumeSizeBytes)
+	// Enforce size limits: smaller requests grow to the minimum if their limit allows it
+	minBytes, maxBytes := cs.volumeSizeBounds()
+	if requiredBytes < minBytes {
+		if limitBytes > 0 && limitBytes < minBytes {
+			return nil, status.Errorf(codes.OutOfRange, "limit bytes %d is below the minimum volume size %d", limitBytes, minBytes)
+		}
+		requiredBytes = minBytes
 	}
 
-	// Use the volume name directly as the volume ID
-	// The external-provisioner passes the PV name (pvc-<uuid>) which is already unique and deterministic
-	volumeID := req.GetName()
+	if requiredBytes > maxBytes {
+		return nil, status.Errorf(codes.OutOfRange, "required bytes %d exceeds maximum %d", requiredBytes, maxBytes)
+	}
+
+	// Map requested size onto RouterOS file-size granularity
+	roundingPolicy, err := ParseSizeRoundingPolicy(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	provisionedBytes, err := RoundVolumeSize(requiredBytes, roundingPolicy)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if provisionedBytes != requiredBytes {
+		logger.V(2).Info("Rounded volume size", "requiredBytes", requiredBytes, "provisionedBytes", provisionedBytes, "policy", roundingPolicy)
+		if limitBytes > 0 && provisionedBytes > limitBytes {
+			return nil, status.Errorf(codes.OutOfRange, "rounded size %d bytes exceeds limit bytes %d (policy: %s)",
+				provisionedBytes, limitBytes, roundingPolicy)
+		}
+		if provisionedBytes > maxBytes {
+			return nil, status.Errorf(codes.OutOfRange, "rounded size %d bytes exceeds maximum %d (policy: %s)",
+				provisionedBytes, maxBytes, roundingPolicy)
+		}
+		requiredBytes = provisionedBytes
+	}
+
+	// Derive the volume ID from the volume name
+	// The external-provisioner passes the PV name (pvc-<uuid>) which is already unique and deterministic;
+	// a configured volume name prefix is embedded as pvc-<prefix>-<uuid>
+	volumeID := utils.VolumeIDForName(req.GetName())
 
 	// Validate the volume ID format
 	if err := utils.ValidateVolumeID(volumeID); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid volume name format: %v", err)
 	}
-	klog.V(4).Infof("Using volume ID: %s (from volume name: %s)", volumeID, req.GetName())
+	logger = logger.WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("Using volume ID")
+	ctx = withRDSCall(ctx, "CreateVolume", volumeID)
+
+	// Select the RDS backend named by the StorageClass
+	backend, err := cs.backendForParams(ctx, req.GetParameters())
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if volume already exists (idempotency)
-	existingVolume, err := cs.driver.rdsClient.GetVolume(volumeID)
+	existingVolume, err := backend.Client.GetVolume(volumeID)
 	if err == nil {
 		// Volume already exists, verify it matches requirements
-		klog.V(2).Infof("Volume %s already exists (idempotent)", volumeID)
+		logger.V(2).Info("Volume already exists (idempotent)")
 
-		// CSI spec requires checking capacity matches for idempotent CreateVolume
-		// If capacity differs, return AlreadyExists error
-		if existingVolume.FileSizeBytes != requiredBytes {
+		// CSI spec requires the capacity to be compatible for idempotent CreateVolume;
+		// RDS may have allocated more than requested, which is reported as created
+		if existingVolume.FileSizeBytes < requiredBytes || (limitBytes > 0 && existingVolume.FileSizeBytes > limitBytes) {
 			return nil, status.Errorf(codes.AlreadyExists,
 				"volume %s already exists with different capacity (existing: %d bytes, requested: %d bytes)",
 				volumeID, existingVolume.FileSizeBytes, requiredBytes)
 		}
+		if qos.IsSet() && existingVolume.QoS != qos {
+			return nil, status.Errorf(codes.AlreadyExists,
+				"volume %s already exists with different IO limits (existing: %+v, requested: %+v)",
+				volumeID, existingVolume.QoS, qos)
+		}
 
 		// Get parameters from StorageClass for response context
 		params := req.GetParameters()
@@ -127,21 +266,41 @@ func (cs *ControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 		// Parse migration timeout
 		migrationTimeout := ParseMigrationTimeout(params)
 
+		volumeContext := map[string]string{
+			"backend":                 backend.Name,
+			"rdsAddress":              cs.getRDSAddress(backend, params),
+			"nvmeAddress":             cs.getNVMEAddress(backend, params),
+			"nvmePort":                fmt.Sprintf("%d", existingVolume.NVMETCPPort),
+			"nqn":                     existingVolume.NVMETCPNQN,
+			"volumePath":              existingVolume.FilePath,
+			volumeContextFilePath:     existingVolume.FilePath,
+			"ctrlLossTmo":             fmt.Sprintf("%d", nvmeParams.CtrlLossTmo),
+			"reconnectDelay":          fmt.Sprintf("%d", nvmeParams.ReconnectDelay),
+			"keepAliveTmo":            fmt.Sprintf("%d", nvmeParams.KeepAliveTmo),
+			"migrationTimeoutSeconds": fmt.Sprintf("%.0f", migrationTimeout.Seconds()),
+		}
+		nvmeParams.addAuthToVolumeContext(volumeContext)
+		fsOpts.addToVolumeContext(volumeContext)
+		blockOpts.addToVolumeContext(volumeContext)
+		volumeContext[volumeContextAccessMode] = accessMode
+		mutable.addToVolumeContext(volumeContext)
+		if existingVolume.WWID != "" {
+			volumeContext[volumeContextWWID] = existingVolume.WWID
+		}
+		// A retried create must return the filesystem UUID of the first call, or the PV
+		// would be created without it and never be checked
+		if fsUUID := cs.existingFilesystemUUID(ctx, req, backend, volumeID, adoptExisting); fsUUID != "" {
+			volumeContext[volumeContextFSUUID] = fsUUID
+		}
+		if err := cs.fitVolumeContext(volumeID, volumeContext); err != nil {
+			return nil, err
+		}
+
 		return &csi.CreateVolumeResponse{
 			Volume: &csi.Volume{
 				VolumeId:      volumeID,
 				CapacityBytes: existingVolume.FileSizeBytes,
-				VolumeContext: map[string]string{
-					"rdsAddress":              cs.getRDSAddress(params),
-					"nvmeAddress":             cs.getNVMEAddress(params),
-					"nvmePort":                fmt.Sprintf("%d", existingVolume.NVMETCPPort),
-					"nqn":                     existingVolume.NVMETCPNQN,
-					"volumePath":              existingVolume.FilePath,
-					"ctrlLossTmo":             fmt.Sprintf("%d", nvmeParams.CtrlLossTmo),
-					"reconnectDelay":          fmt.Sprintf("%d", nvmeParams.ReconnectDelay),
-					"keepAliveTmo":            fmt.Sprintf("%d", nvmeParams.KeepAliveTmo),
-					"migrationTimeoutSeconds": fmt.Sprintf("%.0f", migrationTimeout.Seconds()),
-				},
+				VolumeContext: volumeContext,
 			},
 		}, nil
 	}
@@ -149,7 +308,7 @@ func (cs *ControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 	// Volume doesn't exist - check for volume content source (snapshot restore)
 	if contentSource := req.GetVolumeContentSource(); contentSource != nil {
 		if snapshotSource := contentSource.GetSnapshot(); snapshotSource != nil {
-			return cs.createVolumeFromSnapshot(ctx, req, volumeID, snapshotSource.GetSnapshotId(), requiredBytes)
+			return cs.createVolumeFromSnapshot(ctx, req, backend, volumeID, snapshotSource.GetSnapshotId(), requiredBytes, fsOpts, qos, mutable, opTimeout)
 		}
 		// Volume clone (not yet supported)
 		if contentSource.GetVolume() != nil {
@@ -195,11 +354,59 @@ func (cs *ControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 		return nil, status.Errorf(codes.Internal, "failed to generate file path: %v", err)
 	}
 
+	// A pre-created backing file is adopted only if its size matches exactly
+	adoptFile := false
+	if adoptExisting {
+		existingFile, err := findFile(backend.Client, filePath)
+		if err != nil {
+			return nil, userFacingError(err, codes.Internal, fmt.Sprintf("failed to look up backing file %s on RDS", filePath))
+		}
+		if existingFile != nil {
+			if existingFile.SizeBytes != requiredBytes {
+				return nil, status.Errorf(codes.AlreadyExists,
+					"backing file %s already exists with different size (existing: %d bytes, requested: %d bytes)",
+					filePath, existingFile.SizeBytes, requiredBytes)
+			}
+			logger.V(2).Info("Adopting existing backing file", "path", filePath)
+			adoptFile = true
+		}
+	}
+
+	// Volume context of the new volume, checked against the metadata limits before the
+	// disk is created
+	volumeContext := map[string]string{
+		"backend":                 backend.Name,
+		"rdsAddress":              cs.getRDSAddress(backend, params),
+		"nvmeAddress":             cs.getNVMEAddress(backend, params),
+		"nvmePort":                fmt.Sprintf("%d", nvmePort),
+		"nqn":                     nqn,
+		"volumePath":              filePath,
+		volumeContextFilePath:     filePath,
+		"ctrlLossTmo":             fmt.Sprintf("%d", nvmeParams.CtrlLossTmo),
+		"reconnectDelay":          fmt.Sprintf("%d", nvmeParams.ReconnectDelay),
+		"keepAliveTmo":            fmt.Sprintf("%d", nvmeParams.KeepAliveTmo),
+		"migrationTimeoutSeconds": fmt.Sprintf("%.0f", migrationTimeout.Seconds()),
+	}
+	nvmeParams.addAuthToVolumeContext(volumeContext)
+	fsOpts.addToVolumeContext(volumeContext)
+	blockOpts.addToVolumeContext(volumeContext)
+	volumeContext[volumeContextAccessMode] = accessMode
+	mutable.addToVolumeContext(volumeContext)
+	// An adopted backing file may already carry a filesystem, with a UUID of its own
+	if !adoptFile {
+		if fsUUID := newFilesystemUUID(volumeID, req.GetVolumeCapabilities()); fsUUID != "" {
+			volumeContext[volumeContextFSUUID] = fsUUID
+		}
+	}
+	if err := cs.checkVolumeContextFits(volumeID, volumeContext); err != nil {
+		return nil, err
+	}
+
 	// Create volume on RDS
-	klog.V(4).Infof("Creating volume %s on RDS (size: %d bytes, path: %s, nqn: %s)", volumeID, requiredBytes, filePath, nqn)
+	logger.V(4).Info("Creating volume on RDS", "sizeBytes", requiredBytes, "path", filePath, "nqn", nqn)
 
 	// Log volume create request
-	secLogger := security.GetLogger()
+	secLogger := requestSecurityLogger(ctx)
 	secLogger.LogVolumeCreate(volumeID, req.GetName(), security.OutcomeUnknown, nil, 0)
 
 	createOpts := rds.CreateVolumeOptions{
@@ -208,72 +415,121 @@ func (cs *ControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 		FileSizeBytes: requiredBytes,
 		NVMETCPPort:   nvmePort,
 		NVMETCPNQN:    nqn,
+		Comment:       volumeDiskComment(params, mutable),
+		QoS:           qos,
+
+		AdoptExistingFile: adoptFile,
+		CommandTimeout:    createCommandTimeout(ctx, opTimeout),
 	}
 
 	startTime := time.Now()
-	if err := cs.driver.rdsClient.CreateVolume(createOpts); err != nil {
+	if err := backend.Client.CreateVolume(createOpts); err != nil {
 		// Log volume create failure
 		secLogger.LogVolumeCreate(volumeID, req.GetName(), security.OutcomeFailure, err, time.Since(startTime))
 
-		// Map errors to appropriate gRPC codes
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
-		}
-		if stderrors.Is(err, utils.ErrResourceExhausted) {
-			return nil, status.Errorf(codes.ResourceExhausted, "insufficient storage on RDS: %v", err)
+		// Map errors to appropriate gRPC codes and user-facing messages
+		return nil, userFacingError(err, codes.Internal, fmt.Sprintf("failed to create volume %s on RDS", volumeID))
+	}
+
+	provisioned, err := cs.provisionedVolume(ctx, backend, volumeID, requiredBytes)
+	if err != nil {
+		// A retry would find the undersized volume and fail with AlreadyExists; an
+		// adopted backing file holds data that is not ours to remove
+		if provisioned != nil && !adoptFile {
+			removeUndersizedVolume(ctx, backend, volumeID)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to create volume on RDS: %v", err)
+		secLogger.LogVolumeCreate(volumeID, req.GetName(), security.OutcomeFailure, err, time.Since(startTime))
+		return nil, err
 	}
 
 	// RDS layer already logged "Created volume X" at V(2) - no duplicate needed
-	klog.V(4).Infof("CreateVolume CSI call completed for %s", volumeID)
+	logger.V(4).Info("CreateVolume CSI call completed")
 
 	// Log volume create success
 	secLogger.LogVolumeCreate(volumeID, req.GetName(), security.OutcomeSuccess, nil, time.Since(startTime))
 
-	// Return volume information
+	if provisioned.WWID != "" {
+		volumeContext[volumeContextWWID] = provisioned.WWID
+	}
+	if err := cs.fitVolumeContext(volumeID, volumeContext); err != nil {
+		return nil, err
+	}
+
 	return &csi.CreateVolumeResponse{
 		Volume: &csi.Volume{
 			VolumeId:      volumeID,
-			CapacityBytes: requiredBytes,
-			VolumeContext: map[string]string{
-				"rdsAddress":              cs.getRDSAddress(params),
-				"nvmeAddress":             cs.getNVMEAddress(params),
-				"nvmePort":                fmt.Sprintf("%d", nvmePort),
-				"nqn":                     nqn,
-				"volumePath":              filePath,
-				"ctrlLossTmo":             fmt.Sprintf("%d", nvmeParams.CtrlLossTmo),
-				"reconnectDelay":          fmt.Sprintf("%d", nvmeParams.ReconnectDelay),
-				"keepAliveTmo":            fmt.Sprintf("%d", nvmeParams.KeepAliveTmo),
-				"migrationTimeoutSeconds": fmt.Sprintf("%.0f", migrationTimeout.Seconds()),
-			},
+			CapacityBytes: provisioned.FileSizeBytes,
+			VolumeContext: volumeContext,
 		},
 	}, nil
 }
 
+// createCommandTimeout returns the limit of the RouterOS command creating a volume: the
+// rdsOpTimeout parameter, cut short to the deadline of the CreateVolume call, or 0 for the
+// client's command timeout
+func createCommandTimeout(ctx context.Context, opTimeout time.Duration) time.Duration {
+	if opTimeout <= 0 {
+		return 0
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < opTimeout {
+			return max(remaining, time.Millisecond)
+		}
+	}
+	return opTimeout
+}
+
 // createVolumeFromSnapshot handles CreateVolume with a snapshot source (restore workflow)
 func (cs *ControllerServer) createVolumeFromSnapshot(
 	ctx context.Context,
 	req *csi.CreateVolumeRequest,
+	backend *rds.Backend,
 	volumeID string,
 	snapshotID string,
 	requiredBytes int64,
+	fsOpts FilesystemOptions,
+	qos rds.VolumeQoS,
+	mutable MutableParameters,
+	opTimeout time.Duration,
 ) (*csi.CreateVolumeResponse, error) {
-	klog.V(4).Infof("Creating volume %s from snapshot %s", volumeID, snapshotID)
+	logger := klog.FromContext(ctx).WithValues("snapshotID", snapshotID)
+	logger.V(4).Info("Creating volume from snapshot")
 
 	// Validate snapshot ID
 	if err := utils.ValidateSnapshotID(snapshotID); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid snapshot ID: %v", err)
 	}
+	allowCrossNamespace, err := ParseAllowCrossNamespaceRestore(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 
-	// Verify snapshot exists
-	snapshotInfo, err := cs.driver.rdsClient.GetSnapshot(snapshotID)
+	// Hold the snapshot for the whole restore so DeleteSnapshot cannot remove it mid-copy
+	endRestore, err := cs.driver.snapshotRestores.beginRestore(snapshotID, volumeID)
+	if err != nil {
+		return nil, status.Errorf(codes.Aborted, "cannot restore volume %s: %v", volumeID, err)
+	}
+	defer endRestore()
+
+	// Verify snapshot exists on the selected backend (restores cannot cross arrays)
+	snapshotInfo, err := backend.Client.GetSnapshot(snapshotID)
 	if err != nil {
 		var notFoundErr *rds.SnapshotNotFoundError
 		if stderrors.As(err, &notFoundErr) {
+			if other, _, findErr := cs.driver.backendsFor(ctx).FindSnapshot(snapshotID); findErr == nil {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"snapshot %s is on RDS backend %s, but the StorageClass selects backend %s",
+					snapshotID, other.Name, backend.Name)
+			}
 			return nil, status.Errorf(codes.NotFound, "snapshot %s not found", snapshotID)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get snapshot: %v", err)
+		return nil, userFacingError(err, codes.Internal, "failed to get snapshot")
+	}
+
+	if !allowCrossNamespace {
+		if err := checkRestoreNamespace(snapshotID, snapshotInfo.Comment, req.GetParameters()[paramPVCNamespace]); err != nil {
+			return nil, err
+		}
 	}
 
 	// CSI spec: volume size must not be less than snapshot size
@@ -281,7 +537,8 @@ func (cs *ControllerServer) createVolumeFromSnapshot(
 		requiredBytes = snapshotInfo.FileSizeBytes
 	}
 
-	// Get parameters
+	// Get parameters. The volumePath of the new volume's StorageClass may be another pool
+	// than the snapshot's; RDS copies across pools and both paths must be allowed.
 	params := req.GetParameters()
 	volumeBasePath := defaultVolumeBasePath
 	if path, ok := params[paramVolumePath]; ok {
@@ -313,6 +570,37 @@ func (cs *ControllerServer) createVolumeFromSnapshot(
 		return nil, status.Errorf(codes.Internal, "failed to generate file path: %v", err)
 	}
 
+	// Volume context of the restored volume, checked against the metadata limits before
+	// the copy is made
+	volumeContext := map[string]string{
+		"backend":                 backend.Name,
+		"rdsAddress":              cs.getRDSAddress(backend, params),
+		"nvmeAddress":             cs.getNVMEAddress(backend, params),
+		"nvmePort":                fmt.Sprintf("%d", nvmePort),
+		"nqn":                     nqn,
+		"volumePath":              filePath,
+		volumeContextFilePath:     filePath,
+		"ctrlLossTmo":             fmt.Sprintf("%d", nvmeParams.CtrlLossTmo),
+		"reconnectDelay":          fmt.Sprintf("%d", nvmeParams.ReconnectDelay),
+		"keepAliveTmo":            fmt.Sprintf("%d", nvmeParams.KeepAliveTmo),
+		"migrationTimeoutSeconds": fmt.Sprintf("%.0f", migrationTimeout.Seconds()),
+	}
+	nvmeParams.addAuthToVolumeContext(volumeContext)
+	fsOpts.addToVolumeContext(volumeContext)
+	// Validated by CreateVolume
+	blockOpts, _ := ParseBlockDeviceOptions(req.GetParameters())
+	blockOpts.addToVolumeContext(volumeContext)
+	accessMode, _ := cs.grantedAccessMode(req.GetVolumeCapabilities(), req.GetParameters())
+	volumeContext[volumeContextAccessMode] = accessMode
+	mutable.addToVolumeContext(volumeContext)
+	// The restored filesystem is a copy of the source volume's, UUID included
+	if fsUUID := cs.sourceFilesystemUUID(ctx, snapshotInfo.SourceVolume); fsUUID != "" {
+		volumeContext[volumeContextFSUUID] = fsUUID
+	}
+	if err := cs.checkVolumeContextFits(volumeID, volumeContext); err != nil {
+		return nil, err
+	}
+
 	// Restore: create new volume from snapshot via RDS
 	restoreOpts := rds.CreateVolumeOptions{
 		Slot:          volumeID,
@@ -320,32 +608,38 @@ func (cs *ControllerServer) createVolumeFromSnapshot(
 		FileSizeBytes: requiredBytes,
 		NVMETCPPort:   nvmePort,
 		NVMETCPNQN:    nqn,
+		Comment:       volumeDiskComment(params, mutable),
+		QoS:           qos,
+
+		CommandTimeout: createCommandTimeout(ctx, opTimeout),
+	}
+
+	if err := backend.Client.RestoreSnapshot(snapshotID, restoreOpts); err != nil {
+		return nil, userFacingError(err, codes.Internal, fmt.Sprintf("failed to restore snapshot %s", snapshotID))
 	}
 
-	if err := cs.driver.rdsClient.RestoreSnapshot(snapshotID, restoreOpts); err != nil {
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
+	logger.V(2).Info("Restored volume from snapshot")
+
+	provisioned, err := cs.provisionedVolume(ctx, backend, volumeID, requiredBytes)
+	if err != nil {
+		if provisioned != nil {
+			removeUndersizedVolume(ctx, backend, volumeID)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to restore snapshot: %v", err)
+		return nil, err
 	}
 
-	klog.V(2).Infof("Restored volume %s from snapshot %s", volumeID, snapshotID)
+	if provisioned.WWID != "" {
+		volumeContext[volumeContextWWID] = provisioned.WWID
+	}
+	if err := cs.fitVolumeContext(volumeID, volumeContext); err != nil {
+		return nil, err
+	}
 
 	return &csi.CreateVolumeResponse{
 		Volume: &csi.Volume{
 			VolumeId:      volumeID,
-			CapacityBytes: requiredBytes,
-			VolumeContext: map[string]string{
-				"rdsAddress":              cs.getRDSAddress(params),
-				"nvmeAddress":             cs.getNVMEAddress(params),
-				"nvmePort":                fmt.Sprintf("%d", nvmePort),
-				"nqn":                     nqn,
-				"volumePath":              filePath,
-				"ctrlLossTmo":             fmt.Sprintf("%d", nvmeParams.CtrlLossTmo),
-				"reconnectDelay":          fmt.Sprintf("%d", nvmeParams.ReconnectDelay),
-				"keepAliveTmo":            fmt.Sprintf("%d", nvmeParams.KeepAliveTmo),
-				"migrationTimeoutSeconds": fmt.Sprintf("%.0f", migrationTimeout.Seconds()),
-			},
+			CapacityBytes: provisioned.FileSizeBytes,
+			VolumeContext: volumeContext,
 			ContentSource: &csi.VolumeContentSource{
 				Type: &csi.VolumeContentSource_Snapshot{
 					Snapshot: &csi.VolumeContentSource_SnapshotSource{
@@ -360,7 +654,9 @@ func (cs *ControllerServer) createVolumeFromSnapshot(
 // DeleteVolume removes a volume from RDS
 func (cs *ControllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
 	volumeID := req.GetVolumeId()
-	klog.V(4).Infof("DeleteVolume CSI call for %s", volumeID)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("DeleteVolume CSI call")
 
 	// Validate request
 	if volumeID == "" {
@@ -377,56 +673,83 @@ func (cs *ControllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVol
 		return nil, status.Error(codes.Internal, "RDS client not initialized")
 	}
 
-	// Safety check: verify volume exists before attempting deletion
-	// This helps catch force-deletion scenarios where the volume might still be in use
-	volume, err := cs.driver.rdsClient.GetVolume(volumeID)
-	if err != nil {
-		// Check if this is a connection error (should be retried)
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
+	ctx = withRDSCall(ctx, "DeleteVolume", volumeID)
+
+	cs.volumeLocks.Lock(volumeID)
+	defer cs.volumeLocks.Unlock(volumeID)
+
+	// Safety check: a volume still tracked as attached may be mounted by a pod, e.g. after
+	// its PVC was force-deleted. Deleting it would remove the backing file under the mount.
+	// The normal flow unpublishes, clearing the attachment, before it deletes.
+	if am := cs.driver.attachmentManager; am != nil {
+		if state, attached := am.GetAttachment(volumeID); attached {
+			nodes := strings.Join(state.GetNodeIDs(), ", ")
+			if !cs.driver.allowDeleteAttached {
+				logger.Info("Refusing to delete volume still attached", "nodes", nodes)
+				requestSecurityLogger(ctx).LogVolumeDelete(volumeID, "", security.OutcomeDenied,
+					fmt.Errorf("volume attached to node(s) %s", nodes), 0)
+				return nil, status.Errorf(codes.FailedPrecondition,
+					"volume %s is still attached to node(s) %s; detach it before deleting", volumeID, nodes)
+			}
+			logger.Info("Deleting volume still attached (-allow-delete-attached)", "nodes", nodes)
 		}
+	}
 
+	// Safety check: verify volume exists before attempting deletion
+	// This helps catch force-deletion scenarios where the volume might still be in use.
+	// DeleteVolume carries no parameters, so the backend is found by looking the volume up.
+	backend, volume, err := cs.findVolume(ctx, volumeID, nil)
+	if err != nil {
 		// Check if this is a VolumeNotFoundError (idempotent case)
 		// Check both the typed error and the sentinel error
-		var notFoundErr *rds.VolumeNotFoundError
-		if stderrors.As(err, &notFoundErr) || stderrors.Is(err, utils.ErrVolumeNotFound) {
-			klog.V(4).Infof("Volume %s not found on RDS, assuming already deleted", volumeID)
+		if isVolumeNotFound(err) {
+			logger.V(4).Info("Volume not found on RDS, assuming already deleted")
 			return &csi.DeleteVolumeResponse{}, nil
 		}
 
-		// For other errors (like GetVolume failures), return the error
+		// For other errors (like connection or GetVolume failures), return the error
 		// Don't treat all errors as "volume not found" - could mask real problems
-		return nil, status.Errorf(codes.Internal, "failed to verify volume existence: %v", err)
+		return nil, userFacingError(err, codes.Internal, "failed to verify volume existence")
 	}
 
 	// Log volume details for audit trail
-	klog.V(4).Infof("Deleting volume %s (path=%s, size=%d bytes, nvme_export=%v)",
-		volumeID, volume.FilePath, volume.FileSizeBytes, volume.NVMETCPExport)
+	logger.V(4).Info("Deleting volume", "backend", backend.Name, "slot", volume.Slot, "path", volume.FilePath,
+		"sizeBytes", volume.FileSizeBytes, "nvmeExport", volume.NVMETCPExport)
 
 	// Log volume delete request
-	secLogger := security.GetLogger()
+	secLogger := requestSecurityLogger(ctx)
 	secLogger.LogVolumeDelete(volumeID, "", security.OutcomeUnknown, nil, 0)
 
-	// Delete volume from RDS (idempotent)
+	// Delete volume from RDS (idempotent). With retained files, the NVMe export is
+	// removed but the backing file is moved to .trash/ for recovery. Otherwise deletions
+	// arriving together share one batch of RDS commands.
 	startTime := time.Now()
-	if err := cs.driver.rdsClient.DeleteVolume(volumeID); err != nil {
-		klog.Errorf("Failed to delete volume %s: %v", volumeID, err)
+	if cs.driver.deleteRetainFiles {
+		var trashPath string
+		if trashPath, err = backend.Client.TrashVolume(volume.Slot); err == nil && trashPath != "" {
+			logger.Info("Volume deleted, backing file retained", "trashPath", trashPath)
+		}
+	} else if cs.driver.deleteBatcher != nil {
+		err = cs.driver.deleteBatcher.Delete(ctx, backend.Client, volume.Slot)
+	} else {
+		err = backend.Client.DeleteVolume(volume.Slot)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to delete volume")
 
 		// Log volume delete failure
 		secLogger.LogVolumeDelete(volumeID, "", security.OutcomeFailure, err, time.Since(startTime))
 
-		// Map errors to appropriate gRPC codes
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
-		}
-		return nil, status.Errorf(codes.Internal, "failed to delete volume: %v", err)
+		// Map errors to appropriate gRPC codes and user-facing messages
+		return nil, userFacingError(err, codes.Internal, "failed to delete volume")
 	}
 
 	// RDS layer already logged "Deleted volume X" at V(2) - no duplicate needed
-	klog.V(4).Infof("DeleteVolume CSI call completed for %s", volumeID)
+	logger.V(4).Info("DeleteVolume CSI call completed")
 
 	// Log volume delete success
 	secLogger.LogVolumeDelete(volumeID, "", security.OutcomeSuccess, nil, time.Since(startTime))
+	cs.driver.backendAnnotated.Delete(volumeID)
 
 	return &csi.DeleteVolumeResponse{}, nil
 }
@@ -446,7 +769,7 @@ func (cs *ControllerServer) ValidateVolumeCapabilities(ctx context.Context, req
 	}
 
 	// Check if volume exists
-	if _, err := cs.driver.rdsClient.GetVolume(volumeID); err != nil {
+	if _, _, err := cs.findVolume(ctx, volumeID, req.GetVolumeContext()); err != nil {
 		return nil, status.Errorf(codes.NotFound, "volume %s not found: %v", volumeID, err)
 	}
 
@@ -475,8 +798,13 @@ func (cs *ControllerServer) GetCapacity(ctx context.Context, req *csi.GetCapacit
 		volumeBasePath = path
 	}
 
+	backend, err := cs.backendForParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
 	// Query capacity from RDS
-	capacity, err := cs.driver.rdsClient.GetCapacity(volumeBasePath)
+	capacity, err := backend.Client.GetCapacity(volumeBasePath)
 	if err != nil {
 		klog.Errorf("Failed to get capacity from RDS: %v", err)
 		return nil, status.Errorf(codes.Internal, "failed to query capacity: %v", err)
@@ -516,25 +844,44 @@ func (cs *ControllerServer) validateBlockingNodeExists(ctx context.Context, node
 	return true, nil
 }
 
+// publishContextActiveWriter names the node that should have write access to the volume
+// when it is published, so a node that is not the writer can choose to mount read-only.
+// It is not updated when the writer changes later, e.g. when a migration completes; the
+// active-writer annotation on the PV is.
+const publishContextActiveWriter = "active_writer"
+
 // buildPublishContext creates the publish_context map with NVMe connection parameters.
-// Uses snake_case keys to match existing volumeContext conventions.
-func (cs *ControllerServer) buildPublishContext(volume *rds.VolumeInfo, params map[string]string) map[string]string {
+// Uses snake_case keys to match existing volumeContext conventions. The publish time is
+// when the volume was attached to nodeID, so repeated calls return the same context; it
+// and the active writer are left out when attachments are not tracked.
+func (cs *ControllerServer) buildPublishContext(backend *rds.Backend, volumeID string, volume *rds.VolumeInfo, params map[string]string, nodeID string) map[string]string {
 	fsType := "ext4"
 	if fs, ok := params[paramFSType]; ok && fs != "" {
 		fsType = fs
 	}
 
-	return map[string]string{
-		"nvme_address": cs.getNVMEAddress(params),
+	publishContext := map[string]string{
+		"nvme_address": cs.getNVMEAddress(backend, params),
 		"nvme_port":    fmt.Sprintf("%d", volume.NVMETCPPort),
 		"nvme_nqn":     volume.NVMETCPNQN,
 		"fs_type":      fsType,
 	}
+	if am := cs.driver.GetAttachmentManager(); am != nil {
+		if state, exists := am.GetAttachment(volumeID); exists {
+			if attachedAt, attached := state.NodeAttachedAt(nodeID); attached {
+				publishContext[publishContextPublishedAt] = publishTimestamp(attachedAt)
+			}
+			if state.ActiveWriter != "" {
+				publishContext[publishContextActiveWriter] = state.ActiveWriter
+			}
+		}
+	}
+	return publishContext
 }
 
 // postAttachmentConflictEvent posts a K8s event for an attachment conflict.
 // Best effort - failures are logged but don't affect the main operation.
-func (cs *ControllerServer) postAttachmentConflictEvent(ctx context.Context, req *csi.ControllerPublishVolumeRequest, attachedNode string) {
+func (cs *ControllerServer) postAttachmentConflictEvent(ctx context.Context, req *csi.ControllerPublishVolumeRequest, attachedNode, holder string) {
 	// Extract PVC info from volume context if available
 	volCtx := req.GetVolumeContext()
 	pvcNamespace := volCtx["csi.storage.k8s.io/pvc/namespace"]
@@ -550,8 +897,8 @@ func (cs *ControllerServer) postAttachmentConflictEvent(ctx context.Context, req
 		return
 	}
 
-	poster := NewEventPoster(cs.driver.k8sClient)
-	if err := poster.PostAttachmentConflict(ctx, pvcNamespace, pvcName, req.GetVolumeId(), req.GetNodeId(), attachedNode); err != nil {
+	poster := cs.driver.getEventPoster()
+	if err := poster.PostAttachmentConflict(ctx, pvcNamespace, pvcName, req.GetVolumeId(), req.GetNodeId(), attachedNode, holder); err != nil {
 		klog.Warningf("Failed to post attachment conflict event: %v", err)
 	}
 }
@@ -572,7 +919,7 @@ func (cs *ControllerServer) postVolumeAttachedEvent(ctx context.Context, req *cs
 		return
 	}
 
-	poster := NewEventPoster(cs.driver.k8sClient)
+	poster := cs.driver.getEventPoster()
 	if err := poster.PostVolumeAttached(ctx, pvcNamespace, pvcName, req.GetVolumeId(), req.GetNodeId(), duration); err != nil {
 		klog.Warningf("Failed to post volume attached event: %v", err)
 	}
@@ -599,7 +946,7 @@ func (cs *ControllerServer) postVolumeDetachedEvent(ctx context.Context, req *cs
 		return
 	}
 
-	poster := NewEventPoster(cs.driver.k8sClient)
+	poster := cs.driver.getEventPoster()
 	if err := poster.PostVolumeDetached(ctx, claimRef.Namespace, claimRef.Name, req.GetVolumeId(), req.GetNodeId()); err != nil {
 		klog.Warningf("Failed to post volume detached event: %v", err)
 	}
@@ -612,7 +959,9 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 	volumeID := req.GetVolumeId()
 	nodeID := req.GetNodeId()
 
-	klog.V(2).Infof("ControllerPublishVolume called for volume %s to node %s", volumeID, nodeID)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID, "nodeID", nodeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("ControllerPublishVolume called")
 
 	// Validate request
 	if volumeID == "" {
@@ -633,6 +982,13 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 		return nil, status.Error(codes.InvalidArgument, "volume capability is required")
 	}
 
+	// Attach with the access mode CreateVolume granted, not the one this request asks for
+	accessMode, err := publishAccessMode(req)
+	if err != nil {
+		return nil, err
+	}
+	isRWX := accessMode == AccessModeRWX
+
 	// Validate node exists if we have k8s client
 	// For sanity tests without k8s, only accept the driver's own node ID
 	if cs.driver.k8sClient != nil {
@@ -649,17 +1005,6 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 		}
 	}
 
-	// Determine access mode from VolumeCapability (singular, not slice)
-	// ControllerPublishVolume uses GetVolumeCapability() which returns a single capability
-	accessMode := "RWO"
-	isRWX := false
-	if cap := req.GetVolumeCapability(); cap != nil {
-		if cap.GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER {
-			accessMode = "RWX"
-			isRWX = true
-		}
-	}
-
 	// Acquire per-VMI lock if serialization is enabled
 	// This prevents concurrent volume operations on the same VMI from racing
 	if vmiGrouper := cs.driver.GetVMIGrouper(); vmiGrouper != nil {
@@ -670,13 +1015,19 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 			vmiKey, unlock := vmiGrouper.LockVMI(ctx, pvcNamespace, pvcName)
 			if vmiKey != "" {
 				defer unlock()
-				klog.V(4).Infof("VMI serialization active for volume %s (VMI: %s)", volumeID, vmiKey)
+				logger.V(4).Info("VMI serialization active", "vmi", vmiKey)
 			}
 		}
 	}
 
+	// Held until the attachment is tracked, so a DeleteVolume either runs first and the
+	// volume is not found, or waits and finds it attached
+	cs.volumeLocks.Lock(volumeID)
+	defer cs.volumeLocks.Unlock(volumeID)
+
 	// Verify volume exists on RDS
-	volume, err := cs.driver.rdsClient.GetVolume(volumeID)
+	ctx = withRDSCall(ctx, "ControllerPublishVolume", volumeID)
+	backend, volume, err := cs.findVolume(ctx, volumeID, req.GetVolumeContext())
 	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "volume %s not found: %v", volumeID, err)
 	}
@@ -685,9 +1036,9 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 	am := cs.driver.GetAttachmentManager()
 	if am == nil {
 		// No attachment manager = skip tracking (single-node scenario or disabled)
-		klog.V(4).Infof("Attachment manager not available, skipping tracking for volume %s", volumeID)
+		logger.V(4).Info("Attachment manager not available, skipping tracking")
 		return &csi.ControllerPublishVolumeResponse{
-			PublishContext: cs.buildPublishContext(volume, req.GetVolumeContext()),
+			PublishContext: cs.buildPublishContext(backend, volumeID, volume, req.GetVolumeContext(), nodeID),
 		}, nil
 	}
 
@@ -696,9 +1047,9 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 	if exists {
 		// Check if already attached to requesting node (idempotent)
 		if am.IsAttachedToNode(volumeID, nodeID) {
-			klog.V(2).Infof("Volume %s already attached to node %s (idempotent)", volumeID, nodeID)
+			logger.V(2).Info("Volume already attached to node (idempotent)")
 			return &csi.ControllerPublishVolumeResponse{
-				PublishContext: cs.buildPublishContext(volume, req.GetVolumeContext()),
+				PublishContext: cs.buildPublishContext(backend, volumeID, volume, req.GetVolumeContext(), nodeID),
 			}, nil
 		}
 
@@ -708,8 +1059,7 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 			nodeCount := am.GetNodeCount(volumeID)
 			if nodeCount >= 2 {
 				// ROADMAP-5: 2-node migration limit reached
-				klog.Warningf("RWX volume %s already attached to 2 nodes, rejecting 3rd attachment to %s",
-					volumeID, nodeID)
+				logger.Info("RWX volume already attached to 2 nodes, rejecting 3rd attachment", "attachedNodes", existing.GetNodeIDs())
 				return nil, status.Errorf(codes.FailedPrecondition,
 					"Volume %s already attached to 2 nodes (migration limit). Wait for migration to complete. Attached nodes: %v",
 					volumeID, existing.GetNodeIDs())
@@ -719,27 +1069,14 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 			// This prevents indefinite dual-attach if migration fails
 			if existing.IsMigrationTimedOut() {
 				elapsed := time.Since(*existing.MigrationStartedAt)
-				klog.Warningf("RWX volume %s migration timed out (%v elapsed, %v max), rejecting new secondary attachment",
-					volumeID, elapsed, existing.MigrationTimeout)
-
-				// Record timeout metric
-				if cs.driver.metrics != nil {
-					cs.driver.metrics.RecordMigrationResult("timeout", elapsed)
-				}
-
-				// Post migration failed event
-				if cs.driver.k8sClient != nil {
-					volCtx := req.GetVolumeContext()
-					pvcNamespace := volCtx["csi.storage.k8s.io/pvc/namespace"]
-					pvcName := volCtx["csi.storage.k8s.io/pvc/name"]
-					if pvcNamespace != "" && pvcName != "" && len(existing.Nodes) >= 2 {
-						sourceNode := existing.Nodes[0].NodeID
-						targetNode := existing.Nodes[1].NodeID
-						eventPoster := NewEventPoster(cs.driver.k8sClient)
-						if err := eventPoster.PostMigrationFailed(ctx, pvcNamespace, pvcName, volumeID, sourceNode, targetNode, "timeout", elapsed); err != nil {
-							klog.Warningf("Failed to post migration failed event: %v", err)
-						}
-					}
+				logger.Info("RWX volume migration timed out, rejecting new secondary attachment",
+					"elapsed", elapsed, "migrationTimeout", existing.MigrationTimeout)
+
+				// Revert to the source node; records the timeout metric and posts MigrationFailed
+				if revertedTo, err := am.AbortMigration(ctx, volumeID); err != nil {
+					logger.Error(err, "Failed to abort timed out migration")
+				} else {
+					logger.V(2).Info("Reverted volume to source node after migration timeout", "sourceNode", revertedTo)
 				}
 
 				return nil, status.Errorf(codes.FailedPrecondition,
@@ -756,7 +1093,7 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 			migrationTimeout := ParseMigrationTimeout(migrationTimeoutParams)
 
 			// Allow second attachment for migration
-			klog.V(2).Infof("Allowing second attachment of RWX volume %s to node %s (migration target, timeout=%v)", volumeID, nodeID, migrationTimeout)
+			logger.V(2).Info("Allowing second attachment of RWX volume (migration target)", "migrationTimeout", migrationTimeout)
 
 			// Capture source node before adding secondary attachment
 			// Defensive check: existing.Nodes should always have at least 1 entry if exists=true,
@@ -783,15 +1120,15 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 				pvcNamespace := volCtx["csi.storage.k8s.io/pvc/namespace"]
 				pvcName := volCtx["csi.storage.k8s.io/pvc/name"]
 				if pvcNamespace != "" && pvcName != "" {
-					eventPoster := NewEventPoster(cs.driver.k8sClient)
+					eventPoster := cs.driver.getEventPoster()
 					if err := eventPoster.PostMigrationStarted(ctx, pvcNamespace, pvcName, volumeID, sourceNode, nodeID, migrationTimeout); err != nil {
-						klog.Warningf("Failed to post migration started event: %v", err)
+						logger.Info("Failed to post migration started event", "err", err)
 					}
 				}
 			}
 
 			return &csi.ControllerPublishVolumeResponse{
-				PublishContext: cs.buildPublishContext(volume, req.GetVolumeContext()),
+				PublishContext: cs.buildPublishContext(backend, volumeID, volume, req.GetVolumeContext(), nodeID),
 			}, nil
 		}
 
@@ -805,8 +1142,7 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 		// RWO: Check grace period first
 		gracePeriod := cs.driver.GetAttachmentGracePeriod()
 		if gracePeriod > 0 && am.IsWithinGracePeriod(volumeID, gracePeriod) {
-			klog.V(2).Infof("Volume %s within grace period, allowing attachment handoff from %s to %s",
-				volumeID, existing.NodeID, nodeID)
+			logger.V(2).Info("Volume within grace period, allowing attachment handoff", "previousNode", existing.NodeID)
 
 			// Record grace period usage metric
 			if cs.driver.metrics != nil {
@@ -815,7 +1151,7 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 
 			// Clear the old attachment and detach timestamp before new attach
 			if err := am.UntrackAttachment(ctx, volumeID); err != nil {
-				klog.Warningf("Failed to clear old attachment for volume %s during grace period handoff: %v", volumeID, err)
+				logger.Info("Failed to clear old attachment during grace period handoff", "err", err)
 			}
 			am.ClearDetachTimestamp(volumeID)
 			// Fall through to track new attachment
@@ -824,34 +1160,36 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 			nodeExists, err := cs.validateBlockingNodeExists(ctx, existing.NodeID)
 			if err != nil {
 				// API error - fail closed to prevent data corruption
-				klog.Errorf("Failed to verify node %s existence: %v", existing.NodeID, err)
+				logger.Error(err, "Failed to verify node existence", "attachedNode", existing.NodeID)
 				return nil, status.Errorf(codes.Internal, "failed to verify node %s: %v", existing.NodeID, err)
 			}
 
 			if !nodeExists {
 				// Node deleted - auto-clear stale attachment (self-healing)
-				klog.Warningf("Volume %s attached to deleted node %s, clearing stale attachment", volumeID, existing.NodeID)
+				logger.Info("Volume attached to deleted node, clearing stale attachment", "attachedNode", existing.NodeID)
 				if err := am.UntrackAttachment(ctx, volumeID); err != nil {
-					klog.Warningf("Failed to clear stale attachment for volume %s: %v", volumeID, err)
+					logger.Info("Failed to clear stale attachment", "err", err)
 					// Continue anyway - in-memory state may be stale
 				}
 				// Fall through to allow new attachment
 			} else {
 				// CSI-02: Node exists - genuine RWO conflict - hint about RWX
-				klog.Warningf("RWO volume %s already attached to node %s, rejecting attachment to node %s",
-					volumeID, existing.NodeID, nodeID)
+				// Name the workload holding the volume, so operators need not search for it
+				holder := cs.conflictHolder(ctx, req, existing.NodeID)
+				logger.Info("RWO volume already attached to another node, rejecting attachment",
+					"attachedNode", existing.NodeID, "holder", holder)
 
 				// Post event for operator visibility (best effort)
-				cs.postAttachmentConflictEvent(ctx, req, existing.NodeID)
+				cs.postAttachmentConflictEvent(ctx, req, existing.NodeID, holder)
 
 				// Record conflict metric
 				if cs.driver.metrics != nil {
-					cs.driver.metrics.RecordAttachmentConflict()
+					cs.driver.metrics.RecordAttachmentConflict(holder != "")
 				}
 
 				return nil, status.Errorf(codes.FailedPrecondition,
-					"Volume %s already attached to node %s. For multi-node access, use RWX with block volumes.",
-					volumeID, existing.NodeID)
+					"Volume %s already attached to node %s%s. For multi-node access, use RWX with block volumes.",
+					volumeID, existing.NodeID, conflictHolderSuffix(holder))
 			}
 		}
 	}
@@ -860,12 +1198,13 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 	if err := am.TrackAttachmentWithMode(ctx, volumeID, nodeID, accessMode); err != nil {
 		// Check if this is a conflict (race condition - another request won)
 		if existing, exists := am.GetAttachment(volumeID); exists && !am.IsAttachedToNode(volumeID, nodeID) {
+			holder := cs.conflictHolder(ctx, req, existing.NodeID)
 			if cs.driver.metrics != nil {
-				cs.driver.metrics.RecordAttachmentConflict()
+				cs.driver.metrics.RecordAttachmentConflict(holder != "")
 			}
 			return nil, status.Errorf(codes.FailedPrecondition,
-				"volume %s already attached to node %s, cannot attach to %s",
-				volumeID, existing.NodeID, nodeID)
+				"volume %s already attached to node %s%s, cannot attach to %s",
+				volumeID, existing.NodeID, conflictHolderSuffix(holder), nodeID)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to track attachment: %v", err)
 	}
@@ -878,11 +1217,12 @@ func (cs *ControllerServer) ControllerPublishVolume(ctx context.Context, req *cs
 
 	// Post attachment event (best effort)
 	cs.postVolumeAttachedEvent(ctx, req, duration)
+	cs.annotateBackendDetails(ctx, backend, volumeID, volume)
 
-	klog.V(2).Infof("Successfully published volume %s to node %s", volumeID, nodeID)
+	logger.V(2).Info("Successfully published volume")
 
 	return &csi.ControllerPublishVolumeResponse{
-		PublishContext: cs.buildPublishContext(volume, req.GetVolumeContext()),
+		PublishContext: cs.buildPublishContext(backend, volumeID, volume, req.GetVolumeContext(), nodeID),
 	}, nil
 }
 
@@ -893,7 +1233,9 @@ func (cs *ControllerServer) ControllerUnpublishVolume(ctx context.Context, req *
 	volumeID := req.GetVolumeId()
 	nodeID := req.GetNodeId()
 
-	klog.V(2).Infof("ControllerUnpublishVolume called for volume %s from node %s", volumeID, nodeID)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID, "nodeID", nodeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("ControllerUnpublishVolume called")
 
 	// Validate request
 	if volumeID == "" {
@@ -910,7 +1252,34 @@ func (cs *ControllerServer) ControllerUnpublishVolume(ctx context.Context, req *
 	am := cs.driver.GetAttachmentManager()
 	if am == nil {
 		// No attachment manager = nothing to untrack
-		klog.V(4).Infof("Attachment manager not available, skipping untrack for volume %s", volumeID)
+		logger.V(4).Info("Attachment manager not available, skipping untrack")
+		return &csi.ControllerUnpublishVolumeResponse{}, nil
+	}
+
+	existing, attached := am.GetAttachment(volumeID)
+	if !attached {
+		logger.V(2).Info("Volume is not attached, nothing to unpublish (idempotent)")
+		return &csi.ControllerUnpublishVolumeResponse{}, nil
+	}
+
+	// An empty node ID detaches the volume from every node it is attached to
+	if nodeID == "" {
+		for _, attachedNode := range existing.GetNodeIDs() {
+			if _, err := am.RemoveNodeAttachment(ctx, volumeID, attachedNode); err != nil {
+				logger.Info("Error removing node attachment (returning success)", "attachedNode", attachedNode, "err", err)
+			}
+		}
+		if cs.driver.metrics != nil {
+			cs.driver.metrics.RecordAttachmentOp("detach", nil, time.Since(startTime))
+		}
+		cs.postVolumeDetachedEvent(ctx, req)
+		logger.V(2).Info("Successfully unpublished volume from all nodes")
+		return &csi.ControllerUnpublishVolumeResponse{}, nil
+	}
+
+	// Another node holds the volume: nothing of ours to remove, and its attachment must stay
+	if !existing.IsAttachedToNode(nodeID) {
+		logger.V(2).Info("Volume not attached to node, nothing to unpublish (idempotent)", "attachedNodes", existing.GetNodeIDs())
 		return &csi.ControllerUnpublishVolumeResponse{}, nil
 	}
 
@@ -918,7 +1287,7 @@ func (cs *ControllerServer) ControllerUnpublishVolume(ctx context.Context, req *
 	var wasMigrating bool
 	var sourceNode, targetNode string
 	var migrationStartedAt time.Time
-	if existing, found := am.GetAttachment(volumeID); found && existing.IsMigrating() {
+	if existing.IsMigrating() {
 		wasMigrating = true
 		// Identify which node is being removed (source) and which remains (target)
 		if len(existing.Nodes) == 2 {
@@ -940,7 +1309,7 @@ func (cs *ControllerServer) ControllerUnpublishVolume(ctx context.Context, req *
 	// Remove this node's attachment (handles both RWO and RWX)
 	fullyDetached, err := am.RemoveNodeAttachment(ctx, volumeID, nodeID)
 	if err != nil {
-		klog.Warningf("Error removing node attachment for volume %s: %v (returning success)", volumeID, err)
+		logger.Info("Error removing node attachment (returning success)", "err", err)
 	}
 
 	if fullyDetached {
@@ -951,7 +1320,7 @@ func (cs *ControllerServer) ControllerUnpublishVolume(ctx context.Context, req *
 		cs.postVolumeDetachedEvent(ctx, req)
 	} else {
 		// Partial detach (RWX migration - one node still attached)
-		klog.V(2).Infof("Volume %s partially detached from node %s, other node(s) still attached", volumeID, nodeID)
+		logger.V(2).Info("Volume partially detached, other node(s) still attached")
 		if cs.driver.metrics != nil {
 			cs.driver.metrics.RecordAttachmentOp("detach_partial", nil, time.Since(startTime))
 		}
@@ -966,24 +1335,26 @@ func (cs *ControllerServer) ControllerUnpublishVolume(ctx context.Context, req *
 				pvcNamespace := pv.Spec.ClaimRef.Namespace
 				pvcName := pv.Spec.ClaimRef.Name
 				if pvcNamespace != "" && pvcName != "" {
-					eventPoster := NewEventPoster(cs.driver.k8sClient)
+					eventPoster := cs.driver.getEventPoster()
 					if err := eventPoster.PostMigrationCompleted(ctx, pvcNamespace, pvcName, volumeID, sourceNode, targetNode, duration); err != nil {
-						klog.Warningf("Failed to post migration completed event: %v", err)
+						logger.Info("Failed to post migration completed event", "err", err)
 					}
 				}
 			} else {
-				klog.V(4).Infof("Could not get PVC for migration completed event: %v", err)
+				logger.V(4).Info("Could not get PVC for migration completed event", "err", err)
 			}
 		}
 	}
 
-	klog.V(2).Infof("Successfully unpublished volume %s from node %s", volumeID, nodeID)
+	logger.V(2).Info("Successfully unpublished volume")
 	return &csi.ControllerUnpublishVolumeResponse{}, nil
 }
 
 // CreateSnapshot creates a file-based CoW snapshot of a volume via /disk add copy-from.
 func (cs *ControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
-	klog.V(4).Infof("CreateSnapshot CSI call for name=%s source=%s", req.GetName(), req.GetSourceVolumeId())
+	logger := klog.FromContext(ctx).WithValues("name", req.GetName(), "volumeID", req.GetSourceVolumeId())
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("CreateSnapshot CSI call")
 
 	// 1. Validate request
 	if req.GetName() == "" {
@@ -1000,29 +1371,50 @@ func (cs *ControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateS
 		return nil, status.Errorf(codes.InvalidArgument, "invalid source volume ID: %v", err)
 	}
 
+	verify, err := ParseVerifySnapshot(req.GetParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
 	// Safety check: ensure RDS client is initialized
 	if cs.driver == nil || cs.driver.rdsClient == nil {
 		return nil, status.Error(codes.Internal, "RDS client not initialized")
 	}
 
-	// 2. Generate deterministic snapshot ID from CSI name + source volume for idempotency.
-	// Format: snap-<source-uuid>-at-<10-char-hash-of-csi-name>
-	// The same (name, sourceVolumeID) pair always produces the same snapshot ID,
-	// satisfying CSI idempotency requirements (external-snapshotter won't re-call
-	// CreateSnapshot, but CSI sanity tests and retries need this determinism).
+	// 2. Generate deterministic snapshot ID from the CSI name for idempotency.
+	// Format: snap-<uuid5-of-csi-name>-at-<10-char-hash>, 55 characters however long the name.
+	// The same name always produces the same snapshot ID, satisfying CSI idempotency
+	// requirements (external-snapshotter won't re-call CreateSnapshot, but CSI sanity
+	// tests and retries need this determinism).
 	snapshotID := utils.GenerateSnapshotID(req.GetName(), sourceVolumeID)
+	ctx = withRDSCall(ctx, "CreateSnapshot", snapshotID)
 
 	// 3. Check idempotency: does a snapshot with this ID already exist?
 	// Since the ID is deterministic, a retry with the same (name, source) returns the same
-	// snapshot rather than creating a duplicate.
-	existingSnapshot, err := cs.driver.rdsClient.GetSnapshot(snapshotID)
+	// snapshot rather than creating a duplicate. Snapshots created before IDs had the -at-
+	// suffix have the legacy ID of the same name, so look for that too.
+	existingID := snapshotID
+	existingBackend, existingSnapshot, err := cs.driver.backendsFor(ctx).FindSnapshot(snapshotID)
+	if err != nil {
+		legacyID := utils.SnapshotNameToID(req.GetName())
+		if legacyBackend, legacySnapshot, legacyErr := cs.driver.backendsFor(ctx).FindSnapshot(legacyID); legacyErr == nil {
+			existingID, existingBackend, existingSnapshot, err = legacyID, legacyBackend, legacySnapshot, nil
+		}
+	}
 	if err == nil {
 		// Snapshot exists -- check if same source volume (idempotent) or different (conflict)
 		if existingSnapshot.SourceVolume == sourceVolumeID {
-			klog.V(2).Infof("Snapshot %s already exists for source %s (idempotent)", snapshotID, sourceVolumeID)
+			logger.V(2).Info("Snapshot already exists (idempotent)", "snapshotID", existingID)
+			// An earlier call may have failed to verify it and then to delete it, so the
+			// snapshot is only reported ready once it verifies
+			if verify {
+				if err := cs.verifySnapshot(existingBackend, existingID, cs.snapshotSize(ctx, existingSnapshot)); err != nil {
+					return nil, err
+				}
+			}
 			return &csi.CreateSnapshotResponse{
 				Snapshot: &csi.Snapshot{
-					SnapshotId:     snapshotID,
+					SnapshotId:     existingID,
 					SourceVolumeId: existingSnapshot.SourceVolume,
 					CreationTime:   timestamppb.New(existingSnapshot.CreatedAt),
 					SizeBytes:      existingSnapshot.FileSizeBytes,
@@ -1032,17 +1424,17 @@ func (cs *ControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateS
 		}
 		return nil, status.Errorf(codes.AlreadyExists,
 			"snapshot %s already exists with different source volume (existing: %s, requested: %s)",
-			snapshotID, existingSnapshot.SourceVolume, sourceVolumeID)
+			existingID, existingSnapshot.SourceVolume, sourceVolumeID)
 	}
 
-	// 4. Verify source volume exists on RDS
-	sourceVolume, err := cs.driver.rdsClient.GetVolume(sourceVolumeID)
+	// 4. Verify source volume exists on RDS. Snapshots are created on the source volume's backend.
+	backend, sourceVolume, err := cs.driver.backendsFor(ctx).FindVolume(sourceVolumeID)
 	if err != nil {
 		var notFoundErr *rds.VolumeNotFoundError
 		if stderrors.As(err, &notFoundErr) {
 			return nil, status.Errorf(codes.NotFound, "source volume %s not found", sourceVolumeID)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get source volume: %v", err)
+		return nil, userFacingError(err, codes.Internal, "failed to get source volume")
 	}
 
 	// 5. Determine base path for snapshot file storage
@@ -1058,20 +1450,24 @@ func (cs *ControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateS
 		Name:         snapshotID,
 		SourceVolume: sourceVolumeID,
 		BasePath:     volumeBasePath,
+		Comment:      diskComment(params[paramSnapshotNamespace], params[paramSnapshotName]),
 	}
 
-	snapshotInfo, err := cs.driver.rdsClient.CreateSnapshot(createOpts)
+	snapshotInfo, err := backend.Client.CreateSnapshot(createOpts)
 	if err != nil {
-		// Map connection errors to Unavailable
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
-		}
-		return nil, status.Errorf(codes.Internal, "failed to create snapshot: %v", err)
+		return nil, userFacingError(err, codes.Internal, "failed to create snapshot")
 	}
 
-	klog.V(2).Infof("Created snapshot %s from volume %s", snapshotID, sourceVolumeID)
+	logger.V(2).Info("Created snapshot", "snapshotID", snapshotID)
 
-	// 7. Return response — /disk add copy-from is atomic (CoW), so ready_to_use is always true.
+	// 7. Optionally check the copy on RDS before reporting it ready
+	if verify {
+		if err := cs.verifySnapshot(backend, snapshotID, sourceVolume.FileSizeBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	// 8. Return response — /disk add copy-from is atomic (CoW), so ready_to_use is always true.
 	return &csi.CreateSnapshotResponse{
 		Snapshot: &csi.Snapshot{
 			SnapshotId:     snapshotID,
@@ -1083,10 +1479,46 @@ func (cs *ControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateS
 	}, nil
 }
 
+// snapshotSize returns the size the backing file of snapshot must have: the size of its
+// disk entry, or of its source volume if RDS reports none
+func (cs *ControllerServer) snapshotSize(ctx context.Context, snapshot *rds.SnapshotInfo) int64 {
+	if snapshot.FileSizeBytes != 0 {
+		return snapshot.FileSizeBytes
+	}
+	if _, sourceVolume, err := cs.driver.backendsFor(ctx).FindVolume(snapshot.SourceVolume); err == nil {
+		return sourceVolume.FileSizeBytes
+	}
+	return 0
+}
+
+// verifySnapshot checks that the backing file of a new snapshot matches its source volume
+// (verifySnapshot parameter). A snapshot that fails or cannot be verified is deleted, so
+// a retried CreateSnapshot copies it again rather than finding it and reporting it ready.
+func (cs *ControllerServer) verifySnapshot(backend *rds.Backend, snapshotID string, sizeBytes int64) error {
+	err := backend.Client.VerifySnapshot(snapshotID, sizeBytes)
+	if err == nil {
+		klog.V(2).Infof("Verified snapshot %s", snapshotID)
+		return nil
+	}
+
+	klog.Errorf("Snapshot %s not verified, deleting it: %v", snapshotID, err)
+	if delErr := backend.Client.DeleteSnapshot(snapshotID); delErr != nil {
+		klog.Errorf("Failed to delete unverified snapshot %s: %v", snapshotID, delErr)
+	}
+
+	var verifyErr *rds.SnapshotVerificationError
+	if stderrors.As(err, &verifyErr) {
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	return userFacingError(err, codes.Internal, "failed to verify snapshot")
+}
+
 // DeleteSnapshot removes a file-based CoW snapshot (disk entry + backing file)
 func (cs *ControllerServer) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
 	snapshotID := req.GetSnapshotId()
-	klog.V(4).Infof("DeleteSnapshot CSI call for %s", snapshotID)
+	logger := klog.FromContext(ctx).WithValues("snapshotID", snapshotID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("DeleteSnapshot CSI call")
 
 	// 1. Validate request
 	if snapshotID == "" {
@@ -1097,22 +1529,38 @@ func (cs *ControllerServer) DeleteSnapshot(ctx context.Context, req *csi.DeleteS
 	if err := utils.ValidateSnapshotID(snapshotID); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid snapshot ID: %v", err)
 	}
+	ctx = withRDSCall(ctx, "DeleteSnapshot", snapshotID)
 
 	// Safety check
 	if cs.driver == nil || cs.driver.rdsClient == nil {
 		return nil, status.Error(codes.Internal, "RDS client not initialized")
 	}
 
-	// 2. Delete snapshot via RDS (idempotent -- RDS client returns nil for not-found)
-	if err := cs.driver.rdsClient.DeleteSnapshot(snapshotID); err != nil {
-		// Map connection errors
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
+	// 2. Refuse while a restore is copying from the snapshot; the provisioner retries
+	endDelete, restoring := cs.driver.snapshotRestores.beginDelete(snapshotID)
+	if len(restoring) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"snapshot %s in use by restore of volume %s", snapshotID, strings.Join(restoring, ", "))
+	}
+	defer endDelete()
+
+	// 3. Find the backend holding the snapshot; not found anywhere means already deleted
+	backend, _, err := cs.driver.backendsFor(ctx).FindSnapshot(snapshotID)
+	if err != nil {
+		var notFoundErr *rds.SnapshotNotFoundError
+		if stderrors.As(err, &notFoundErr) {
+			logger.V(4).Info("Snapshot not found on any backend, assuming already deleted")
+			return &csi.DeleteSnapshotResponse{}, nil
 		}
-		return nil, status.Errorf(codes.Internal, "failed to delete snapshot: %v", err)
+		return nil, userFacingError(err, codes.Internal, "failed to look up snapshot")
 	}
 
-	klog.V(2).Infof("Deleted snapshot %s", snapshotID)
+	// 4. Delete snapshot via RDS (idempotent -- RDS client returns nil for not-found)
+	if err := backend.Client.DeleteSnapshot(snapshotID); err != nil {
+		return nil, userFacingError(err, codes.Internal, "failed to delete snapshot")
+	}
+
+	logger.V(2).Info("Deleted snapshot")
 	return &csi.DeleteSnapshotResponse{}, nil
 }
 
@@ -1134,7 +1582,7 @@ func (cs *ControllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnap
 			return &csi.ListSnapshotsResponse{}, nil
 		}
 
-		snap, err := cs.driver.rdsClient.GetSnapshot(snapshotID)
+		_, snap, err := cs.driver.backendsFor(ctx).FindSnapshot(snapshotID)
 		if err != nil {
 			// Not found -> return empty response (not error)
 			return &csi.ListSnapshotsResponse{}, nil
@@ -1155,19 +1603,38 @@ func (cs *ControllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnap
 		}, nil
 	}
 
-	// Fetch all snapshots from RDS
-	allSnapshots, err := cs.driver.rdsClient.ListSnapshots()
-	if err != nil {
-		if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) {
-			return nil, status.Errorf(codes.Unavailable, "RDS unavailable: %v", err)
+	// A source volume ID that cannot name a slot has no snapshots
+	if req.GetSourceVolumeId() != "" {
+		if err := utils.ValidateSlotName(req.GetSourceVolumeId()); err != nil {
+			return &csi.ListSnapshotsResponse{}, nil
 		}
-		return nil, status.Errorf(codes.Internal, "failed to list snapshots: %v", err)
 	}
 
-	// Filter by source volume if specified.
+	// Fetch the snapshots from every RDS backend, only those of the source volume if one is
+	// given so RDS does the filtering
+	var allSnapshots []rds.SnapshotInfo
+	for _, backend := range cs.driver.backendsFor(ctx).Backends() {
+		var snapshots []rds.SnapshotInfo
+		var err error
+		if req.GetSourceVolumeId() != "" {
+			snapshots, err = backend.Client.ListSnapshotsBySource(req.GetSourceVolumeId())
+		} else {
+			snapshots, err = backend.Client.ListSnapshots()
+		}
+		if err != nil {
+			if stderrors.Is(err, utils.ErrConnectionFailed) || stderrors.Is(err, utils.ErrOperationTimeout) || stderrors.Is(err, rds.ErrRetryBudgetExhausted) {
+				return nil, status.Errorf(codes.Unavailable, "RDS backend %s unavailable: %v", backend.Name, err)
+			}
+			return nil, status.Errorf(codes.Internal, "failed to list snapshots on backend %s: %v", backend.Name, err)
+		}
+		allSnapshots = append(allSnapshots, snapshots...)
+	}
+
+	// Filter by source volume if specified. ListSnapshotsBySource falls back to listing
+	// all snapshots when RDS cannot filter, so this stays as the backstop.
 	// SourceVolume is populated by parseSnapshotInfo from the source-volume= field in
-	// RDS /disk print output. Snapshots without a source-volume field are excluded from
-	// source-based filtering (they cannot be matched to a source volume).
+	// RDS /disk print output, or else from the snapshot comment. Snapshots with neither
+	// are excluded from source-based filtering (they cannot be matched to a source volume).
 	if req.GetSourceVolumeId() != "" {
 		filtered := make([]rds.SnapshotInfo, 0)
 		for _, s := range allSnapshots {
@@ -1231,7 +1698,9 @@ func (cs *ControllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnap
 // ControllerExpandVolume expands a volume on the backend storage
 func (cs *ControllerServer) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
 	volumeID := req.GetVolumeId()
-	klog.V(4).Infof("ControllerExpandVolume CSI call for %s", volumeID)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("ControllerExpandVolume CSI call")
 
 	// Validate request
 	if volumeID == "" {
@@ -1242,6 +1711,7 @@ func (cs *ControllerServer) ControllerExpandVolume(ctx context.Context, req *csi
 	if err := utils.ValidateVolumeID(volumeID); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid volume ID: %v", err)
 	}
+	ctx = withRDSCall(ctx, "ControllerExpandVolume", volumeID)
 
 	// Get required capacity
 	requiredBytes := req.GetCapacityRange().GetRequiredBytes()
@@ -1250,8 +1720,9 @@ func (cs *ControllerServer) ControllerExpandVolume(ctx context.Context, req *csi
 	}
 
 	// Enforce size limits
-	if requiredBytes < minVolumeSizeBytes {
-		return nil, status.Errorf(codes.OutOfRange, "required bytes %d is less than minimum %d", requiredBytes, minVolumeSizeBytes)
+	minBytes, maxBytes := cs.volumeSizeBounds()
+	if requiredBytes < minBytes {
+		return nil, status.Errorf(codes.OutOfRange, "required bytes %d is less than minimum %d", requiredBytes, minBytes)
 	}
 
 	limitBytes := req.GetCapacityRange().GetLimitBytes()
@@ -1259,20 +1730,37 @@ func (cs *ControllerServer) ControllerExpandVolume(ctx context.Context, req *csi
 		return nil, status.Errorf(codes.OutOfRange, "required bytes %d exceeds limit bytes %d", requiredBytes, limitBytes)
 	}
 
-	if requiredBytes > maxVolumeSizeBytes {
-		return nil, status.Errorf(codes.OutOfRange, "required bytes %d exceeds maximum %d", requiredBytes, maxVolumeSizeBytes)
+	if requiredBytes > maxBytes {
+		return nil, status.Errorf(codes.OutOfRange, "required bytes %d exceeds maximum %d", requiredBytes, maxBytes)
+	}
+
+	// RouterOS truncates file sizes to their largest unit, so grow to the next size it can
+	// represent rather than less than requested, and report that size as the capacity
+	provisionedBytes, err := RoundVolumeSize(requiredBytes, SizeRoundingUp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	if provisionedBytes != requiredBytes {
+		logger.V(2).Info("Rounded expanded size", "requiredBytes", requiredBytes, "provisionedBytes", provisionedBytes)
+		if limitBytes > 0 && provisionedBytes > limitBytes {
+			return nil, status.Errorf(codes.OutOfRange, "rounded size %d bytes exceeds limit bytes %d", provisionedBytes, limitBytes)
+		}
+		if provisionedBytes > maxBytes {
+			return nil, status.Errorf(codes.OutOfRange, "rounded size %d bytes exceeds maximum %d", provisionedBytes, maxBytes)
+		}
+		requiredBytes = provisionedBytes
 	}
 
 	// Check if volume exists
-	existingVolume, err := cs.driver.rdsClient.GetVolume(volumeID)
+	backend, existingVolume, err := cs.findVolume(ctx, volumeID, nil)
 	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "volume %s not found: %v", volumeID, err)
 	}
 
 	// Check if expansion is needed
 	if existingVolume.FileSizeBytes >= requiredBytes {
-		klog.V(4).Infof("Volume %s already at or above requested size (%d >= %d), no expansion needed",
-			volumeID, existingVolume.FileSizeBytes, requiredBytes)
+		logger.V(4).Info("Volume already at or above requested size, no expansion needed",
+			"sizeBytes", existingVolume.FileSizeBytes, "requiredBytes", requiredBytes)
 		return &csi.ControllerExpandVolumeResponse{
 			CapacityBytes:         existingVolume.FileSizeBytes,
 			NodeExpansionRequired: false,
@@ -1280,34 +1768,45 @@ func (cs *ControllerServer) ControllerExpandVolume(ctx context.Context, req *csi
 	}
 
 	// Resize volume on RDS
-	klog.V(4).Infof("Expanding volume %s from %d to %d bytes", volumeID, existingVolume.FileSizeBytes, requiredBytes)
+	logger.V(4).Info("Expanding volume", "fromBytes", existingVolume.FileSizeBytes, "toBytes", requiredBytes)
 
-	if err := cs.driver.rdsClient.ResizeVolume(volumeID, requiredBytes); err != nil {
-		// Check if this is a capacity error
-		if stderrors.Is(err, utils.ErrResourceExhausted) {
-			return nil, status.Errorf(codes.ResourceExhausted, "insufficient storage on RDS for expansion: %v", err)
-		}
-		return nil, status.Errorf(codes.Internal, "failed to resize volume on RDS: %v", err)
+	if err := backend.Client.ResizeVolume(existingVolume.Slot, requiredBytes); err != nil {
+		return nil, userFacingError(err, codes.Internal, "failed to resize volume on RDS")
+	}
+
+	provisioned, err := cs.provisionedVolume(ctx, backend, existingVolume.Slot, requiredBytes)
+	if err != nil {
+		return nil, err
 	}
+	requiredBytes = provisioned.FileSizeBytes
 
 	// RDS layer already logged "Resized volume X" at V(2) - no duplicate needed
-	klog.V(4).Infof("ControllerExpandVolume CSI call completed for %s", volumeID)
+	logger.V(4).Info("ControllerExpandVolume CSI call completed")
+
+	cs.refreshDiskComment(ctx, backend, volumeID, existingVolume)
 
-	// Determine if node expansion is required
-	// For mount volumes: yes, to resize the filesystem (ext4, xfs, etc.)
-	// For block volumes: no, kernel sees new size automatically via NVMe rescan
-	nodeExpansionRequired := true
-	if cap := req.GetVolumeCapability(); cap != nil {
-		if cap.GetBlock() != nil {
-			nodeExpansionRequired = false
-			klog.V(4).Infof("Block volume expansion for %s - node expansion not required", volumeID)
+	// Node expansion is always required. For mount volumes NodeExpandVolume rescans the
+	// namespace and grows the filesystem (ext4, xfs, etc.); for block volumes it only
+	// rescans, so the device reports the new size. Detached volumes are expanded on the
+	// node when they are next published.
+	if req.GetVolumeCapability().GetBlock() != nil {
+		logger.V(4).Info("Block volume expanded - node must rescan, no filesystem to grow")
+	}
+
+	// Record a pending node resize on attached volumes until NodeExpandVolume confirms it
+	if am := cs.driver.GetAttachmentManager(); am != nil {
+		if _, err := am.MarkPendingNodeResize(ctx, volumeID, requiredBytes); err != nil {
+			logger.Info("Failed to persist pending node resize", "err", err)
 		}
 	}
 
-	// Return response indicating node expansion is required to resize the filesystem
+	if cs.driver.metrics != nil {
+		cs.driver.metrics.RecordVolumeExpansion(observability.ExpansionPhasePending)
+	}
+
 	return &csi.ControllerExpandVolumeResponse{
 		CapacityBytes:         requiredBytes,
-		NodeExpansionRequired: nodeExpansionRequired,
+		NodeExpansionRequired: true,
 	}, nil
 }
 
@@ -1315,20 +1814,32 @@ func (cs *ControllerServer) ControllerExpandVolume(ctx context.Context, req *csi
 func (cs *ControllerServer) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
 	klog.V(4).Info("ListVolumes called")
 
-	// Query all volumes from RDS
-	volumes, err := cs.driver.rdsClient.ListVolumes()
-	if err != nil {
-		klog.Errorf("Failed to list volumes from RDS: %v", err)
-		return nil, status.Errorf(codes.Internal, "failed to list volumes: %v", err)
+	// Query all volumes from every RDS backend
+	var volumes []rds.VolumeInfo
+	for _, backend := range cs.driver.backendsFor(ctx).Backends() {
+		backendVolumes, err := backend.Client.ListVolumes()
+		if cs.driver.volumeCache != nil {
+			cs.driver.volumeCache.record(backend.Name, backendVolumes, err)
+		}
+		if err != nil {
+			klog.Errorf("Failed to list volumes from RDS backend %s: %v", backend.Name, err)
+			return nil, status.Errorf(codes.Internal, "failed to list volumes on backend %s: %v", backend.Name, err)
+		}
+		volumes = append(volumes, backendVolumes...)
 	}
 
-	// Convert to CSI format
+	// Convert to CSI format, reporting only slots this driver manages
 	var entries []*csi.ListVolumesResponse_Entry
 	for _, vol := range volumes {
+		if !utils.IsManagedSlot(vol.Slot) {
+			klog.V(5).Infof("ListVolumes: skipping unmanaged slot %s", vol.Slot)
+			continue
+		}
 		entries = append(entries, &csi.ListVolumesResponse_Entry{
 			Volume: &csi.Volume{
 				VolumeId:      vol.Slot,
 				CapacityBytes: vol.FileSizeBytes,
+				VolumeContext: diskCommentContext(vol.Comment),
 			},
 		})
 	}
@@ -1338,18 +1849,147 @@ func (cs *ControllerServer) ListVolumes(ctx context.Context, req *csi.ListVolume
 	}, nil
 }
 
-// ControllerGetVolume is not yet implemented
+// ControllerGetVolume returns a volume's capacity, the nodes it is published to,
+// and a condition reporting any resize still waiting for node confirmation
 func (cs *ControllerServer) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "ControllerGetVolume is not yet implemented")
+	volumeID := req.GetVolumeId()
+	klog.V(4).Infof("ControllerGetVolume CSI call for %s", volumeID)
+
+	if volumeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume ID is required")
+	}
+
+	if err := utils.ValidateVolumeID(volumeID); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid volume ID: %v", err)
+	}
+
+	_, volume, err := cs.findVolume(ctx, volumeID, nil)
+	if err != nil {
+		if isVolumeNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "volume %s not found", volumeID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get volume %s: %v", volumeID, err)
+	}
+
+	condition := &csi.VolumeCondition{
+		Abnormal: false,
+		Message:  "volume is healthy",
+	}
+	var publishedNodes []string
+	if am := cs.driver.GetAttachmentManager(); am != nil {
+		if state, exists := am.GetAttachment(volumeID); exists {
+			publishedNodes = state.GetNodeIDs()
+		}
+		if pending := am.GetPendingNodeResize(ctx, volumeID); pending != nil {
+			condition.Message = fmt.Sprintf("resize in progress: pending node resize to %d bytes since %s",
+				pending.RequestedBytes, pending.Since.UTC().Format(time.RFC3339))
+		}
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      volumeID,
+			CapacityBytes: volume.FileSizeBytes,
+			VolumeContext: diskCommentContext(volume.Comment),
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			PublishedNodeIds: publishedNodes,
+			VolumeCondition:  condition,
+		},
+	}, nil
 }
 
-// ControllerModifyVolume is not yet implemented
+// ControllerModifyVolume applies VolumeAttributesClass parameters to an existing volume.
+// The comment is written to the RDS disk entry; both parameters are recorded as PV
+// annotations so later operations (e.g. expansion) keep them.
 func (cs *ControllerServer) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "ControllerModifyVolume is not yet implemented")
+	volumeID := req.GetVolumeId()
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("ControllerModifyVolume CSI call")
+
+	if volumeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume ID is required")
+	}
+	if err := utils.ValidateVolumeID(volumeID); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid volume ID: %v", err)
+	}
+	ctx = withRDSCall(ctx, "ControllerModifyVolume", volumeID)
+
+	mutable, err := ParseMutableParameters(req.GetMutableParameters())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid mutable parameters: %v", err)
+	}
+
+	backend, volume, err := cs.findVolume(ctx, volumeID, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "volume %s not found: %v", volumeID, err)
+	}
+
+	if mutable.Comment != "" && mutable.Comment != volume.Comment {
+		if err := backend.Client.SetDiskComment(volume.Slot, mutable.Comment); err != nil {
+			return nil, userFacingError(err, codes.Internal, fmt.Sprintf("failed to set comment on volume %s", volumeID))
+		}
+		logger.V(2).Info("Set disk comment", "comment", mutable.Comment)
+	}
+
+	if err := cs.annotateMutableParameters(ctx, volumeID, mutable); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record mutable parameters on PV %s: %v", volumeID, err)
+	}
+
+	logger.V(4).Info("ControllerModifyVolume CSI call completed")
+	return &csi.ControllerModifyVolumeResponse{}, nil
 }
 
 // Helper functions
 
+// provisionedVolume reads a volume back from RDS after it was created or resized for
+// requiredBytes. The size RouterOS allocated can differ from the request, so responses
+// report the size RDS has rather than the one asked for; a volume smaller than requested
+// is an error, returned along with the volume so a caller that created it can remove it.
+// The WWID of the volume comes from the same read.
+func (cs *ControllerServer) provisionedVolume(ctx context.Context, backend *rds.Backend, volumeID string, requiredBytes int64) (*rds.VolumeInfo, error) {
+	volume, err := backend.Client.GetVolume(volumeID)
+	if err != nil {
+		return nil, userFacingError(err, codes.Internal, fmt.Sprintf("failed to read volume %s back from RDS", volumeID))
+	}
+	if volume.FileSizeBytes < requiredBytes {
+		return volume, status.Errorf(codes.Internal, "volume %s has %d bytes on RDS, less than the %d bytes requested",
+			volumeID, volume.FileSizeBytes, requiredBytes)
+	}
+	if unit := rds.FileSizeGranularity(requiredBytes); volume.FileSizeBytes-requiredBytes > unit {
+		klog.FromContext(ctx).V(2).Info("Volume size on RDS exceeds the request by more than the allocation unit",
+			"sizeBytes", volume.FileSizeBytes, "requestedBytes", requiredBytes, "allocationUnit", unit)
+	}
+	return volume, nil
+}
+
+// removeUndersizedVolume removes a volume CreateVolume just made smaller than requested,
+// so the retry creates it again rather than finding it with the wrong size
+func removeUndersizedVolume(ctx context.Context, backend *rds.Backend, volumeID string) {
+	logger := klog.FromContext(ctx)
+	if err := backend.Client.DeleteVolume(volumeID); err != nil {
+		logger.Info("Failed to remove volume created smaller than requested", "err", err)
+		return
+	}
+	logger.V(2).Info("Removed volume created smaller than requested")
+}
+
+// findFile returns the file at exactly path on RDS, or nil if there is none.
+// ListFiles matches by pattern, so longer names sharing the prefix are skipped.
+func findFile(client rds.RDSClient, path string) (*rds.FileInfo, error) {
+	files, err := client.ListFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		if files[i].Path == path {
+			return &files[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // validateVolumeCapabilities checks if the requested capabilities are supported
 func (cs *ControllerServer) validateVolumeCapabilities(caps []*csi.VolumeCapability) error {
 	for _, cap := range caps {
@@ -1376,7 +2016,7 @@ func (cs *ControllerServer) validateVolumeCapabilities(caps []*csi.VolumeCapabil
 		// RWX with filesystem volumes risks data corruption - reject with actionable error
 		if accessMode == csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER {
 			if cap.GetMount() != nil {
-				return fmt.Errorf("RWX access mode requires volumeMode: Block. " +
+				return fmt.Errorf("MULTI_NODE_MULTI_WRITER: RWX access mode requires volumeMode: Block. " +
 					"Filesystem volumes risk data corruption with multi-node access. " +
 					"For KubeVirt VM live migration, use volumeMode: Block in your PVC")
 			}
@@ -1388,22 +2028,270 @@ func (cs *ControllerServer) validateVolumeCapabilities(caps []*csi.VolumeCapabil
 	return nil
 }
 
+// volumeSizeBounds returns the smallest and largest volume size in bytes: the built-in
+// limits, narrowed by --min-volume-size and --max-volume-size
+func (cs *ControllerServer) volumeSizeBounds() (int64, int64) {
+	minBytes, maxBytes := int64(minVolumeSizeBytes), int64(maxVolumeSizeBytes)
+	if cs.driver.minVolumeSize > minBytes {
+		minBytes = cs.driver.minVolumeSize
+	}
+	if cs.driver.maxVolumeSize > 0 && cs.driver.maxVolumeSize < maxBytes {
+		maxBytes = cs.driver.maxVolumeSize
+	}
+	return minBytes, maxBytes
+}
+
+// requestedFSTypes returns the fsTypes a CreateVolume request would put on the volume: the
+// fsType parameter, and for each filesystem capability the filesystem the node formats
+func requestedFSTypes(caps []*csi.VolumeCapability, params map[string]string) []string {
+	var fsTypes []string
+	if fsType := params[paramFSType]; fsType != "" {
+		fsTypes = append(fsTypes, fsType)
+	}
+	for _, volCap := range caps {
+		if mnt := volCap.GetMount(); mnt != nil && mnt.FsType != "" {
+			fsTypes = append(fsTypes, mnt.FsType)
+		} else if mnt != nil && params[paramFSType] == "" {
+			fsTypes = append(fsTypes, defaultFSType)
+		}
+	}
+	return fsTypes
+}
+
+// hasMultiWriterCapability reports whether any capability requests MULTI_NODE_MULTI_WRITER
+func hasMultiWriterCapability(caps []*csi.VolumeCapability) bool {
+	for _, volCap := range caps {
+		if volCap.GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER {
+			return true
+		}
+	}
+	return false
+}
+
+// diskComment builds the "<namespace>/<name>" comment written on RDS disk entries so
+// operators can tell which PVC or VolumeSnapshot a slot belongs to in /disk print.
+// Returns "" if the provisioner did not pass --extra-create-metadata.
+func diskComment(namespace, name string) string {
+	if namespace == "" || name == "" {
+		return ""
+	}
+	return utils.SanitizeDiskComment(namespace + "/" + name)
+}
+
+// checkRestoreNamespace returns PermissionDenied if the snapshot was taken in another
+// namespace than the PVC restoring it. The snapshot's namespace comes from its disk
+// comment, which CreateSnapshot writes as "<namespace>/<name>" with
+// --extra-create-metadata; a VolumeSnapshot always lives in its source PVC's namespace.
+// Restores that cannot be checked because either namespace is unknown are allowed.
+func checkRestoreNamespace(snapshotID, snapshotComment, pvcNamespace string) error {
+	snapshotNamespace, _, ok := strings.Cut(snapshotComment, "/")
+	if !ok || snapshotNamespace == "" || pvcNamespace == "" {
+		klog.Warningf("Cannot check that snapshot %s is restored in its own namespace (snapshot comment %q, PVC namespace %q); allowing the restore",
+			snapshotID, snapshotComment, pvcNamespace)
+		return nil
+	}
+	if snapshotNamespace != pvcNamespace {
+		return status.Errorf(codes.PermissionDenied,
+			"snapshot %s belongs to namespace %s and cannot be restored in namespace %s unless the StorageClass sets %s: \"true\"",
+			snapshotID, snapshotNamespace, pvcNamespace, paramAllowCrossNamespaceRestore)
+	}
+	return nil
+}
+
+// volumeDiskComment returns the disk comment for a new volume: the comment mutable
+// parameter if set, otherwise the PVC passed by --extra-create-metadata
+func volumeDiskComment(params map[string]string, mutable MutableParameters) string {
+	if mutable.Comment != "" {
+		return mutable.Comment
+	}
+	return diskComment(params[paramPVCNamespace], params[paramPVCName])
+}
+
+// diskCommentContext reports a volume's RDS disk comment in ListVolumes/ControllerGetVolume
+func diskCommentContext(comment string) map[string]string {
+	if comment == "" {
+		return nil
+	}
+	return map[string]string{volumeContextDiskComment: comment}
+}
+
+// volumeContextOptionalKeys are dropped from a volume context above the hard metadata
+// limit, in this order: the disk comment, then filePath, which repeats volumePath. The
+// WWID is never dropped, as the node verifies the connected device with it.
+var volumeContextOptionalKeys = []string{volumeContextDiskComment, volumeContextFilePath}
+
+// maxWWIDLength is the length of the longest WWID RDS reports, an NGUID as eui.<32 hex>
+const maxWWIDLength = len("eui.") + 32
+
+// checkVolumeContextFits fails with InvalidArgument if volumeContext cannot fit the hard
+// metadata limit once the WWID of the new volume is added, so that CreateVolume refuses
+// the request before creating a disk it would have to leave behind
+func (cs *ControllerServer) checkVolumeContextFits(volumeID string, volumeContext map[string]string) error {
+	withWWID := maps.Clone(volumeContext)
+	withWWID[volumeContextWWID] = strings.Repeat("0", maxWWIDLength)
+	if _, _, err := utils.FitMetadata(withWWID, cs.driver.metadataLimits, volumeContextOptionalKeys); err != nil {
+		return status.Errorf(codes.InvalidArgument, "volume context of %s: %v", volumeID, err)
+	}
+	return nil
+}
+
+// fitVolumeContext enforces the metadata limits on the volume context of volumeID, which
+// ends up in the attributes of its PV. It logs a warning above the soft limit, drops
+// optional keys above the hard limit, and fails if that is not enough.
+func (cs *ControllerServer) fitVolumeContext(volumeID string, volumeContext map[string]string) error {
+	limits := cs.driver.metadataLimits
+	if cs.driver.metrics != nil {
+		cs.driver.metrics.RecordMetadataSize("volume_context", utils.MetadataSize(volumeContext))
+	}
+	trimmed, size, err := utils.FitMetadata(volumeContext, limits, volumeContextOptionalKeys)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "volume context of %s: %v", volumeID, err)
+	}
+	if len(trimmed) > 0 {
+		klog.Warningf("Dropped %v from the volume context of %s to fit the %d byte limit", trimmed, volumeID, limits.HardLimit())
+	} else if size > limits.SoftLimit() {
+		klog.V(1).Infof("Warning: volume context of %s is %d bytes, above the %d byte soft limit", volumeID, size, limits.SoftLimit())
+	}
+	return nil
+}
+
+// refreshDiskComment rewrites a volume's disk comment from its PV claimRef, so volumes
+// created without --extra-create-metadata (or before comments existed) get labelled.
+// A comment set through ControllerModifyVolume (recorded on the PV) takes precedence.
+// Best effort - failures are logged but don't affect the main operation.
+func (cs *ControllerServer) refreshDiskComment(ctx context.Context, backend *rds.Backend, volumeID string, volume *rds.VolumeInfo) {
+	if cs.driver.k8sClient == nil {
+		return
+	}
+
+	pv, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if err != nil || pv.Spec.ClaimRef == nil {
+		klog.V(4).Infof("Cannot determine PVC for volume %s, leaving disk comment unchanged", volumeID)
+		return
+	}
+
+	comment := diskComment(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+	if modified := pv.Annotations[AnnotationComment]; modified != "" {
+		comment = modified
+	}
+	if comment == "" || comment == volume.Comment {
+		return
+	}
+	if err := backend.Client.SetDiskComment(volume.Slot, comment); err != nil {
+		klog.Warningf("Failed to update disk comment for volume %s: %v", volume.Slot, err)
+	}
+}
+
+// annotateMutableParameters records the specified mutable parameters as annotations on
+// the volume's PV. A missing PV (or no Kubernetes client) is not an error.
+func (cs *ControllerServer) annotateMutableParameters(ctx context.Context, volumeID string, mutable MutableParameters) error {
+	if cs.driver.k8sClient == nil {
+		return nil
+	}
+
+	annotations := map[string]string{}
+	if mutable.Comment != "" {
+		annotations[AnnotationComment] = mutable.Comment
+	}
+	if mutable.QoSTier != "" {
+		annotations[AnnotationQoSTier] = mutable.QoSTier
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	pvs := cs.driver.k8sClient.CoreV1().PersistentVolumes()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv, err := pvs.Get(ctx, volumeID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		changed := false
+		for key, value := range annotations {
+			if pv.Annotations[key] != value {
+				if pv.Annotations == nil {
+					pv.Annotations = map[string]string{}
+				}
+				pv.Annotations[key] = value
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		_, err = pvs.Update(ctx, pv, metav1.UpdateOptions{})
+		return err
+	})
+	if errors.IsNotFound(err) {
+		klog.V(4).Infof("PV %s not found, mutable parameters not recorded", volumeID)
+		return nil
+	}
+	return err
+}
+
+// backendForParams returns the RDS backend selected by the StorageClass "backend"
+// parameter, or the default backend if none is set
+func (cs *ControllerServer) backendForParams(ctx context.Context, params map[string]string) (*rds.Backend, error) {
+	backend, err := cs.driver.backendsFor(ctx).Get(params[paramBackend])
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s parameter: %v", paramBackend, err)
+	}
+	return backend, nil
+}
+
+// findVolume returns the backend and info of a volume. The backend recorded in the
+// volume context is used when present; otherwise (older PVs, calls without a volume
+// context) all backends are searched. A volume whose slot was renamed on RDS is found by
+// its recorded slot or backing file; callers must address RDS by the returned Slot.
+func (cs *ControllerServer) findVolume(ctx context.Context, volumeID string, volumeContext map[string]string) (*rds.Backend, *rds.VolumeInfo, error) {
+	backend, volume, err := cs.findVolumeBySlot(ctx, volumeID, volumeContext)
+	if err == nil || !isVolumeNotFound(err) {
+		return backend, volume, err
+	}
+	if renamedBackend, renamedVolume, renamedErr := cs.findRenamedVolume(ctx, volumeID, volumeContext); renamedErr == nil {
+		return renamedBackend, renamedVolume, nil
+	} else if !isVolumeNotFound(renamedErr) {
+		return nil, nil, renamedErr
+	}
+	return nil, nil, err
+}
+
+// findVolumeBySlot looks a volume up under the slot named after its volume ID
+func (cs *ControllerServer) findVolumeBySlot(ctx context.Context, volumeID string, volumeContext map[string]string) (*rds.Backend, *rds.VolumeInfo, error) {
+	if name := volumeContext[paramBackend]; name != "" {
+		backend, err := cs.driver.backendsFor(ctx).Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		volume, err := backend.Client.GetVolume(volumeID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return backend, volume, nil
+	}
+	return cs.driver.backendsFor(ctx).FindVolume(volumeID)
+}
+
 // getRDSAddress extracts RDS address from parameters
-func (cs *ControllerServer) getRDSAddress(params map[string]string) string {
+func (cs *ControllerServer) getRDSAddress(backend *rds.Backend, params map[string]string) string {
 	if addr, ok := params[paramRDSAddress]; ok {
 		return addr
 	}
-	// Fall back to driver's RDS client address
-	return cs.driver.rdsClient.GetAddress()
+	// Fall back to the backend's RDS client address
+	return backend.Client.GetAddress()
 }
 
-// getNVMEAddress gets the NVMe/TCP target address from params or falls back to RDS address
-func (cs *ControllerServer) getNVMEAddress(params map[string]string) string {
+// getNVMEAddress gets the NVMe/TCP target address from params or falls back to the backend's address
+func (cs *ControllerServer) getNVMEAddress(backend *rds.Backend, params map[string]string) string {
 	// Prefer nvmeAddress if specified (for separate storage network)
 	if addr, ok := params[paramNVMEAddress]; ok {
 		return addr
 	}
-	// Fall back to RDS address if nvmeAddress not specified
-	return cs.getRDSAddress(params)
+	// Then the backend's configured storage address, then its RDS address
+	if backend.NVMEAddress != "" {
+		return backend.NVMEAddress
+	}
+	return cs.getRDSAddress(backend, params)
 }
 
@@ -3,6 +3,8 @@ package driver
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -11,11 +13,13 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
@@ -281,30 +285,6 @@ func TestDeleteVolumeValidation(t *testing.T) {
 	}
 }
 
-func TestUnimplementedMethods(t *testing.T) {
-	cs := &ControllerServer{
-		driver: &Driver{},
-	}
-
-	// Test all unimplemented methods return Unimplemented error
-	// Note: ControllerPublishVolume and ControllerUnpublishVolume are now implemented
-	// Note: ControllerExpandVolume is now implemented, so it's not in this test
-	// Note: CreateSnapshot, DeleteSnapshot, and ListSnapshots are now implemented, so they're not in this test
-
-	// Currently all previously unimplemented methods have been implemented
-	// This test is kept as a placeholder for future unimplemented methods
-	t.Run("ControllerGetVolume", func(t *testing.T) {
-		_, err := cs.ControllerGetVolume(context.Background(), &csi.ControllerGetVolumeRequest{})
-		if err == nil {
-			t.Error("Expected unimplemented error")
-		}
-		st, _ := status.FromError(err)
-		if st.Code() != codes.Unimplemented {
-			t.Errorf("Expected Unimplemented code, got %v", st.Code())
-		}
-	})
-}
-
 func TestParseEndpoint(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -470,6 +450,9 @@ func TestControllerPublishVolume_Success(t *testing.T) {
 	if resp.PublishContext["fs_type"] == "" {
 		t.Error("fs_type missing from PublishContext")
 	}
+	if _, err := time.Parse(time.RFC3339Nano, resp.PublishContext[publishContextPublishedAt]); err != nil {
+		t.Errorf("invalid %s in PublishContext: %v", publishContextPublishedAt, err)
+	}
 }
 
 func TestControllerPublishVolume_Idempotent(t *testing.T) {
@@ -498,12 +481,13 @@ func TestControllerPublishVolume_Idempotent(t *testing.T) {
 	}
 
 	// First publish
-	_, err := cs.ControllerPublishVolume(ctx, req)
+	first, err := cs.ControllerPublishVolume(ctx, req)
 	if err != nil {
 		t.Fatalf("First publish failed: %v", err)
 	}
 
 	// Second publish (same node) - should succeed
+	time.Sleep(time.Millisecond)
 	resp, err := cs.ControllerPublishVolume(ctx, req)
 	if err != nil {
 		t.Fatalf("Second publish (idempotent) failed: %v", err)
@@ -511,6 +495,10 @@ func TestControllerPublishVolume_Idempotent(t *testing.T) {
 	if resp.PublishContext == nil {
 		t.Error("Idempotent publish should return PublishContext")
 	}
+	// The publish time is that of the attachment, not of the repeated call
+	if !reflect.DeepEqual(resp.PublishContext, first.PublishContext) {
+		t.Errorf("repeated publish returned %v, first returned %v", resp.PublishContext, first.PublishContext)
+	}
 }
 
 func TestControllerPublishVolume_RWOConflict(t *testing.T) {
@@ -1079,6 +1067,258 @@ func TestCreateVolume_RWXFilesystemRejected(t *testing.T) {
 	}
 }
 
+func TestCreateVolume_SharedBlockRiskGuardrail(t *testing.T) {
+	blockCap := func(mode csi.VolumeCapability_AccessMode_Mode) *csi.VolumeCapability {
+		return &csi.VolumeCapability{
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: mode},
+			AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+		}
+	}
+	rwx := blockCap(csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER)
+
+	tests := []struct {
+		name       string
+		params     map[string]string
+		capability *csi.VolumeCapability
+		expectCode codes.Code
+		expectMsg  string
+	}{
+		{name: "RWX without acknowledgment", capability: rwx, expectCode: codes.InvalidArgument, expectMsg: "acknowledgeSharedBlockRisk"},
+		{name: "RWX acknowledged", params: map[string]string{"acknowledgeSharedBlockRisk": "true"}, capability: rwx, expectCode: codes.OK},
+		{name: "RWX acknowledgment declined", params: map[string]string{"acknowledgeSharedBlockRisk": "false"}, capability: rwx, expectCode: codes.InvalidArgument, expectMsg: "corrupted"},
+		{name: "RWX invalid acknowledgment", params: map[string]string{"acknowledgeSharedBlockRisk": "yes please"}, capability: rwx, expectCode: codes.InvalidArgument, expectMsg: "invalid acknowledgeSharedBlockRisk"},
+		{name: "RWX with gfs2", params: map[string]string{"fsType": "gfs2"}, capability: rwx, expectCode: codes.OK},
+		{name: "RWX with ocfs2", params: map[string]string{"fsType": "ocfs2"}, capability: rwx, expectCode: codes.OK},
+		{name: "RWX with ext4 fsType", params: map[string]string{"fsType": "ext4"}, capability: rwx, expectCode: codes.InvalidArgument, expectMsg: "acknowledgeSharedBlockRisk"},
+		{name: "RWO needs no acknowledgment", capability: blockCap(csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER), expectCode: codes.OK},
+		{name: "read-only needs no acknowledgment", capability: blockCap(csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY), expectCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+
+			_, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "pvc-11111111-2222-3333-4444-555555555555",
+				Parameters:         tt.params,
+				VolumeCapabilities: []*csi.VolumeCapability{tt.capability},
+			})
+
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, err)
+			}
+			if tt.expectMsg != "" && !strings.Contains(status.Convert(err).Message(), tt.expectMsg) {
+				t.Errorf("expected error to mention %q, got %q", tt.expectMsg, status.Convert(err).Message())
+			}
+			// Rejected requests never reach RDS
+			_, getErr := mockRDS.GetVolume("pvc-11111111-2222-3333-4444-555555555555")
+			if created := getErr == nil; created != (tt.expectCode == codes.OK) {
+				t.Errorf("volume created = %v, want %v", created, tt.expectCode == codes.OK)
+			}
+		})
+	}
+}
+
+func TestCreateVolume_AllowedFSTypes(t *testing.T) {
+	mountCap := func(fsType string) *csi.VolumeCapability {
+		return &csi.VolumeCapability{
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: fsType}},
+		}
+	}
+	blockCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+	}
+	xfsOnly := map[string]bool{"xfs": true}
+
+	tests := []struct {
+		name       string
+		allowed    map[string]bool
+		params     map[string]string
+		capability *csi.VolumeCapability
+		expectCode codes.Code
+	}{
+		{name: "allowed capability fsType", allowed: xfsOnly, capability: mountCap("xfs"), expectCode: codes.OK},
+		{name: "allowed fsType parameter", allowed: xfsOnly, params: map[string]string{"fsType": "xfs"}, capability: mountCap(""), expectCode: codes.OK},
+		{name: "disallowed capability fsType", allowed: xfsOnly, capability: mountCap("ext4"), expectCode: codes.InvalidArgument},
+		{name: "disallowed fsType parameter", allowed: xfsOnly, params: map[string]string{"fsType": "ext3"}, capability: mountCap("xfs"), expectCode: codes.InvalidArgument},
+		{name: "default ext4 disallowed", allowed: xfsOnly, capability: mountCap(""), expectCode: codes.InvalidArgument},
+		{name: "block without fsType", allowed: xfsOnly, capability: blockCap, expectCode: codes.OK},
+		{name: "default allows ext4", capability: mountCap("ext4"), expectCode: codes.OK},
+		{name: "default allows ext3", capability: mountCap("ext3"), expectCode: codes.OK},
+		{name: "default allows xfs", capability: mountCap("xfs"), expectCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			cs.driver.allowedFSTypes = tt.allowed
+
+			_, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "pvc-11111111-2222-3333-4444-555555555555",
+				Parameters:         tt.params,
+				VolumeCapabilities: []*csi.VolumeCapability{tt.capability},
+			})
+
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, err)
+			}
+			if tt.expectCode == codes.InvalidArgument && !strings.Contains(status.Convert(err).Message(), "allowed: xfs") {
+				t.Errorf("expected error to list the allowed fsTypes, got %q", status.Convert(err).Message())
+			}
+			// Rejected requests never reach RDS
+			_, getErr := mockRDS.GetVolume("pvc-11111111-2222-3333-4444-555555555555")
+			if created := getErr == nil; created != (tt.expectCode == codes.OK) {
+				t.Errorf("volume created = %v, want %v", created, tt.expectCode == codes.OK)
+			}
+		})
+	}
+}
+
+// TestCreateVolume_MetadataLimits tests that optional volume context keys are dropped
+// above the hard metadata limit and that CreateVolume fails before creating the disk if
+// that is not enough
+func TestCreateVolume_MetadataLimits(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	create := func(volumeID string) (*csi.CreateVolumeResponse, error) {
+		return cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:               volumeID,
+			VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+		})
+	}
+
+	resp, err := create(testVolumeID1)
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	fullSize := utils.MetadataSize(resp.Volume.VolumeContext)
+
+	// One byte over: filePath goes, volumePath stays
+	cs.driver.metadataLimits = utils.MetadataLimits{Soft: 1, Hard: fullSize - 1}
+	resp, err = create(testVolumeID2)
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	if _, ok := resp.Volume.VolumeContext[volumeContextFilePath]; ok {
+		t.Error("expected filePath to be dropped")
+	}
+	if resp.Volume.VolumeContext["volumePath"] == "" {
+		t.Error("expected volumePath to be kept")
+	}
+
+	cs.driver.metadataLimits = utils.MetadataLimits{Soft: 1, Hard: 64}
+	_, err = create(testVolumeID3)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "hard limit of 64 bytes") {
+		t.Errorf("expected error to name the hard limit, got %v", err)
+	}
+	// The limit is checked before the disk is created, so nothing is left on RDS
+	if _, err := mockRDS.GetVolume(testVolumeID3); err == nil {
+		t.Error("expected no disk for a volume context above the hard limit")
+	}
+}
+
+func TestCreateVolume_SizePolicy(t *testing.T) {
+	const gib = int64(1024 * 1024 * 1024)
+
+	tests := []struct {
+		name       string
+		minSize    int64
+		maxSize    int64
+		required   int64
+		limit      int64
+		expectCode codes.Code
+		expectSize int64
+	}{
+		{name: "no request gets the minimum", minSize: 5 * gib, expectCode: codes.OK, expectSize: 5 * gib},
+		{name: "under minimum grows to it", minSize: 5 * gib, required: 2 * gib, expectCode: codes.OK, expectSize: 5 * gib},
+		{name: "under minimum within limit", minSize: 5 * gib, required: 2 * gib, limit: 10 * gib, expectCode: codes.OK, expectSize: 5 * gib},
+		{name: "limit below minimum", minSize: 5 * gib, required: 2 * gib, limit: 4 * gib, expectCode: codes.OutOfRange},
+		{name: "limit only below minimum", minSize: 5 * gib, limit: 3 * gib, expectCode: codes.OutOfRange},
+		{name: "within bounds", minSize: 5 * gib, maxSize: 100 * gib, required: 50 * gib, expectCode: codes.OK, expectSize: 50 * gib},
+		{name: "at maximum", maxSize: 100 * gib, required: 100 * gib, expectCode: codes.OK, expectSize: 100 * gib},
+		{name: "over maximum", maxSize: 100 * gib, required: 101 * gib, expectCode: codes.OutOfRange},
+		{name: "range above maximum", minSize: 5 * gib, maxSize: 100 * gib, required: 200 * gib, limit: 300 * gib, expectCode: codes.OutOfRange},
+		{name: "built-in maximum still applies", maxSize: 32 * 1024 * gib, required: 17 * 1024 * gib, expectCode: codes.OutOfRange},
+		{name: "minimum below built-in", minSize: 1024, required: 1024, expectCode: codes.OK, expectSize: gib},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			cs.driver.minVolumeSize = tt.minSize
+			cs.driver.maxVolumeSize = tt.maxSize
+
+			resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "pvc-11111111-2222-3333-4444-555555555555",
+				CapacityRange:      &csi.CapacityRange{RequiredBytes: tt.required, LimitBytes: tt.limit},
+				VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+			})
+
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, err)
+			}
+			if err != nil {
+				if _, getErr := mockRDS.GetVolume("pvc-11111111-2222-3333-4444-555555555555"); getErr == nil {
+					t.Error("rejected request created a volume")
+				}
+				return
+			}
+			if resp.Volume.CapacityBytes != tt.expectSize {
+				t.Errorf("capacity = %d, want %d", resp.Volume.CapacityBytes, tt.expectSize)
+			}
+		})
+	}
+}
+
+func TestControllerExpandVolume_MaxVolumeSize(t *testing.T) {
+	const gib = int64(1024 * 1024 * 1024)
+	cs, mockRDS := testControllerServer(t)
+	cs.driver.maxVolumeSize = 100 * gib
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * gib,
+	})
+
+	_, err := cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{
+		VolumeId:      testVolumeID1,
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 200 * gib},
+	})
+	if status.Code(err) != codes.OutOfRange {
+		t.Fatalf("expected OutOfRange, got %v", err)
+	}
+	if vol, _ := mockRDS.GetVolume(testVolumeID1); vol.FileSizeBytes != 10*gib {
+		t.Errorf("volume resized to %d", vol.FileSizeBytes)
+	}
+}
+
+func TestControllerExpandVolume_MinVolumeSize(t *testing.T) {
+	const gib = int64(1024 * 1024 * 1024)
+	cs, mockRDS := testControllerServer(t)
+	cs.driver.minVolumeSize = 5 * gib
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 2 * gib,
+	})
+
+	_, err := cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{
+		VolumeId:      testVolumeID1,
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 3 * gib},
+	})
+	if status.Code(err) != codes.OutOfRange {
+		t.Fatalf("expected OutOfRange below the configured minimum, got %v", err)
+	}
+	if vol, _ := mockRDS.GetVolume(testVolumeID1); vol.FileSizeBytes != 2*gib {
+		t.Errorf("volume resized to %d", vol.FileSizeBytes)
+	}
+}
+
 func TestDriverVolumeCapabilities_IncludesRWX(t *testing.T) {
 	cs, _ := testControllerServer(t)
 	driver := cs.driver
@@ -1164,6 +1404,54 @@ func TestControllerPublishVolume_RWXDualAttach(t *testing.T) {
 	}
 }
 
+func TestControllerPublishVolume_ActiveWriter(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t, testNode("node-1"), testNode("node-2"))
+
+	volumeID := testVolumeID1
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:        volumeID,
+		NVMETCPNQN:  "nqn.2000-02.com.mikrotik:" + volumeID,
+		NVMETCPPort: 4420,
+	})
+	rwxCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Block{
+			Block: &csi.VolumeCapability_BlockVolume{},
+		},
+	}
+	publish := func(nodeID string) string {
+		t.Helper()
+		resp, err := cs.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+			VolumeId:         volumeID,
+			NodeId:           nodeID,
+			VolumeCapability: rwxCap,
+		})
+		if err != nil {
+			t.Fatalf("publish to %s failed: %v", nodeID, err)
+		}
+		return resp.PublishContext[publishContextActiveWriter]
+	}
+
+	if writer := publish("node-1"); writer != "node-1" {
+		t.Errorf("expected node-1 to be the writer of the first attachment, got %q", writer)
+	}
+	// The migration target is attached while the source keeps write access
+	if writer := publish("node-2"); writer != "node-1" {
+		t.Errorf("expected the migration target to see node-1 as the writer, got %q", writer)
+	}
+
+	// The source detaches, completing the migration
+	if _, err := cs.ControllerUnpublishVolume(ctx, &csi.ControllerUnpublishVolumeRequest{VolumeId: volumeID, NodeId: "node-1"}); err != nil {
+		t.Fatalf("unpublish from node-1 failed: %v", err)
+	}
+	if writer := publish("node-2"); writer != "node-2" {
+		t.Errorf("expected node-2 to be the writer after the migration completed, got %q", writer)
+	}
+}
+
 func TestControllerPublishVolume_RWOConflictHintsRWX(t *testing.T) {
 	ctx := context.Background()
 	node1 := testNode("node-1")
@@ -1520,6 +1808,135 @@ func TestCreateVolume_ErrorScenarios(t *testing.T) {
 	}
 }
 
+func TestDeleteVolume_RetainFiles(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	cs.driver.deleteRetainFiles = true
+
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPExport: true,
+	})
+
+	if _, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1}); err != nil {
+		t.Fatalf("DeleteVolume failed: %v", err)
+	}
+
+	if _, err := mockRDS.GetVolume(testVolumeID1); err == nil {
+		t.Error("expected disk slot to be removed")
+	}
+	trashPath, ok := mockRDS.GetTrashedFile(testVolumeID1)
+	if !ok {
+		t.Fatal("expected backing file to be retained in trash")
+	}
+	if !strings.HasPrefix(trashPath, "/storage-pool/metal-csi/.trash/"+testVolumeID1+"-") {
+		t.Errorf("unexpected trash path %q", trashPath)
+	}
+
+	// Repeated delete stays idempotent
+	if _, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1}); err != nil {
+		t.Errorf("second DeleteVolume failed: %v", err)
+	}
+}
+
+func TestDeleteVolume_Attached(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPExport: true,
+	})
+	if err := cs.driver.attachmentManager.TrackAttachment(ctx, testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	// Refused by default, naming the node holding the volume
+	_, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("DeleteVolume of an attached volume = %v, want FailedPrecondition", err)
+	}
+	if !strings.Contains(err.Error(), "node-1") {
+		t.Errorf("error %q does not name the holding node", err)
+	}
+	if _, err := mockRDS.GetVolume(testVolumeID1); err != nil {
+		t.Errorf("refused DeleteVolume removed the volume: %v", err)
+	}
+
+	// Deleted once detached
+	if _, err := cs.driver.attachmentManager.RemoveNodeAttachment(ctx, testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("RemoveNodeAttachment failed: %v", err)
+	}
+	if _, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1}); err != nil {
+		t.Fatalf("DeleteVolume after detach failed: %v", err)
+	}
+	if _, err := mockRDS.GetVolume(testVolumeID1); err == nil {
+		t.Error("expected the detached volume to be removed")
+	}
+}
+
+// TestDeleteVolume_WaitsForPublish tests that DeleteVolume checks the attachment only
+// once a publish of the volume in progress has tracked it
+func TestDeleteVolume_WaitsForPublish(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPExport: true,
+	})
+
+	// A publish holding the volume, not yet tracked as attached
+	cs.volumeLocks.Lock(testVolumeID1)
+	done := make(chan error, 1)
+	go func() {
+		_, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("DeleteVolume returned while a publish held the volume: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := cs.driver.attachmentManager.TrackAttachment(ctx, testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	cs.volumeLocks.Unlock(testVolumeID1)
+
+	if err := <-done; status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("DeleteVolume after the publish = %v, want FailedPrecondition", err)
+	}
+	if _, err := mockRDS.GetVolume(testVolumeID1); err != nil {
+		t.Errorf("DeleteVolume removed the published volume: %v", err)
+	}
+}
+
+func TestDeleteVolume_AllowDeleteAttached(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	cs.driver.allowDeleteAttached = true
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPExport: true,
+	})
+	if err := cs.driver.attachmentManager.TrackAttachment(ctx, testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	if _, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1}); err != nil {
+		t.Fatalf("DeleteVolume with -allow-delete-attached failed: %v", err)
+	}
+	if _, err := mockRDS.GetVolume(testVolumeID1); err == nil {
+		t.Error("expected the attached volume to be removed")
+	}
+}
+
 func TestDeleteVolume_ErrorScenarios(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -1879,7 +2296,7 @@ func TestCSI_NegativeScenarios_Controller(t *testing.T) {
 			specRef:    "CSI 3.6 ControllerPublishVolume: nonexistent volume returns NotFound",
 		},
 		// Note: RWO conflict test requires prior attachment tracking which needs K8s client setup
-		// This is covered in existing TestControllerPublishVolume_RWOConflict
+		// This is covered in TestCSI_NegativeScenarios_ControllerAttachment
 
 		// ControllerUnpublishVolume - CSI spec section 3.7
 		{
@@ -2056,6 +2473,141 @@ func TestCSI_NegativeScenarios_Controller(t *testing.T) {
 	}
 }
 
+// TestCSI_NegativeScenarios_ControllerAttachment covers publish conflicts and unpublish
+// idempotency, which need nodes in Kubernetes and prior attachment state.
+func TestCSI_NegativeScenarios_ControllerAttachment(t *testing.T) {
+	rwo := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+	rwx := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+	}
+	attachRWO := func(ctx context.Context, am *attachment.AttachmentManager) error {
+		return am.TrackAttachmentWithMode(ctx, testVolumeID1, "node-1", "RWO")
+	}
+	attachRWXMigrating := func(ctx context.Context, am *attachment.AttachmentManager) error {
+		if err := am.TrackAttachmentWithMode(ctx, testVolumeID1, "node-1", "RWX"); err != nil {
+			return err
+		}
+		return am.AddSecondaryAttachment(ctx, testVolumeID1, "node-2", 5*time.Minute)
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(context.Context, *attachment.AttachmentManager) error
+		request    interface{} // *csi.ControllerPublishVolumeRequest or *csi.ControllerUnpublishVolumeRequest
+		wantCode   codes.Code
+		wantErrMsg string
+		wantNodes  []string // Nodes the volume is attached to afterwards
+		specRef    string
+	}{
+		{
+			name:       "ControllerPublishVolume: RWO attached to another node",
+			setup:      attachRWO,
+			request:    &csi.ControllerPublishVolumeRequest{VolumeId: testVolumeID1, NodeId: "node-2", VolumeCapability: rwo},
+			wantCode:   codes.FailedPrecondition,
+			wantErrMsg: "already attached to node node-1",
+			wantNodes:  []string{"node-1"},
+			specRef:    "CSI 3.6 ControllerPublishVolume: incompatible attachment returns FAILED_PRECONDITION",
+		},
+		{
+			name:       "ControllerPublishVolume: RWX already on two nodes",
+			setup:      attachRWXMigrating,
+			request:    &csi.ControllerPublishVolumeRequest{VolumeId: testVolumeID1, NodeId: "node-3", VolumeCapability: rwx},
+			wantCode:   codes.FailedPrecondition,
+			wantErrMsg: "node-1",
+			wantNodes:  []string{"node-1", "node-2"},
+			specRef:    "CSI 3.6 ControllerPublishVolume: max attachments reached returns FAILED_PRECONDITION",
+		},
+		{
+			name:      "ControllerPublishVolume: already published to node (idempotent)",
+			setup:     attachRWO,
+			request:   &csi.ControllerPublishVolumeRequest{VolumeId: testVolumeID1, NodeId: "node-1", VolumeCapability: rwo},
+			wantCode:  codes.OK,
+			wantNodes: []string{"node-1"},
+			specRef:   "CSI 3.6 ControllerPublishVolume: already published returns success (idempotent)",
+		},
+		{
+			name: "ControllerUnpublishVolume: already unpublished (idempotent)",
+			setup: func(ctx context.Context, am *attachment.AttachmentManager) error {
+				if err := attachRWO(ctx, am); err != nil {
+					return err
+				}
+				_, err := am.RemoveNodeAttachment(ctx, testVolumeID1, "node-1")
+				return err
+			},
+			request:  &csi.ControllerUnpublishVolumeRequest{VolumeId: testVolumeID1, NodeId: "node-1"},
+			wantCode: codes.OK,
+			specRef:  "CSI 3.7 ControllerUnpublishVolume: not published returns success (idempotent)",
+		},
+		{
+			name:      "ControllerUnpublishVolume: attached to another node",
+			setup:     attachRWO,
+			request:   &csi.ControllerUnpublishVolumeRequest{VolumeId: testVolumeID1, NodeId: "node-2"},
+			wantCode:  codes.OK,
+			wantNodes: []string{"node-1"},
+			specRef:   "CSI 3.7 ControllerUnpublishVolume: not published to node returns success and leaves other nodes attached",
+		},
+		{
+			name:     "ControllerUnpublishVolume: empty node ID detaches all nodes",
+			setup:    attachRWXMigrating,
+			request:  &csi.ControllerUnpublishVolumeRequest{VolumeId: testVolumeID1},
+			wantCode: codes.OK,
+			specRef:  "CSI 3.7 ControllerUnpublishVolume: empty node_id unpublishes from all nodes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cs, mockRDS := testControllerServer(t, testNode("node-1"), testNode("node-2"), testNode("node-3"))
+			cs.driver.metrics = observability.NewMetrics()
+			mockRDS.AddVolume(&rds.VolumeInfo{
+				Slot:        testVolumeID1,
+				NVMETCPPort: 4420,
+				NVMETCPNQN:  "nqn.2000-02.com.mikrotik:" + testVolumeID1,
+			})
+			am := cs.driver.GetAttachmentManager()
+			if err := tt.setup(ctx, am); err != nil {
+				t.Fatalf("setup failed: %v", err)
+			}
+
+			var err error
+			switch req := tt.request.(type) {
+			case *csi.ControllerPublishVolumeRequest:
+				_, err = cs.ControllerPublishVolume(ctx, req)
+			case *csi.ControllerUnpublishVolumeRequest:
+				_, err = cs.ControllerUnpublishVolume(ctx, req)
+			default:
+				t.Fatalf("Unknown request type: %T", tt.request)
+			}
+
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("[%s] Expected code %v, got %v", tt.specRef, tt.wantCode, err)
+			}
+			if tt.wantErrMsg != "" && !strings.Contains(status.Convert(err).Message(), tt.wantErrMsg) {
+				t.Errorf("[%s] Expected error containing %q, got %q", tt.specRef, tt.wantErrMsg, status.Convert(err).Message())
+			}
+
+			var nodes []string
+			if state, ok := am.GetAttachment(testVolumeID1); ok {
+				nodes = state.GetNodeIDs()
+			}
+			sort.Strings(nodes)
+			if len(nodes) != len(tt.wantNodes) || (len(nodes) > 0 && !reflect.DeepEqual(nodes, tt.wantNodes)) {
+				t.Errorf("[%s] Attached nodes = %v, want %v", tt.specRef, nodes, tt.wantNodes)
+			}
+
+			// A no-op unpublish is not a partial detach of a migrating volume
+			if strings.Contains(scrapeMetrics(t, cs.driver.metrics), `operation="detach_partial"`) {
+				t.Errorf("[%s] No-op unpublish recorded as detach_partial", tt.specRef)
+			}
+		})
+	}
+}
+
 // TestSanityRegression_CreateVolumeZeroCapacity is a regression test
 // for CSI sanity edge case: capacity_range with required_bytes=0.
 // Driver should use default minimum capacity (1 GiB).
@@ -2485,7 +3037,159 @@ func TestCreateSnapshotIdempotency(t *testing.T) {
 	_ = mockRDS.DeleteSnapshot(resp3.Snapshot.SnapshotId)
 }
 
-func TestDeleteSnapshot(t *testing.T) {
+// TestCreateSnapshot_LegacyID tests that snapshots created with the legacy snap-<uuid> ID
+// scheme are found again by CreateSnapshot retries and can be deleted
+func TestCreateSnapshot_LegacyID(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+	})
+
+	snapshotName := "snapshot-from-an-older-release"
+	legacyID := utils.SnapshotNameToID(snapshotName)
+	mockRDS.AddSnapshot(&rds.SnapshotInfo{
+		Name:          legacyID,
+		SourceVolume:  testVolumeID1,
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		CreatedAt:     time.Now(),
+	})
+
+	resp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: testVolumeID1})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	if resp.Snapshot.SnapshotId != legacyID {
+		t.Errorf("expected the legacy snapshot %s, got %s", legacyID, resp.Snapshot.SnapshotId)
+	}
+	if snapshots, _ := mockRDS.ListSnapshots(); len(snapshots) != 1 {
+		t.Errorf("expected no second snapshot, got %d snapshots", len(snapshots))
+	}
+
+	// Same name from another source conflicts with the legacy snapshot too
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID2, FilePath: "/storage-pool/metal-csi/" + testVolumeID2 + ".img"})
+	_, err = cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: testVolumeID2})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists, got %v", err)
+	}
+
+	if _, err := cs.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: legacyID}); err != nil {
+		t.Fatalf("DeleteSnapshot of legacy ID failed: %v", err)
+	}
+	if _, err := mockRDS.GetSnapshot(legacyID); err == nil {
+		t.Error("expected the legacy snapshot to be deleted")
+	}
+}
+
+func TestCreateSnapshot_Verify(t *testing.T) {
+	tests := []struct {
+		name     string
+		truncate bool
+		wantCode codes.Code
+	}{
+		{name: "matching copy is ready", wantCode: codes.OK},
+		{name: "truncated copy is deleted", truncate: true, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cs, mockRDS := testControllerServer(t)
+			mockRDS.AddVolume(&rds.VolumeInfo{
+				Slot:          testVolumeID1,
+				FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+				FileSizeBytes: 10 * 1024 * 1024 * 1024,
+			})
+			mockRDS.SetTruncateSnapshots(tt.truncate)
+
+			resp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+				Name:           "verified-snapshot",
+				SourceVolumeId: testVolumeID1,
+				Parameters:     map[string]string{"verifySnapshot": "true"},
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v", tt.wantCode, err)
+			}
+
+			snapshots, _ := mockRDS.ListSnapshots()
+			if tt.wantCode != codes.OK {
+				if len(snapshots) != 0 {
+					t.Errorf("expected the unverified snapshot to be deleted, got %d snapshots", len(snapshots))
+				}
+				return
+			}
+			if !resp.Snapshot.ReadyToUse {
+				t.Error("expected the verified snapshot to be ready")
+			}
+			if len(snapshots) != 1 {
+				t.Errorf("expected 1 snapshot, got %d", len(snapshots))
+			}
+		})
+	}
+}
+
+func TestCreateSnapshot_VerifyAfterFailedDelete(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+	})
+	mockRDS.SetTruncateSnapshots(true)
+	req := &csi.CreateSnapshotRequest{
+		Name:           "verified-snapshot",
+		SourceVolumeId: testVolumeID1,
+		Parameters:     map[string]string{"verifySnapshot": "true"},
+	}
+	snapshotID := utils.GenerateSnapshotID(req.Name, testVolumeID1)
+
+	// The truncated copy fails verification and then cannot be deleted
+	mockRDS.SetDeleteError(snapshotID, fmt.Errorf("ssh: %w", utils.ErrConnectionFailed))
+	if _, err := cs.CreateSnapshot(ctx, req); status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+	if _, err := mockRDS.GetSnapshot(snapshotID); err != nil {
+		t.Fatalf("expected the undeletable snapshot to remain: %v", err)
+	}
+
+	// The retry finds it, but must not report it ready without verifying it
+	mockRDS.SetDeleteError(snapshotID, nil)
+	if _, err := cs.CreateSnapshot(ctx, req); status.Code(err) != codes.Internal {
+		t.Fatalf("expected the retry to fail verification, got %v", err)
+	}
+	if _, err := mockRDS.GetSnapshot(snapshotID); err == nil {
+		t.Error("expected the unverified snapshot to be deleted on retry")
+	}
+}
+
+func TestCreateSnapshot_VerifyOffByDefault(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+	})
+	mockRDS.SetTruncateSnapshots(true)
+
+	if _, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: "unverified-snapshot", SourceVolumeId: testVolumeID1}); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	_, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		Name:           "bad-parameter",
+		SourceVolumeId: testVolumeID1,
+		Parameters:     map[string]string{"verifySnapshot": "yes please"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an invalid verifySnapshot, got %v", err)
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
 	ctx := context.Background()
 	cs, mockRDS := testControllerServer(t)
 
@@ -2644,6 +3348,18 @@ func TestListSnapshots(t *testing.T) {
 			sourceVolume: testVolumeID2,
 			wantCount:    1, // snap3 only
 		},
+		{
+			name:         "filter by source volume with starting_token",
+			sourceVolume: testVolumeID1,
+			maxEntries:   1,
+			startToken:   "1", // snap2, after snap1 of the filtered list
+			wantCount:    1,
+		},
+		{
+			name:         "filter by invalid source volume ID",
+			sourceVolume: "pvc;ls",
+			wantCount:    0, // Empty response, not error
+		},
 		{
 			name:       "pagination: max_entries=1",
 			maxEntries: 1,
@@ -2856,3 +3572,1189 @@ func TestCreateVolumeFromSnapshot(t *testing.T) {
 	// Cleanup snapshot
 	_ = mockRDS.DeleteSnapshot(snapshotID)
 }
+
+// TestCreateVolumeFromSnapshot_DifferentPool tests restoring a snapshot into the pool the
+// new volume's StorageClass selects rather than the snapshot's
+func TestCreateVolumeFromSnapshot_DifferentPool(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + testVolumeID1,
+	})
+	snapResp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		Name:           "test-snapshot-fast",
+		SourceVolumeId: testVolumeID1,
+		Parameters:     map[string]string{"volumePath": "/storage-pool/metal-csi"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test snapshot: %v", err)
+	}
+
+	resp, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               "restored-bulk",
+		VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 10 * 1024 * 1024 * 1024},
+		VolumeContentSource: &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Snapshot{
+				Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapResp.Snapshot.SnapshotId},
+			},
+		},
+		Parameters: map[string]string{"volumePath": "/bulk-pool/metal-csi"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	volumeID := resp.Volume.VolumeId
+	wantPath := "/bulk-pool/metal-csi/" + volumeID + ".img"
+	if got := resp.Volume.VolumeContext["volumePath"]; got != wantPath {
+		t.Errorf("volumePath = %s, want %s", got, wantPath)
+	}
+	restored, err := mockRDS.GetVolume(volumeID)
+	if err != nil {
+		t.Fatalf("restored volume not found: %v", err)
+	}
+	if restored.FilePath != wantPath {
+		t.Errorf("restored file = %s, want %s", restored.FilePath, wantPath)
+	}
+}
+
+// TestCreateVolumeFromSnapshot_NamespaceGuard tests that snapshots are only restored into
+// PVCs of their own namespace unless the StorageClass opts in
+func TestCreateVolumeFromSnapshot_NamespaceGuard(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + testVolumeID1,
+	})
+	tenantSnap, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		Name:           "test-snapshot-tenant-a",
+		SourceVolumeId: testVolumeID1,
+		Parameters: map[string]string{
+			paramSnapshotNamespace: "tenant-a",
+			paramSnapshotName:      "nightly",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test snapshot: %v", err)
+	}
+	// Snapshots created without --extra-create-metadata, or before the check existed
+	legacySnap, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		Name:           "test-snapshot-legacy",
+		SourceVolumeId: testVolumeID1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test snapshot: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		snapshotID string
+		params     map[string]string
+		expectCode codes.Code
+	}{
+		{
+			name:       "same namespace",
+			snapshotID: tenantSnap.Snapshot.SnapshotId,
+			params:     map[string]string{paramPVCNamespace: "tenant-a"},
+			expectCode: codes.OK,
+		},
+		{
+			name:       "cross namespace blocked",
+			snapshotID: tenantSnap.Snapshot.SnapshotId,
+			params:     map[string]string{paramPVCNamespace: "tenant-b"},
+			expectCode: codes.PermissionDenied,
+		},
+		{
+			name:       "cross namespace opted in",
+			snapshotID: tenantSnap.Snapshot.SnapshotId,
+			params:     map[string]string{paramPVCNamespace: "tenant-b", paramAllowCrossNamespaceRestore: "true"},
+			expectCode: codes.OK,
+		},
+		{
+			name:       "snapshot without namespace",
+			snapshotID: legacySnap.Snapshot.SnapshotId,
+			params:     map[string]string{paramPVCNamespace: "tenant-b"},
+			expectCode: codes.OK,
+		},
+		{
+			name:       "invalid opt-in",
+			snapshotID: tenantSnap.Snapshot.SnapshotId,
+			params:     map[string]string{paramPVCNamespace: "tenant-b", paramAllowCrossNamespaceRestore: "maybe"},
+			expectCode: codes.InvalidArgument,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+				Name:               fmt.Sprintf("restored-%d", i),
+				VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+				CapacityRange:      &csi.CapacityRange{RequiredBytes: 10 * 1024 * 1024 * 1024},
+				VolumeContentSource: &csi.VolumeContentSource{
+					Type: &csi.VolumeContentSource_Snapshot{
+						Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: tt.snapshotID},
+					},
+				},
+				Parameters: tt.params,
+			})
+			if code := status.Code(err); code != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, err)
+			}
+			if tt.expectCode == codes.PermissionDenied && !strings.Contains(err.Error(), "namespace tenant-a") {
+				t.Errorf("expected the snapshot's namespace in the error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestDeleteSnapshot_RestoreInProgress tests that DeleteSnapshot is refused while a
+// restore is copying from the snapshot and succeeds once the restore completes
+func TestDeleteSnapshot_RestoreInProgress(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          testVolumeID1,
+		FilePath:      "/storage-pool/metal-csi/" + testVolumeID1 + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + testVolumeID1,
+	})
+	snapResp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		Name:           "test-snapshot-in-use",
+		SourceVolumeId: testVolumeID1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test snapshot: %v", err)
+	}
+	snapshotID := snapResp.Snapshot.SnapshotId
+
+	// Block the copy until the test releases it
+	copyStarted := make(chan struct{})
+	releaseCopy := make(chan struct{})
+	mockRDS.SetRestoreHook(func(string) {
+		close(copyStarted)
+		<-releaseCopy
+	})
+
+	const restoredVolumeID = "pvc-22222222-3333-4444-5555-666666666666"
+	restoreDone := make(chan error, 1)
+	go func() {
+		_, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name: restoredVolumeID,
+			VolumeCapabilities: []*csi.VolumeCapability{{
+				AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+				AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			}},
+			VolumeContentSource: &csi.VolumeContentSource{
+				Type: &csi.VolumeContentSource_Snapshot{
+					Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapshotID},
+				},
+			},
+		})
+		restoreDone <- err
+	}()
+	<-copyStarted
+
+	if got := cs.driver.snapshotRestores.inProgress(); got != 1 {
+		t.Errorf("restores in progress = %d, want 1", got)
+	}
+
+	_, err = cs.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotID})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition during restore, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "in use by restore of volume "+restoredVolumeID) {
+		t.Errorf("error should name the restoring volume: %v", err)
+	}
+	if _, err := mockRDS.GetSnapshot(snapshotID); err != nil {
+		t.Fatalf("snapshot was deleted during restore: %v", err)
+	}
+
+	close(releaseCopy)
+	if err := <-restoreDone; err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if got := cs.driver.snapshotRestores.inProgress(); got != 0 {
+		t.Errorf("restores in progress = %d after completion, want 0", got)
+	}
+
+	// The provisioner's retry succeeds once the restore has finished
+	if _, err := cs.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotID}); err != nil {
+		t.Fatalf("DeleteSnapshot after restore failed: %v", err)
+	}
+	if _, err := mockRDS.GetSnapshot(snapshotID); err == nil {
+		t.Error("snapshot should be deleted")
+	}
+}
+
+func TestCreateVolume_SizeRoundingPolicy(t *testing.T) {
+	const GiB = int64(1024 * 1024 * 1024)
+	awkwardSize := GiB + GiB/2 // 1.5 GiB - not representable in whole GiB
+
+	tests := []struct {
+		name          string
+		params        map[string]string
+		limitBytes    int64
+		expectCode    codes.Code
+		expectedBytes int64
+	}{
+		{name: "default rounds up", params: nil, expectCode: codes.OK, expectedBytes: 2 * GiB},
+		{name: "up rounds up", params: map[string]string{"sizeRoundingPolicy": "up"}, expectCode: codes.OK, expectedBytes: 2 * GiB},
+		{name: "nearest", params: map[string]string{"sizeRoundingPolicy": "nearest"}, expectCode: codes.OK, expectedBytes: 2 * GiB},
+		{name: "exact-or-fail rejects", params: map[string]string{"sizeRoundingPolicy": "exact-or-fail"}, expectCode: codes.InvalidArgument},
+		{name: "invalid policy", params: map[string]string{"sizeRoundingPolicy": "bogus"}, expectCode: codes.InvalidArgument},
+		{name: "up exceeds limit", params: map[string]string{"sizeRoundingPolicy": "up"}, limitBytes: awkwardSize, expectCode: codes.OutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+
+			resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:       "pvc-11111111-2222-3333-4444-555555555555",
+				Parameters: tt.params,
+				VolumeCapabilities: []*csi.VolumeCapability{
+					{
+						AccessMode: &csi.VolumeCapability_AccessMode{
+							Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+						},
+						AccessType: &csi.VolumeCapability_Mount{
+							Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"},
+						},
+					},
+				},
+				CapacityRange: &csi.CapacityRange{
+					RequiredBytes: awkwardSize,
+					LimitBytes:    tt.limitBytes,
+				},
+			})
+
+			if tt.expectCode != codes.OK {
+				if status.Code(err) != tt.expectCode {
+					t.Fatalf("expected %v, got %v", tt.expectCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Volume.CapacityBytes != tt.expectedBytes {
+				t.Errorf("expected reported capacity %d, got %d", tt.expectedBytes, resp.Volume.CapacityBytes)
+			}
+
+			vol, err := mockRDS.GetVolume("pvc-11111111-2222-3333-4444-555555555555")
+			if err != nil {
+				t.Fatalf("volume not created on RDS: %v", err)
+			}
+			if vol.FileSizeBytes != resp.Volume.CapacityBytes {
+				t.Errorf("provisioned size %d does not match reported capacity %d", vol.FileSizeBytes, resp.Volume.CapacityBytes)
+			}
+		})
+	}
+}
+
+func TestCreateVolume_FilesystemOptions(t *testing.T) {
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"},
+		},
+	}
+	blockCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Block{
+			Block: &csi.VolumeCapability_BlockVolume{},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]string
+		capability    *csi.VolumeCapability
+		expectCode    codes.Code
+		expectContext map[string]string
+	}{
+		{name: "defaults omitted from context", params: nil, capability: mountCap, expectCode: codes.OK, expectContext: map[string]string{}},
+		{name: "filesystem echoes options", params: map[string]string{"initialTrim": "true", "discard": "true"}, capability: mountCap, expectCode: codes.OK,
+			expectContext: map[string]string{"initialTrim": "true", "discard": "true"}},
+		{name: "block rejects initialTrim", params: map[string]string{"initialTrim": "true"}, capability: blockCap, expectCode: codes.InvalidArgument},
+		{name: "block rejects discard", params: map[string]string{"discard": "true"}, capability: blockCap, expectCode: codes.InvalidArgument},
+		{name: "block allows explicit false", params: map[string]string{"discard": "false"}, capability: blockCap, expectCode: codes.OK, expectContext: map[string]string{}},
+		{name: "invalid value", params: map[string]string{"discard": "maybe"}, capability: mountCap, expectCode: codes.InvalidArgument},
+		{name: "formatPolicy never echoed", params: map[string]string{"formatPolicy": "never"}, capability: mountCap, expectCode: codes.OK,
+			expectContext: map[string]string{"formatPolicy": "never"}},
+		{name: "formatPolicy auto omitted", params: map[string]string{"formatPolicy": "auto"}, capability: blockCap, expectCode: codes.OK, expectContext: map[string]string{}},
+		{name: "block rejects formatPolicy never", params: map[string]string{"formatPolicy": "never"}, capability: blockCap, expectCode: codes.InvalidArgument},
+		{name: "invalid formatPolicy", params: map[string]string{"formatPolicy": "sometimes"}, capability: mountCap, expectCode: codes.InvalidArgument},
+		{name: "block echoes device node options", params: map[string]string{"blockDeviceMode": "660", "blockDeviceOwner": "107:107"}, capability: blockCap, expectCode: codes.OK,
+			expectContext: map[string]string{"blockDeviceOwner": "107:107"}},
+		{name: "block echoes device mode", params: map[string]string{"blockDeviceMode": "0600"}, capability: blockCap, expectCode: codes.OK,
+			expectContext: map[string]string{"blockDeviceMode": "0600"}},
+		{name: "filesystem rejects blockDeviceMode", params: map[string]string{"blockDeviceMode": "0600"}, capability: mountCap, expectCode: codes.InvalidArgument},
+		{name: "invalid blockDeviceMode", params: map[string]string{"blockDeviceMode": "0777"}, capability: blockCap, expectCode: codes.InvalidArgument},
+		{name: "quotaBytes echoed in bytes", params: map[string]string{"quotaBytes": "1Gi"}, capability: mountCap, expectCode: codes.OK,
+			expectContext: map[string]string{"quotaBytes": "1073741824"}},
+		{name: "block rejects quotaBytes", params: map[string]string{"quotaBytes": "1Gi"}, capability: blockCap, expectCode: codes.InvalidArgument},
+		{name: "quotaBytes rejects ext3", params: map[string]string{"quotaBytes": "1Gi", "fsType": "ext3"}, capability: mountCap, expectCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, _ := testControllerServer(t)
+
+			resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "pvc-11111111-2222-3333-4444-555555555555",
+				Parameters:         tt.params,
+				VolumeCapabilities: []*csi.VolumeCapability{tt.capability},
+			})
+
+			if tt.expectCode != codes.OK {
+				if status.Code(err) != tt.expectCode {
+					t.Fatalf("expected %v, got %v", tt.expectCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, key := range []string{"initialTrim", "discard", "formatPolicy", "quotaBytes", "blockDeviceMode", "blockDeviceOwner"} {
+				got, ok := resp.Volume.VolumeContext[key]
+				want, wantOK := tt.expectContext[key]
+				if ok != wantOK || got != want {
+					t.Errorf("VolumeContext[%q] = %q (present=%v), want %q (present=%v)", key, got, ok, want, wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateVolume_QoS(t *testing.T) {
+	const volumeID = "pvc-11111111-2222-3333-4444-555555555555"
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"},
+		},
+	}
+	qosParams := map[string]string{"maxReadIOPS": "5000", "maxWriteIOPS": "2500", "maxBandwidth": "100Mi"}
+	wantQoS := rds.VolumeQoS{MaxReadIOPS: 5000, MaxWriteIOPS: 2500, MaxBandwidthBytes: 100 * 1024 * 1024}
+
+	tests := []struct {
+		name       string
+		version    string
+		params     map[string]string
+		expectCode codes.Code
+		expectQoS  rds.VolumeQoS
+	}{
+		{name: "limits applied", version: rds.VolumeQoSMinVersion, params: qosParams, expectCode: codes.OK, expectQoS: wantQoS},
+		{name: "no limits on old RouterOS", version: "7.16", params: nil, expectCode: codes.OK},
+		{name: "limits rejected on old RouterOS", version: "7.16", params: qosParams, expectCode: codes.InvalidArgument},
+		{name: "invalid limit", version: rds.VolumeQoSMinVersion, params: map[string]string{"maxReadIOPS": "0"}, expectCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			mockRDS.SetSystemInfo(&rds.SystemInfo{Version: tt.version})
+
+			_, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               volumeID,
+				Parameters:         tt.params,
+				VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+			})
+
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, err)
+			}
+			volume, getErr := mockRDS.GetVolume(volumeID)
+			if tt.expectCode != codes.OK {
+				if getErr == nil {
+					t.Errorf("volume %s was created despite error", volumeID)
+				}
+				return
+			}
+			if getErr != nil {
+				t.Fatalf("volume not created: %v", getErr)
+			}
+			if volume.QoS != tt.expectQoS {
+				t.Errorf("QoS = %+v, want %+v", volume.QoS, tt.expectQoS)
+			}
+		})
+	}
+}
+
+func TestCreateVolume_AdoptExisting(t *testing.T) {
+	const volumeID = "pvc-11111111-2222-3333-4444-555555555555"
+	const size = 10 * 1024 * 1024 * 1024
+	filePath, _ := utils.VolumeIDToFilePath(volumeID, defaultVolumeBasePath)
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		params     map[string]string
+		file       *rds.FileInfo
+		source     *csi.VolumeContentSource
+		expectCode codes.Code
+		expectFile bool // the pre-created file is consumed by the new disk
+	}{
+		{
+			name:       "adopts file of matching size",
+			params:     map[string]string{"adoptExisting": "true"},
+			file:       &rds.FileInfo{Path: filePath, SizeBytes: size},
+			expectCode: codes.OK,
+			expectFile: true,
+		},
+		{
+			name:       "rejects file of different size",
+			params:     map[string]string{"adoptExisting": "true"},
+			file:       &rds.FileInfo{Path: filePath, SizeBytes: 5 * 1024 * 1024 * 1024},
+			expectCode: codes.AlreadyExists,
+		},
+		{
+			name:       "ignores file sharing the path prefix",
+			params:     map[string]string{"adoptExisting": "true"},
+			file:       &rds.FileInfo{Path: filePath + ".bak", SizeBytes: 5 * 1024 * 1024 * 1024},
+			expectCode: codes.OK,
+		},
+		{
+			name:       "creates new file when none exists",
+			params:     map[string]string{"adoptExisting": "true"},
+			expectCode: codes.OK,
+		},
+		{
+			name:       "invalid value",
+			params:     map[string]string{"adoptExisting": "yes please"},
+			expectCode: codes.InvalidArgument,
+		},
+		{
+			name:   "rejects content source",
+			params: map[string]string{"adoptExisting": "true"},
+			source: &csi.VolumeContentSource{
+				Type: &csi.VolumeContentSource_Snapshot{
+					Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: "snap-11111111-2222-3333-4444-555555555555"},
+				},
+			},
+			expectCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			if tt.file != nil {
+				mockRDS.AddFile(tt.file)
+			}
+
+			resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:                volumeID,
+				Parameters:          tt.params,
+				CapacityRange:       &csi.CapacityRange{RequiredBytes: size},
+				VolumeCapabilities:  []*csi.VolumeCapability{mountCap},
+				VolumeContentSource: tt.source,
+			})
+			if status.Code(err) != tt.expectCode {
+				t.Fatalf("expected %v, got %v", tt.expectCode, err)
+			}
+			if tt.expectCode != codes.OK {
+				if _, getErr := mockRDS.GetVolume(volumeID); getErr == nil {
+					t.Errorf("volume %s was created despite error", volumeID)
+				}
+				return
+			}
+
+			volume, err := mockRDS.GetVolume(volumeID)
+			if err != nil {
+				t.Fatalf("volume not created: %v", err)
+			}
+			if volume.FilePath != filePath || volume.FileSizeBytes != size || resp.Volume.CapacityBytes != size {
+				t.Errorf("unexpected volume: path=%s size=%d capacity=%d", volume.FilePath, volume.FileSizeBytes, resp.Volume.CapacityBytes)
+			}
+			remaining, _ := mockRDS.ListFiles(filePath)
+			adopted := tt.file != nil && len(remaining) == 0
+			if adopted != tt.expectFile {
+				t.Errorf("file adopted = %v, want %v (remaining files: %v)", adopted, tt.expectFile, remaining)
+			}
+		})
+	}
+}
+
+func TestCreateVolume_QoSIdempotency(t *testing.T) {
+	const volumeID = "pvc-11111111-2222-3333-4444-555555555555"
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"},
+		},
+	}
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.SetSystemInfo(&rds.SystemInfo{Version: rds.VolumeQoSMinVersion})
+
+	req := &csi.CreateVolumeRequest{
+		Name:               volumeID,
+		Parameters:         map[string]string{"maxReadIOPS": "5000"},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+	}
+	if _, err := cs.CreateVolume(context.Background(), req); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	if _, err := cs.CreateVolume(context.Background(), req); err != nil {
+		t.Errorf("retry with the same limits failed: %v", err)
+	}
+
+	req.Parameters = map[string]string{"maxReadIOPS": "1000"}
+	if _, err := cs.CreateVolume(context.Background(), req); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists for different limits, got %v", err)
+	}
+}
+
+func TestListVolumes_MixedSlotPrefixes(t *testing.T) {
+	tests := []struct {
+		name       string
+		slotPrefix string
+		want       []string
+	}{
+		{
+			name: "default lists only pvc slots",
+			want: []string{"pvc-11111111-2222-3333-4444-555555555555"},
+		},
+		{
+			name:       "slot prefix lists pvc and prefixed slots",
+			slotPrefix: "infra-",
+			want:       []string{"infra-backup", "pvc-11111111-2222-3333-4444-555555555555"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := utils.SetSlotPrefix(tt.slotPrefix); err != nil {
+				t.Fatalf("SetSlotPrefix() failed: %v", err)
+			}
+			t.Cleanup(utils.ResetSlotNaming)
+
+			cs, mockRDS := testControllerServer(t)
+			for _, slot := range []string{"pvc-11111111-2222-3333-4444-555555555555", "infra-backup", "manual-volume", "infra-"} {
+				mockRDS.AddVolume(&rds.VolumeInfo{
+					Slot:          slot,
+					FilePath:      "/storage-pool/metal-csi/" + slot + ".img",
+					FileSizeBytes: 1 << 30,
+				})
+			}
+
+			resp, err := cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+			if err != nil {
+				t.Fatalf("ListVolumes failed: %v", err)
+			}
+
+			var got []string
+			for _, entry := range resp.Entries {
+				got = append(got, entry.Volume.VolumeId)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListVolumes returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControllerExpandVolume_AttachedBlockPendingResize(t *testing.T) {
+	cs, mockRDS := testControllerServer(t)
+	ctx := context.Background()
+	const oldSize, newSize = int64(1 << 30), int64(2 << 30)
+
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID1, FileSizeBytes: oldSize})
+	k8sClient := cs.driver.k8sClient
+	if _, err := k8sClient.CoreV1().PersistentVolumes().Create(ctx, &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: testVolumeID1},
+		Spec:       corev1.PersistentVolumeSpec{ClaimRef: &corev1.ObjectReference{Namespace: "default", Name: "data"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create PV: %v", err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: *resource.NewQuantity(oldSize, resource.BinarySI)},
+		},
+	}
+	if _, err := k8sClient.CoreV1().PersistentVolumeClaims("default").Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create PVC: %v", err)
+	}
+	if err := cs.driver.attachmentManager.TrackAttachment(ctx, testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	blockCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+	resp, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+		VolumeId:         testVolumeID1,
+		CapacityRange:    &csi.CapacityRange{RequiredBytes: newSize},
+		VolumeCapability: blockCap,
+	})
+	if err != nil {
+		t.Fatalf("ControllerExpandVolume failed: %v", err)
+	}
+	if !resp.NodeExpansionRequired {
+		t.Error("Expected node expansion to be required for attached block volume")
+	}
+
+	getResp, err := cs.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID1})
+	if err != nil {
+		t.Fatalf("ControllerGetVolume failed: %v", err)
+	}
+	if getResp.Volume.CapacityBytes != newSize {
+		t.Errorf("Expected capacity %d, got %d", newSize, getResp.Volume.CapacityBytes)
+	}
+	if !reflect.DeepEqual(getResp.Status.PublishedNodeIds, []string{"node-1"}) {
+		t.Errorf("Expected published nodes [node-1], got %v", getResp.Status.PublishedNodeIds)
+	}
+	if msg := getResp.Status.VolumeCondition.Message; !strings.Contains(msg, "pending node resize") {
+		t.Errorf("Expected pending resize in condition message, got %q", msg)
+	}
+
+	// kubelet reports the new size on the PVC once NodeExpandVolume succeeded
+	pvc.Status.Capacity[corev1.ResourceStorage] = *resource.NewQuantity(newSize, resource.BinarySI)
+	if _, err := k8sClient.CoreV1().PersistentVolumeClaims("default").UpdateStatus(ctx, pvc, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update PVC: %v", err)
+	}
+
+	getResp, err = cs.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID1})
+	if err != nil {
+		t.Fatalf("ControllerGetVolume failed: %v", err)
+	}
+	if msg := getResp.Status.VolumeCondition.Message; strings.Contains(msg, "pending") {
+		t.Errorf("Expected no pending resize after confirmation, got %q", msg)
+	}
+}
+
+// TestControllerExpandVolume_NodeExpansionRequired tests that block and filesystem
+// volumes both need node expansion, attached or not, and that the reported capacity is
+// the size RouterOS can represent
+func TestControllerExpandVolume_NodeExpansionRequired(t *testing.T) {
+	blockCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+	}
+	tests := []struct {
+		name          string
+		capability    *csi.VolumeCapability
+		requiredBytes int64
+		wantCapacity  int64
+	}{
+		{name: "block", capability: blockCap, requiredBytes: 2 << 30, wantCapacity: 2 << 30},
+		{name: "filesystem", capability: createFilesystemVolumeCapability(), requiredBytes: 2 << 30, wantCapacity: 2 << 30},
+		{name: "block rounded up", capability: blockCap, requiredBytes: 5 << 29, wantCapacity: 3 << 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			ctx := context.Background()
+			mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID1, FileSizeBytes: 1 << 30})
+
+			resp, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+				VolumeId:         testVolumeID1,
+				CapacityRange:    &csi.CapacityRange{RequiredBytes: tt.requiredBytes},
+				VolumeCapability: tt.capability,
+			})
+			if err != nil {
+				t.Fatalf("ControllerExpandVolume failed: %v", err)
+			}
+			if !resp.NodeExpansionRequired {
+				t.Error("Expected node expansion to be required")
+			}
+			if resp.CapacityBytes != tt.wantCapacity {
+				t.Errorf("Expected capacity %d, got %d", tt.wantCapacity, resp.CapacityBytes)
+			}
+			volume, err := mockRDS.GetVolume(testVolumeID1)
+			if err != nil {
+				t.Fatalf("GetVolume failed: %v", err)
+			}
+			if volume.FileSizeBytes != tt.wantCapacity {
+				t.Errorf("Expected backing file of %d bytes, got %d", tt.wantCapacity, volume.FileSizeBytes)
+			}
+			// Only attached volumes wait for a node to confirm the resize
+			if pending := cs.driver.attachmentManager.GetPendingNodeResize(ctx, testVolumeID1); pending != nil {
+				t.Errorf("Expected no pending resize for detached volume, got %+v", pending)
+			}
+		})
+	}
+}
+
+func TestControllerGetVolume_NotFound(t *testing.T) {
+	cs, _ := testControllerServer(t)
+
+	_, err := cs.ControllerGetVolume(context.Background(), &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID1})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	_, err = cs.ControllerGetVolume(context.Background(), &csi.ControllerGetVolumeRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty volume ID, got %v", err)
+	}
+}
+
+func TestCreateVolume_WWIDInVolumeContext(t *testing.T) {
+	const volumeID = "pvc-11111111-2222-3333-4444-555555555555"
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		existing *rds.VolumeInfo
+		wantWWID string
+	}{
+		{
+			name:     "RDS without identifier omits wwid",
+			wantWWID: "",
+		},
+		{
+			name: "identifier reported by RDS is emitted",
+			existing: &rds.VolumeInfo{
+				Slot:          volumeID,
+				FileSizeBytes: 1 << 30,
+				NVMETCPPort:   4420,
+				NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + volumeID,
+				WWID:          "eui.0025385b71b0a1f2",
+			},
+			wantWWID: "eui.0025385b71b0a1f2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			if tt.existing != nil {
+				mockRDS.AddVolume(tt.existing)
+			}
+
+			resp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               volumeID,
+				CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+				VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, ok := resp.Volume.VolumeContext["wwid"]
+			if tt.wantWWID == "" {
+				if ok {
+					t.Errorf("expected no wwid in VolumeContext, got %q", got)
+				}
+				return
+			}
+			if got != tt.wantWWID {
+				t.Errorf("VolumeContext[wwid] = %q, want %q", got, tt.wantWWID)
+			}
+		})
+	}
+}
+
+// testMultiBackendControllerServer adds a second RDS backend "array2" to a test controller
+func testMultiBackendControllerServer(t *testing.T) (*ControllerServer, *rds.MockClient, *rds.MockClient) {
+	t.Helper()
+	cs, defaultRDS := testControllerServer(t, testNode("node-1"))
+
+	array2 := rds.NewMockClient()
+	array2.SetAddress("10.0.0.2")
+	cs.driver.backends = rds.NewClientRegistry(defaultRDS)
+	if err := cs.driver.backends.Register(&rds.Backend{Name: "array2", Client: array2, NVMEAddress: "10.0.1.2"}); err != nil {
+		t.Fatalf("failed to register backend: %v", err)
+	}
+	return cs, defaultRDS, array2
+}
+
+func TestCreateVolume_BackendRouting(t *testing.T) {
+	ctx := context.Background()
+	cs, defaultRDS, array2 := testMultiBackendControllerServer(t)
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"}},
+	}
+
+	resp, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeID1,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+		Parameters:         map[string]string{"backend": "array2"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	if _, err := array2.GetVolume(testVolumeID1); err != nil {
+		t.Errorf("expected volume on array2: %v", err)
+	}
+	if _, err := defaultRDS.GetVolume(testVolumeID1); err == nil {
+		t.Error("volume must not be created on the default backend")
+	}
+
+	volCtx := resp.Volume.VolumeContext
+	if volCtx["backend"] != "array2" || volCtx["rdsAddress"] != "10.0.0.2" || volCtx["nvmeAddress"] != "10.0.1.2" {
+		t.Errorf("VolumeContext does not record array2: backend=%q rdsAddress=%q nvmeAddress=%q",
+			volCtx["backend"], volCtx["rdsAddress"], volCtx["nvmeAddress"])
+	}
+
+	// Volumes without a backend parameter stay on the default backend
+	resp, err = cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeID2,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume on default backend failed: %v", err)
+	}
+	if _, err := defaultRDS.GetVolume(testVolumeID2); err != nil {
+		t.Errorf("expected volume on default backend: %v", err)
+	}
+	if resp.Volume.VolumeContext["backend"] != rds.DefaultBackendName || resp.Volume.VolumeContext["nvmeAddress"] != "10.0.0.1" {
+		t.Errorf("unexpected default VolumeContext: %v", resp.Volume.VolumeContext)
+	}
+
+	// Publish uses the backend recorded in the volume context
+	publishResp, err := cs.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+		VolumeId:         testVolumeID1,
+		NodeId:           "node-1",
+		VolumeCapability: mountCap,
+		VolumeContext:    volCtx,
+	})
+	if err != nil {
+		t.Fatalf("ControllerPublishVolume failed: %v", err)
+	}
+	if publishResp.PublishContext["nvme_address"] != "10.0.1.2" {
+		t.Errorf("expected publish context to target array2, got %q", publishResp.PublishContext["nvme_address"])
+	}
+
+	// Snapshots are taken on the source volume's backend
+	snapResp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: "snap-1", SourceVolumeId: testVolumeID1})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	snapshotID := snapResp.Snapshot.SnapshotId
+	if _, err := array2.GetSnapshot(snapshotID); err != nil {
+		t.Errorf("expected snapshot on array2: %v", err)
+	}
+	if _, err := cs.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotID}); err != nil {
+		t.Fatalf("DeleteSnapshot failed: %v", err)
+	}
+	if _, err := array2.GetSnapshot(snapshotID); err == nil {
+		t.Error("expected snapshot to be deleted from array2")
+	}
+
+	// DeleteVolume carries only the ID; the volume is found on its backend
+	if _, err := cs.ControllerUnpublishVolume(ctx, &csi.ControllerUnpublishVolumeRequest{
+		VolumeId: testVolumeID1,
+		NodeId:   "node-1",
+	}); err != nil {
+		t.Fatalf("ControllerUnpublishVolume failed: %v", err)
+	}
+	if _, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID1}); err != nil {
+		t.Fatalf("DeleteVolume failed: %v", err)
+	}
+	if _, err := array2.GetVolume(testVolumeID1); err == nil {
+		t.Error("expected volume to be deleted from array2")
+	}
+	if _, err := defaultRDS.GetVolume(testVolumeID2); err != nil {
+		t.Errorf("default backend volume must be untouched: %v", err)
+	}
+
+	// ListVolumes reports volumes from every backend
+	array2.AddVolume(&rds.VolumeInfo{Slot: testVolumeID3, FileSizeBytes: 1 << 30})
+	listResp, err := cs.ListVolumes(ctx, &csi.ListVolumesRequest{})
+	if err != nil {
+		t.Fatalf("ListVolumes failed: %v", err)
+	}
+	if len(listResp.Entries) != 2 {
+		t.Errorf("expected 2 volumes across backends, got %d", len(listResp.Entries))
+	}
+}
+
+func TestCreateVolume_UnknownBackend(t *testing.T) {
+	cs, _, _ := testMultiBackendControllerServer(t)
+
+	_, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:          testVolumeID1,
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{{
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		}},
+		Parameters: map[string]string{"backend": "array3"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for unknown backend, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "array3") {
+		t.Errorf("expected error to name the unknown backend, got %v", err)
+	}
+
+	_, err = cs.GetCapacity(context.Background(), &csi.GetCapacityRequest{Parameters: map[string]string{"backend": "array3"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument from GetCapacity for unknown backend, got %v", err)
+	}
+}
+
+func TestDiskComments(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"}},
+	}
+
+	// PVC metadata from --extra-create-metadata is written as the disk comment
+	_, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeID1,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+		Parameters: map[string]string{
+			paramPVCNamespace: "databases",
+			paramPVCName:      "data-postgres-0",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	volume, _ := mockRDS.GetVolume(testVolumeID1)
+	if volume.Comment != "databases/data-postgres-0" {
+		t.Errorf("expected comment databases/data-postgres-0, got %q", volume.Comment)
+	}
+
+	getResp, err := cs.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID1})
+	if err != nil {
+		t.Fatalf("ControllerGetVolume failed: %v", err)
+	}
+	if got := getResp.Volume.VolumeContext[volumeContextDiskComment]; got != "databases/data-postgres-0" {
+		t.Errorf("ControllerGetVolume comment = %q", got)
+	}
+	listResp, err := cs.ListVolumes(ctx, &csi.ListVolumesRequest{})
+	if err != nil {
+		t.Fatalf("ListVolumes failed: %v", err)
+	}
+	if len(listResp.Entries) != 1 || listResp.Entries[0].Volume.VolumeContext[volumeContextDiskComment] != "databases/data-postgres-0" {
+		t.Errorf("ListVolumes did not surface the comment: %v", listResp.Entries)
+	}
+
+	// User-controlled names cannot inject RouterOS syntax
+	_, err = cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeID2,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+		Parameters: map[string]string{
+			paramPVCNamespace: "ns",
+			paramPVCName:      `x" slot=evil; /system reboot`,
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	volume, _ = mockRDS.GetVolume(testVolumeID2)
+	if err := utils.ValidateDiskComment(volume.Comment); err != nil || !strings.HasPrefix(volume.Comment, "ns/x_") {
+		t.Errorf("expected sanitized comment, got %q (%v)", volume.Comment, err)
+	}
+
+	// Snapshots are labelled with the VolumeSnapshot
+	snapResp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		Name:           "snap-1",
+		SourceVolumeId: testVolumeID1,
+		Parameters: map[string]string{
+			paramSnapshotNamespace: "databases",
+			paramSnapshotName:      "nightly",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	snapshot, _ := mockRDS.GetSnapshot(snapResp.Snapshot.SnapshotId)
+	if want := "databases/nightly/" + testVolumeID1; snapshot.Comment != want {
+		t.Errorf("expected snapshot comment %s, got %q", want, snapshot.Comment)
+	}
+
+	// Expansion labels volumes created without metadata from their PV claimRef
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID3, FileSizeBytes: 1 << 30})
+	if _, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Create(ctx, &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: testVolumeID3},
+		Spec:       corev1.PersistentVolumeSpec{ClaimRef: &corev1.ObjectReference{Namespace: "web", Name: "uploads"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create PV: %v", err)
+	}
+	if _, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+		VolumeId:         testVolumeID3,
+		CapacityRange:    &csi.CapacityRange{RequiredBytes: 2 << 30},
+		VolumeCapability: mountCap,
+	}); err != nil {
+		t.Fatalf("ControllerExpandVolume failed: %v", err)
+	}
+	volume, _ = mockRDS.GetVolume(testVolumeID3)
+	if volume.Comment != "web/uploads" {
+		t.Errorf("expected comment web/uploads after expand, got %q", volume.Comment)
+	}
+}
+
+func TestControllerModifyVolume(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID1, FileSizeBytes: 1 << 30, Comment: "web/uploads"})
+	pvs := cs.driver.k8sClient.CoreV1().PersistentVolumes()
+	if _, err := pvs.Create(ctx, &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: testVolumeID1},
+		Spec:       corev1.PersistentVolumeSpec{ClaimRef: &corev1.ObjectReference{Namespace: "web", Name: "uploads"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create PV: %v", err)
+	}
+
+	modify := func(params map[string]string) error {
+		_, err := cs.ControllerModifyVolume(ctx, &csi.ControllerModifyVolumeRequest{
+			VolumeId:          testVolumeID1,
+			MutableParameters: params,
+		})
+		return err
+	}
+	params := map[string]string{paramComment: "web/uploads-archive", paramQoSTier: "gold"}
+
+	t.Run("apply", func(t *testing.T) {
+		if err := modify(params); err != nil {
+			t.Fatalf("ControllerModifyVolume failed: %v", err)
+		}
+		volume, _ := mockRDS.GetVolume(testVolumeID1)
+		if volume.Comment != "web/uploads-archive" {
+			t.Errorf("comment = %q, want web/uploads-archive", volume.Comment)
+		}
+		pv, _ := pvs.Get(ctx, testVolumeID1, metav1.GetOptions{})
+		if pv.Annotations[AnnotationComment] != "web/uploads-archive" || pv.Annotations[AnnotationQoSTier] != "gold" {
+			t.Errorf("unexpected PV annotations: %v", pv.Annotations)
+		}
+	})
+
+	t.Run("idempotent re-apply", func(t *testing.T) {
+		before, _ := pvs.Get(ctx, testVolumeID1, metav1.GetOptions{})
+		if err := modify(params); err != nil {
+			t.Fatalf("re-applying the same parameters failed: %v", err)
+		}
+		volume, _ := mockRDS.GetVolume(testVolumeID1)
+		if volume.Comment != "web/uploads-archive" {
+			t.Errorf("comment = %q after re-apply, want web/uploads-archive", volume.Comment)
+		}
+		after, _ := pvs.Get(ctx, testVolumeID1, metav1.GetOptions{})
+		if after.ResourceVersion != before.ResourceVersion {
+			t.Errorf("PV updated although annotations were unchanged")
+		}
+	})
+
+	t.Run("expansion keeps the modified comment", func(t *testing.T) {
+		if _, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+			VolumeId:      testVolumeID1,
+			CapacityRange: &csi.CapacityRange{RequiredBytes: 2 << 30},
+		}); err != nil {
+			t.Fatalf("ControllerExpandVolume failed: %v", err)
+		}
+		volume, _ := mockRDS.GetVolume(testVolumeID1)
+		if volume.Comment != "web/uploads-archive" {
+			t.Errorf("comment = %q after expand, want web/uploads-archive", volume.Comment)
+		}
+	})
+
+	rejects := []struct {
+		name     string
+		volumeID string
+		params   map[string]string
+		code     codes.Code
+	}{
+		{name: "unsupported key", volumeID: testVolumeID1, params: map[string]string{"maxReadIOPS": "100"}, code: codes.InvalidArgument},
+		{name: "invalid comment", volumeID: testVolumeID1, params: map[string]string{paramComment: "a;b"}, code: codes.InvalidArgument},
+		{name: "missing volume ID", params: params, code: codes.InvalidArgument},
+		{name: "unknown volume", volumeID: testVolumeID2, params: params, code: codes.NotFound},
+	}
+	for _, tt := range rejects {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cs.ControllerModifyVolume(ctx, &csi.ControllerModifyVolumeRequest{
+				VolumeId:          tt.volumeID,
+				MutableParameters: tt.params,
+			})
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+
+	t.Run("unsupported key lists accepted keys", func(t *testing.T) {
+		err := modify(map[string]string{"fsType": "xfs"})
+		if err == nil || !strings.Contains(err.Error(), "accepted: comment, qosTier") {
+			t.Errorf("expected error listing accepted keys, got %v", err)
+		}
+	})
+}
+
+func TestCreateVolume_MutableParameters(t *testing.T) {
+	ctx := context.Background()
+	cs, mockRDS := testControllerServer(t)
+	mountCap := &csi.VolumeCapability{
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"}},
+	}
+
+	resp, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeID1,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+		Parameters:         map[string]string{paramPVCNamespace: "web", paramPVCName: "uploads"},
+		MutableParameters:  map[string]string{paramComment: "shared/assets", paramQoSTier: "silver"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	volume, _ := mockRDS.GetVolume(testVolumeID1)
+	if volume.Comment != "shared/assets" {
+		t.Errorf("comment = %q, want shared/assets", volume.Comment)
+	}
+	if got := resp.Volume.VolumeContext[volumeContextQoSTier]; got != "silver" {
+		t.Errorf("VolumeContext qosTier = %q, want silver", got)
+	}
+
+	_, err = cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeID2,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{mountCap},
+		MutableParameters:  map[string]string{"maxWriteIOPS": "100"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unsupported mutable parameter, got %v", err)
+	}
+}
@@ -0,0 +1,324 @@
+package driver
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+const (
+	// debugVolumeCacheTTL is how long a ListVolumes result is served before it is refreshed
+	debugVolumeCacheTTL = 30 * time.Second
+
+	// Live GetVolume lookups open an SSH session each, so operators get a small budget
+	debugLiveLookupQPS   = 1
+	debugLiveLookupBurst = 5
+)
+
+// debugSlotPattern matches the slot names RDS accepts in lookups
+var debugSlotPattern = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// volumeListCache keeps the most recent ListVolumes result per backend so debug
+// requests don't query RDS on every call. CSI ListVolumes refreshes it as well.
+type volumeListCache struct {
+	mu      sync.Mutex
+	entries map[string]*volumeListEntry // by backend name
+	hits    uint64
+	misses  uint64
+	now     func() time.Time
+}
+
+// volumeListEntry is one backend's cached ListVolumes result
+type volumeListEntry struct {
+	volumes   []rds.VolumeInfo
+	fetchedAt time.Time
+	err       error
+}
+
+func newVolumeListCache() *volumeListCache {
+	return &volumeListCache{
+		entries: make(map[string]*volumeListEntry),
+		now:     time.Now,
+	}
+}
+
+// record stores a ListVolumes result for backend
+func (c *volumeListCache) record(backend string, volumes []rds.VolumeInfo, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[backend] = &volumeListEntry{volumes: volumes, fetchedAt: c.now(), err: err}
+}
+
+// get returns backend's cached result, listing volumes on RDS first if the entry
+// is missing or older than debugVolumeCacheTTL
+func (c *volumeListCache) get(backend *rds.Backend) *volumeListEntry {
+	c.mu.Lock()
+	entry, ok := c.entries[backend.Name]
+	if ok && c.now().Sub(entry.fetchedAt) < debugVolumeCacheTTL {
+		c.hits++
+		c.mu.Unlock()
+		return entry
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	volumes, err := backend.Client.ListVolumes()
+	c.record(backend.Name, volumes, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[backend.Name]
+}
+
+// stats returns the cache hit and miss counters
+func (c *volumeListCache) stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// debugVolume is the JSON view of a volume in debug responses
+type debugVolume struct {
+	Backend       string   `json:"backend"`
+	Slot          string   `json:"slot"`
+	Type          string   `json:"type"`
+	FilePath      string   `json:"filePath"`
+	FileSizeBytes int64    `json:"fileSizeBytes"`
+	NVMETCPExport bool     `json:"nvmeTcpExport"`
+	NVMETCPPort   int      `json:"nvmeTcpPort"`
+	NVMETCPNQN    string   `json:"nvmeTcpNqn"`
+	WWID          string   `json:"wwid,omitempty"`
+	Status        string   `json:"status"`
+	Comment       string   `json:"comment,omitempty"`
+	MaxReadIOPS   int64    `json:"maxReadIOPS,omitempty"`
+	MaxWriteIOPS  int64    `json:"maxWriteIOPS,omitempty"`
+	MaxBandwidth  int64    `json:"maxBandwidthBytes,omitempty"`
+	ParseWarnings []string `json:"parseWarnings,omitempty"`
+}
+
+func newDebugVolume(backend string, v *rds.VolumeInfo) debugVolume {
+	return debugVolume{
+		Backend:       backend,
+		Slot:          v.Slot,
+		Type:          v.Type,
+		FilePath:      v.FilePath,
+		FileSizeBytes: v.FileSizeBytes,
+		NVMETCPExport: v.NVMETCPExport,
+		NVMETCPPort:   v.NVMETCPPort,
+		NVMETCPNQN:    v.NVMETCPNQN,
+		WWID:          v.WWID,
+		Status:        v.Status,
+		Comment:       v.Comment,
+		MaxReadIOPS:   v.QoS.MaxReadIOPS,
+		MaxWriteIOPS:  v.QoS.MaxWriteIOPS,
+		MaxBandwidth:  v.QoS.MaxBandwidthBytes,
+		ParseWarnings: v.ParseWarnings,
+	}
+}
+
+// debugBackendVolumes is one backend's part of the /debug/rds/volumes response
+type debugBackendVolumes struct {
+	Backend         string        `json:"backend"`
+	FetchedAt       time.Time     `json:"fetchedAt"`
+	CacheAgeSeconds float64       `json:"cacheAgeSeconds"`
+	Error           string        `json:"error,omitempty"`
+	Volumes         []debugVolume `json:"volumes"`
+}
+
+// debugCacheStats reports how the volume cache is used
+type debugCacheStats struct {
+	TTLSeconds float64 `json:"ttlSeconds"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+}
+
+// debugVolumeResponse is the /debug/rds/volumes/<slot> response
+type debugVolumeResponse struct {
+	Live            bool        `json:"live"`
+	CacheAgeSeconds float64     `json:"cacheAgeSeconds,omitempty"`
+	Volume          debugVolume `json:"volume"`
+}
+
+// debugServer serves the read-only RDS debug endpoints
+type debugServer struct {
+	driver      *Driver
+	cache       *volumeListCache
+	allowRemote bool
+
+	limiterMu sync.Mutex
+	limiter   flowcontrol.PassiveRateLimiter
+}
+
+// DebugHandler returns the HTTP handler for read-only RDS debug endpoints, showing what
+// the controller believes exists on RDS without running SSH commands by hand. Requests
+// from other hosts are refused unless allowRemote is set.
+//
+//	GET /debug/rds/volumes
+//	    Cached ListVolumes result of every backend, with cache age and hit/miss counters.
+//	GET /debug/rds/volumes/<slot>?live=true
+//	    One volume, from the cache or, with live=true, from a rate-limited GetVolume.
+func (d *Driver) DebugHandler(allowRemote bool) http.Handler {
+	s := &debugServer{
+		driver:      d,
+		cache:       d.volumeCache,
+		allowRemote: allowRemote,
+		limiter:     flowcontrol.NewTokenBucketPassiveRateLimiter(debugLiveLookupQPS, debugLiveLookupBurst),
+	}
+	if s.cache == nil {
+		s.cache = newVolumeListCache()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/rds/volumes", s.handleVolumes)
+	mux.HandleFunc("/debug/rds/volumes/", s.handleVolume)
+	return mux
+}
+
+// checkRequest rejects non-GET requests, remote clients, and drivers without a controller
+func (s *debugServer) checkRequest(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if !s.allowRemote && !isLoopbackRequest(r) {
+		http.Error(w, "debug endpoints only accept requests from localhost", http.StatusForbidden)
+		return false
+	}
+	if s.driver.rdsClient == nil {
+		http.Error(w, "RDS debug endpoints require controller mode", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+func (s *debugServer) handleVolumes(w http.ResponseWriter, r *http.Request) {
+	if !s.checkRequest(w, r) {
+		return
+	}
+
+	var backends []debugBackendVolumes
+	for _, backend := range s.driver.getBackends().Backends() {
+		entry := s.cache.get(backend)
+		result := debugBackendVolumes{
+			Backend:         backend.Name,
+			FetchedAt:       entry.fetchedAt,
+			CacheAgeSeconds: s.cache.now().Sub(entry.fetchedAt).Seconds(),
+			Volumes:         make([]debugVolume, 0, len(entry.volumes)),
+		}
+		if entry.err != nil {
+			result.Error = entry.err.Error()
+		}
+		for i := range entry.volumes {
+			result.Volumes = append(result.Volumes, newDebugVolume(backend.Name, &entry.volumes[i]))
+		}
+		backends = append(backends, result)
+	}
+
+	hits, misses := s.cache.stats()
+	writeDebugJSON(w, struct {
+		Backends []debugBackendVolumes `json:"backends"`
+		Cache    debugCacheStats       `json:"cache"`
+	}{
+		Backends: backends,
+		Cache:    debugCacheStats{TTLSeconds: debugVolumeCacheTTL.Seconds(), Hits: hits, Misses: misses},
+	})
+}
+
+func (s *debugServer) handleVolume(w http.ResponseWriter, r *http.Request) {
+	if !s.checkRequest(w, r) {
+		return
+	}
+
+	slot := strings.TrimPrefix(r.URL.Path, "/debug/rds/volumes/")
+	if !debugSlotPattern.MatchString(slot) {
+		http.Error(w, "invalid slot name: "+slot, http.StatusBadRequest)
+		return
+	}
+
+	live := false
+	if value := r.URL.Query().Get("live"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "invalid live value: "+value, http.StatusBadRequest)
+			return
+		}
+		live = parsed
+	}
+
+	if live {
+		s.handleLiveVolume(w, r, slot)
+		return
+	}
+
+	for _, backend := range s.driver.getBackends().Backends() {
+		entry := s.cache.get(backend)
+		for i := range entry.volumes {
+			if entry.volumes[i].Slot == slot {
+				writeDebugJSON(w, debugVolumeResponse{
+					CacheAgeSeconds: s.cache.now().Sub(entry.fetchedAt).Seconds(),
+					Volume:          newDebugVolume(backend.Name, &entry.volumes[i]),
+				})
+				return
+			}
+		}
+	}
+	http.Error(w, "volume "+slot+" not in cached volume list (try live=true)", http.StatusNotFound)
+}
+
+// handleLiveVolume looks slot up on every backend with GetVolume
+func (s *debugServer) handleLiveVolume(w http.ResponseWriter, r *http.Request, slot string) {
+	s.limiterMu.Lock()
+	allowed := s.limiter.TryAccept()
+	s.limiterMu.Unlock()
+	if !allowed {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many live lookups, try again shortly", http.StatusTooManyRequests)
+		return
+	}
+
+	klog.V(2).Infof("Debug request: live GetVolume %s (remote=%s)", slot, r.RemoteAddr)
+	for _, backend := range s.driver.getBackends().Backends() {
+		volume, err := backend.Client.GetVolume(slot)
+		if err != nil {
+			var notFound *rds.VolumeNotFoundError
+			if errors.As(err, &notFound) || errors.Is(err, utils.ErrVolumeNotFound) {
+				continue
+			}
+			http.Error(w, "GetVolume on backend "+backend.Name+" failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeDebugJSON(w, debugVolumeResponse{Live: true, Volume: newDebugVolume(backend.Name, volume)})
+		return
+	}
+	http.Error(w, "volume "+slot+" not found on RDS", http.StatusNotFound)
+}
+
+// isLoopbackRequest reports whether r came from the local host
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeDebugJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Errorf("Failed to write debug response: %v", err)
+	}
+}
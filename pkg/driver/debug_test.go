@@ -0,0 +1,174 @@
+package driver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+// debugTestDriver returns a controller driver backed by a mock RDS and a cache with a
+// controllable clock
+func debugTestDriver(t *testing.T) (*Driver, *rds.MockClient, *time.Time) {
+	t.Helper()
+	mockRDS := rds.NewMockClient()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := newVolumeListCache()
+	cache.now = func() time.Time { return now }
+	return &Driver{name: DriverName, rdsClient: mockRDS, volumeCache: cache}, mockRDS, &now
+}
+
+// debugGet serves a GET for target from localhost and decodes a 200 response into out
+func debugGet(t *testing.T, handler http.Handler, target string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK && out != nil {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			t.Fatalf("failed to decode %s response: %v", target, err)
+		}
+	}
+	return rec.Code
+}
+
+type debugVolumesResult struct {
+	Backends []debugBackendVolumes `json:"backends"`
+	Cache    debugCacheStats       `json:"cache"`
+}
+
+func TestDebugHandler_Volumes(t *testing.T) {
+	d, mockRDS, now := debugTestDriver(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-a", FilePath: "/storage-pool/pvc-a.img", FileSizeBytes: 1 << 30, Status: "ready"})
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-b", FilePath: "/storage-pool/pvc-b.img", ParseWarnings: []string{"file-size=1.2.3GiB: invalid syntax"}})
+	handler := d.DebugHandler(false)
+
+	var first debugVolumesResult
+	if code := debugGet(t, handler, "/debug/rds/volumes", &first); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(first.Backends) != 1 || len(first.Backends[0].Volumes) != 2 {
+		t.Fatalf("expected 2 volumes on one backend, got %+v", first.Backends)
+	}
+	if first.Cache.Misses != 1 || first.Cache.Hits != 0 {
+		t.Errorf("first request should miss the cache, got %+v", first.Cache)
+	}
+	for _, v := range first.Backends[0].Volumes {
+		if v.Slot == "pvc-b" && (len(v.ParseWarnings) != 1 || v.ParseWarnings[0] != "file-size=1.2.3GiB: invalid syntax") {
+			t.Errorf("parse warnings not reported: %+v", v)
+		}
+	}
+
+	// Within the TTL the cached list is served, even though RDS changed
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-c"})
+	*now = now.Add(10 * time.Second)
+	var cached debugVolumesResult
+	debugGet(t, handler, "/debug/rds/volumes", &cached)
+	if len(cached.Backends[0].Volumes) != 2 || cached.Cache.Hits != 1 {
+		t.Errorf("expected cached result with 2 volumes and 1 hit, got %d volumes, %+v", len(cached.Backends[0].Volumes), cached.Cache)
+	}
+	if cached.Backends[0].CacheAgeSeconds != 10 {
+		t.Errorf("cache age = %v, want 10", cached.Backends[0].CacheAgeSeconds)
+	}
+
+	// After the TTL the list is refreshed
+	*now = now.Add(debugVolumeCacheTTL)
+	var refreshed debugVolumesResult
+	debugGet(t, handler, "/debug/rds/volumes", &refreshed)
+	if len(refreshed.Backends[0].Volumes) != 3 || refreshed.Cache.Misses != 2 || refreshed.Backends[0].CacheAgeSeconds != 0 {
+		t.Errorf("expected refreshed result with 3 volumes, got %d volumes, age %v, %+v",
+			len(refreshed.Backends[0].Volumes), refreshed.Backends[0].CacheAgeSeconds, refreshed.Cache)
+	}
+}
+
+func TestDebugHandler_Volume(t *testing.T) {
+	d, mockRDS, _ := debugTestDriver(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-a", FileSizeBytes: 1 << 30})
+	handler := d.DebugHandler(false)
+
+	var cached debugVolumeResponse
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-a", &cached); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if cached.Live || cached.Volume.Slot != "pvc-a" || cached.Volume.FileSizeBytes != 1<<30 || cached.Volume.Backend != rds.DefaultBackendName {
+		t.Errorf("unexpected cached volume: %+v", cached)
+	}
+
+	// A volume created after the list was cached is only found live
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-new"})
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-new", nil); code != http.StatusNotFound {
+		t.Errorf("cached lookup of new volume: status = %d, want 404", code)
+	}
+	var live debugVolumeResponse
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-new?live=true", &live); code != http.StatusOK {
+		t.Fatalf("live lookup: status = %d, want 200", code)
+	}
+	if !live.Live || live.Volume.Slot != "pvc-new" {
+		t.Errorf("unexpected live volume: %+v", live)
+	}
+
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-missing?live=true", nil); code != http.StatusNotFound {
+		t.Errorf("live lookup of missing volume: status = %d, want 404", code)
+	}
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-a;reboot", nil); code != http.StatusBadRequest {
+		t.Errorf("invalid slot: status = %d, want 400", code)
+	}
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-a?live=maybe", nil); code != http.StatusBadRequest {
+		t.Errorf("invalid live value: status = %d, want 400", code)
+	}
+}
+
+func TestDebugHandler_LiveLookupRateLimited(t *testing.T) {
+	d, mockRDS, _ := debugTestDriver(t)
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-a"})
+	handler := d.DebugHandler(false)
+
+	for i := 0; i < debugLiveLookupBurst; i++ {
+		if code := debugGet(t, handler, "/debug/rds/volumes/pvc-a?live=true", nil); code != http.StatusOK {
+			t.Fatalf("live lookup %d: status = %d, want 200", i+1, code)
+		}
+	}
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-a?live=true", nil); code != http.StatusTooManyRequests {
+		t.Errorf("lookup beyond burst: status = %d, want 429", code)
+	}
+	// Cached lookups are not limited
+	if code := debugGet(t, handler, "/debug/rds/volumes/pvc-a", nil); code != http.StatusOK {
+		t.Errorf("cached lookup: status = %d, want 200", code)
+	}
+}
+
+func TestDebugHandler_Access(t *testing.T) {
+	d, _, _ := debugTestDriver(t)
+
+	tests := []struct {
+		name        string
+		driver      *Driver
+		allowRemote bool
+		method      string
+		remoteAddr  string
+		wantCode    int
+	}{
+		{name: "localhost", driver: d, method: http.MethodGet, remoteAddr: "127.0.0.1:40000", wantCode: http.StatusOK},
+		{name: "IPv6 localhost", driver: d, method: http.MethodGet, remoteAddr: "[::1]:40000", wantCode: http.StatusOK},
+		{name: "remote refused by default", driver: d, method: http.MethodGet, remoteAddr: "10.0.0.5:40000", wantCode: http.StatusForbidden},
+		{name: "remote allowed", driver: d, allowRemote: true, method: http.MethodGet, remoteAddr: "10.0.0.5:40000", wantCode: http.StatusOK},
+		{name: "POST rejected", driver: d, method: http.MethodPost, remoteAddr: "127.0.0.1:40000", wantCode: http.StatusMethodNotAllowed},
+		{name: "node-only driver", driver: &Driver{}, method: http.MethodGet, remoteAddr: "127.0.0.1:40000", wantCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/debug/rds/volumes", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			tt.driver.DebugHandler(tt.allowRemote).ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+const (
+	// DefaultDeleteBatchWindow is how long DeleteVolume waits for other deletions to share
+	// one batch of RDS commands with. The wait holds the volume's lock, so it is kept short:
+	// a namespace teardown deletes its PVCs within a few hundred milliseconds of each other.
+	DefaultDeleteBatchWindow = 250 * time.Millisecond
+
+	// maxDeleteBatchSize bounds the slots removed by one RouterOS command, so a namespace
+	// teardown does not build a single command line of unbounded length
+	maxDeleteBatchSize = 50
+)
+
+// deleteBatcher coalesces DeleteVolume calls that arrive within a short window into one
+// DeleteVolumes call per backend. Deleting a namespace with many PVCs otherwise runs
+// several SSH commands per volume back to back, stalling provisioning behind them.
+// Every caller still gets the outcome of its own slot.
+type deleteBatcher struct {
+	window  time.Duration
+	mu      sync.Mutex
+	pending map[rds.RDSClient]*deleteBatch // Batches still collecting slots, by backend client
+}
+
+// deleteBatch is the set of slots one DeleteVolumes call will remove
+type deleteBatch struct {
+	client  rds.RDSClient
+	slots   []string
+	waiters map[string][]deleteWaiter // Callers waiting for each slot's outcome
+	timer   *time.Timer
+}
+
+// deleteWaiter is a DeleteVolume call waiting for the outcome of its slot
+type deleteWaiter struct {
+	requestID string // x-csi-request-id of the call, logged against its slot
+	result    chan error
+}
+
+// newDeleteBatcher creates a batcher that waits window for a batch to fill
+func newDeleteBatcher(window time.Duration) *deleteBatcher {
+	return &deleteBatcher{
+		window:  window,
+		pending: make(map[rds.RDSClient]*deleteBatch),
+	}
+}
+
+// Delete deletes slot on client as part of the next batch and returns its outcome. If ctx
+// ends first, Delete returns ctx.Err() and the deletion still completes with the batch.
+// A batch serves several calls, so it runs on the shared client rather than a call's view;
+// the request ID of each call is logged against its slot when the batch is flushed.
+func (b *deleteBatcher) Delete(ctx context.Context, client rds.RDSClient, slot string) error {
+	client = rds.Unscoped(client)
+	result := make(chan error, 1)
+
+	b.mu.Lock()
+	batch, ok := b.pending[client]
+	if !ok {
+		batch = &deleteBatch{client: client, waiters: make(map[string][]deleteWaiter)}
+		b.pending[client] = batch
+		batch.timer = time.AfterFunc(b.window, func() { b.flush(batch) })
+	}
+	if _, queued := batch.waiters[slot]; !queued {
+		batch.slots = append(batch.slots, slot)
+	}
+	batch.waiters[slot] = append(batch.waiters[slot], deleteWaiter{requestID: requestIDFromContext(ctx), result: result})
+	full := len(batch.slots) >= maxDeleteBatchSize
+	if full {
+		// Later callers start a new batch rather than wait for this one's window
+		delete(b.pending, client)
+	}
+	b.mu.Unlock()
+
+	if full && batch.timer.Stop() {
+		go b.flush(batch)
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush deletes the slots of batch and delivers each slot's outcome to its callers
+func (b *deleteBatcher) flush(batch *deleteBatch) {
+	b.mu.Lock()
+	if b.pending[batch.client] == batch {
+		delete(b.pending, batch.client)
+	}
+	b.mu.Unlock()
+
+	// No caller can join the batch any more, so its slots and waiters are fixed
+	// The RouterOS commands carry no request ID, so correlate the slots with their calls here
+	klog.V(4).Infof("Deleting %d volumes in one batch", len(batch.slots))
+	for _, slot := range batch.slots {
+		klog.V(2).Infof("Deleting volume %s in a batch of %d (request IDs: %s)", slot, len(batch.slots), batch.requestIDs(slot))
+	}
+	results := batch.client.DeleteVolumes(batch.slots)
+	for _, slot := range batch.slots {
+		err, ok := results[slot]
+		if !ok {
+			err = fmt.Errorf("no outcome reported for volume %s", slot)
+		}
+		for _, waiter := range batch.waiters[slot] {
+			waiter.result <- err
+		}
+	}
+}
+
+// requestIDs returns the request IDs of the calls waiting for slot, comma-separated
+func (batch *deleteBatch) requestIDs(slot string) string {
+	ids := make([]string, 0, len(batch.waiters[slot]))
+	for _, waiter := range batch.waiters[slot] {
+		if waiter.requestID != "" {
+			ids = append(ids, waiter.requestID)
+		}
+	}
+	if len(ids) == 0 {
+		return "none"
+	}
+	return strings.Join(ids, ",")
+}
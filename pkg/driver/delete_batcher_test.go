@@ -0,0 +1,161 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+func TestDeleteBatcher_MixedOutcomes(t *testing.T) {
+	mockRDS := rds.NewMockClient()
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID1})
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID2})
+	mockRDS.SetDeleteError(testVolumeID2, errors.New("failure: execution error"))
+	batcher := newDeleteBatcher(50 * time.Millisecond)
+
+	// testVolumeID1 is requested twice, testVolumeID3 does not exist
+	slots := []string{testVolumeID1, testVolumeID2, testVolumeID3, testVolumeID1}
+	errs := make([]error, len(slots))
+	var wg sync.WaitGroup
+	for i, slot := range slots {
+		wg.Add(1)
+		go func(i int, slot string) {
+			defer wg.Done()
+			errs[i] = batcher.Delete(context.Background(), mockRDS, slot)
+		}(i, slot)
+	}
+	wg.Wait()
+
+	for i, slot := range slots {
+		wantErr := slot == testVolumeID2
+		if (errs[i] != nil) != wantErr {
+			t.Errorf("Delete(%s) error = %v, want error %v", slot, errs[i], wantErr)
+		}
+	}
+	if batches := mockRDS.DeleteBatches(); len(batches) != 1 || len(batches[0]) != 3 {
+		t.Errorf("expected one batch of 3 slots, got %v", batches)
+	}
+	if _, err := mockRDS.GetVolume(testVolumeID1); err == nil {
+		t.Error("expected volume 1 to be deleted")
+	}
+	if _, err := mockRDS.GetVolume(testVolumeID2); err != nil {
+		t.Error("volume whose deletion failed should remain")
+	}
+}
+
+func TestDeleteBatcher_LogsRequestIDs(t *testing.T) {
+	buf := captureKlog(t, "2")
+	mockRDS := rds.NewMockClient()
+	batcher := newDeleteBatcher(50 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i, slot := range []string{testVolumeID1, testVolumeID2, testVolumeID1} {
+		wg.Add(1)
+		go func(requestID, slot string) {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), requestIDKey{}, requestID)
+			if err := batcher.Delete(ctx, mockRDS, slot); err != nil {
+				t.Errorf("Delete(%s) failed: %v", slot, err)
+			}
+		}(fmt.Sprintf("req-%d", i), slot)
+	}
+	wg.Wait()
+	klog.Flush()
+
+	for _, want := range []*regexp.Regexp{
+		regexp.MustCompile("Deleting volume " + testVolumeID1 + ` in a batch of 2 \(request IDs: req-[02],req-[02]\)`),
+		regexp.MustCompile("Deleting volume " + testVolumeID2 + ` in a batch of 2 \(request IDs: req-1\)`),
+	} {
+		if !want.MatchString(buf.String()) {
+			t.Errorf("expected a log line matching %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDeleteBatcher_FullBatchDoesNotWait(t *testing.T) {
+	mockRDS := rds.NewMockClient()
+	batcher := newDeleteBatcher(time.Hour)
+
+	errs := make(chan error, maxDeleteBatchSize)
+	for i := 0; i < maxDeleteBatchSize; i++ {
+		go func(i int) {
+			errs <- batcher.Delete(context.Background(), mockRDS, fmt.Sprintf("pvc-%d", i))
+		}(i)
+	}
+	for i := 0; i < maxDeleteBatchSize; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("full batch was not flushed before the window")
+		}
+	}
+}
+
+func TestDeleteBatcher_ContextCancelled(t *testing.T) {
+	mockRDS := rds.NewMockClient()
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: testVolumeID1})
+	batcher := newDeleteBatcher(100 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := batcher.Delete(ctx, mockRDS, testVolumeID1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// The deletion still completes with the batch, so a retry finds nothing to delete
+	time.Sleep(300 * time.Millisecond)
+	if _, err := mockRDS.GetVolume(testVolumeID1); err == nil {
+		t.Error("expected the batch to delete the volume")
+	}
+}
+
+func TestDeleteVolume_Batched(t *testing.T) {
+	cs, mockRDS := testControllerServer(t)
+	cs.driver.deleteBatcher = newDeleteBatcher(50 * time.Millisecond)
+	for _, slot := range []string{testVolumeID1, testVolumeID2, testVolumeID3} {
+		mockRDS.AddVolume(&rds.VolumeInfo{Slot: slot, FilePath: "/storage-pool/metal-csi/" + slot + ".img"})
+	}
+	mockRDS.SetDeleteError(testVolumeID2, errors.New("failure: execution error"))
+
+	volumeIDs := []string{testVolumeID1, testVolumeID2, testVolumeID3}
+	errs := make([]error, len(volumeIDs))
+	var wg sync.WaitGroup
+	for i, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(i int, volumeID string) {
+			defer wg.Done()
+			_, errs[i] = cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: volumeID})
+		}(i, volumeID)
+	}
+	wg.Wait()
+
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("expected volumes 1 and 3 to delete, got %v and %v", errs[0], errs[2])
+	}
+	if status.Code(errs[1]) != codes.Internal {
+		t.Errorf("expected Internal for volume 2, got %v", errs[1])
+	}
+	if batches := mockRDS.DeleteBatches(); len(batches) != 1 {
+		t.Errorf("expected one batch, got %v", batches)
+	}
+
+	// The failed volume is retried on its own and succeeds once RDS allows it
+	mockRDS.SetDeleteError(testVolumeID2, nil)
+	if _, err := cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: testVolumeID2}); err != nil {
+		t.Errorf("retry failed: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// describeCheckTimeout bounds each check of /admin/describe, so that one hung check
+// (e.g. an unresponsive RDS) does not hang the report
+const describeCheckTimeout = 10 * time.Second
+
+// Outcomes of a describe check
+const (
+	checkOK       = "ok"
+	checkFailed   = "failed"
+	checkTimedOut = "timeout"
+	checkSkipped  = "skipped" // not applicable, or depends on a check that did not pass
+)
+
+// describeReport is the /admin/describe response: live checks of one volume on RDS and
+// in the controller's attachment state. Healthy is false if any check failed or timed out.
+type describeReport struct {
+	VolumeID   string              `json:"volumeID"`
+	Healthy    bool                `json:"healthy"`
+	RDS        *describeRDSVolume  `json:"rds,omitempty"`
+	Attachment *describeAttachment `json:"attachment,omitempty"`
+	Checks     []describeCheck     `json:"checks"`
+}
+
+// describeRDSVolume is the disk entry of the volume as RDS reports it
+type describeRDSVolume struct {
+	Backend    string `json:"backend,omitempty"`
+	Slot       string `json:"slot"`
+	Status     string `json:"status"`
+	SizeBytes  int64  `json:"sizeBytes"`
+	FilePath   string `json:"filePath"`
+	NVMeExport bool   `json:"nvmeExport"`
+	NQN        string `json:"nqn,omitempty"`
+	Port       int    `json:"port,omitempty"`
+}
+
+// describeAttachment is the controller's attachment state of the volume
+type describeAttachment struct {
+	Nodes      []string `json:"nodes"`
+	AccessMode string   `json:"accessMode,omitempty"`
+	Migrating  bool     `json:"migrating,omitempty"`
+}
+
+// describeCheck is the outcome of one check
+type describeCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+func (d *Driver) handleDescribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if d.rdsClient == nil {
+		http.Error(w, "describe requires controller mode", http.StatusServiceUnavailable)
+		return
+	}
+
+	volumeID := r.URL.Query().Get("volumeID")
+	if volumeID == "" {
+		http.Error(w, "volumeID is required", http.StatusBadRequest)
+		return
+	}
+	if err := utils.ValidateVolumeID(volumeID); err != nil {
+		http.Error(w, "invalid volumeID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	klog.Infof("Admin request: describe (volume=%s, remote=%s)", volumeID, r.RemoteAddr)
+	report := d.describeVolumeLive(r.Context(), volumeID, describeCheckTimeout)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		klog.Errorf("Failed to write describe report: %v", err)
+	}
+}
+
+// describeVolumeLive runs the checks of volumeID, each bounded by timeout. The RDS volume
+// and attachment checks run together; the NVMe checks need the volume's backend and run
+// after. Node-side device and mount health is reported as skipped: the controller has no
+// view of the nodes' devices.
+func (d *Driver) describeVolumeLive(ctx context.Context, volumeID string, timeout time.Duration) describeReport {
+	report := describeReport{VolumeID: volumeID}
+
+	// backend, volume and state are only set by checks that finished, and only read
+	// when the check reports ok
+	var (
+		backend *rds.Backend
+		volume  *rds.VolumeInfo
+		state   *describeAttachment
+	)
+	var volumeCheck, attachmentCheck describeCheck
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		volumeCheck = runDescribeCheck(ctx, "rds-volume", timeout, func(ctx context.Context) (string, error) {
+			var err error
+			backend, volume, err = NewControllerServer(d).findVolume(ctx, volumeID, nil)
+			if err != nil {
+				if isVolumeNotFound(err) {
+					return "", fmt.Errorf("volume not found on RDS: %w", err)
+				}
+				return "", err
+			}
+			if volume.Status != "" && volume.Status != "ready" {
+				return "", fmt.Errorf("volume status is %q", volume.Status)
+			}
+			return fmt.Sprintf("slot %s has %d bytes in %s", volume.Slot, volume.FileSizeBytes, volume.FilePath), nil
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		if d.attachmentManager == nil {
+			attachmentCheck = describeCheck{Name: "attachment", Status: checkSkipped, Message: "attachment tracking is not running"}
+			return
+		}
+		attachmentCheck = runDescribeCheck(ctx, "attachment", timeout, func(ctx context.Context) (string, error) {
+			state = &describeAttachment{Nodes: []string{}}
+			attachment, ok := d.attachmentManager.GetAttachment(volumeID)
+			if !ok {
+				return "not attached", nil
+			}
+			state.Nodes = attachment.GetNodeIDs()
+			state.AccessMode = attachment.AccessMode
+			state.Migrating = attachment.IsMigrating()
+			if attachment.IsMigrationTimedOut() {
+				return "", fmt.Errorf("migration between %v timed out", state.Nodes)
+			}
+			return fmt.Sprintf("attached to %v", state.Nodes), nil
+		})
+	}()
+	wg.Wait()
+
+	rdsFound := volumeCheck.Status == checkOK || (volumeCheck.Status == checkFailed && volume != nil)
+	if rdsFound {
+		report.RDS = &describeRDSVolume{
+			Backend:    backend.Name,
+			Slot:       volume.Slot,
+			Status:     volume.Status,
+			SizeBytes:  volume.FileSizeBytes,
+			FilePath:   volume.FilePath,
+			NVMeExport: volume.NVMETCPExport,
+			NQN:        volume.NVMETCPNQN,
+			Port:       volume.NVMETCPPort,
+		}
+	}
+	if attachmentCheck.Status == checkOK || attachmentCheck.Status == checkFailed {
+		report.Attachment = state
+	}
+
+	exportCheck := describeCheck{Name: "nvme-export", Status: checkSkipped, Message: "RDS volume check did not succeed"}
+	sessionsCheck := describeCheck{Name: "nvme-sessions", Status: checkSkipped, Message: "RDS volume check did not succeed"}
+	if rdsFound {
+		switch {
+		case !volume.NVMETCPExport:
+			exportCheck = describeCheck{Name: "nvme-export", Status: checkFailed, Error: "NVMe/TCP export is disabled"}
+		default:
+			exportCheck = describeCheck{Name: "nvme-export", Status: checkOK,
+				Message: fmt.Sprintf("exported as %s on port %d", volume.NVMETCPNQN, volume.NVMETCPPort)}
+		}
+		attachedNodes := -1
+		if report.Attachment != nil {
+			attachedNodes = len(report.Attachment.Nodes)
+		}
+		sessionsCheck = runDescribeCheck(ctx, "nvme-sessions", timeout, func(ctx context.Context) (string, error) {
+			sessions, err := backend.Client.GetNVMeSessions()
+			if err != nil {
+				return "", err
+			}
+			connected := sessions[volume.Slot]
+			if attachedNodes > 0 && connected == 0 {
+				return "", fmt.Errorf("attached to %d node(s) but no host is connected on RDS", attachedNodes)
+			}
+			return fmt.Sprintf("%d host connection(s) on RDS", connected), nil
+		})
+	}
+
+	nodeCheck := describeCheck{Name: "node-health", Status: checkSkipped,
+		Message: "node device and mount health is not available to the controller"}
+
+	report.Checks = []describeCheck{volumeCheck, exportCheck, attachmentCheck, sessionsCheck, nodeCheck}
+	report.Healthy = true
+	for _, check := range report.Checks {
+		if check.Status == checkFailed || check.Status == checkTimedOut {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// runDescribeCheck runs check with ctx limited to timeout. A check still running at the
+// timeout is reported as timed out and left to finish in the background, since RDS
+// commands do not take a context.
+func runDescribeCheck(ctx context.Context, name string, timeout time.Duration, check func(context.Context) (string, error)) describeCheck {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		message string
+		err     error
+	}
+	done := make(chan outcome, 1)
+	started := time.Now()
+	go func() {
+		message, err := check(ctx)
+		done <- outcome{message, err}
+	}()
+
+	result := describeCheck{Name: name}
+	select {
+	case out := <-done:
+		result.Status, result.Message = checkOK, out.message
+		if out.err != nil {
+			result.Status, result.Error = checkFailed, out.err.Error()
+			if errors.Is(out.err, context.DeadlineExceeded) || errors.Is(out.err, utils.ErrOperationTimeout) {
+				result.Status = checkTimedOut
+			}
+		}
+	case <-ctx.Done():
+		result.Status, result.Error = checkTimedOut, fmt.Sprintf("no result within %v", timeout)
+	}
+	result.DurationMs = time.Since(started).Milliseconds()
+	return result
+}
@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+// hungRDSClient never answers GetVolume, as an RDS that stopped responding
+type hungRDSClient struct {
+	*rds.MockClient
+	release chan struct{}
+}
+
+func (c *hungRDSClient) GetVolume(slot string) (*rds.VolumeInfo, error) {
+	<-c.release
+	return c.MockClient.GetVolume(slot)
+}
+
+// describeTestVolume returns a ready, exported volume
+func describeTestVolume(volumeID string) *rds.VolumeInfo {
+	return &rds.VolumeInfo{
+		Slot:          volumeID,
+		FilePath:      "/storage-pool/metal-csi/" + volumeID + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPExport: true,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + volumeID,
+		Status:        "ready",
+	}
+}
+
+// checkStatuses returns the status of each check of report by name
+func checkStatuses(report describeReport) map[string]string {
+	statuses := make(map[string]string, len(report.Checks))
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+func TestDescribeVolume(t *testing.T) {
+	tests := []struct {
+		name        string
+		volume      func() *rds.VolumeInfo // nil: the volume does not exist on RDS
+		attach      bool
+		sessions    int
+		wantHealthy bool
+		want        map[string]string
+	}{
+		{
+			name:        "healthy and attached",
+			volume:      func() *rds.VolumeInfo { return describeTestVolume(testVolumeID1) },
+			attach:      true,
+			sessions:    1,
+			wantHealthy: true,
+			want: map[string]string{"rds-volume": checkOK, "nvme-export": checkOK, "attachment": checkOK,
+				"nvme-sessions": checkOK, "node-health": checkSkipped},
+		},
+		{
+			name: "export disabled",
+			volume: func() *rds.VolumeInfo {
+				volume := describeTestVolume(testVolumeID1)
+				volume.NVMETCPExport = false
+				return volume
+			},
+			want: map[string]string{"rds-volume": checkOK, "nvme-export": checkFailed, "attachment": checkOK,
+				"nvme-sessions": checkOK, "node-health": checkSkipped},
+		},
+		{
+			name:   "attached without host connection",
+			volume: func() *rds.VolumeInfo { return describeTestVolume(testVolumeID1) },
+			attach: true,
+			want: map[string]string{"rds-volume": checkOK, "nvme-export": checkOK, "attachment": checkOK,
+				"nvme-sessions": checkFailed, "node-health": checkSkipped},
+		},
+		{
+			name: "still formatting",
+			volume: func() *rds.VolumeInfo {
+				volume := describeTestVolume(testVolumeID1)
+				volume.Status = "formatting"
+				return volume
+			},
+			want: map[string]string{"rds-volume": checkFailed, "nvme-export": checkOK, "attachment": checkOK,
+				"nvme-sessions": checkOK, "node-health": checkSkipped},
+		},
+		{
+			name:   "missing on RDS",
+			attach: true,
+			want: map[string]string{"rds-volume": checkFailed, "nvme-export": checkSkipped, "attachment": checkOK,
+				"nvme-sessions": checkSkipped, "node-health": checkSkipped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, mockRDS := testControllerServer(t)
+			if tt.volume != nil {
+				mockRDS.AddVolume(tt.volume())
+			}
+			if tt.attach {
+				if err := cs.driver.attachmentManager.TrackAttachment(context.Background(), testVolumeID1, "node-1"); err != nil {
+					t.Fatalf("TrackAttachment failed: %v", err)
+				}
+			}
+			mockRDS.SetNVMeSessions(map[string]int{testVolumeID1: tt.sessions})
+
+			report := cs.driver.describeVolumeLive(context.Background(), testVolumeID1, time.Second)
+			if report.Healthy != tt.wantHealthy {
+				t.Errorf("Healthy = %v, want %v (%+v)", report.Healthy, tt.wantHealthy, report.Checks)
+			}
+			got := checkStatuses(report)
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("check %s = %s, want %s (%+v)", name, got[name], want, report.Checks)
+				}
+			}
+			if (report.RDS != nil) != (tt.volume != nil) {
+				t.Errorf("RDS = %+v, want it reported only for volumes on RDS", report.RDS)
+			}
+			if tt.attach && (report.Attachment == nil || len(report.Attachment.Nodes) != 1 || report.Attachment.Nodes[0] != "node-1") {
+				t.Errorf("Attachment = %+v, want node-1", report.Attachment)
+			}
+		})
+	}
+}
+
+func TestDescribeVolume_HungCheckTimesOut(t *testing.T) {
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(describeTestVolume(testVolumeID1))
+	hung := &hungRDSClient{MockClient: mockRDS, release: make(chan struct{})}
+	defer close(hung.release)
+	cs.driver.rdsClient = hung
+	if err := cs.driver.attachmentManager.TrackAttachment(context.Background(), testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+
+	start := time.Now()
+	report := cs.driver.describeVolumeLive(context.Background(), testVolumeID1, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("report took %v with a 50ms check timeout", elapsed)
+	}
+
+	want := map[string]string{"rds-volume": checkTimedOut, "nvme-export": checkSkipped, "attachment": checkOK,
+		"nvme-sessions": checkSkipped, "node-health": checkSkipped}
+	got := checkStatuses(report)
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("check %s = %s, want %s (%+v)", name, got[name], status, report.Checks)
+		}
+	}
+	if report.Healthy {
+		t.Error("a timed out check must make the report unhealthy")
+	}
+	if report.RDS != nil {
+		t.Errorf("RDS = %+v, want nothing for a timed out check", report.RDS)
+	}
+}
+
+func TestAdminHandler_Describe(t *testing.T) {
+	cs, mockRDS := testControllerServer(t)
+	mockRDS.AddVolume(describeTestVolume(testVolumeID1))
+	handler := cs.driver.AdminHandler()
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		wantStatus int
+	}{
+		{name: "describe", method: http.MethodGet, url: "/admin/describe?volumeID=" + testVolumeID1, wantStatus: http.StatusOK},
+		{name: "missing volumeID", method: http.MethodGet, url: "/admin/describe", wantStatus: http.StatusBadRequest},
+		{name: "invalid volumeID", method: http.MethodGet, url: "/admin/describe?volumeID=../etc", wantStatus: http.StatusBadRequest},
+		{name: "POST rejected", method: http.MethodPost, url: "/admin/describe?volumeID=" + testVolumeID1, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var report describeReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("invalid report: %v", err)
+			}
+			if !report.Healthy || report.RDS == nil || report.RDS.SizeBytes != 10*1024*1024*1024 {
+				t.Errorf("unexpected report: %s", rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	(&Driver{}).AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/describe?volumeID="+testVolumeID1, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without controller = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
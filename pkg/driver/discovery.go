@@ -0,0 +1,86 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// errDiscoveryFailed marks a discovery:// address that could not be resolved to endpoints
+var errDiscoveryFailed = errors.New("NVMe target discovery failed")
+
+// srvResolver resolves discovery:// addresses; *net.Resolver implements it
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// nvmeEndpoint is one address and port an NVMe/TCP target is reachable at
+type nvmeEndpoint struct {
+	Address string
+	Port    int
+}
+
+func (e nvmeEndpoint) String() string {
+	return net.JoinHostPort(e.Address, fmt.Sprint(e.Port))
+}
+
+// resolveNVMeEndpoints returns the endpoints to connect to for the volume context address
+// and port, in the order to try them. An IP address is the only endpoint. A discovery://
+// address is looked up as a DNS SRV record: its targets are tried by priority, within a
+// priority in the order of the resolver, which shuffles them by weight, and each target
+// contributes every address its name resolves to with the port of the record.
+func (ns *NodeServer) resolveNVMeEndpoints(ctx context.Context, address string, port int) ([]nvmeEndpoint, error) {
+	record, discovery, err := utils.ParseDiscoveryAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if !discovery {
+		if err := utils.ValidateNVMEAddress(address, port); err != nil {
+			return nil, err
+		}
+		return []nvmeEndpoint{{Address: address, Port: port}}, nil
+	}
+
+	resolver := ns.srvResolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, "", "", record)
+	if err != nil {
+		return nil, fmt.Errorf("%w: SRV lookup of %s: %v", errDiscoveryFailed, record, err)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Priority < records[j].Priority })
+
+	var endpoints []nvmeEndpoint
+	for _, srv := range records {
+		target := strings.TrimSuffix(srv.Target, ".")
+		addresses := []string{target}
+		if net.ParseIP(target) == nil {
+			addresses, err = resolver.LookupHost(ctx, target)
+			if err != nil {
+				klog.FromContext(ctx).Info("Skipping NVMe target", "target", target, "record", record, "err", err)
+				continue
+			}
+		}
+		for _, addr := range addresses {
+			if err := utils.ValidateNVMEAddress(addr, int(srv.Port)); err != nil {
+				klog.FromContext(ctx).Info("Skipping NVMe target", "target", target, "record", record, "err", err)
+				continue
+			}
+			endpoints = append(endpoints, nvmeEndpoint{Address: addr, Port: int(srv.Port)})
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("%w: %s has no usable targets", errDiscoveryFailed, record)
+	}
+	klog.FromContext(ctx).V(2).Info("Resolved NVMe targets", "record", record, "targets", endpoints)
+	return endpoints, nil
+}
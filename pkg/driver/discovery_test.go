@@ -0,0 +1,171 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+type fakeSRVResolver struct {
+	records map[string][]*net.SRV
+	hosts   map[string][]string
+	err     error
+}
+
+func (r *fakeSRVResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	records, ok := r.records[name]
+	if !ok {
+		return "", nil, fmt.Errorf("lookup %s: no such host", name)
+	}
+	return name, records, nil
+}
+
+func (r *fakeSRVResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, fmt.Errorf("lookup %s: no such host", host)
+	}
+	return addrs, nil
+}
+
+// endpointRecordingConnector records the endpoint of every connect and fails those in failing
+type endpointRecordingConnector struct {
+	*mockNVMEConnector
+	failing   map[string]bool
+	endpoints []string
+}
+
+func (c *endpointRecordingConnector) ConnectWithRetry(ctx context.Context, target nvme.Target, config nvme.ConnectionConfig) (string, error) {
+	endpoint := net.JoinHostPort(target.TargetAddress, fmt.Sprint(target.TargetPort))
+	c.endpoints = append(c.endpoints, endpoint)
+	if c.failing[endpoint] {
+		return "", errors.New("connection refused")
+	}
+	return c.mockNVMEConnector.ConnectWithRetry(ctx, target, config)
+}
+
+func discoveryStageRequest(t *testing.T, address string) *csi.NodeStageVolumeRequest {
+	return &csi.NodeStageVolumeRequest{
+		VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+		VolumeCapability:  createFilesystemVolumeCapability(),
+		VolumeContext: map[string]string{
+			"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+			"nvmeAddress": address,
+			"nvmePort":    "4420",
+		},
+	}
+}
+
+func discoveryNodeServer(conn nvme.Connector, resolver srvResolver) *NodeServer {
+	return &NodeServer{
+		driver: &Driver{
+			name:    DriverName,
+			version: "test",
+			metrics: observability.NewMetrics(),
+		},
+		mounter:        &mockMounter{},
+		nvmeConn:       conn,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+		srvResolver:    resolver,
+	}
+}
+
+// TestNodeStageVolume_DiscoveryPriorityOrder tests that the targets of a discovery://
+// address are connected in SRV priority order until one succeeds
+func TestNodeStageVolume_DiscoveryPriorityOrder(t *testing.T) {
+	resolver := &fakeSRVResolver{
+		records: map[string][]*net.SRV{
+			"_nvme-tcp._tcp.storage.example.com": {
+				{Target: "rds-b.storage.example.com.", Port: 4420, Priority: 20},
+				{Target: "10.42.68.1", Port: 4420, Priority: 10},
+				{Target: "rds-c.storage.example.com.", Port: 4421, Priority: 30},
+			},
+		},
+		hosts: map[string][]string{
+			"rds-b.storage.example.com": {"10.42.68.2"},
+			"rds-c.storage.example.com": {"10.42.68.3"},
+		},
+	}
+	conn := &endpointRecordingConnector{
+		mockNVMEConnector: &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+		failing:           map[string]bool{"10.42.68.1:4420": true, "10.42.68.2:4420": true},
+	}
+	ns := discoveryNodeServer(conn, resolver)
+
+	if _, err := ns.NodeStageVolume(context.Background(), discoveryStageRequest(t, "discovery://_nvme-tcp._tcp.storage.example.com")); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+	want := []string{"10.42.68.1:4420", "10.42.68.2:4420", "10.42.68.3:4421"}
+	if !reflect.DeepEqual(conn.endpoints, want) {
+		t.Errorf("expected connects to %v, got %v", want, conn.endpoints)
+	}
+}
+
+// TestNodeStageVolume_DiscoveryFailure tests that an unresolvable discovery:// address
+// fails the stage as Unavailable without connecting
+func TestNodeStageVolume_DiscoveryFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver *fakeSRVResolver
+	}{
+		{
+			name:     "lookup error",
+			resolver: &fakeSRVResolver{err: errors.New("i/o timeout")},
+		},
+		{
+			name: "no usable targets",
+			resolver: &fakeSRVResolver{
+				records: map[string][]*net.SRV{
+					"_nvme-tcp._tcp.storage.example.com": {
+						{Target: "gone.storage.example.com.", Port: 4420, Priority: 10},
+						{Target: "10.42.68.1", Port: 0, Priority: 20},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &endpointRecordingConnector{mockNVMEConnector: &mockNVMEConnector{devicePath: "/dev/nvme0n1"}}
+			ns := discoveryNodeServer(conn, tt.resolver)
+
+			_, err := ns.NodeStageVolume(context.Background(), discoveryStageRequest(t, "discovery://_nvme-tcp._tcp.storage.example.com"))
+			if status.Code(err) != codes.Unavailable {
+				t.Fatalf("expected Unavailable, got %v", err)
+			}
+			if len(conn.endpoints) != 0 {
+				t.Errorf("expected no connects, got %v", conn.endpoints)
+			}
+		})
+	}
+}
+
+// TestNodeStageVolume_DiscoveryInvalidAddress tests that a malformed discovery:// address
+// is rejected before any lookup
+func TestNodeStageVolume_DiscoveryInvalidAddress(t *testing.T) {
+	conn := &endpointRecordingConnector{mockNVMEConnector: &mockNVMEConnector{devicePath: "/dev/nvme0n1"}}
+	ns := discoveryNodeServer(conn, &fakeSRVResolver{})
+
+	_, err := ns.NodeStageVolume(context.Background(), discoveryStageRequest(t, "discovery://storage;reboot"))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -12,6 +12,7 @@
 //     Examples: "Checking attachment state", "Found device path"
 //   - V(5): Trace level - command I/O, parsing details
 //     Examples: "RouterOS output: ...", "SSH command: ..."
+//     RouterOS command I/O also needs --log-raw-rds-io and has secret values redacted.
 //
 // V(3) is avoided in favor of V(2) (if actionable) or V(4) (if diagnostic).
 //
@@ -0,0 +1,125 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+)
+
+const (
+	// DefaultDrainTimeout bounds how long NodeUnstageVolume waits for a device to drain
+	// with --drain-before-disconnect
+	DefaultDrainTimeout = 30 * time.Second
+
+	// drainPollInterval is how often the in-flight I/O of a draining device is read
+	drainPollInterval = 100 * time.Millisecond
+)
+
+// syncFilesystem writes back the dirty pages of the filesystem mounted at path
+func syncFilesystem(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return unix.Syncfs(int(f.Fd()))
+}
+
+// filesystemSyncs runs at most one sync per path. syncfs cannot be interrupted, so an
+// unstage that gives up on a hung one leaves it running; its retries wait for that call
+// instead of each leaving another goroutine blocked on the same filesystem.
+type filesystemSyncs struct {
+	mu      sync.Mutex
+	running map[string]*filesystemSync
+}
+
+// filesystemSync is a sync in progress; err is set when done is closed
+type filesystemSync struct {
+	done chan struct{}
+	err  error
+}
+
+// start returns the sync of path in progress, or starts one with syncFS
+func (s *filesystemSyncs) start(path string, syncFS func(string) error) *filesystemSync {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if running, ok := s.running[path]; ok {
+		klog.V(2).Infof("Filesystem at %s is still syncing from an earlier unstage, waiting for it", path)
+		return running
+	}
+	if s.running == nil {
+		s.running = make(map[string]*filesystemSync)
+	}
+	fsSync := &filesystemSync{done: make(chan struct{})}
+	s.running[path] = fsSync
+	go func() {
+		fsSync.err = syncFS(path)
+		s.mu.Lock()
+		delete(s.running, path)
+		s.mu.Unlock()
+		close(fsSync.done)
+	}()
+	return fsSync
+}
+
+// drainDevice syncs the filesystem staged at stagingPath and waits up to the drain
+// timeout for the I/O in flight on devicePath to reach zero, so unmount and disconnect
+// do not lose writes a crashed pod left behind
+func (ns *NodeServer) drainDevice(ctx context.Context, stagingPath, devicePath string) error {
+	syncFS := ns.syncFunc
+	if syncFS == nil {
+		syncFS = syncFilesystem
+	}
+	sysfs := ns.sysfs
+	if sysfs == nil {
+		sysfs = nvme.NewSysfsScanner()
+	}
+	timeout := ns.driver.drainTimeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// syncfs blocks until writeback completes; a hung target must not hang the unstage
+	fsSync := ns.filesystemSyncs.start(stagingPath, syncFS)
+	select {
+	case <-fsSync.done:
+		if fsSync.err != nil {
+			return fmt.Errorf("failed to sync filesystem at %s: %w", stagingPath, fsSync.err)
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("filesystem at %s did not sync within %v", stagingPath, timeout)
+	}
+
+	device := filepath.Base(devicePath)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		inflight, err := sysfs.ReadInflight(device)
+		if err != nil {
+			return err
+		}
+		if inflight == 0 {
+			klog.FromContext(ctx).V(2).Info("Device drained", "device", devicePath, "duration", time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+		klog.FromContext(ctx).V(4).Info("Device has I/O requests in flight, waiting", "device", devicePath, "inflight", inflight)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("device %s still has %d I/O requests in flight after %v", devicePath, inflight, timeout)
+		case <-ticker.C:
+		}
+	}
+}
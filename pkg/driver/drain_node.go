@@ -0,0 +1,156 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Results of draining a volume from a node
+const (
+	drainDetached = "detached" // the node's attachment was removed
+	drainInUse    = "in-use"   // a pod on the node still uses the volume; not detached
+	drainUnknown  = "unknown"  // no bound PV, so pod use cannot be checked; not detached
+	drainFailed   = "failed"   // the check or the detach failed
+)
+
+// drainReport is the /admin/drain-node response
+type drainReport struct {
+	Node    string              `json:"node"`
+	Force   bool                `json:"force"`
+	Volumes []drainVolumeResult `json:"volumes"`
+}
+
+// drainVolumeResult is the outcome for one volume attached to the drained node
+type drainVolumeResult struct {
+	VolumeID string `json:"volumeID"`
+	PVC      string `json:"pvc,omitempty"` // <namespace>/<name> of the bound claim
+	Result   string `json:"result"`
+	Holder   string `json:"holder,omitempty"` // the pod using the volume, for in-use results
+	Error    string `json:"error,omitempty"`
+}
+
+func (d *Driver) handleDrainNode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if d.rdsClient == nil || d.attachmentManager == nil || d.k8sClient == nil {
+		http.Error(w, "drain-node requires controller mode and a Kubernetes client", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	node := query.Get("node")
+	if node == "" {
+		http.Error(w, "node is required", http.StatusBadRequest)
+		return
+	}
+	force := false
+	if value := query.Get("force"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "invalid force value: "+value, http.StatusBadRequest)
+			return
+		}
+		force = parsed
+	}
+
+	klog.Infof("Admin request: drain-node (node=%s, force=%v, remote=%s)", node, force, r.RemoteAddr)
+	report, err := d.drainNode(r.Context(), node, force)
+	if err != nil {
+		klog.Errorf("Drain of node %s failed: %v", node, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		klog.Errorf("Failed to write drain report: %v", err)
+	}
+}
+
+// drainNode detaches every volume the attachment manager has on node through
+// ControllerUnpublishVolume, so metrics and events are the same as for a detach by the
+// external-attacher. Unless force is set, a volume still used by a pod on the node, or
+// one without a bound PV to check that with, is left attached. Draining only acts on current attachments, so running it again resumes
+// with what is left.
+func (d *Driver) drainNode(ctx context.Context, node string, force bool) (*drainReport, error) {
+	var volumeIDs []string
+	for volumeID, state := range d.attachmentManager.ListAttachments() {
+		if state.IsAttachedToNode(node) {
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+	}
+	sort.Strings(volumeIDs)
+
+	report := &drainReport{Node: node, Force: force, Volumes: []drainVolumeResult{}}
+	if len(volumeIDs) == 0 {
+		klog.Infof("Drain of node %s: no volumes attached", node)
+		return report, nil
+	}
+
+	// One PV list resolves the claims of all volumes
+	pvs, err := d.k8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PVs: %w", err)
+	}
+	claims := make(map[string]*corev1.ObjectReference)
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == d.name && pv.Spec.ClaimRef != nil {
+			claims[pv.Spec.CSI.VolumeHandle] = pv.Spec.ClaimRef
+		}
+	}
+
+	cs := NewControllerServer(d)
+	for _, volumeID := range volumeIDs {
+		result := drainVolumeResult{VolumeID: volumeID}
+		claim := claims[volumeID]
+		if claim != nil {
+			result.PVC = claim.Namespace + "/" + claim.Name
+		}
+
+		if claim == nil && !force {
+			// Without the claim no pod can be matched, which does not mean none uses the volume
+			klog.Infof("Drain of node %s: volume %s has no bound PV, not detaching without force", node, volumeID)
+			result.Result = drainUnknown
+			report.Volumes = append(report.Volumes, result)
+			continue
+		}
+		if !force {
+			holder, err := d.claimHolder(ctx, claim.Namespace, claim.Name, node)
+			if err != nil {
+				result.Result, result.Error = drainFailed, err.Error()
+				report.Volumes = append(report.Volumes, result)
+				continue
+			}
+			if holder != "" {
+				klog.Infof("Drain of node %s: volume %s still in use by %s, not detaching", node, volumeID, holder)
+				result.Result, result.Holder = drainInUse, holder
+				report.Volumes = append(report.Volumes, result)
+				continue
+			}
+		}
+
+		_, err := cs.ControllerUnpublishVolume(ctx, &csi.ControllerUnpublishVolumeRequest{VolumeId: volumeID, NodeId: node})
+		if err != nil {
+			result.Result, result.Error = drainFailed, err.Error()
+		} else {
+			result.Result = drainDetached
+		}
+		report.Volumes = append(report.Volumes, result)
+	}
+
+	klog.Infof("Drain of node %s finished: %d volumes", node, len(report.Volumes))
+	return report, nil
+}
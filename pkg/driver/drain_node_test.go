@@ -0,0 +1,160 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// drainTestPV returns the PV of volumeID bound to the PVC vms/<claim>
+func drainTestPV(volumeID, claim string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: volumeID},
+		Spec: corev1.PersistentVolumeSpec{
+			ClaimRef: &corev1.ObjectReference{Namespace: "vms", Name: claim},
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:       DriverName,
+				VolumeHandle: volumeID,
+			}},
+		},
+	}
+}
+
+func drainNodeRequest(t *testing.T, handler http.Handler, url string) drainReport {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, url, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var report drainReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid report: %v", err)
+	}
+	return report
+}
+
+func TestAdminHandler_DrainNode(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := testControllerServer(t, testNode("node-1"))
+	d := cs.driver
+	d.metrics = observability.NewMetrics()
+	recorder := record.NewFakeRecorder(10)
+	d.getEventPoster().recorder = recorder
+
+	for _, obj := range []struct {
+		volumeID, claim string
+	}{{testVolumeID1, "disk-a"}, {testVolumeID2, "disk-b"}, {testVolumeID3, "disk-c"}} {
+		if _, err := d.k8sClient.CoreV1().PersistentVolumes().Create(ctx, drainTestPV(obj.volumeID, obj.claim), metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create PV: %v", err)
+		}
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: obj.claim, Namespace: "vms"}}
+		if _, err := d.k8sClient.CoreV1().PersistentVolumeClaims("vms").Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create PVC: %v", err)
+		}
+		if err := d.attachmentManager.TrackAttachment(ctx, obj.volumeID, "node-1"); err != nil {
+			t.Fatalf("TrackAttachment failed: %v", err)
+		}
+	}
+	// disk-b is still used by a pod on the node
+	pod := conflictTestPod("app-0", "node-1", nil)
+	pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName = "disk-b"
+	if _, err := d.k8sClient.CoreV1().Pods("vms").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	handler := d.AdminHandler()
+
+	report := drainNodeRequest(t, handler, "/admin/drain-node?node=node-1")
+	want := []drainVolumeResult{
+		{VolumeID: testVolumeID1, PVC: "vms/disk-a", Result: drainDetached},
+		{VolumeID: testVolumeID2, PVC: "vms/disk-b", Result: drainInUse, Holder: "pod vms/app-0"},
+		{VolumeID: testVolumeID3, PVC: "vms/disk-c", Result: drainDetached},
+	}
+	if !reflect.DeepEqual(report.Volumes, want) {
+		t.Errorf("volumes = %+v, want %+v", report.Volumes, want)
+	}
+	if !d.attachmentManager.IsAttachedToNode(testVolumeID2, "node-1") {
+		t.Error("expected the volume in use to stay attached")
+	}
+	if got := d.metrics.AttachmentSnapshot().DetachSuccess; got != 2 {
+		t.Errorf("expected 2 detaches recorded, got %v", got)
+	}
+	events := drainEvents(recorder)
+	if len(events) != 2 || !strings.Contains(events[0], EventReasonVolumeDetached) {
+		t.Errorf("expected 2 %s events, got %q", EventReasonVolumeDetached, events)
+	}
+
+	// Repeating the drain resumes with the volume left; force detaches it
+	report = drainNodeRequest(t, handler, "/admin/drain-node?node=node-1&force=true")
+	want = []drainVolumeResult{{VolumeID: testVolumeID2, PVC: "vms/disk-b", Result: drainDetached}}
+	if !reflect.DeepEqual(report.Volumes, want) {
+		t.Errorf("volumes = %+v, want %+v", report.Volumes, want)
+	}
+
+	report = drainNodeRequest(t, handler, "/admin/drain-node?node=node-1")
+	if len(report.Volumes) != 0 {
+		t.Errorf("expected nothing left to drain, got %+v", report.Volumes)
+	}
+}
+
+func TestAdminHandler_DrainNodeWithoutPV(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := testControllerServer(t, testNode("node-1"))
+	d := cs.driver
+	if err := d.attachmentManager.TrackAttachment(ctx, testVolumeID1, "node-1"); err != nil {
+		t.Fatalf("TrackAttachment failed: %v", err)
+	}
+	handler := d.AdminHandler()
+
+	// Without a PV no pod can be checked, so the volume is not taken for unused
+	report := drainNodeRequest(t, handler, "/admin/drain-node?node=node-1")
+	want := []drainVolumeResult{{VolumeID: testVolumeID1, Result: drainUnknown}}
+	if !reflect.DeepEqual(report.Volumes, want) {
+		t.Errorf("volumes = %+v, want %+v", report.Volumes, want)
+	}
+	if !d.attachmentManager.IsAttachedToNode(testVolumeID1, "node-1") {
+		t.Error("expected the volume without a PV to stay attached")
+	}
+
+	report = drainNodeRequest(t, handler, "/admin/drain-node?node=node-1&force=true")
+	want = []drainVolumeResult{{VolumeID: testVolumeID1, Result: drainDetached}}
+	if !reflect.DeepEqual(report.Volumes, want) {
+		t.Errorf("volumes = %+v, want %+v", report.Volumes, want)
+	}
+}
+
+func TestAdminHandler_DrainNodeInvalid(t *testing.T) {
+	cs, _ := testControllerServer(t)
+	handler := cs.driver.AdminHandler()
+	for _, tt := range []struct {
+		method     string
+		url        string
+		wantStatus int
+	}{
+		{method: http.MethodGet, url: "/admin/drain-node?node=node-1", wantStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, url: "/admin/drain-node", wantStatus: http.StatusBadRequest},
+		{method: http.MethodPost, url: "/admin/drain-node?node=node-1&force=maybe", wantStatus: http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.url, rec.Code, tt.wantStatus)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	(&Driver{}).AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/drain-node?node=node-1", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d without controller mode", rec.Code, http.StatusServiceUnavailable)
+	}
+}
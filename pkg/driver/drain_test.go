@@ -0,0 +1,152 @@
+package driver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// writeInflight replaces the inflight file of nvme0n1 below sysfsRoot atomically, so a
+// concurrent read never sees it empty
+func writeInflight(t *testing.T, sysfsRoot, content string) {
+	t.Helper()
+	dir := filepath.Join(sysfsRoot, "class", "block", "nvme0n1")
+	mkdirAll(t, dir)
+	tmp := filepath.Join(dir, "inflight.tmp")
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Errorf("failed to write inflight: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "inflight")); err != nil {
+		t.Errorf("failed to replace inflight: %v", err)
+	}
+}
+
+// drainNodeServer returns a node server draining mounted filesystem volumes on the mock
+// sysfs at sysfsRoot, with synced recording the paths synced
+func drainNodeServer(sysfsRoot string, timeout time.Duration, mounter *mockMounter, conn *mockNVMEConnector, synced *[]string) *NodeServer {
+	return &NodeServer{
+		driver: &Driver{
+			name: DriverName, version: "test", metrics: observability.NewMetrics(),
+			drainBeforeDisconnect: true, drainTimeout: timeout,
+		},
+		mounter:        mounter,
+		nvmeConn:       conn,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+		sysfs:          nvme.NewSysfsScannerWithRoot(sysfsRoot),
+		syncFunc: func(path string) error {
+			*synced = append(*synced, path)
+			return nil
+		},
+	}
+}
+
+func TestNodeUnstageVolume_DrainBeforeDisconnect(t *testing.T) {
+	sysfsRoot := t.TempDir()
+	writeInflight(t, sysfsRoot, "       2        5\n")
+	staging := t.TempDir()
+
+	mounter := &mockMounter{isLikelyMounted: true}
+	conn := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	var synced []string
+	ns := drainNodeServer(sysfsRoot, 5*time.Second, mounter, conn, &synced)
+
+	go func() {
+		time.Sleep(3 * drainPollInterval)
+		writeInflight(t, sysfsRoot, "       0        0\n")
+	}()
+
+	start := time.Now()
+	if _, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+		VolumeId: leftoversTestVolume, StagingTargetPath: staging,
+	}); err != nil {
+		t.Fatalf("NodeUnstageVolume failed: %v", err)
+	}
+	if time.Since(start) < 3*drainPollInterval {
+		t.Errorf("unstage returned after %v, before the device drained", time.Since(start))
+	}
+	if len(synced) != 1 || synced[0] != staging {
+		t.Errorf("synced %v, want [%s]", synced, staging)
+	}
+	if !mounter.unmountCalled || !conn.disconnectCalled {
+		t.Errorf("expected unmount and disconnect after the drain, got unmount=%v disconnect=%v", mounter.unmountCalled, conn.disconnectCalled)
+	}
+}
+
+func TestNodeUnstageVolume_DrainHungSync(t *testing.T) {
+	sysfsRoot := t.TempDir()
+	writeInflight(t, sysfsRoot, "       0        0\n")
+	staging := t.TempDir()
+
+	mounter := &mockMounter{isLikelyMounted: true}
+	conn := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	var synced []string
+	ns := drainNodeServer(sysfsRoot, 100*time.Millisecond, mounter, conn, &synced)
+	release := make(chan struct{})
+	var syncs atomic.Int32
+	ns.syncFunc = func(string) error {
+		syncs.Add(1)
+		<-release
+		return nil
+	}
+	req := &csi.NodeUnstageVolumeRequest{VolumeId: leftoversTestVolume, StagingTargetPath: staging}
+
+	// Retries while the first sync hangs wait for it rather than starting more
+	for i := 0; i < 3; i++ {
+		if _, err := ns.NodeUnstageVolume(context.Background(), req); status.Code(err) != codes.Internal {
+			t.Fatalf("NodeUnstageVolume error = %v, want Internal", err)
+		}
+	}
+	if got := syncs.Load(); got != 1 {
+		t.Errorf("expected 1 sync running, got %d", got)
+	}
+
+	// Once it completes, the next retry syncs again and unstages
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := ns.NodeUnstageVolume(context.Background(), req)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("NodeUnstageVolume still failing after the sync completed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !conn.disconnectCalled {
+		t.Error("expected the volume to be disconnected after the sync completed")
+	}
+}
+
+func TestNodeUnstageVolume_DrainTimeout(t *testing.T) {
+	sysfsRoot := t.TempDir()
+	writeInflight(t, sysfsRoot, "       0        1\n")
+
+	mounter := &mockMounter{isLikelyMounted: true}
+	conn := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	var synced []string
+	ns := drainNodeServer(sysfsRoot, 300*time.Millisecond, mounter, conn, &synced)
+
+	_, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+		VolumeId: leftoversTestVolume, StagingTargetPath: t.TempDir(),
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("NodeUnstageVolume error = %v, want Internal", err)
+	}
+	if mounter.unmountCalled || conn.disconnectCalled {
+		t.Errorf("expected the volume to stay mounted and connected, got unmount=%v disconnect=%v", mounter.unmountCalled, conn.disconnectCalled)
+	}
+}
@@ -2,7 +2,9 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -48,6 +50,9 @@ type Driver struct {
 	// RDS client (interface allows different implementations: SSH, API, mock)
 	rdsClient rds.RDSClient
 
+	// Named RDS backends selectable per StorageClass; the default backend is rdsClient
+	backends *rds.ClientRegistry
+
 	// NVMe connector (interface allows different implementations: real, mock)
 	nvmeConnector nvme.Connector
 
@@ -60,26 +65,67 @@ type Driver struct {
 	// Kubernetes client (for events and reconciler)
 	k8sClient kubernetes.Interface
 
+	// Shared event poster so rate-limit state survives across calls (lazily created)
+	eventPoster     *EventPoster
+	eventPosterOnce sync.Once
+
 	// Informer factory (for cached API access, avoids throttling)
 	informerFactory informers.SharedInformerFactory
 
 	// Prometheus metrics (may be nil if disabled)
 	metrics *observability.Metrics
 
+	// Unregister the optional metrics this driver set up, called by Stop
+	metricsMu         sync.Mutex
+	unregisterMetrics []func()
+
 	// Orphan reconciler (optional)
 	reconciler *reconciler.OrphanReconciler
 
+	// File housekeeper, run on demand from the admin endpoint (controller only, may be nil)
+	housekeeper *reconciler.FileHousekeeper
+
+	// Capacity monitor for low-space warnings (controller only, may be nil)
+	capacityMonitor *CapacityMonitor
+
+	// CSIStorageCapacity publisher (controller only, may be nil)
+	capacityPublisher *CapacityPublisher
+
 	// Attachment manager (for controller only)
 	attachmentManager *attachment.AttachmentManager
 
+	// Attached volume count for the nvme_connections_active gauge (may be nil)
+	attachmentGauge *attachmentGauge
+
 	// Attachment reconciler (for controller only)
 	attachmentReconciler *attachment.AttachmentReconciler
 
 	// Node watcher for event-driven attachment reconciliation
 	nodeWatcher *attachment.NodeWatcher
 
-	// Connection manager for RDS connection resilience
-	connectionManager *rds.ConnectionManager
+	// Connection managers for RDS connection resilience: connectionManager watches the
+	// default backend (and feeds Probe), backendConnectionManagers the additional ones
+	connectionManager         *rds.ConnectionManager
+	backendConnectionManagers []*rds.ConnectionManager
+
+	// RouterOS command audit log shared by all RDS clients (may be nil)
+	rdsAuditLog *rds.AuditLog
+
+	// Log RouterOS command I/O of all RDS clients at V(5)
+	logRawRDSIO bool
+
+	// Command retry budget shared by all RDS clients (nil for unlimited retries)
+	rdsRetryBudget *rds.RetryBudget
+
+	// Concurrent RouterOS commands per RDS backend (0 for no limit)
+	rdsMaxSessions int
+
+	// RouterOS command timeouts of all RDS clients (rds.ClientConfig semantics)
+	rdsCommandTimeout     time.Duration
+	rdsLongCommandTimeout time.Duration
+
+	// SSH algorithm restrictions applied to all RDS clients
+	rdsSSHAlgorithms rds.SSHAlgorithms
 
 	// Grace period for attachment handoff during live migration
 	attachmentGracePeriod time.Duration
@@ -90,6 +136,95 @@ type Driver struct {
 	// Managed NQN prefix for orphan cleaner filtering
 	managedNQNPrefix string
 
+	// Move backing files to .trash/ on DeleteVolume instead of deleting them
+	deleteRetainFiles bool
+
+	// Let DeleteVolume remove volumes still tracked as attached
+	allowDeleteAttached bool
+
+	// Coalesces DeleteVolume calls into batched RDS commands (nil deletes one at a time)
+	deleteBatcher *deleteBatcher
+
+	// Record the slot of volumes renamed on RDS as a PV annotation when they are found
+	healRenamedSlots bool
+
+	// Record the slot, NQN, RDS address and base path of volumes on their PVs at attach
+	annotateBackendDetails bool
+
+	// backendAnnotated holds the backend details last found on the PV of each volume ID
+	backendAnnotated sync.Map
+
+	// Size limits for volume contexts and PV annotations the controller writes
+	metadataLimits utils.MetadataLimits
+
+	// fsTypes CreateVolume accepts (nil allows any)
+	allowedFSTypes map[string]bool
+
+	// Volume size policy in bytes (0 keeps the built-in 1 GiB minimum / 16 TiB maximum)
+	minVolumeSize int64
+	maxVolumeSize int64
+
+	// In-progress snapshot restores, which DeleteSnapshot must wait for
+	snapshotRestores snapshotRestoreTracker
+
+	// Most recent ListVolumes result per backend, served by the debug endpoints
+	volumeCache *volumeListCache
+
+	// Node readiness check reported by Probe (nil for controller-only drivers)
+	nodeReadinessCheck func() error
+
+	// nvme_tcp kernel module check run once when the node service starts with the real
+	// NVMe connector, and the loader tried if it is missing (nil when modprobe is not permitted)
+	tcpModuleDetector func() error
+	tcpModuleLoader   func() error
+
+	// DH-HMAC-CHAP support check run when staging a volume with nvmeAuth: "dhchap"
+	// (nil for controller-only drivers)
+	dhchapDetector func() error
+
+	// Split of the NodeStageVolume deadline between its phases (nil for the defaults)
+	stagePhaseBudgets PhaseBudgets
+
+	// Write the NQN of staged block volumes to their staging path
+	blockStageMetadata bool
+
+	// Interval between read probes of staged volumes (0 disables them)
+	readProbeInterval time.Duration
+
+	// NVMe connects per second the node may start across all volumes (0 for no limit)
+	nvmeConnectRate float64
+
+	// Kubelet root directory; unpublish and unstage only remove leftovers recursively below it
+	kubeletDir string
+
+	// Sync and wait for in-flight I/O to drain before unstaging filesystem volumes
+	drainBeforeDisconnect bool
+	drainTimeout          time.Duration
+
+	// Enforce the quotaBytes of filesystem volumes with project quotas
+	enableProjectQuota bool
+
+	// Fail node RPCs whose VolumeContext lacks keys instead of inferring them
+	strictVolumeContext bool
+
+	// Force unmount targets still busy after the unmount retries
+	forceUnmountOnBusy bool
+
+	// Staging directory janitor settings (interval 0 disables the janitor)
+	stagingJanitorInterval    time.Duration
+	stagingJanitorGracePeriod time.Duration
+
+	// CSI socket watchdog settings (interval 0 disables the watchdog)
+	socketCheckInterval    time.Duration
+	registrationSocketPath string
+
+	// gRPC server transport settings, the running server and its socket watchdog
+	// (set by Run, stopped by Stop)
+	serverOptions ServerOptions
+	serverMu      sync.Mutex
+	server        *NonBlockingGRPCServer
+	stopWatchdog  context.CancelFunc
+
 	// Capabilities
 	vcaps  []*csi.VolumeCapability_AccessMode
 	cscaps []*csi.ControllerServiceCapability
@@ -103,13 +238,45 @@ type DriverConfig struct {
 	Version    string
 
 	// RDS connection settings
-	RDSAddress            string
-	RDSPort               int
-	RDSUser               string
-	RDSPrivateKey         []byte
-	RDSHostKey            []byte // SSH host public key for verification
-	RDSInsecureSkipVerify bool   // Skip host key verification (INSECURE)
-	RDSVolumeBasePath     string // Base path for volumes on RDS (e.g., /storage-pool/metal-csi)
+	RDSAddress             string
+	RDSPort                int
+	RDSUser                string
+	RDSPrivateKey          []byte
+	RDSHostKey             []byte   // SSH host public key(s) for verification, one per line
+	RDSHostKeyFingerprints []string // Accepted SHA256 host key fingerprints (alternative to RDSHostKey)
+	RDSInsecureSkipVerify  bool     // Skip host key verification (INSECURE)
+	RDSVolumeBasePath      string   // Base path for volumes on RDS (e.g., /storage-pool/metal-csi)
+	RDSAdditionalBasePaths []string // Base paths of other pools StorageClasses may select with volumePath
+
+	// Ciphers, key exchanges and MACs offered to all RDS backends (empty lists keep the defaults)
+	RDSSSHAlgorithms rds.SSHAlgorithms
+
+	// Additional named RDS backends, selected by the StorageClass "backend" parameter
+	RDSBackends map[string]rds.BackendConfig
+
+	// StrictCompat refuses to start if the RouterOS release of an RDS backend cannot be determined
+	StrictCompat bool
+
+	// RDSCredentialsSecret is the Secret ("namespace/name") holding RDSPrivateKey, named in
+	// the error hint for rejected SSH logins (optional)
+	RDSCredentialsSecret string
+
+	// RDSAuditLog records the RouterOS commands of all RDS backends (optional, closed by Stop)
+	RDSAuditLog *rds.AuditLog
+
+	// LogRawRDSIO logs RouterOS commands and output of all RDS backends at V(5), redacted
+	LogRawRDSIO bool
+
+	// RDSRetryBudget caps the command retries of all RDS backends together (optional)
+	RDSRetryBudget *rds.RetryBudget
+
+	// RDSMaxSessions limits the commands running at once on each RDS backend (0 for no limit)
+	RDSMaxSessions int
+
+	// RDSCommandTimeout limits each RouterOS command, RDSLongCommandTimeout the ones creating
+	// or copying a disk (0 for the rds defaults, rds.NoTimeout for no limit)
+	RDSCommandTimeout     time.Duration
+	RDSLongCommandTimeout time.Duration
 
 	// Kubernetes client (required for orphan reconciler)
 	K8sClient kubernetes.Interface
@@ -123,6 +290,37 @@ type DriverConfig struct {
 	OrphanGracePeriod      time.Duration
 	OrphanDryRun           bool
 
+	// Retained backing file settings
+	DeleteRetainFiles bool          // Move backing files to .trash/ on DeleteVolume instead of deleting them
+	TrashRetention    time.Duration // Age after which the orphan reconciler purges trashed files (0 to keep forever)
+
+	// DeleteBatchWindow is how long DeleteVolume waits to batch with other deletions (0 to disable)
+	DeleteBatchWindow time.Duration
+
+	// AllowDeleteAttached lets DeleteVolume remove volumes still tracked as attached
+	// instead of failing with FailedPrecondition
+	AllowDeleteAttached bool
+
+	// HealRenamedSlots records the slot a volume renamed on RDS was found under on its PV
+	// (AnnotationSlot), so later lookups go straight to it
+	HealRenamedSlots bool
+
+	// AnnotateBackendDetails records the slot, NQN, RDS address and base path of a volume
+	// as informational annotations on its PV when it is attached
+	AnnotateBackendDetails bool
+
+	// MetadataLimits bounds the size of new volume contexts and of PV annotations after an
+	// attachment is recorded (zero values use the defaults)
+	MetadataLimits utils.MetadataLimits
+
+	// AllowedFSTypes restricts the fsTypes CreateVolume accepts (nil allows any, see ParseAllowedFSTypes)
+	AllowedFSTypes map[string]bool
+
+	// MinVolumeSize and MaxVolumeSize bound the size of new and expanded volumes in bytes
+	// (0 keeps the built-in limits)
+	MinVolumeSize int64
+	MaxVolumeSize int64
+
 	// Attachment reconciler settings
 	EnableAttachmentReconciler  bool
 	AttachmentReconcileInterval time.Duration // Default: 5 minutes
@@ -132,9 +330,90 @@ type DriverConfig struct {
 	EnableVMISerialization bool          // Enable per-VMI operation locks
 	VMICacheTTL            time.Duration // Cache TTL for PVC->VMI mapping (default: 60s)
 
+	// Capacity monitor settings (controller only)
+	CapacityCheckInterval       time.Duration // How often to query RDS free space (0 to disable)
+	CapacityBasePaths           []string      // Base paths to monitor (default: RDSVolumeBasePath)
+	LowCapacityThresholdPercent float64       // Free space percentage to post LowCapacity events below (0 to disable)
+	CapacityEventNamespace      string        // Namespace of the ConfigMap low capacity events are posted on
+	CapacityEventConfigMap      string        // Name of that ConfigMap (empty to only log)
+
+	// CSIStorageCapacity publishing (controller only, needs K8sClient)
+	PublishStorageCapacity   bool          // Publish one CSIStorageCapacity per StorageClass of this driver
+	StorageCapacityNamespace string        // Namespace of the published objects
+	StorageCapacityInterval  time.Duration // Default: DefaultStorageCapacityInterval
+
+	// CSI socket watchdog settings
+	SocketCheckInterval    time.Duration // How often to check the CSI socket still exists (0 to disable)
+	RegistrationSocketPath string        // node-driver-registrar registration socket to check (optional)
+
+	// gRPC server transport settings (TLS is required for tcp:// endpoints unless AllowInsecureTCP)
+	ServerOptions ServerOptions
+
 	// NQN prefix for orphan cleaner filtering (required for node mode)
 	ManagedNQNPrefix string
 
+	// NVMeTCPModprobe lets the node service run modprobe nvme_tcp if the module is missing at startup
+	NVMeTCPModprobe bool
+
+	// StagePhaseBudgets splits the NodeStageVolume deadline between its phases
+	// (optional, DefaultStagePhaseBudgets if nil)
+	StagePhaseBudgets PhaseBudgets
+
+	// BlockStageMetadata makes NodeStageVolume record the NQN of block volumes at their
+	// staging path, for NodePublishVolume of static volumes whose NQN cannot be derived
+	BlockStageMetadata bool
+
+	// VolumeReadProbeInterval is how often the node reads the first 4KiB of every staged
+	// volume to detect IO errors for the volume condition (optional, 0 disables the probe)
+	VolumeReadProbeInterval time.Duration
+
+	// NVMeConnectRate is how many NVMe connects per second the node may start, shared by
+	// all its volumes, so reconnects after a target outage are spread out (0 for no limit)
+	NVMeConnectRate float64
+
+	// DrainBeforeDisconnect makes NodeUnstageVolume sync filesystem volumes and wait for
+	// their device's in-flight I/O to reach zero before unmounting and disconnecting
+	DrainBeforeDisconnect bool
+
+	// DrainTimeout bounds the wait for a device to drain (default DefaultDrainTimeout)
+	DrainTimeout time.Duration
+
+	// EnableProjectQuota lets NodeStageVolume enforce the quotaBytes parameter of ext4 and
+	// XFS volumes with a filesystem project quota
+	EnableProjectQuota bool
+
+	// StrictVolumeContext makes node RPCs fail with InvalidArgument on a VolumeContext
+	// lacking keys, such as that of a PV of an early driver version, instead of inferring them
+	StrictVolumeContext bool
+
+	// ForceUnmountOnBusy makes NodeUnstageVolume and NodeUnpublishVolume force unmount a
+	// target that is still busy after the unmount retries, instead of failing
+	ForceUnmountOnBusy bool
+
+	// EnableStagingJanitor makes the node remove staging directories kubelet left behind
+	// under KubeletDir for volumes no longer staged, attached or connected
+	EnableStagingJanitor bool
+
+	// KubeletDir is the kubelet root directory (default /var/lib/kubelet), scanned by the
+	// staging janitor, bounding the leftovers unpublish and unstage remove, and expected to
+	// hold the plugin directory of the CSI socket
+	KubeletDir string
+
+	// StagingJanitorInterval is how often the janitor scans (default DefaultStagingJanitorInterval)
+	StagingJanitorInterval time.Duration
+
+	// StagingJanitorGracePeriod is how long a staging directory must be untouched before
+	// the janitor removes it (default DefaultStagingJanitorGracePeriod)
+	StagingJanitorGracePeriod time.Duration
+
+	// SlotPrefix is an additional accepted disk slot prefix for volumes created
+	// outside Kubernetes (optional; "pvc-" is always accepted)
+	SlotPrefix string
+
+	// VolumeNamePrefix is embedded in the slot names of new volumes (pvc-<prefix>-<uuid>) so
+	// the orphan reconciler only considers this instance's volumes (optional)
+	VolumeNamePrefix string
+
 	// Mode flags
 	EnableController bool
 	EnableNode       bool
@@ -158,6 +437,31 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 		}
 		klog.Infof("Volume base path configured: %s", config.RDSVolumeBasePath)
 	}
+	for _, path := range config.RDSAdditionalBasePaths {
+		if err := utils.AddAllowedBasePath(path); err != nil {
+			return nil, fmt.Errorf("failed to add allowed base path %s: %w", path, err)
+		}
+	}
+	if len(config.RDSAdditionalBasePaths) > 0 {
+		klog.Infof("Additional volume base paths configured: %v", config.RDSAdditionalBasePaths)
+	}
+
+	// Accept an additional slot prefix for non-PVC consumers
+	if config.SlotPrefix != "" {
+		if err := utils.SetSlotPrefix(config.SlotPrefix); err != nil {
+			return nil, fmt.Errorf("failed to set slot prefix: %w", err)
+		}
+		klog.Infof("Accepting disk slots with prefixes: %v", utils.GetSlotNamingStrategy().Prefixes())
+	}
+
+	// Embed a per-instance prefix in volume slot names for shared base paths
+	if config.VolumeNamePrefix != "" {
+		if err := utils.SetVolumeNamePrefix(config.VolumeNamePrefix); err != nil {
+			return nil, fmt.Errorf("failed to set volume name prefix: %w", err)
+		}
+		klog.Infof("Volume slot names use prefix %q; only pvc-%s-<uuid> volumes are orphan candidates",
+			config.VolumeNamePrefix, config.VolumeNamePrefix)
+	}
 
 	// Validate NQN prefix for node plugin (required for orphan cleaner safety)
 	if config.EnableNode {
@@ -170,24 +474,106 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 		klog.Infof("Driver managing volumes with NQN prefix: %s", config.ManagedNQNPrefix)
 	}
 
+	rdsCredentialsSecret.Store(&config.RDSCredentialsSecret)
+
 	driver := &Driver{
-		name:             config.DriverName,
-		version:          config.Version,
-		nodeID:           config.NodeID,
-		k8sClient:        config.K8sClient,
-		metrics:          config.Metrics,
-		managedNQNPrefix: config.ManagedNQNPrefix,
+		name:                  config.DriverName,
+		version:               config.Version,
+		nodeID:                config.NodeID,
+		k8sClient:             config.K8sClient,
+		metrics:               config.Metrics,
+		managedNQNPrefix:      config.ManagedNQNPrefix,
+		deleteRetainFiles:     config.DeleteRetainFiles,
+		rdsAuditLog:           config.RDSAuditLog,
+		logRawRDSIO:           config.LogRawRDSIO,
+		rdsRetryBudget:        config.RDSRetryBudget,
+		rdsMaxSessions:        config.RDSMaxSessions,
+		rdsCommandTimeout:     config.RDSCommandTimeout,
+		rdsLongCommandTimeout: config.RDSLongCommandTimeout,
+		rdsSSHAlgorithms:      config.RDSSSHAlgorithms,
+		volumeCache:           newVolumeListCache(),
+
+		socketCheckInterval:    config.SocketCheckInterval,
+		registrationSocketPath: config.RegistrationSocketPath,
+		serverOptions:          config.ServerOptions,
+	}
+	if driver.serverOptions.Metrics == nil {
+		driver.serverOptions.Metrics = config.Metrics
+	}
+
+	if config.EnableController && config.DeleteRetainFiles {
+		klog.Infof("DeleteVolume retains backing files in %s/ (retention=%v)", rds.TrashDirName, config.TrashRetention)
+		if !config.EnableOrphanReconciler || config.RDSVolumeBasePath == "" {
+			klog.Warning("Retained backing files are never purged: the trash sweeper needs the orphan reconciler and a volume base path")
+		}
+	}
+
+	if config.EnableController && config.DeleteBatchWindow > 0 && !config.DeleteRetainFiles {
+		driver.deleteBatcher = newDeleteBatcher(config.DeleteBatchWindow)
+	}
+
+	if config.EnableController && config.AllowDeleteAttached {
+		driver.allowDeleteAttached = true
+		klog.Warning("DeleteVolume removes volumes still attached to a node (-allow-delete-attached)")
+	}
+
+	if config.EnableController && config.HealRenamedSlots {
+		driver.healRenamedSlots = true
+		klog.Info("Slots of volumes renamed on RDS are recorded on their PVs")
+	}
+
+	if config.EnableController && config.AnnotateBackendDetails {
+		driver.annotateBackendDetails = true
+		klog.Info("Backend details of volumes are recorded on their PVs at attach")
+	}
+
+	if err := config.MetadataLimits.Validate(); err != nil {
+		return nil, err
+	}
+	if config.EnableController {
+		driver.metadataLimits = config.MetadataLimits
+		klog.V(2).Infof("Metadata size limits: soft=%d hard=%d bytes",
+			config.MetadataLimits.SoftLimit(), config.MetadataLimits.HardLimit())
+	}
+
+	if config.EnableController && config.AllowedFSTypes != nil {
+		driver.allowedFSTypes = config.AllowedFSTypes
+		klog.Infof("CreateVolume only accepts fsTypes: %s", formatFSTypes(config.AllowedFSTypes))
+	}
+
+	for _, size := range []int64{config.MinVolumeSize, config.MaxVolumeSize} {
+		if size != 0 && (size < minVolumeSizeBytes || size > maxVolumeSizeBytes) {
+			return nil, fmt.Errorf("%w: volume size limit %d is outside the supported range %d to %d bytes",
+				utils.ErrInvalidParameter, size, int64(minVolumeSizeBytes), int64(maxVolumeSizeBytes))
+		}
+	}
+	if config.MaxVolumeSize > 0 && config.MinVolumeSize > config.MaxVolumeSize {
+		return nil, fmt.Errorf("%w: minimum volume size %d exceeds maximum volume size %d", utils.ErrInvalidParameter, config.MinVolumeSize, config.MaxVolumeSize)
+	}
+	if config.EnableController && (config.MinVolumeSize > 0 || config.MaxVolumeSize > 0) {
+		driver.minVolumeSize = config.MinVolumeSize
+		driver.maxVolumeSize = config.MaxVolumeSize
+		klog.Infof("Volume size policy: min=%d max=%d bytes (0 for the built-in limit)", config.MinVolumeSize, config.MaxVolumeSize)
 	}
 
 	// Initialize RDS client if controller is enabled
 	if config.EnableController {
 		rdsClient, err := rds.NewClient(rds.ClientConfig{
-			Address:            config.RDSAddress,
-			Port:               config.RDSPort,
-			User:               config.RDSUser,
-			PrivateKey:         config.RDSPrivateKey,
-			HostKey:            config.RDSHostKey,
-			InsecureSkipVerify: config.RDSInsecureSkipVerify,
+			Address:             config.RDSAddress,
+			Port:                config.RDSPort,
+			User:                config.RDSUser,
+			PrivateKey:          config.RDSPrivateKey,
+			HostKey:             config.RDSHostKey,
+			HostKeyFingerprints: config.RDSHostKeyFingerprints,
+			InsecureSkipVerify:  config.RDSInsecureSkipVerify,
+			Algorithms:          config.RDSSSHAlgorithms,
+			AuditLog:            config.RDSAuditLog,
+			LogRawIO:            config.LogRawRDSIO,
+			Metrics:             config.Metrics,
+			RetryBudget:         config.RDSRetryBudget,
+			MaxSessions:         config.RDSMaxSessions,
+			CommandTimeout:      config.RDSCommandTimeout,
+			LongCommandTimeout:  config.RDSLongCommandTimeout,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create RDS client: %w", err)
@@ -200,28 +586,55 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 
 		driver.rdsClient = rdsClient
 		klog.Infof("Connected to RDS at %s:%d", config.RDSAddress, config.RDSPort)
+
+		if err := checkRouterOSCompat(rds.DefaultBackendName, rdsClient, config.Metrics, config.StrictCompat); err != nil {
+			_ = rdsClient.Close()
+			return nil, err
+		}
+
+		driver.backends = rds.NewClientRegistry(rdsClient)
+		for name, backendConfig := range config.RDSBackends {
+			if err := driver.addBackend(name, backendConfig, config.StrictCompat); err != nil {
+				driver.backends.Close()
+				_ = rdsClient.Close()
+				return nil, fmt.Errorf("failed to configure RDS backend %s: %w", name, err)
+			}
+		}
 	}
 
 	// Initialize attachment manager if controller is enabled
 	if config.EnableController && config.K8sClient != nil {
 		driver.attachmentManager = attachment.NewAttachmentManager(config.K8sClient)
+		driver.attachmentManager.SetEventPoster(driver.getEventPoster())
+		driver.attachmentManager.SetMetadataLimits(config.MetadataLimits)
 		if config.Metrics != nil {
 			driver.attachmentManager.SetMetrics(config.Metrics)
 
 			// Wire AttachmentManager into Metrics for nvme_connections_active gauge.
-			// GaugeFunc callback queries current attachment count on each Prometheus scrape,
-			// surviving controller restarts (attachment state rebuilt from VolumeAttachments).
+			// The count follows the attachment events, rebuilds from VolumeAttachments
+			// included, so it survives controller restarts.
 			//
 			// NOTE: This counts volumes (len(attachments)), not per-node attachments.
 			// During live migration with dual-attach, a volume attached to 2 nodes temporarily
 			// is counted as 1, not 2. This matches the VolumeAttachment count in the cluster.
-			config.Metrics.SetAttachmentManager(func() int {
-				return len(driver.attachmentManager.ListAttachments())
-			})
+			driver.attachmentGauge = newAttachmentGauge(driver.attachmentManager)
+			driver.ownMetrics(config.Metrics.SetAttachmentManager(driver.attachmentGauge.Count))
 		}
 		klog.Info("Attachment manager created")
 	}
 
+	if config.EnableController && config.Metrics != nil {
+		driver.ownMetrics(config.Metrics.SetSnapshotRestoresInProgress(driver.snapshotRestores.inProgress))
+	}
+
+	if config.Metrics != nil && config.RDSAuditLog != nil {
+		driver.ownMetrics(config.Metrics.SetRDSAuditDropped(config.RDSAuditLog.Dropped))
+	}
+
+	if config.EnableController && config.Metrics != nil && config.RDSRetryBudget != nil {
+		driver.ownMetrics(config.Metrics.SetRDSRetryBudget(config.RDSRetryBudget.Consumed))
+	}
+
 	// Wire RDS monitoring (disk performance + hardware health) into Prometheus metrics.
 	// GaugeFunc callbacks poll via SSH (/disk monitor-traffic) and SNMP (MIKROTIK-MIB)
 	// during Prometheus scrape. Only registers in controller mode (node plugin has no RDS client).
@@ -235,7 +648,7 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 		snmpHost := "10.42.68.1"  // TODO: make configurable via helm values
 		snmpCommunity := "public" // TODO: make configurable via helm values
 
-		config.Metrics.SetRDSMonitoring(
+		driver.ownMetrics(config.Metrics.SetRDSMonitoring(
 			storageSlot,
 			snmpHost,
 			snmpCommunity,
@@ -276,8 +689,18 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 					DiskPoolUsedBytes: metrics.DiskPoolUsedBytes,
 				}, nil
 			},
-		)
+		))
 		klog.Infof("RDS monitoring enabled (disk slot=%s, snmp=%s)", storageSlot, snmpHost)
+
+		// Storage-side NVMe/TCP session counts, a cross-check for nvme_connections_active.
+		// Older RouterOS releases cannot list connections, so probe once and skip if unsupported.
+		if _, err := driver.rdsClient.GetNVMeSessions(); errors.Is(err, rds.ErrCommandUnsupported) {
+			klog.Info("RDS does not support NVMe/TCP session listing, rds_nvme_sessions metric disabled")
+		} else {
+			driver.ownMetrics(config.Metrics.SetRDSNVMeSessions(func() (map[string]int, error) {
+				return driver.rdsClient.GetNVMeSessions()
+			}))
+		}
 	}
 
 	// Initialize informer factory if we have k8s client (needed for attachment reconciler caching)
@@ -298,7 +721,7 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 		// Create EventPoster for posting lifecycle events
 		var eventPoster attachment.EventPoster
 		if config.K8sClient != nil {
-			eventPoster = NewEventPoster(config.K8sClient)
+			eventPoster = driver.getEventPoster()
 		}
 
 		// Get listers from informer factory (cached, no API calls)
@@ -351,18 +774,63 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 	// Add node service capabilities
 	if config.EnableNode {
 		driver.addNodeServiceCapabilities()
+		driver.nodeReadinessCheck = nvme.NewSysfsScanner().CheckTCPTransport
+		driver.tcpModuleDetector = nvme.NewSysfsScanner().CheckTCPModule
+		if config.NVMeTCPModprobe {
+			driver.tcpModuleLoader = nvme.LoadTCPModule
+		}
+		driver.dhchapDetector = nvme.DHCHAPSupported
+		driver.stagePhaseBudgets = config.StagePhaseBudgets
+		driver.blockStageMetadata = config.BlockStageMetadata
+		driver.readProbeInterval = config.VolumeReadProbeInterval
+		driver.nvmeConnectRate = config.NVMeConnectRate
+		driver.kubeletDir = config.KubeletDir
+		if driver.kubeletDir == "" {
+			driver.kubeletDir = "/var/lib/kubelet"
+		}
+		if config.DrainBeforeDisconnect {
+			driver.drainBeforeDisconnect = true
+			driver.drainTimeout = config.DrainTimeout
+			if driver.drainTimeout <= 0 {
+				driver.drainTimeout = DefaultDrainTimeout
+			}
+			klog.Infof("Filesystem volumes are drained before disconnect (timeout %v)", driver.drainTimeout)
+		}
+		if config.ForceUnmountOnBusy {
+			driver.forceUnmountOnBusy = true
+			klog.Info("Unmount targets still busy after retries are force unmounted")
+		}
+		if config.EnableProjectQuota {
+			driver.enableProjectQuota = true
+			klog.Info("Project quotas are enforced on filesystem volumes with quotaBytes")
+		}
+		if config.StrictVolumeContext {
+			driver.strictVolumeContext = true
+			klog.Info("Volume contexts lacking keys are rejected instead of inferred")
+		}
+		if config.EnableStagingJanitor {
+			driver.stagingJanitorInterval = config.StagingJanitorInterval
+			if driver.stagingJanitorInterval <= 0 {
+				driver.stagingJanitorInterval = DefaultStagingJanitorInterval
+			}
+			driver.stagingJanitorGracePeriod = config.StagingJanitorGracePeriod
+			if driver.stagingJanitorGracePeriod <= 0 {
+				driver.stagingJanitorGracePeriod = DefaultStagingJanitorGracePeriod
+			}
+		}
 	}
 
 	// Initialize orphan reconciler if enabled and we have controller + k8s client
 	if config.EnableController && config.EnableOrphanReconciler && config.K8sClient != nil {
 		reconcilerConfig := reconciler.OrphanReconcilerConfig{
-			RDSClient:     driver.rdsClient,
-			K8sClient:     config.K8sClient,
-			CheckInterval: config.OrphanCheckInterval,
-			GracePeriod:   config.OrphanGracePeriod,
-			DryRun:        config.OrphanDryRun,
-			Enabled:       true,
-			BasePath:      config.RDSVolumeBasePath,
+			RDSClient:      driver.rdsClient,
+			K8sClient:      config.K8sClient,
+			CheckInterval:  config.OrphanCheckInterval,
+			GracePeriod:    config.OrphanGracePeriod,
+			DryRun:         config.OrphanDryRun,
+			Enabled:        true,
+			BasePath:       config.RDSVolumeBasePath,
+			TrashRetention: config.TrashRetention,
 		}
 
 		orphanReconciler, err := reconciler.NewOrphanReconciler(reconcilerConfig)
@@ -375,6 +843,61 @@ func NewDriver(config DriverConfig) (*Driver, error) {
 			config.OrphanCheckInterval, config.OrphanGracePeriod, config.OrphanDryRun)
 	}
 
+	// Capacity monitor queries the same base paths CreateVolume allocates from, on every backend
+	if config.EnableController && config.CapacityCheckInterval > 0 && driver.backends != nil {
+		basePaths := config.CapacityBasePaths
+		if len(basePaths) == 0 {
+			basePath := config.RDSVolumeBasePath
+			if basePath == "" {
+				basePath = defaultVolumeBasePath
+			}
+			basePaths = []string{basePath}
+		}
+		if config.CapacityEventConfigMap != "" && config.K8sClient == nil {
+			klog.Warning("Low capacity events need a Kubernetes client; low capacity will only be logged")
+		}
+		driver.capacityMonitor = NewCapacityMonitor(CapacityMonitorConfig{
+			Backends:         driver.backends,
+			BasePaths:        basePaths,
+			Interval:         config.CapacityCheckInterval,
+			ThresholdPercent: config.LowCapacityThresholdPercent,
+			EventNamespace:   config.CapacityEventNamespace,
+			EventName:        config.CapacityEventConfigMap,
+			EventPoster:      driver.getEventPoster(),
+			Metrics:          config.Metrics,
+		})
+	}
+
+	if config.EnableController && config.PublishStorageCapacity {
+		if config.K8sClient == nil || driver.backends == nil {
+			return nil, fmt.Errorf("publishing CSIStorageCapacity objects needs a Kubernetes client and an RDS client")
+		}
+		if config.StorageCapacityNamespace == "" {
+			return nil, fmt.Errorf("publishing CSIStorageCapacity objects needs a namespace")
+		}
+		driver.capacityPublisher = NewCapacityPublisher(CapacityPublisherConfig{
+			K8sClient:  config.K8sClient,
+			Backends:   driver.backends,
+			DriverName: driver.name,
+			Namespace:  config.StorageCapacityNamespace,
+			Interval:   config.StorageCapacityInterval,
+		})
+	}
+
+	// File housekeeping needs the PV list to protect volumes whose disk entry is missing
+	if config.EnableController && config.K8sClient != nil && config.RDSVolumeBasePath != "" {
+		housekeeper, err := reconciler.NewFileHousekeeper(reconciler.FileHousekeeperConfig{
+			RDSClient:   driver.rdsClient,
+			K8sClient:   config.K8sClient,
+			BasePath:    config.RDSVolumeBasePath,
+			GracePeriod: config.OrphanGracePeriod,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file housekeeper: %w", err)
+		}
+		driver.housekeeper = housekeeper
+	}
+
 	return driver, nil
 }
 
@@ -417,6 +940,13 @@ func (d *Driver) addControllerServiceCapabilities() {
 				},
 			},
 		},
+		{
+			Type: &csi.ControllerServiceCapability_Rpc{
+				Rpc: &csi.ControllerServiceCapability_RPC{
+					Type: csi.ControllerServiceCapability_RPC_MODIFY_VOLUME,
+				},
+			},
+		},
 		{
 			Type: &csi.ControllerServiceCapability_Rpc{
 				Rpc: &csi.ControllerServiceCapability_RPC{
@@ -438,6 +968,20 @@ func (d *Driver) addControllerServiceCapabilities() {
 				},
 			},
 		},
+		{
+			Type: &csi.ControllerServiceCapability_Rpc{
+				Rpc: &csi.ControllerServiceCapability_RPC{
+					Type: csi.ControllerServiceCapability_RPC_GET_VOLUME,
+				},
+			},
+		},
+		{
+			Type: &csi.ControllerServiceCapability_Rpc{
+				Rpc: &csi.ControllerServiceCapability_RPC{
+					Type: csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+				},
+			},
+		},
 	}
 }
 
@@ -492,6 +1036,9 @@ func (d *Driver) Run(endpoint string) error {
 	if d.nodeID != "" {
 		klog.Info("Node service enabled")
 		d.ns = NewNodeServer(d, d.nodeID, d.k8sClient)
+		if warning := kubeletRootWarning(endpoint, d.kubeletDir, d.name); warning != "" {
+			klog.Warning(warning)
+		}
 	}
 
 	// Start informers if we have an informer factory
@@ -548,25 +1095,31 @@ func (d *Driver) Run(endpoint string) error {
 		}
 		klog.Info("Attachment reconciler started (using cached informers, no API throttling)")
 
-		// Start connection manager (after RDS client is connected)
-		if d.rdsClient != nil {
-			cmConfig := rds.ConnectionManagerConfig{
-				Client:  d.rdsClient,
-				Metrics: d.metrics,
-			}
-			// Set OnReconnect callback to trigger attachment reconciliation
-			cmConfig.OnReconnect = func() {
-				klog.Info("RDS reconnected, triggering attachment reconciliation")
-				d.attachmentReconciler.TriggerReconcile()
-			}
-			connectionManager, err := rds.NewConnectionManager(cmConfig)
-			if err != nil {
-				return fmt.Errorf("failed to create connection manager: %w", err)
+		// Start a connection manager for each backend (after the RDS clients are connected)
+		if d.backends != nil {
+			for _, backend := range d.backends.Backends() {
+				cmConfig := rds.ConnectionManagerConfig{
+					Client:  backend.Client,
+					Metrics: d.metrics,
+				}
+				// Set OnReconnect callback to trigger attachment reconciliation
+				backendName := backend.Name
+				cmConfig.OnReconnect = func() {
+					klog.Infof("RDS backend %s reconnected, triggering attachment reconciliation", backendName)
+					d.attachmentReconciler.TriggerReconcile()
+				}
+				connectionManager, err := rds.NewConnectionManager(cmConfig)
+				if err != nil {
+					return fmt.Errorf("failed to create connection manager for RDS backend %s: %w", backend.Name, err)
+				}
+				if backend.Name == rds.DefaultBackendName {
+					d.connectionManager = connectionManager
+				} else {
+					d.backendConnectionManagers = append(d.backendConnectionManagers, connectionManager)
+				}
+				connectionManager.StartMonitor(context.Background())
 			}
-			d.connectionManager = connectionManager
-			ctx := context.Background()
-			d.connectionManager.StartMonitor(ctx)
-			klog.Info("RDS connection manager started with automatic reconnection")
+			klog.Infof("RDS connection managers started with automatic reconnection for backends %v", d.backends.Names())
 		}
 
 		// Perform startup reconciliation (after informers synced AND attachment manager initialized)
@@ -584,11 +1137,35 @@ func (d *Driver) Run(endpoint string) error {
 		klog.Info("Orphan reconciler started")
 	}
 
+	// Start capacity monitor if configured
+	if d.capacityMonitor != nil {
+		d.capacityMonitor.Start(context.Background())
+	}
+	if d.capacityPublisher != nil {
+		d.capacityPublisher.Start(context.Background())
+	}
+
+	// Start volume read probe if configured
+	if ns, ok := d.ns.(*NodeServer); ok {
+		ns.readProber.Start(context.Background())
+		ns.stagingJanitor.Start(context.Background())
+	}
+
 	// Start gRPC server
-	server := NewNonBlockingGRPCServer(endpoint)
+	server := NewNonBlockingGRPCServerWithOptions(endpoint, d.serverOptions)
 	if err := server.Start(d.ids, d.cs, d.ns); err != nil {
 		return fmt.Errorf("failed to start gRPC server: %w", err)
 	}
+	d.serverMu.Lock()
+	d.server = server
+
+	// Watch for the socket being deleted from under us (unix endpoints only)
+	if server.SocketPath() != "" && d.socketCheckInterval > 0 {
+		watchdogCtx, cancel := context.WithCancel(context.Background())
+		d.stopWatchdog = cancel
+		NewSocketWatchdog(server, d.socketCheckInterval, d.registrationSocketPath, d.metrics).Start(watchdogCtx)
+	}
+	d.serverMu.Unlock()
 
 	klog.Info("Driver initialization complete, server running")
 
@@ -600,17 +1177,42 @@ func (d *Driver) Run(endpoint string) error {
 func (d *Driver) Stop() {
 	klog.Info("Stopping RDS CSI driver")
 
+	// Stop accepting CSI calls first; GracefulStop waits for in-flight RPCs on
+	// both unix and tcp listeners. The socket watchdog goes first so it does not
+	// re-create the socket of a server that is shutting down.
+	d.serverMu.Lock()
+	server := d.server
+	d.server = nil
+	if d.stopWatchdog != nil {
+		d.stopWatchdog()
+		d.stopWatchdog = nil
+	}
+	d.serverMu.Unlock()
+	if server != nil {
+		server.Stop()
+	}
+
 	// Stop attachment reconciler if running
 	if d.attachmentReconciler != nil {
 		d.attachmentReconciler.Stop()
 		klog.Info("Attachment reconciler stopped")
 	}
+	if d.attachmentGauge != nil {
+		d.attachmentGauge.Stop()
+	}
+	if d.attachmentManager != nil {
+		d.attachmentManager.StopPersistQueue()
+	}
 
-	// Stop connection manager if running
+	// Stop connection managers if running
 	if d.connectionManager != nil {
 		d.connectionManager.Stop()
 		klog.Info("RDS connection manager stopped")
 	}
+	for _, connectionManager := range d.backendConnectionManagers {
+		connectionManager.Stop()
+	}
+	d.backendConnectionManagers = nil
 
 	// Stop orphan reconciler if running
 	if d.reconciler != nil {
@@ -618,11 +1220,54 @@ func (d *Driver) Stop() {
 		klog.Info("Orphan reconciler stopped")
 	}
 
+	if d.capacityMonitor != nil {
+		d.capacityMonitor.Stop()
+	}
+	if d.capacityPublisher != nil {
+		d.capacityPublisher.Stop()
+	}
+
+	if ns, ok := d.ns.(*NodeServer); ok {
+		ns.readProber.Stop()
+		ns.stagingJanitor.Stop()
+	}
+
+	if d.backends != nil {
+		d.backends.Close()
+	}
+
 	if d.rdsClient != nil {
 		if err := d.rdsClient.Close(); err != nil {
 			klog.Errorf("Error closing RDS client: %v", err)
 		}
 	}
+
+	if d.rdsAuditLog != nil {
+		if err := d.rdsAuditLog.Close(); err != nil {
+			klog.Errorf("Error closing RDS audit log: %v", err)
+		}
+	}
+
+	// Drop the metrics whose callbacks refer to this driver, so a driver created
+	// afterwards with the same Metrics can register its own. Their last values are
+	// logged first: an evicted pod is often gone before the next scrape.
+	if d.metrics != nil {
+		klog.V(2).Infof("Final metrics snapshot: %s", d.metrics.Snapshot())
+		d.metricsMu.Lock()
+		for _, unregister := range d.unregisterMetrics {
+			unregister()
+		}
+		d.unregisterMetrics = nil
+		d.metricsMu.Unlock()
+	}
+}
+
+// ownMetrics records the function unregistering optional metrics this driver set up.
+// Stop calls it; those another driver sharing the Metrics set up stay registered.
+func (d *Driver) ownMetrics(unregister func()) {
+	d.metricsMu.Lock()
+	defer d.metricsMu.Unlock()
+	d.unregisterMetrics = append(d.unregisterMetrics, unregister)
 }
 
 // ShutdownWithContext gracefully stops the driver within the given context timeout.
@@ -651,6 +1296,83 @@ func (d *Driver) ShutdownWithContext(ctx context.Context) error {
 // SetRDSClient sets the RDS client (for testing)
 func (d *Driver) SetRDSClient(client rds.RDSClient) {
 	d.rdsClient = client
+	if d.backends != nil {
+		d.backends.SetDefault(client)
+	}
+}
+
+// addBackend connects to an additional RDS backend and registers it
+func (d *Driver) addBackend(name string, config rds.BackendConfig, strictCompat bool) error {
+	clientConfig, err := config.ClientConfig()
+	if err != nil {
+		return err
+	}
+	clientConfig.AuditLog = d.rdsAuditLog
+	clientConfig.LogRawIO = d.logRawRDSIO
+	clientConfig.Algorithms = d.rdsSSHAlgorithms
+	clientConfig.Metrics = d.metrics
+	clientConfig.RetryBudget = d.rdsRetryBudget
+	clientConfig.MaxSessions = d.rdsMaxSessions
+	clientConfig.CommandTimeout = d.rdsCommandTimeout
+	clientConfig.LongCommandTimeout = d.rdsLongCommandTimeout
+	client, err := rds.NewClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create RDS client: %w", err)
+	}
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to RDS: %w", err)
+	}
+	if err := checkRouterOSCompat(name, client, d.metrics, strictCompat); err != nil {
+		_ = client.Close()
+		return err
+	}
+	if err := d.backends.Register(&rds.Backend{Name: name, Client: client, NVMEAddress: config.NVMEAddress}); err != nil {
+		_ = client.Close()
+		return err
+	}
+	klog.Infof("Connected to RDS backend %s at %s:%d", name, clientConfig.Address, clientConfig.Port)
+	return nil
+}
+
+// checkRouterOSCompat logs the RouterOS version of an RDS backend, records it in
+// rds_csi_rds_routeros_info and warns about driver features known not to work on it.
+// Known issues only warn: the thresholds come from field reports, not vendor release
+// notes. With strict set, a version that cannot be determined fails startup.
+func checkRouterOSCompat(backend string, client rds.RDSClient, metrics *observability.Metrics, strict bool) error {
+	info, err := client.GetSystemInfo()
+	if err == nil {
+		klog.V(1).Infof("RDS backend %s runs RouterOS %s (channel=%s, board=%s)", backend, info.Version, info.Channel, info.BoardName)
+		if metrics != nil {
+			metrics.RecordRouterOSInfo(backend, info.Version)
+		}
+	}
+
+	var issues []rds.CompatIssue
+	if err == nil {
+		issues, err = rds.CheckCompatibility(info.Version)
+	}
+	if err != nil {
+		if strict {
+			return fmt.Errorf("cannot check RouterOS compatibility of RDS backend %s (strict compatibility enabled): %w", backend, err)
+		}
+		klog.Warningf("Skipping RouterOS compatibility check for RDS backend %s: %v", backend, err)
+		return nil
+	}
+
+	for _, issue := range issues {
+		klog.Warningf("RDS backend %s runs RouterOS %s: %s needs RouterOS %s or later (%s)",
+			backend, info.Version, issue.Feature, issue.MinVersion, issue.Reason)
+	}
+	return nil
+}
+
+// getBackends returns the backend registry. Drivers assembled without NewDriver
+// (tests) get a registry holding only the default client.
+func (d *Driver) getBackends() *rds.ClientRegistry {
+	if d.backends == nil {
+		return rds.NewClientRegistry(d.rdsClient)
+	}
+	return d.backends
 }
 
 // SetNVMEConnector sets the NVMe connector (for testing)
@@ -658,6 +1380,11 @@ func (d *Driver) SetNVMEConnector(connector nvme.Connector) {
 	d.nvmeConnector = connector
 }
 
+// SetNodeReadinessCheck replaces the node readiness check used by Probe (for testing)
+func (d *Driver) SetNodeReadinessCheck(check func() error) {
+	d.nodeReadinessCheck = check
+}
+
 // SetMounter sets the mounter (for testing)
 func (d *Driver) SetMounter(mounter mount.Mounter) {
 	d.mounter = mounter
@@ -688,11 +1415,31 @@ func (d *Driver) GetMetrics() *observability.Metrics {
 	return d.metrics
 }
 
+// GetRDSClient returns the client of the default RDS backend (nil if controller disabled)
+func (d *Driver) GetRDSClient() rds.RDSClient {
+	return d.rdsClient
+}
+
 // GetAttachmentManager returns the attachment manager (may be nil if controller disabled)
 func (d *Driver) GetAttachmentManager() *attachment.AttachmentManager {
 	return d.attachmentManager
 }
 
+// getEventPoster returns the driver-wide EventPoster, creating it on first use.
+// Returns nil if no Kubernetes client is configured.
+func (d *Driver) getEventPoster() *EventPoster {
+	if d.k8sClient == nil {
+		return nil
+	}
+	d.eventPosterOnce.Do(func() {
+		d.eventPoster = NewEventPoster(d.k8sClient)
+		if d.metrics != nil {
+			d.eventPoster.SetMetrics(d.metrics)
+		}
+	})
+	return d.eventPoster
+}
+
 // GetAttachmentGracePeriod returns the configured grace period for attachment handoff.
 func (d *Driver) GetAttachmentGracePeriod() time.Duration {
 	return d.attachmentGracePeriod
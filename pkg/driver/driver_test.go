@@ -1,12 +1,17 @@
 package driver
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 // TestAttachmentManager_SetMetricsMethod verifies the SetMetrics method exists and works.
@@ -126,3 +131,86 @@ func TestNewDriver_ConnectionManagerNilWhenNoRDSClient(t *testing.T) {
 		t.Error("ConnectionManager should be nil when RDS client is not initialized")
 	}
 }
+
+func TestStop_LogsFinalMetricsSnapshot(t *testing.T) {
+	logs := captureKlog(t, "2")
+	metrics := observability.NewMetrics()
+	metrics.SetAttachmentManager(func() int { return 3 })
+	metrics.RecordMigrationStarted()
+
+	d := &Driver{name: DriverName, version: "test", metrics: metrics}
+	d.Stop()
+	klog.Flush()
+
+	want := "Final metrics snapshot: active_migrations=1 nvme_connections_active=3 rds_disk_in_flight_operations=unknown"
+	if !strings.Contains(logs.String(), want) {
+		t.Errorf("expected Stop to log %q, got:\n%s", want, logs.String())
+	}
+}
+
+func TestCheckRouterOSCompat(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    *rds.SystemInfo
+		err     error
+		strict  bool
+		wantErr bool
+	}{
+		{name: "supported version", info: &rds.SystemInfo{Version: "7.16"}},
+		{name: "supported version strict", info: &rds.SystemInfo{Version: "7.18.1"}, strict: true},
+		{name: "known issue warns", info: &rds.SystemInfo{Version: "7.15"}},
+		{name: "known issue strict still warns", info: &rds.SystemInfo{Version: "7.15"}, strict: true},
+		{name: "unknown version warns", info: &rds.SystemInfo{Version: "7.x"}},
+		{name: "unknown version strict", info: &rds.SystemInfo{Version: "7.x"}, strict: true, wantErr: true},
+		{name: "query failure warns", err: errors.New("bad command name")},
+		{name: "query failure strict", err: errors.New("bad command name"), strict: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := rds.NewMockClient()
+			if tt.info != nil {
+				client.SetSystemInfo(tt.info)
+			}
+			if tt.err != nil {
+				client.SetError(tt.err)
+			}
+			err := checkRouterOSCompat(rds.DefaultBackendName, client, observability.NewMetrics(), tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkRouterOSCompat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNewDriver_VolumeSizeLimitsOutOfRange verifies that size limits outside the
+// built-in 1 GiB to 16 TiB range are refused when the driver starts
+func TestNewDriver_VolumeSizeLimitsOutOfRange(t *testing.T) {
+	const gib = int64(1024 * 1024 * 1024)
+	tests := []struct {
+		name     string
+		min, max int64
+	}{
+		{"negative", -1, 0},
+		{"minimum below 1 GiB", 1024, 0},
+		{"minimum above 16 TiB", 17 * 1024 * gib, 0},
+		{"maximum below 1 GiB", 0, gib - 1},
+		{"maximum above 16 TiB", 0, 32 * 1024 * gib},
+		{"minimum above maximum", 10 * gib, 5 * gib},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDriver(DriverConfig{
+				DriverName:       "rds.csi.srvlab.io",
+				NodeID:           "test-node",
+				EnableNode:       true,
+				K8sClient:        fake.NewSimpleClientset(),
+				ManagedNQNPrefix: "nqn.2000-02.com.example:csi",
+				MinVolumeSize:    tt.min,
+				MaxVolumeSize:    tt.max,
+			})
+			if !errors.Is(err, utils.ErrInvalidParameter) {
+				t.Errorf("expected ErrInvalidParameter, got %v", err)
+			}
+		})
+	}
+}
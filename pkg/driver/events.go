@@ -3,15 +3,19 @@ package driver
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 )
@@ -47,13 +51,50 @@ const (
 	EventReasonRDSDisconnected       = "RDSDisconnected"
 	EventReasonRDSReconnected        = "RDSReconnected"
 	EventReasonStartupReconciliation = "StartupReconciliation"
+
+	// Storage pool capacity events, posted on the configured ConfigMap
+	EventReasonLowCapacity       = "LowCapacity"
+	EventReasonCapacityRecovered = "CapacityRecovered"
+)
+
+// Event rate limiting. A long RDS outage can otherwise produce thousands of
+// near-identical events per PVC and trip the API server's own spam protection,
+// which then drops unrelated events.
+const (
+	// Correlator spam filter: per (PVC, reason) token bucket inside the broadcaster
+	eventSpamBurst = 25
+	eventSpamQPS   = 1.0 / 60
+
+	// Correlator aggregation: similar events within the interval collapse into one
+	eventAggregateMaxEvents       = 5
+	eventAggregateIntervalSeconds = 600
+
+	// Poster-side limiter: per (volume, reason) token bucket, checked before the
+	// event reaches the broadcaster so suppression is visible in metrics
+	eventLimiterBurst = 5
+	eventLimiterQPS   = 1.0 / 60
+
+	// eventLimiterIdle is how long an unused limiter takes to refill its burst; past
+	// it, the limiter is no different from a new one and is removed
+	eventLimiterIdle = time.Duration(eventLimiterBurst/eventLimiterQPS) * time.Second
 )
 
+// eventLimiter is the limiter of one (volume, reason) pair and when it was last used
+type eventLimiter struct {
+	limiter  flowcontrol.PassiveRateLimiter
+	lastUsed time.Time
+}
+
 // EventPoster posts Kubernetes events for mount operations
 type EventPoster struct {
 	recorder  record.EventRecorder
 	clientset kubernetes.Interface
 	metrics   *observability.Metrics
+
+	clock      clock.PassiveClock
+	limitersMu sync.Mutex
+	limiters   map[string]*eventLimiter // keyed by volumeID/reason
+	lastPrune  time.Time                // when idle limiters were last removed
 }
 
 // SetMetrics sets the Prometheus metrics for recording event posting
@@ -113,8 +154,16 @@ func (a *eventSinkAdapter) Patch(event *corev1.Event, data []byte) (*corev1.Even
 // NewEventPoster creates a new EventPoster
 // Accepts kubernetes.Interface and creates EventRecorder for posting events to PVCs
 func NewEventPoster(clientset kubernetes.Interface) *EventPoster {
-	// Create event broadcaster
-	broadcaster := record.NewBroadcaster()
+	// Create event broadcaster with a correlator tuned for our reasons.
+	// The spam key includes the reason so a flood of one reason on a PVC
+	// cannot starve other reasons on the same PVC.
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		BurstSize:            eventSpamBurst,
+		QPS:                  eventSpamQPS,
+		MaxEvents:            eventAggregateMaxEvents,
+		MaxIntervalInSeconds: eventAggregateIntervalSeconds,
+		SpamKeyFunc:          eventSpamKey,
+	})
 
 	// Start logging events to klog for visibility
 	broadcaster.StartLogging(klog.Infof)
@@ -134,7 +183,68 @@ func NewEventPoster(clientset kubernetes.Interface) *EventPoster {
 	return &EventPoster{
 		recorder:  recorder,
 		clientset: clientset,
+		clock:     clock.RealClock{},
+		limiters:  make(map[string]*eventLimiter),
+	}
+}
+
+// eventSpamKey groups events for the correlator spam filter by source,
+// involved object and reason.
+func eventSpamKey(event *corev1.Event) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s",
+		event.Source.Component,
+		event.InvolvedObject.Kind,
+		event.InvolvedObject.Namespace,
+		event.InvolvedObject.Name,
+		string(event.InvolvedObject.UID),
+		event.Reason)
+}
+
+// emit posts an event on obj (usually the volume's PVC) unless the per-(volume, reason)
+// limiter rejects it. Returns true if the event was handed to the recorder.
+func (ep *EventPoster) emit(obj runtime.Object, volumeID, eventType, reason, message string) bool {
+	if !ep.allow(volumeID, reason) {
+		if ep.metrics != nil {
+			ep.metrics.RecordEventSuppressed(reason)
+		}
+		klog.V(4).Infof("Suppressed %s event for volume %s (rate limited): %s", reason, volumeID, message)
+		return false
+	}
+
+	ep.recorder.Event(obj, eventType, reason, message)
+
+	if ep.metrics != nil {
+		ep.metrics.RecordEventPosted(reason)
+	}
+	return true
+}
+
+// allow takes a token from the limiter for the given volume and reason. Limiters
+// idle for eventLimiterIdle are removed along the way, so volumes that are gone do
+// not keep theirs.
+func (ep *EventPoster) allow(volumeID, reason string) bool {
+	key := volumeID + "/" + reason
+
+	ep.limitersMu.Lock()
+	defer ep.limitersMu.Unlock()
+
+	now := ep.clock.Now()
+	if now.Sub(ep.lastPrune) >= eventLimiterIdle {
+		for k, l := range ep.limiters {
+			if now.Sub(l.lastUsed) >= eventLimiterIdle {
+				delete(ep.limiters, k)
+			}
+		}
+		ep.lastPrune = now
+	}
+
+	l, ok := ep.limiters[key]
+	if !ok {
+		l = &eventLimiter{limiter: flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(eventLimiterQPS, eventLimiterBurst, ep.clock)}
+		ep.limiters[key] = l
 	}
+	l.lastUsed = now
+	return l.limiter.TryAccept()
 }
 
 // PostMountFailure posts a Warning event about a mount failure to the PVC
@@ -153,12 +263,8 @@ func (ep *EventPoster) PostMountFailure(ctx context.Context, pvcNamespace, pvcNa
 	// Format event message with volume and node context
 	eventMessage := fmt.Sprintf("[%s] on [%s]: %s", volumeID, nodeName, message)
 
-	// Post Warning event to PVC
-	ep.recorder.Event(pvc, corev1.EventTypeWarning, EventReasonMountFailure, eventMessage)
-
-	// Record metric
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonMountFailure)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeWarning, EventReasonMountFailure, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted mount failure event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -180,12 +286,8 @@ func (ep *EventPoster) PostRecoveryFailed(ctx context.Context, pvcNamespace, pvc
 	// Format event message with recovery context
 	eventMessage := fmt.Sprintf("[%s] on [%s]: Recovery failed after %d attempts: %v", volumeID, nodeName, attemptCount, finalErr)
 
-	// Post Warning event to PVC
-	ep.recorder.Event(pvc, corev1.EventTypeWarning, EventReasonRecoveryFailed, eventMessage)
-
-	// Record metric
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonRecoveryFailed)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeWarning, EventReasonRecoveryFailed, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted recovery failure event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -208,12 +310,8 @@ func (ep *EventPoster) PostStaleMountDetected(ctx context.Context, pvcNamespace,
 	// Format event message with device path info
 	eventMessage := fmt.Sprintf("[%s] on [%s]: Stale mount detected - old device: %s, new device: %s", volumeID, nodeName, oldDevicePath, newDevicePath)
 
-	// Post Normal event to PVC (informational, not a failure)
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonStaleMountDetected, eventMessage)
-
-	// Record metric
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonStaleMountDetected)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonStaleMountDetected, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted stale mount detection event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -221,6 +319,26 @@ func (ep *EventPoster) PostStaleMountDetected(ctx context.Context, pvcNamespace,
 	return nil
 }
 
+// PostFilesystemMismatch posts a Warning event when the device found for a volume carries
+// a different filesystem than the one recorded for the volume, so the mount was refused
+func (ep *EventPoster) PostFilesystemMismatch(ctx context.Context, pvcNamespace, pvcName, volumeID, nodeName, devicePath, expectedUUID, actualUUID string) error {
+	pvc, err := ep.clientset.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("Failed to get PVC %s/%s for filesystem mismatch event: %v", pvcNamespace, pvcName, err)
+		return nil
+	}
+
+	eventMessage := fmt.Sprintf("[%s] on [%s]: Refused to mount %s - filesystem UUID %s, expected %s", volumeID, nodeName, devicePath, actualUUID, expectedUUID)
+
+	if !ep.emit(pvc, volumeID, corev1.EventTypeWarning, EventReasonStaleMountDetected, eventMessage) {
+		return nil
+	}
+
+	klog.V(2).Infof("Posted filesystem mismatch event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
+
+	return nil
+}
+
 // PostConnectionFailure posts a Warning event when NVMe connection fails
 // Parameters: ctx, pvcNamespace, pvcName, volumeID, nodeName, targetAddress, err
 func (ep *EventPoster) PostConnectionFailure(ctx context.Context, pvcNamespace, pvcName, volumeID, nodeName, targetAddress string, err error) error {
@@ -231,11 +349,8 @@ func (ep *EventPoster) PostConnectionFailure(ctx context.Context, pvcNamespace,
 	}
 
 	eventMessage := fmt.Sprintf("[%s] on [%s]: Connection to %s failed: %v", volumeID, nodeName, targetAddress, err)
-	ep.recorder.Event(pvc, corev1.EventTypeWarning, EventReasonConnectionFailure, eventMessage)
-
-	// Record metric
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonConnectionFailure)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeWarning, EventReasonConnectionFailure, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted connection failure event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -251,11 +366,8 @@ func (ep *EventPoster) PostConnectionRecovery(ctx context.Context, pvcNamespace,
 	}
 
 	eventMessage := fmt.Sprintf("[%s] on [%s]: Connection to %s recovered after %d attempts", volumeID, nodeName, targetAddress, attempts)
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonConnectionRecovery, eventMessage)
-
-	// Record metric
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonConnectionRecovery)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonConnectionRecovery, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted connection recovery event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -287,8 +399,9 @@ func (ep *EventPoster) PostOrphanCleaned(ctx context.Context, nodeName, nqn stri
 
 // PostAttachmentConflict posts a Warning event when a volume attachment is rejected
 // due to the volume being attached to a different node.
-// Parameters: ctx, pvcNamespace, pvcName, volumeID, requestedNode, attachedNode
-func (ep *EventPoster) PostAttachmentConflict(ctx context.Context, pvcNamespace, pvcName, volumeID, requestedNode, attachedNode string) error {
+// Parameters: ctx, pvcNamespace, pvcName, volumeID, requestedNode, attachedNode, holder
+// (the workload using the volume on attachedNode, "" if unknown)
+func (ep *EventPoster) PostAttachmentConflict(ctx context.Context, pvcNamespace, pvcName, volumeID, requestedNode, attachedNode, holder string) error {
 	pvc, err := ep.clientset.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(ctx, pvcName, metav1.GetOptions{})
 	if err != nil {
 		// Don't fail the operation just because event couldn't be posted
@@ -298,12 +411,12 @@ func (ep *EventPoster) PostAttachmentConflict(ctx context.Context, pvcNamespace,
 
 	// Format message with actionable information for operators
 	eventMessage := fmt.Sprintf("[%s]: Attachment to node %s rejected - volume already attached to node %s. Delete the pod on %s to release the volume.", volumeID, requestedNode, attachedNode, attachedNode)
+	if holder != "" {
+		eventMessage = fmt.Sprintf("[%s]: Attachment to node %s rejected - volume already attached to node %s and in use by %s. Delete it to release the volume.", volumeID, requestedNode, attachedNode, holder)
+	}
 
-	ep.recorder.Event(pvc, corev1.EventTypeWarning, EventReasonAttachmentConflict, eventMessage)
-
-	// Record metric
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonAttachmentConflict)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeWarning, EventReasonAttachmentConflict, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted attachment conflict event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -320,10 +433,8 @@ func (ep *EventPoster) PostVolumeAttached(ctx context.Context, pvcNamespace, pvc
 	}
 
 	eventMessage := fmt.Sprintf("[%s]: Attached to node %s (duration: %s)", volumeID, nodeID, duration.Round(time.Millisecond))
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonVolumeAttached, eventMessage)
-
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonVolumeAttached)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonVolumeAttached, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted volume attached event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -340,10 +451,8 @@ func (ep *EventPoster) PostVolumeDetached(ctx context.Context, pvcNamespace, pvc
 	}
 
 	eventMessage := fmt.Sprintf("[%s]: Detached from node %s", volumeID, nodeID)
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonVolumeDetached, eventMessage)
-
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonVolumeDetached)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonVolumeDetached, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted volume detached event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -359,11 +468,9 @@ func (ep *EventPoster) PostStaleAttachmentCleared(ctx context.Context, pvcNamesp
 		return nil
 	}
 
-	eventMessage := fmt.Sprintf("[%s]: Cleared stale attachment from deleted node %s", volumeID, staleNodeID)
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonStaleAttachmentCleared, eventMessage)
-
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonStaleAttachmentCleared)
+	eventMessage := fmt.Sprintf("[%s]: Cleared stale attachment from unavailable node %s", volumeID, staleNodeID)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonStaleAttachmentCleared, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted stale attachment cleared event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -380,10 +487,8 @@ func (ep *EventPoster) PostMigrationStarted(ctx context.Context, pvcNamespace, p
 	}
 
 	eventMessage := fmt.Sprintf("[%s]: KubeVirt live migration started - source: %s, target: %s, timeout: %s", volumeID, sourceNode, targetNode, timeout.Round(time.Second))
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonMigrationStarted, eventMessage)
-
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonMigrationStarted)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonMigrationStarted, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted migration started event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -400,10 +505,8 @@ func (ep *EventPoster) PostMigrationCompleted(ctx context.Context, pvcNamespace,
 	}
 
 	eventMessage := fmt.Sprintf("[%s]: KubeVirt live migration completed - source: %s -> target: %s (duration: %s)", volumeID, sourceNode, targetNode, duration.Round(time.Second))
-	ep.recorder.Event(pvc, corev1.EventTypeNormal, EventReasonMigrationCompleted, eventMessage)
-
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonMigrationCompleted)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeNormal, EventReasonMigrationCompleted, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted migration completed event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
@@ -420,12 +523,49 @@ func (ep *EventPoster) PostMigrationFailed(ctx context.Context, pvcNamespace, pv
 	}
 
 	eventMessage := fmt.Sprintf("[%s]: KubeVirt live migration failed - source: %s, attempted target: %s, reason: %s, elapsed: %s", volumeID, sourceNode, targetNode, reason, duration.Round(time.Second))
-	ep.recorder.Event(pvc, corev1.EventTypeWarning, EventReasonMigrationFailed, eventMessage)
-
-	if ep.metrics != nil {
-		ep.metrics.RecordEventPosted(EventReasonMigrationFailed)
+	if !ep.emit(pvc, volumeID, corev1.EventTypeWarning, EventReasonMigrationFailed, eventMessage) {
+		return nil
 	}
 
 	klog.V(2).Infof("Posted migration failed event to PVC %s/%s: %s", pvcNamespace, pvcName, eventMessage)
 	return nil
 }
+
+// PostLowCapacity posts a Warning event on the ConfigMap namespace/name when free space
+// in pool (a base path, prefixed with the backend name for additional backends) drops
+// below thresholdPercent of the filesystem.
+func (ep *EventPoster) PostLowCapacity(ctx context.Context, namespace, name, pool string, availableBytes, totalBytes int64, thresholdPercent float64) error {
+	cm, err := ep.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("Failed to get ConfigMap %s/%s for low capacity event: %v", namespace, name, err)
+		return nil
+	}
+
+	eventMessage := fmt.Sprintf("[%s]: RDS free space %.1f GiB of %.1f GiB (%.1f%%) is below the %.1f%% threshold; new volumes may fail to provision",
+		pool, bytesToGiB(availableBytes), bytesToGiB(totalBytes), freePercent(availableBytes, totalBytes), thresholdPercent)
+	if !ep.emit(cm, pool, corev1.EventTypeWarning, EventReasonLowCapacity, eventMessage) {
+		return nil
+	}
+
+	klog.V(2).Infof("Posted low capacity event to ConfigMap %s/%s: %s", namespace, name, eventMessage)
+	return nil
+}
+
+// PostCapacityRecovered posts a Normal event on the ConfigMap namespace/name when free
+// space in pool is back above the threshold after a low capacity warning.
+func (ep *EventPoster) PostCapacityRecovered(ctx context.Context, namespace, name, pool string, availableBytes, totalBytes int64) error {
+	cm, err := ep.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("Failed to get ConfigMap %s/%s for capacity recovered event: %v", namespace, name, err)
+		return nil
+	}
+
+	eventMessage := fmt.Sprintf("[%s]: RDS free space recovered to %.1f GiB of %.1f GiB (%.1f%%)",
+		pool, bytesToGiB(availableBytes), bytesToGiB(totalBytes), freePercent(availableBytes, totalBytes))
+	if !ep.emit(cm, pool, corev1.EventTypeNormal, EventReasonCapacityRecovered, eventMessage) {
+		return nil
+	}
+
+	klog.V(2).Infof("Posted capacity recovered event to ConfigMap %s/%s: %s", namespace, name, eventMessage)
+	return nil
+}
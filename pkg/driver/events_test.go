@@ -3,13 +3,20 @@ package driver
 import (
 	"context"
 	"fmt"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 )
 
 // TestNewEventPoster_CreatesRecorder tests EventPoster creation
@@ -733,3 +740,117 @@ func TestPostMigrationFailed(t *testing.T) {
 	// We can't easily verify the event type with fake client, but the implementation
 	// uses corev1.EventTypeWarning
 }
+
+// TestEventPoster_RateLimitsIdenticalEvents fires a burst of identical events and
+// verifies only a bounded number reach the API server sink.
+func TestEventPoster_RateLimitsIdenticalEvents(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pvc", Namespace: "default", UID: "test-uid-123"},
+	}
+	fakeClient := fake.NewSimpleClientset(pvc)
+
+	var sinkWrites atomic.Int32
+	fakeClient.PrependReactor("*", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetVerb() == "create" || action.GetVerb() == "patch" || action.GetVerb() == "update" {
+			sinkWrites.Add(1)
+		}
+		return false, nil, nil
+	})
+
+	poster := NewEventPoster(fakeClient)
+	metrics := observability.NewMetrics()
+	poster.SetMetrics(metrics)
+
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		if err := poster.PostMountFailure(ctx, "default", "test-pvc", "pvc-123", "node-1", "RDS unreachable"); err != nil {
+			t.Fatalf("PostMountFailure failed: %v", err)
+		}
+	}
+
+	// Broadcaster delivery is asynchronous; wait for the first write then let the rest drain
+	deadline := time.Now().Add(5 * time.Second)
+	for sinkWrites.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	writes := sinkWrites.Load()
+	if writes == 0 {
+		t.Fatal("expected at least one event to reach the sink")
+	}
+	if writes > eventLimiterBurst {
+		t.Errorf("expected at most %d sink writes, got %d", eventLimiterBurst, writes)
+	}
+
+	body := scrapeMetrics(t, metrics)
+	if !strings.Contains(body, fmt.Sprintf(`rds_csi_events_posted_total{reason="MountFailure"} %d`, eventLimiterBurst)) {
+		t.Errorf("expected %d posted MountFailure events in metrics", eventLimiterBurst)
+	}
+	if !strings.Contains(body, fmt.Sprintf(`rds_csi_events_suppressed_total{reason="MountFailure"} %d`, 100-eventLimiterBurst)) {
+		t.Errorf("expected %d suppressed MountFailure events in metrics", 100-eventLimiterBurst)
+	}
+}
+
+// TestEventPoster_RateLimitKeyedByVolumeAndReason verifies that exhausting the
+// limiter for one (volume, reason) pair does not affect others, and that tokens refill.
+func TestEventPoster_RateLimitKeyedByVolumeAndReason(t *testing.T) {
+	poster := NewEventPoster(fake.NewSimpleClientset())
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	poster.clock = fakeClock
+
+	for i := 0; i < eventLimiterBurst; i++ {
+		if !poster.allow("pvc-1", EventReasonMountFailure) {
+			t.Fatalf("event %d should be allowed within burst", i)
+		}
+	}
+	if poster.allow("pvc-1", EventReasonMountFailure) {
+		t.Error("event beyond burst should be suppressed")
+	}
+
+	if !poster.allow("pvc-1", EventReasonConnectionFailure) {
+		t.Error("different reason on same volume should not be suppressed")
+	}
+	if !poster.allow("pvc-2", EventReasonMountFailure) {
+		t.Error("same reason on different volume should not be suppressed")
+	}
+
+	fakeClock.SetTime(fakeClock.Now().Add(time.Duration(1/eventLimiterQPS) * time.Second))
+	if !poster.allow("pvc-1", EventReasonMountFailure) {
+		t.Error("limiter should refill after the fill interval")
+	}
+}
+
+// scrapeMetrics returns the text exposition of the given metrics registry.
+func scrapeMetrics(t *testing.T, m *observability.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	return rec.Body.String()
+}
+
+// TestEventPoster_IdleLimitersRemoved verifies that limiters of volumes no longer
+// posting events are removed once they would have refilled anyway.
+func TestEventPoster_IdleLimitersRemoved(t *testing.T) {
+	poster := NewEventPoster(fake.NewSimpleClientset())
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	poster.clock = fakeClock
+
+	for i := 0; i < 100; i++ {
+		poster.allow(fmt.Sprintf("pvc-%d", i), EventReasonMountFailure)
+	}
+	if len(poster.limiters) != 100 {
+		t.Fatalf("expected 100 limiters, got %d", len(poster.limiters))
+	}
+
+	fakeClock.SetTime(fakeClock.Now().Add(eventLimiterIdle / 2))
+	poster.allow("pvc-0", EventReasonMountFailure)
+	fakeClock.SetTime(fakeClock.Now().Add(eventLimiterIdle / 2))
+	poster.allow("pvc-new", EventReasonMountFailure)
+	if len(poster.limiters) != 2 {
+		t.Errorf("expected only the limiters of pvc-0 and pvc-new to remain, got %d", len(poster.limiters))
+	}
+	if _, ok := poster.limiters["pvc-0/"+EventReasonMountFailure]; !ok {
+		t.Error("limiter used within the idle time was removed")
+	}
+}
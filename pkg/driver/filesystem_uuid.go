@@ -0,0 +1,164 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/mount"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+// filesystemUUIDRecordDir holds, below the plugin directory of the driver in the kubelet
+// root, the filesystem UUIDs the node adopted for volumes without an assigned one
+const filesystemUUIDRecordDir = "filesystem-uuids"
+
+// volumeContextFSUUID is the UUID the controller assigns to the filesystem of a volume.
+// The node formats new volumes with it and, before every mount, compares it with the
+// filesystem found on the device it resolved, so a device mix-up is refused instead of
+// mounting another volume's data.
+const volumeContextFSUUID = "fsUUID"
+
+// filesystemUUIDNamespace is the name-based UUID namespace filesystem UUIDs of new volumes
+// are derived in. It must never change, or retried CreateVolume calls of volumes created
+// before the change would get other UUIDs than their first call.
+var filesystemUUIDNamespace = uuid.MustParse("5b0c6a2e-8f0d-4b6e-9a43-1e9d7c2f4a81")
+
+// newFilesystemUUID returns the filesystem UUID of the new, empty volume volumeID, or ""
+// for volumes that are only used as block devices and never formatted by the node. The
+// UUID is derived from the volume ID, so a retried CreateVolume returns the same one.
+func newFilesystemUUID(volumeID string, volCaps []*csi.VolumeCapability) string {
+	for _, volCap := range volCaps {
+		if volCap.GetMount() != nil {
+			return uuid.NewSHA1(filesystemUUIDNamespace, []byte(volumeID)).String()
+		}
+	}
+	return ""
+}
+
+// existingFilesystemUUID returns the filesystem UUID of volumeID for an idempotent
+// CreateVolume of a volume that already exists on backend: the UUID its first call
+// returned. Whether that call adopted a pre-created backing file is not recorded, so with
+// adoptExisting no UUID is returned and the node adopts the one it finds at staging.
+func (cs *ControllerServer) existingFilesystemUUID(ctx context.Context, req *csi.CreateVolumeRequest, backend *rds.Backend, volumeID string, adoptExisting bool) string {
+	if snapshotSource := req.GetVolumeContentSource().GetSnapshot(); snapshotSource != nil {
+		snapshotInfo, err := backend.Client.GetSnapshot(snapshotSource.GetSnapshotId())
+		if err != nil {
+			var notFoundErr *rds.SnapshotNotFoundError
+			if !stderrors.As(err, &notFoundErr) {
+				klog.FromContext(ctx).Info("Failed to look up the source snapshot, volume gets no filesystem UUID", "snapshotID", snapshotSource.GetSnapshotId(), "err", err)
+			}
+			return ""
+		}
+		return cs.sourceFilesystemUUID(ctx, snapshotInfo.SourceVolume)
+	}
+	if adoptExisting {
+		return ""
+	}
+	return newFilesystemUUID(volumeID, req.GetVolumeCapabilities())
+}
+
+// sourceFilesystemUUID returns the filesystem UUID assigned to sourceVolumeID, which a
+// volume restored from its snapshot carries as well, or "" if it is not known. The PV is
+// found by its volume handle, so statically provisioned PVs with other names count too.
+func (cs *ControllerServer) sourceFilesystemUUID(ctx context.Context, sourceVolumeID string) string {
+	if cs.driver.k8sClient == nil || sourceVolumeID == "" {
+		return ""
+	}
+	pvs, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.FromContext(ctx).Info("Failed to list PVs, restored volume gets no filesystem UUID", "sourceVolumeID", sourceVolumeID, "err", err)
+		return ""
+	}
+	for _, pv := range pvs.Items {
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == cs.driver.name && pv.Spec.CSI.VolumeHandle == sourceVolumeID {
+			return pv.Spec.CSI.VolumeAttributes[volumeContextFSUUID]
+		}
+	}
+	return ""
+}
+
+// filesystemUUIDRecordPath is the file the node records the adopted filesystem UUID of
+// volumeID in, "" if the kubelet root is unknown
+func (ns *NodeServer) filesystemUUIDRecordPath(volumeID string) string {
+	if ns.driver.kubeletDir == "" {
+		return ""
+	}
+	return filepath.Join(ns.driver.kubeletDir, "plugins", ns.driver.name, filesystemUUIDRecordDir, volumeID)
+}
+
+// expectedFilesystemUUID returns the filesystem UUID volumeID must carry: the one the
+// controller assigned, or else the one this node adopted when it first staged the volume.
+// It returns "" if there is neither.
+func (ns *NodeServer) expectedFilesystemUUID(volumeID string, volumeContext map[string]string) string {
+	if fsUUID := volumeContext[volumeContextFSUUID]; fsUUID != "" {
+		return fsUUID
+	}
+	path := ns.filesystemUUIDRecordPath(volumeID)
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// checkFilesystemUUID compares the filesystem on devicePath with the UUID expected of
+// volumeID before it is mounted. Volumes created before UUIDs were assigned have none the
+// first time they are staged on a node; the UUID found then is adopted and checked on
+// later stages.
+func (ns *NodeServer) checkFilesystemUUID(ctx context.Context, volumeID, devicePath string, volumeContext map[string]string) error {
+	expected := ns.expectedFilesystemUUID(volumeID, volumeContext)
+	if expected == "" {
+		ns.adoptFilesystemUUID(ctx, volumeID, devicePath)
+		return nil
+	}
+	return mount.CheckFilesystemUUID(ns.mounter, devicePath, expected)
+}
+
+// adoptFilesystemUUID records the UUID of the filesystem on devicePath as the one volumeID
+// must carry from now on. Failures are logged: the volume is then just not checked.
+func (ns *NodeServer) adoptFilesystemUUID(ctx context.Context, volumeID, devicePath string) {
+	logger := klog.FromContext(ctx)
+	path := ns.filesystemUUIDRecordPath(volumeID)
+	if path == "" {
+		logger.V(2).Info("Volume has no assigned filesystem UUID, not checking it", "device", devicePath)
+		return
+	}
+	fsUUID, err := ns.mounter.FilesystemUUID(devicePath)
+	if err != nil {
+		logger.Info("Volume has no assigned filesystem UUID and reading the current one failed, not checking it", "device", devicePath, "err", err)
+		return
+	}
+	if err := writeFilesystemUUIDRecord(path, fsUUID); err != nil {
+		logger.Info("Failed to record the adopted filesystem UUID, not checking it", "device", devicePath, "err", err)
+		return
+	}
+	logger.V(2).Info("Volume has no assigned filesystem UUID, adopted the current one", "device", devicePath, "fsUUID", fsUUID, "record", path)
+}
+
+// writeFilesystemUUIDRecord writes fsUUID to path atomically, creating its directory
+func writeFilesystemUUIDRecord(path, fsUUID string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fsUUID+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to rename %s: %w", tmp, err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package driver
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+const (
+	fsUUIDVolumeID = "pvc-12345678-1234-1234-1234-123456789012"
+	fsUUIDOwn      = "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a"
+	fsUUIDOther    = "c0a9e8a3-0f6b-4f55-9c5e-2b1f4a7d8e90"
+)
+
+func staleMountsDetected(t *testing.T, metrics *observability.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "rds_csi_stale_mounts_detected_total "); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// testFilesystemUUIDNodeServer returns a node server without a Kubernetes client: the
+// filesystem UUID comes with the volume context
+func testFilesystemUUIDNodeServer(mounter *mockMounter) (*NodeServer, *mockNVMEConnector) {
+	connector := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	return &NodeServer{
+		driver: &Driver{
+			name:    "rds.csi.srvlab.io",
+			version: "test",
+			metrics: observability.NewMetrics(),
+		},
+		mounter:        mounter,
+		nvmeConn:       connector,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}, connector
+}
+
+func stageFilesystemVolume(t *testing.T, ns *NodeServer, fsUUID string) error {
+	t.Helper()
+	volumeContext := map[string]string{
+		"nqn":         "nqn.2000-02.com.mikrotik:" + fsUUIDVolumeID,
+		"nvmeAddress": "10.42.68.1",
+		"nvmePort":    "4420",
+	}
+	if fsUUID != "" {
+		volumeContext[volumeContextFSUUID] = fsUUID
+	}
+	_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          fsUUIDVolumeID,
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+		VolumeCapability:  createFilesystemVolumeCapability(),
+		VolumeContext:     volumeContext,
+	})
+	return err
+}
+
+func TestNodeStageVolume_FilesystemUUID(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatted bool
+		deviceFS  string // UUID of the filesystem on the device, if formatted
+		assigned  string
+		wantCode  codes.Code
+	}{
+		{name: "fresh format uses the assigned UUID", formatted: false, assigned: fsUUIDOwn, wantCode: codes.OK},
+		{name: "matching UUID mounts", formatted: true, deviceFS: fsUUIDOwn, assigned: fsUUIDOwn, wantCode: codes.OK},
+		{name: "legacy volume is not checked", formatted: true, wantCode: codes.OK},
+		{name: "other filesystem is refused", formatted: true, deviceFS: fsUUIDOther, assigned: fsUUIDOwn, wantCode: codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := &mockMounter{isFormatted: tt.formatted, fsUUID: tt.deviceFS}
+			ns, connector := testFilesystemUUIDNodeServer(mounter)
+
+			err := stageFilesystemVolume(t, ns, tt.assigned)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (err: %v)", tt.wantCode, status.Code(err), err)
+			}
+
+			if tt.wantCode == codes.OK {
+				if !tt.formatted && mounter.fsUUID != tt.assigned {
+					t.Errorf("expected the device to be formatted with UUID %q, got %q", tt.assigned, mounter.fsUUID)
+				}
+				if !mounter.mountCalled {
+					t.Error("expected the volume to be mounted")
+				}
+				return
+			}
+			if mounter.mountCalled {
+				t.Error("expected the wrong device not to be mounted")
+			}
+			if !connector.disconnectCalled {
+				t.Error("expected the wrong device to be disconnected")
+			}
+			if got := staleMountsDetected(t, ns.driver.metrics); got != "1" {
+				t.Errorf("expected 1 stale mount recorded, got %q", got)
+			}
+		})
+	}
+}
+
+func TestNodeStageVolume_AdoptsLegacyFilesystemUUID(t *testing.T) {
+	mounter := &mockMounter{isFormatted: true, fsUUID: fsUUIDOwn}
+	ns, _ := testFilesystemUUIDNodeServer(mounter)
+	ns.driver.kubeletDir = t.TempDir()
+
+	if err := stageFilesystemVolume(t, ns, ""); err != nil {
+		t.Fatalf("expected the legacy volume to be staged, got %v", err)
+	}
+	if got := ns.expectedFilesystemUUID(fsUUIDVolumeID, nil); got != fsUUIDOwn {
+		t.Fatalf("expected the current UUID %q to be adopted, got %q", fsUUIDOwn, got)
+	}
+
+	// A later stage resolves to another volume's device
+	mounter.fsUUID = fsUUIDOther
+	mounter.mountCalled = false
+	err := stageFilesystemVolume(t, ns, "")
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition against the adopted UUID, got %v", err)
+	}
+	if mounter.mountCalled {
+		t.Error("expected the wrong device not to be mounted")
+	}
+
+	// An assigned UUID takes precedence over the adopted one
+	if got := ns.expectedFilesystemUUID(fsUUIDVolumeID, map[string]string{volumeContextFSUUID: fsUUIDOther}); got != fsUUIDOther {
+		t.Errorf("expected the assigned UUID %q, got %q", fsUUIDOther, got)
+	}
+}
+
+func TestNewFilesystemUUID(t *testing.T) {
+	fsUUID := newFilesystemUUID("pvc-a", []*csi.VolumeCapability{createFilesystemVolumeCapability()})
+	if fsUUID == "" {
+		t.Fatal("expected a UUID for a filesystem volume")
+	}
+	if again := newFilesystemUUID("pvc-a", []*csi.VolumeCapability{createFilesystemVolumeCapability()}); again != fsUUID {
+		t.Errorf("expected the same volume to get the same UUID, got %s and %s", fsUUID, again)
+	}
+	if other := newFilesystemUUID("pvc-b", []*csi.VolumeCapability{createFilesystemVolumeCapability()}); other == fsUUID {
+		t.Errorf("expected every volume to get its own UUID, got %s twice", fsUUID)
+	}
+	if got := newFilesystemUUID("pvc-a", []*csi.VolumeCapability{createBlockVolumeCapability()}); got != "" {
+		t.Errorf("expected no UUID for a block volume, got %s", got)
+	}
+}
+
+func TestCreateVolume_FilesystemUUID(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := testControllerServer(t)
+
+	resp, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               "fs-uuid-source",
+		VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1024 * 1024 * 1024},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	sourceID := resp.Volume.VolumeId
+	fsUUID := resp.Volume.VolumeContext[volumeContextFSUUID]
+	if fsUUID == "" {
+		t.Fatal("expected a filesystem UUID in the volume context")
+	}
+
+	// The PV is found by its volume handle, whatever its name
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "static-pv"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           DriverName,
+					VolumeHandle:     sourceID,
+					VolumeAttributes: resp.Volume.VolumeContext,
+				},
+			},
+		},
+	}
+	if _, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Create(ctx, pv, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create PV: %v", err)
+	}
+
+	snapResp, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: "fs-uuid-snapshot", SourceVolumeId: sourceID})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	restored, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               "fs-uuid-restored",
+		VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1024 * 1024 * 1024},
+		VolumeContentSource: &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Snapshot{
+				Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapResp.Snapshot.SnapshotId},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume from snapshot failed: %v", err)
+	}
+	if got := restored.Volume.VolumeContext[volumeContextFSUUID]; got != fsUUID {
+		t.Errorf("expected the restored volume to carry the source UUID %q, got %q", fsUUID, got)
+	}
+}
+
+func TestCreateVolume_FilesystemUUIDIdempotent(t *testing.T) {
+	ctx := context.Background()
+	cs, _ := testControllerServer(t)
+
+	req := &csi.CreateVolumeRequest{
+		Name:               "fs-uuid-retried",
+		VolumeCapabilities: []*csi.VolumeCapability{createFilesystemVolumeCapability()},
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1024 * 1024 * 1024},
+	}
+	first, err := cs.CreateVolume(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	fsUUID := first.Volume.VolumeContext[volumeContextFSUUID]
+	if fsUUID == "" {
+		t.Fatal("expected a filesystem UUID in the volume context")
+	}
+
+	// The first response was lost and the provisioner retries
+	retried, err := cs.CreateVolume(ctx, req)
+	if err != nil {
+		t.Fatalf("retried CreateVolume failed: %v", err)
+	}
+	if got := retried.Volume.VolumeContext[volumeContextFSUUID]; got != fsUUID {
+		t.Errorf("expected the retried create to return UUID %q, got %q", fsUUID, got)
+	}
+}
@@ -2,6 +2,9 @@ package driver
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
@@ -10,16 +13,28 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// probeCacheTTL is how long a Probe result is reused. Sidecars probe frequently and the
+// RDS check opens an SSH session, so a probe storm must not become SSH load on RDS.
+const probeCacheTTL = 5 * time.Second
+
 // IdentityServer implements the CSI Identity service
 type IdentityServer struct {
 	csi.UnimplementedIdentityServer
 	driver *Driver
+
+	// probeMu serializes readiness checks and protects the cached result
+	probeMu      sync.Mutex
+	probeTTL     time.Duration
+	probeChecked time.Time
+	probeReady   bool
+	probeReason  string
 }
 
 // NewIdentityServer creates a new Identity service
 func NewIdentityServer(driver *Driver) *IdentityServer {
 	return &IdentityServer{
-		driver: driver,
+		driver:   driver,
+		probeTTL: probeCacheTTL,
 	}
 }
 
@@ -61,23 +76,54 @@ func (ids *IdentityServer) GetPluginCapabilities(ctx context.Context, req *csi.G
 	}, nil
 }
 
-// Probe returns the health and readiness of the plugin
+// Probe returns the readiness of the plugin.
+// The controller is not ready while RDS is unreachable; the node is not ready while
+// NVMe/TCP is unavailable. ProbeResponse has no message field, so the reason is logged.
 func (ids *IdentityServer) Probe(ctx context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
 	klog.V(5).Info("Probe called")
 
+	ids.probeMu.Lock()
+	defer ids.probeMu.Unlock()
+
+	if !ids.probeChecked.IsZero() && time.Since(ids.probeChecked) < ids.probeTTL {
+		return &csi.ProbeResponse{
+			Ready: wrapperspb.Bool(ids.probeReady),
+		}, nil
+	}
+
+	ready, reason := ids.checkReadiness()
+	if !ready {
+		klog.Warningf("Reporting not ready: %s", reason)
+	} else if !ids.probeChecked.IsZero() && !ids.probeReady {
+		klog.Infof("Ready again (was: %s)", ids.probeReason)
+	}
+
+	ids.probeChecked = time.Now()
+	ids.probeReady = ready
+	ids.probeReason = reason
+
+	return &csi.ProbeResponse{
+		Ready: wrapperspb.Bool(ready),
+	}, nil
+}
+
+// checkReadiness runs the controller and node backend checks.
+// Returns false and a reason if either enabled service cannot serve requests.
+func (ids *IdentityServer) checkReadiness() (bool, string) {
 	ready := true
+	reason := ""
 
 	// Check RDS connection state (prefer connectionManager if available)
 	if ids.driver.connectionManager != nil {
 		if !ids.driver.connectionManager.IsConnected() {
-			klog.Warning("RDS client is not connected - reporting not ready")
 			ready = false
+			reason = "RDS client is not connected"
 		}
 	} else if ids.driver.rdsClient != nil {
 		// Fallback to direct client check
 		if !ids.driver.rdsClient.IsConnected() {
-			klog.Warning("RDS client is not connected - reporting not ready")
 			ready = false
+			reason = "RDS client is not connected"
 		}
 	}
 
@@ -86,7 +132,18 @@ func (ids *IdentityServer) Probe(ctx context.Context, req *csi.ProbeRequest) (*c
 		ids.driver.metrics.RecordConnectionState(ids.driver.rdsClient.GetAddress(), ready)
 	}
 
-	return &csi.ProbeResponse{
-		Ready: wrapperspb.Bool(ready),
-	}, nil
+	// Check the node can make NVMe/TCP connections
+	if ids.driver.nodeReadinessCheck != nil {
+		if err := ids.driver.nodeReadinessCheck(); err != nil {
+			nodeReason := fmt.Sprintf("node cannot attach volumes: %v", err)
+			if reason != "" {
+				reason += "; " + nodeReason
+			} else {
+				reason = nodeReason
+			}
+			ready = false
+		}
+	}
+
+	return ready, reason
 }
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -257,6 +258,100 @@ func TestProbeNoPanicWhenMetricsNil(t *testing.T) {
 	}
 }
 
+// TestProbeNodeReadiness tests that Probe reports the node not ready when NVMe/TCP is unavailable
+func TestProbeNodeReadiness(t *testing.T) {
+	tests := []struct {
+		name          string
+		rdsConnected  bool
+		nodeErr       error
+		expectedReady bool
+	}{
+		{
+			name:          "node transport available",
+			rdsConnected:  true,
+			expectedReady: true,
+		},
+		{
+			name:          "nvme_tcp not loaded",
+			rdsConnected:  true,
+			nodeErr:       errors.New("nvme_tcp kernel module not loaded"),
+			expectedReady: false,
+		},
+		{
+			name:          "RDS down and node transport missing",
+			rdsConnected:  false,
+			nodeErr:       errors.New("NVMe subsystem path /sys/class/nvme-subsystem not found"),
+			expectedReady: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &Driver{
+				name:               "test.csi.driver",
+				version:            "v1.0.0",
+				rdsClient:          newTestMockClient(tt.rdsConnected),
+				nodeReadinessCheck: func() error { return tt.nodeErr },
+			}
+
+			ids := NewIdentityServer(driver)
+			resp, err := ids.Probe(context.Background(), &csi.ProbeRequest{})
+			if err != nil {
+				t.Fatalf("Probe failed: %v", err)
+			}
+
+			if resp.Ready.GetValue() != tt.expectedReady {
+				t.Errorf("Expected ready=%v, got %v", tt.expectedReady, resp.Ready.GetValue())
+			}
+			if !tt.expectedReady && ids.probeReason == "" {
+				t.Error("Expected a not-ready reason to be recorded")
+			}
+		})
+	}
+}
+
+// TestProbeCachesResult tests that repeated probes within the TTL do not re-run backend checks
+func TestProbeCachesResult(t *testing.T) {
+	mockClient := newTestMockClient(true)
+	checks := 0
+
+	driver := &Driver{
+		name:      "test.csi.driver",
+		version:   "v1.0.0",
+		rdsClient: mockClient,
+		nodeReadinessCheck: func() error {
+			checks++
+			return nil
+		},
+	}
+
+	ids := NewIdentityServer(driver)
+	for i := 0; i < 10; i++ {
+		if _, err := ids.Probe(context.Background(), &csi.ProbeRequest{}); err != nil {
+			t.Fatalf("Probe failed: %v", err)
+		}
+	}
+	if checks != 1 {
+		t.Errorf("Expected 1 backend check within the cache TTL, got %d", checks)
+	}
+
+	// A connection loss is not visible until the cached result expires
+	mockClient.SetConnected(false)
+	resp, _ := ids.Probe(context.Background(), &csi.ProbeRequest{})
+	if !resp.Ready.GetValue() {
+		t.Error("Expected cached ready=true within the TTL")
+	}
+
+	ids.probeTTL = 0
+	resp, _ = ids.Probe(context.Background(), &csi.ProbeRequest{})
+	if resp.Ready.GetValue() {
+		t.Error("Expected ready=false after the cached result expired")
+	}
+	if checks != 2 {
+		t.Errorf("Expected backend to be re-checked after expiry, got %d checks", checks)
+	}
+}
+
 // Test helper functions and mocks
 
 // newTestMockClient creates a test MockClient from rds package
@@ -0,0 +1,199 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// AnnotationImportedComment records the RDS disk comment of an imported volume, usually the
+// PVC the volume belonged to, so the operator can bind the PV to it
+const AnnotationImportedComment = "rds.csi.srvlab.io/disk-comment"
+
+// ImportConfig configures ImportVolumes
+type ImportConfig struct {
+	// DriverName is the CSI driver of the PVs, and of the PVs already referencing volumes
+	DriverName string
+
+	// BasePath limits the import to volumes whose backing file is below it (empty for all)
+	BasePath string
+
+	// RDSAddress and NVMEAddress are recorded in the volume attributes (NVMEAddress
+	// defaults to RDSAddress)
+	RDSAddress  string
+	NVMEAddress string
+
+	// FSType is the fsType of the PVs. RDS does not know which filesystem a disk holds,
+	// so this is a guess the operator checks before applying (default ext4).
+	FSType string
+
+	// StorageClassName is the storage class of the PVs (empty for none)
+	StorageClassName string
+}
+
+// ImportReport is the result of ImportVolumes
+type ImportReport struct {
+	// PersistentVolumes are the PVs for volumes no PV references, ordered by slot
+	PersistentVolumes []corev1.PersistentVolume
+
+	// Skipped lists the unreferenced volumes that cannot be imported, with the reason
+	Skipped []SkippedImport
+}
+
+// SkippedImport is a volume ImportVolumes found but could not build a PV for
+type SkippedImport struct {
+	Slot   string
+	Reason string
+}
+
+// ImportVolumes builds static PVs for the volumes on RDS no PV of the driver references,
+// by volume handle, slot annotation or backing file, so volumes created outside of
+// Kubernetes or left by another cluster can be adopted. It only reads from RDS and
+// Kubernetes; applying the PVs is up to the operator.
+func ImportVolumes(ctx context.Context, rdsClient rds.RDSClient, k8sClient kubernetes.Interface, config ImportConfig) (*ImportReport, error) {
+	volumes, err := rdsClient.ListVolumes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list RDS volumes: %w", err)
+	}
+	pvs, err := k8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PVs: %w", err)
+	}
+
+	referencedSlots := make(map[string]bool)
+	referencedFiles := make(map[string]bool)
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+			continue
+		}
+		referencedSlots[pv.Spec.CSI.VolumeHandle] = true
+		referencedSlots[pvSlot(pv)] = true
+		for _, key := range []string{volumeContextFilePath, paramVolumePath} {
+			if filePath := pv.Spec.CSI.VolumeAttributes[key]; filePath != "" {
+				referencedFiles[filePath] = true
+			}
+		}
+	}
+
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Slot < volumes[j].Slot })
+
+	report := &ImportReport{}
+	for i := range volumes {
+		vol := &volumes[i]
+		if strings.HasPrefix(vol.Slot, utils.SnapshotIDPrefix) || referencedSlots[vol.Slot] || referencedFiles[vol.FilePath] {
+			continue
+		}
+		if config.BasePath != "" && !strings.HasPrefix(vol.FilePath, strings.TrimSuffix(config.BasePath, "/")+"/") {
+			continue
+		}
+		if reason := importSkipReason(vol); reason != "" {
+			report.Skipped = append(report.Skipped, SkippedImport{Slot: vol.Slot, Reason: reason})
+			continue
+		}
+		report.PersistentVolumes = append(report.PersistentVolumes, importPV(vol, config))
+	}
+	return report, nil
+}
+
+// importSkipReason returns why vol cannot be attached through a static PV, or "" if it can
+func importSkipReason(vol *rds.VolumeInfo) string {
+	if err := utils.ValidateVolumeID(vol.Slot); err != nil {
+		return fmt.Sprintf("slot is not a valid volume ID: %v", err)
+	}
+	if !vol.NVMETCPExport || vol.NVMETCPNQN == "" {
+		return "not exported over NVMe/TCP"
+	}
+	if vol.FileSizeBytes <= 0 {
+		return "size unknown"
+	}
+	return ""
+}
+
+// importPV builds the static PV of vol, with the volume attributes CreateVolume records
+func importPV(vol *rds.VolumeInfo, config ImportConfig) corev1.PersistentVolume {
+	nvmeAddress := config.NVMEAddress
+	if nvmeAddress == "" {
+		nvmeAddress = config.RDSAddress
+	}
+	fsType := config.FSType
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+	nvmePort := vol.NVMETCPPort
+	if nvmePort == 0 {
+		nvmePort = defaultNVMETCPPort
+	}
+
+	attributes := map[string]string{
+		"rdsAddress":             config.RDSAddress,
+		volumeContextNVMEAddress: nvmeAddress,
+		"nvmePort":               strconv.Itoa(nvmePort),
+		volumeContextNQN:         vol.NVMETCPNQN,
+		paramVolumePath:          vol.FilePath,
+		volumeContextFilePath:    vol.FilePath,
+		volumeContextAccessMode:  AccessModeRWO,
+	}
+	if vol.WWID != "" {
+		attributes[volumeContextWWID] = vol.WWID
+	}
+
+	pv := corev1.PersistentVolume{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolume"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: strings.ToLower(vol.Slot),
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: *resource.NewQuantity(vol.FileSizeBytes, resource.BinarySI),
+			},
+			AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			StorageClassName:              config.StorageClassName,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           config.DriverName,
+					VolumeHandle:     vol.Slot,
+					FSType:           fsType,
+					VolumeAttributes: attributes,
+				},
+			},
+		},
+	}
+	if vol.Comment != "" {
+		pv.Annotations = map[string]string{AnnotationImportedComment: vol.Comment}
+	}
+	return pv
+}
+
+// WriteYAML writes the PVs of the report to w as a multi-document YAML stream, preceded
+// by a comment listing the skipped volumes
+func (r *ImportReport) WriteYAML(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %d volume(s) without a PersistentVolume\n", len(r.PersistentVolumes))
+	for _, skipped := range r.Skipped {
+		fmt.Fprintf(&b, "# skipped %s: %s\n", skipped.Slot, skipped.Reason)
+	}
+	for i := range r.PersistentVolumes {
+		manifest, err := yaml.Marshal(&r.PersistentVolumes[i])
+		if err != nil {
+			return fmt.Errorf("failed to marshal PV %s: %w", r.PersistentVolumes[i].Name, err)
+		}
+		b.WriteString("---\n")
+		b.Write(manifest)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,153 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+// importTestVolume adds an exported volume under /storage-pool/metal-csi to mockRDS
+func importTestVolume(mockRDS *rds.MockClient, slot string) {
+	mockRDS.AddVolume(&rds.VolumeInfo{
+		Slot:          slot,
+		FilePath:      "/storage-pool/metal-csi/" + slot + ".img",
+		FileSizeBytes: 10 << 30,
+		NVMETCPExport: true,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + slot,
+	})
+}
+
+func importTestPV(name, handle string, attributes map[string]string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: DriverName, VolumeHandle: handle, VolumeAttributes: attributes},
+			},
+		},
+	}
+}
+
+func TestImportVolumes(t *testing.T) {
+	const (
+		referenced   = "pvc-11111111-1111-1111-1111-111111111111"
+		renamed      = "pvc-22222222-2222-2222-2222-222222222222"
+		unreferenced = "pvc-33333333-3333-3333-3333-333333333333"
+		otherPool    = "pvc-44444444-4444-4444-4444-444444444444"
+		unexported   = "pvc-55555555-5555-5555-5555-555555555555"
+		byFile       = "pvc-66666666-6666-6666-6666-666666666666"
+	)
+	mockRDS := rds.NewMockClient()
+	for _, slot := range []string{referenced, renamed, unreferenced, unexported, byFile} {
+		importTestVolume(mockRDS, slot)
+	}
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: otherPool, FilePath: "/bulk-pool/" + otherPool + ".img", FileSizeBytes: 1 << 30, NVMETCPExport: true, NVMETCPNQN: "nqn.2000-02.com.mikrotik:" + otherPool})
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "snap-" + referenced, FilePath: "/storage-pool/metal-csi/snap.img", FileSizeBytes: 1 << 30})
+	vol, _ := mockRDS.GetVolume(unexported)
+	vol.NVMETCPExport = false
+	mockRDS.AddVolume(vol)
+	vol, _ = mockRDS.GetVolume(unreferenced)
+	vol.Comment = "default/data"
+	vol.WWID = "eui.0123456789abcdef"
+	mockRDS.AddVolume(vol)
+
+	renamedPV := importTestPV("pv-renamed", "pvc-old-handle", nil)
+	renamedPV.Annotations = map[string]string{AnnotationSlot: renamed}
+	k8sClient := fake.NewSimpleClientset(
+		importTestPV("pv-referenced", referenced, nil),
+		renamedPV,
+		importTestPV("pv-by-file", "pvc-other-handle", map[string]string{volumeContextFilePath: "/storage-pool/metal-csi/" + byFile + ".img"}),
+	)
+
+	report, err := ImportVolumes(context.Background(), mockRDS, k8sClient, ImportConfig{
+		DriverName:       DriverName,
+		BasePath:         "/storage-pool/metal-csi",
+		RDSAddress:       "10.0.0.1",
+		NVMEAddress:      "10.0.1.1",
+		StorageClassName: "rds-nvme",
+	})
+	if err != nil {
+		t.Fatalf("ImportVolumes failed: %v", err)
+	}
+
+	if len(report.Skipped) != 1 || report.Skipped[0].Slot != unexported {
+		t.Errorf("skipped = %+v, want only %s", report.Skipped, unexported)
+	}
+	if len(report.PersistentVolumes) != 1 {
+		t.Fatalf("got %d PVs, want 1 for %s", len(report.PersistentVolumes), unreferenced)
+	}
+	pv := report.PersistentVolumes[0]
+	if pv.Name != unreferenced || pv.Spec.CSI.VolumeHandle != unreferenced || pv.Spec.CSI.FSType != "ext4" {
+		t.Errorf("unexpected PV %s: handle %s, fsType %s", pv.Name, pv.Spec.CSI.VolumeHandle, pv.Spec.CSI.FSType)
+	}
+	if got := pv.Spec.Capacity[corev1.ResourceStorage]; got.Value() != 10<<30 {
+		t.Errorf("capacity = %s, want 10Gi", got.String())
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain || pv.Spec.StorageClassName != "rds-nvme" {
+		t.Errorf("reclaim policy %s, storage class %q", pv.Spec.PersistentVolumeReclaimPolicy, pv.Spec.StorageClassName)
+	}
+	wantAttributes := map[string]string{
+		"rdsAddress":             "10.0.0.1",
+		volumeContextNVMEAddress: "10.0.1.1",
+		"nvmePort":               "4420",
+		volumeContextNQN:         "nqn.2000-02.com.mikrotik:" + unreferenced,
+		volumeContextFilePath:    "/storage-pool/metal-csi/" + unreferenced + ".img",
+		volumeContextWWID:        "eui.0123456789abcdef",
+		volumeContextAccessMode:  AccessModeRWO,
+	}
+	for key, want := range wantAttributes {
+		if got := pv.Spec.CSI.VolumeAttributes[key]; got != want {
+			t.Errorf("volume attribute %s = %q, want %q", key, got, want)
+		}
+	}
+	if pv.Annotations[AnnotationImportedComment] != "default/data" {
+		t.Errorf("disk comment annotation = %q", pv.Annotations[AnnotationImportedComment])
+	}
+}
+
+func TestImportReport_WriteYAML(t *testing.T) {
+	mockRDS := rds.NewMockClient()
+	slots := []string{"pvc-11111111-1111-1111-1111-111111111111", "pvc-22222222-2222-2222-2222-222222222222"}
+	for _, slot := range slots {
+		importTestVolume(mockRDS, slot)
+	}
+	mockRDS.AddVolume(&rds.VolumeInfo{Slot: "pvc-Bad_Slot", FilePath: "/storage-pool/metal-csi/bad.img", FileSizeBytes: 1 << 30, NVMETCPExport: true, NVMETCPNQN: "nqn.2000-02.com.mikrotik:bad"})
+
+	report, err := ImportVolumes(context.Background(), mockRDS, fake.NewSimpleClientset(), ImportConfig{DriverName: DriverName, RDSAddress: "10.0.0.1", FSType: "xfs"})
+	if err != nil {
+		t.Fatalf("ImportVolumes failed: %v", err)
+	}
+	var out bytes.Buffer
+	if err := report.WriteYAML(&out); err != nil {
+		t.Fatalf("WriteYAML failed: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "# skipped pvc-Bad_Slot: slot is not a valid volume ID") {
+		t.Errorf("output does not list the skipped slot:\n%s", out.String())
+	}
+	documents := strings.Split(out.String(), "---\n")[1:]
+	if len(documents) != len(slots) {
+		t.Fatalf("got %d manifests, want %d:\n%s", len(documents), len(slots), out.String())
+	}
+	for i, document := range documents {
+		var pv corev1.PersistentVolume
+		if err := yaml.UnmarshalStrict([]byte(document), &pv); err != nil {
+			t.Fatalf("manifest %d does not parse: %v", i, err)
+		}
+		if pv.Kind != "PersistentVolume" || pv.Spec.CSI.VolumeHandle != slots[i] || pv.Spec.CSI.FSType != "xfs" {
+			t.Errorf("manifest %d: kind %s, handle %s, fsType %s", i, pv.Kind, pv.Spec.CSI.VolumeHandle, pv.Spec.CSI.FSType)
+		}
+		if pv.Spec.CSI.VolumeAttributes[volumeContextNQN] != "nqn.2000-02.com.mikrotik:"+slots[i] {
+			t.Errorf("manifest %d has NQN %s", i, pv.Spec.CSI.VolumeAttributes[volumeContextNQN])
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// maxLoggedLeftovers caps the entries of a leftover directory named in the log
+const maxLoggedLeftovers = 10
+
+// podsRoot is the kubelet directory holding publish target paths, "" if unknown
+func (ns *NodeServer) podsRoot() string {
+	if ns.driver.kubeletDir == "" {
+		return ""
+	}
+	return filepath.Join(ns.driver.kubeletDir, "pods")
+}
+
+// stagingRoot is the kubelet directory holding this driver's staging paths, "" if unknown
+func (ns *NodeServer) stagingRoot() string {
+	if ns.driver.kubeletDir == "" {
+		return ""
+	}
+	return filepath.Join(ns.driver.kubeletDir, "plugins", "kubernetes.io", "csi", ns.driver.name)
+}
+
+// removeLeftovers removes what a kubelet crash or an interrupted publish left at path
+// once the volume is unmounted from it, so unpublish and unstage do not fail on it
+// forever. Symlinks are removed, never followed. A directory with contents is only
+// emptied if nothing is mounted at or below it and it is below root; otherwise the
+// contents are logged and kept, and an error is returned, FailedPrecondition if path is
+// not below root. keepDir keeps path itself, e.g. a staging path that
+// kubelet removes. A removal failing with EBUSY force unmounts path and is retried.
+// Every anomaly found is logged at V(2).
+func (ns *NodeServer) removeLeftovers(ctx context.Context, path, root string, keepDir bool) error {
+	logger := klog.FromContext(ctx)
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		dest, _ := os.Readlink(path)
+		if _, err := os.Stat(path); err != nil {
+			logger.V(2).Info("Removing dangling symlink", "path", path, "target", dest, "err", err)
+		} else {
+			logger.V(2).Info("Removing symlink without following it", "path", path, "target", dest)
+		}
+		return ns.removeOrForceUnmount(path, func() error { return os.Remove(path) })
+	}
+
+	if !info.IsDir() {
+		if keepDir {
+			logger.V(2).Info("Keeping path: expected a directory", "path", path, "mode", info.Mode())
+			return nil
+		}
+		return ns.removeOrForceUnmount(path, func() error { return os.Remove(path) })
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		if keepDir {
+			return nil
+		}
+		return ns.removeOrForceUnmount(path, func() error { return os.Remove(path) })
+	}
+
+	names := make([]string, 0, maxLoggedLeftovers)
+	for _, entry := range entries {
+		if len(names) == maxLoggedLeftovers {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, entry.Name())
+	}
+	logger.V(2).Info("Directory is not empty after unmount", "path", path, "entries", len(entries), "names", strings.Join(names, ", "))
+
+	// Recursive removal below a mount would delete the data of the mounted volume
+	mountPoints := ns.mountPoints
+	if mountPoints == nil {
+		mountPoints = listMountPoints
+	}
+	points, err := mountPoints(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check for mounts below %s: %w", path, err)
+	}
+	for _, point := range points {
+		if point == path || strings.HasPrefix(point, path+"/") {
+			return fmt.Errorf("%s is not empty and %s is still mounted", path, point)
+		}
+	}
+
+	// Reporting success would leave kubelet failing to remove the directory itself, forever
+	if !isBelowRoot(path, root) {
+		logger.V(2).Info("Keeping the directory contents: not below the root", "path", path, "root", root)
+		return status.Errorf(codes.FailedPrecondition,
+			"%s is not empty (%s) and not below %q, so its contents are not removed; remove them by hand",
+			path, strings.Join(names, ", "), root)
+	}
+
+	logger.V(2).Info("Removing the stale directory contents", "path", path)
+	if !keepDir {
+		return ns.removeOrForceUnmount(path, func() error { return os.RemoveAll(path) })
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		if err := ns.removeOrForceUnmount(entryPath, func() error { return os.RemoveAll(entryPath) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBelowRoot reports whether path is strictly below root, both as written and with
+// symlinks resolved, so a symlinked parent cannot lead a removal out of the kubelet
+// root. An empty root, or a path whose parent cannot be resolved, is never below.
+func isBelowRoot(path, root string) bool {
+	if root == "" || !strings.HasPrefix(filepath.Clean(path), filepath.Clean(root)+"/") {
+		return false
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	resolvedParent, err := filepath.EvalSymlinks(filepath.Dir(filepath.Clean(path)))
+	if err != nil {
+		return false
+	}
+	return resolvedParent == resolvedRoot || strings.HasPrefix(resolvedParent, resolvedRoot+"/")
+}
+
+// kubeletRootWarning returns why the unix socket of endpoint looks misplaced for a
+// kubelet rooted at kubeletDir, or "" if it does not. The socket belongs in
+// <kubelet-root>/plugins/<driver>/, either directly or through a mount of that
+// directory such as /csi; sockets elsewhere suggest the manifests still use the
+// default root on a node that relocated it.
+func kubeletRootWarning(endpoint, kubeletDir, driverName string) string {
+	proto, addr, err := parseEndpoint(endpoint)
+	if err != nil || proto != "unix" || kubeletDir == "" {
+		return ""
+	}
+	if isBelowRoot(addr, kubeletDir) {
+		return ""
+	}
+	socketDir := filepath.Dir(filepath.Clean(addr))
+	pluginDir := filepath.Join(kubeletDir, "plugins", driverName)
+	socketInfo, socketErr := os.Stat(socketDir)
+	pluginInfo, pluginErr := os.Stat(pluginDir)
+	if socketErr == nil && pluginErr == nil && os.SameFile(socketInfo, pluginInfo) {
+		return ""
+	}
+	return fmt.Sprintf("CSI endpoint %s is not under the kubelet root %s and its directory is not %s; "+
+		"check -kubelet-root and the plugin directory mount", endpoint, kubeletDir, pluginDir)
+}
+
+// removeOrForceUnmount runs remove, and if path turns out to be busy (still a mount point
+// the mount table did not show) force unmounts it and runs remove again
+func (ns *NodeServer) removeOrForceUnmount(path string, remove func() error) error {
+	err := remove()
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	if !errors.Is(err, syscall.EBUSY) {
+		return err
+	}
+
+	klog.V(2).Infof("Removing %s failed with %v, force unmounting it", path, err)
+	if err := ns.mounter.ForceUnmount(path, corruptedTargetUnmountTimeout); err != nil {
+		return fmt.Errorf("%s is busy and could not be unmounted: %w", path, err)
+	}
+	if err := remove(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
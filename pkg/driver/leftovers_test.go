@@ -0,0 +1,340 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+const leftoversTestVolume = "pvc-12345678-1234-1234-1234-123456789012"
+
+// leftoversNodeServer returns a node server for kubeletDir whose mount table holds mounts
+func leftoversNodeServer(kubeletDir string, mounter *mockMounter, mounts ...string) *NodeServer {
+	return &NodeServer{
+		driver:         &Driver{name: DriverName, version: "test", metrics: observability.NewMetrics(), kubeletDir: kubeletDir},
+		mounter:        mounter,
+		nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1", getDevicePathErr: errors.New("not connected")},
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+		mountPoints:    func(context.Context) ([]string, error) { return mounts, nil },
+	}
+}
+
+func mkdirAll(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0750); err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	mkdirAll(t, filepath.Dir(path))
+	if err := os.WriteFile(path, []byte("stale"), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func symlink(t *testing.T, dest, path string) {
+	t.Helper()
+	mkdirAll(t, filepath.Dir(path))
+	if err := os.Symlink(dest, path); err != nil {
+		t.Fatalf("failed to create symlink %s: %v", path, err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// testKubeletRoots are the kubelet root layouts the leftover tests run against: the
+// default, a root relocated with kubelet --root-dir, and one reached through a symlink
+var testKubeletRoots = []struct {
+	name  string
+	setup func(t *testing.T) string
+}{
+	{name: "default root", setup: func(t *testing.T) string {
+		root := filepath.Join(t.TempDir(), "var", "lib", "kubelet")
+		mkdirAll(t, root)
+		return root
+	}},
+	{name: "relocated root", setup: func(t *testing.T) string {
+		root := filepath.Join(t.TempDir(), "data", "kubelet")
+		mkdirAll(t, root)
+		return root
+	}},
+	{name: "symlinked root", setup: func(t *testing.T) string {
+		base := t.TempDir()
+		mkdirAll(t, filepath.Join(base, "data", "kubelet"))
+		root := filepath.Join(base, "var", "lib", "kubelet")
+		symlink(t, filepath.Join(base, "data", "kubelet"), root)
+		return root
+	}},
+}
+
+// TestNodeUnpublishVolume_CorruptTargets tests unpublishing targets a kubelet crash left
+// as symlinks or directories with stale files
+func TestNodeUnpublishVolume_CorruptTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup builds the layout and returns the target path and the mounts of the node
+		setup       func(t *testing.T, kubeletDir, outside string) (string, []string)
+		wantCode    codes.Code
+		wantRemoved bool
+		// check verifies what must survive
+		check func(t *testing.T, kubeletDir, outside string)
+	}{
+		{
+			name: "dangling symlink",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				target := filepath.Join(kubeletDir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv", "mount")
+				symlink(t, filepath.Join(outside, "gone"), target)
+				return target, nil
+			},
+			wantRemoved: true,
+		},
+		{
+			name: "symlink to a directory with data is not followed",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				target := filepath.Join(kubeletDir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv", "mount")
+				writeFile(t, filepath.Join(outside, "data", "important"))
+				symlink(t, filepath.Join(outside, "data"), target)
+				return target, nil
+			},
+			wantRemoved: true,
+			check: func(t *testing.T, kubeletDir, outside string) {
+				if !exists(filepath.Join(outside, "data", "important")) {
+					t.Error("symlink destination was deleted")
+				}
+			},
+		},
+		{
+			name: "stale files below the pods directory",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				target := filepath.Join(kubeletDir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv", "mount")
+				writeFile(t, filepath.Join(target, "lost+found", "#12"))
+				writeFile(t, filepath.Join(target, "app.log"))
+				return target, nil
+			},
+			wantRemoved: true,
+		},
+		{
+			name: "stale files outside the pods directory are kept",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				target := filepath.Join(outside, "mount")
+				writeFile(t, filepath.Join(target, "app.log"))
+				return target, nil
+			},
+			wantCode: codes.FailedPrecondition,
+			check: func(t *testing.T, kubeletDir, outside string) {
+				if !exists(filepath.Join(outside, "mount", "app.log")) {
+					t.Error("files outside the pods directory were deleted")
+				}
+			},
+		},
+		{
+			name: "pods directory reached through ..",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				writeFile(t, filepath.Join(kubeletDir, "app.log"))
+				mkdirAll(t, filepath.Join(kubeletDir, "pods"))
+				return filepath.Join(kubeletDir, "pods") + "/..", nil
+			},
+			wantCode: codes.FailedPrecondition,
+			check: func(t *testing.T, kubeletDir, outside string) {
+				if !exists(filepath.Join(kubeletDir, "app.log")) {
+					t.Error("files above the pods directory were deleted")
+				}
+			},
+		},
+		{
+			name: "symlinked parent leading out of the pods directory",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				writeFile(t, filepath.Join(outside, "uid", "volumes", "kubernetes.io~csi", "pv", "mount", "important"))
+				symlink(t, filepath.Join(outside, "uid"), filepath.Join(kubeletDir, "pods", "uid"))
+				return filepath.Join(kubeletDir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv", "mount"), nil
+			},
+			wantCode: codes.FailedPrecondition,
+			check: func(t *testing.T, kubeletDir, outside string) {
+				if !exists(filepath.Join(outside, "uid", "volumes", "kubernetes.io~csi", "pv", "mount", "important")) {
+					t.Error("files reached through a symlinked parent were deleted")
+				}
+			},
+		},
+		{
+			name: "stale files with a mount below",
+			setup: func(t *testing.T, kubeletDir, outside string) (string, []string) {
+				target := filepath.Join(kubeletDir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv", "mount")
+				writeFile(t, filepath.Join(target, "data", "important"))
+				return target, []string{filepath.Join(target, "data")}
+			},
+			wantCode: codes.Internal,
+			check: func(t *testing.T, kubeletDir, outside string) {
+				target := filepath.Join(kubeletDir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv", "mount")
+				if !exists(filepath.Join(target, "data", "important")) {
+					t.Error("files below a mount were deleted")
+				}
+			},
+		},
+	}
+
+	for _, root := range testKubeletRoots {
+		for _, tt := range tests {
+			t.Run(root.name+"/"+tt.name, func(t *testing.T) {
+				logs := captureKlog(t, "2")
+				kubeletDir, outside := root.setup(t), t.TempDir()
+				target, mounts := tt.setup(t, kubeletDir, outside)
+				mounter := &mockMounter{}
+				ns := leftoversNodeServer(kubeletDir, mounter, mounts...)
+
+				_, err := ns.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{
+					VolumeId:   leftoversTestVolume,
+					TargetPath: target,
+				})
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("NodeUnpublishVolume error = %v, want %v", err, tt.wantCode)
+				}
+				if exists(target) == tt.wantRemoved {
+					t.Errorf("target exists = %v, want removed = %v", exists(target), tt.wantRemoved)
+				}
+				if tt.check != nil {
+					tt.check(t, kubeletDir, outside)
+				}
+				if logs.Len() == 0 {
+					t.Error("expected the anomaly to be logged")
+				}
+			})
+		}
+	}
+}
+
+// TestNodeUnstageVolume_CorruptStagingPaths tests that unstaging cleans up staging paths
+// left as symlinks or with stale files, leaving the directory itself to kubelet
+func TestNodeUnstageVolume_CorruptStagingPaths(t *testing.T) {
+	for _, root := range testKubeletRoots {
+		t.Run(root.name, func(t *testing.T) {
+			kubeletDir := root.setup(t)
+			stagingDir := filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", DriverName, "0123abcd")
+
+			t.Run("stale files", func(t *testing.T) {
+				staging := filepath.Join(stagingDir, "globalmount")
+				writeFile(t, filepath.Join(staging, "lost+found", "#12"))
+				writeFile(t, filepath.Join(staging, "stale"))
+				ns := leftoversNodeServer(kubeletDir, &mockMounter{})
+
+				if _, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+					VolumeId: leftoversTestVolume, StagingTargetPath: staging,
+				}); err != nil {
+					t.Fatalf("NodeUnstageVolume failed: %v", err)
+				}
+				entries, err := os.ReadDir(staging)
+				if err != nil || len(entries) != 0 {
+					t.Errorf("expected an empty staging directory, got %v, %v", entries, err)
+				}
+			})
+
+			t.Run("dangling symlink", func(t *testing.T) {
+				staging := filepath.Join(stagingDir, "globalmount-link")
+				symlink(t, filepath.Join(kubeletDir, "gone"), staging)
+				ns := leftoversNodeServer(kubeletDir, &mockMounter{})
+
+				if _, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+					VolumeId: leftoversTestVolume, StagingTargetPath: staging,
+				}); err != nil {
+					t.Fatalf("NodeUnstageVolume failed: %v", err)
+				}
+				if exists(staging) {
+					t.Error("dangling symlink was not removed")
+				}
+			})
+
+			t.Run("stale files outside the staging root are kept", func(t *testing.T) {
+				staging := filepath.Join(t.TempDir(), "globalmount")
+				writeFile(t, filepath.Join(staging, "stale"))
+				ns := leftoversNodeServer(kubeletDir, &mockMounter{})
+
+				// Success would leave kubelet unable to remove the staging directory
+				_, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+					VolumeId: leftoversTestVolume, StagingTargetPath: staging,
+				})
+				if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), staging) {
+					t.Fatalf("expected FailedPrecondition naming %s, got %v", staging, err)
+				}
+				if !exists(filepath.Join(staging, "stale")) {
+					t.Error("file outside the staging root was deleted")
+				}
+			})
+		})
+	}
+}
+
+// TestRemoveOrForceUnmount tests that a removal failing with EBUSY escalates to a force
+// unmount and is retried
+func TestRemoveOrForceUnmount(t *testing.T) {
+	busy := &os.PathError{Op: "remove", Path: "target", Err: syscall.EBUSY}
+
+	mounter := &mockMounter{}
+	ns := leftoversNodeServer(t.TempDir(), mounter)
+	calls := 0
+	err := ns.removeOrForceUnmount("target", func() error {
+		calls++
+		if calls == 1 {
+			return busy
+		}
+		return nil
+	})
+	if err != nil || !mounter.forceUnmounted || calls != 2 {
+		t.Errorf("expected a force unmount and a retry, got err=%v forceUnmounted=%v calls=%d", err, mounter.forceUnmounted, calls)
+	}
+
+	mounter = &mockMounter{unmountErr: errors.New("target is busy")}
+	ns = leftoversNodeServer(t.TempDir(), mounter)
+	if err := ns.removeOrForceUnmount("target", func() error { return busy }); err == nil {
+		t.Error("expected an error when the force unmount fails")
+	}
+
+	mounter = &mockMounter{}
+	ns = leftoversNodeServer(t.TempDir(), mounter)
+	if err := ns.removeOrForceUnmount("target", func() error { return syscall.EACCES }); err == nil || mounter.forceUnmounted {
+		t.Errorf("expected other errors returned without force unmount, got %v", err)
+	}
+}
+
+// TestKubeletRootWarning tests the startup check that the CSI socket is in the plugin
+// directory of the kubelet root
+func TestKubeletRootWarning(t *testing.T) {
+	for _, root := range testKubeletRoots {
+		t.Run(root.name, func(t *testing.T) {
+			kubeletDir := root.setup(t)
+			pluginDir := filepath.Join(kubeletDir, "plugins", DriverName)
+			mkdirAll(t, pluginDir)
+			// The node plugin usually sees the plugin directory mounted at /csi
+			mountedDir := filepath.Join(t.TempDir(), "csi")
+			symlink(t, pluginDir, mountedDir)
+			elsewhere := filepath.Join(t.TempDir(), "var", "lib", "kubelet", "plugins", DriverName)
+			mkdirAll(t, elsewhere)
+
+			for endpoint, wantWarning := range map[string]bool{
+				"unix://" + filepath.Join(pluginDir, "csi.sock"):  false,
+				"unix://" + filepath.Join(mountedDir, "csi.sock"): false,
+				"unix://" + filepath.Join(elsewhere, "csi.sock"):  true,
+				"tcp://127.0.0.1:10000":                           false,
+			} {
+				if got := kubeletRootWarning(endpoint, kubeletDir, DriverName); (got != "") != wantWarning {
+					t.Errorf("kubeletRootWarning(%s) = %q, want warning %v", endpoint, got, wantWarning)
+				}
+			}
+		})
+	}
+}
@@ -2,16 +2,20 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"golang.org/x/sys/unix"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/client-go/kubernetes"
@@ -20,6 +24,7 @@ import (
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/mount"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/security"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
@@ -37,8 +42,12 @@ const (
 	volumeContextNVMEAddress = "nvmeAddress"
 	volumeContextPort        = "nvmePort"
 	volumeContextFSType      = "fsType"
+	volumeContextWWID        = "wwid"
 )
 
+// errNoFilesystem is returned when formatPolicy=never forbids formatting a blank volume
+var errNoFilesystem = errors.New("volume has no filesystem")
+
 // NodeServer implements the CSI Node service
 type NodeServer struct {
 	csi.UnimplementedNodeServer
@@ -46,10 +55,50 @@ type NodeServer struct {
 	nvmeConn       nvme.Connector
 	mounter        mount.Mounter
 	nodeID         string
-	eventPoster    *EventPoster                         // for posting K8s events
-	staleChecker   *mount.StaleMountChecker             // for detecting stale mounts
-	recoverer      *mount.MountRecoverer                // for recovering stale mounts
-	circuitBreaker *circuitbreaker.VolumeCircuitBreaker // for preventing mount retry storms
+	eventPoster    *EventPoster                           // for posting K8s events
+	staleChecker   *mount.StaleMountChecker               // for detecting stale mounts
+	recoverer      *mount.MountRecoverer                  // for recovering stale mounts
+	circuitBreaker *circuitbreaker.VolumeCircuitBreaker   // for preventing mount retry storms
+	deviceSizeFunc func(devicePath string) (int64, error) // reports block device size (injectable for tests)
+	readProber     *volumeReadProber                      // probes staged devices, nil unless --volume-read-probe-interval is set
+	stagingJanitor *stagingJanitor                        // removes orphaned staging directories, nil unless --enable-staging-janitor is set
+	connectLimiter *rate.Limiter                          // spaces NVMe connects of all volumes, nil when --nvme-connect-rate is 0
+
+	// statFunc stats publish targets (injectable for tests, nil means syscall.Stat)
+	statFunc func(path string, stat *syscall.Stat_t) error
+
+	// mountPoints lists the node's mount points before leftovers are removed recursively
+	// (injectable for tests, nil means the mount table)
+	mountPoints func(ctx context.Context) ([]string, error)
+
+	// syncFunc syncs a staged filesystem and sysfs reads device in-flight I/O before a
+	// drained disconnect (injectable for tests, nil means syncfs and /sys)
+	syncFunc func(path string) error
+	sysfs    *nvme.SysfsScanner
+
+	// filesystemSyncs holds the syncs of drained filesystems still in progress
+	filesystemSyncs filesystemSyncs
+
+	// quotaStats holds the project quotas volume stats last read
+	quotaStats quotaStatsCache
+
+	// unmountBusyBackoff is the first wait before retrying a busy unmount (injectable for
+	// tests, 0 means defaultUnmountBusyBackoff)
+	unmountBusyBackoff time.Duration
+
+	// srvResolver resolves discovery:// NVMe addresses (injectable for tests, nil means net.DefaultResolver)
+	srvResolver srvResolver
+
+	// tcpModuleDetector re-checks the nvme_tcp kernel module while tcpModuleErr is set
+	tcpModuleMu       sync.Mutex
+	tcpModuleDetector func() error
+	tcpModuleErr      error // why NVMe/TCP is unavailable on this node, nil if it is available
+
+	// dhchapDetector reports why this node cannot authenticate with DH-HMAC-CHAP (nil skips the check)
+	dhchapDetector func() error
+
+	// measuredPublishes keeps a volume staged again from being measured from its old publish
+	measuredPublishes measuredPublishes
 }
 
 // NewNodeServer creates a new Node service
@@ -106,7 +155,7 @@ func NewNodeServer(driver *Driver, nodeID string, k8sClient kubernetes.Interface
 		eventPoster.SetMetrics(driver.metrics)
 	}
 
-	return &NodeServer{
+	ns := &NodeServer{
 		driver:         driver,
 		nvmeConn:       connector,
 		mounter:        m,
@@ -115,7 +164,88 @@ func NewNodeServer(driver *Driver, nodeID string, k8sClient kubernetes.Interface
 		staleChecker:   staleChecker,
 		recoverer:      recoverer,
 		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+		deviceSizeFunc: rescanBlockDeviceSize,
+		statFunc:       syscall.Stat,
+		connectLimiter: newConnectLimiter(driver.nvmeConnectRate),
+	}
+	ns.measuredPublishes.since = time.Now()
+
+	// The real connector needs the nvme_tcp kernel module; check once up front so a node
+	// without it reports one clear error instead of failing every connect obscurely
+	if driver.nvmeConnector == nil {
+		ns.dhchapDetector = driver.dhchapDetector
+	}
+	if driver.nvmeConnector == nil && driver.tcpModuleDetector != nil {
+		ns.tcpModuleDetector = driver.tcpModuleDetector
+		if driver.metrics != nil {
+			driver.ownMetrics(driver.metrics.EnableNodeNVMeTCPMetrics())
+		}
+		ns.checkTCPModule(driver.tcpModuleLoader)
+	}
+
+	if driver.readProbeInterval > 0 {
+		ns.readProber = newVolumeReadProber(driver.readProbeInterval, connector.GetDevicePath, driver.metrics)
+	}
+
+	if driver.stagingJanitorInterval > 0 {
+		ns.stagingJanitor = newStagingJanitor(driver.kubeletDir, driver.name, driver.stagingJanitorInterval,
+			driver.stagingJanitorGracePeriod, connector.IsConnectedWithContext, driver.metrics)
+		if k8sClient != nil {
+			ns.stagingJanitor.attachedVolumes = attachedVolumesFunc(k8sClient, driver.name, nodeID)
+		}
+	}
+
+	return ns
+}
+
+// checkTCPModule verifies the nvme_tcp kernel module is available, loading it with
+// modprobe if load is set. The result is logged and recorded in node_nvme_tcp_available.
+func (ns *NodeServer) checkTCPModule(load func() error) {
+	err := ns.tcpModuleDetector()
+	if err != nil && load != nil {
+		klog.Infof("NVMe/TCP transport not available (%v), loading %s kernel module", err, nvme.TCPModuleName)
+		if loadErr := load(); loadErr != nil {
+			klog.Warningf("Failed to load %s kernel module: %v", nvme.TCPModuleName, loadErr)
+		} else {
+			err = ns.tcpModuleDetector()
+		}
+	}
+
+	ns.tcpModuleMu.Lock()
+	ns.tcpModuleErr = err
+	ns.tcpModuleMu.Unlock()
+	if ns.driver.metrics != nil {
+		ns.driver.metrics.SetNodeNVMeTCPAvailable(err == nil)
+	}
+
+	if err != nil {
+		klog.Errorf("NVMe/TCP is not available on node %s: %v. Volumes cannot be staged on this node until "+
+			"the %s kernel module is loaded (modprobe %s)", ns.nodeID, err, nvme.TCPModuleName, nvme.TCPModuleName)
+		return
 	}
+	klog.V(2).Infof("NVMe/TCP transport available (%s kernel module loaded)", nvme.TCPModuleName)
+}
+
+// tcpModuleAvailable returns why NVMe/TCP connections cannot work on this node, or nil.
+// A module missing at startup is looked for again, so loading it later recovers the node.
+func (ns *NodeServer) tcpModuleAvailable() error {
+	ns.tcpModuleMu.Lock()
+	defer ns.tcpModuleMu.Unlock()
+
+	if ns.tcpModuleErr == nil {
+		return nil
+	}
+	if err := ns.tcpModuleDetector(); err != nil {
+		return fmt.Errorf("NVMe/TCP is not available on node %s: %v; load the %s kernel module (modprobe %s)",
+			ns.nodeID, err, nvme.TCPModuleName, nvme.TCPModuleName)
+	}
+
+	klog.Infof("NVMe/TCP transport is now available on node %s", ns.nodeID)
+	ns.tcpModuleErr = nil
+	if ns.driver.metrics != nil {
+		ns.driver.metrics.SetNodeNVMeTCPAvailable(true)
+	}
+	return nil
 }
 
 // NodeStageVolume stages a volume to a staging path on the node
@@ -136,7 +266,9 @@ func (ns *NodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVol
 	volumeID := req.GetVolumeId()
 	stagingPath := req.GetStagingTargetPath()
 
-	klog.V(2).Infof("NodeStageVolume called for volume: %s, staging path: %s", volumeID, stagingPath)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("NodeStageVolume called", "stagingPath", stagingPath)
 
 	// Validate request
 	if volumeID == "" {
@@ -152,38 +284,42 @@ func (ns *NodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVol
 	// Detect volume mode early - block volumes don't have filesystems
 	isBlockVolume := req.GetVolumeCapability().GetBlock() != nil
 
-	// Extract volume context
-	volumeContext := req.GetVolumeContext()
+	// Extract volume context, filling in keys that PVs of early driver versions lack
+	// (nvmeAddress falls back to rdsAddress)
+	volumeContext, err := ns.resolveVolumeContext(volumeID, req.GetVolumeContext(), stageContextKeys)
+	if err != nil {
+		return nil, err
+	}
+	if report := checkVolumeContext(volumeID, volumeContext, stageContextKeys); !report.Complete() {
+		return nil, status.Error(codes.InvalidArgument, report.remediation())
+	}
 	nqn := volumeContext[volumeContextNQN]
 	nvmeAddress := volumeContext[volumeContextNVMEAddress]
-	// Fall back to rdsAddress if nvmeAddress not set (backward compatibility)
-	if nvmeAddress == "" {
-		nvmeAddress = volumeContext[volumeContextAddress]
-	}
 	nvmePort := volumeContext[volumeContextPort]
 
-	if nqn == "" || nvmeAddress == "" || nvmePort == "" {
-		return nil, status.Errorf(codes.InvalidArgument,
-			"missing required volume context: nqn=%s, nvmeAddress=%s, nvmePort=%s",
-			nqn, nvmeAddress, nvmePort)
-	}
-
 	// SECURITY: Validate port format and range
 	port, err := utils.ValidatePortString(nvmePort, true)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid nvmePort: %v", err)
 	}
 
-	// SECURITY: Validate IP address format
-	if err := utils.ValidateIPAddress(nvmeAddress); err != nil {
+	// SECURITY: Validate the address: an IP, or a discovery:// SRV record whose targets
+	// are validated when it is resolved before connecting
+	_, discovery, err := utils.ParseDiscoveryAddress(nvmeAddress)
+	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid nvmeAddress: %v", err)
 	}
+	if !discovery {
+		if err := utils.ValidateIPAddress(nvmeAddress); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid nvmeAddress: %v", err)
+		}
 
-	// SECURITY: Validate NVMe target context (address + port combination)
-	// Note: expectedAddress is empty here as we don't have RDS address in node plugin
-	// The controller validates this during volume creation
-	if err := utils.ValidateNVMETargetContext(nqn, nvmeAddress, port, ""); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid NVMe target context: %v", err)
+		// SECURITY: Validate NVMe target context (address + port combination)
+		// Note: expectedAddress is empty here as we don't have RDS address in node plugin
+		// The controller validates this during volume creation
+		if err := utils.ValidateNVMETargetContext(nqn, nvmeAddress, port, ""); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid NVMe target context: %v", err)
+		}
 	}
 
 	// Get filesystem type from capability or use default (only for filesystem volumes)
@@ -196,6 +332,33 @@ func (ns *NodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVol
 		}
 	}
 
+	// Filesystem options from StorageClass (ignored for block volumes)
+	var fsOpts FilesystemOptions
+	if !isBlockVolume {
+		parsed, parseErr := ParseFilesystemOptions(volumeContext)
+		if parseErr != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid filesystem parameters: %v", parseErr)
+		}
+		fsOpts = parsed
+	}
+	if fsOpts.QuotaBytes > 0 {
+		if !ns.driver.enableProjectQuota {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"volume %s has a %s limit, which needs project quotas enabled on the node (-enable-project-quota)", volumeID, paramQuotaBytes)
+		}
+		if !supportsProjectQuota(fsType) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s requires an ext4 or xfs filesystem, not %s", paramQuotaBytes, fsType)
+		}
+	}
+
+	// Expected namespace identifier pins the device when the subsystem has several namespaces
+	wwid := volumeContext[volumeContextWWID]
+	if wwid != "" {
+		if err := utils.ValidateWWID(wwid); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid wwid in volume context: %v", err)
+		}
+	}
+
 	// Extract connection parameters from VolumeContext
 	connConfig := nvme.DefaultConnectionConfig()
 
@@ -217,57 +380,100 @@ func (ns *NodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVol
 		}
 	}
 
-	klog.V(2).Infof("Staging volume %s: NQN=%s, Address=%s:%d, FSType=%s",
-		volumeID, nqn, nvmeAddress, port, fsType)
+	logger.V(2).Info("Staging volume", "nqn", nqn, "address", nvmeAddress, "port", port, "fsType", fsType)
 
 	// Extract PVC info for event posting
 	pvcNamespace := volumeContext["csi.storage.k8s.io/pvc/namespace"]
 	pvcName := volumeContext["csi.storage.k8s.io/pvc/name"]
 
 	// Log volume stage request
-	secLogger := security.GetLogger()
+	secLogger := requestSecurityLogger(ctx)
 	secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeUnknown, nil, 0)
 
 	startTime := time.Now()
 
+	// Without the nvme_tcp kernel module every connect fails; say so instead
+	if err := ns.tcpModuleAvailable(); err != nil {
+		secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeFailure, err, time.Since(startTime))
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// The endpoints of a discovery:// address can change; look them up on every stage
+	endpoints, err := ns.resolveNVMeEndpoints(ctx, nvmeAddress, port)
+	if err != nil {
+		secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeFailure, err, time.Since(startTime))
+		return nil, status.Errorf(codes.Unavailable, "failed to resolve NVMe target: %v", err)
+	}
+
 	// Step 1: Connect to NVMe/TCP target with retry support
 	target := nvme.Target{
-		Transport:     "tcp",
-		NQN:           nqn,
-		TargetAddress: nvmeAddress,
-		TargetPort:    port,
+		Transport: "tcp",
+		NQN:       nqn,
+		WWID:      wwid,
 	}
+	if err := ns.applyNVMeAuth(volumeContext, req.GetSecrets(), &target); err != nil {
+		secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeFailure, err, time.Since(startTime))
+		return nil, err
+	}
+
+	logger.V(2).Info("Connecting with retry", "ctrlLossTmo", connConfig.CtrlLossTmo, "reconnectDelay", connConfig.ReconnectDelay, "dhchap", target.HasAuth())
 
-	klog.V(2).Infof("Connecting with config: ctrl_loss_tmo=%d, reconnect_delay=%d (with retry)",
-		connConfig.CtrlLossTmo, connConfig.ReconnectDelay)
+	// Each step gets its share of the kubelet deadline, so a timeout names the step that stalled
+	phases := newPhaseRunner(ctx, "stage", ns.stagePhaseBudgets(), ns.driver.metrics)
 
-	devicePath, err := ns.nvmeConn.ConnectWithRetry(ctx, target, connConfig)
+	var devicePath string
+	err = phases.run(PhaseConnect, func(ctx context.Context) error {
+		// Endpoints are tried in order until one connects
+		var connectErr error
+		for i, endpoint := range endpoints {
+			target.TargetAddress, target.TargetPort = endpoint.Address, endpoint.Port
+			devicePath, connectErr = ns.connectWithLimit(ctx, target, connConfig)
+			if connectErr == nil || ctx.Err() != nil {
+				return connectErr
+			}
+			if i < len(endpoints)-1 {
+				logger.Info("Failed to connect volume, trying the next target", "target", endpoint, "err", connectErr)
+			}
+		}
+		return connectErr
+	})
 	if err != nil {
 		// Post connection failure event (ignore error - event posting is best effort)
 		if ns.eventPoster != nil && pvcNamespace != "" && pvcName != "" {
-			targetAddr := fmt.Sprintf("%s:%d", nvmeAddress, port)
+			targetAddr := net.JoinHostPort(nvmeAddress, strconv.Itoa(port))
 			_ = ns.eventPoster.PostConnectionFailure(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID, targetAddr, err)
 		}
 		// Log volume stage failure
 		secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeFailure, err, time.Since(startTime))
-		return nil, status.Errorf(codes.Internal, "failed to connect to NVMe target: %v", err)
+		return nil, status.Errorf(stageErrorCode(err, codes.Internal), "failed to connect to NVMe target: %v", err)
 	}
 
-	klog.V(2).Infof("Connected to NVMe target, device: %s", devicePath)
+	logger.V(2).Info("Connected to NVMe target", "device", devicePath)
 
 	if isBlockVolume {
 		// Block volume: device is connected above via nvme-tcp
 		// Per CSI spec and AWS EBS CSI driver pattern, NodeStageVolume for block volumes
 		// does NOT create anything at staging_target_path - it just ensures device is ready
-		// NodePublishVolume will find the device by NQN and bind mount to target path
-		klog.V(2).Infof("Successfully staged block volume %s (device: %s, NQN: %s)",
-			volumeID, devicePath, nqn)
+		// NodePublishVolume will find the device by NQN and bind mount to target path.
+		// With --block-stage-metadata the NQN is also recorded there, for volumes whose
+		// NQN cannot be derived from the volume ID.
+		if ns.driver.blockStageMetadata {
+			metadata := blockStageMetadata{VolumeID: volumeID, NQN: nqn, DevicePath: devicePath}
+			if err := writeBlockStageMetadata(stagingPath, metadata); err != nil {
+				secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeFailure, err, time.Since(startTime))
+				return nil, status.Errorf(codes.Internal, "failed to write block stage metadata: %v", err)
+			}
+		}
+		ns.readProber.Track(volumeID, nqn)
+		ns.recordVolumeReady(volumeID, req.GetPublishContext(), observability.VolumeAttachBlock, time.Since(metricsStart))
+		logger.V(2).Info("Successfully staged block volume", "device", devicePath, "nqn", nqn)
 		secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeSuccess, nil, time.Since(startTime))
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
 
 	// Filesystem volume: format and mount with circuit breaker protection
 	// Wrap format and mount operations in circuit breaker to prevent retry storms
+	attach := observability.VolumeAttachReattach
 	err = ns.circuitBreaker.Execute(ctx, volumeID, func() error {
 		// Step 2a: Determine filesystem state with retry for transient device errors
 		// After NVMe-oF connect, the device may not be immediately ready for I/O.
@@ -280,73 +486,135 @@ func (ns *NodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVol
 		)
 
 		var formatted bool
-		var formatCheckErr error
+		err := phases.run(PhaseDeviceWait, func(ctx context.Context) error {
+			var formatCheckErr error
+			for attempt := 1; attempt <= isFormattedMaxRetries; attempt++ {
+				formatted, formatCheckErr = ns.mounter.IsFormatted(devicePath)
+				if formatCheckErr == nil {
+					// blkid succeeded or returned exit 2 (no fs) - we have a definitive answer
+					return nil
+				}
 
-		for attempt := 1; attempt <= isFormattedMaxRetries; attempt++ {
-			formatted, formatCheckErr = ns.mounter.IsFormatted(devicePath)
-			if formatCheckErr == nil {
-				// blkid succeeded or returned exit 2 (no fs) - we have a definitive answer
-				break
+				// blkid returned an error (likely exit 1 - device not ready)
+				if attempt < isFormattedMaxRetries {
+					logger.Info("IsFormatted check failed, retrying", "device", devicePath,
+						"attempt", attempt, "maxAttempts", isFormattedMaxRetries, "retryDelay", isFormattedRetryDelay, "err", formatCheckErr)
+					select {
+					case <-ctx.Done():
+						return fmt.Errorf("context cancelled while waiting for device %s to be ready: %w", devicePath, ctx.Err())
+					case <-time.After(isFormattedRetryDelay):
+						// continue retry
+					}
+				}
 			}
 
-			// blkid returned an error (likely exit 1 - device not ready)
-			if attempt < isFormattedMaxRetries {
-				klog.Warningf("IsFormatted check failed for %s (attempt %d/%d): %v - retrying in %v",
-					devicePath, attempt, isFormattedMaxRetries, formatCheckErr, isFormattedRetryDelay)
-				select {
-				case <-ctx.Done():
-					return fmt.Errorf("context cancelled while waiting for device %s to be ready: %w", devicePath, ctx.Err())
-				case <-time.After(isFormattedRetryDelay):
-					// continue retry
+			// All retries exhausted - device is not readable
+			logger.Error(formatCheckErr, "IsFormatted check failed, refusing to format to prevent data loss",
+				"device", devicePath, "attempts", isFormattedMaxRetries)
+			return fmt.Errorf("cannot determine filesystem state of device %s after %d attempts (last error: %w) - refusing to format to prevent potential data loss",
+				devicePath, isFormattedMaxRetries, formatCheckErr)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = phases.run(PhaseFormat, func(ctx context.Context) error {
+			// Step 2b: Check filesystem health (only for existing filesystems)
+			if formatted {
+				logger.V(2).Info("Running filesystem health check", "device", devicePath)
+				if healthErr := mount.CheckFilesystemHealth(ctx, devicePath, fsType); healthErr != nil {
+					return fmt.Errorf("filesystem health check failed: %w", healthErr)
 				}
-			} else {
-				// All retries exhausted - device is not readable
-				klog.Errorf("IsFormatted check failed for %s after %d attempts: %v - refusing to format to prevent data loss",
-					devicePath, isFormattedMaxRetries, formatCheckErr)
-				return fmt.Errorf("cannot determine filesystem state of device %s after %d attempts (last error: %w) - refusing to format to prevent potential data loss",
-					devicePath, isFormattedMaxRetries, formatCheckErr)
 			}
-		}
 
-		// Step 2b: Check filesystem health (only for existing filesystems)
-		if formatted {
-			klog.V(2).Infof("Running filesystem health check for %s", devicePath)
-			if healthErr := mount.CheckFilesystemHealth(ctx, devicePath, fsType); healthErr != nil {
-				return fmt.Errorf("filesystem health check failed: %w", healthErr)
+			// Step 2c: Format filesystem if needed (only when blkid definitively confirmed no filesystem).
+			// With formatPolicy=never the volume must already carry a filesystem; mkfs never runs.
+			if fsOpts.NeverFormat {
+				if !formatted {
+					return fmt.Errorf("%w on device %s and %s is %s", errNoFilesystem, devicePath, paramFormatPolicy, FormatPolicyNever)
+				}
+			} else if formatErr := ns.mounter.Format(devicePath, fsType, volumeContext[volumeContextFSUUID]); formatErr != nil {
+				return fmt.Errorf("failed to format device: %w", formatErr)
 			}
+			if !formatted {
+				attach = observability.VolumeAttachNew
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
-		// Step 2c: Format filesystem if needed (only when blkid definitively confirmed no filesystem)
-		if formatErr := ns.mounter.Format(devicePath, fsType); formatErr != nil {
-			return fmt.Errorf("failed to format device: %w", formatErr)
-		}
+		return phases.run(PhaseMount, func(ctx context.Context) error {
+			// Step 3: Mount to staging path, once the filesystem is known to be the volume's
+			if uuidErr := ns.checkFilesystemUUID(ctx, volumeID, devicePath, volumeContext); uuidErr != nil {
+				return uuidErr
+			}
+			mountOptions := buildStagingMountOptions(req.GetVolumeCapability(), fsType, fsOpts)
+			if fsOpts.QuotaBytes > 0 {
+				if quotaErr := ns.mounter.EnableProjectQuota(devicePath, fsType); quotaErr != nil {
+					return fmt.Errorf("failed to enable project quota: %w", quotaErr)
+				}
+			}
 
-		// Step 3: Mount to staging path
-		mountOptions := []string{}
-		if mnt := req.GetVolumeCapability().GetMount(); mnt != nil {
-			mountOptions = mnt.MountFlags
-		}
+			if mountErr := ns.mounter.Mount(devicePath, stagingPath, fsType, mountOptions); mountErr != nil {
+				return fmt.Errorf("failed to mount device: %w", mountErr)
+			}
+			if fsOpts.QuotaBytes > 0 {
+				if quotaErr := ns.applyVolumeQuota(volumeID, stagingPath, fsOpts.QuotaBytes); quotaErr != nil {
+					return quotaErr
+				}
+			}
 
-		if mountErr := ns.mounter.Mount(devicePath, stagingPath, fsType, mountOptions); mountErr != nil {
-			return fmt.Errorf("failed to mount device: %w", mountErr)
-		}
+			// Step 4: Discard unused blocks on a freshly formatted filesystem (best-effort)
+			// mkfs may leave the thin-provisioned backing file fully allocated; a failed
+			// trim only costs space on RDS, so it must never fail the stage.
+			if fsOpts.InitialTrim && !formatted {
+				if trimErr := ns.mounter.Trim(stagingPath); trimErr != nil {
+					logger.Info("Initial trim failed (continuing)", "stagingPath", stagingPath, "err", trimErr)
+				}
+			}
 
-		return nil
+			return nil
+		})
 	})
 
 	if err != nil {
+		var mismatchErr *mount.FilesystemMismatchError
+		isMismatch := errors.As(err, &mismatchErr)
+		if isMismatch {
+			logger.Error(err, "Refusing to stage volume")
+			if ns.driver.metrics != nil {
+				ns.driver.metrics.RecordStaleMountDetected()
+			}
+		}
 		// Post failure event if this is a circuit breaker or mount error
 		if ns.eventPoster != nil && pvcNamespace != "" && pvcName != "" {
-			_ = ns.eventPoster.PostMountFailure(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID,
-				fmt.Sprintf("stage volume failed: %v", err))
+			if isMismatch {
+				_ = ns.eventPoster.PostFilesystemMismatch(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID,
+					mismatchErr.DevicePath, mismatchErr.Expected, mismatchErr.Actual)
+			} else {
+				_ = ns.eventPoster.PostMountFailure(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID,
+					fmt.Sprintf("stage volume failed: %v", err))
+			}
 		}
 		// Cleanup NVMe connection on failure
 		_ = ns.nvmeConn.Disconnect(nqn)
 		secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeFailure, err, time.Since(startTime))
-		return nil, status.Errorf(codes.Internal, "failed to stage filesystem volume: %v", err)
+		// A short-circuited stage was not attempted; report why and when it will be retried
+		var openErr *circuitbreaker.OpenError
+		if errors.As(err, &openErr) {
+			return nil, status.Error(codes.Unavailable, openErr.Error())
+		}
+		if errors.Is(err, errNoFilesystem) || isMismatch {
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to stage filesystem volume: %v", err)
+		}
+		return nil, status.Errorf(stageErrorCode(err, codes.Internal), "failed to stage filesystem volume: %v", err)
 	}
 
-	klog.V(2).Infof("Successfully staged volume %s to %s", volumeID, stagingPath)
+	ns.readProber.Track(volumeID, nqn)
+	ns.recordVolumeReady(volumeID, req.GetPublishContext(), attach, time.Since(metricsStart))
+	logger.V(2).Info("Successfully staged volume", "stagingPath", stagingPath)
 
 	// Log volume stage success
 	secLogger.LogVolumeStage(volumeID, ns.nodeID, nqn, nvmeAddress, security.OutcomeSuccess, nil, time.Since(startTime))
@@ -354,6 +622,27 @@ func (ns *NodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVol
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
+// stagePhaseBudgets returns the configured split of the NodeStageVolume deadline
+func (ns *NodeServer) stagePhaseBudgets() PhaseBudgets {
+	if ns.driver.stagePhaseBudgets != nil {
+		return ns.driver.stagePhaseBudgets
+	}
+	return DefaultStagePhaseBudgets()
+}
+
+// stageErrorCode returns Unavailable for connects refused by the connect rate limit,
+// DeadlineExceeded for phase timeouts and fallback otherwise
+func stageErrorCode(err error, fallback codes.Code) codes.Code {
+	if errors.Is(err, errConnectRateLimited) {
+		return codes.Unavailable
+	}
+	var timeoutErr *PhaseTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return codes.DeadlineExceeded
+	}
+	return fallback
+}
+
 // NodeUnstageVolume unstages a volume from the staging path
 // This involves:
 // 1. Unmounting the filesystem from the staging path
@@ -370,7 +659,9 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 	volumeID := req.GetVolumeId()
 	stagingPath := req.GetStagingTargetPath()
 
-	klog.V(2).Infof("NodeUnstageVolume called for volume: %s, staging path: %s", volumeID, stagingPath)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("NodeUnstageVolume called", "stagingPath", stagingPath)
 
 	// Validate request
 	if volumeID == "" {
@@ -380,14 +671,17 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 		return nil, status.Error(codes.InvalidArgument, "staging target path is required")
 	}
 
-	// Derive NQN from volume ID for logging
+	// Derive NQN from volume ID, or take it from the block stage metadata of a static volume
 	nqn, err := volumeIDToNQN(volumeID)
 	if err != nil {
 		nqn = "" // Will use empty NQN in logs
+		if metadata, metaErr := readBlockStageMetadata(stagingPath, volumeID); metaErr == nil {
+			nqn = metadata.NQN
+		}
 	}
 
 	// Log volume unstage request
-	secLogger := security.GetLogger()
+	secLogger := requestSecurityLogger(ctx)
 	secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeUnknown, nil, 0)
 
 	startTime := time.Now()
@@ -402,11 +696,11 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 		isBlockVolume = true
 	}
 
-	klog.V(2).Infof("NodeUnstageVolume: volume %s, isBlock=%v", volumeID, isBlockVolume)
+	logger.V(2).Info("Unstaging volume", "block", isBlockVolume)
 
 	if isBlockVolume {
 		// Block volume: no filesystem to unmount, just disconnect NVMe device
-		klog.V(2).Infof("Unstaging block volume %s (NQN: %s)", volumeID, nqn)
+		logger.V(2).Info("Unstaging block volume", "nqn", nqn)
 
 		// Step 1: Clean up orphaned bind mounts BEFORE checking device-in-use
 		// This prevents the device-in-use check from detecting our own bind mounts
@@ -417,9 +711,9 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 				// Find and cleanup orphaned bind mounts to this device
 				cleanedCount, cleanupErr := ns.findAndCleanupOrphanedMounts(ctx, devicePath)
 				if cleanupErr != nil {
-					klog.Warningf("Error cleaning up orphaned mounts for %s: %v (proceeding)", devicePath, cleanupErr)
+					logger.Info("Error cleaning up orphaned mounts (proceeding)", "device", devicePath, "err", cleanupErr)
 				} else if cleanedCount > 0 {
-					klog.Infof("Cleaned up %d orphaned bind mount(s) for device %s before unstaging", cleanedCount, devicePath)
+					logger.Info("Cleaned up orphaned bind mounts before unstaging", "count", cleanedCount, "device", devicePath)
 				}
 
 				// Step 2: NOW check device-in-use (after cleaning up our own mounts)
@@ -428,12 +722,11 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 
 				// Log if we filtered out driver's own PID (helps diagnose false positives)
 				if result.FilteredSelfPIDs > 0 {
-					klog.V(2).Infof("Device-in-use check filtered %d driver self-reference(s) for %s",
-						result.FilteredSelfPIDs, devicePath)
+					logger.V(2).Info("Device-in-use check filtered driver self-references", "count", result.FilteredSelfPIDs, "device", devicePath)
 				}
 
 				if result.TimedOut {
-					klog.Warningf("Device %s busy check timed out, proceeding with disconnect", devicePath)
+					logger.Info("Device busy check timed out, proceeding with disconnect", "device", devicePath)
 				} else if result.InUse {
 					// Device still in use after cleaning up bind mounts
 					// This means actual processes have it open
@@ -443,12 +736,12 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 					select {
 					case <-ctx.Done():
 						// Context cancelled - driver is shutting down
-						klog.Warningf("Device %s in use but driver shutting down, forcing cleanup to prevent node wedge. Processes: %v",
-							devicePath, result.Processes)
+						logger.Info("Device in use but driver shutting down, forcing cleanup to prevent node wedge",
+							"device", devicePath, "processes", result.Processes)
 						// Proceed with cleanup
 					default:
 						// Not shutting down - this is a normal unstage, don't force it
-						klog.Errorf("Device %s in use by external processes: %v", devicePath, result.Processes)
+						logger.Error(nil, "Device in use by external processes", "device", devicePath, "processes", result.Processes)
 						secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeFailure,
 							fmt.Errorf("device in use"), time.Since(startTime))
 						return nil, status.Errorf(codes.FailedPrecondition,
@@ -457,26 +750,40 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 							devicePath, result.Processes)
 					}
 				} else if result.Error != nil {
-					klog.Warningf("Device busy check failed for %s: %v (proceeding)", devicePath, result.Error)
+					logger.Info("Device busy check failed (proceeding)", "device", devicePath, "err", result.Error)
 				}
 			} else {
-				klog.Warningf("Failed to find device for NQN %s: %v (proceeding with disconnect)", nqn, err)
+				logger.Info("Failed to find device (proceeding with disconnect)", "nqn", nqn, "err", err)
 			}
 		}
 
-		// No staging directory or files to clean up for block volumes
+		// Block volumes leave at most the stage metadata file at the staging path
+		if err := removeBlockStageMetadata(stagingPath); err != nil {
+			logger.Info("Failed to remove block stage metadata", "err", err)
+		}
 		// Proceed to NVMe disconnect (below)
 	} else {
 		// Filesystem volume: existing unmount logic
 
+		// With --drain-before-disconnect, write back what a crashed pod left dirty and let
+		// the device finish its I/O while the filesystem is still mounted
+		if ns.driver.drainBeforeDisconnect && nqn != "" {
+			if devicePath, devErr := ns.nvmeConn.GetDevicePath(nqn); devErr != nil {
+				logger.V(2).Info("Not draining volume without a device", "nqn", nqn, "err", devErr)
+			} else if err := ns.drainDevice(ctx, stagingPath, devicePath); err != nil {
+				secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeFailure, err, time.Since(startTime))
+				return nil, status.Errorf(codes.Internal, "failed to drain volume %s before disconnect: %v", volumeID, err)
+			}
+		}
+
 		// Step 1: Unmount from staging path
-		if err := ns.mounter.Unmount(stagingPath); err != nil {
+		if err := ns.unmountRetryBusy(ctx, stagingPath); err != nil {
 			// Log volume unstage failure
 			secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeFailure, err, time.Since(startTime))
 			return nil, status.Errorf(codes.Internal, "failed to unmount staging path: %v", err)
 		}
 
-		klog.V(2).Infof("Unmounted volume %s from %s", volumeID, stagingPath)
+		logger.V(2).Info("Unmounted volume", "stagingPath", stagingPath)
 
 		// SAFETY-04: Check device-in-use before NVMe disconnect (filesystem volume path)
 		// This prevents data corruption if processes still have the device open
@@ -491,7 +798,7 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 				// 1. Device was already disconnected (idempotent unstage)
 				// 2. Connection was lost (device unreachable)
 				// In both cases, proceed with disconnect attempt (which will be a no-op or cleanup)
-				klog.V(4).Infof("Could not get device path for NQN %s: %v (device may already be disconnected, proceeding)", nqn, devErr)
+				logger.V(4).Info("Could not get device path, device may already be disconnected (proceeding)", "nqn", nqn, "err", devErr)
 			} else {
 				// Device path found - check if it's in use before disconnecting
 				// Use retry logic to avoid transient false positives from momentary FD operations
@@ -499,18 +806,16 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 
 				// Log if we filtered out driver's own PID (helps diagnose false positives)
 				if result.FilteredSelfPIDs > 0 {
-					klog.V(2).Infof("Device-in-use check filtered %d driver self-reference(s) for %s",
-						result.FilteredSelfPIDs, devicePath)
+					logger.V(2).Info("Device-in-use check filtered driver self-references", "count", result.FilteredSelfPIDs, "device", devicePath)
 				}
 
 				if result.TimedOut {
 					// Device check timed out - device may be unresponsive
 					// Log warning and proceed with disconnect (device likely dead anyway)
-					klog.Warningf("Device %s busy check timed out, proceeding with disconnect (device may be unresponsive)",
-						devicePath)
+					logger.Info("Device busy check timed out, proceeding with disconnect (device may be unresponsive)", "device", devicePath)
 				} else if result.InUse {
 					// Device has open file descriptors - unsafe to disconnect
-					klog.Errorf("Device %s in use by external processes: %v", devicePath, result.Processes)
+					logger.Error(nil, "Device in use by external processes", "device", devicePath, "processes", result.Processes)
 
 					// Log failure
 					secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeFailure,
@@ -522,34 +827,49 @@ func (ns *NodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstag
 						devicePath, result.Processes)
 				} else if result.Error != nil {
 					// Check failed but not critical - log and proceed
-					klog.Warningf("Device busy check failed for %s: %v (proceeding with disconnect)",
-						devicePath, result.Error)
+					logger.Info("Device busy check failed (proceeding with disconnect)", "device", devicePath, "err", result.Error)
 				}
 				// If InUse=false and no error, proceed normally
 			}
 		}
 	}
 
+	// Leftovers at the staging path (a symlink, stale files) would fail kubelet's removal
+	// of it; the directory itself is kubelet's to remove
+	if err := ns.removeLeftovers(ctx, stagingPath, ns.stagingRoot(), true); err != nil {
+		secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeFailure, err, time.Since(startTime))
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "failed to clean up staging path: %v", err)
+	}
+
 	// Step 2: Disconnect from NVMe/TCP target
 	// Derive NQN from volume ID (same as what was used during CreateVolume)
 	if nqn == "" {
 		nqn, err = volumeIDToNQN(volumeID)
 		if err != nil {
 			// Log but don't fail - volume might have been disconnected already
-			klog.Warningf("Failed to derive NQN from volume ID %s: %v", volumeID, err)
+			logger.Info("Failed to derive NQN from volume ID", "err", err)
 		}
 	}
 
 	if nqn != "" {
 		if err := ns.nvmeConn.Disconnect(nqn); err != nil {
 			// Log but don't fail - disconnection issues shouldn't block unstaging
-			klog.Warningf("Failed to disconnect NVMe device for volume %s: %v", volumeID, err)
+			logger.Info("Failed to disconnect NVMe device", "err", err)
 		} else {
-			klog.V(2).Infof("Disconnected NVMe device for volume %s (NQN: %s)", volumeID, nqn)
+			logger.V(2).Info("Disconnected NVMe device", "nqn", nqn)
+		}
+		// The namespace is no longer expected here; NodeStageVolume registers it again
+		if resolver := ns.nvmeConn.GetResolver(); resolver != nil {
+			resolver.SetExpectedWWID(nqn, "")
 		}
 	}
 
-	klog.V(2).Infof("Successfully unstaged volume %s", volumeID)
+	ns.readProber.Untrack(volumeID)
+	ns.quotaStats.forget(volumeID)
+	logger.V(2).Info("Successfully unstaged volume")
 
 	// Log volume unstage success
 	secLogger.LogVolumeUnstage(volumeID, ns.nodeID, nqn, security.OutcomeSuccess, nil, time.Since(startTime))
@@ -564,7 +884,9 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	stagingPath := req.GetStagingTargetPath()
 	targetPath := req.GetTargetPath()
 
-	klog.V(2).Infof("NodePublishVolume called for volume: %s, target path: %s", volumeID, targetPath)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("NodePublishVolume called", "targetPath", targetPath)
 
 	// Validate request
 	if volumeID == "" {
@@ -580,23 +902,48 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 		return nil, status.Error(codes.InvalidArgument, "volume capability is required")
 	}
 
+	// Fill in keys that PVs of early driver versions lack
+	volumeContext, err := ns.resolveVolumeContext(volumeID, req.GetVolumeContext(), publishContextKeys)
+	if err != nil {
+		return nil, err
+	}
+
 	// Detect volume mode early
 	isBlockVolume := req.GetVolumeCapability().GetBlock() != nil
 
 	if isBlockVolume {
 		// Block volume: find NVMe device by NQN and bind mount to target file
 
-		// Get NQN from volume context or derive from volume ID
-		volumeContext := req.GetVolumeContext()
+		// Get NQN from volume context or derive from volume ID, falling back to the
+		// metadata NodeStageVolume wrote with --block-stage-metadata
 		nqn := volumeContext[volumeContextNQN]
 		if nqn == "" {
 			var err error
 			nqn, err = volumeIDToNQN(volumeID)
 			if err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to derive NQN from volume ID: %v", err)
+				metadata, metaErr := readBlockStageMetadata(stagingPath, volumeID)
+				if metaErr != nil {
+					return nil, status.Errorf(codes.Internal,
+						"failed to derive NQN from volume ID: %v (no block stage metadata: %v)", err, metaErr)
+				}
+				logger.V(2).Info("Using NQN from block stage metadata", "nqn", metadata.NQN)
+				nqn = metadata.NQN
+			}
+		}
+
+		// Re-register the expected namespace in case the plugin restarted since NodeStageVolume
+		if wwid := volumeContext[volumeContextWWID]; wwid != "" && utils.ValidateWWID(wwid) == nil {
+			if resolver := ns.nvmeConn.GetResolver(); resolver != nil {
+				resolver.SetExpectedWWID(nqn, wwid)
 			}
 		}
 
+		// Validated by CreateVolume; a bad value here means an edited PV
+		blockOpts, err := ParseBlockDeviceOptions(volumeContext)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+
 		// Find device path by NQN (device was connected in NodeStageVolume)
 		devicePath, err := ns.nvmeConn.GetDevicePath(nqn)
 		if err != nil {
@@ -610,11 +957,10 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 			return nil, status.Errorf(codes.Internal, "block device not found: %s", devicePath)
 		}
 
-		klog.V(2).Infof("Publishing block volume %s: NQN %s, device %s -> target %s",
-			volumeID, nqn, devicePath, targetPath)
+		logger.V(2).Info("Publishing block volume", "nqn", nqn, "device", devicePath, "targetPath", targetPath)
 
 		// Log volume publish request
-		secLogger := security.GetLogger()
+		secLogger := requestSecurityLogger(ctx)
 		secLogger.LogVolumePublish(volumeID, ns.nodeID, targetPath, security.OutcomeUnknown, nil, 0)
 		startTime := time.Now()
 
@@ -634,25 +980,26 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 
 		// Check if device node already exists (idempotency)
 		if _, err := os.Stat(targetPath); err == nil {
-			klog.V(4).Infof("Device node %s already exists, assuming idempotent retry", targetPath)
+			logger.V(4).Info("Device node already exists, assuming idempotent retry", "targetPath", targetPath)
 		} else {
 			// Create device node using mknod (avoids devtmpfs bind mount storm)
 			// This creates a block device node with the same major:minor as the source device
-			mode := uint32(syscall.S_IFBLK | 0660)
-			if req.GetReadonly() {
-				mode = uint32(syscall.S_IFBLK | 0440)
-			}
-
-			if err := syscall.Mknod(targetPath, mode, int(stat.Rdev)); err != nil {
+			mode := blockOpts.ModeFor(req.GetReadonly())
+			if err := syscall.Mknod(targetPath, syscall.S_IFBLK|uint32(mode), int(stat.Rdev)); err != nil {
 				secLogger.LogVolumePublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
 				return nil, status.Errorf(codes.Internal, "failed to create device node via mknod: %v", err)
 			}
+			if err := setDeviceNodePermissions(targetPath, mode, blockOpts); err != nil {
+				_ = os.Remove(targetPath)
+				secLogger.LogVolumePublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
+				return nil, status.Errorf(codes.Internal, "failed to set permissions of device node: %v", err)
+			}
 
-			klog.V(2).Infof("Created block device node at %s (major:minor %d:%d)",
-				targetPath, unix.Major(uint64(stat.Rdev)), unix.Minor(uint64(stat.Rdev)))
+			logger.V(2).Info("Created block device node", "targetPath", targetPath,
+				"major", unix.Major(uint64(stat.Rdev)), "minor", unix.Minor(uint64(stat.Rdev)), "mode", fmt.Sprintf("%04o", uint32(mode)))
 		}
 
-		klog.V(2).Infof("Successfully published block volume %s to %s", volumeID, targetPath)
+		logger.V(2).Info("Successfully published block volume", "targetPath", targetPath)
 		secLogger.LogVolumePublish(volumeID, ns.nodeID, targetPath, security.OutcomeSuccess, nil, time.Since(startTime))
 		return &csi.NodePublishVolumeResponse{}, nil
 	}
@@ -671,7 +1018,6 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 
 	// Check for stale mount and attempt recovery
 	// Extract NQN from volume context or derive from volumeID
-	volumeContext := req.GetVolumeContext()
 	nqn := volumeContext[volumeContextNQN]
 	if nqn == "" {
 		nqn, _ = volumeIDToNQN(volumeID)
@@ -682,16 +1028,18 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 			fsType = mnt.FsType
 		}
 		// Get mount options for recovery (base options, not bind options)
-		stagingMountOptions := []string{}
-		if mnt := req.GetVolumeCapability().GetMount(); mnt != nil {
-			stagingMountOptions = mnt.MountFlags
-		}
+		// The options were validated at stage time, so parse errors fall back to defaults
+		fsOpts, _ := ParseFilesystemOptions(volumeContext)
+		stagingMountOptions := buildStagingMountOptions(req.GetVolumeCapability(), fsType, fsOpts)
 
 		// Extract PVC info from volume context if available
 		pvcNamespace := volumeContext["csi.storage.k8s.io/pvc/namespace"]
 		pvcName := volumeContext["csi.storage.k8s.io/pvc/name"]
 
-		if err := ns.checkAndRecoverMount(ctx, stagingPath, nqn, fsType, stagingMountOptions, pvcNamespace, pvcName, volumeID); err != nil {
+		if err := ns.checkAndRecoverMount(ctx, stagingPath, nqn, fsType, stagingMountOptions, pvcNamespace, pvcName, volumeID, ns.expectedFilesystemUUID(volumeID, volumeContext)); err != nil {
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
 			return nil, status.Errorf(codes.Internal, "stale mount recovery failed: %v", err)
 		}
 	}
@@ -708,7 +1056,7 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	}
 
 	// Log volume publish request
-	secLogger := security.GetLogger()
+	secLogger := requestSecurityLogger(ctx)
 	secLogger.LogVolumePublish(volumeID, ns.nodeID, targetPath, security.OutcomeUnknown, nil, 0)
 
 	startTime := time.Now()
@@ -720,7 +1068,7 @@ func (ns *NodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 		return nil, status.Errorf(codes.Internal, "failed to bind mount: %v", err)
 	}
 
-	klog.V(2).Infof("Successfully published volume %s to %s", volumeID, targetPath)
+	logger.V(2).Info("Successfully published volume", "targetPath", targetPath)
 
 	// Log volume publish success
 	secLogger.LogVolumePublish(volumeID, ns.nodeID, targetPath, security.OutcomeSuccess, nil, time.Since(startTime))
@@ -733,7 +1081,9 @@ func (ns *NodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpu
 	volumeID := req.GetVolumeId()
 	targetPath := req.GetTargetPath()
 
-	klog.V(2).Infof("NodeUnpublishVolume called for volume: %s, target path: %s", volumeID, targetPath)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("NodeUnpublishVolume called", "targetPath", targetPath)
 
 	// Validate request
 	if volumeID == "" {
@@ -744,48 +1094,85 @@ func (ns *NodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpu
 	}
 
 	// Log volume unpublish request
-	secLogger := security.GetLogger()
+	secLogger := requestSecurityLogger(ctx)
 	secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeUnknown, nil, 0)
 
 	startTime := time.Now()
 
-	// Check if target is a block device (mknod approach) or mount (filesystem)
+	// A target kubelet left as a symlink is removed without following it, so nothing is
+	// unmounted or deleted wherever it points
+	if info, err := os.Lstat(targetPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := ns.removeLeftovers(ctx, targetPath, ns.podsRoot(), false); err != nil {
+			secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
+			return nil, status.Errorf(codes.Internal, "failed to remove symlinked target path: %v", err)
+		}
+		secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeSuccess, nil, time.Since(startTime))
+		return &csi.NodeUnpublishVolumeResponse{}, nil
+	}
+
+	// Check what is actually at the target rather than trusting the volume's access type:
+	// a publish that crashed mid-mknod or mid-bind-mount can leave a directory where a
+	// device node belongs or vice versa, and a mount whose device vanished fails stat
+	statTarget := ns.statFunc
+	if statTarget == nil {
+		statTarget = syscall.Stat
+	}
 	var stat syscall.Stat_t
-	if err := syscall.Stat(targetPath, &stat); err != nil {
+	if err := statTarget(targetPath, &stat); err != nil {
 		if os.IsNotExist(err) {
 			// Already cleaned up - idempotent
-			klog.V(4).Infof("Target path %s does not exist, assuming already unpublished", targetPath)
+			logger.V(4).Info("Target path does not exist, assuming already unpublished", "targetPath", targetPath)
 			secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeSuccess, nil, time.Since(startTime))
 			return &csi.NodeUnpublishVolumeResponse{}, nil
 		}
-		secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
-		return nil, status.Errorf(codes.Internal, "failed to stat target path: %v", err)
-	}
+		if !isCorruptedMountError(err) {
+			secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
+			return nil, status.Errorf(codes.Internal, "failed to stat target path: %v", err)
+		}
 
-	// Block device created with mknod - just remove the device node file
-	if stat.Mode&syscall.S_IFMT == syscall.S_IFBLK {
-		klog.V(4).Infof("Target %s is a block device node, removing via unlink", targetPath)
+		// Dangling mount (device gone): unmount without touching the filesystem
+		logger.Info("Target is a corrupted mount, force unmounting", "targetPath", targetPath, "err", err)
+		if err := ns.mounter.ForceUnmount(targetPath, corruptedTargetUnmountTimeout); err != nil {
+			secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
+			return nil, status.Errorf(codes.Internal, "failed to unmount corrupted target path: %v", err)
+		}
+	} else if stat.Mode&syscall.S_IFMT == syscall.S_IFBLK {
+		// Block device created with mknod - remove the device node file. Older publishes
+		// bind-mounted the device over a file, which must be unmounted before unlink.
+		if mounted, _ := ns.mounter.IsLikelyMountPoint(targetPath); mounted {
+			logger.V(4).Info("Target is a bind-mounted block device, unmounting", "targetPath", targetPath)
+			if err := ns.unmountRetryBusy(ctx, targetPath); err != nil {
+				secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
+				return nil, status.Errorf(codes.Internal, "failed to unmount block target path: %v", err)
+			}
+		}
+		logger.V(4).Info("Target is a block device node, removing via unlink", "targetPath", targetPath)
 		if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
 			secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
 			return nil, status.Errorf(codes.Internal, "failed to remove block device node: %v", err)
 		}
 	} else {
-		// Filesystem volume - unmount as usual
-		klog.V(4).Infof("Target %s is a mount point, unmounting", targetPath)
-		if err := ns.mounter.Unmount(targetPath); err != nil {
+		// Filesystem mount, or a plain directory or file left by an interrupted publish.
+		// Unmount is a no-op when nothing is mounted.
+		logger.V(4).Info("Target is a mount point or leftover, unmounting", "targetPath", targetPath, "kind", targetKind(stat.Mode))
+		if err := ns.unmountRetryBusy(ctx, targetPath); err != nil {
 			secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
 			return nil, status.Errorf(codes.Internal, "failed to unmount target path: %v", err)
 		}
 	}
 
-	klog.V(2).Infof("Successfully unpublished volume %s from %s", volumeID, targetPath)
+	logger.V(2).Info("Successfully unpublished volume", "targetPath", targetPath)
 
 	// Clean up target path after unmount
-	// For block volumes, target is a file; for filesystem volumes, target is a directory
-	// Use os.RemoveAll which handles both cases
-	if err := os.RemoveAll(targetPath); err != nil {
-		// Log but don't fail - unmount succeeded, cleanup is best-effort
-		klog.Warningf("Failed to remove target path %s: %v", targetPath, err)
+	// For block volumes, target is a file; for filesystem volumes, target is a directory.
+	// Stale files left in it are only removed below the kubelet pods directory and if
+	// nothing is mounted there any more.
+	if err := ns.removeLeftovers(ctx, targetPath, ns.podsRoot(), false); err != nil {
+		secLogger.LogVolumeUnpublish(volumeID, ns.nodeID, targetPath, security.OutcomeFailure, err, time.Since(startTime))
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "failed to remove target path: %v", err)
 	}
 
 	// Log volume unpublish success
@@ -794,12 +1181,43 @@ func (ns *NodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpu
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
+// corruptedTargetUnmountTimeout bounds the normal unmount attempt on a dangling publish
+// mount before ForceUnmount escalates to a lazy unmount
+const corruptedTargetUnmountTimeout = 10 * time.Second
+
+// isCorruptedMountError reports whether a stat error means the path is a mount whose
+// backing device or connection is gone, as opposed to a path that cannot be accessed
+func isCorruptedMountError(err error) bool {
+	return errors.Is(err, syscall.ENOTCONN) ||
+		errors.Is(err, syscall.ESTALE) ||
+		errors.Is(err, syscall.EIO) ||
+		errors.Is(err, syscall.EHOSTDOWN) ||
+		errors.Is(err, syscall.ENXIO) ||
+		errors.Is(err, syscall.ENODEV)
+}
+
+// targetKind names the file type of a publish target for logging
+func targetKind(mode uint32) string {
+	switch mode & syscall.S_IFMT {
+	case syscall.S_IFDIR:
+		return "directory"
+	case syscall.S_IFREG:
+		return "file"
+	case syscall.S_IFCHR:
+		return "character device"
+	default:
+		return fmt.Sprintf("file type %o", mode&syscall.S_IFMT)
+	}
+}
+
 // NodeGetVolumeStats returns volume usage statistics
 func (ns *NodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
 	volumeID := req.GetVolumeId()
 	volumePath := req.GetVolumePath()
 
-	klog.V(4).Infof("NodeGetVolumeStats called for volume: %s, path: %s", volumeID, volumePath)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("NodeGetVolumeStats called", "volumePath", volumePath)
 
 	// Validate request
 	if volumeID == "" {
@@ -822,10 +1240,34 @@ func (ns *NodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVo
 	// Check for stale mount if we can derive NQN
 	// For stats, we just need to verify mount is healthy
 	nqn, err := volumeIDToNQN(volumeID)
+	if err == nil {
+		// Volumes staged before a restart are picked up by the read probe here
+		ns.readProber.Track(volumeID, nqn)
+	}
+
+	// A subsystem that was disconnected (e.g. administratively) leaves no device for the
+	// stale checker to resolve, which would otherwise count as inconclusive and healthy.
+	// Volumes whose NQN cannot be derived keep the healthy default.
+	if err == nil && ns.nvmeConn != nil {
+		connected, connErr := ns.nvmeConn.IsConnectedWithContext(ctx, nqn)
+		if connErr != nil {
+			logger.V(4).Info("Could not check NVMe connection", "err", connErr)
+		} else if !connected {
+			logger.Info("NVMe subsystem of volume is not connected", "nqn", nqn, "volumePath", volumePath)
+			return &csi.NodeGetVolumeStatsResponse{
+				Usage: []*csi.VolumeUsage{},
+				VolumeCondition: &csi.VolumeCondition{
+					Abnormal: true,
+					Message:  "NVMe subsystem not connected",
+				},
+			}, nil
+		}
+	}
+
 	if err == nil && ns.staleChecker != nil {
 		stale, reason, checkErr := ns.staleChecker.IsMountStale(volumePath, nqn)
 		if checkErr != nil {
-			klog.V(4).Infof("Could not check mount staleness: %v", checkErr)
+			logger.V(4).Info("Could not check mount staleness", "err", checkErr)
 			// Health check inconclusive - report as healthy with note
 			volumeCondition = &csi.VolumeCondition{
 				Abnormal: false,
@@ -834,7 +1276,7 @@ func (ns *NodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVo
 		} else if stale {
 			// For GetVolumeStats, we report unhealthy rather than attempting recovery
 			// Recovery should happen in NodePublishVolume when pod accesses volume
-			klog.Warningf("Stale mount detected for volume %s at %s (reason: %s)", volumeID, volumePath, reason)
+			logger.Info("Stale mount detected", "volumePath", volumePath, "reason", reason)
 			// Record stale mount metric
 			if ns.driver.metrics != nil {
 				ns.driver.metrics.RecordStaleMountDetected()
@@ -876,6 +1318,37 @@ func (ns *NodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVo
 		return nil, status.Errorf(codes.Internal, "failed to get volume stats: %v", err)
 	}
 
+	if ns.driver.enableProjectQuota {
+		ns.applyQuotaToStats(volumeID, volumePath, stats)
+	}
+
+	// statfs keeps working on a filesystem the kernel remounted read-only after
+	// IO errors, so check the error state GetDeviceStats read alongside usage
+	if stats.ReadOnlyRemount {
+		logger.Info("Volume was remounted read-only after errors", "volumePath", volumePath, "ext4Errors", stats.FilesystemErrors)
+		if ns.driver.metrics != nil {
+			ns.driver.metrics.RecordFilesystemErrorDetected(observability.FilesystemErrorReadOnlyRemount)
+		}
+		volumeCondition = &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  "filesystem remounted read-only after errors",
+		}
+	} else if stats.FilesystemErrors > 0 {
+		logger.Info("Volume has ext4 errors recorded", "volumePath", volumePath, "ext4Errors", stats.FilesystemErrors)
+		if ns.driver.metrics != nil {
+			ns.driver.metrics.RecordFilesystemErrorDetected(observability.FilesystemErrorExt4Errors)
+		}
+		volumeCondition = &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  fmt.Sprintf("filesystem has recorded %d errors", stats.FilesystemErrors),
+		}
+	} else if probeErr := ns.readProber.Condition(volumeID); probeErr != nil {
+		volumeCondition = &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  fmt.Sprintf("read probe failed: %v", probeErr),
+		}
+	}
+
 	return &csi.NodeGetVolumeStatsResponse{
 		Usage: []*csi.VolumeUsage{
 			{
@@ -925,7 +1398,8 @@ func (ns *NodeServer) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoReque
 // findAndCleanupOrphanedMounts finds all bind mounts pointing to a device and unmounts them
 // Returns the number of mounts cleaned up
 func (ns *NodeServer) findAndCleanupOrphanedMounts(ctx context.Context, devicePath string) (int, error) {
-	klog.V(4).Infof("Searching for orphaned bind mounts to device %s", devicePath)
+	logger := klog.FromContext(ctx)
+	logger.V(4).Info("Searching for orphaned bind mounts", "device", devicePath)
 
 	// Get all mounts with timeout to prevent hanging
 	// Uses package-level function from mount package
@@ -939,32 +1413,37 @@ func (ns *NodeServer) findAndCleanupOrphanedMounts(ctx context.Context, devicePa
 		// Check if this mount's source is our device
 		// mnt.Source is the source device for the mount
 		if mnt.Source == devicePath {
-			klog.V(2).Infof("Found orphaned bind mount: %s -> %s", devicePath, mnt.Mountpoint)
+			logger.V(2).Info("Found orphaned bind mount", "device", devicePath, "mountpoint", mnt.Mountpoint)
 
 			// Unmount it (force if needed)
 			if err := ns.mounter.Unmount(mnt.Mountpoint); err != nil {
-				klog.Warningf("Failed to unmount orphaned mount %s: %v", mnt.Mountpoint, err)
+				logger.Info("Failed to unmount orphaned mount", "mountpoint", mnt.Mountpoint, "err", err)
 				// Continue trying other mounts
 			} else {
-				klog.V(2).Infof("Successfully cleaned up orphaned mount %s", mnt.Mountpoint)
+				logger.V(2).Info("Successfully cleaned up orphaned mount", "mountpoint", mnt.Mountpoint)
 				cleanedCount++
 			}
 		}
 	}
 
 	if cleanedCount > 0 {
-		klog.Infof("Cleaned up %d orphaned bind mount(s) for device %s", cleanedCount, devicePath)
+		logger.Info("Cleaned up orphaned bind mounts", "count", cleanedCount, "device", devicePath)
 	}
 
 	return cleanedCount, nil
 }
 
-// NodeExpandVolume expands the filesystem on the node after volume expansion
+// NodeExpandVolume expands the filesystem on the node after volume expansion.
+// For block volumes it rescans the device and confirms the new size instead.
+// Either way, kubelet then records the new size on the PVC, from which the controller
+// clears the pending node resize it recorded.
 func (ns *NodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
 	volumeID := req.GetVolumeId()
 	volumePath := req.GetVolumePath()
 
-	klog.V(2).Infof("NodeExpandVolume called for volume: %s, path: %s", volumeID, volumePath)
+	logger := klog.FromContext(ctx).WithValues("volumeID", volumeID)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("NodeExpandVolume called", "volumePath", volumePath)
 
 	// Validate request
 	if volumeID == "" {
@@ -974,11 +1453,20 @@ func (ns *NodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandV
 		return nil, status.Error(codes.InvalidArgument, "volume path is required")
 	}
 
+	isBlock := req.GetVolumeCapability().GetBlock() != nil
+
 	// Check if volume path is mounted
 	// Per CSI spec, should return NotFound if volume doesn't exist
-	mounted, err := ns.mounter.IsLikelyMountPoint(volumePath)
-	if err != nil || !mounted {
-		return nil, status.Errorf(codes.NotFound, "volume path %s not found or not mounted", volumePath)
+	// Block volumes are published as a device file, so only check existence
+	if isBlock {
+		if _, err := os.Stat(volumePath); err != nil {
+			return nil, status.Errorf(codes.NotFound, "volume path %s not found", volumePath)
+		}
+	} else {
+		mounted, err := ns.mounter.IsLikelyMountPoint(volumePath)
+		if err != nil || !mounted {
+			return nil, status.Errorf(codes.NotFound, "volume path %s not found or not mounted", volumePath)
+		}
 	}
 
 	// Derive NQN from volume ID to get device path
@@ -993,17 +1481,36 @@ func (ns *NodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandV
 		return nil, status.Errorf(codes.Internal, "failed to get device path: %v", err)
 	}
 
-	klog.V(2).Infof("Expanding filesystem on device %s for volume %s", devicePath, volumeID)
-
-	// Resize the filesystem to use the expanded device
-	if err := ns.mounter.ResizeFilesystem(devicePath, volumePath); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to resize filesystem: %v", err)
-	}
-
 	// Get updated capacity
 	capacityBytes := req.GetCapacityRange().GetRequiredBytes()
 
-	klog.V(2).Infof("Successfully expanded volume %s filesystem to %d bytes", volumeID, capacityBytes)
+	if isBlock {
+		// No filesystem to grow - the device itself must show the new size
+		deviceBytes, err := ns.deviceSizeFunc(devicePath)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read size of device %s: %v", devicePath, err)
+		}
+		if deviceBytes < capacityBytes {
+			// Internal so that kubelet retries once the namespace rescan lands
+			return nil, status.Errorf(codes.Internal, "device %s reports %d bytes, expected at least %d bytes",
+				devicePath, deviceBytes, capacityBytes)
+		}
+		capacityBytes = deviceBytes
+		logger.V(2).Info("Block device reports expanded size", "device", devicePath, "bytes", deviceBytes)
+	} else {
+		logger.V(2).Info("Expanding filesystem", "device", devicePath)
+
+		// Resize the filesystem to use the expanded device
+		if err := ns.mounter.ResizeFilesystem(devicePath, volumePath); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to resize filesystem: %v", err)
+		}
+
+		logger.V(2).Info("Successfully expanded filesystem", "bytes", capacityBytes)
+	}
+
+	if ns.driver.metrics != nil {
+		ns.driver.metrics.RecordVolumeExpansion(observability.ExpansionPhaseCompleted)
+	}
 
 	return &csi.NodeExpandVolumeResponse{
 		CapacityBytes: capacityBytes,
@@ -1014,17 +1521,22 @@ func (ns *NodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandV
 
 // checkAndRecoverMount checks if staging mount is stale and attempts recovery
 // Returns nil if mount is healthy or recovery succeeded
-// Returns error if mount is stale and recovery failed
-func (ns *NodeServer) checkAndRecoverMount(ctx context.Context, stagingPath, nqn, fsType string, mountOptions []string, pvcNamespace, pvcName, volumeID string) error {
+// Returns error if mount is stale and recovery failed. If the staging mount is backed by
+// another volume's device (a staging path reused after the PVC was re-created), or the
+// device the NQN resolves to does not carry the filesystem fsUUID, the error is
+// FailedPrecondition so kubelet stages the current volume again before retrying.
+func (ns *NodeServer) checkAndRecoverMount(ctx context.Context, stagingPath, nqn, fsType string, mountOptions []string, pvcNamespace, pvcName, volumeID, fsUUID string) error {
 	// Skip stale mount check if staleChecker is not initialized (e.g., in tests)
 	if ns.staleChecker == nil {
 		return nil
 	}
 
+	logger := klog.FromContext(ctx)
+
 	// Check for stale mount with detailed info for event posting
 	staleInfo, err := ns.staleChecker.GetStaleInfo(stagingPath, nqn)
 	if err != nil {
-		klog.Warningf("Failed to check mount staleness for %s: %v", stagingPath, err)
+		logger.Info("Failed to check mount staleness", "stagingPath", stagingPath, "err", err)
 		// Don't fail the operation if we can't check - proceed optimistically
 		return nil
 	}
@@ -1033,30 +1545,101 @@ func (ns *NodeServer) checkAndRecoverMount(ctx context.Context, stagingPath, nqn
 		return nil
 	}
 
-	klog.Warningf("Stale mount detected at %s (reason: %s), attempting recovery", stagingPath, staleInfo.Reason)
+	logger.Info("Stale mount detected, attempting recovery", "stagingPath", stagingPath, "reason", staleInfo.Reason)
 
 	// Post stale mount detection event (ignore error - event posting is best effort)
 	if ns.eventPoster != nil && pvcNamespace != "" && pvcName != "" {
 		_ = ns.eventPoster.PostStaleMountDetected(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID, staleInfo.MountDevice, staleInfo.CurrentDevice)
 	}
 
-	// Attempt recovery
-	result, err := ns.recoverer.Recover(ctx, stagingPath, nqn, fsType, mountOptions)
+	// Attempt recovery, without read probes of the device being replaced
+	defer ns.readProber.BeginRecovery(volumeID)()
+	result, err := ns.recoverer.Recover(ctx, stagingPath, nqn, fsType, mountOptions, fsUUID)
 	if err != nil {
+		var mismatchErr *mount.FilesystemMismatchError
+		if errors.As(err, &mismatchErr) {
+			logger.Error(err, "Refusing to recover mount")
+			if ns.driver.metrics != nil {
+				ns.driver.metrics.RecordStaleMountDetected()
+			}
+			if ns.eventPoster != nil && pvcNamespace != "" && pvcName != "" {
+				_ = ns.eventPoster.PostFilesystemMismatch(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID,
+					mismatchErr.DevicePath, mismatchErr.Expected, mismatchErr.Actual)
+			}
+			return status.Errorf(codes.FailedPrecondition, "stale mount recovery failed: %v", err)
+		}
 		// Recovery failed - post event and return error (ignore event error - best effort)
 		if ns.eventPoster != nil {
 			_ = ns.eventPoster.PostRecoveryFailed(ctx, pvcNamespace, pvcName, volumeID, ns.nodeID, result.Attempts, err)
 		}
+		if staleInfo.Reason == mount.StaleReasonNQNMismatch {
+			return status.Errorf(codes.FailedPrecondition,
+				"staging path %s is backed by device %s of NQN %s, not the requested NQN %s, and recovery failed: %v",
+				stagingPath, staleInfo.MountDevice, staleInfo.MountNQN, nqn, err)
+		}
 		return fmt.Errorf("mount recovery failed: %w", err)
 	}
 
-	klog.V(2).Infof("Mount recovery succeeded for %s (attempts: %d, device: %s -> %s)",
-		stagingPath, result.Attempts, result.OldDevice, result.NewDevice)
+	logger.V(2).Info("Mount recovery succeeded", "stagingPath", stagingPath, "attempts", result.Attempts,
+		"oldDevice", result.OldDevice, "newDevice", result.NewDevice)
 
 	return nil
 }
 
+// setDeviceNodePermissions sets the mode of a device node created by mknod, which the
+// process umask may have narrowed, and its owner if blockDeviceOwner is set
+func setDeviceNodePermissions(path string, mode os.FileMode, opts BlockDeviceOptions) error {
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+	if opts.UID >= 0 {
+		return os.Lchown(path, opts.UID, opts.GID)
+	}
+	return nil
+}
+
 // volumeIDToNQN converts a volume ID to an NVMe Qualified Name
 func volumeIDToNQN(volumeID string) (string, error) {
 	return utils.VolumeIDToNQN(volumeID)
 }
+
+// buildStagingMountOptions returns the options for mounting the device at the
+// staging path: the capability's mount flags plus any implied by StorageClass
+// filesystem options
+func buildStagingMountOptions(volCap *csi.VolumeCapability, fsType string, fsOpts FilesystemOptions) []string {
+	mountOptions := []string{}
+	if mnt := volCap.GetMount(); mnt != nil {
+		mountOptions = append(mountOptions, mnt.MountFlags...)
+	}
+	if fsOpts.Discard {
+		mountOptions = append(mountOptions, "discard")
+	}
+	// XFS only enforces project quotas it was mounted with; ext4 keeps them in its features
+	if fsOpts.QuotaBytes > 0 && fsType == "xfs" {
+		mountOptions = append(mountOptions, "prjquota")
+	}
+	return mountOptions
+}
+
+// rescanBlockDeviceSize asks the NVMe controller to rescan its namespaces, then
+// returns the device size from sysfs. The rescan is best effort: the kernel also
+// picks up namespace size changes from the target's async event notification.
+func rescanBlockDeviceSize(devicePath string) (int64, error) {
+	devName := filepath.Base(devicePath)
+
+	rescanPath := filepath.Join("/sys/class/block", devName, "device", "rescan_controller")
+	if err := os.WriteFile(rescanPath, []byte("1"), 0200); err != nil {
+		klog.V(4).Infof("NVMe rescan via %s failed (continuing): %v", rescanPath, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join("/sys/class/block", devName, "size"))
+	if err != nil {
+		return 0, err
+	}
+	sectors, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size in sysfs for %s: %w", devName, err)
+	}
+	// sysfs reports size in 512-byte sectors regardless of logical block size
+	return sectors * 512, nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -24,25 +25,50 @@ import (
 type mockMounter struct {
 	formatCalled    bool
 	mountCalled     bool
+	trimCalled      bool
+	resizeCalled    bool
+	trimErr         error
+	mountOptions    []string
 	unmountCalled   bool
+	forceUnmounted  bool
 	mountErr        error
 	unmountErr      error
 	formatErr       error
 	isFormatted     bool
 	isFormattedErr  error
+	fsUUID          string
 	isLikelyMounted bool
 	isLikelyErr     error
 	stats           *mount.DeviceStats
 	statsErr        error
+	readOnlyRemount bool  // simulate the kernel remounting the filesystem read-only
+	fsErrors        int64 // simulate ext4 errors_count
+	formatDelay     time.Duration
+	mountDelay      time.Duration
+	quotaEnabled    string              // fsType EnableProjectQuota was called with
+	quotaLimit      int64               // limit SetProjectQuota was called with
+	quotaErr        error               // returned by SetProjectQuota
+	projectQuota    *mount.ProjectQuota // returned by GetProjectQuota
+	quotaReads      int                 // GetProjectQuota calls
+	unmountErrs     []error             // returned by successive Unmount calls before unmountErr
+	unmountCalls    int
 }
 
 func (m *mockMounter) Mount(source, target, fsType string, options []string) error {
+	time.Sleep(m.mountDelay)
 	m.mountCalled = true
+	m.mountOptions = options
 	return m.mountErr
 }
 
 func (m *mockMounter) Unmount(target string) error {
 	m.unmountCalled = true
+	m.unmountCalls++
+	if len(m.unmountErrs) > 0 {
+		err := m.unmountErrs[0]
+		m.unmountErrs = m.unmountErrs[1:]
+		return err
+	}
 	return m.unmountErr
 }
 
@@ -50,8 +76,13 @@ func (m *mockMounter) IsLikelyMountPoint(path string) (bool, error) {
 	return m.isLikelyMounted, m.isLikelyErr
 }
 
-func (m *mockMounter) Format(device, fsType string) error {
+func (m *mockMounter) Format(device, fsType, fsUUID string) error {
+	time.Sleep(m.formatDelay)
 	m.formatCalled = true
+	// Like mkfs via mount.Mounter, an existing filesystem is left alone
+	if m.formatErr == nil && !m.isFormatted && fsUUID != "" {
+		m.fsUUID = fsUUID
+	}
 	return m.formatErr
 }
 
@@ -59,28 +90,44 @@ func (m *mockMounter) IsFormatted(device string) (bool, error) {
 	return m.isFormatted, m.isFormattedErr
 }
 
+func (m *mockMounter) FilesystemUUID(device string) (string, error) {
+	if m.fsUUID == "" {
+		return "", fmt.Errorf("no filesystem UUID on %s", device)
+	}
+	return m.fsUUID, nil
+}
+
 func (m *mockMounter) ResizeFilesystem(device, volumePath string) error {
+	m.resizeCalled = true
 	return nil
 }
 
+func (m *mockMounter) Trim(path string) error {
+	m.trimCalled = true
+	return m.trimErr
+}
+
 func (m *mockMounter) GetDeviceStats(path string) (*mount.DeviceStats, error) {
 	if m.statsErr != nil {
 		return nil, m.statsErr
 	}
 	if m.stats == nil {
 		return &mount.DeviceStats{
-			TotalBytes:      100 * 1024 * 1024,
-			UsedBytes:       50 * 1024 * 1024,
-			AvailableBytes:  50 * 1024 * 1024,
-			TotalInodes:     1000,
-			UsedInodes:      100,
-			AvailableInodes: 900,
+			TotalBytes:       100 * 1024 * 1024,
+			UsedBytes:        50 * 1024 * 1024,
+			AvailableBytes:   50 * 1024 * 1024,
+			TotalInodes:      1000,
+			UsedInodes:       100,
+			AvailableInodes:  900,
+			ReadOnlyRemount:  m.readOnlyRemount,
+			FilesystemErrors: m.fsErrors,
 		}, nil
 	}
 	return m.stats, nil
 }
 
 func (m *mockMounter) ForceUnmount(target string, timeout time.Duration) error {
+	m.forceUnmounted = true
 	return m.unmountErr
 }
 
@@ -92,6 +139,24 @@ func (m *mockMounter) MakeFile(pathname string) error {
 	return nil
 }
 
+func (m *mockMounter) EnableProjectQuota(device, fsType string) error {
+	m.quotaEnabled = fsType
+	return nil
+}
+
+func (m *mockMounter) SetProjectQuota(path string, projectID uint32, limitBytes int64) error {
+	m.quotaLimit = limitBytes
+	return m.quotaErr
+}
+
+func (m *mockMounter) GetProjectQuota(path string, projectID uint32) (*mount.ProjectQuota, error) {
+	m.quotaReads++
+	if m.projectQuota == nil {
+		return &mount.ProjectQuota{}, nil
+	}
+	return m.projectQuota, nil
+}
+
 // staleCheckBehavior defines the expected behavior of stale check
 type staleCheckBehavior struct {
 	stale  bool
@@ -487,6 +552,46 @@ func TestNodeGetVolumeStats_StaleMountReturnsEmptyUsage(t *testing.T) {
 	}
 }
 
+// TestNodeGetVolumeStats_NVMeNotConnected tests that a volume whose NVMe subsystem was
+// disconnected is reported abnormal, while volumes without a derivable NQN stay healthy
+func TestNodeGetVolumeStats_NVMeNotConnected(t *testing.T) {
+	tests := []struct {
+		name         string
+		volumeID     string
+		wantAbnormal bool
+	}{
+		{name: "derivable NQN", volumeID: "pvc-12345678-1234-1234-1234-123456789012", wantAbnormal: true},
+		{name: "non-standard volume ID", volumeID: "static_volume", wantAbnormal: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := &mockMounter{isLikelyMounted: true, stats: &mount.DeviceStats{TotalBytes: 1 << 30}}
+			ns := createNodeServerNoStaleChecker(mounter)
+			ns.nvmeConn = &mockNVMEConnector{notConnected: true}
+
+			resp, err := ns.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{
+				VolumeId:   tt.volumeID,
+				VolumePath: "/var/lib/kubelet/pods/test-pod/volumes/test-volume",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.VolumeCondition.Abnormal != tt.wantAbnormal {
+				t.Fatalf("Abnormal = %v, want %v (message %q)", resp.VolumeCondition.Abnormal, tt.wantAbnormal, resp.VolumeCondition.Message)
+			}
+			if tt.wantAbnormal {
+				if resp.VolumeCondition.Message != "NVMe subsystem not connected" {
+					t.Errorf("unexpected message %q", resp.VolumeCondition.Message)
+				}
+				if len(resp.Usage) != 0 {
+					t.Errorf("expected empty usage without an NVMe connection, got %d entries", len(resp.Usage))
+				}
+			}
+		})
+	}
+}
+
 // TestNodeGetVolumeStats_MetricsRecorded tests that stale mount detection
 // records metrics
 func TestNodeGetVolumeStats_MetricsRecorded(t *testing.T) {
@@ -583,6 +688,10 @@ type mockNVMEConnector struct {
 	connectErr       error
 	disconnectErr    error
 	getDevicePathErr error
+	lastTarget       nvme.Target
+	stallConnect     bool // ConnectWithRetry blocks until its context is done
+	notConnected     bool // IsConnected reports every subsystem disconnected
+	resolver         *nvme.DeviceResolver
 }
 
 func (m *mockNVMEConnector) Connect(target nvme.Target) (string, error) {
@@ -611,6 +720,11 @@ func (m *mockNVMEConnector) ConnectWithConfig(ctx context.Context, target nvme.T
 
 func (m *mockNVMEConnector) ConnectWithRetry(ctx context.Context, target nvme.Target, config nvme.ConnectionConfig) (string, error) {
 	m.connectCalled = true
+	m.lastTarget = target
+	if m.stallConnect {
+		<-ctx.Done()
+		return "", fmt.Errorf("connect to %s: %w", target.NQN, ctx.Err())
+	}
 	if m.connectErr != nil {
 		return "", m.connectErr
 	}
@@ -628,11 +742,11 @@ func (m *mockNVMEConnector) DisconnectWithContext(ctx context.Context, nqn strin
 }
 
 func (m *mockNVMEConnector) IsConnected(nqn string) (bool, error) {
-	return true, nil
+	return !m.notConnected, nil
 }
 
 func (m *mockNVMEConnector) IsConnectedWithContext(ctx context.Context, nqn string) (bool, error) {
-	return true, nil
+	return !m.notConnected, nil
 }
 
 func (m *mockNVMEConnector) GetDevicePath(nqn string) (string, error) {
@@ -655,7 +769,7 @@ func (m *mockNVMEConnector) GetConfig() nvme.Config {
 }
 
 func (m *mockNVMEConnector) GetResolver() *nvme.DeviceResolver {
-	return nil
+	return m.resolver
 }
 
 func (m *mockNVMEConnector) SetPromMetrics(metrics *observability.Metrics) {
@@ -827,6 +941,525 @@ func TestNodeStageVolume_FilesystemVolume_Unchanged(t *testing.T) {
 	}
 }
 
+// TestNodeStageVolume_FilesystemOptions tests the initialTrim and discard VolumeContext options
+func TestNodeStageVolume_FilesystemOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		volumeContext map[string]string
+		trimErr       error
+		wantCode      codes.Code
+		wantDiscard   bool
+		wantTrim      bool
+	}{
+		{
+			name:     "defaults leave options off",
+			wantCode: codes.OK,
+		},
+		{
+			name:          "discard adds mount option",
+			volumeContext: map[string]string{"discard": "true"},
+			wantCode:      codes.OK,
+			wantDiscard:   true,
+		},
+		{
+			name:          "initialTrim trims fresh filesystem",
+			volumeContext: map[string]string{"initialTrim": "true"},
+			wantCode:      codes.OK,
+			wantTrim:      true,
+		},
+		{
+			name:          "initialTrim failure is not fatal",
+			volumeContext: map[string]string{"initialTrim": "true"},
+			trimErr:       errors.New("fstrim: FITRIM ioctl failed: Operation not supported"),
+			wantCode:      codes.OK,
+			wantTrim:      true,
+		},
+		{
+			name:          "invalid discard value",
+			volumeContext: map[string]string{"discard": "sometimes"},
+			wantCode:      codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stagingPath := filepath.Join(t.TempDir(), "staging")
+
+			mounter := &mockMounter{trimErr: tt.trimErr}
+			ns := &NodeServer{
+				driver: &Driver{
+					name:    "rds.csi.srvlab.io",
+					version: "test",
+					metrics: observability.NewMetrics(),
+				},
+				mounter:        mounter,
+				nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+				nodeID:         "test-node",
+				circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+			}
+
+			volumeContext := map[string]string{
+				"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+				"nvmeAddress": "10.42.68.1",
+				"nvmePort":    "4420",
+			}
+			for k, v := range tt.volumeContext {
+				volumeContext[k] = v
+			}
+
+			_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+				VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+				StagingTargetPath: stagingPath,
+				VolumeCapability:  createFilesystemVolumeCapability(),
+				VolumeContext:     volumeContext,
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (err: %v)", tt.wantCode, status.Code(err), err)
+			}
+			if tt.wantCode != codes.OK {
+				return
+			}
+
+			hasDiscard := false
+			for _, opt := range mounter.mountOptions {
+				if opt == "discard" {
+					hasDiscard = true
+				}
+			}
+			if hasDiscard != tt.wantDiscard {
+				t.Errorf("discard mount option present=%v, want %v (options: %v)", hasDiscard, tt.wantDiscard, mounter.mountOptions)
+			}
+			if mounter.trimCalled != tt.wantTrim {
+				t.Errorf("trim called=%v, want %v", mounter.trimCalled, tt.wantTrim)
+			}
+		})
+	}
+}
+
+// TestNodeStageVolume_FormatPolicyNever tests that formatPolicy=never mounts existing
+// filesystems without running mkfs and refuses blank volumes
+func TestNodeStageVolume_FormatPolicyNever(t *testing.T) {
+	tests := []struct {
+		name        string
+		formatted   bool
+		wantCode    codes.Code
+		wantMounted bool
+	}{
+		{name: "formatted volume is mounted", formatted: true, wantCode: codes.OK, wantMounted: true},
+		{name: "blank volume is refused", formatted: false, wantCode: codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := &mockMounter{isFormatted: tt.formatted}
+			connector := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+			ns := &NodeServer{
+				driver: &Driver{
+					name:    "rds.csi.srvlab.io",
+					version: "test",
+					metrics: observability.NewMetrics(),
+				},
+				mounter:        mounter,
+				nvmeConn:       connector,
+				nodeID:         "test-node",
+				circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+			}
+
+			_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+				VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+				StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+				VolumeCapability:  createFilesystemVolumeCapability(),
+				VolumeContext: map[string]string{
+					"nqn":          "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+					"nvmeAddress":  "10.42.68.1",
+					"nvmePort":     "4420",
+					"formatPolicy": "never",
+				},
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (err: %v)", tt.wantCode, status.Code(err), err)
+			}
+			if mounter.formatCalled {
+				t.Error("Format must not be called with formatPolicy=never")
+			}
+			if mounter.mountCalled != tt.wantMounted {
+				t.Errorf("mount called=%v, want %v", mounter.mountCalled, tt.wantMounted)
+			}
+		})
+	}
+}
+
+// TestNodeStageVolume_PhaseTimeouts stalls one stage phase at a time and checks that the
+// timeout is attributed to it, with its budget and the phases that completed
+func TestNodeStageVolume_PhaseTimeouts(t *testing.T) {
+	tests := []struct {
+		name      string
+		connector *mockNVMEConnector
+		mounter   *mockMounter
+		phase     string
+		completed string
+	}{
+		{
+			name:      "connect",
+			connector: &mockNVMEConnector{devicePath: "/dev/nvme0n1", stallConnect: true},
+			mounter:   &mockMounter{},
+			phase:     PhaseConnect,
+			completed: "none",
+		},
+		{
+			name:      "device wait",
+			connector: &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+			mounter:   &mockMounter{isFormattedErr: errors.New("blkid exit 1")},
+			phase:     PhaseDeviceWait,
+			completed: PhaseConnect,
+		},
+		{
+			name:      "format",
+			connector: &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+			mounter:   &mockMounter{formatDelay: 500 * time.Millisecond, formatErr: errors.New("mkfs killed")},
+			phase:     PhaseFormat,
+			completed: "connect, device_wait",
+		},
+		{
+			name:      "mount",
+			connector: &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+			mounter:   &mockMounter{mountDelay: 300 * time.Millisecond, mountErr: errors.New("mount interrupted")},
+			phase:     PhaseMount,
+			completed: "connect, device_wait, format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := observability.NewMetrics()
+			ns := &NodeServer{
+				driver: &Driver{
+					name:    "rds.csi.srvlab.io",
+					version: "test",
+					metrics: metrics,
+				},
+				mounter:        tt.mounter,
+				nvmeConn:       tt.connector,
+				nodeID:         "test-node",
+				circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+			}
+
+			// Default budgets: connect 400ms, device wait 200ms, format 300ms, mount 100ms
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_, err := ns.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+				VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+				StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+				VolumeCapability:  createFilesystemVolumeCapability(),
+				VolumeContext: map[string]string{
+					"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+					"nvmeAddress": "10.42.68.1",
+					"nvmePort":    "4420",
+				},
+			})
+			if status.Code(err) != codes.DeadlineExceeded {
+				t.Fatalf("expected DeadlineExceeded, got %v", err)
+			}
+			msg := status.Convert(err).Message()
+			if !strings.Contains(msg, "stage phase "+tt.phase+" timed out") || !strings.Contains(msg, "budget ") {
+				t.Errorf("error does not name phase %s and its budget: %s", tt.phase, msg)
+			}
+			if !strings.Contains(msg, "completed phases: "+tt.completed+")") {
+				t.Errorf("error does not report completed phases %q: %s", tt.completed, msg)
+			}
+
+			body := scrapeMetrics(t, metrics)
+			if !strings.Contains(body, `rds_csi_stage_phase_duration_seconds_count{phase="`+tt.phase+`"} 1`) {
+				t.Errorf("expected a %s phase duration sample, got:\n%s", tt.phase, body)
+			}
+		})
+	}
+}
+
+// TestNodeServer_TCPModuleCheck tests the startup check for the nvme_tcp kernel module
+// with an injected detector and loader
+func TestNodeServer_TCPModuleCheck(t *testing.T) {
+	errMissing := errors.New("nvme_tcp kernel module not loaded (/sys/module/nvme_tcp not found)")
+
+	tests := []struct {
+		name          string
+		loaded        bool
+		loadErr       error
+		withLoader    bool
+		wantAvailable bool
+		wantLoad      bool
+	}{
+		{name: "module loaded", loaded: true, withLoader: true, wantAvailable: true},
+		{name: "missing, modprobe not permitted", wantAvailable: false},
+		{name: "missing, modprobe loads it", withLoader: true, wantAvailable: true, wantLoad: true},
+		{name: "missing, modprobe fails", withLoader: true, loadErr: errors.New("modprobe: FATAL: Module nvme_tcp not found"), wantLoad: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded := tt.loaded
+			loadCalled := false
+			metrics := observability.NewMetrics()
+			driver := &Driver{
+				name:    DriverName,
+				version: "test",
+				metrics: metrics,
+				tcpModuleDetector: func() error {
+					if loaded {
+						return nil
+					}
+					return errMissing
+				},
+			}
+			if tt.withLoader {
+				driver.tcpModuleLoader = func() error {
+					loadCalled = true
+					if tt.loadErr == nil {
+						loaded = true
+					}
+					return tt.loadErr
+				}
+			}
+
+			ns := NewNodeServer(driver, "test-node", nil)
+
+			if loadCalled != tt.wantLoad {
+				t.Errorf("loader called=%v, want %v", loadCalled, tt.wantLoad)
+			}
+			err := ns.tcpModuleAvailable()
+			if (err == nil) != tt.wantAvailable {
+				t.Errorf("tcpModuleAvailable() = %v, want available=%v", err, tt.wantAvailable)
+			}
+			want := "rds_csi_node_nvme_tcp_available 0"
+			if tt.wantAvailable {
+				want = "rds_csi_node_nvme_tcp_available 1"
+			}
+			if body := scrapeMetrics(t, metrics); !strings.Contains(body, want) {
+				t.Errorf("expected %q in metrics, got:\n%s", want, body)
+			}
+		})
+	}
+}
+
+// TestNodeStageVolume_TCPModuleMissing tests that staging on a node without nvme_tcp fails
+// with FailedPrecondition before connecting, and recovers once the module is loaded
+func TestNodeStageVolume_TCPModuleMissing(t *testing.T) {
+	loaded := false
+	connector := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	ns := &NodeServer{
+		driver: &Driver{
+			name:    DriverName,
+			version: "test",
+			metrics: observability.NewMetrics(),
+		},
+		mounter:        &mockMounter{},
+		nvmeConn:       connector,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+		tcpModuleDetector: func() error {
+			if loaded {
+				return nil
+			}
+			return errors.New("nvme_tcp kernel module not loaded")
+		},
+		tcpModuleErr: errors.New("nvme_tcp kernel module not loaded"),
+	}
+	req := &csi.NodeStageVolumeRequest{
+		VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+		VolumeCapability:  createFilesystemVolumeCapability(),
+		VolumeContext: map[string]string{
+			"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+			"nvmeAddress": "10.42.68.1",
+			"nvmePort":    "4420",
+		},
+	}
+
+	_, err := ns.NodeStageVolume(context.Background(), req)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "modprobe nvme_tcp") {
+		t.Errorf("error should point at the missing module, got %v", err)
+	}
+	if connector.connectCalled {
+		t.Error("connect should not be attempted without nvme_tcp")
+	}
+
+	loaded = true
+	if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+		t.Fatalf("expected stage to succeed once nvme_tcp is loaded, got %v", err)
+	}
+}
+
+// TestNodeStageVolume_CircuitOpen tests that a short-circuited stage reports the
+// last mount failure and the remaining cooldown as Unavailable
+func TestNodeStageVolume_CircuitOpen(t *testing.T) {
+	const volumeID = "pvc-12345678-1234-1234-1234-123456789012"
+	mounter := &mockMounter{mountErr: errors.New("mount: wrong fs type, bad superblock on /dev/nvme0n1")}
+	ns := &NodeServer{
+		driver: &Driver{
+			name:    "rds.csi.srvlab.io",
+			version: "test",
+			metrics: observability.NewMetrics(),
+		},
+		mounter:        mounter,
+		nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}
+	req := &csi.NodeStageVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+		VolumeCapability:  createFilesystemVolumeCapability(),
+		VolumeContext: map[string]string{
+			"nqn":         "nqn.2000-02.com.mikrotik:" + volumeID,
+			"nvmeAddress": "10.42.68.1",
+			"nvmePort":    "4420",
+		},
+	}
+
+	for i := 0; i < circuitbreaker.DefaultConsecutiveFailures; i++ {
+		if _, err := ns.NodeStageVolume(context.Background(), req); status.Code(err) != codes.Internal {
+			t.Fatalf("attempt %d: expected Internal, got %v", i+1, err)
+		}
+	}
+
+	mounter.mountCalled = false
+	_, err := ns.NodeStageVolume(context.Background(), req)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable once the circuit is open, got %v", err)
+	}
+	if mounter.mountCalled {
+		t.Error("mount should not be attempted while the circuit is open")
+	}
+	msg := status.Convert(err).Message()
+	for _, want := range []string{"circuit open for volume " + volumeID, "last error: failed to mount device: mount: wrong fs type", "retrying after"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q should contain %q", msg, want)
+		}
+	}
+}
+
+// TestNodeStageVolume_WWID tests that an expected namespace WWID in VolumeContext
+// is validated and passed to the connector
+func TestNodeStageVolume_WWID(t *testing.T) {
+	tests := []struct {
+		name     string
+		wwid     string
+		wantCode codes.Code
+	}{
+		{name: "no wwid", wantCode: codes.OK},
+		{name: "valid wwid", wwid: "eui.0025385b71b0a1f2", wantCode: codes.OK},
+		{name: "invalid wwid", wwid: "eui.0025;reboot", wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+			ns := &NodeServer{
+				driver: &Driver{
+					name:    "rds.csi.srvlab.io",
+					version: "test",
+					metrics: observability.NewMetrics(),
+				},
+				mounter:        &mockMounter{},
+				nvmeConn:       connector,
+				nodeID:         "test-node",
+				circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+			}
+
+			volumeContext := map[string]string{
+				"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+				"nvmeAddress": "10.42.68.1",
+				"nvmePort":    "4420",
+			}
+			if tt.wwid != "" {
+				volumeContext["wwid"] = tt.wwid
+			}
+
+			_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+				VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+				StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+				VolumeCapability:  createFilesystemVolumeCapability(),
+				VolumeContext:     volumeContext,
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (err: %v)", tt.wantCode, status.Code(err), err)
+			}
+			if tt.wantCode == codes.OK && connector.lastTarget.WWID != tt.wwid {
+				t.Errorf("expected target WWID %q, got %q", tt.wwid, connector.lastTarget.WWID)
+			}
+		})
+	}
+}
+
+// TestNodeExpandVolume_BlockChecksDeviceSize tests that a block volume expansion only
+// succeeds once the device shows the new size
+func TestNodeExpandVolume_BlockChecksDeviceSize(t *testing.T) {
+	const volumeID = "pvc-12345678-1234-1234-1234-123456789012"
+	const newSize = int64(2 << 30)
+
+	tests := []struct {
+		name        string
+		deviceBytes int64
+		wantCode    codes.Code
+	}{
+		{
+			name:        "device shows new size",
+			deviceBytes: newSize,
+			wantCode:    codes.OK,
+		},
+		{
+			name:        "device not yet rescanned",
+			deviceBytes: 1 << 30,
+			wantCode:    codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			publishPath := filepath.Join(t.TempDir(), "block")
+			if err := os.WriteFile(publishPath, nil, 0600); err != nil {
+				t.Fatalf("failed to create publish path: %v", err)
+			}
+
+			mounter := &mockMounter{}
+			ns := &NodeServer{
+				driver: &Driver{
+					name:    "rds.csi.srvlab.io",
+					version: "test",
+					metrics: observability.NewMetrics(),
+				},
+				mounter:        mounter,
+				nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+				nodeID:         "test-node",
+				circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+				deviceSizeFunc: func(string) (int64, error) { return tt.deviceBytes, nil },
+			}
+
+			resp, err := ns.NodeExpandVolume(ctx, &csi.NodeExpandVolumeRequest{
+				VolumeId:      volumeID,
+				VolumePath:    publishPath,
+				CapacityRange: &csi.CapacityRange{RequiredBytes: newSize},
+				VolumeCapability: &csi.VolumeCapability{
+					AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+				},
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (err: %v)", tt.wantCode, status.Code(err), err)
+			}
+			if err == nil && resp.CapacityBytes != tt.deviceBytes {
+				t.Errorf("expected capacity %d, got %d", tt.deviceBytes, resp.CapacityBytes)
+			}
+			if mounter.resizeCalled {
+				t.Error("block volumes have no filesystem to resize")
+			}
+		})
+	}
+}
+
 // TestNodePublishVolume_BlockVolume tests publishing a block volume.
 // Block volume publish finds device by NQN via nvmeConn.GetDevicePath(),
 // then creates a device node at target path using mknod (not bind mount).
@@ -895,6 +1528,96 @@ func TestNodePublishVolume_BlockVolume(t *testing.T) {
 	}
 }
 
+// TestNodePublishVolume_BlockDeviceMode tests that the device node gets the mode and
+// owner of the volume context, without write bits when published readonly. Skipped
+// where mknod is not permitted.
+func TestNodePublishVolume_BlockDeviceMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		context   map[string]string
+		readonly  bool
+		wantMode  os.FileMode
+		wantOwner int // -1 for the test process's user
+	}{
+		{name: "default", wantMode: DefaultBlockDeviceMode, wantOwner: -1},
+		{name: "default readonly", readonly: true, wantMode: 0440, wantOwner: -1},
+		{name: "custom mode", context: map[string]string{paramBlockDeviceMode: "0664"}, wantMode: 0664, wantOwner: -1},
+		{name: "custom mode readonly", context: map[string]string{paramBlockDeviceMode: "0664"}, readonly: true, wantMode: 0444, wantOwner: -1},
+		{name: "owner", context: map[string]string{paramBlockDeviceOwner: "107:107"}, wantMode: DefaultBlockDeviceMode, wantOwner: 107},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantOwner >= 0 && os.Geteuid() != 0 {
+				t.Skip("chown needs root")
+			}
+			tmpDir := t.TempDir()
+			targetPath := filepath.Join(tmpDir, "target")
+
+			// Any device works as the source; only its major:minor is copied
+			ns := &NodeServer{
+				driver:   &Driver{name: DriverName, version: "test", metrics: observability.NewMetrics()},
+				mounter:  &mockMounter{},
+				nvmeConn: &mockNVMEConnector{devicePath: "/dev/null"},
+				nodeID:   "test-node",
+			}
+			volumeContext := map[string]string{"nqn": "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012"}
+			for k, v := range tt.context {
+				volumeContext[k] = v
+			}
+
+			_, err := ns.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+				VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+				StagingTargetPath: filepath.Join(tmpDir, "staging"),
+				TargetPath:        targetPath,
+				VolumeCapability:  createBlockVolumeCapability(),
+				VolumeContext:     volumeContext,
+				Readonly:          tt.readonly,
+			})
+			if err != nil && strings.Contains(err.Error(), "operation not permitted") {
+				t.Skipf("mknod not permitted: %v", err)
+			}
+			if err != nil {
+				t.Fatalf("NodePublishVolume failed: %v", err)
+			}
+
+			var stat syscall.Stat_t
+			if err := syscall.Stat(targetPath, &stat); err != nil {
+				t.Fatalf("failed to stat device node: %v", err)
+			}
+			if stat.Mode&syscall.S_IFMT != syscall.S_IFBLK {
+				t.Errorf("expected a block device node, got mode %o", stat.Mode)
+			}
+			if mode := os.FileMode(stat.Mode & 0777); mode != tt.wantMode {
+				t.Errorf("mode = %04o, want %04o", uint32(mode), uint32(tt.wantMode))
+			}
+			if tt.wantOwner >= 0 && (int(stat.Uid) != tt.wantOwner || int(stat.Gid) != tt.wantOwner) {
+				t.Errorf("owner = %d:%d, want %d:%d", stat.Uid, stat.Gid, tt.wantOwner, tt.wantOwner)
+			}
+		})
+	}
+}
+
+// TestNodePublishVolume_InvalidBlockDeviceMode tests that a mode edited into a PV is rejected
+func TestNodePublishVolume_InvalidBlockDeviceMode(t *testing.T) {
+	ns := &NodeServer{
+		driver:   &Driver{name: DriverName, version: "test", metrics: observability.NewMetrics()},
+		mounter:  &mockMounter{},
+		nvmeConn: &mockNVMEConnector{devicePath: "/dev/null"},
+		nodeID:   "test-node",
+	}
+	_, err := ns.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+		VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+		TargetPath:        filepath.Join(t.TempDir(), "target"),
+		VolumeCapability:  createBlockVolumeCapability(),
+		VolumeContext:     map[string]string{paramBlockDeviceMode: "0777"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
 // TestNodePublishVolume_BlockVolume_MissingDevice tests error when device is not found
 func TestNodePublishVolume_BlockVolume_MissingDevice(t *testing.T) {
 	// Create temp directories for staging and target
@@ -1063,6 +1786,37 @@ func TestNodeUnstageVolume_BlockVolume(t *testing.T) {
 	}
 }
 
+// TestNodeUnstageVolume_ForgetsExpectedWWID tests that unstaging drops the namespace
+// WWID registered for the volume's NQN
+func TestNodeUnstageVolume_ForgetsExpectedWWID(t *testing.T) {
+	const volumeID = "pvc-12345678-1234-1234-1234-123456789012"
+	nqn, err := volumeIDToNQN(volumeID)
+	if err != nil {
+		t.Fatalf("volumeIDToNQN failed: %v", err)
+	}
+
+	resolver := nvme.NewDeviceResolverWithConfig(nvme.ResolverConfig{SysfsRoot: t.TempDir()})
+	resolver.SetExpectedWWID(nqn, "uuid.3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a")
+	ns := &NodeServer{
+		driver:         &Driver{name: "rds.csi.srvlab.io", version: "test", metrics: observability.NewMetrics()},
+		mounter:        &mockMounter{},
+		nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1", resolver: resolver},
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}
+
+	_, err = ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+	})
+	if err != nil {
+		t.Fatalf("NodeUnstageVolume failed: %v", err)
+	}
+	if wwid := resolver.ExpectedWWID(nqn); wwid != "" {
+		t.Errorf("expected the WWID of %s to be forgotten, got %q", nqn, wwid)
+	}
+}
+
 // TestNodeUnstageVolume_FilesystemVolume_Unchanged tests that filesystem volumes still work
 func TestNodeUnstageVolume_FilesystemVolume_Unchanged(t *testing.T) {
 	// Create temp directory for staging
@@ -1177,6 +1931,100 @@ func TestNodePublishVolume_FilesystemVolume(t *testing.T) {
 	}
 }
 
+// TestNodePublishVolume_RecreatedVolumeStaleStaging covers a PVC deleted and re-created
+// with the same name: kubelet reuses the staging path, which is still mounted from the
+// old volume's device, while the new volume has a different NQN that is not connected.
+// Publishing must not bind mount the old volume's filesystem.
+func TestNodePublishVolume_RecreatedVolumeStaleStaging(t *testing.T) {
+	oldVolumeID := "pvc-11111111-1111-1111-1111-111111111111"
+	newVolumeID := "pvc-22222222-2222-2222-2222-222222222222"
+	oldNQN, err := volumeIDToNQN(oldVolumeID)
+	if err != nil {
+		t.Fatalf("failed to derive NQN: %v", err)
+	}
+
+	// Only the old volume is connected, as nvme1 -> nvme1n1
+	sysfsRoot := t.TempDir()
+	ctrlDir := filepath.Join(sysfsRoot, "class", "nvme", "nvme1")
+	if err := os.MkdirAll(ctrlDir, 0755); err != nil {
+		t.Fatalf("failed to create controller dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ctrlDir, "subsysnqn"), []byte(oldNQN+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write subsysnqn: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(sysfsRoot, "class", "block", "nvme1n1"), 0755); err != nil {
+		t.Fatalf("failed to create block device dir: %v", err)
+	}
+
+	// The staging path is mounted from the old volume's device
+	tmpDir := t.TempDir()
+	oldDevice := filepath.Join(tmpDir, "nvme1n1")
+	if err := os.WriteFile(oldDevice, []byte{}, 0644); err != nil {
+		t.Fatalf("failed to create device file: %v", err)
+	}
+	stagingPath := filepath.Join(tmpDir, "staging")
+	targetPath := filepath.Join(tmpDir, "target")
+
+	newNodeServer := func(mounter *mockMounter) *NodeServer {
+		resolver := nvme.NewDeviceResolverWithConfig(nvme.ResolverConfig{SysfsRoot: sysfsRoot})
+		checker := mount.NewStaleMountChecker(resolver)
+		checker.SetMountDeviceFunc(func(path string) (string, error) {
+			return oldDevice, nil
+		})
+		recoverer := mount.NewMountRecoverer(mount.RecoveryConfig{
+			MaxAttempts:       1,
+			InitialBackoff:    time.Millisecond,
+			BackoffMultiplier: 1,
+		}, mounter, checker, resolver)
+		return &NodeServer{
+			driver:       &Driver{name: "rds.csi.srvlab.io", version: "test", metrics: observability.NewMetrics()},
+			mounter:      mounter,
+			nodeID:       "test-node",
+			staleChecker: checker,
+			recoverer:    recoverer,
+		}
+	}
+	publish := func(ns *NodeServer, volumeID string) error {
+		_, err := ns.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+			VolumeId:          volumeID,
+			StagingTargetPath: stagingPath,
+			TargetPath:        targetPath,
+			VolumeCapability:  createFilesystemVolumeCapability(),
+		})
+		return err
+	}
+
+	t.Run("new volume on old staging mount", func(t *testing.T) {
+		mounter := &mockMounter{isLikelyMounted: true}
+		err := publish(newNodeServer(mounter), newVolumeID)
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected FailedPrecondition, got %v", err)
+		}
+		if !strings.Contains(err.Error(), oldNQN) {
+			t.Errorf("error should name the NQN backing the staging mount: %v", err)
+		}
+		if !mounter.forceUnmounted {
+			t.Error("recovery should unmount the stale staging mount")
+		}
+		if mounter.mountCalled {
+			t.Error("the old volume's filesystem must not be bind mounted")
+		}
+	})
+
+	t.Run("old volume on its own staging mount", func(t *testing.T) {
+		mounter := &mockMounter{isLikelyMounted: true}
+		if err := publish(newNodeServer(mounter), oldVolumeID); err != nil {
+			t.Fatalf("NodePublishVolume failed: %v", err)
+		}
+		if mounter.forceUnmounted {
+			t.Error("a staging mount of the requested NQN must not be recovered")
+		}
+		if !mounter.mountCalled {
+			t.Error("expected bind mount")
+		}
+	})
+}
+
 // TestNodeUnpublishVolume_FilesystemVolume tests unpublishing a filesystem volume
 func TestNodeUnpublishVolume_FilesystemVolume(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "node-test-fs-unpublish-*")
@@ -1220,6 +2068,115 @@ func TestNodeUnpublishVolume_FilesystemVolume(t *testing.T) {
 	}
 }
 
+// TestNodeUnpublishVolume_PartialPublishArtifacts covers targets left in an unexpected
+// state by a publish that crashed part-way through
+func TestNodeUnpublishVolume_PartialPublishArtifacts(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup creates whatever a crashed publish left at target
+		setup    func(t *testing.T, target string)
+		statErr  error
+		mounter  *mockMounter
+		wantCode codes.Code
+		// wantForce expects the corrupted-mount path to force unmount
+		wantForce bool
+	}{
+		{
+			name: "block target is a directory",
+			setup: func(t *testing.T, target string) {
+				if err := os.MkdirAll(filepath.Join(target, "lost+found"), 0750); err != nil {
+					t.Fatalf("failed to create target dir: %v", err)
+				}
+			},
+			mounter: &mockMounter{},
+		},
+		{
+			name: "filesystem target is a file",
+			setup: func(t *testing.T, target string) {
+				if err := os.WriteFile(target, []byte{}, 0600); err != nil {
+					t.Fatalf("failed to create target file: %v", err)
+				}
+			},
+			mounter: &mockMounter{},
+		},
+		{
+			name: "dangling mount with missing device",
+			setup: func(t *testing.T, target string) {
+				if err := os.MkdirAll(target, 0750); err != nil {
+					t.Fatalf("failed to create target dir: %v", err)
+				}
+			},
+			statErr:   &os.PathError{Op: "stat", Path: "target", Err: syscall.ENOTCONN},
+			mounter:   &mockMounter{},
+			wantForce: true,
+		},
+		{
+			name: "dangling mount that cannot be unmounted",
+			setup: func(t *testing.T, target string) {
+				if err := os.MkdirAll(target, 0750); err != nil {
+					t.Fatalf("failed to create target dir: %v", err)
+				}
+			},
+			statErr:   syscall.EIO,
+			mounter:   &mockMounter{unmountErr: errors.New("target is busy")},
+			wantCode:  codes.Internal,
+			wantForce: true,
+		},
+		{
+			name: "unreadable target is not treated as a mount",
+			setup: func(t *testing.T, target string) {
+				if err := os.MkdirAll(target, 0750); err != nil {
+					t.Fatalf("failed to create target dir: %v", err)
+				}
+			},
+			statErr:  syscall.EACCES,
+			mounter:  &mockMounter{},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeletDir := t.TempDir()
+			targetPath := filepath.Join(kubeletDir, "pods", "pod-uid", "volumes", "kubernetes.io~csi", "target")
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0750); err != nil {
+				t.Fatalf("failed to create pod volume dir: %v", err)
+			}
+			tt.setup(t, targetPath)
+
+			ns := &NodeServer{
+				driver:      &Driver{name: "rds.csi.srvlab.io", version: "test", metrics: observability.NewMetrics(), kubeletDir: kubeletDir},
+				mounter:     tt.mounter,
+				nodeID:      "test-node",
+				mountPoints: func(context.Context) ([]string, error) { return nil, nil },
+			}
+			if tt.statErr != nil {
+				ns.statFunc = func(string, *syscall.Stat_t) error { return tt.statErr }
+			}
+
+			_, err := ns.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{
+				VolumeId:   "pvc-12345678-1234-1234-1234-123456789012",
+				TargetPath: targetPath,
+			})
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("expected %v, got %v", tt.wantCode, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("NodeUnpublishVolume failed: %v", err)
+				}
+				if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+					t.Error("target should have been removed")
+				}
+			}
+			if tt.mounter.forceUnmounted != tt.wantForce {
+				t.Errorf("ForceUnmount called = %v, want %v", tt.mounter.forceUnmounted, tt.wantForce)
+			}
+		})
+	}
+}
+
 // TestNodeGetVolumeStats_VolumeConditionNeverNil is a focused test to verify
 // the critical invariant that VolumeCondition is never nil
 func TestNodeGetVolumeStats_VolumeConditionNeverNil(t *testing.T) {
@@ -1265,6 +2222,17 @@ func TestNodeGetVolumeStats_VolumeConditionNeverNil(t *testing.T) {
 				})
 			},
 		},
+		{
+			name:     "filesystem remounted read-only",
+			volumeID: "pvc-12345678-1234-1234-1234-123456789012",
+			setup: func() *NodeServer {
+				return createNodeServerNoStaleChecker(&mockMounter{
+					isLikelyMounted: true,
+					readOnlyRemount: true,
+					fsErrors:        2,
+				})
+			},
+		},
 	}
 
 	for _, sc := range scenarios {
@@ -1289,6 +2257,48 @@ func TestNodeGetVolumeStats_VolumeConditionNeverNil(t *testing.T) {
 	}
 }
 
+func TestNodeGetVolumeStats_FilesystemErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		readOnlyRemount bool
+		fsErrors        int64
+		wantAbnormal    bool
+		wantMessage     string
+	}{
+		{"healthy", false, 0, false, "Volume is healthy"},
+		{"read-only remount", true, 3, true, "filesystem remounted read-only after errors"},
+		{"errors still writable", false, 1, true, "filesystem has recorded 1 errors"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := createNodeServerNoStaleChecker(&mockMounter{
+				isLikelyMounted: true,
+				readOnlyRemount: tt.readOnlyRemount,
+				fsErrors:        tt.fsErrors,
+			})
+
+			resp, err := ns.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{
+				VolumeId:   "pvc-12345678-1234-1234-1234-123456789012",
+				VolumePath: "/var/lib/kubelet/pods/test-pod/volumes/test-volume",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.VolumeCondition.Abnormal != tt.wantAbnormal {
+				t.Errorf("Abnormal = %v, want %v", resp.VolumeCondition.Abnormal, tt.wantAbnormal)
+			}
+			if resp.VolumeCondition.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", resp.VolumeCondition.Message, tt.wantMessage)
+			}
+			// Usage is still reported: statfs works on a read-only filesystem
+			if len(resp.Usage) != 2 {
+				t.Errorf("expected 2 usage entries, got %d", len(resp.Usage))
+			}
+		})
+	}
+}
+
 // TestNodeStageVolume_ErrorScenarios tests error path handling in NodeStageVolume
 func TestNodeStageVolume_ErrorScenarios(t *testing.T) {
 	tests := []struct {
@@ -1397,7 +2407,7 @@ func TestNodeStageVolume_ErrorScenarios(t *testing.T) {
 			errMsg:    "mount",
 		},
 		{
-			name: "missing required context - no NQN",
+			name: "missing required context - no address",
 			setupMock: func(nvmeConn *mockNVMEConnector, mounter *mockMounter) {
 				// No setup needed - context is invalid
 			},
@@ -1406,14 +2416,15 @@ func TestNodeStageVolume_ErrorScenarios(t *testing.T) {
 				StagingTargetPath: "/staging/path",
 				VolumeCapability:  createFilesystemVolumeCapability(),
 				VolumeContext: map[string]string{
-					// Missing nqn
-					"nvmeAddress": "10.42.68.1",
-					"nvmePort":    "4420",
+					// Missing nvmeAddress and rdsAddress; unlike the NQN and port, the
+					// address cannot be inferred
+					"nqn":      "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+					"nvmePort": "4420",
 				},
 			},
 			expectErr: true,
 			errCode:   "InvalidArgument",
-			errMsg:    "nqn",
+			errMsg:    "nvmeAddress",
 		},
 		{
 			name: "invalid IP address format",
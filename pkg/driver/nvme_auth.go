@@ -0,0 +1,57 @@
+package driver
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+)
+
+// Keys of the DH-HMAC-CHAP secrets in the node-stage secret of a StorageClass with
+// nvmeAuth: "dhchap" (csi.storage.k8s.io/node-stage-secret-name/namespace)
+const (
+	// secretDHCHAPSecret is the host secret, required
+	secretDHCHAPSecret = "dhchapSecret"
+
+	// secretDHCHAPCtrlSecret is the controller secret for bidirectional authentication, optional
+	secretDHCHAPCtrlSecret = "dhchapCtrlSecret"
+)
+
+// applyNVMeAuth sets the DH-HMAC-CHAP secrets of target from the node-stage secrets if the
+// volume context asks for authentication. It fails with FailedPrecondition if this node
+// cannot authenticate. Errors never include the secrets.
+func (ns *NodeServer) applyNVMeAuth(volumeContext, secrets map[string]string, target *nvme.Target) error {
+	switch auth := volumeContext[paramNVMeAuth]; auth {
+	case "":
+		return nil
+	case NVMeAuthDHCHAP:
+	default:
+		return status.Errorf(codes.InvalidArgument, "invalid %s %q in volume context (expected %q)", paramNVMeAuth, auth, NVMeAuthDHCHAP)
+	}
+
+	secret := secrets[secretDHCHAPSecret]
+	if secret == "" {
+		return status.Errorf(codes.InvalidArgument, "%s is %q but the node-stage secrets have no %s; set csi.storage.k8s.io/node-stage-secret-name on the StorageClass",
+			paramNVMeAuth, NVMeAuthDHCHAP, secretDHCHAPSecret)
+	}
+	if err := nvme.ValidateDHCHAPSecret(secret); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid %s in node-stage secrets: %v", secretDHCHAPSecret, err)
+	}
+	ctrlSecret := secrets[secretDHCHAPCtrlSecret]
+	if ctrlSecret != "" {
+		if err := nvme.ValidateDHCHAPSecret(ctrlSecret); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s in node-stage secrets: %v", secretDHCHAPCtrlSecret, err)
+		}
+	}
+
+	if ns.dhchapDetector != nil {
+		if err := ns.dhchapDetector(); err != nil {
+			return status.Errorf(codes.FailedPrecondition, "volume requires DH-HMAC-CHAP authentication but node %s cannot authenticate: %v",
+				ns.nodeID, err)
+		}
+	}
+
+	target.DHCHAPSecret = secret
+	target.DHCHAPCtrlSecret = ctrlSecret
+	return nil
+}
@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testDHCHAPSecret     = "DHHC-1:00:AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyAhIiMk:"
+	testDHCHAPCtrlSecret = "DHHC-1:01:KCkqKywtLi8wMTIzNDU2Nzg5Ojs8PT4/QEFCQ0RFRkdISUpL:"
+)
+
+// TestNodeStageVolume_DHCHAP tests that the node-stage secrets of a volume with
+// nvmeAuth: "dhchap" reach the connector and never the logs
+func TestNodeStageVolume_DHCHAP(t *testing.T) {
+	logs := captureKlog(t, "10")
+	conn := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	ns := discoveryNodeServer(conn, nil)
+	ns.dhchapDetector = func() error { return nil }
+
+	req := discoveryStageRequest(t, "10.42.68.1")
+	req.VolumeContext[paramNVMeAuth] = NVMeAuthDHCHAP
+	req.Secrets = map[string]string{
+		secretDHCHAPSecret:     testDHCHAPSecret,
+		secretDHCHAPCtrlSecret: testDHCHAPCtrlSecret,
+	}
+	if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+
+	if conn.lastTarget.DHCHAPSecret != testDHCHAPSecret || conn.lastTarget.DHCHAPCtrlSecret != testDHCHAPCtrlSecret {
+		t.Errorf("secrets not passed to the connector: %v", conn.lastTarget)
+	}
+	for _, secret := range []string{testDHCHAPSecret, testDHCHAPCtrlSecret} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("secret found in logs:\n%s", logs.String())
+		}
+	}
+}
+
+func TestNodeStageVolume_DHCHAPErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		auth      string
+		secrets   map[string]string
+		detectErr error
+		wantCode  codes.Code
+	}{
+		{
+			name:      "unsupported node",
+			auth:      NVMeAuthDHCHAP,
+			secrets:   map[string]string{secretDHCHAPSecret: testDHCHAPSecret},
+			detectErr: errors.New("the kernel does not support DH-HMAC-CHAP authentication"),
+			wantCode:  codes.FailedPrecondition,
+		},
+		{
+			name:     "missing secret",
+			auth:     NVMeAuthDHCHAP,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "malformed secret",
+			auth:     NVMeAuthDHCHAP,
+			secrets:  map[string]string{secretDHCHAPSecret: "hunter2"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "malformed controller secret",
+			auth:     NVMeAuthDHCHAP,
+			secrets:  map[string]string{secretDHCHAPSecret: testDHCHAPSecret, secretDHCHAPCtrlSecret: "hunter2"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "unknown mode",
+			auth:     "tls",
+			secrets:  map[string]string{secretDHCHAPSecret: testDHCHAPSecret},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+			ns := discoveryNodeServer(conn, nil)
+			ns.dhchapDetector = func() error { return tt.detectErr }
+
+			req := discoveryStageRequest(t, "10.42.68.1")
+			req.VolumeContext[paramNVMeAuth] = tt.auth
+			req.Secrets = tt.secrets
+			_, err := ns.NodeStageVolume(context.Background(), req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("NodeStageVolume error = %v, want %v", err, tt.wantCode)
+			}
+			for _, secret := range tt.secrets {
+				if strings.Contains(err.Error(), secret) {
+					t.Errorf("error contains a secret: %v", err)
+				}
+			}
+			if conn.connectCalled {
+				t.Error("expected no connect")
+			}
+		})
+	}
+}
+
+// TestNodeStageVolume_NoAuth tests that volumes without nvmeAuth connect without
+// secrets and without checking DH-HMAC-CHAP support
+func TestNodeStageVolume_NoAuth(t *testing.T) {
+	conn := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	ns := discoveryNodeServer(conn, nil)
+	ns.dhchapDetector = func() error { return errors.New("unsupported") }
+
+	req := discoveryStageRequest(t, "10.42.68.1")
+	req.Secrets = map[string]string{secretDHCHAPSecret: testDHCHAPSecret}
+	if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+	if conn.lastTarget.HasAuth() {
+		t.Errorf("expected no authentication, got %v", conn.lastTarget)
+	}
+}
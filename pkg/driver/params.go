@@ -2,10 +2,18 @@ package driver
 
 import (
 	"fmt"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 // NVMe connection parameter keys for StorageClass
@@ -21,8 +29,15 @@ const (
 	// paramKeepAliveTmo is the keep-alive timeout parameter key
 	// Value: integer seconds, 0 for kernel default
 	paramKeepAliveTmo = "keepAliveTmo"
+
+	// paramNVMeAuth is the in-band authentication parameter key
+	// Value: "dhchap" to authenticate with the node-stage secrets, empty for none
+	paramNVMeAuth = "nvmeAuth"
 )
 
+// NVMeAuthDHCHAP authenticates NVMe/TCP connections with DH-HMAC-CHAP
+const NVMeAuthDHCHAP = "dhchap"
+
 // NVMEConnectionParams holds parsed NVMe connection parameters from StorageClass
 type NVMEConnectionParams struct {
 	// CtrlLossTmo is the controller loss timeout in seconds
@@ -34,6 +49,9 @@ type NVMEConnectionParams struct {
 
 	// KeepAliveTmo is the keep-alive timeout in seconds
 	KeepAliveTmo int
+
+	// Auth is the in-band authentication of the connection: "" or NVMeAuthDHCHAP
+	Auth string
 }
 
 // DefaultNVMEConnectionParams returns the default connection parameters
@@ -91,17 +109,35 @@ func ParseNVMEConnectionParams(params map[string]string) (NVMEConnectionParams,
 		config.KeepAliveTmo = parsed
 	}
 
+	// Parse nvmeAuth if present
+	if val, ok := params[paramNVMeAuth]; ok && val != "" {
+		if val != NVMeAuthDHCHAP {
+			return config, fmt.Errorf("%s must be %q or empty; got %q", paramNVMeAuth, NVMeAuthDHCHAP, val)
+		}
+		config.Auth = val
+	}
+
 	return config, nil
 }
 
 // ToVolumeContext converts NVMEConnectionParams to a string map for inclusion in VolumeContext
 // This allows the parameters to be passed from Controller to Node via CSI VolumeContext
 func ToVolumeContext(params NVMEConnectionParams) map[string]string {
-	return map[string]string{
+	volumeContext := map[string]string{
 		paramCtrlLossTmo:    fmt.Sprintf("%d", params.CtrlLossTmo),
 		paramReconnectDelay: fmt.Sprintf("%d", params.ReconnectDelay),
 		paramKeepAliveTmo:   fmt.Sprintf("%d", params.KeepAliveTmo),
 	}
+	params.addAuthToVolumeContext(volumeContext)
+	return volumeContext
+}
+
+// addAuthToVolumeContext records the in-band authentication in a VolumeContext map if
+// the connection authenticates, so the node knows to read the node-stage secrets
+func (p NVMEConnectionParams) addAuthToVolumeContext(volumeContext map[string]string) {
+	if p.Auth != "" {
+		volumeContext[paramNVMeAuth] = p.Auth
+	}
 }
 
 const (
@@ -152,3 +188,535 @@ func ParseMigrationTimeout(params map[string]string) time.Duration {
 
 	return timeout
 }
+
+// Size rounding policies for the sizeRoundingPolicy StorageClass parameter.
+// They control how requested bytes map onto the RouterOS file-size granularity.
+const (
+	paramSizeRoundingPolicy = "sizeRoundingPolicy"
+
+	// SizeRoundingUp rounds up to the next representable size (never under-allocates)
+	SizeRoundingUp = "up"
+	// SizeRoundingNearest rounds to the nearest representable size (may under-allocate)
+	SizeRoundingNearest = "nearest"
+	// SizeRoundingExactOrFail rejects sizes that cannot be represented exactly
+	SizeRoundingExactOrFail = "exact-or-fail"
+)
+
+// ParseSizeRoundingPolicy extracts sizeRoundingPolicy from parameters.
+// Returns SizeRoundingUp if not specified, or an error for unknown values.
+func ParseSizeRoundingPolicy(params map[string]string) (string, error) {
+	policy, ok := params[paramSizeRoundingPolicy]
+	if !ok || policy == "" {
+		return SizeRoundingUp, nil
+	}
+
+	switch policy {
+	case SizeRoundingUp, SizeRoundingNearest, SizeRoundingExactOrFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q (must be %s, %s or %s)",
+			paramSizeRoundingPolicy, policy, SizeRoundingUp, SizeRoundingNearest, SizeRoundingExactOrFail)
+	}
+}
+
+// RoundVolumeSize maps requested bytes onto a size RouterOS can represent exactly,
+// according to policy. The returned size is what will actually be provisioned.
+func RoundVolumeSize(requestedBytes int64, policy string) (int64, error) {
+	granularity := rds.FileSizeGranularity(requestedBytes)
+	remainder := requestedBytes % granularity
+	if remainder == 0 {
+		return requestedBytes, nil
+	}
+
+	floor := requestedBytes - remainder
+	switch policy {
+	case SizeRoundingUp:
+		return floor + granularity, nil
+	case SizeRoundingNearest:
+		if remainder*2 >= granularity {
+			return floor + granularity, nil
+		}
+		return floor, nil
+	case SizeRoundingExactOrFail:
+		return 0, fmt.Errorf("size %d bytes is not a multiple of the RouterOS file-size granularity (%d bytes)",
+			requestedBytes, granularity)
+	default:
+		return 0, fmt.Errorf("unknown size rounding policy %q", policy)
+	}
+}
+
+// Filesystem option parameter keys for StorageClass.
+// All are echoed into VolumeContext so the node sees them at stage time.
+const (
+	// paramInitialTrim runs fstrim once after a freshly formatted volume is mounted
+	// Value: "true" or "false" (default false)
+	paramInitialTrim = "initialTrim"
+
+	// paramDiscard adds the "discard" mount option for online discard
+	// Value: "true" or "false" (default false)
+	paramDiscard = "discard"
+
+	// paramFormatPolicy controls whether NodeStageVolume may run mkfs
+	// Value: FormatPolicyAuto or FormatPolicyNever (default auto)
+	paramFormatPolicy = "formatPolicy"
+
+	// paramQuotaBytes limits the space of the filesystem with a project quota on its root,
+	// enforced at stage time (ext4 and xfs, nodes need -enable-project-quota)
+	// Value: a quantity such as "10Gi" (default no quota)
+	paramQuotaBytes = "quotaBytes"
+)
+
+// Format policies for the formatPolicy StorageClass parameter
+const (
+	// FormatPolicyAuto formats volumes that have no filesystem yet
+	FormatPolicyAuto = "auto"
+	// FormatPolicyNever only mounts volumes that already carry a filesystem
+	FormatPolicyNever = "never"
+)
+
+// FilesystemOptions holds parsed filesystem options from StorageClass.
+// They only apply to filesystem volumes; block volumes reject them.
+type FilesystemOptions struct {
+	// InitialTrim discards unused blocks after the first format+mount (best-effort)
+	InitialTrim bool
+
+	// Discard mounts the filesystem with the "discard" option
+	Discard bool
+
+	// NeverFormat refuses to create a filesystem on an unformatted volume
+	NeverFormat bool
+
+	// QuotaBytes is the project quota of the filesystem (0 for none)
+	QuotaBytes int64
+}
+
+// IsSet reports whether any filesystem option is enabled
+func (o FilesystemOptions) IsSet() bool {
+	return o.InitialTrim || o.Discard || o.NeverFormat || o.QuotaBytes > 0
+}
+
+// ParseFilesystemOptions parses filesystem options from StorageClass parameters or VolumeContext.
+// Missing parameters default to false and formatPolicy to auto; invalid values return an error.
+func ParseFilesystemOptions(params map[string]string) (FilesystemOptions, error) {
+	var opts FilesystemOptions
+
+	if val, ok := params[paramInitialTrim]; ok && val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s value %q: %w", paramInitialTrim, val, err)
+		}
+		opts.InitialTrim = parsed
+	}
+
+	if val, ok := params[paramDiscard]; ok && val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s value %q: %w", paramDiscard, val, err)
+		}
+		opts.Discard = parsed
+	}
+
+	switch policy := params[paramFormatPolicy]; policy {
+	case "", FormatPolicyAuto:
+	case FormatPolicyNever:
+		opts.NeverFormat = true
+	default:
+		return opts, fmt.Errorf("invalid %s %q (must be %s or %s)",
+			paramFormatPolicy, policy, FormatPolicyAuto, FormatPolicyNever)
+	}
+
+	if val, ok := params[paramQuotaBytes]; ok && val != "" {
+		quantity, err := resource.ParseQuantity(val)
+		if err != nil || quantity.Value() <= 0 {
+			return opts, fmt.Errorf("invalid %s value %q (must be a positive size such as 10Gi)", paramQuotaBytes, val)
+		}
+		opts.QuotaBytes = quantity.Value()
+	}
+
+	return opts, nil
+}
+
+// addToVolumeContext records enabled filesystem options in a VolumeContext map.
+// Disabled options are omitted to keep VolumeContext unchanged for existing StorageClasses.
+func (o FilesystemOptions) addToVolumeContext(volumeContext map[string]string) {
+	if o.InitialTrim {
+		volumeContext[paramInitialTrim] = "true"
+	}
+	if o.Discard {
+		volumeContext[paramDiscard] = "true"
+	}
+	if o.NeverFormat {
+		volumeContext[paramFormatPolicy] = FormatPolicyNever
+	}
+	if o.QuotaBytes > 0 {
+		volumeContext[paramQuotaBytes] = strconv.FormatInt(o.QuotaBytes, 10)
+	}
+}
+
+// Block device node parameter keys for StorageClass.
+// Both are echoed into VolumeContext so the node sees them at publish time.
+const (
+	// paramBlockDeviceMode is the permission mode of the device node NodePublishVolume
+	// creates for block volumes. Readonly publishes drop the write bits.
+	// Value: octal, e.g. "0660" (default DefaultBlockDeviceMode)
+	paramBlockDeviceMode = "blockDeviceMode"
+
+	// paramBlockDeviceOwner is the owner of that device node
+	// Value: "<uid>:<gid>" (default: the node plugin's user, root)
+	paramBlockDeviceOwner = "blockDeviceOwner"
+)
+
+// DefaultBlockDeviceMode is the mode of block device nodes without blockDeviceMode
+const DefaultBlockDeviceMode os.FileMode = 0660
+
+// blockDeviceModePattern matches three or four octal digits without special bits
+var blockDeviceModePattern = regexp.MustCompile(`^0?[0-7]{3}$`)
+
+// BlockDeviceOptions holds the parsed device node parameters of block volumes
+type BlockDeviceOptions struct {
+	// Mode is the permission mode of the device node
+	Mode os.FileMode
+
+	// UID and GID own the device node; -1 leaves the node plugin's user
+	UID int
+	GID int
+}
+
+// IsSet reports whether any option differs from the defaults
+func (o BlockDeviceOptions) IsSet() bool {
+	return o.Mode != DefaultBlockDeviceMode || o.UID >= 0
+}
+
+// ModeFor returns the device node mode for a publish, without write bits if readonly
+func (o BlockDeviceOptions) ModeFor(readonly bool) os.FileMode {
+	if readonly {
+		return o.Mode &^ 0222
+	}
+	return o.Mode
+}
+
+// ParseBlockDeviceOptions parses block device node options from StorageClass parameters
+// or VolumeContext. World-writable modes are rejected: the device node of a volume
+// must not be writable by every user of the pod.
+func ParseBlockDeviceOptions(params map[string]string) (BlockDeviceOptions, error) {
+	opts := BlockDeviceOptions{Mode: DefaultBlockDeviceMode, UID: -1, GID: -1}
+
+	if val := params[paramBlockDeviceMode]; val != "" {
+		if !blockDeviceModePattern.MatchString(val) {
+			return opts, fmt.Errorf("invalid %s %q (must be an octal mode such as 0660)", paramBlockDeviceMode, val)
+		}
+		mode, err := strconv.ParseUint(val, 8, 32)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s %q: %w", paramBlockDeviceMode, val, err)
+		}
+		if mode&0002 != 0 {
+			return opts, fmt.Errorf("invalid %s %q: world-writable modes are not allowed", paramBlockDeviceMode, val)
+		}
+		opts.Mode = os.FileMode(mode)
+	}
+
+	if val := params[paramBlockDeviceOwner]; val != "" {
+		uidStr, gidStr, ok := strings.Cut(val, ":")
+		uid, uidErr := strconv.ParseUint(uidStr, 10, 31)
+		gid, gidErr := strconv.ParseUint(gidStr, 10, 31)
+		if !ok || uidErr != nil || gidErr != nil {
+			return opts, fmt.Errorf("invalid %s %q (must be <uid>:<gid>, e.g. 1000:1000)", paramBlockDeviceOwner, val)
+		}
+		opts.UID, opts.GID = int(uid), int(gid)
+	}
+
+	return opts, nil
+}
+
+// addToVolumeContext records options that differ from the defaults in a VolumeContext map
+func (o BlockDeviceOptions) addToVolumeContext(volumeContext map[string]string) {
+	if o.Mode != DefaultBlockDeviceMode {
+		volumeContext[paramBlockDeviceMode] = fmt.Sprintf("%04o", uint32(o.Mode))
+	}
+	if o.UID >= 0 {
+		volumeContext[paramBlockDeviceOwner] = fmt.Sprintf("%d:%d", o.UID, o.GID)
+	}
+}
+
+// paramAdoptExisting lets CreateVolume take over a backing file that already exists
+// on RDS with the requested size instead of creating a new one.
+// Value: "true" or "false" (default false)
+const paramAdoptExisting = "adoptExisting"
+
+// ParseAdoptExisting extracts adoptExisting from StorageClass parameters.
+// Returns false if not specified, or an error for an invalid boolean.
+func ParseAdoptExisting(params map[string]string) (bool, error) {
+	val, ok := params[paramAdoptExisting]
+	if !ok || val == "" {
+		return false, nil
+	}
+	adopt, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: %w", paramAdoptExisting, val, err)
+	}
+	return adopt, nil
+}
+
+// paramVerifySnapshot makes CreateSnapshot check the copied backing file on RDS before
+// reporting the snapshot ready (VolumeSnapshotClass parameter).
+// Value: "true" or "false" (default false)
+const paramVerifySnapshot = "verifySnapshot"
+
+// ParseVerifySnapshot extracts verifySnapshot from VolumeSnapshotClass parameters.
+// Returns false if not specified, or an error for an invalid boolean.
+func ParseVerifySnapshot(params map[string]string) (bool, error) {
+	val, ok := params[paramVerifySnapshot]
+	if !ok || val == "" {
+		return false, nil
+	}
+	verify, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: %w", paramVerifySnapshot, val, err)
+	}
+	return verify, nil
+}
+
+// paramRDSOpTimeout gives the RouterOS /disk add creating a volume longer than the
+// controller's command timeout, for large volumes RDS takes minutes to allocate.
+// Value: a Go duration, e.g. "15m" (default: the command timeout)
+const paramRDSOpTimeout = "rdsOpTimeout"
+
+// ParseRDSOpTimeout extracts rdsOpTimeout from StorageClass parameters.
+// Returns 0 if not specified, or an error for an invalid or non-positive duration.
+func ParseRDSOpTimeout(params map[string]string) (time.Duration, error) {
+	val, ok := params[paramRDSOpTimeout]
+	if !ok || val == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q: %w", paramRDSOpTimeout, val, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be positive", paramRDSOpTimeout, val)
+	}
+	return timeout, nil
+}
+
+// paramAllowCrossNamespaceRestore lets CreateVolume restore a snapshot into a PVC of
+// another namespace than the snapshot's. Without it such restores are rejected, so a
+// snapshot ID leaked to another tenant cannot be used to clone the snapshot's data.
+// Value: "true" or "false" (default false)
+const paramAllowCrossNamespaceRestore = "allowCrossNamespaceRestore"
+
+// ParseAllowCrossNamespaceRestore extracts allowCrossNamespaceRestore from StorageClass
+// parameters. Returns false if not specified, or an error for an invalid boolean.
+func ParseAllowCrossNamespaceRestore(params map[string]string) (bool, error) {
+	val, ok := params[paramAllowCrossNamespaceRestore]
+	if !ok || val == "" {
+		return false, nil
+	}
+	allow, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: %w", paramAllowCrossNamespaceRestore, val, err)
+	}
+	return allow, nil
+}
+
+// paramAcknowledgeSharedBlockRisk confirms that MULTI_NODE_MULTI_WRITER volumes are only
+// written by one node at a time, as during KubeVirt live migration. Several nodes writing
+// a non-clustered filesystem (ext4, xfs) on the shared block device corrupts it.
+// Value: "true" or "false" (default false)
+const paramAcknowledgeSharedBlockRisk = "acknowledgeSharedBlockRisk"
+
+// clusteredFilesystems can be written by several nodes at once. The driver does not format
+// them; naming one in fsType declares that the workload manages it on the block device.
+var clusteredFilesystems = map[string]bool{
+	"gfs2":  true,
+	"ocfs2": true,
+}
+
+// formattableFilesystems are the fsTypes NodeStageVolume can create with mkfs
+var formattableFilesystems = map[string]bool{
+	"ext3": true,
+	"ext4": true,
+	"xfs":  true,
+}
+
+// ParseAllowedFSTypes parses the comma-separated --allowed-fstypes value into a set. Each
+// entry must be a filesystem the driver formats or a clustered filesystem. An empty value
+// returns nil, which allows any fsType.
+func ParseAllowedFSTypes(value string) (map[string]bool, error) {
+	var allowed map[string]bool
+	for _, fsType := range strings.Split(value, ",") {
+		fsType = strings.TrimSpace(fsType)
+		if fsType == "" {
+			continue
+		}
+		if !formattableFilesystems[fsType] && !clusteredFilesystems[fsType] {
+			return nil, fmt.Errorf("unsupported fsType %q (supported: ext3, ext4, xfs, gfs2, ocfs2)", fsType)
+		}
+		if allowed == nil {
+			allowed = make(map[string]bool)
+		}
+		allowed[fsType] = true
+	}
+	return allowed, nil
+}
+
+// formatFSTypes lists a set of fsTypes in sorted order for messages
+func formatFSTypes(fsTypes map[string]bool) string {
+	names := make([]string, 0, len(fsTypes))
+	for fsType := range fsTypes {
+		names = append(names, fsType)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ParseSharedBlockRiskAcknowledged reports whether StorageClass parameters allow
+// MULTI_NODE_MULTI_WRITER volumes: acknowledgeSharedBlockRisk is "true" or fsType is a
+// clustered filesystem. Returns an error for an invalid boolean.
+func ParseSharedBlockRiskAcknowledged(params map[string]string) (bool, error) {
+	if clusteredFilesystems[params[paramFSType]] {
+		return true, nil
+	}
+	val, ok := params[paramAcknowledgeSharedBlockRisk]
+	if !ok || val == "" {
+		return false, nil
+	}
+	acknowledged, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: %w", paramAcknowledgeSharedBlockRisk, val, err)
+	}
+	return acknowledged, nil
+}
+
+// IO limit parameter keys for StorageClass. RDS enforces them per volume from
+// RouterOS rds.VolumeQoSMinVersion; on older releases CreateVolume fails rather than
+// silently provisioning an unlimited volume. Unset keys mean no limit.
+const (
+	// paramMaxReadIOPS caps read operations per second
+	// Value: positive integer
+	paramMaxReadIOPS = "maxReadIOPS"
+
+	// paramMaxWriteIOPS caps write operations per second
+	// Value: positive integer
+	paramMaxWriteIOPS = "maxWriteIOPS"
+
+	// paramMaxBandwidth caps combined read and write throughput per second
+	// Value: positive quantity of bytes, e.g. "100Mi" or "50M"
+	paramMaxBandwidth = "maxBandwidth"
+)
+
+// ParseVolumeQoS parses per-volume IO limits from StorageClass parameters.
+// Missing parameters are unlimited; non-positive or malformed values return an error.
+func ParseVolumeQoS(params map[string]string) (rds.VolumeQoS, error) {
+	var qos rds.VolumeQoS
+
+	for _, iops := range []struct {
+		key   string
+		value *int64
+	}{
+		{paramMaxReadIOPS, &qos.MaxReadIOPS},
+		{paramMaxWriteIOPS, &qos.MaxWriteIOPS},
+	} {
+		val, ok := params[iops.key]
+		if !ok || val == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return qos, fmt.Errorf("invalid %s value %q: %w", iops.key, val, err)
+		}
+		if parsed < 1 {
+			return qos, fmt.Errorf("%s must be positive; got %d", iops.key, parsed)
+		}
+		*iops.value = parsed
+	}
+
+	if val, ok := params[paramMaxBandwidth]; ok && val != "" {
+		quantity, err := resource.ParseQuantity(val)
+		if err != nil {
+			return qos, fmt.Errorf("invalid %s value %q: %w", paramMaxBandwidth, val, err)
+		}
+		bytes, ok := quantity.AsInt64()
+		if !ok || bytes < 1 {
+			return qos, fmt.Errorf("%s must be a positive whole number of bytes; got %q", paramMaxBandwidth, val)
+		}
+		qos.MaxBandwidthBytes = bytes
+	}
+
+	return qos, nil
+}
+
+// Mutable parameter keys, set through a VolumeAttributesClass at creation or later
+// with ControllerModifyVolume. No other keys are accepted as mutable parameters.
+const (
+	// paramComment replaces the RDS disk comment (default "<namespace>/<pvc-name>")
+	// Value: alphanumerics, '.', '_', '/' and '-' (see utils.ValidateDiskComment)
+	paramComment = "comment"
+
+	// paramQoSTier names a QoS tier for the volume. It is only recorded for now:
+	// in VolumeContext at creation and as a PV annotation when modified.
+	// Value: DNS label, e.g. "gold"
+	paramQoSTier = "qosTier"
+)
+
+// PV annotations recording mutable parameters applied by ControllerModifyVolume
+const (
+	// AnnotationComment is the disk comment set with the comment parameter
+	AnnotationComment = "rds.csi.srvlab.io/comment"
+
+	// AnnotationQoSTier is the tier set with the qosTier parameter
+	AnnotationQoSTier = "rds.csi.srvlab.io/qos-tier"
+)
+
+// qosTierPattern matches a DNS label
+var qosTierPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// MutableParameters holds parsed VolumeAttributesClass parameters.
+// Empty fields were not specified and leave the volume unchanged.
+type MutableParameters struct {
+	// Comment replaces the RDS disk comment
+	Comment string
+
+	// QoSTier is recorded but not enforced
+	QoSTier string
+}
+
+// ParseMutableParameters parses VolumeAttributesClass parameters. Unknown keys and
+// invalid values return an error naming the accepted keys.
+func ParseMutableParameters(params map[string]string) (MutableParameters, error) {
+	var mutable MutableParameters
+
+	var unsupported []string
+	for key := range params {
+		if key != paramComment && key != paramQoSTier {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return mutable, fmt.Errorf("unsupported mutable parameters %s (accepted: %s, %s)",
+			strings.Join(unsupported, ", "), paramComment, paramQoSTier)
+	}
+
+	if comment := params[paramComment]; comment != "" {
+		if err := utils.ValidateDiskComment(comment); err != nil {
+			return mutable, fmt.Errorf("invalid %s: %w", paramComment, err)
+		}
+		mutable.Comment = comment
+	}
+
+	if tier := params[paramQoSTier]; tier != "" {
+		if !qosTierPattern.MatchString(tier) {
+			return mutable, fmt.Errorf("invalid %s %q (must be a lowercase DNS label)", paramQoSTier, tier)
+		}
+		mutable.QoSTier = tier
+	}
+
+	return mutable, nil
+}
+
+// addToVolumeContext records the mutable parameters that nodes or tooling may read
+func (m MutableParameters) addToVolumeContext(volumeContext map[string]string) {
+	if m.QoSTier != "" {
+		volumeContext[volumeContextQoSTier] = m.QoSTier
+	}
+}
@@ -1,9 +1,13 @@
 package driver
 
 import (
+	"context"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
 )
 
 func TestParseNVMEConnectionParams_Defaults(t *testing.T) {
@@ -134,6 +138,11 @@ func TestParseNVMEConnectionParams_InvalidInputs(t *testing.T) {
 			params:        map[string]string{"reconnectDelay": "abc"},
 			errorContains: "invalid reconnectDelay",
 		},
+		{
+			name:          "nvmeAuth=tls (unsupported)",
+			params:        map[string]string{"nvmeAuth": "tls"},
+			errorContains: "nvmeAuth must be",
+		},
 		{
 			name:          "keepAliveTmo=-1 (must be >= 0)",
 			params:        map[string]string{"keepAliveTmo": "-1"},
@@ -191,6 +200,7 @@ func TestToVolumeContext_RoundTrip(t *testing.T) {
 		CtrlLossTmo:    600,
 		ReconnectDelay: 10,
 		KeepAliveTmo:   45,
+		Auth:           NVMeAuthDHCHAP,
 	}
 
 	ctx := ToVolumeContext(original)
@@ -208,6 +218,9 @@ func TestToVolumeContext_RoundTrip(t *testing.T) {
 	if parsed.KeepAliveTmo != original.KeepAliveTmo {
 		t.Errorf("KeepAliveTmo: expected %d, got %d", original.KeepAliveTmo, parsed.KeepAliveTmo)
 	}
+	if parsed.Auth != original.Auth {
+		t.Errorf("Auth: expected %q, got %q", original.Auth, parsed.Auth)
+	}
 }
 
 func TestDefaultNVMEConnectionParams(t *testing.T) {
@@ -322,3 +335,339 @@ func TestParseMigrationTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRDSOpTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "not specified", params: map[string]string{}, want: 0},
+		{name: "empty", params: map[string]string{"rdsOpTimeout": ""}, want: 0},
+		{name: "minutes", params: map[string]string{"rdsOpTimeout": "15m"}, want: 15 * time.Minute},
+		{name: "invalid", params: map[string]string{"rdsOpTimeout": "15"}, wantErr: true},
+		{name: "zero", params: map[string]string{"rdsOpTimeout": "0s"}, wantErr: true},
+		{name: "negative", params: map[string]string{"rdsOpTimeout": "-1m"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRDSOpTimeout(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRDSOpTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRDSOpTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateCommandTimeout(t *testing.T) {
+	if got := createCommandTimeout(context.Background(), 0); got != 0 {
+		t.Errorf("expected 0 without rdsOpTimeout, got %v", got)
+	}
+	if got := createCommandTimeout(context.Background(), 15*time.Minute); got != 15*time.Minute {
+		t.Errorf("expected 15m without a deadline, got %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if got := createCommandTimeout(ctx, 15*time.Minute); got <= 0 || got > 5*time.Minute {
+		t.Errorf("expected the timeout cut to the 5m deadline, got %v", got)
+	}
+	if got := createCommandTimeout(ctx, time.Minute); got != time.Minute {
+		t.Errorf("expected 1m within the deadline, got %v", got)
+	}
+}
+
+func TestParseSizeRoundingPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]string
+		expected  string
+		expectErr bool
+	}{
+		{name: "not specified - defaults to up", params: map[string]string{}, expected: SizeRoundingUp},
+		{name: "empty string - defaults to up", params: map[string]string{"sizeRoundingPolicy": ""}, expected: SizeRoundingUp},
+		{name: "up", params: map[string]string{"sizeRoundingPolicy": "up"}, expected: SizeRoundingUp},
+		{name: "nearest", params: map[string]string{"sizeRoundingPolicy": "nearest"}, expected: SizeRoundingNearest},
+		{name: "exact-or-fail", params: map[string]string{"sizeRoundingPolicy": "exact-or-fail"}, expected: SizeRoundingExactOrFail},
+		{name: "unknown policy", params: map[string]string{"sizeRoundingPolicy": "down"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseSizeRoundingPolicy(tt.params)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got policy %q", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if policy != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, policy)
+			}
+		})
+	}
+}
+
+func TestParseFilesystemOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]string
+		expected  FilesystemOptions
+		expectErr bool
+	}{
+		{name: "not specified - both off", params: map[string]string{}, expected: FilesystemOptions{}},
+		{name: "empty strings - both off", params: map[string]string{"initialTrim": "", "discard": ""}, expected: FilesystemOptions{}},
+		{name: "initialTrim", params: map[string]string{"initialTrim": "true"}, expected: FilesystemOptions{InitialTrim: true}},
+		{name: "discard", params: map[string]string{"discard": "true"}, expected: FilesystemOptions{Discard: true}},
+		{name: "both", params: map[string]string{"initialTrim": "true", "discard": "true"}, expected: FilesystemOptions{InitialTrim: true, Discard: true}},
+		{name: "explicit false", params: map[string]string{"initialTrim": "false", "discard": "false"}, expected: FilesystemOptions{}},
+		{name: "invalid initialTrim", params: map[string]string{"initialTrim": "yes please"}, expectErr: true},
+		{name: "invalid discard", params: map[string]string{"discard": "on"}, expectErr: true},
+		{name: "formatPolicy auto", params: map[string]string{"formatPolicy": "auto"}, expected: FilesystemOptions{}},
+		{name: "formatPolicy never", params: map[string]string{"formatPolicy": "never"}, expected: FilesystemOptions{NeverFormat: true}},
+		{name: "invalid formatPolicy", params: map[string]string{"formatPolicy": "Never"}, expectErr: true},
+		{name: "quotaBytes", params: map[string]string{"quotaBytes": "10Gi"}, expected: FilesystemOptions{QuotaBytes: 10 << 30}},
+		{name: "quotaBytes in bytes", params: map[string]string{"quotaBytes": "1073741824"}, expected: FilesystemOptions{QuotaBytes: 1 << 30}},
+		{name: "zero quotaBytes", params: map[string]string{"quotaBytes": "0"}, expectErr: true},
+		{name: "invalid quotaBytes", params: map[string]string{"quotaBytes": "lots"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseFilesystemOptions(tt.params)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opts != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, opts)
+			}
+		})
+	}
+}
+
+func TestParseBlockDeviceOptions(t *testing.T) {
+	defaults := BlockDeviceOptions{Mode: DefaultBlockDeviceMode, UID: -1, GID: -1}
+	tests := []struct {
+		name      string
+		params    map[string]string
+		expected  BlockDeviceOptions
+		expectErr bool
+	}{
+		{name: "not specified", params: map[string]string{}, expected: defaults},
+		{name: "four digits", params: map[string]string{"blockDeviceMode": "0600"}, expected: BlockDeviceOptions{Mode: 0600, UID: -1, GID: -1}},
+		{name: "three digits", params: map[string]string{"blockDeviceMode": "664"}, expected: BlockDeviceOptions{Mode: 0664, UID: -1, GID: -1}},
+		{name: "owner", params: map[string]string{"blockDeviceOwner": "1000:2000"}, expected: BlockDeviceOptions{Mode: DefaultBlockDeviceMode, UID: 1000, GID: 2000}},
+		{name: "world-writable", params: map[string]string{"blockDeviceMode": "0666"}, expectErr: true},
+		{name: "setuid", params: map[string]string{"blockDeviceMode": "4660"}, expectErr: true},
+		{name: "not octal", params: map[string]string{"blockDeviceMode": "0680"}, expectErr: true},
+		{name: "symbolic", params: map[string]string{"blockDeviceMode": "rw-rw----"}, expectErr: true},
+		{name: "owner without group", params: map[string]string{"blockDeviceOwner": "1000"}, expectErr: true},
+		{name: "negative owner", params: map[string]string{"blockDeviceOwner": "-1:1000"}, expectErr: true},
+		{name: "named owner", params: map[string]string{"blockDeviceOwner": "qemu:kvm"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseBlockDeviceOptions(tt.params)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opts != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, opts)
+			}
+
+			// Options survive the round trip through VolumeContext
+			volumeContext := map[string]string{}
+			opts.addToVolumeContext(volumeContext)
+			if parsed, err := ParseBlockDeviceOptions(volumeContext); err != nil || parsed != opts {
+				t.Errorf("round trip through %v gave %+v, %v", volumeContext, parsed, err)
+			}
+		})
+	}
+
+	if mode := (BlockDeviceOptions{Mode: 0664}).ModeFor(true); mode != 0444 {
+		t.Errorf("readonly mode = %04o, want 0444", uint32(mode))
+	}
+}
+
+func TestParseVolumeQoS(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]string
+		expected  rds.VolumeQoS
+		expectErr bool
+	}{
+		{name: "not specified - unlimited", params: map[string]string{}, expected: rds.VolumeQoS{}},
+		{name: "empty strings - unlimited", params: map[string]string{"maxReadIOPS": "", "maxBandwidth": ""}, expected: rds.VolumeQoS{}},
+		{name: "IOPS limits", params: map[string]string{"maxReadIOPS": "5000", "maxWriteIOPS": "2500"},
+			expected: rds.VolumeQoS{MaxReadIOPS: 5000, MaxWriteIOPS: 2500}},
+		{name: "binary bandwidth", params: map[string]string{"maxBandwidth": "100Mi"}, expected: rds.VolumeQoS{MaxBandwidthBytes: 100 * 1024 * 1024}},
+		{name: "decimal bandwidth", params: map[string]string{"maxBandwidth": "50M"}, expected: rds.VolumeQoS{MaxBandwidthBytes: 50_000_000}},
+		{name: "invalid IOPS", params: map[string]string{"maxReadIOPS": "fast"}, expectErr: true},
+		{name: "zero IOPS", params: map[string]string{"maxWriteIOPS": "0"}, expectErr: true},
+		{name: "negative IOPS", params: map[string]string{"maxReadIOPS": "-100"}, expectErr: true},
+		{name: "invalid bandwidth", params: map[string]string{"maxBandwidth": "lots"}, expectErr: true},
+		{name: "fractional bandwidth", params: map[string]string{"maxBandwidth": "0.5"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qos, err := ParseVolumeQoS(tt.params)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", qos)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if qos != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, qos)
+			}
+		})
+	}
+}
+
+func TestRoundVolumeSize(t *testing.T) {
+	const (
+		MiB = int64(1024 * 1024)
+		GiB = 1024 * MiB
+		TiB = 1024 * GiB
+	)
+
+	tests := []struct {
+		name      string
+		requested int64
+		policy    string
+		expected  int64
+		expectErr bool
+	}{
+		// Exact sizes are unchanged by every policy
+		{name: "exact GiB - up", requested: 5 * GiB, policy: SizeRoundingUp, expected: 5 * GiB},
+		{name: "exact GiB - nearest", requested: 5 * GiB, policy: SizeRoundingNearest, expected: 5 * GiB},
+		{name: "exact GiB - exact-or-fail", requested: 5 * GiB, policy: SizeRoundingExactOrFail, expected: 5 * GiB},
+		{name: "exact TiB - exact-or-fail", requested: 2 * TiB, policy: SizeRoundingExactOrFail, expected: 2 * TiB},
+
+		// 1.5 GiB (1536 MiB)
+		{name: "1.5GiB - up", requested: GiB + 512*MiB, policy: SizeRoundingUp, expected: 2 * GiB},
+		{name: "1.5GiB - nearest rounds half up", requested: GiB + 512*MiB, policy: SizeRoundingNearest, expected: 2 * GiB},
+		{name: "1.5GiB - exact-or-fail", requested: GiB + 512*MiB, policy: SizeRoundingExactOrFail, expectErr: true},
+
+		// One byte over a GiB boundary
+		{name: "GiB+1 byte - up", requested: 10*GiB + 1, policy: SizeRoundingUp, expected: 11 * GiB},
+		{name: "GiB+1 byte - nearest", requested: 10*GiB + 1, policy: SizeRoundingNearest, expected: 10 * GiB},
+		{name: "GiB+1 byte - exact-or-fail", requested: 10*GiB + 1, policy: SizeRoundingExactOrFail, expectErr: true},
+
+		// Just under a TiB crosses into the next unit when rounded up
+		{name: "1023.5GiB - up", requested: 1023*GiB + 512*MiB, policy: SizeRoundingUp, expected: TiB},
+
+		// TiB granularity above 1 TiB
+		{name: "1.25TiB - up", requested: TiB + 256*GiB, policy: SizeRoundingUp, expected: 2 * TiB},
+		{name: "1.25TiB - nearest", requested: TiB + 256*GiB, policy: SizeRoundingNearest, expected: TiB},
+		{name: "1.25TiB - exact-or-fail", requested: TiB + 256*GiB, policy: SizeRoundingExactOrFail, expectErr: true},
+
+		{name: "unknown policy", requested: GiB + 1, policy: "sideways", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RoundVolumeSize(tt.requested, tt.policy)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("RoundVolumeSize(%d, %s) = %d, expected %d", tt.requested, tt.policy, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMutableParameters(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]string
+		expected  MutableParameters
+		expectErr string
+	}{
+		{name: "not specified", params: nil, expected: MutableParameters{}},
+		{name: "comment and tier", params: map[string]string{"comment": "team-a/db", "qosTier": "gold"},
+			expected: MutableParameters{Comment: "team-a/db", QoSTier: "gold"}},
+		{name: "empty values", params: map[string]string{"comment": "", "qosTier": ""}, expected: MutableParameters{}},
+		{name: "invalid comment", params: map[string]string{"comment": `x" slot=evil`}, expectErr: "invalid comment"},
+		{name: "invalid tier", params: map[string]string{"qosTier": "Gold Plus"}, expectErr: "invalid qosTier"},
+		{name: "unsupported keys", params: map[string]string{"maxReadIOPS": "100", "fsType": "xfs", "comment": "ok"},
+			expectErr: "unsupported mutable parameters fsType, maxReadIOPS (accepted: comment, qosTier)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mutable, err := ParseMutableParameters(tt.params)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expected error containing %q, got %v (%+v)", tt.expectErr, err, mutable)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mutable != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, mutable)
+			}
+		})
+	}
+}
+
+func TestParseAllowedFSTypes(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: " , ", want: nil},
+		{value: "xfs", want: map[string]bool{"xfs": true}},
+		{value: "ext4, xfs,gfs2", want: map[string]bool{"ext4": true, "xfs": true, "gfs2": true}},
+		{value: "xfs,btrfs", wantErr: true},
+		{value: "XFS", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseAllowedFSTypes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAllowedFSTypes(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAllowedFSTypes(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
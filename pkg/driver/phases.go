@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// Phases of NodeStageVolume. Each runs with its own share of the stage deadline so a
+// timeout can be attributed to the step that used up the time.
+const (
+	PhaseConnect    = "connect"     // NVMe/TCP connect, including retries
+	PhaseDeviceWait = "device_wait" // Waiting for the block device to become readable
+	PhaseFormat     = "format"      // Filesystem health check and mkfs
+	PhaseMount      = "mount"       // Mount to the staging path and initial trim
+)
+
+// PhaseBudgets maps phase names to their share (0-1) of an operation's deadline
+type PhaseBudgets map[string]float64
+
+// DefaultStagePhaseBudgets returns the default split of the NodeStageVolume deadline
+func DefaultStagePhaseBudgets() PhaseBudgets {
+	return PhaseBudgets{
+		PhaseConnect:    0.4,
+		PhaseDeviceWait: 0.2,
+		PhaseFormat:     0.3,
+		PhaseMount:      0.1,
+	}
+}
+
+// ParsePhaseBudgets parses a comma-separated list of "phase=percent" pairs, e.g.
+// "connect=50,format=20", overriding those phases in defaults. Only phases present
+// in defaults are accepted, and the resulting shares must add up to 100%.
+func ParsePhaseBudgets(value string, defaults PhaseBudgets) (PhaseBudgets, error) {
+	budgets := PhaseBudgets{}
+	for phase, share := range defaults {
+		budgets[phase] = share
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		phase, percentStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid phase budget %q (expected phase=percent)", pair)
+		}
+		phase = strings.TrimSpace(phase)
+		if _, known := defaults[phase]; !known {
+			return nil, fmt.Errorf("unknown phase %q (valid: %s)", phase, strings.Join(defaults.phases(), ", "))
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSpace(percentStr), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid budget %q for phase %s (must be a percentage between 0 and 100)", percentStr, phase)
+		}
+		budgets[phase] = percent / 100
+	}
+
+	total := 0.0
+	for _, share := range budgets {
+		total += share
+	}
+	if math.Abs(total-1) > 0.001 {
+		return nil, fmt.Errorf("phase budgets add up to %.0f%%, must be 100%%", total*100)
+	}
+	return budgets, nil
+}
+
+// phases returns the phase names in alphabetical order
+func (b PhaseBudgets) phases() []string {
+	phases := make([]string, 0, len(b))
+	for phase := range b {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	return phases
+}
+
+// PhaseTimeoutError reports a phase of a node operation that ran out of time
+type PhaseTimeoutError struct {
+	Operation string        // e.g. "stage"
+	Phase     string        // Phase that timed out
+	Budget    time.Duration // Time the phase was allowed, 0 if only the operation deadline applied
+	Elapsed   time.Duration // Time the phase ran
+	Completed []string      // Phases that finished before this one
+	Err       error         // Error returned by the phase
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	budget := "operation deadline"
+	if e.Budget > 0 {
+		budget = e.Budget.Round(time.Millisecond).String()
+	}
+	completed := "none"
+	if len(e.Completed) > 0 {
+		completed = strings.Join(e.Completed, ", ")
+	}
+	return fmt.Sprintf("%s phase %s timed out after %v (budget %s, completed phases: %s): %v",
+		e.Operation, e.Phase, e.Elapsed.Round(time.Millisecond), budget, completed, e.Err)
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// phaseRunner runs the phases of one node operation. Each phase gets a context bounded by
+// its share of the time left on the operation's context when the runner was created, and
+// its duration is recorded in rds_csi_stage_phase_duration_seconds.
+type phaseRunner struct {
+	ctx       context.Context
+	operation string
+	budgets   PhaseBudgets
+	total     time.Duration // Time left on ctx at the start, 0 without a deadline
+	metrics   *observability.Metrics
+	completed []string
+}
+
+func newPhaseRunner(ctx context.Context, operation string, budgets PhaseBudgets, metrics *observability.Metrics) *phaseRunner {
+	r := &phaseRunner{
+		ctx:       ctx,
+		operation: operation,
+		budgets:   budgets,
+		metrics:   metrics,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		r.total = time.Until(deadline)
+	}
+	return r
+}
+
+// budget returns how long phase may run, or 0 if it is only bounded by the operation deadline
+func (r *phaseRunner) budget(phase string) time.Duration {
+	share, ok := r.budgets[phase]
+	if !ok || r.total <= 0 {
+		return 0
+	}
+	return time.Duration(float64(r.total) * share)
+}
+
+// run runs fn as phase. If fn fails after the phase's context expired, the error is
+// returned as a *PhaseTimeoutError naming the phase, its budget and the phases completed.
+func (r *phaseRunner) run(phase string, fn func(ctx context.Context) error) error {
+	ctx := r.ctx
+	budget := r.budget(phase)
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(r.ctx, budget)
+		defer cancel()
+	}
+
+	started := time.Now()
+	err := fn(ctx)
+	elapsed := time.Since(started)
+	if r.metrics != nil {
+		r.metrics.RecordStagePhase(phase, elapsed)
+	}
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &PhaseTimeoutError{
+				Operation: r.operation,
+				Phase:     phase,
+				Budget:    budget,
+				Elapsed:   elapsed,
+				Completed: append([]string(nil), r.completed...),
+				Err:       err,
+			}
+		}
+		return err
+	}
+
+	// Steps that cannot be interrupted (mkfs, mount) may finish late; the next phases
+	// then have less time than their budget
+	if budget > 0 && elapsed > budget {
+		klog.Warningf("%s phase %s took %v, over its budget of %v", r.operation, phase, elapsed, budget)
+	}
+	klog.V(4).Infof("%s phase %s completed in %v", r.operation, phase, elapsed)
+	r.completed = append(r.completed, phase)
+	return nil
+}
@@ -0,0 +1,109 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePhaseBudgets(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  PhaseBudgets
+		expectErr string
+	}{
+		{name: "empty keeps defaults", value: "", expected: DefaultStagePhaseBudgets()},
+		{name: "override two phases", value: "connect=50, format=20",
+			expected: PhaseBudgets{PhaseConnect: 0.5, PhaseDeviceWait: 0.2, PhaseFormat: 0.2, PhaseMount: 0.1}},
+		{name: "all phases", value: "connect=25,device_wait=25,format=25,mount=25",
+			expected: PhaseBudgets{PhaseConnect: 0.25, PhaseDeviceWait: 0.25, PhaseFormat: 0.25, PhaseMount: 0.25}},
+		{name: "does not total 100", value: "connect=60", expectErr: "add up to 120%"},
+		{name: "unknown phase", value: "resize=10", expectErr: "unknown phase"},
+		{name: "missing percent", value: "connect", expectErr: "expected phase=percent"},
+		{name: "zero", value: "mount=0", expectErr: "invalid budget"},
+		{name: "not a number", value: "mount=lots", expectErr: "invalid budget"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budgets, err := ParsePhaseBudgets(tt.value, DefaultStagePhaseBudgets())
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(budgets) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, budgets)
+			}
+			for phase, share := range tt.expected {
+				if budgets[phase] != share {
+					t.Errorf("phase %s: expected %v, got %v", phase, share, budgets[phase])
+				}
+			}
+		})
+	}
+}
+
+func TestPhaseRunner(t *testing.T) {
+	budgets := PhaseBudgets{"first": 0.5, "second": 0.5}
+
+	t.Run("phase contexts are bounded by their budget", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		r := newPhaseRunner(ctx, "unstage", budgets, nil)
+
+		if err := r.run("first", func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if !ok || time.Until(deadline) > 110*time.Millisecond {
+				t.Errorf("expected a deadline within the 100ms budget, got %v", time.Until(deadline))
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("first phase failed: %v", err)
+		}
+
+		err := r.run("second", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		var timeoutErr *PhaseTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("expected PhaseTimeoutError, got %v", err)
+		}
+		if timeoutErr.Operation != "unstage" || timeoutErr.Phase != "second" || len(timeoutErr.Completed) != 1 || timeoutErr.Budget <= 0 {
+			t.Errorf("unexpected timeout error: %+v", timeoutErr)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("timeout error should wrap the phase error: %v", err)
+		}
+	})
+
+	t.Run("failures before the deadline are returned unchanged", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		errFailed := errors.New("mount failed")
+		err := newPhaseRunner(ctx, "stage", budgets, nil).run("first", func(context.Context) error { return errFailed })
+		if err != errFailed {
+			t.Errorf("expected the phase error, got %v", err)
+		}
+	})
+
+	t.Run("without a deadline phases are unbounded", func(t *testing.T) {
+		r := newPhaseRunner(context.Background(), "stage", budgets, nil)
+		if err := r.run("first", func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); ok {
+				t.Error("phase context should have no deadline")
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("phase failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/mount"
+)
+
+// volumeQuotaProjectID is the filesystem project the root of a volume with a quotaBytes
+// limit is assigned to. Every volume has its own filesystem, so one ID serves them all.
+const volumeQuotaProjectID uint32 = 1
+
+// quotaStatsTTL is how long NodeGetVolumeStats reuses the quota it read for a volume,
+// about the interval kubelet collects volume stats at
+const quotaStatsTTL = time.Minute
+
+// quotaStatsCache holds the project quota last read for each volume, so volume stats
+// do not run xfs_quota on every call
+type quotaStatsCache struct {
+	mu      sync.Mutex
+	entries map[string]quotaStatsEntry
+}
+
+// quotaStatsEntry is a quota read at readAt; quota is nil when the read failed
+type quotaStatsEntry struct {
+	quota  *mount.ProjectQuota
+	readAt time.Time
+}
+
+// get returns the quota of volumeID read within quotaStatsTTL
+func (c *quotaStatsCache) get(volumeID string) (quotaStatsEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[volumeID]
+	if !ok || time.Since(entry.readAt) >= quotaStatsTTL {
+		return quotaStatsEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *quotaStatsCache) put(volumeID string, quota *mount.ProjectQuota) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]quotaStatsEntry)
+	}
+	c.entries[volumeID] = quotaStatsEntry{quota: quota, readAt: time.Now()}
+}
+
+func (c *quotaStatsCache) forget(volumeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, volumeID)
+}
+
+// supportsProjectQuota reports whether project quotas can be enforced on fsType ("" is
+// the default ext4)
+func supportsProjectQuota(fsType string) bool {
+	switch fsType {
+	case "", "ext4", "xfs":
+		return true
+	default:
+		return false
+	}
+}
+
+// applyVolumeQuota limits the filesystem mounted at stagingPath to limitBytes. A quota
+// that cannot be set leaves the volume unmounted, so the retried stage sets it again
+// rather than finding the volume staged without one.
+func (ns *NodeServer) applyVolumeQuota(volumeID, stagingPath string, limitBytes int64) error {
+	ns.quotaStats.forget(volumeID)
+	if err := ns.mounter.SetProjectQuota(stagingPath, volumeQuotaProjectID, limitBytes); err != nil {
+		if unmountErr := ns.mounter.Unmount(stagingPath); unmountErr != nil {
+			klog.Warningf("Failed to unmount %s after failing to set its quota: %v", stagingPath, unmountErr)
+		}
+		return fmt.Errorf("failed to set quota of volume %s: %w", volumeID, err)
+	}
+	return nil
+}
+
+// applyQuotaToStats reports the project quota of the volume at volumePath, if it has
+// one, as the size of the volume in stats, so consumers see the enforced limit rather
+// than the size of the device. The quota is read at most once per quotaStatsTTL, a
+// failed read included, so a hung filesystem is not queried on every call.
+func (ns *NodeServer) applyQuotaToStats(volumeID, volumePath string, stats *mount.DeviceStats) {
+	entry, ok := ns.quotaStats.get(volumeID)
+	if !ok {
+		quota, err := ns.mounter.GetProjectQuota(volumePath, volumeQuotaProjectID)
+		if err != nil {
+			klog.V(4).Infof("Failed to read quota of volume %s at %s (reporting filesystem usage): %v", volumeID, volumePath, err)
+		}
+		ns.quotaStats.put(volumeID, quota)
+		entry.quota = quota
+	}
+	quota := entry.quota
+	if quota == nil || quota.LimitBytes <= 0 {
+		return
+	}
+
+	available := quota.LimitBytes - quota.UsedBytes
+	if available < 0 {
+		available = 0
+	}
+	if available > stats.AvailableBytes {
+		available = stats.AvailableBytes
+	}
+	stats.TotalBytes = quota.LimitBytes
+	stats.UsedBytes = quota.UsedBytes
+	stats.AvailableBytes = available
+}
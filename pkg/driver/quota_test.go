@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/mount"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// TestNodeStageVolume_ProjectQuota tests that quotaBytes is enforced with a project
+// quota, and refused by nodes without -enable-project-quota
+func TestNodeStageVolume_ProjectQuota(t *testing.T) {
+	tests := []struct {
+		name          string
+		enabled       bool
+		fsType        string
+		quotaErr      error
+		wantCode      codes.Code
+		wantPrjquota  bool
+		wantUnmounted bool
+	}{
+		{name: "ext4", enabled: true, fsType: "ext4", wantCode: codes.OK},
+		{name: "xfs is mounted with prjquota", enabled: true, fsType: "xfs", wantCode: codes.OK, wantPrjquota: true},
+		{name: "node without project quotas", fsType: "ext4", wantCode: codes.FailedPrecondition},
+		{name: "unsupported filesystem", enabled: true, fsType: "ext3", wantCode: codes.InvalidArgument},
+		{name: "failed quota unmounts", enabled: true, fsType: "ext4", quotaErr: errors.New("xfs_quota failed"), wantCode: codes.Internal, wantUnmounted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := &mockMounter{isFormatted: true, quotaErr: tt.quotaErr}
+			ns := &NodeServer{
+				driver: &Driver{
+					name:               "rds.csi.srvlab.io",
+					version:            "test",
+					metrics:            observability.NewMetrics(),
+					enableProjectQuota: tt.enabled,
+				},
+				mounter:        mounter,
+				nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+				nodeID:         "test-node",
+				circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+			}
+			volCap := createFilesystemVolumeCapability()
+			volCap.GetMount().FsType = tt.fsType
+
+			_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+				VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+				StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+				VolumeCapability:  volCap,
+				VolumeContext: map[string]string{
+					"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+					"nvmeAddress": "10.42.68.1",
+					"nvmePort":    "4420",
+					"quotaBytes":  "1073741824",
+				},
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (err: %v)", tt.wantCode, status.Code(err), err)
+			}
+			if tt.wantCode == codes.FailedPrecondition || tt.wantCode == codes.InvalidArgument {
+				if mounter.mountCalled {
+					t.Error("volume must not be mounted")
+				}
+				return
+			}
+
+			if mounter.quotaEnabled != tt.fsType {
+				t.Errorf("EnableProjectQuota called for %q, want %q", mounter.quotaEnabled, tt.fsType)
+			}
+			if mounter.quotaLimit != 1<<30 {
+				t.Errorf("quota limit = %d, want %d", mounter.quotaLimit, 1<<30)
+			}
+			if got := slices.Contains(mounter.mountOptions, "prjquota"); got != tt.wantPrjquota {
+				t.Errorf("prjquota mount option = %v, want %v (options %v)", got, tt.wantPrjquota, mounter.mountOptions)
+			}
+			if mounter.unmountCalled != tt.wantUnmounted {
+				t.Errorf("unmount called = %v, want %v", mounter.unmountCalled, tt.wantUnmounted)
+			}
+		})
+	}
+}
+
+// TestNodeGetVolumeStats_ProjectQuota tests that the quota limit is reported as the
+// capacity of the volume
+func TestNodeGetVolumeStats_ProjectQuota(t *testing.T) {
+	tests := []struct {
+		name          string
+		quota         *mount.ProjectQuota
+		wantTotal     int64
+		wantUsed      int64
+		wantAvailable int64
+	}{
+		{name: "volume without quota", quota: &mount.ProjectQuota{UsedBytes: 100 << 20}, wantTotal: 4 << 30, wantUsed: 1 << 30, wantAvailable: 3 << 30},
+		{name: "quota below the free space", quota: &mount.ProjectQuota{UsedBytes: 1 << 30, LimitBytes: 2 << 30}, wantTotal: 2 << 30, wantUsed: 1 << 30, wantAvailable: 1 << 30},
+		{name: "quota above the free space", quota: &mount.ProjectQuota{UsedBytes: 1 << 30, LimitBytes: 8 << 30}, wantTotal: 8 << 30, wantUsed: 1 << 30, wantAvailable: 3 << 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := &mockMounter{
+				isLikelyMounted: true,
+				projectQuota:    tt.quota,
+				stats: &mount.DeviceStats{
+					TotalBytes:     4 << 30,
+					UsedBytes:      1 << 30,
+					AvailableBytes: 3 << 30,
+				},
+			}
+			ns := createNodeServerNoStaleChecker(mounter)
+			ns.driver.enableProjectQuota = true
+
+			resp, err := ns.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{
+				VolumeId:   "pvc-12345678-1234-1234-1234-123456789012",
+				VolumePath: "/var/lib/kubelet/pods/test-pod/volumes/test-volume",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, usage := range resp.Usage {
+				if usage.Unit != csi.VolumeUsage_BYTES {
+					continue
+				}
+				if usage.Total != tt.wantTotal || usage.Used != tt.wantUsed || usage.Available != tt.wantAvailable {
+					t.Errorf("bytes usage = %d/%d/%d (total/used/available), want %d/%d/%d",
+						usage.Total, usage.Used, usage.Available, tt.wantTotal, tt.wantUsed, tt.wantAvailable)
+				}
+			}
+		})
+	}
+}
+
+// TestNodeGetVolumeStats_QuotaCached tests that repeated stats calls read the quota
+// once, and that staging the volume again reads it anew
+func TestNodeGetVolumeStats_QuotaCached(t *testing.T) {
+	mounter := &mockMounter{
+		isLikelyMounted: true,
+		projectQuota:    &mount.ProjectQuota{UsedBytes: 1 << 30, LimitBytes: 2 << 30},
+		stats:           &mount.DeviceStats{TotalBytes: 4 << 30, UsedBytes: 1 << 30, AvailableBytes: 3 << 30},
+	}
+	ns := createNodeServerNoStaleChecker(mounter)
+	ns.driver.enableProjectQuota = true
+
+	volumeID := "pvc-12345678-1234-1234-1234-123456789012"
+	req := &csi.NodeGetVolumeStatsRequest{
+		VolumeId:   volumeID,
+		VolumePath: "/var/lib/kubelet/pods/test-pod/volumes/test-volume",
+	}
+	for range 3 {
+		if _, err := ns.NodeGetVolumeStats(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if mounter.quotaReads != 1 {
+		t.Errorf("quota read %d times, want 1", mounter.quotaReads)
+	}
+
+	if err := ns.applyVolumeQuota(volumeID, "/var/lib/kubelet/staging", 2<<30); err != nil {
+		t.Fatalf("applyVolumeQuota failed: %v", err)
+	}
+	if _, err := ns.NodeGetVolumeStats(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mounter.quotaReads != 2 {
+		t.Errorf("quota read %d times after setting it, want 2", mounter.quotaReads)
+	}
+}
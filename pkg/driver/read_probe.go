@@ -0,0 +1,245 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+const (
+	// readProbeSize is how much of each device a read probe reads, one O_DIRECT-aligned block
+	readProbeSize = 4096
+
+	// readProbeSpacing is the minimum time between two probe reads, so a node issues at
+	// most 10 of them per second however many volumes it has staged
+	readProbeSpacing = 100 * time.Millisecond
+
+	// readProbeTimeout is how long a probe read may take before the volume counts as failed.
+	// The read itself cannot be cancelled; the volume is not probed again until it returns.
+	readProbeTimeout = 10 * time.Second
+)
+
+// volumeReadProber periodically reads the first 4KiB of every staged volume's device,
+// bypassing the page cache, so IO errors on an NVMe path show up in the volume condition
+// before an application touches cold data. It never writes.
+type volumeReadProber struct {
+	interval time.Duration
+	spacing  time.Duration
+	timeout  time.Duration
+	resolve  func(nqn string) (string, error) // device path of a connected NQN
+	read     func(devicePath string) error    // reads the device (injectable for tests)
+	metrics  *observability.Metrics
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	volumes map[string]*probedVolume // Staged volumes by volume ID
+}
+
+// probedVolume is the probe state of one staged volume
+type probedVolume struct {
+	nqn        string
+	recovering int   // Mount recoveries in progress; the volume is not probed meanwhile
+	inFlight   bool  // A probe read has not returned yet
+	err        error // Why the last probe failed, nil while healthy
+}
+
+// newVolumeReadProber creates a prober that probes every staged volume once per interval
+func newVolumeReadProber(interval time.Duration, resolve func(nqn string) (string, error), metrics *observability.Metrics) *volumeReadProber {
+	return &volumeReadProber{
+		interval: interval,
+		spacing:  readProbeSpacing,
+		timeout:  readProbeTimeout,
+		resolve:  resolve,
+		read:     readDeviceHead,
+		metrics:  metrics,
+		volumes:  make(map[string]*probedVolume),
+	}
+}
+
+// Start probes the staged volumes every interval until Stop is called
+func (p *volumeReadProber) Start(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	klog.Infof("Starting volume read probe (interval=%v)", p.interval)
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.probeAll(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops a started prober
+func (p *volumeReadProber) Stop() {
+	if p != nil && p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// Track adds a staged volume to the probed set, or updates its NQN
+func (p *volumeReadProber) Track(volumeID, nqn string) {
+	if p == nil || nqn == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if v, ok := p.volumes[volumeID]; ok {
+		v.nqn = nqn
+		return
+	}
+	p.volumes[volumeID] = &probedVolume{nqn: nqn}
+}
+
+// Untrack removes an unstaged volume from the probed set
+func (p *volumeReadProber) Untrack(volumeID string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.volumes, volumeID)
+}
+
+// BeginRecovery suspends probing of volumeID until the returned function is called. A
+// probe that completes during the recovery is discarded.
+func (p *volumeReadProber) BeginRecovery(volumeID string) func() {
+	if p == nil {
+		return func() {}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.volumes[volumeID]
+	if !ok {
+		return func() {}
+	}
+	v.recovering++
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		v.recovering--
+	}
+}
+
+// Condition returns why the last probe of volumeID failed, or nil if it succeeded or the
+// volume was not probed yet
+func (p *volumeReadProber) Condition(volumeID string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if v, ok := p.volumes[volumeID]; ok {
+		return v.err
+	}
+	return nil
+}
+
+// probeAll probes each staged volume once, waiting spacing between probes
+func (p *volumeReadProber) probeAll(ctx context.Context) {
+	p.mu.Lock()
+	volumeIDs := make([]string, 0, len(p.volumes))
+	for volumeID := range p.volumes {
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	p.mu.Unlock()
+	sort.Strings(volumeIDs)
+
+	for i, volumeID := range volumeIDs {
+		if i > 0 {
+			select {
+			case <-time.After(p.spacing):
+			case <-ctx.Done():
+				return
+			}
+		}
+		p.probe(volumeID)
+	}
+}
+
+// probe reads the device of volumeID and records the outcome
+func (p *volumeReadProber) probe(volumeID string) {
+	p.mu.Lock()
+	v, ok := p.volumes[volumeID]
+	if !ok || v.recovering > 0 || v.inFlight {
+		p.mu.Unlock()
+		return
+	}
+	v.inFlight = true
+	nqn := v.nqn
+	p.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		devicePath, err := p.resolve(nqn)
+		if err == nil {
+			err = p.read(devicePath)
+		}
+		p.mu.Lock()
+		v.inFlight = false
+		p.mu.Unlock()
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(p.timeout):
+		err = fmt.Errorf("read did not complete within %v", p.timeout)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.volumes[volumeID] != v || v.recovering > 0 {
+		return // Unstaged or under recovery since the probe started
+	}
+	if err != nil {
+		if p.metrics != nil {
+			p.metrics.RecordReadProbeFailure()
+		}
+		if v.err == nil {
+			klog.Warningf("Read probe of volume %s (NQN %s) failed: %v", volumeID, nqn, err)
+		}
+	} else if v.err != nil {
+		klog.Infof("Read probe of volume %s succeeded again after: %v", volumeID, v.err)
+	}
+	v.err = err
+}
+
+// readDeviceHead reads the first readProbeSize bytes of devicePath with O_DIRECT, so the
+// read reaches the device rather than the page cache
+func readDeviceHead(devicePath string) error {
+	f, err := os.OpenFile(devicePath, os.O_RDONLY|unix.O_DIRECT, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	// O_DIRECT needs a block-aligned buffer; anonymous mappings are page aligned
+	buf, err := unix.Mmap(-1, 0, readProbeSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return fmt.Errorf("failed to allocate probe buffer: %w", err)
+	}
+	defer func() { _ = unix.Munmap(buf) }()
+
+	if _, err := f.ReadAt(buf, 0); err != nil {
+		return fmt.Errorf("failed to read %s: %w", devicePath, err)
+	}
+	return nil
+}
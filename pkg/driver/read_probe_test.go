@@ -0,0 +1,161 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/mount"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+const readProbeVolumeID = "pvc-12345678-1234-1234-1234-123456789012"
+
+// testReadProber returns a prober whose reads fail while failing is set
+func testReadProber(metrics *observability.Metrics, failing *atomic.Bool, reads *atomic.Int32) *volumeReadProber {
+	p := newVolumeReadProber(time.Hour, func(nqn string) (string, error) { return "/dev/nvme0n1", nil }, metrics)
+	p.spacing = time.Millisecond
+	p.read = func(devicePath string) error {
+		reads.Add(1)
+		if failing.Load() {
+			return errors.New("input/output error")
+		}
+		return nil
+	}
+	return p
+}
+
+func probeFailures(t *testing.T, metrics *observability.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, "rds_csi_volume_read_probe_failures_total ") {
+			return strings.TrimPrefix(line, "rds_csi_volume_read_probe_failures_total ")
+		}
+	}
+	return ""
+}
+
+func TestVolumeReadProber_ConditionFlipsAndRecovers(t *testing.T) {
+	metrics := observability.NewMetrics()
+	var failing atomic.Bool
+	var reads atomic.Int32
+	p := testReadProber(metrics, &failing, &reads)
+	p.Track(readProbeVolumeID, "nqn.2000-02.com.mikrotik:"+readProbeVolumeID)
+
+	p.probeAll(context.Background())
+	if err := p.Condition(readProbeVolumeID); err != nil {
+		t.Fatalf("expected a healthy volume, got %v", err)
+	}
+
+	failing.Store(true)
+	p.probeAll(context.Background())
+	p.probeAll(context.Background())
+	if err := p.Condition(readProbeVolumeID); err == nil || !strings.Contains(err.Error(), "input/output error") {
+		t.Errorf("expected the read error as condition, got %v", err)
+	}
+	if got := probeFailures(t, metrics); got != "2" {
+		t.Errorf("expected 2 probe failures recorded, got %q", got)
+	}
+
+	failing.Store(false)
+	p.probeAll(context.Background())
+	if err := p.Condition(readProbeVolumeID); err != nil {
+		t.Errorf("expected the volume to recover, got %v", err)
+	}
+
+	p.Untrack(readProbeVolumeID)
+	p.probeAll(context.Background())
+	if got := reads.Load(); got != 4 {
+		t.Errorf("expected no read of an untracked volume, got %d reads", got)
+	}
+}
+
+func TestVolumeReadProber_SkippedDuringRecovery(t *testing.T) {
+	var failing atomic.Bool
+	var reads atomic.Int32
+	p := testReadProber(nil, &failing, &reads)
+	p.Track(readProbeVolumeID, "nqn.2000-02.com.mikrotik:"+readProbeVolumeID)
+
+	failing.Store(true)
+	done := p.BeginRecovery(readProbeVolumeID)
+	p.probeAll(context.Background())
+	if reads.Load() != 0 {
+		t.Error("expected no probe while the volume is under recovery")
+	}
+	done()
+
+	p.probeAll(context.Background())
+	if reads.Load() != 1 || p.Condition(readProbeVolumeID) == nil {
+		t.Error("expected probing to resume after recovery")
+	}
+}
+
+func TestVolumeReadProber_HungRead(t *testing.T) {
+	var reads atomic.Int32
+	release := make(chan struct{})
+	p := newVolumeReadProber(time.Hour, func(nqn string) (string, error) { return "/dev/nvme0n1", nil }, nil)
+	p.timeout = 20 * time.Millisecond
+	p.read = func(devicePath string) error {
+		reads.Add(1)
+		<-release
+		return nil
+	}
+	p.Track(readProbeVolumeID, "nqn.2000-02.com.mikrotik:"+readProbeVolumeID)
+
+	p.probeAll(context.Background())
+	if err := p.Condition(readProbeVolumeID); err == nil {
+		t.Error("expected a read that does not complete to mark the volume abnormal")
+	}
+
+	// The hung read is not repeated
+	p.probeAll(context.Background())
+	if got := reads.Load(); got != 1 {
+		t.Errorf("expected 1 read while the first is hung, got %d", got)
+	}
+	close(release)
+}
+
+func TestNodeGetVolumeStats_ReadProbeCondition(t *testing.T) {
+	mounter := &mockMounter{isLikelyMounted: true, stats: &mount.DeviceStats{TotalBytes: 1 << 30}}
+	ns := createNodeServerNoStaleChecker(mounter)
+	var failing atomic.Bool
+	var reads atomic.Int32
+	ns.readProber = testReadProber(nil, &failing, &reads)
+
+	stats := func() *csi.VolumeCondition {
+		t.Helper()
+		resp, err := ns.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{
+			VolumeId:   readProbeVolumeID,
+			VolumePath: t.TempDir(),
+		})
+		if err != nil {
+			t.Fatalf("NodeGetVolumeStats failed: %v", err)
+		}
+		return resp.VolumeCondition
+	}
+
+	// The first call tracks the volume, as after a node plugin restart
+	if condition := stats(); condition.Abnormal {
+		t.Fatalf("expected a normal condition before probing, got %q", condition.Message)
+	}
+
+	failing.Store(true)
+	ns.readProber.probeAll(context.Background())
+	if condition := stats(); !condition.Abnormal || !strings.Contains(condition.Message, "read probe failed") {
+		t.Errorf("expected an abnormal condition from the read probe, got %+v", condition)
+	}
+
+	failing.Store(false)
+	ns.readProber.probeAll(context.Background())
+	if condition := stats(); condition.Abnormal {
+		t.Errorf("expected the condition to recover, got %q", condition.Message)
+	}
+}
@@ -0,0 +1,128 @@
+package driver
+
+import (
+	"context"
+	stderrors "errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// AnnotationSlot records the slot a volume was found under after its slot was renamed on
+// RDS. It is written with --heal-renamed-slots, and on attach with --annotate-backend-details.
+const AnnotationSlot = "rds.csi.srvlab.io/slot"
+
+// isVolumeNotFound reports whether err means RDS has no volume under the looked-up key
+func isVolumeNotFound(err error) bool {
+	var notFoundErr *rds.VolumeNotFoundError
+	return stderrors.As(err, &notFoundErr) || stderrors.Is(err, utils.ErrVolumeNotFound)
+}
+
+// renamedVolumeKeys returns the secondary keys volumeID can be found by once its slot no
+// longer matches: the slot recorded on its PV by an earlier lookup, and its backing file
+// from the volume context or, for calls without one (DeleteVolume, ControllerExpandVolume),
+// from the PV
+func (cs *ControllerServer) renamedVolumeKeys(ctx context.Context, volumeID string, volumeContext map[string]string) (slot, filePath string) {
+	filePath = volumeContextBackingFile(volumeContext)
+	if cs.driver.k8sClient == nil {
+		return "", filePath
+	}
+
+	pv, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeID, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Warningf("Failed to get PV %s to look for a renamed slot: %v", volumeID, err)
+		}
+		return "", filePath
+	}
+	if filePath == "" && pv.Spec.CSI != nil {
+		filePath = volumeContextBackingFile(pv.Spec.CSI.VolumeAttributes)
+	}
+	return pv.Annotations[AnnotationSlot], filePath
+}
+
+// volumeContextBackingFile returns the backing file recorded in a volume context. Volumes
+// created before the filePath key carry the same path as volumePath.
+func volumeContextBackingFile(volumeContext map[string]string) string {
+	if filePath := volumeContext[volumeContextFilePath]; filePath != "" {
+		return filePath
+	}
+	return volumeContext[paramVolumePath]
+}
+
+// findRenamedVolume looks up volumeID, which no backend has a slot of that name for,
+// under the slot recorded on its PV and then by its backing file. A volume ID is only
+// ever created on one backend, so all backends are searched.
+func (cs *ControllerServer) findRenamedVolume(ctx context.Context, volumeID string, volumeContext map[string]string) (*rds.Backend, *rds.VolumeInfo, error) {
+	slot, filePath := cs.renamedVolumeKeys(ctx, volumeID, volumeContext)
+	backends := cs.driver.backendsFor(ctx)
+
+	if slot != "" && slot != volumeID {
+		backend, volume, err := backends.FindVolume(slot)
+		if err == nil {
+			klog.V(4).Infof("Volume %s found under its recorded slot %s", volumeID, slot)
+			return backend, volume, nil
+		}
+		if !isVolumeNotFound(err) {
+			return nil, nil, err
+		}
+	}
+
+	if filePath == "" {
+		return nil, nil, &rds.VolumeNotFoundError{Slot: volumeID}
+	}
+	backend, volume, err := backends.FindVolumeByFilePath(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	klog.V(1).Infof("Warning: volume %s was renamed on RDS backend %s and is now slot %s (backing file %s); using the new slot",
+		volumeID, backend.Name, volume.Slot, filePath)
+	if cs.driver.healRenamedSlots {
+		cs.recordRenamedSlot(ctx, volumeID, volume.Slot)
+	}
+	return backend, volume, nil
+}
+
+// recordRenamedSlot annotates the PV of volumeID with the slot it was found under, so
+// later lookups find it directly. Best effort - failures are logged.
+func (cs *ControllerServer) recordRenamedSlot(ctx context.Context, volumeID, slot string) {
+	if cs.driver.k8sClient == nil {
+		return
+	}
+	pvs := cs.driver.k8sClient.CoreV1().PersistentVolumes()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pv, err := pvs.Get(ctx, volumeID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if pv.Annotations[AnnotationSlot] == slot {
+			return nil
+		}
+		if pv.Annotations == nil {
+			pv.Annotations = map[string]string{}
+		}
+		pv.Annotations[AnnotationSlot] = slot
+		_, err = pvs.Update(ctx, pv, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Warningf("Failed to record renamed slot %s on PV %s: %v", slot, volumeID, err)
+		return
+	}
+	klog.Infof("Recorded renamed slot %s of volume %s on its PV", slot, volumeID)
+}
+
+// pvSlot returns the RDS slot of pv's volume: the recorded renamed slot, or its volume ID
+func pvSlot(pv *corev1.PersistentVolume) string {
+	if slot := pv.Annotations[AnnotationSlot]; slot != "" {
+		return slot
+	}
+	return pv.Spec.CSI.VolumeHandle
+}
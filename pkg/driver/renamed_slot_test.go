@@ -0,0 +1,156 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var renamedSlotTestCapability = &csi.VolumeCapability{
+	AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: "ext4"}},
+}
+
+// createVolumeWithPV creates volumeID and a PV for it carrying its volume context
+func createVolumeWithPV(t *testing.T, cs *ControllerServer, volumeID string) *csi.Volume {
+	t.Helper()
+	ctx := context.Background()
+	resp, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               volumeID,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{renamedSlotTestCapability},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: volumeID},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           DriverName,
+					VolumeHandle:     volumeID,
+					VolumeAttributes: resp.Volume.VolumeContext,
+				},
+			},
+		},
+	}
+	if _, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Create(ctx, pv, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create PV: %v", err)
+	}
+	return resp.Volume
+}
+
+func TestCreateVolume_FilePathInVolumeContext(t *testing.T) {
+	cs, mockRDS := testControllerServer(t)
+	volume := createVolumeWithPV(t, cs, testVolumeID1)
+
+	info, err := mockRDS.GetVolume(testVolumeID1)
+	if err != nil {
+		t.Fatalf("volume not created: %v", err)
+	}
+	if got := volume.VolumeContext[volumeContextFilePath]; got != info.FilePath {
+		t.Errorf("VolumeContext[filePath] = %q, want %q", got, info.FilePath)
+	}
+}
+
+// TestRenamedSlot_Lifecycle tests that expand, get and delete of a volume whose slot was
+// renamed on RDS act on the new slot, and that the slot is recorded on the PV when healing
+func TestRenamedSlot_Lifecycle(t *testing.T) {
+	const newSlot = "pvc-archive-data"
+
+	for _, tt := range []struct {
+		name string
+		heal bool
+	}{
+		{name: "without healing"},
+		{name: "with healing", heal: true},
+	} {
+		heal := tt.heal
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cs, mockRDS := testControllerServer(t)
+			cs.driver.healRenamedSlots = heal
+			createVolumeWithPV(t, cs, testVolumeID2)
+			mockRDS.RenameVolume(testVolumeID2, newSlot)
+
+			resp, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+				VolumeId:      testVolumeID2,
+				CapacityRange: &csi.CapacityRange{RequiredBytes: 2 << 30},
+			})
+			if err != nil {
+				t.Fatalf("ControllerExpandVolume failed: %v", err)
+			}
+			if resp.CapacityBytes != 2<<30 {
+				t.Errorf("expected capacity %d, got %d", int64(2<<30), resp.CapacityBytes)
+			}
+			renamed, err := mockRDS.GetVolume(newSlot)
+			if err != nil {
+				t.Fatalf("renamed volume missing: %v", err)
+			}
+			if renamed.FileSizeBytes != 2<<30 {
+				t.Errorf("expected renamed slot resized to %d, got %d", int64(2<<30), renamed.FileSizeBytes)
+			}
+
+			got, err := cs.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: testVolumeID2})
+			if err != nil {
+				t.Fatalf("ControllerGetVolume failed: %v", err)
+			}
+			if got.Volume.VolumeId != testVolumeID2 {
+				t.Errorf("expected volume ID %s, got %s", testVolumeID2, got.Volume.VolumeId)
+			}
+
+			pv, err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Get(ctx, testVolumeID2, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PV: %v", err)
+			}
+			wantAnnotation := ""
+			if heal {
+				wantAnnotation = newSlot
+			}
+			if pv.Annotations[AnnotationSlot] != wantAnnotation {
+				t.Errorf("expected %s annotation %q, got %q", AnnotationSlot, wantAnnotation, pv.Annotations[AnnotationSlot])
+			}
+
+			if _, err := cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: testVolumeID2}); err != nil {
+				t.Fatalf("DeleteVolume failed: %v", err)
+			}
+			if _, err := mockRDS.GetVolume(newSlot); err == nil {
+				t.Error("expected the renamed slot to be deleted")
+			}
+		})
+	}
+}
+
+// TestRenamedSlot_VolumeContext tests that a renamed volume is found by the backing file in
+// its volume context when it has no PV
+func TestRenamedSlot_VolumeContext(t *testing.T) {
+	cs, mockRDS := testControllerServer(t)
+	volume := createVolumeWithPV(t, cs, testVolumeID3)
+	mockRDS.RenameVolume(testVolumeID3, "pvc-archive-web")
+	if err := cs.driver.k8sClient.CoreV1().PersistentVolumes().Delete(context.Background(), testVolumeID3, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete PV: %v", err)
+	}
+
+	req := &csi.ValidateVolumeCapabilitiesRequest{
+		VolumeId:           testVolumeID3,
+		VolumeContext:      volume.VolumeContext,
+		VolumeCapabilities: []*csi.VolumeCapability{renamedSlotTestCapability},
+	}
+	if _, err := cs.ValidateVolumeCapabilities(context.Background(), req); err != nil {
+		t.Fatalf("expected renamed volume to be found, got %v", err)
+	}
+
+	// Without the backing file the renamed volume cannot be found
+	req.VolumeContext = nil
+	_, err := cs.ValidateVolumeCapabilities(context.Background(), req)
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package driver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/security"
+)
+
+// requestIDMetadataKey is the gRPC metadata key carrying a correlation ID for a CSI call.
+// Callers may set it; otherwise one is generated. It is returned in the response trailer.
+const requestIDMetadataKey = "x-csi-request-id"
+
+// requestIDPattern limits caller-supplied IDs to characters that are safe in log lines
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// requestIDInterceptor gives every CSI call a request ID, taken from the x-csi-request-id
+// metadata or generated. The ID is added to the contextual logger, tagged on the security
+// events and RDS audit entries of the call, and echoed in the response trailer so the
+// caller can log it too.
+func requestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestID := incomingRequestID(ctx)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	logger := klog.FromContext(ctx).WithValues("requestID", requestID, "method", info.FullMethod)
+	ctx = klog.NewContext(context.WithValue(ctx, requestIDKey{}, requestID), logger)
+	if err := grpc.SetTrailer(ctx, metadata.Pairs(requestIDMetadataKey, requestID)); err != nil {
+		logger.V(4).Info("Failed to set request ID trailer", "err", err)
+	}
+
+	logger.V(4).Info("CSI call started")
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	if err != nil {
+		logger.V(2).Info("CSI call failed", "code", status.Code(err), "duration", time.Since(started), "err", err)
+	} else {
+		logger.V(4).Info("CSI call finished", "duration", time.Since(started))
+	}
+	return resp, err
+}
+
+// incomingRequestID returns the caller's request ID, or "" if it sent none or an unusable one
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(requestIDMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	if !requestIDPattern.MatchString(values[0]) {
+		klog.V(4).Infof("Ignoring invalid %s metadata", requestIDMetadataKey)
+		return ""
+	}
+	return values[0]
+}
+
+// newRequestID generates a random 128-bit request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestIDFromContext returns the request ID the interceptor attached to ctx, if any
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// requestSecurityLogger returns the security logger for the CSI call of ctx, which tags its
+// events with the call's request ID
+func requestSecurityLogger(ctx context.Context) *security.Logger {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		return security.GetLogger().WithRequestID(requestID)
+	}
+	return security.GetLogger()
+}
+
+type rdsCallKey struct{}
+
+// withRDSCall records on ctx that the CSI call of ctx is operation working on volumeID (a
+// volume or snapshot ID). The RDS commands issued through backendsFor(ctx) carry both,
+// along with the request ID, in the audit log.
+func withRDSCall(ctx context.Context, operation, volumeID string) context.Context {
+	return context.WithValue(ctx, rdsCallKey{}, rds.Call{
+		Operation: operation,
+		VolumeID:  volumeID,
+		RequestID: requestIDFromContext(ctx),
+	})
+}
+
+// backendsFor returns the backends to use for the CSI call of ctx. Once withRDSCall has
+// named the call, their clients attribute the commands they run to it.
+func (d *Driver) backendsFor(ctx context.Context) *rds.ClientRegistry {
+	call, ok := ctx.Value(rdsCallKey{}).(rds.Call)
+	if !ok {
+		return d.getBackends()
+	}
+	return d.getBackends().ForCall(call)
+}
@@ -0,0 +1,256 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+// captureKlog sets klog verbosity to v and returns a buffer receiving all log output.
+// The previous settings are restored when the test ends.
+func captureKlog(t *testing.T, v string) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	for name, value := range map[string]string{"v": v, "logtostderr": "false", "alsologtostderr": "false"} {
+		if err := flags.Set(name, value); err != nil {
+			t.Fatalf("failed to set klog flag %s: %v", name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		klog.SetOutput(io.Discard)
+		_ = flags.Set("v", "0")
+		_ = flags.Set("logtostderr", "true")
+	})
+	return &buf
+}
+
+// startTestNodeServer serves a node service backed by mocks on a unix socket and returns a
+// client connected to it
+func startTestNodeServer(t *testing.T) csi.NodeClient {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "csi-rid")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	ns := &NodeServer{
+		driver:         &Driver{name: DriverName, version: "test", metrics: observability.NewMetrics()},
+		mounter:        &mockMounter{},
+		nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}
+	server := NewNonBlockingGRPCServer("unix://" + filepath.Join(dir, "csi.sock"))
+	if err := server.Start(nil, nil, ns); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("unix://"+filepath.Join(dir, "csi.sock"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return csi.NewNodeClient(conn)
+}
+
+func stageRequest(stagingPath string) *csi.NodeStageVolumeRequest {
+	return &csi.NodeStageVolumeRequest{
+		VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+		StagingTargetPath: stagingPath,
+		VolumeCapability:  createBlockVolumeCapability(),
+		VolumeContext: map[string]string{
+			"nqn":         "nqn.2000-02.com.mikrotik:pvc-12345678-1234-1234-1234-123456789012",
+			"nvmeAddress": "10.42.68.1",
+			"nvmePort":    "4420",
+		},
+	}
+}
+
+func TestRequestID_NodeStageVolume(t *testing.T) {
+	logs := captureKlog(t, "4")
+	client := startTestNodeServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, "kubelet-7f3a")
+
+	var trailer metadata.MD
+	if _, err := client.NodeStageVolume(ctx, stageRequest(filepath.Join(t.TempDir(), "staging")), grpc.Trailer(&trailer)); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+	if got := trailer.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "kubelet-7f3a" {
+		t.Errorf("expected the request ID echoed in the trailer, got %v", got)
+	}
+
+	klog.Flush()
+	output := logs.String()
+	for _, want := range []string{
+		// Structured lines of the contextual logger
+		`"CSI call started" requestID="kubelet-7f3a" method="/csi.v1.Node/NodeStageVolume"`,
+		`"CSI call finished" requestID="kubelet-7f3a"`,
+		// Security events for the stage and the NVMe connect
+		`type=volume_stage_success`,
+		`request_id=kubelet-7f3a operation=NodeStageVolume`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("logs missing %s", want)
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "[SECURITY]") && !strings.Contains(line, "request_id=kubelet-7f3a") {
+			t.Errorf("security event without the request ID: %s", line)
+		}
+	}
+}
+
+// TestRequestID_HandlerLogs tests that the lines the handler logs for the volume carry the
+// request ID from the incoming metadata
+func TestRequestID_HandlerLogs(t *testing.T) {
+	logs := captureKlog(t, "2")
+	client := startTestNodeServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, "kubelet-91c2")
+	if _, err := client.NodeStageVolume(ctx, stageRequest(filepath.Join(t.TempDir(), "staging"))); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+
+	klog.Flush()
+	const volume = `volumeID="pvc-12345678-1234-1234-1234-123456789012"`
+	var handlerLines int
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if !strings.Contains(line, volume) {
+			continue
+		}
+		handlerLines++
+		if !strings.Contains(line, `requestID="kubelet-91c2"`) {
+			t.Errorf("volume log line without the request ID: %s", line)
+		}
+	}
+	for _, want := range []string{"NodeStageVolume called", "Connected to NVMe target", "Successfully staged block volume"} {
+		if !strings.Contains(logs.String(), `"`+want+`" requestID="kubelet-91c2"`) {
+			t.Errorf("logs missing %q with the request ID", want)
+		}
+	}
+	if handlerLines == 0 {
+		t.Fatalf("no log lines for the volume:\n%s", logs.String())
+	}
+}
+
+func TestRequestID_GeneratedWhenMissingOrInvalid(t *testing.T) {
+	client := startTestNodeServer(t)
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	for name, md := range map[string]metadata.MD{
+		"missing":  nil,
+		"invalid":  metadata.Pairs(requestIDMetadataKey, "bad id security=forged"),
+		"too long": metadata.Pairs(requestIDMetadataKey, strings.Repeat("a", 129)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if md != nil {
+				ctx = metadata.NewOutgoingContext(ctx, md)
+			}
+
+			var trailer metadata.MD
+			if _, err := client.NodeStageVolume(ctx, stageRequest(filepath.Join(t.TempDir(), "staging")), grpc.Trailer(&trailer)); err != nil {
+				t.Fatalf("NodeStageVolume failed: %v", err)
+			}
+			if got := trailer.Get(requestIDMetadataKey); len(got) != 1 || !generated.MatchString(got[0]) {
+				t.Errorf("expected a generated request ID in the trailer, got %v", got)
+			}
+		})
+	}
+}
+
+func TestRequestID_TrailerOnError(t *testing.T) {
+	client := startTestNodeServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, "req-invalid")
+
+	var trailer metadata.MD
+	if _, err := client.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{}, grpc.Trailer(&trailer)); err == nil {
+		t.Fatal("expected an invalid request to fail")
+	}
+	if got := trailer.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-invalid" {
+		t.Errorf("expected the request ID in the trailer of a failed call, got %v", got)
+	}
+}
+
+// emptyExecutor answers every RouterOS command with no output
+type emptyExecutor struct{}
+
+func (emptyExecutor) Run(ctx context.Context, command string) (string, error) {
+	return "", nil
+}
+
+func TestBackendsFor_AttributesCommandsToCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := rds.NewAuditLog(rds.AuditConfig{Path: path, IncludeReadOnly: true})
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+	client, err := rds.NewClient(rds.ClientConfig{Address: "10.42.68.1", User: "admin", Executor: emptyExecutor{}, AuditLog: audit})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	d := &Driver{rdsClient: client, backends: rds.NewClientRegistry(client)}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	ctx = withRDSCall(ctx, "DeleteVolume", "pvc-abc")
+	_, _, _ = d.backendsFor(ctx).FindVolume("pvc-abc")
+	_, _, _ = d.backendsFor(context.Background()).FindVolume("pvc-def")
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	var entries []rds.AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry rds.AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid entry %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if got := entries[0]; got.Operation != "DeleteVolume" || got.VolumeID != "pvc-abc" || got.RequestID != "req-1" {
+		t.Errorf("entry of the call = %+v, want DeleteVolume of pvc-abc by req-1", got)
+	}
+	if got := entries[1]; got.Operation != "" || got.VolumeID != "" || got.RequestID != "" {
+		t.Errorf("entry without a call = %+v, want no attribution", got)
+	}
+}
@@ -1,15 +1,23 @@
 package driver
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 )
 
 const (
@@ -17,11 +25,39 @@ const (
 	maxMsgSize = 16 * 1024 * 1024 // 16 MiB
 )
 
+// ServerOptions configures transport security and call reporting for the gRPC server
+type ServerOptions struct {
+	// TLS certificate and key served on tcp:// endpoints (both or neither)
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSClientCAFile enables mTLS: clients must present a certificate signed by this CA
+	TLSClientCAFile string
+
+	// AllowInsecureTCP permits tcp:// endpoints without TLS (testing only)
+	AllowInsecureTCP bool
+
+	// Soft deadline after which a CSI call is reported as slow (0 disables), and
+	// per-method deadlines overriding it, keyed by method name such as "CreateVolume"
+	SlowOperationThreshold time.Duration
+	SlowOperationOverrides map[string]time.Duration
+
+	// Metrics counts slow calls (optional)
+	Metrics *observability.Metrics
+}
+
 // NonBlockingGRPCServer is a non-blocking gRPC server
 type NonBlockingGRPCServer struct {
 	server   *grpc.Server
-	listener net.Listener
 	endpoint string
+	options  ServerOptions
+	proto    string
+	addr     string
+
+	// mu protects listener and retired
+	mu       sync.Mutex
+	listener net.Listener
+	retired  map[net.Listener]bool // listeners replaced by Rebind, whose Serve errors are expected
 }
 
 // NewNonBlockingGRPCServer creates a new non-blocking gRPC server
@@ -31,6 +67,14 @@ func NewNonBlockingGRPCServer(endpoint string) *NonBlockingGRPCServer {
 	}
 }
 
+// NewNonBlockingGRPCServerWithOptions creates a new non-blocking gRPC server with transport options
+func NewNonBlockingGRPCServerWithOptions(endpoint string, options ServerOptions) *NonBlockingGRPCServer {
+	return &NonBlockingGRPCServer{
+		endpoint: endpoint,
+		options:  options,
+	}
+}
+
 // Start starts the gRPC server
 func (s *NonBlockingGRPCServer) Start(ids csi.IdentityServer, cs csi.ControllerServer, ns csi.NodeServer) error {
 	// Parse endpoint
@@ -40,25 +84,30 @@ func (s *NonBlockingGRPCServer) Start(ids csi.IdentityServer, cs csi.ControllerS
 	}
 
 	klog.V(4).Infof("Starting gRPC server on %s://%s", proto, addr)
+	s.proto = proto
+	s.addr = addr
 
-	// Remove existing socket file if it exists (unix sockets only)
-	if proto == "unix" {
-		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove existing socket: %w", err)
-		}
+	creds, err := s.transportCredentials()
+	if err != nil {
+		return err
 	}
 
-	// Create listener
-	listener, err := net.Listen(proto, addr)
+	listener, err := s.listen()
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s://%s: %w", proto, addr, err)
+		return err
 	}
-	s.listener = listener
 
 	// Configure gRPC server options
 	opts := []grpc.ServerOption{
 		grpc.MaxRecvMsgSize(maxMsgSize),
 		grpc.MaxSendMsgSize(maxMsgSize),
+		grpc.ChainUnaryInterceptor(
+			requestIDInterceptor,
+			slowOperationInterceptor(s.options.SlowOperationThreshold, s.options.SlowOperationOverrides, s.options.Metrics),
+		),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
 	}
 
 	// Create gRPC server
@@ -81,22 +130,168 @@ func (s *NonBlockingGRPCServer) Start(ids csi.IdentityServer, cs csi.ControllerS
 	}
 
 	// Start serving in a goroutine
-	klog.Infof("gRPC server listening on %s://%s", proto, addr)
+	klog.Infof("gRPC server listening on %s://%s (tls=%v)", proto, addr, creds != nil)
+	s.serve(listener)
+
+	return nil
+}
+
+// transportCredentials builds TLS credentials for tcp:// endpoints.
+// Returns nil credentials for unix sockets, which are protected by file permissions,
+// and for plaintext TCP when AllowInsecureTCP is set.
+func (s *NonBlockingGRPCServer) transportCredentials() (credentials.TransportCredentials, error) {
+	opts := s.options
+	tlsConfigured := opts.TLSCertFile != "" || opts.TLSKeyFile != "" || opts.TLSClientCAFile != ""
+
+	if s.proto != "tcp" {
+		if tlsConfigured {
+			return nil, fmt.Errorf("TLS options require a tcp:// endpoint, got %s://", s.proto)
+		}
+		return nil, nil
+	}
+
+	if !tlsConfigured {
+		if !opts.AllowInsecureTCP {
+			return nil, fmt.Errorf("refusing to serve plaintext gRPC on tcp://%s: configure TLS or allow insecure TCP", s.addr)
+		}
+		klog.Warningf("Serving plaintext gRPC on tcp://%s (INSECURE - for testing only)", s.addr)
+		return nil, nil
+	}
+
+	if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+		return nil, fmt.Errorf("TLS certificate and key must be specified together")
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if opts.TLSClientCAFile != "" {
+		caPEM, err := os.ReadFile(opts.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in TLS client CA file %s", opts.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// listen removes any stale unix socket file and opens a new listener
+func (s *NonBlockingGRPCServer) listen() (net.Listener, error) {
+	// Remove existing socket file if it exists (unix sockets only)
+	if s.proto == "unix" {
+		if err := os.Remove(s.addr); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove existing socket: %w", err)
+		}
+	}
+
+	// Create listener
+	listener, err := net.Listen(s.proto, s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s://%s: %w", s.proto, s.addr, err)
+	}
+	return listener, nil
+}
+
+// serve makes listener the active listener and serves on it in a goroutine
+func (s *NonBlockingGRPCServer) serve(listener net.Listener) {
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
 	go func() {
 		if err := s.server.Serve(listener); err != nil {
+			// Stop was called before Serve started (e.g. shutdown right after startup)
+			if errors.Is(err, grpc.ErrServerStopped) {
+				return
+			}
+			s.mu.Lock()
+			retired := s.retired[listener]
+			delete(s.retired, listener)
+			s.mu.Unlock()
+			if retired {
+				klog.V(4).Infof("Stopped serving on replaced listener: %v", err)
+				return
+			}
 			klog.Fatalf("Failed to serve: %v", err)
 		}
 	}()
+}
+
+// Rebind re-creates the unix socket and serves on it, replacing the current listener.
+// Used when the socket file was deleted from under a running server, which leaves the
+// old listener open but unreachable.
+func (s *NonBlockingGRPCServer) Rebind() error {
+	if s.server == nil || s.proto != "unix" {
+		return fmt.Errorf("rebind requires a started unix socket server")
+	}
+
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.listener
+	if old != nil {
+		if s.retired == nil {
+			s.retired = make(map[net.Listener]bool)
+		}
+		s.retired[old] = true
+	}
+	s.mu.Unlock()
 
+	// Closing the old unix listener would unlink the path, which now belongs to the new socket
+	if unixListener, ok := old.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	if old != nil {
+		_ = old.Close()
+	}
+
+	s.serve(listener)
+	klog.Infof("gRPC server re-listening on %s://%s", s.proto, s.addr)
 	return nil
 }
 
+// Addr returns the address the server is listening on (resolves port 0 for tcp endpoints)
+func (s *NonBlockingGRPCServer) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// SocketPath returns the unix socket path, or "" for non-unix endpoints
+func (s *NonBlockingGRPCServer) SocketPath() string {
+	if s.proto != "unix" {
+		return ""
+	}
+	return s.addr
+}
+
 // Stop stops the gRPC server
 func (s *NonBlockingGRPCServer) Stop() {
 	klog.Info("Stopping gRPC server")
 	if s.server != nil {
 		s.server.GracefulStop()
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.listener != nil {
 		_ = s.listener.Close()
 	}
@@ -0,0 +1,262 @@
+package driver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func callGetPluginInfo(t *testing.T, target string, creds credentials.TransportCredentials) error {
+	t.Helper()
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := csi.NewIdentityClient(conn).GetPluginInfo(ctx, &csi.GetPluginInfoRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.Name != DriverName {
+		t.Errorf("expected driver name %s, got %s", DriverName, resp.Name)
+	}
+	return nil
+}
+
+func startIdentityServer(t *testing.T, endpoint string, options ServerOptions) *NonBlockingGRPCServer {
+	t.Helper()
+	server := NewNonBlockingGRPCServerWithOptions(endpoint, options)
+	if err := server.Start(NewIdentityServer(&Driver{name: DriverName, version: "test"}), nil, nil); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(server.Stop)
+	return server
+}
+
+// testPKI holds PEM files for a CA, a server certificate for 127.0.0.1, and a client certificate
+type testPKI struct {
+	caFile, serverCertFile, serverKeyFile string
+	caPool                                *x509.CertPool
+	clientCert                            tls.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		return der, key
+	}
+
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	keyDER := func(key *ecdsa.PrivateKey) []byte {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %v", err)
+		}
+		return der
+	}
+
+	serverDER, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	clientDER, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+
+	pki := &testPKI{
+		caFile:         writePEM("ca.crt", "CERTIFICATE", caDER),
+		serverCertFile: writePEM("tls.crt", "CERTIFICATE", serverDER),
+		serverKeyFile:  writePEM("tls.key", "EC PRIVATE KEY", keyDER(serverKey)),
+		caPool:         x509.NewCertPool(),
+	}
+	pki.caPool.AddCert(caCert)
+	pki.clientCert, err = tls.X509KeyPair(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER(clientKey)}),
+	)
+	if err != nil {
+		t.Fatalf("failed to load client certificate: %v", err)
+	}
+	return pki
+}
+
+func TestNonBlockingGRPCServer_UnixSocket(t *testing.T) {
+	_, socketPath := startTestSocketServer(t)
+
+	if err := callGetPluginInfo(t, "unix://"+socketPath, insecure.NewCredentials()); err != nil {
+		t.Fatalf("GetPluginInfo over unix socket failed: %v", err)
+	}
+}
+
+func TestNonBlockingGRPCServer_InsecureTCP(t *testing.T) {
+	server := startIdentityServer(t, "tcp://127.0.0.1:0", ServerOptions{AllowInsecureTCP: true})
+
+	if err := callGetPluginInfo(t, server.Addr(), insecure.NewCredentials()); err != nil {
+		t.Fatalf("GetPluginInfo over plaintext tcp failed: %v", err)
+	}
+}
+
+func TestNonBlockingGRPCServer_MutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	server := startIdentityServer(t, "tcp://127.0.0.1:0", ServerOptions{
+		TLSCertFile:     pki.serverCertFile,
+		TLSKeyFile:      pki.serverKeyFile,
+		TLSClientCAFile: pki.caFile,
+	})
+
+	clientTLS := credentials.NewTLS(&tls.Config{
+		RootCAs:      pki.caPool,
+		Certificates: []tls.Certificate{pki.clientCert},
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err := callGetPluginInfo(t, server.Addr(), clientTLS); err != nil {
+		t.Fatalf("GetPluginInfo over mTLS failed: %v", err)
+	}
+
+	// Clients without a certificate are rejected
+	noClientCert := credentials.NewTLS(&tls.Config{RootCAs: pki.caPool, MinVersion: tls.VersionTLS12})
+	if err := callGetPluginInfo(t, server.Addr(), noClientCert); err == nil {
+		t.Error("expected client without certificate to be rejected")
+	}
+
+	// Plaintext clients are rejected
+	if err := callGetPluginInfo(t, server.Addr(), insecure.NewCredentials()); err == nil {
+		t.Error("expected plaintext client to be rejected")
+	}
+}
+
+func TestNonBlockingGRPCServer_InvalidTransportOptions(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		name      string
+		endpoint  string
+		options   ServerOptions
+		expectErr string
+	}{
+		{
+			name:      "plaintext tcp refused",
+			endpoint:  "tcp://127.0.0.1:0",
+			expectErr: "refusing to serve plaintext",
+		},
+		{
+			name:      "cert without key",
+			endpoint:  "tcp://127.0.0.1:0",
+			options:   ServerOptions{TLSCertFile: pki.serverCertFile},
+			expectErr: "must be specified together",
+		},
+		{
+			name:      "TLS on unix socket",
+			endpoint:  "unix:///tmp/csi-tls-test.sock",
+			options:   ServerOptions{TLSCertFile: pki.serverCertFile, TLSKeyFile: pki.serverKeyFile},
+			expectErr: "require a tcp:// endpoint",
+		},
+		{
+			name:     "CA file without certificates",
+			endpoint: "tcp://127.0.0.1:0",
+			options: ServerOptions{
+				TLSCertFile:     pki.serverCertFile,
+				TLSKeyFile:      pki.serverKeyFile,
+				TLSClientCAFile: pki.serverKeyFile,
+			},
+			expectErr: "no certificates found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewNonBlockingGRPCServerWithOptions(tt.endpoint, tt.options)
+			err := server.Start(NewIdentityServer(&Driver{name: DriverName, version: "test"}), nil, nil)
+			if err == nil {
+				server.Stop()
+				t.Fatal("expected Start to fail")
+			}
+			if !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestDriverStop_StopsGRPCServer(t *testing.T) {
+	for _, endpoint := range []string{"unix", "tcp"} {
+		t.Run(endpoint, func(t *testing.T) {
+			var server *NonBlockingGRPCServer
+			var target string
+			if endpoint == "unix" {
+				var socketPath string
+				server, socketPath = startTestSocketServer(t)
+				target = "unix://" + socketPath
+			} else {
+				server = startIdentityServer(t, "tcp://127.0.0.1:0", ServerOptions{AllowInsecureTCP: true})
+				target = server.Addr()
+			}
+
+			d := &Driver{name: DriverName, version: "test", server: server}
+			d.Stop()
+
+			if err := callGetPluginInfo(t, target, insecure.NewCredentials()); err == nil {
+				t.Error("expected calls to fail after driver stop")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// DefaultSlowOperationThreshold is the soft deadline after which a CSI call is reported as slow
+const DefaultSlowOperationThreshold = time.Minute
+
+// slowOperationInterceptor reports CSI calls still running after their soft deadline:
+// the threshold of the method from overrides, or threshold. Past the deadline it logs a
+// warning at -v=1 with the call's context and counts the call in
+// rds_csi_slow_operations_total{method}. The call is not cancelled; kubelet and the
+// sidecars keep enforcing their own timeouts. A deadline of 0 disables the report.
+func slowOperationInterceptor(threshold time.Duration, overrides map[string]time.Duration, metrics *observability.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := path.Base(info.FullMethod)
+		deadline := threshold
+		if override, ok := overrides[method]; ok {
+			deadline = override
+		}
+		if deadline <= 0 {
+			return handler(ctx, req)
+		}
+
+		logger := klog.FromContext(ctx)
+		if v, ok := req.(interface{ GetVolumeId() string }); ok && v.GetVolumeId() != "" {
+			logger = logger.WithValues("volumeID", v.GetVolumeId())
+		}
+		if n, ok := req.(interface{ GetNodeId() string }); ok && n.GetNodeId() != "" {
+			logger = logger.WithValues("nodeID", n.GetNodeId())
+		}
+
+		started := time.Now()
+		timer := time.AfterFunc(deadline, func() {
+			logger.V(1).Info("CSI call exceeded its soft deadline and is still running", "deadline", deadline, "elapsed", time.Since(started))
+			if metrics != nil {
+				metrics.RecordSlowOperation(method)
+			}
+		})
+		resp, err := handler(ctx, req)
+		if !timer.Stop() {
+			logger.V(1).Info("Slow CSI call finished", "deadline", deadline, "duration", time.Since(started))
+		}
+		return resp, err
+	}
+}
+
+// ParseSlowOperationOverrides parses a comma-separated list of "method=duration" pairs,
+// e.g. "CreateVolume=5m,NodeGetVolumeStats=10s", into per-method soft deadlines. Methods
+// are the names of Identity, Controller and Node RPCs; a duration of 0 disables the
+// report for the method.
+func ParseSlowOperationOverrides(value string) (map[string]time.Duration, error) {
+	known := csiMethodNames()
+	overrides := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		method, durationStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid slow operation override %q (expected method=duration)", pair)
+		}
+		method = strings.TrimSpace(method)
+		if !known[method] {
+			return nil, fmt.Errorf("unknown CSI method %q", method)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(durationStr))
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("invalid duration %q for method %s", durationStr, method)
+		}
+		overrides[method] = duration
+	}
+	return overrides, nil
+}
+
+// csiMethodNames returns the names of the RPCs the driver serves
+func csiMethodNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, desc := range []grpc.ServiceDesc{csi.Identity_ServiceDesc, csi.Controller_ServiceDesc, csi.Node_ServiceDesc} {
+		for _, method := range desc.Methods {
+			names[method.MethodName] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// callSlowOperationInterceptor runs a handler taking delay through the interceptor as
+// NodeStageVolume of testVolumeID1
+func callSlowOperationInterceptor(t *testing.T, interceptor grpc.UnaryServerInterceptor, delay time.Duration) {
+	t.Helper()
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodeStageVolume"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(delay)
+		return &csi.NodeStageVolumeResponse{}, nil
+	}
+	if _, err := interceptor(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: testVolumeID1}, info, handler); err != nil {
+		t.Fatalf("interceptor returned error: %v", err)
+	}
+}
+
+func TestSlowOperationInterceptor(t *testing.T) {
+	logs := captureKlog(t, "1")
+	metrics := observability.NewMetrics()
+	interceptor := slowOperationInterceptor(50*time.Millisecond, nil, metrics)
+
+	callSlowOperationInterceptor(t, interceptor, 200*time.Millisecond)
+
+	klog.Flush()
+	output := logs.String()
+	for _, want := range []string{
+		`"CSI call exceeded its soft deadline and is still running" volumeID="` + testVolumeID1 + `" deadline="50ms"`,
+		`"Slow CSI call finished"`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("logs missing %s, got:\n%s", want, output)
+		}
+	}
+	if body := scrapeMetrics(t, metrics); !strings.Contains(body, `rds_csi_slow_operations_total{method="NodeStageVolume"} 1`) {
+		t.Errorf("expected one slow NodeStageVolume counted, got:\n%s", body)
+	}
+}
+
+func TestSlowOperationInterceptor_FastAndDisabled(t *testing.T) {
+	logs := captureKlog(t, "1")
+	metrics := observability.NewMetrics()
+
+	// A call finishing in time, and a slow call of a method whose report is disabled
+	callSlowOperationInterceptor(t, slowOperationInterceptor(time.Second, nil, metrics), 0)
+	callSlowOperationInterceptor(t, slowOperationInterceptor(10*time.Millisecond, map[string]time.Duration{"NodeStageVolume": 0}, metrics), 50*time.Millisecond)
+
+	klog.Flush()
+	if strings.Contains(logs.String(), "soft deadline") {
+		t.Errorf("unexpected slow operation warning:\n%s", logs.String())
+	}
+	if body := scrapeMetrics(t, metrics); strings.Contains(body, "rds_csi_slow_operations_total{") {
+		t.Errorf("expected no slow operations counted, got:\n%s", body)
+	}
+}
+
+func TestSlowOperationInterceptor_Override(t *testing.T) {
+	metrics := observability.NewMetrics()
+	interceptor := slowOperationInterceptor(time.Hour, map[string]time.Duration{"NodeStageVolume": 20 * time.Millisecond}, metrics)
+
+	callSlowOperationInterceptor(t, interceptor, 100*time.Millisecond)
+
+	if body := scrapeMetrics(t, metrics); !strings.Contains(body, `rds_csi_slow_operations_total{method="NodeStageVolume"} 1`) {
+		t.Errorf("expected the override to apply, got:\n%s", body)
+	}
+}
+
+func TestParseSlowOperationOverrides(t *testing.T) {
+	overrides, err := ParseSlowOperationOverrides(" CreateVolume=5m, NodeGetVolumeStats=0 ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(overrides) != 2 || overrides["CreateVolume"] != 5*time.Minute || overrides["NodeGetVolumeStats"] != 0 {
+		t.Errorf("unexpected overrides %v", overrides)
+	}
+
+	for _, value := range []string{"CreateVolume", "CreateVolume=fast", "CreateVolume=-1s", "MakeVolume=1m"} {
+		if _, err := ParseSlowOperationOverrides(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// snapshotRestoreTracker counts in-progress restores per snapshot so DeleteSnapshot cannot
+// remove a snapshot while RDS is still copying it into a new volume. The zero value is ready to use.
+type snapshotRestoreTracker struct {
+	mu sync.Mutex
+
+	// restores maps snapshot ID -> restoring volume ID -> number of in-flight restores
+	restores map[string]map[string]int
+
+	// deleting counts DeleteSnapshot calls in progress per snapshot; restores of them are refused
+	deleting map[string]int
+}
+
+// beginRestore records a restore of snapshotID into volumeID. The returned function ends it
+// (calling it again is a no-op). Fails if the snapshot is being deleted.
+func (t *snapshotRestoreTracker) beginRestore(snapshotID, volumeID string) (func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.deleting[snapshotID] > 0 {
+		return nil, fmt.Errorf("snapshot %s is being deleted", snapshotID)
+	}
+	if t.restores == nil {
+		t.restores = make(map[string]map[string]int)
+	}
+	if t.restores[snapshotID] == nil {
+		t.restores[snapshotID] = make(map[string]int)
+	}
+	t.restores[snapshotID][volumeID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			volumes := t.restores[snapshotID]
+			if volumes[volumeID]--; volumes[volumeID] <= 0 {
+				delete(volumes, volumeID)
+			}
+			if len(volumes) == 0 {
+				delete(t.restores, snapshotID)
+			}
+		})
+	}, nil
+}
+
+// beginDelete marks snapshotID as being deleted so no new restore of it starts. If restores
+// are in progress it returns the volumes being restored and no release function.
+func (t *snapshotRestoreTracker) beginDelete(snapshotID string) (func(), []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if volumes := t.restores[snapshotID]; len(volumes) > 0 {
+		inUse := make([]string, 0, len(volumes))
+		for volumeID := range volumes {
+			inUse = append(inUse, volumeID)
+		}
+		sort.Strings(inUse)
+		return nil, inUse
+	}
+
+	if t.deleting == nil {
+		t.deleting = make(map[string]int)
+	}
+	t.deleting[snapshotID]++
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.deleting[snapshotID]--; t.deleting[snapshotID] <= 0 {
+			delete(t.deleting, snapshotID)
+		}
+	}, nil
+}
+
+// inProgress returns the number of restores currently running across all snapshots
+func (t *snapshotRestoreTracker) inProgress() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total := 0
+	for _, volumes := range t.restores {
+		for _, count := range volumes {
+			total += count
+		}
+	}
+	return total
+}
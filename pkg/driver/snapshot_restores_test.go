@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestoreTracker(t *testing.T) {
+	var tracker snapshotRestoreTracker
+
+	endA, err := tracker.beginRestore("snap-1", "pvc-a")
+	if err != nil {
+		t.Fatalf("beginRestore failed: %v", err)
+	}
+	endB, err := tracker.beginRestore("snap-1", "pvc-b")
+	if err != nil {
+		t.Fatalf("beginRestore failed: %v", err)
+	}
+	if got := tracker.inProgress(); got != 2 {
+		t.Errorf("inProgress() = %d, want 2", got)
+	}
+
+	if release, inUse := tracker.beginDelete("snap-1"); release != nil || !reflect.DeepEqual(inUse, []string{"pvc-a", "pvc-b"}) {
+		t.Errorf("beginDelete during restores = %v, want [pvc-a pvc-b]", inUse)
+	}
+
+	endA()
+	endA() // ending twice must not release pvc-b's restore
+	if _, inUse := tracker.beginDelete("snap-1"); !reflect.DeepEqual(inUse, []string{"pvc-b"}) {
+		t.Errorf("beginDelete = %v, want [pvc-b]", inUse)
+	}
+	endB()
+
+	release, inUse := tracker.beginDelete("snap-1")
+	if release == nil || len(inUse) != 0 {
+		t.Fatalf("beginDelete after restores = %v, want no restores", inUse)
+	}
+	if _, err := tracker.beginRestore("snap-1", "pvc-c"); err == nil {
+		t.Error("beginRestore should fail while the snapshot is being deleted")
+	}
+	release()
+	if _, err := tracker.beginRestore("snap-1", "pvc-c"); err != nil {
+		t.Errorf("beginRestore after delete finished failed: %v", err)
+	}
+}
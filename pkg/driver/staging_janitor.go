@@ -0,0 +1,333 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/mount"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+const (
+	// DefaultStagingJanitorInterval is how often the staging janitor scans by default
+	DefaultStagingJanitorInterval = 7 * 24 * time.Hour
+
+	// stagingJanitorStartJitter bounds the random delay of the scan at startup, so node
+	// plugins restarted together by a rollout do not all scan at once
+	stagingJanitorStartJitter = 10 * time.Minute
+
+	// DefaultStagingJanitorGracePeriod is how long a staging directory must be untouched
+	// before the janitor may remove it
+	DefaultStagingJanitorGracePeriod = 24 * time.Hour
+
+	// kubeletVolDataFile is the file kubelet writes next to globalmount recording which
+	// driver and volume handle a staging directory belongs to
+	kubeletVolDataFile = "vol_data.json"
+
+	// kubeletGlobalMount is the staging path kubelet passes to NodeStageVolume
+	kubeletGlobalMount = "globalmount"
+)
+
+// stagingJanitor removes staging directories that kubelet left behind for volumes that
+// are long gone. Kubelet stages volumes at <kubelet-root>/plugins/kubernetes.io/csi/<driver>/
+// <sha256 of volume handle>/globalmount, with vol_data.json beside it. A directory is
+// removed only if that layout checks out, nothing was written to it for the grace period,
+// it holds nothing but vol_data.json and an empty globalmount, nothing is mounted in it,
+// the volume's NVMe subsystem is not connected and no VolumeAttachment binds the volume
+// to this node. Any doubt keeps the directory.
+type stagingJanitor struct {
+	root       string // The driver's staging root under the kubelet plugin dir
+	driverName string
+	interval   time.Duration
+	grace      time.Duration
+	startDelay time.Duration // Upper bound of the random delay of the first scan
+	metrics    *observability.Metrics
+	cancel     context.CancelFunc
+
+	// mountPoints lists the mount points of the node (injectable for tests)
+	mountPoints func(ctx context.Context) ([]string, error)
+	// isConnected reports whether an NVMe subsystem is connected
+	isConnected func(ctx context.Context, nqn string) (bool, error)
+	// attachedVolumes returns the volume handles attached to this node, nil without a
+	// Kubernetes client
+	attachedVolumes func(ctx context.Context) (map[string]bool, error)
+	now             func() time.Time
+}
+
+// volData is the part of kubelet's vol_data.json the janitor checks
+type volData struct {
+	DriverName   string `json:"driverName"`
+	VolumeHandle string `json:"volumeHandle"`
+}
+
+// newStagingJanitor creates a janitor for the staging directories of driverName under kubeletDir
+func newStagingJanitor(kubeletDir, driverName string, interval, grace time.Duration, isConnected func(ctx context.Context, nqn string) (bool, error), metrics *observability.Metrics) *stagingJanitor {
+	return &stagingJanitor{
+		root:        filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", driverName),
+		driverName:  driverName,
+		interval:    interval,
+		grace:       grace,
+		startDelay:  min(stagingJanitorStartJitter, interval),
+		metrics:     metrics,
+		mountPoints: listMountPoints,
+		isConnected: isConnected,
+		now:         time.Now,
+	}
+}
+
+// listMountPoints returns the mount points of the node from the mount table
+func listMountPoints(ctx context.Context) ([]string, error) {
+	mounts, err := mount.GetMountsWithTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]string, 0, len(mounts))
+	for _, m := range mounts {
+		points = append(points, m.Mountpoint)
+	}
+	return points, nil
+}
+
+// attachedVolumesFunc returns a function listing the volume handles that VolumeAttachments
+// of driverName bind to nodeID
+func attachedVolumesFunc(client kubernetes.Interface, driverName, nodeID string) func(ctx context.Context) (map[string]bool, error) {
+	return func(ctx context.Context) (map[string]bool, error) {
+		vas, err := client.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list VolumeAttachments: %w", err)
+		}
+
+		handles := make(map[string]bool)
+		for _, va := range vas.Items {
+			if va.Spec.Attacher != driverName || va.Spec.NodeName != nodeID {
+				continue
+			}
+			if spec := va.Spec.Source.InlineVolumeSpec; spec != nil && spec.CSI != nil {
+				handles[spec.CSI.VolumeHandle] = true
+			}
+			pvName := va.Spec.Source.PersistentVolumeName
+			if pvName == nil {
+				continue
+			}
+			pv, err := client.CoreV1().PersistentVolumes().Get(ctx, *pvName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get PersistentVolume %s: %w", *pvName, err)
+			}
+			if pv.Spec.CSI != nil {
+				handles[pv.Spec.CSI.VolumeHandle] = true
+			}
+		}
+		return handles, nil
+	}
+}
+
+// Start scans the staging root shortly after startup, then every interval until Stop is
+// called. A node plugin restarted more often than the interval would otherwise never scan.
+func (j *stagingJanitor) Start(ctx context.Context) {
+	if j == nil {
+		return
+	}
+	var delay time.Duration
+	if j.startDelay > 0 {
+		delay = rand.N(j.startDelay)
+	}
+	klog.Infof("Starting staging directory janitor (root=%s, interval=%v, grace=%v, first scan in %v)", j.root, j.interval, j.grace, delay.Round(time.Second))
+
+	ctx, j.cancel = context.WithCancel(ctx)
+	go func() {
+		first := time.NewTimer(delay)
+		defer first.Stop()
+		select {
+		case <-first.C:
+			j.scan(ctx)
+		case <-ctx.Done():
+			return
+		}
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.scan(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// scan sweeps the staging root, logging why a scan was skipped
+func (j *stagingJanitor) scan(ctx context.Context) {
+	if err := j.sweep(ctx); err != nil {
+		klog.Warningf("Staging directory janitor skipped a scan: %v", err)
+	}
+}
+
+// Stop stops a started janitor
+func (j *stagingJanitor) Stop() {
+	if j != nil && j.cancel != nil {
+		j.cancel()
+	}
+}
+
+// sweep scans the staging root once and removes the orphaned directories. It returns an
+// error, removing nothing, if the evidence that protects live volumes cannot be gathered.
+func (j *stagingJanitor) sweep(ctx context.Context) error {
+	entries, err := os.ReadDir(j.root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read staging root: %w", err)
+	}
+
+	mountPoints, err := j.mountPoints(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the mount table: %w", err)
+	}
+	var attached map[string]bool
+	if j.attachedVolumes != nil {
+		if attached, err = j.attachedVolumes(ctx); err != nil {
+			return err
+		}
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(j.root, entry.Name())
+		volumeID, reason := j.checkOrphaned(ctx, dir, mountPoints, attached)
+		if reason != "" {
+			klog.V(5).Infof("Keeping staging directory %s: %s", dir, reason)
+			continue
+		}
+		if err := removeStagingDir(dir); err != nil {
+			klog.Warningf("Failed to remove orphaned staging directory %s of volume %s: %v", dir, volumeID, err)
+			continue
+		}
+		klog.V(2).Infof("Removed orphaned staging directory %s of volume %s", dir, volumeID)
+		removed++
+		if j.metrics != nil {
+			j.metrics.RecordStagingDirRemoved()
+		}
+	}
+	klog.V(4).Infof("Staging directory janitor removed %d of %d directories", removed, len(entries))
+	return nil
+}
+
+// checkOrphaned returns the volume of a staging directory, and why the directory must be
+// kept or "" if it may be removed
+func (j *stagingJanitor) checkOrphaned(ctx context.Context, dir string, mountPoints []string, attached map[string]bool) (string, string) {
+	// Never touch a directory with anything mounted in it, whatever else says
+	for _, point := range mountPoints {
+		if point == dir || strings.HasPrefix(point, dir+"/") {
+			return "", "mounted at " + point
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, kubeletVolDataFile))
+	if err != nil {
+		return "", "no readable " + kubeletVolDataFile
+	}
+	var vol volData
+	if err := json.Unmarshal(data, &vol); err != nil {
+		return "", "invalid " + kubeletVolDataFile
+	}
+	if vol.DriverName != j.driverName || vol.VolumeHandle == "" {
+		return "", fmt.Sprintf("%s is for driver %q", kubeletVolDataFile, vol.DriverName)
+	}
+	if filepath.Base(dir) != fmt.Sprintf("%x", sha256.Sum256([]byte(vol.VolumeHandle))) {
+		return vol.VolumeHandle, "directory name is not the hash of the volume handle"
+	}
+
+	if reason := j.checkIdle(dir); reason != "" {
+		return vol.VolumeHandle, reason
+	}
+
+	if attached[vol.VolumeHandle] {
+		return vol.VolumeHandle, "VolumeAttachment on this node"
+	}
+	nqn, err := utils.VolumeIDToNQN(vol.VolumeHandle)
+	if err != nil {
+		return vol.VolumeHandle, "NQN unknown: " + err.Error()
+	}
+	connected, err := j.isConnected(ctx, nqn)
+	if err != nil {
+		return vol.VolumeHandle, "NVMe connection state unknown: " + err.Error()
+	}
+	if connected {
+		return vol.VolumeHandle, "NVMe subsystem connected"
+	}
+	return vol.VolumeHandle, ""
+}
+
+// checkIdle returns why dir is not an idle leftover, or "" if it only holds vol_data.json
+// and an empty globalmount, all older than the grace period
+func (j *stagingJanitor) checkIdle(dir string) string {
+	cutoff := j.now().Add(-j.grace)
+	paths := []string{dir, filepath.Join(dir, kubeletVolDataFile)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err.Error()
+	}
+	for _, entry := range entries {
+		switch entry.Name() {
+		case kubeletVolDataFile:
+		case kubeletGlobalMount:
+			globalMount := filepath.Join(dir, kubeletGlobalMount)
+			contents, err := os.ReadDir(globalMount)
+			if err != nil {
+				return err.Error()
+			}
+			if len(contents) > 0 {
+				return kubeletGlobalMount + " is not empty"
+			}
+			paths = append(paths, globalMount)
+		default:
+			return "unexpected entry " + entry.Name()
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err.Error()
+		}
+		if info.ModTime().After(cutoff) {
+			return fmt.Sprintf("%s modified within the grace period", filepath.Base(path))
+		}
+	}
+	return ""
+}
+
+// removeStagingDir removes an idle staging directory entry by entry, so a directory that
+// gained contents since it was checked fails to remove instead of being deleted with them
+func removeStagingDir(dir string) error {
+	if err := os.Remove(filepath.Join(dir, kubeletGlobalMount)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, kubeletVolDataFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(dir)
+}
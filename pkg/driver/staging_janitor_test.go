@@ -0,0 +1,224 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// stagingTree builds a fake kubelet staging root for the janitor tests
+type stagingTree struct {
+	t    *testing.T
+	root string
+	old  time.Time
+}
+
+func newStagingTree(t *testing.T) *stagingTree {
+	return &stagingTree{t: t, root: t.TempDir(), old: time.Now().Add(-48 * time.Hour)}
+}
+
+// add creates the staging directory of volumeID as kubelet lays it out, aged past the
+// grace period, and returns its path
+func (s *stagingTree) add(volumeID string) string {
+	s.t.Helper()
+	dir := filepath.Join(s.root, fmt.Sprintf("%x", sha256.Sum256([]byte(volumeID))))
+	s.addAt(dir, fmt.Sprintf(`{"driverName":%q,"volumeHandle":%q}`, DriverName, volumeID))
+	return dir
+}
+
+func (s *stagingTree) addAt(dir, volData string) {
+	s.t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, kubeletGlobalMount), 0750); err != nil {
+		s.t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, kubeletVolDataFile), []byte(volData), 0600); err != nil {
+		s.t.Fatal(err)
+	}
+	s.age(dir)
+}
+
+// age backdates dir and its entries past the grace period
+func (s *stagingTree) age(dir string) {
+	s.t.Helper()
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if err := os.Chtimes(filepath.Join(dir, entry.Name()), s.old, s.old); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(dir, s.old, s.old); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func stagingDirsRemoved(t *testing.T, metrics *observability.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "rds_csi_staging_dirs_removed_total "); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func testStagingJanitor(root string, mounts []string, connected map[string]bool) *stagingJanitor {
+	j := newStagingJanitor("/var/lib/kubelet", DriverName, time.Hour, DefaultStagingJanitorGracePeriod,
+		func(ctx context.Context, nqn string) (bool, error) { return connected[nqn], nil }, nil)
+	j.root = root
+	j.mountPoints = func(ctx context.Context) ([]string, error) { return mounts, nil }
+	return j
+}
+
+func TestStagingJanitor_Sweep(t *testing.T) {
+	tree := newStagingTree(t)
+	orphan := tree.add("pvc-00000000-0000-0000-0000-000000000001")
+	mounted := tree.add("pvc-00000000-0000-0000-0000-000000000002")
+	connected := tree.add("pvc-00000000-0000-0000-0000-000000000003")
+	attached := tree.add("pvc-00000000-0000-0000-0000-000000000004")
+	recent := tree.add("pvc-00000000-0000-0000-0000-000000000005")
+	if err := os.Chtimes(filepath.Join(recent, kubeletGlobalMount), time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	notEmpty := tree.add("pvc-00000000-0000-0000-0000-000000000006")
+	if err := os.WriteFile(filepath.Join(notEmpty, kubeletGlobalMount, "data"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tree.age(filepath.Join(notEmpty, kubeletGlobalMount))
+	tree.age(notEmpty)
+	otherDriver := filepath.Join(tree.root, fmt.Sprintf("%x", sha256.Sum256([]byte("pvc-00000000-0000-0000-0000-000000000007"))))
+	tree.addAt(otherDriver, `{"driverName":"other.csi.example.com","volumeHandle":"pvc-00000000-0000-0000-0000-000000000007"}`)
+	misnamed := filepath.Join(tree.root, "pvc-00000000-0000-0000-0000-000000000008")
+	tree.addAt(misnamed, `{"driverName":"`+DriverName+`","volumeHandle":"pvc-00000000-0000-0000-0000-000000000008"}`)
+	noVolData := filepath.Join(tree.root, "unknown")
+	if err := os.MkdirAll(noVolData, 0750); err != nil {
+		t.Fatal(err)
+	}
+	tree.age(noVolData)
+
+	metrics := observability.NewMetrics()
+	j := testStagingJanitor(tree.root,
+		[]string{"/", filepath.Join(mounted, kubeletGlobalMount)},
+		map[string]bool{"nqn.2000-02.com.mikrotik:pvc-00000000-0000-0000-0000-000000000003": true})
+	j.metrics = metrics
+	j.attachedVolumes = func(ctx context.Context) (map[string]bool, error) {
+		return map[string]bool{"pvc-00000000-0000-0000-0000-000000000004": true}, nil
+	}
+
+	if err := j.sweep(context.Background()); err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("expected the orphaned staging directory to be removed, got %v", err)
+	}
+	for name, dir := range map[string]string{
+		"mounted": mounted, "connected": connected, "attached": attached, "recent": recent,
+		"not empty": notEmpty, "other driver": otherDriver, "misnamed": misnamed, "no vol_data.json": noVolData,
+	} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected the %s staging directory to be kept, got %v", name, err)
+		}
+	}
+	if got := stagingDirsRemoved(t, metrics); got != "1" {
+		t.Errorf("expected 1 removal recorded, got %q", got)
+	}
+}
+
+func TestStagingJanitor_StartScansBeforeInterval(t *testing.T) {
+	tree := newStagingTree(t)
+	orphan := tree.add("pvc-00000000-0000-0000-0000-000000000001")
+	j := testStagingJanitor(tree.root, []string{"/"}, nil)
+	j.interval = 7 * 24 * time.Hour
+	j.startDelay = 0
+
+	j.Start(context.Background())
+	defer j.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(orphan); os.IsNotExist(err) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the first scan at startup to remove the orphaned staging directory")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStagingJanitor_MissingEvidenceRemovesNothing(t *testing.T) {
+	for name, setup := range map[string]func(j *stagingJanitor){
+		"mount table": func(j *stagingJanitor) {
+			j.mountPoints = func(ctx context.Context) ([]string, error) { return nil, errors.New("timed out") }
+		},
+		"VolumeAttachments": func(j *stagingJanitor) {
+			j.attachedVolumes = func(ctx context.Context) (map[string]bool, error) { return nil, errors.New("forbidden") }
+		},
+		"NVMe state": func(j *stagingJanitor) {
+			j.isConnected = func(ctx context.Context, nqn string) (bool, error) { return false, errors.New("sysfs unreadable") }
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tree := newStagingTree(t)
+			dir := tree.add("pvc-00000000-0000-0000-0000-000000000001")
+			j := testStagingJanitor(tree.root, nil, nil)
+			setup(j)
+
+			_ = j.sweep(context.Background())
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("expected the staging directory to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAttachedVolumesFunc(t *testing.T) {
+	pvName := "pv-attached"
+	otherPVName := "pv-other-node"
+	client := fake.NewSimpleClientset(
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvName},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: DriverName, VolumeHandle: "pvc-00000000-0000-0000-0000-000000000001"},
+			}},
+		},
+		&storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "va-1"},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: DriverName, NodeName: "node-1",
+				Source: storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+			},
+		},
+		&storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "va-2"},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: DriverName, NodeName: "node-2",
+				Source: storagev1.VolumeAttachmentSource{PersistentVolumeName: &otherPVName},
+			},
+		},
+	)
+
+	handles, err := attachedVolumesFunc(client, DriverName, "node-1")(context.Background())
+	if err != nil {
+		t.Fatalf("attachedVolumes failed: %v", err)
+	}
+	if len(handles) != 1 || !handles["pvc-00000000-0000-0000-0000-000000000001"] {
+		t.Errorf("expected only the volume attached to node-1, got %v", handles)
+	}
+}
@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// publishContextPublishedAt is the publish context key carrying when ControllerPublishVolume
+// attached the volume to the node (RFC 3339, controller clock), from which NodeStageVolume
+// measures how long the volume took to become usable
+const publishContextPublishedAt = "published_at"
+
+// publishTimestamp returns the publish context value of an attachment made at attachedAt.
+// It is cut to microseconds like the persisted attachment, so the value stays the same
+// after the controller restarts.
+func publishTimestamp(attachedAt time.Time) string {
+	return attachedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// measuredPublishes remembers the publish time each volume's time to ready was measured
+// from. A volume staged again under the same publish context, after NodeUnstageVolume or
+// a node reboot, would otherwise be measured from a publish that may be days old.
+type measuredPublishes struct {
+	mu sync.Mutex
+
+	// since is when the node plugin started; earlier publishes are not measured because
+	// a stage before the restart may already have measured them (zero measures all)
+	since time.Time
+
+	published map[string]string // Volume ID -> published_at last measured
+}
+
+// first reports whether the time to ready from the publish of volumeID at publishedAt
+// (value in the publish context) has not been measured yet, and marks it measured
+func (m *measuredPublishes) first(volumeID, value string, publishedAt time.Time) bool {
+	if publishedAt.Before(m.since) {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.published[volumeID] == value {
+		return false
+	}
+	if m.published == nil {
+		m.published = make(map[string]string)
+	}
+	m.published[volumeID] = value
+	return true
+}
+
+// recordVolumeReady records the duration of a successful NodeStageVolume and, if the publish
+// context carries a publish time not measured before, the time to ready since
+// ControllerPublishVolume. The stage
+// duration is node-local; the time to ready compares controller and node clocks, so a
+// negative value (clock skew) is logged and dropped.
+func (ns *NodeServer) recordVolumeReady(volumeID string, publishContext map[string]string, attach string, stageDuration time.Duration) {
+	if ns.driver.metrics == nil {
+		return
+	}
+	ns.driver.metrics.RecordVolumeStageDuration(attach, stageDuration)
+
+	value, ok := publishContext[publishContextPublishedAt]
+	if !ok {
+		return // Published by a controller that does not stamp the publish time
+	}
+	publishedAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		klog.V(4).Infof("Ignoring invalid %s %q of volume %s: %v", publishContextPublishedAt, value, volumeID, err)
+		return
+	}
+	timeToReady := time.Since(publishedAt)
+	if timeToReady < 0 {
+		klog.V(4).Infof("Volume %s was published %v in the future; controller and node clocks differ", volumeID, -timeToReady)
+		return
+	}
+	if !ns.measuredPublishes.first(volumeID, value, publishedAt) {
+		klog.V(4).Infof("Volume %s staged again since its publish at %s, not measuring time to ready", volumeID, value)
+		return
+	}
+	klog.V(4).Infof("Volume %s ready %v after ControllerPublishVolume (stage took %v)", volumeID, timeToReady, stageDuration)
+	ns.driver.metrics.RecordVolumeTimeToReady(attach, timeToReady)
+}
@@ -0,0 +1,133 @@
+package driver
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// readinessCounts returns the observation counts of the time to ready and stage duration
+// histograms for an attach kind
+func readinessCounts(t *testing.T, metrics *observability.Metrics, attach string) (timeToReady, stage string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, `rds_csi_volume_time_to_ready_seconds_count{attach="`+attach+`"} `); ok {
+			timeToReady = value
+		}
+		if value, ok := strings.CutPrefix(line, `rds_csi_volume_stage_duration_seconds_count{attach="`+attach+`"} `); ok {
+			stage = value
+		}
+	}
+	return timeToReady, stage
+}
+
+func testReadinessNodeServer(mounter *mockMounter) (*NodeServer, *observability.Metrics) {
+	metrics := observability.NewMetrics()
+	return &NodeServer{
+		driver:         &Driver{name: DriverName, version: "test", metrics: metrics},
+		mounter:        mounter,
+		nvmeConn:       &mockNVMEConnector{devicePath: "/dev/nvme0n1"},
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}, metrics
+}
+
+func TestNodeStageVolume_TimeToReady(t *testing.T) {
+	tests := []struct {
+		name      string
+		block     bool
+		formatted bool
+		attach    string
+	}{
+		{name: "block", block: true, attach: observability.VolumeAttachBlock},
+		{name: "new filesystem", attach: observability.VolumeAttachNew},
+		{name: "existing filesystem", formatted: true, attach: observability.VolumeAttachReattach},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns, metrics := testReadinessNodeServer(&mockMounter{isFormatted: tt.formatted})
+			req := stageRequest(filepath.Join(t.TempDir(), "staging"))
+			if !tt.block {
+				req.VolumeCapability = createFilesystemVolumeCapability()
+			}
+			// Published 30s ago by the controller
+			req.PublishContext = map[string]string{
+				publishContextPublishedAt: time.Now().Add(-30 * time.Second).UTC().Format(time.RFC3339Nano),
+			}
+
+			if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+				t.Fatalf("NodeStageVolume failed: %v", err)
+			}
+			timeToReady, stage := readinessCounts(t, metrics, tt.attach)
+			if timeToReady != "1" || stage != "1" {
+				t.Errorf("expected one time to ready and one stage duration labelled %q, got %q and %q", tt.attach, timeToReady, stage)
+			}
+		})
+	}
+}
+
+func TestNodeStageVolume_TimeToReadySkipped(t *testing.T) {
+	tests := map[string]map[string]string{
+		"clock skew":     {publishContextPublishedAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)},
+		"invalid":        {publishContextPublishedAt: "yesterday"},
+		"old controller": nil,
+	}
+
+	for name, publishContext := range tests {
+		t.Run(name, func(t *testing.T) {
+			ns, metrics := testReadinessNodeServer(&mockMounter{})
+			req := stageRequest(filepath.Join(t.TempDir(), "staging"))
+			req.PublishContext = publishContext
+
+			if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+				t.Fatalf("NodeStageVolume failed: %v", err)
+			}
+			// The node-local stage duration is recorded regardless
+			timeToReady, stage := readinessCounts(t, metrics, observability.VolumeAttachBlock)
+			if timeToReady != "" || stage != "1" {
+				t.Errorf("expected only the stage duration recorded, got time to ready %q and stage %q", timeToReady, stage)
+			}
+		})
+	}
+}
+
+func TestNodeStageVolume_TimeToReadyOncePerPublish(t *testing.T) {
+	ns, metrics := testReadinessNodeServer(&mockMounter{})
+	publishContext := map[string]string{
+		publishContextPublishedAt: publishTimestamp(time.Now().Add(-30 * time.Second)),
+	}
+
+	// Staged, unstaged and staged again under the same publish context
+	for range 2 {
+		req := stageRequest(filepath.Join(t.TempDir(), "staging"))
+		req.PublishContext = publishContext
+		if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+			t.Fatalf("NodeStageVolume failed: %v", err)
+		}
+	}
+	timeToReady, stage := readinessCounts(t, metrics, observability.VolumeAttachBlock)
+	if timeToReady != "1" || stage != "2" {
+		t.Errorf("expected one time to ready and two stage durations, got %q and %q", timeToReady, stage)
+	}
+
+	// A publish from before the node plugin started may have been measured already
+	ns.measuredPublishes.since = time.Now()
+	req := stageRequest(filepath.Join(t.TempDir(), "staging"))
+	req.VolumeId = "pvc-22222222-3333-4444-5555-666666666666"
+	req.PublishContext = map[string]string{publishContextPublishedAt: publishTimestamp(time.Now().Add(-time.Hour))}
+	if _, err := ns.NodeStageVolume(context.Background(), req); err != nil {
+		t.Fatalf("NodeStageVolume failed: %v", err)
+	}
+	if timeToReady, _ := readinessCounts(t, metrics, observability.VolumeAttachBlock); timeToReady != "1" {
+		t.Errorf("publish before the plugin started was measured, count %q", timeToReady)
+	}
+}
@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// unmountBusyRetries is how many times an unmount failing on a busy target is retried;
+	// the process holding it is usually a container still exiting
+	unmountBusyRetries = 4
+
+	// defaultUnmountBusyBackoff is the wait before the first retry, doubled for each next one
+	defaultUnmountBusyBackoff = 100 * time.Millisecond
+
+	// busyForceUnmountTimeout bounds the normal unmount attempt of ForceUnmount after the
+	// retries of a busy target are exhausted
+	busyForceUnmountTimeout = 5 * time.Second
+)
+
+// isBusyUnmountError reports whether an unmount failed because the target is in use
+func isBusyUnmountError(err error) bool {
+	if errors.Is(err, syscall.EBUSY) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "target is busy") || strings.Contains(msg, "device is busy")
+}
+
+// unmountRetryBusy unmounts target, retrying with backoff while it is busy. Other errors
+// are returned right away. Once the retries are exhausted, the target is force unmounted
+// with --force-unmount-on-busy, and the busy error is returned otherwise.
+func (ns *NodeServer) unmountRetryBusy(ctx context.Context, target string) error {
+	logger := klog.FromContext(ctx)
+	backoff := ns.unmountBusyBackoff
+	if backoff <= 0 {
+		backoff = defaultUnmountBusyBackoff
+	}
+
+	err := ns.mounter.Unmount(target)
+	for retry := 1; err != nil && isBusyUnmountError(err) && retry <= unmountBusyRetries; retry++ {
+		logger.V(2).Info("Unmount target is busy, retrying", "target", target, "retry", retry, "backoff", backoff, "err", err)
+		if ns.driver.metrics != nil {
+			ns.driver.metrics.RecordUnmountBusyRetry()
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = ns.mounter.Unmount(target)
+	}
+	if err == nil || !isBusyUnmountError(err) || !ns.driver.forceUnmountOnBusy {
+		return err
+	}
+
+	logger.Info("Unmount target still busy after retries, force unmounting", "target", target, "err", err)
+	return ns.mounter.ForceUnmount(target, busyForceUnmountTimeout)
+}
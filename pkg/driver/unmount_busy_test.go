@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+var errTargetBusy = errors.New("umount failed: exit status 32, output: umount: /target: target is busy.")
+
+// busyNodeServer returns a node server unpublishing with mounter and fast busy retries
+func busyNodeServer(mounter *mockMounter, forceUnmountOnBusy bool) *NodeServer {
+	return &NodeServer{
+		driver: &Driver{name: "rds.csi.srvlab.io", version: "test", metrics: observability.NewMetrics(),
+			forceUnmountOnBusy: forceUnmountOnBusy},
+		mounter:            mounter,
+		nodeID:             "test-node",
+		unmountBusyBackoff: time.Millisecond,
+	}
+}
+
+// unpublish unpublishes a filesystem volume from a fresh target directory
+func unpublish(t *testing.T, ns *NodeServer) error {
+	t.Helper()
+	_, err := ns.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{
+		VolumeId:   "pvc-12345678-1234-1234-1234-123456789012",
+		TargetPath: t.TempDir(),
+	})
+	return err
+}
+
+// busyRetries returns the value of rds_csi_unmount_busy_retries_total
+func busyRetries(t *testing.T, metrics *observability.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "rds_csi_unmount_busy_retries_total "); ok {
+			return value
+		}
+	}
+	t.Fatal("rds_csi_unmount_busy_retries_total not exported")
+	return ""
+}
+
+func TestNodeUnpublishVolume_BusyThenSuccess(t *testing.T) {
+	mounter := &mockMounter{unmountErrs: []error{errTargetBusy, errTargetBusy}}
+	ns := busyNodeServer(mounter, false)
+
+	if err := unpublish(t, ns); err != nil {
+		t.Fatalf("NodeUnpublishVolume failed: %v", err)
+	}
+	if mounter.unmountCalls != 3 || mounter.forceUnmounted {
+		t.Errorf("unmounted %d times (force %v), want 3 normal unmounts", mounter.unmountCalls, mounter.forceUnmounted)
+	}
+	if retries := busyRetries(t, ns.driver.metrics); retries != "2" {
+		t.Errorf("busy retries = %s, want 2", retries)
+	}
+}
+
+func TestNodeUnpublishVolume_BusyForever(t *testing.T) {
+	alwaysBusy := func() []error {
+		errs := make([]error, unmountBusyRetries+1)
+		for i := range errs {
+			errs[i] = errTargetBusy
+		}
+		return errs
+	}
+
+	// Without -force-unmount-on-busy, the busy error is returned once the retries are exhausted
+	mounter := &mockMounter{unmountErrs: alwaysBusy()}
+	ns := busyNodeServer(mounter, false)
+	err := unpublish(t, ns)
+	if status.Code(err) != codes.Internal || !strings.Contains(err.Error(), "target is busy") {
+		t.Errorf("NodeUnpublishVolume = %v, want Internal with the busy error", err)
+	}
+	if mounter.unmountCalls != unmountBusyRetries+1 || mounter.forceUnmounted {
+		t.Errorf("unmounted %d times (force %v), want %d normal unmounts", mounter.unmountCalls, mounter.forceUnmounted, unmountBusyRetries+1)
+	}
+	if retries := busyRetries(t, ns.driver.metrics); retries != "4" {
+		t.Errorf("busy retries = %s, want 4", retries)
+	}
+
+	// With it, the target is force unmounted instead
+	mounter = &mockMounter{unmountErrs: alwaysBusy()}
+	ns = busyNodeServer(mounter, true)
+	if err := unpublish(t, ns); err != nil {
+		t.Fatalf("NodeUnpublishVolume with -force-unmount-on-busy failed: %v", err)
+	}
+	if !mounter.forceUnmounted {
+		t.Error("busy target not force unmounted")
+	}
+}
+
+func TestNodeUnstageVolume_UnmountErrorNotRetried(t *testing.T) {
+	mounter := &mockMounter{isLikelyMounted: true, unmountErr: errors.New("umount failed: exit status 32, output: umount: /staging: not mounted.")}
+	ns := busyNodeServer(mounter, true)
+	ns.nvmeConn = &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	_, err := ns.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{
+		VolumeId:          "pvc-12345678-1234-1234-1234-123456789012",
+		StagingTargetPath: t.TempDir(),
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("NodeUnstageVolume = %v, want Internal", err)
+	}
+	if mounter.unmountCalls != 1 || mounter.forceUnmounted {
+		t.Errorf("unmounted %d times (force %v), want a single unmount", mounter.unmountCalls, mounter.forceUnmounted)
+	}
+}
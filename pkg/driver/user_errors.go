@@ -0,0 +1,162 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// userErrorClass is a kind of RDS failure shown to users as a concise message and a
+// remediation hint, instead of the wrapped error chain that ends up on their PVC
+type userErrorClass struct {
+	sentinel error      // Matched with errors.Is
+	code     codes.Code // gRPC code returned to the sidecar
+	reason   string     // Machine-readable reason in the status details
+	message  string     // Short description of what went wrong
+	hint     string     // What the user can do about it
+
+	// hintFunc builds the hint instead, for hints naming configured resources
+	hintFunc func() string
+}
+
+// rdsCredentialsSecret is the Secret ("namespace/name") holding the controller's SSH key,
+// named in the hint for rejected logins. NewDriver sets it from
+// DriverConfig.RDSCredentialsSecret.
+var rdsCredentialsSecret atomic.Pointer[string]
+
+// credentialsHint is the hint for rejected SSH logins. Without a configured Secret it
+// names the key file flag instead.
+func credentialsHint() string {
+	if secret := rdsCredentialsSecret.Load(); secret != nil && *secret != "" {
+		return fmt.Sprintf("check the SSH private key in secret %s and the --rds-user of the controller", *secret)
+	}
+	return "check the SSH private key given by --rds-key-file and the --rds-user of the controller"
+}
+
+// userErrorClasses maps typed RDS errors to what users see. Order matters: the first
+// matching class wins, so more specific errors come before the ones they may wrap.
+var userErrorClasses = []userErrorClass{
+	{
+		sentinel: utils.ErrAuthenticationFailed,
+		code:     codes.Unavailable,
+		reason:   "RDS_AUTHENTICATION_FAILED",
+		message:  "RDS unavailable: SSH login rejected",
+		hintFunc: credentialsHint,
+	},
+	{
+		sentinel: rds.ErrCircuitOpen,
+		code:     codes.Unavailable,
+		reason:   "RDS_CIRCUIT_OPEN",
+		message:  "RDS unavailable: too many recent connection failures",
+		hint:     "the driver retries automatically; check RDS health if this persists",
+	},
+	{
+		sentinel: rds.ErrPoolExhausted,
+		code:     codes.Unavailable,
+		reason:   "RDS_BUSY",
+		message:  "RDS unavailable: all connections are in use",
+		hint:     "the request is retried automatically",
+	},
+	{
+		sentinel: rds.ErrRetryBudgetExhausted,
+		code:     codes.Unavailable,
+		reason:   "RDS_RETRY_BUDGET_EXHAUSTED",
+		message:  "RDS unavailable: too many failing commands, not retried",
+		hint:     "RDS looks degraded; the request is retried automatically once it recovers",
+	},
+	{
+		sentinel: rds.ErrCommandInterrupted,
+		code:     codes.Unavailable,
+		reason:   "RDS_CONNECTION_LOST",
+		message:  "RDS unavailable: connection lost during the operation",
+		hint:     "RDS may be restarting; the request is retried automatically",
+	},
+	{
+		sentinel: utils.ErrConnectionFailed,
+		code:     codes.Unavailable,
+		reason:   "RDS_CONNECTION_FAILED",
+		message:  "RDS unavailable: cannot connect",
+		hint:     "check that the RDS address is reachable from the controller and SSH is enabled on RouterOS",
+	},
+	{
+		sentinel: utils.ErrOperationTimeout,
+		code:     codes.Unavailable,
+		reason:   "RDS_TIMEOUT",
+		message:  "RDS unavailable: operation timed out",
+		hint:     "RDS may be overloaded; the request is retried automatically",
+	},
+	{
+		sentinel: utils.ErrResourceExhausted,
+		code:     codes.ResourceExhausted,
+		reason:   "RDS_POOL_FULL",
+		message:  "insufficient storage on RDS: storage pool full",
+		hint:     "free space or expand the pool",
+	},
+	{
+		sentinel: rds.ErrQoSUnsupported,
+		code:     codes.InvalidArgument,
+		reason:   "RDS_QOS_UNSUPPORTED",
+		message:  "IO limit parameters cannot be applied: RouterOS on RDS is too old",
+		hint: fmt.Sprintf("upgrade RouterOS to %s or later, or remove %s, %s and %s from the StorageClass",
+			rds.VolumeQoSMinVersion, paramMaxReadIOPS, paramMaxWriteIOPS, paramMaxBandwidth),
+	},
+	{
+		sentinel: utils.ErrPathNotAllowed,
+		code:     codes.InvalidArgument,
+		reason:   "RDS_PATH_NOT_ALLOWED",
+		message:  "volume file path is outside the allowed base paths",
+		hint:     "set the volumePath of the StorageClass under --rds-volume-base-path or a pool listed in --rds-additional-base-paths",
+	},
+	{
+		sentinel: utils.ErrVolumeExists,
+		code:     codes.AlreadyExists,
+		reason:   "RDS_VOLUME_CONFLICT",
+		message:  "volume already exists on RDS with different parameters",
+		hint:     "remove the stale disk on RDS if nothing uses it",
+	},
+}
+
+// classifyUserError returns the class err belongs to, or nil if it has none
+func classifyUserError(err error) *userErrorClass {
+	for i := range userErrorClasses {
+		if errors.Is(err, userErrorClasses[i].sentinel) {
+			return &userErrorClasses[i]
+		}
+	}
+	return nil
+}
+
+// userFacingError returns the gRPC error for a failed RDS operation. Errors of a known class
+// become "<action>: <message> (hint: <hint>)" with the full error logged at V(2) and attached
+// to the status details; anything else is returned as "<action>: <error>" with code fallback.
+func userFacingError(err error, fallback codes.Code, action string) error {
+	class := classifyUserError(err)
+	if class == nil {
+		return status.Errorf(fallback, "%s: %v", action, err)
+	}
+
+	hint := class.hint
+	if class.hintFunc != nil {
+		hint = class.hintFunc()
+	}
+	klog.V(2).Infof("%s: %v", action, err)
+	st := status.Newf(class.code, "%s: %s (hint: %s)", action, class.message, hint)
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   class.reason,
+		Domain:   DriverName,
+		Metadata: map[string]string{"error": err.Error()},
+	})
+	if detailErr != nil {
+		klog.V(4).Infof("Failed to attach error details: %v", detailErr)
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,166 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+func TestUserFacingError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		reason  string
+		message string
+		hint    string
+	}{
+		{
+			name:    "SSH authentication",
+			err:     fmt.Errorf("max retries (3) exceeded: failed to connect to 10.0.0.1:22: %w: ssh: handshake failed: ssh: unable to authenticate", utils.ErrAuthenticationFailed),
+			code:    codes.Unavailable,
+			reason:  "RDS_AUTHENTICATION_FAILED",
+			message: "RDS unavailable: SSH login rejected",
+			hint:    "check the SSH private key given by --rds-key-file",
+		},
+		{
+			name:    "connection refused",
+			err:     fmt.Errorf("failed to connect to 10.0.0.1:22: %w: dial tcp: connection refused", utils.ErrConnectionFailed),
+			code:    codes.Unavailable,
+			reason:  "RDS_CONNECTION_FAILED",
+			message: "RDS unavailable: cannot connect",
+			hint:    "check that the RDS address is reachable",
+		},
+		{
+			name:    "circuit open",
+			err:     fmt.Errorf("get connection: %w", rds.ErrCircuitOpen),
+			code:    codes.Unavailable,
+			reason:  "RDS_CIRCUIT_OPEN",
+			message: "too many recent connection failures",
+			hint:    "the driver retries automatically",
+		},
+		{
+			name:    "connection lost",
+			err:     fmt.Errorf("failed to get volume info: failed to run command: %w: EOF", rds.ErrCommandInterrupted),
+			code:    codes.Unavailable,
+			reason:  "RDS_CONNECTION_LOST",
+			message: "connection lost during the operation",
+			hint:    "RDS may be restarting",
+		},
+		{
+			name:    "retry budget exhausted",
+			err:     fmt.Errorf("%w, not retried after attempt 1/3: failed to connect to 10.0.0.1:22: %w: dial tcp: connection refused", rds.ErrRetryBudgetExhausted, utils.ErrConnectionFailed),
+			code:    codes.Unavailable,
+			reason:  "RDS_RETRY_BUDGET_EXHAUSTED",
+			message: "too many failing commands, not retried",
+			hint:    "RDS looks degraded",
+		},
+		{
+			name:    "timeout",
+			err:     fmt.Errorf("volume pvc-a not ready after 30s: %w", utils.ErrOperationTimeout),
+			code:    codes.Unavailable,
+			reason:  "RDS_TIMEOUT",
+			message: "RDS unavailable: operation timed out",
+			hint:    "RDS may be overloaded",
+		},
+		{
+			name:    "pool full",
+			err:     fmt.Errorf("%w: failure: not enough space", utils.ErrResourceExhausted),
+			code:    codes.ResourceExhausted,
+			reason:  "RDS_POOL_FULL",
+			message: "storage pool full",
+			hint:    "free space or expand the pool",
+		},
+		{
+			name:    "IO limits unsupported",
+			err:     fmt.Errorf("%w: RDS runs RouterOS 7.16, IO limits need 7.18 or later", rds.ErrQoSUnsupported),
+			code:    codes.InvalidArgument,
+			reason:  "RDS_QOS_UNSUPPORTED",
+			message: "RouterOS on RDS is too old",
+			hint:    "upgrade RouterOS to " + rds.VolumeQoSMinVersion,
+		},
+		{
+			name:    "path outside allowed pools",
+			err:     fmt.Errorf("invalid volume options: security validation failed for file path: %w: /bulk-pool/metal-csi/pvc-a.img", utils.ErrPathNotAllowed),
+			code:    codes.InvalidArgument,
+			reason:  "RDS_PATH_NOT_ALLOWED",
+			message: "outside the allowed base paths",
+			hint:    "set the volumePath of the StorageClass",
+		},
+		{
+			name:    "conflicting volume",
+			err:     fmt.Errorf("%w: pvc-a has file-size 1G", utils.ErrVolumeExists),
+			code:    codes.AlreadyExists,
+			reason:  "RDS_VOLUME_CONFLICT",
+			message: "already exists on RDS with different parameters",
+			hint:    "remove the stale disk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := userFacingError(tt.err, codes.Internal, "failed to create volume pvc-a on RDS")
+			st, ok := status.FromError(err)
+			if !ok {
+				t.Fatalf("expected gRPC status, got %v", err)
+			}
+			if st.Code() != tt.code {
+				t.Errorf("code = %v, want %v", st.Code(), tt.code)
+			}
+
+			msg := st.Message()
+			if !strings.HasPrefix(msg, "failed to create volume pvc-a on RDS: ") {
+				t.Errorf("message %q does not start with the action", msg)
+			}
+			if !strings.Contains(msg, tt.message) || !strings.Contains(msg, "(hint: "+tt.hint) {
+				t.Errorf("message %q, want message %q and hint %q", msg, tt.message, tt.hint)
+			}
+			// The detailed error stays out of the message users see
+			if strings.Contains(msg, "10.0.0.1") || strings.Contains(msg, tt.err.Error()) {
+				t.Errorf("message %q leaks the detailed error", msg)
+			}
+
+			if len(st.Details()) != 1 {
+				t.Fatalf("expected one status detail, got %v", st.Details())
+			}
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			if !ok {
+				t.Fatalf("expected ErrorInfo detail, got %T", st.Details()[0])
+			}
+			if info.Reason != tt.reason || info.Domain != DriverName || info.Metadata["error"] != tt.err.Error() {
+				t.Errorf("unexpected error info: %+v", info)
+			}
+		})
+	}
+}
+
+func TestUserFacingError_Unclassified(t *testing.T) {
+	err := userFacingError(errors.New("unexpected output"), codes.Internal, "failed to delete volume")
+	st, _ := status.FromError(err)
+	if st.Code() != codes.Internal || st.Message() != "failed to delete volume: unexpected output" {
+		t.Errorf("unexpected status: %v %q", st.Code(), st.Message())
+	}
+	if len(st.Details()) != 0 {
+		t.Errorf("unclassified errors carry no details, got %v", st.Details())
+	}
+}
+
+func TestUserFacingError_CredentialsSecret(t *testing.T) {
+	previous := rdsCredentialsSecret.Load()
+	t.Cleanup(func() { rdsCredentialsSecret.Store(previous) })
+	secret := "storage/rds-credentials"
+	rdsCredentialsSecret.Store(&secret)
+
+	err := userFacingError(fmt.Errorf("failed to connect: %w", utils.ErrAuthenticationFailed), codes.Internal, "failed to create volume")
+	if !strings.Contains(status.Convert(err).Message(), "check the SSH private key in secret storage/rds-credentials") {
+		t.Errorf("hint should name the configured secret: %v", err)
+	}
+}
@@ -138,9 +138,9 @@ func (g *VMIGrouper) lookupVMIFromAPI(ctx context.Context, pvcNamespace, pvcName
 
 	// Find pod(s) mounting this PVC
 	for _, pod := range pods.Items {
-		if g.podMountsPVC(&pod, pvcName) {
+		if podMountsPVC(&pod, pvcName) {
 			// Check ownerReferences for VMI
-			vmiKey := g.extractVMIFromPod(&pod)
+			vmiKey := extractVMIFromPod(&pod)
 			if vmiKey != "" {
 				klog.V(4).Infof("Found VMI %s for PVC %s/%s via pod %s",
 					vmiKey, pvcNamespace, pvcName, pod.Name)
@@ -154,7 +154,7 @@ func (g *VMIGrouper) lookupVMIFromAPI(ctx context.Context, pvcNamespace, pvcName
 }
 
 // podMountsPVC checks if a pod mounts the given PVC
-func (g *VMIGrouper) podMountsPVC(pod *corev1.Pod, pvcName string) bool {
+func podMountsPVC(pod *corev1.Pod, pvcName string) bool {
 	for _, vol := range pod.Spec.Volumes {
 		if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == pvcName {
 			return true
@@ -164,7 +164,7 @@ func (g *VMIGrouper) podMountsPVC(pod *corev1.Pod, pvcName string) bool {
 }
 
 // extractVMIFromPod extracts the VMI identity from a pod's ownerReferences
-func (g *VMIGrouper) extractVMIFromPod(pod *corev1.Pod) string {
+func extractVMIFromPod(pod *corev1.Pod) string {
 	// Check ownerReferences for VirtualMachineInstance
 	for _, ownerRef := range pod.OwnerReferences {
 		if ownerRef.Kind == "VirtualMachineInstance" {
@@ -0,0 +1,194 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// volumeContextKey is a VolumeContext key a node RPC needs. PVs created by early driver
+// versions lack some of them; infer returns the value the node assumes for a missing
+// key, or "" if there is none.
+type volumeContextKey struct {
+	name  string
+	hint  string // What the value is, for the remediation of a missing key
+	infer func(volumeID string, volumeContext map[string]string) string
+}
+
+var (
+	contextKeyNQN = volumeContextKey{
+		name: volumeContextNQN,
+		hint: "the NQN the volume is exported under",
+		infer: func(volumeID string, _ map[string]string) string {
+			nqn, err := volumeIDToNQN(volumeID)
+			if err != nil {
+				return ""
+			}
+			return nqn
+		},
+	}
+	contextKeyNVMEAddress = volumeContextKey{
+		name: volumeContextNVMEAddress,
+		hint: "the NVMe/TCP address of RDS",
+		infer: func(_ string, volumeContext map[string]string) string {
+			return volumeContext[volumeContextAddress]
+		},
+	}
+	contextKeyPort = volumeContextKey{
+		name: volumeContextPort,
+		hint: "the NVMe/TCP port of RDS",
+		infer: func(string, map[string]string) string {
+			return strconv.Itoa(defaultNVMETCPPort)
+		},
+	}
+)
+
+// The VolumeContext keys each node RPC needs. NodePublishVolume only needs the NQN, to
+// find the device of block volumes and recover stale mounts.
+var (
+	stageContextKeys   = []volumeContextKey{contextKeyNQN, contextKeyNVMEAddress, contextKeyPort}
+	publishContextKeys = []volumeContextKey{contextKeyNQN}
+)
+
+// VolumeContextReport is the verdict on the VolumeContext of a volume
+type VolumeContextReport struct {
+	VolumeID string
+	Missing  []MissingContextKey
+}
+
+// MissingContextKey is a required VolumeContext key the context lacks
+type MissingContextKey struct {
+	Key  string
+	Hint string
+
+	// Inferred is the value the node uses outside strict mode ("" if it cannot infer one)
+	Inferred string
+}
+
+// CheckVolumeContext checks the VolumeContext of volumeID for the keys NodeStageVolume
+// needs, which include those of NodePublishVolume
+func CheckVolumeContext(volumeID string, volumeContext map[string]string) VolumeContextReport {
+	return checkVolumeContext(volumeID, volumeContext, stageContextKeys)
+}
+
+func checkVolumeContext(volumeID string, volumeContext map[string]string, keys []volumeContextKey) VolumeContextReport {
+	report := VolumeContextReport{VolumeID: volumeID}
+	for _, key := range keys {
+		if volumeContext[key.name] != "" {
+			continue
+		}
+		report.Missing = append(report.Missing, MissingContextKey{
+			Key:      key.name,
+			Hint:     key.hint,
+			Inferred: key.infer(volumeID, volumeContext),
+		})
+	}
+	return report
+}
+
+// Complete reports whether the context has every required key
+func (r VolumeContextReport) Complete() bool {
+	return len(r.Missing) == 0
+}
+
+// Inferable reports whether the node can infer every missing key outside strict mode
+func (r VolumeContextReport) Inferable() bool {
+	for _, missing := range r.Missing {
+		if missing.Inferred == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// remediation describes the missing keys and how to add them to the PV
+func (r VolumeContextReport) remediation() string {
+	keys := make([]string, 0, len(r.Missing))
+	for _, missing := range r.Missing {
+		key := fmt.Sprintf("%s (%s", missing.Key, missing.Hint)
+		if missing.Inferred != "" {
+			key += ", likely " + missing.Inferred
+		}
+		keys = append(keys, key+")")
+	}
+	return fmt.Sprintf("volume context of %s lacks %s; add them to spec.csi.volumeAttributes of its PV. "+
+		"The volume source of a PV is immutable, so set its reclaim policy to Retain, then delete and re-create "+
+		"it with the attributes added", r.VolumeID, strings.Join(keys, ", "))
+}
+
+// Write prints the verdict for the PV named pvName, for `inspect pv-context`
+func (r VolumeContextReport) Write(w io.Writer, pvName string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "PV %s (volume %s)\n", pvName, r.VolumeID)
+	for _, missing := range r.Missing {
+		inferred := "cannot be inferred"
+		if missing.Inferred != "" {
+			inferred = "inferred as " + missing.Inferred
+		}
+		fmt.Fprintf(&b, "  %s: missing, %s\n", missing.Key, inferred)
+	}
+	switch {
+	case r.Complete():
+		b.WriteString("Verdict: complete\n")
+	case r.Inferable():
+		b.WriteString("Verdict: stages with inferred values, fails with -strict-volume-context\n")
+	default:
+		b.WriteString("Verdict: fails to stage\n")
+	}
+	if !r.Complete() {
+		fmt.Fprintf(&b, "Fix: %s\n", r.remediation())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// resolveVolumeContext checks volumeContext for keys at the start of a node RPC. A context
+// lacking some fails with InvalidArgument in strict mode. Otherwise the inferable keys are
+// filled in, with a warning, and any others are left to the RPC to report.
+func (ns *NodeServer) resolveVolumeContext(volumeID string, volumeContext map[string]string,
+	keys []volumeContextKey) (map[string]string, error) {
+	report := checkVolumeContext(volumeID, volumeContext, keys)
+	if report.Complete() {
+		return volumeContext, nil
+	}
+	if ns.driver.strictVolumeContext {
+		return nil, status.Errorf(codes.InvalidArgument, "%s (-strict-volume-context is on)", report.remediation())
+	}
+
+	resolved := make(map[string]string, len(volumeContext)+len(report.Missing))
+	for key, value := range volumeContext {
+		resolved[key] = value
+	}
+	inferred := make([]string, 0, len(report.Missing))
+	for _, missing := range report.Missing {
+		if missing.Inferred != "" {
+			resolved[missing.Key] = missing.Inferred
+			inferred = append(inferred, missing.Key+"="+missing.Inferred)
+		}
+	}
+	if len(inferred) > 0 {
+		klog.Warningf("Volume context of %s lacks keys, using inferred values %s; "+
+			"add them to the PV or check it with `rds-csi-plugin inspect pv-context`", volumeID, strings.Join(inferred, ", "))
+	}
+	return resolved, nil
+}
+
+// InspectPVContext checks the VolumeContext of the PV named pvName, a PV of driverName
+func InspectPVContext(ctx context.Context, k8sClient kubernetes.Interface, driverName, pvName string) (VolumeContextReport, error) {
+	pv, err := k8sClient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+	if err != nil {
+		return VolumeContextReport{}, fmt.Errorf("failed to get PV %s: %w", pvName, err)
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != driverName {
+		return VolumeContextReport{}, fmt.Errorf("PV %s is not a volume of %s", pvName, driverName)
+	}
+	return CheckVolumeContext(pv.Spec.CSI.VolumeHandle, pv.Spec.CSI.VolumeAttributes), nil
+}
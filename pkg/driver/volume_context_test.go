@@ -0,0 +1,211 @@
+package driver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/circuitbreaker"
+)
+
+const legacyVolumeID = "pvc-12345678-1234-1234-1234-123456789012"
+
+// legacyContexts are volume contexts of PVs created by early driver versions
+var legacyContexts = []struct {
+	name         string
+	context      map[string]string
+	missing      []string
+	notInferable []string
+}{
+	{
+		name: "complete",
+		context: map[string]string{"nqn": "nqn.2000-02.com.mikrotik:" + legacyVolumeID,
+			"nvmeAddress": "10.42.68.1", "nvmePort": "4420"},
+	},
+	{
+		name:    "rdsAddress only",
+		context: map[string]string{"rdsAddress": "10.42.68.1"},
+		missing: []string{"nqn", "nvmeAddress", "nvmePort"},
+	},
+	{
+		name:         "no address",
+		context:      map[string]string{"nqn": "nqn.2000-02.com.mikrotik:" + legacyVolumeID},
+		missing:      []string{"nvmeAddress", "nvmePort"},
+		notInferable: []string{"nvmeAddress"},
+	},
+}
+
+func TestCheckVolumeContext(t *testing.T) {
+	for _, tt := range legacyContexts {
+		t.Run(tt.name, func(t *testing.T) {
+			report := CheckVolumeContext(legacyVolumeID, tt.context)
+			var missing []string
+			for _, key := range report.Missing {
+				missing = append(missing, key.Key)
+			}
+			if strings.Join(missing, ",") != strings.Join(tt.missing, ",") {
+				t.Errorf("missing = %v, want %v", missing, tt.missing)
+			}
+			if report.Complete() != (len(tt.missing) == 0) || report.Inferable() != (len(tt.notInferable) == 0) {
+				t.Errorf("Complete() = %v, Inferable() = %v, want %v, %v",
+					report.Complete(), report.Inferable(), len(tt.missing) == 0, len(tt.notInferable) == 0)
+			}
+		})
+	}
+
+	// The inferred values are the derived NQN, the RDS address and the default port
+	report := CheckVolumeContext(legacyVolumeID, map[string]string{"rdsAddress": "10.42.68.1"})
+	want := map[string]string{"nqn": "nqn.2000-02.com.mikrotik:" + legacyVolumeID, "nvmeAddress": "10.42.68.1", "nvmePort": "4420"}
+	for _, key := range report.Missing {
+		if key.Inferred != want[key.Key] {
+			t.Errorf("%s inferred as %q, want %q", key.Key, key.Inferred, want[key.Key])
+		}
+	}
+}
+
+// legacyNodeServer returns a node server staging with mocks
+func legacyNodeServer(strict bool) (*NodeServer, *mockNVMEConnector) {
+	connector := &mockNVMEConnector{devicePath: "/dev/nvme0n1"}
+	return &NodeServer{
+		driver:         &Driver{name: "rds.csi.srvlab.io", version: "test", strictVolumeContext: strict},
+		mounter:        &mockMounter{},
+		nvmeConn:       connector,
+		nodeID:         "test-node",
+		circuitBreaker: circuitbreaker.NewVolumeCircuitBreaker(),
+	}, connector
+}
+
+func TestNodeStageVolume_LegacyVolumeContext(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		for _, tt := range legacyContexts {
+			t.Run(tt.name+map[bool]string{false: "", true: " strict"}[strict], func(t *testing.T) {
+				ns, connector := legacyNodeServer(strict)
+				_, err := ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+					VolumeId:          legacyVolumeID,
+					StagingTargetPath: filepath.Join(t.TempDir(), "staging"),
+					VolumeCapability:  createBlockVolumeCapability(),
+					VolumeContext:     tt.context,
+				})
+
+				wantOK := len(tt.missing) == 0 || (len(tt.notInferable) == 0 && !strict)
+				if wantOK {
+					if err != nil {
+						t.Fatalf("NodeStageVolume failed: %v", err)
+					}
+					target := connector.lastTarget
+					if target.NQN != "nqn.2000-02.com.mikrotik:"+legacyVolumeID || target.TargetAddress != "10.42.68.1" || target.TargetPort != 4420 {
+						t.Errorf("connected to %+v", target)
+					}
+					return
+				}
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("NodeStageVolume = %v, want InvalidArgument", err)
+				}
+				// Outside strict mode, the keys that could be inferred were
+				wantMissing := tt.missing
+				if !strict {
+					wantMissing = tt.notInferable
+				}
+				for _, key := range wantMissing {
+					if !strings.Contains(err.Error(), key+" (") {
+						t.Errorf("error %q does not name missing key %s", err, key)
+					}
+				}
+				if !strings.Contains(err.Error(), "spec.csi.volumeAttributes") {
+					t.Errorf("error %q does not tell how to fix the PV", err)
+				}
+				if connector.connectCalled {
+					t.Error("NVMe connect called for a rejected context")
+				}
+			})
+		}
+	}
+}
+
+func TestNodePublishVolume_LegacyVolumeContext(t *testing.T) {
+	publish := func(ns *NodeServer, t *testing.T) error {
+		tmpDir := t.TempDir()
+		stagingPath := filepath.Join(tmpDir, "staging")
+		if err := os.MkdirAll(stagingPath, 0750); err != nil {
+			t.Fatal(err)
+		}
+		ns.nvmeConn.(*mockNVMEConnector).devicePath = filepath.Join(tmpDir, "mock-nvme0n1")
+		if err := os.WriteFile(filepath.Join(tmpDir, "mock-nvme0n1"), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ns.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+			VolumeId:          legacyVolumeID,
+			StagingTargetPath: stagingPath,
+			TargetPath:        filepath.Join(tmpDir, "target"),
+			VolumeCapability:  createBlockVolumeCapability(),
+			VolumeContext:     map[string]string{"rdsAddress": "10.42.68.1"},
+		})
+		return err
+	}
+
+	// The NQN is inferred outside strict mode. Where mknod is not permitted, publishing
+	// gets as far as creating the device node.
+	ns, _ := legacyNodeServer(false)
+	if err := publish(ns, t); err != nil && !strings.Contains(err.Error(), "operation not permitted") {
+		t.Fatalf("NodePublishVolume failed: %v", err)
+	}
+
+	ns, _ = legacyNodeServer(true)
+	err := publish(ns, t)
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "nqn (") {
+		t.Errorf("NodePublishVolume in strict mode = %v, want InvalidArgument naming nqn", err)
+	}
+}
+
+func TestInspectPVContext(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-legacy"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           "rds.csi.srvlab.io",
+					VolumeHandle:     legacyVolumeID,
+					VolumeAttributes: map[string]string{"rdsAddress": "10.42.68.1"},
+				},
+			},
+		},
+	}
+	k8sClient := fake.NewSimpleClientset(pv)
+
+	report, err := InspectPVContext(context.Background(), k8sClient, "rds.csi.srvlab.io", "pv-legacy")
+	if err != nil {
+		t.Fatalf("InspectPVContext failed: %v", err)
+	}
+	var out strings.Builder
+	if err := report.Write(&out, "pv-legacy"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"PV pv-legacy (volume " + legacyVolumeID + ")",
+		"nqn: missing, inferred as nqn.2000-02.com.mikrotik:" + legacyVolumeID,
+		"nvmePort: missing, inferred as 4420",
+		"Verdict: stages with inferred values, fails with -strict-volume-context",
+		"Fix: ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := InspectPVContext(context.Background(), k8sClient, "other.csi.example.com", "pv-legacy"); err == nil {
+		t.Error("expected an error for a PV of another driver")
+	}
+	if _, err := InspectPVContext(context.Background(), k8sClient, "rds.csi.srvlab.io", "pv-missing"); err == nil {
+		t.Error("expected an error for a missing PV")
+	}
+}
@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// DefaultSocketCheckInterval is how often the socket watchdog checks the CSI socket
+const DefaultSocketCheckInterval = 30 * time.Second
+
+// rebindableServer is the part of NonBlockingGRPCServer the watchdog needs
+type rebindableServer interface {
+	SocketPath() string
+	Rebind() error
+}
+
+// SocketWatchdog re-creates the CSI unix socket if it disappears while the driver runs.
+//
+// A deleted socket file leaves the gRPC server listening on an unreachable inode, so
+// kubelet (or the external sidecars) can no longer call the driver and nothing fails
+// until the next mount. If the socket cannot be re-created, onRebindFailure is called,
+// which by default exits the process so the DaemonSet restarts it.
+type SocketWatchdog struct {
+	server   rebindableServer
+	interval time.Duration
+	metrics  *observability.Metrics
+
+	// registrationPath is the node-driver-registrar's kubelet registration socket.
+	// Optional: when set, its absence also marks registration unhealthy.
+	registrationPath string
+
+	// onRebindFailure is called when the socket cannot be re-created
+	onRebindFailure func(err error)
+}
+
+// NewSocketWatchdog creates a watchdog for server's unix socket
+func NewSocketWatchdog(server rebindableServer, interval time.Duration, registrationPath string, metrics *observability.Metrics) *SocketWatchdog {
+	if interval <= 0 {
+		interval = DefaultSocketCheckInterval
+	}
+	return &SocketWatchdog{
+		server:           server,
+		interval:         interval,
+		metrics:          metrics,
+		registrationPath: registrationPath,
+		onRebindFailure: func(err error) {
+			klog.Fatalf("CSI socket watchdog: %v", err)
+		},
+	}
+}
+
+// Start runs the watchdog until ctx is cancelled, which also unregisters its metrics
+func (w *SocketWatchdog) Start(ctx context.Context) {
+	unregisterMetrics := func() {}
+	if w.metrics != nil {
+		unregisterMetrics = w.metrics.EnableSocketWatchdogMetrics()
+	}
+	klog.Infof("Starting CSI socket watchdog for %s (interval=%v, registration=%q)",
+		w.server.SocketPath(), w.interval, w.registrationPath)
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		w.check()
+		for {
+			select {
+			case <-ticker.C:
+				w.check()
+			case <-ctx.Done():
+				unregisterMetrics()
+				return
+			}
+		}
+	}()
+}
+
+// check verifies the socket and registration path, re-creating the socket if needed
+func (w *SocketWatchdog) check() {
+	socketPath := w.server.SocketPath()
+
+	if !isSocket(socketPath) {
+		klog.Warningf("CSI socket %s is missing, re-creating it", socketPath)
+		if err := w.server.Rebind(); err != nil {
+			w.setHealthy(false)
+			w.onRebindFailure(fmt.Errorf("failed to re-create socket %s: %w", socketPath, err))
+			return
+		}
+		if w.metrics != nil {
+			w.metrics.RecordSocketRecreation()
+		}
+	}
+
+	healthy := true
+	if w.registrationPath != "" && !isSocket(w.registrationPath) {
+		klog.Warningf("Registration socket %s is missing; kubelet may not see this driver until node-driver-registrar re-registers",
+			w.registrationPath)
+		healthy = false
+	}
+	w.setHealthy(healthy)
+}
+
+func (w *SocketWatchdog) setHealthy(healthy bool) {
+	if w.metrics != nil {
+		w.metrics.SetRegistrationHealthy(healthy)
+	}
+}
+
+// isSocket reports whether path exists and is a unix socket
+func isSocket(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeSocket != 0
+}
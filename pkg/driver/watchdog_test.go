@@ -0,0 +1,111 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/credentials/insecure"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+)
+
+// startTestSocketServer serves the identity service on a unix socket in a short temp dir
+// (unix socket paths are limited to ~108 bytes, too short for t.TempDir())
+func startTestSocketServer(t *testing.T) (*NonBlockingGRPCServer, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "csi-wd")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "csi.sock")
+	server := NewNonBlockingGRPCServer("unix://" + socketPath)
+	driver := &Driver{name: DriverName, version: "test"}
+	if err := server.Start(NewIdentityServer(driver), nil, nil); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(server.Stop)
+	return server, socketPath
+}
+
+func TestSocketWatchdog_RecreatesDeletedSocket(t *testing.T) {
+	server, socketPath := startTestSocketServer(t)
+	metrics := observability.NewMetrics()
+
+	watchdog := NewSocketWatchdog(server, 20*time.Millisecond, "", metrics)
+	watchdog.onRebindFailure = func(err error) { t.Errorf("unexpected rebind failure: %v", err) }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchdog.Start(ctx)
+
+	if err := callGetPluginInfo(t, "unix://"+socketPath, insecure.NewCredentials()); err != nil {
+		t.Fatalf("GetPluginInfo before deletion failed: %v", err)
+	}
+
+	// Simulate the kubelet cleanup bug deleting the socket mid-run
+	if err := os.Remove(socketPath); err != nil {
+		t.Fatalf("failed to remove socket: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !isSocket(socketPath) {
+		if time.Now().After(deadline) {
+			t.Fatal("socket was not re-created")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := callGetPluginInfo(t, "unix://"+socketPath, insecure.NewCredentials()); err != nil {
+		t.Fatalf("GetPluginInfo after recovery failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "rds_csi_socket_recreations_total 1") {
+		t.Errorf("expected one socket recreation, got:\n%s", body)
+	}
+	if !strings.Contains(body, "rds_csi_registration_healthy 1") {
+		t.Errorf("expected registration_healthy 1, got:\n%s", body)
+	}
+}
+
+func TestSocketWatchdog_MissingRegistrationSocket(t *testing.T) {
+	server, socketPath := startTestSocketServer(t)
+	metrics := observability.NewMetrics()
+	metrics.EnableSocketWatchdogMetrics()
+
+	watchdog := NewSocketWatchdog(server, time.Hour, filepath.Join(filepath.Dir(socketPath), "reg.sock"), metrics)
+	watchdog.check()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "rds_csi_registration_healthy 0") {
+		t.Errorf("expected registration_healthy 0 without a registration socket, got:\n%s", rec.Body.String())
+	}
+}
+
+// failingRebindServer reports a socket path that never exists and cannot be re-created
+type failingRebindServer struct{}
+
+func (failingRebindServer) SocketPath() string { return "/nonexistent/csi.sock" }
+func (failingRebindServer) Rebind() error      { return errors.New("address in use") }
+
+func TestSocketWatchdog_RebindFailureCrashes(t *testing.T) {
+	watchdog := NewSocketWatchdog(failingRebindServer{}, time.Hour, "", nil)
+
+	var failure error
+	watchdog.onRebindFailure = func(err error) { failure = err }
+	watchdog.check()
+
+	if failure == nil || !strings.Contains(failure.Error(), "address in use") {
+		t.Errorf("expected rebind failure to be reported, got %v", failure)
+	}
+}
@@ -3,7 +3,10 @@ package mount
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +25,7 @@ const (
 // IMPORTANT: Only call this on UNMOUNTED devices. Running fsck on mounted
 // filesystems can cause false positives or corruption.
 func CheckFilesystemHealth(ctx context.Context, devicePath, fsType string) error {
+	logger := klog.FromContext(ctx).WithValues("device", devicePath, "fsType", fsType)
 	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
 	defer cancel()
 
@@ -38,7 +42,7 @@ func CheckFilesystemHealth(ctx context.Context, devicePath, fsType string) error
 		cmd = exec.CommandContext(ctx, "xfs_repair", "-n", devicePath)
 	default:
 		// Unknown filesystem - skip check (don't fail on unknown types)
-		klog.V(2).Infof("Skipping health check for unsupported filesystem type: %s", fsType)
+		logger.V(2).Info("Skipping health check for unsupported filesystem type")
 		return nil
 	}
 
@@ -46,7 +50,7 @@ func CheckFilesystemHealth(ctx context.Context, devicePath, fsType string) error
 	duration := time.Since(startTime)
 
 	if duration > 10*time.Second {
-		klog.Warningf("Filesystem health check took %v (device: %s, fsType: %s)", duration, devicePath, fsType)
+		logger.Info("Filesystem health check was slow", "duration", duration)
 	}
 
 	if ctx.Err() == context.DeadlineExceeded {
@@ -59,7 +63,7 @@ func CheckFilesystemHealth(ctx context.Context, devicePath, fsType string) error
 
 		// Check if command not found (tool not installed) - skip check gracefully
 		if strings.Contains(err.Error(), "executable file not found") || strings.Contains(err.Error(), "no such file or directory") {
-			klog.V(2).Infof("Skipping health check for %s: filesystem check tool not found", fsType)
+			logger.V(2).Info("Skipping health check: filesystem check tool not found")
 			return nil
 		}
 
@@ -67,7 +71,7 @@ func CheckFilesystemHealth(ctx context.Context, devicePath, fsType string) error
 		// fsck outputs "No such file or directory while trying to open /dev/X" when device is missing
 		if strings.Contains(outputStr, "No such file or directory while trying to open") ||
 			strings.Contains(outputStr, "Possibly non-existent device") {
-			klog.V(2).Infof("Skipping health check for %s: device %s not found (will be formatted)", fsType, devicePath)
+			logger.V(2).Info("Skipping health check: device not found (will be formatted)")
 			return nil
 		}
 
@@ -77,6 +81,67 @@ func CheckFilesystemHealth(ctx context.Context, devicePath, fsType string) error
 			devicePath, fsType, err, outputStr)
 	}
 
-	klog.V(4).Infof("Filesystem health check passed for %s (fsType: %s, duration: %v)", devicePath, fsType, duration)
+	logger.V(4).Info("Filesystem health check passed", "duration", duration)
 	return nil
 }
+
+// checkFilesystemErrors fills the error fields of stats for the filesystem mounted at path.
+// Best-effort: if the mount table or sysfs cannot be read, stats are left as healthy.
+//
+// /proc/mounts merges per-mount and superblock flags into one "ro", which cannot tell a
+// volume published read-only from one the kernel remounted after errors. mountinfo keeps
+// them apart: a read-write mount over a read-only superblock is an error remount.
+func (m *mounter) checkFilesystemErrors(path string, stats *DeviceStats) {
+	mountInfoPath := m.mountInfoPath
+	if mountInfoPath == "" {
+		mountInfoPath = procSelfMountInfo
+	}
+	mounts, err := readMountInfo(mountInfoPath)
+	if err != nil {
+		klog.V(4).Infof("Could not read mount table for %s: %v", path, err)
+		return
+	}
+
+	// The last entry wins when mounts are stacked on the same target
+	var entry *MountInfo
+	for i := range mounts {
+		if mounts[i].Target == path {
+			entry = &mounts[i]
+		}
+	}
+	if entry == nil {
+		return
+	}
+
+	stats.ReadOnlyRemount = hasMountOption(entry.Options, "rw") && hasMountOption(entry.SuperOptions, "ro")
+
+	if entry.FSType != "ext4" || !strings.HasPrefix(entry.Source, "/dev/") {
+		return
+	}
+	sysfsPath := m.sysfsPath
+	if sysfsPath == "" {
+		sysfsPath = "/sys"
+	}
+	errorsFile := filepath.Join(sysfsPath, "fs", "ext4", filepath.Base(entry.Source), "errors_count")
+	data, err := os.ReadFile(errorsFile)
+	if err != nil {
+		klog.V(4).Infof("Could not read ext4 error count for %s: %v", entry.Source, err)
+		return
+	}
+	count, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		klog.V(4).Infof("Ignoring malformed ext4 error count %q for %s", strings.TrimSpace(string(data)), entry.Source)
+		return
+	}
+	stats.FilesystemErrors = count
+}
+
+// hasMountOption reports whether a comma-separated option list contains opt
+func hasMountOption(options, opt string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package mount
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -36,3 +38,76 @@ func TestCheckFilesystemHealth_ContextCancelled(t *testing.T) {
 // Note: Testing actual filesystem checks requires root and real devices.
 // These tests verify the function handles edge cases gracefully.
 // Integration tests with real devices should be done in E2E tests.
+
+func TestCheckFilesystemErrors(t *testing.T) {
+	const target = "/var/lib/kubelet/pods/abc/volumes/kubernetes.io~csi/pvc-1/mount"
+
+	tests := []struct {
+		name        string
+		mountinfo   string
+		errorsCount string
+		wantRemount bool
+		wantErrors  int64
+	}{
+		{
+			name:      "healthy read-write",
+			mountinfo: "120 55 259:1 / " + target + " rw,relatime - ext4 /dev/nvme0n1 rw",
+		},
+		{
+			name:        "kernel remounted read-only",
+			mountinfo:   "120 55 259:1 / " + target + " rw,relatime - ext4 /dev/nvme0n1 ro,errors=remount-ro",
+			errorsCount: "3\n",
+			wantRemount: true,
+			wantErrors:  3,
+		},
+		{
+			name:      "published read-only",
+			mountinfo: "120 55 259:1 / " + target + " ro,relatime - ext4 /dev/nvme0n1 rw",
+		},
+		{
+			name:        "errors without remount",
+			mountinfo:   "120 55 259:1 / " + target + " rw,relatime - ext4 /dev/nvme0n1 rw",
+			errorsCount: "1",
+			wantErrors:  1,
+		},
+		{
+			name:        "xfs ignores ext4 counters",
+			mountinfo:   "120 55 259:1 / " + target + " rw,relatime - xfs /dev/nvme0n1 rw",
+			errorsCount: "5",
+		},
+		{
+			name:      "not in mount table",
+			mountinfo: "48 27 8:1 / /boot rw,relatime - ext4 /dev/sda1 ro",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			mountInfoPath := filepath.Join(tmpDir, "mountinfo")
+			if err := os.WriteFile(mountInfoPath, []byte(tt.mountinfo+"\n"), 0644); err != nil {
+				t.Fatalf("Failed to write mountinfo: %v", err)
+			}
+			if tt.errorsCount != "" {
+				ext4Dir := filepath.Join(tmpDir, "fs", "ext4", "nvme0n1")
+				if err := os.MkdirAll(ext4Dir, 0755); err != nil {
+					t.Fatalf("Failed to create sysfs dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(ext4Dir, "errors_count"), []byte(tt.errorsCount), 0644); err != nil {
+					t.Fatalf("Failed to write errors_count: %v", err)
+				}
+			}
+
+			m := &mounter{mountInfoPath: mountInfoPath, sysfsPath: tmpDir}
+			stats := &DeviceStats{}
+			m.checkFilesystemErrors(target, stats)
+
+			if stats.ReadOnlyRemount != tt.wantRemount {
+				t.Errorf("ReadOnlyRemount = %v, want %v", stats.ReadOnlyRemount, tt.wantRemount)
+			}
+			if stats.FilesystemErrors != tt.wantErrors {
+				t.Errorf("FilesystemErrors = %d, want %d", stats.FilesystemErrors, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -59,6 +59,11 @@ var allowedMountOptions = map[string]bool{
 	"strictatime": true,
 	"lazytime":    true,
 	"nolazytime":  true,
+	"discard":     true,
+	"nodiscard":   true,
+
+	// XFS project quota accounting and enforcement
+	"prjquota": true,
 }
 
 // Mounter handles filesystem operations
@@ -72,15 +77,22 @@ type Mounter interface {
 	// IsLikelyMountPoint checks if a path is a mount point
 	IsLikelyMountPoint(path string) (bool, error)
 
-	// Format formats the device with the given filesystem type
-	Format(device, fsType string) error
+	// Format formats the device with the given filesystem type. A non-empty fsUUID
+	// becomes the UUID of the new filesystem; otherwise mkfs generates one.
+	Format(device, fsType, fsUUID string) error
 
 	// IsFormatted checks if device has a filesystem
 	IsFormatted(device string) (bool, error)
 
+	// FilesystemUUID returns the UUID of the filesystem on device
+	FilesystemUUID(device string) (string, error)
+
 	// ResizeFilesystem resizes the filesystem on the device to use available space
 	ResizeFilesystem(device, volumePath string) error
 
+	// Trim discards unused blocks on the filesystem mounted at path (fstrim)
+	Trim(path string) error
+
 	// GetDeviceStats returns filesystem statistics
 	GetDeviceStats(path string) (*DeviceStats, error)
 
@@ -95,6 +107,15 @@ type Mounter interface {
 	// MakeFile creates an empty file at the given path
 	// Used for block volume target paths where target must be a file, not directory
 	MakeFile(pathname string) error
+
+	// EnableProjectQuota prepares the unmounted filesystem on device for project quotas
+	EnableProjectQuota(device, fsType string) error
+
+	// SetProjectQuota assigns the tree at path to projectID and limits the project's space
+	SetProjectQuota(path string, projectID uint32, limitBytes int64) error
+
+	// GetProjectQuota returns the usage and limit of projectID on the filesystem of path
+	GetProjectQuota(path string, projectID uint32) (*ProjectQuota, error)
 }
 
 // DeviceStats represents filesystem statistics
@@ -116,11 +137,22 @@ type DeviceStats struct {
 
 	// Available inodes
 	AvailableInodes int64
+
+	// ReadOnlyRemount is true when the mount was made read-write but the kernel has
+	// since switched the filesystem to read-only (ext4 errors=remount-ro)
+	ReadOnlyRemount bool
+
+	// FilesystemErrors is the ext4 errors_count from sysfs (0 if not ext4 or unavailable)
+	FilesystemErrors int64
 }
 
 // mounter implements Mounter interface using system commands
 type mounter struct {
 	execCommand func(name string, args ...string) *exec.Cmd
+
+	// mountInfoPath and sysfsPath override /proc/self/mountinfo and /sys in tests
+	mountInfoPath string
+	sysfsPath     string
 }
 
 // NewMounter creates a new filesystem mounter
@@ -339,8 +371,9 @@ func (m *mounter) IsLikelyMountPoint(path string) (bool, error) {
 	return len(output) > 0, nil
 }
 
-// Format formats a device with the specified filesystem type
-func (m *mounter) Format(device, fsType string) error {
+// Format formats a device with the specified filesystem type, and fsUUID as the
+// filesystem UUID if it is set
+func (m *mounter) Format(device, fsType, fsUUID string) error {
 	klog.V(4).Infof("Formatting device %s with %s", device, fsType)
 
 	// Check if already formatted
@@ -358,18 +391,23 @@ func (m *mounter) Format(device, fsType string) error {
 	klog.V(2).Infof("Format: device %s confirmed unformatted by blkid, proceeding with mkfs.%s", device, fsType)
 
 	// Build mkfs command based on filesystem type
-	var cmd *exec.Cmd
+	var args []string
 	switch fsType {
-	case "ext4":
-		// mkfs.ext4 -F (force) device
-		cmd = m.execCommand("mkfs.ext4", "-F", device)
-	case "ext3":
-		cmd = m.execCommand("mkfs.ext3", "-F", device)
+	case "ext4", "ext3":
+		// mkfs.ext4 -F (force) [-U uuid] device
+		args = []string{"-F"}
+		if fsUUID != "" {
+			args = append(args, "-U", fsUUID)
+		}
 	case "xfs":
-		cmd = m.execCommand("mkfs.xfs", "-f", device)
+		args = []string{"-f"}
+		if fsUUID != "" {
+			args = append(args, "-m", "uuid="+fsUUID)
+		}
 	default:
 		return fmt.Errorf("unsupported filesystem type: %s", fsType)
 	}
+	cmd := m.execCommand("mkfs."+fsType, append(args, device)...)
 
 	// Execute mkfs command
 	output, err := cmd.CombinedOutput()
@@ -421,6 +459,49 @@ func (m *mounter) IsFormatted(device string) (bool, error) {
 	return false, nil
 }
 
+// FilesystemUUID returns the UUID of the filesystem on device, as reported by blkid
+func (m *mounter) FilesystemUUID(device string) (string, error) {
+	cmd := m.execCommand("blkid", "-o", "value", "-s", "UUID", device)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("blkid failed to read filesystem UUID of %s: %w (output: %s)", device, err, strings.TrimSpace(string(output)))
+	}
+
+	uuid := strings.TrimSpace(string(output))
+	if uuid == "" {
+		return "", fmt.Errorf("no filesystem UUID on %s", device)
+	}
+	return uuid, nil
+}
+
+// FilesystemMismatchError is returned when a device does not carry the filesystem
+// recorded for the volume it was resolved for
+type FilesystemMismatchError struct {
+	DevicePath string
+	Expected   string
+	Actual     string // "" if the device has no filesystem
+}
+
+func (e *FilesystemMismatchError) Error() string {
+	if e.Actual == "" {
+		return fmt.Sprintf("device %s has no filesystem, expected filesystem UUID %s", e.DevicePath, e.Expected)
+	}
+	return fmt.Sprintf("device %s has filesystem UUID %s, expected %s - refusing to mount what may be the wrong device", e.DevicePath, e.Actual, e.Expected)
+}
+
+// CheckFilesystemUUID returns a *FilesystemMismatchError unless the filesystem on device
+// has the UUID expected
+func CheckFilesystemUUID(m Mounter, device, expected string) error {
+	actual, err := m.FilesystemUUID(device)
+	if err != nil {
+		return fmt.Errorf("failed to read filesystem UUID: %w", err)
+	}
+	if !strings.EqualFold(actual, expected) {
+		return &FilesystemMismatchError{DevicePath: device, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
 // ResizeFilesystem resizes the filesystem on the device to use available space
 func (m *mounter) ResizeFilesystem(device, volumePath string) error {
 	klog.V(4).Infof("Resizing filesystem on device %s (volume path: %s)", device, volumePath)
@@ -468,6 +549,21 @@ func (m *mounter) ResizeFilesystem(device, volumePath string) error {
 	return nil
 }
 
+// Trim discards unused blocks on the filesystem mounted at path so thin-provisioned
+// backing storage can reclaim them
+func (m *mounter) Trim(path string) error {
+	klog.V(4).Infof("Trimming filesystem mounted at %s", path)
+
+	cmd := m.execCommand("fstrim", "-v", path)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fstrim failed: %w, output: %s", err, string(output))
+	}
+
+	klog.V(2).Infof("Trimmed filesystem at %s: %s", path, strings.TrimSpace(string(output)))
+	return nil
+}
+
 // GetDeviceStats returns filesystem statistics for the given path
 func (m *mounter) GetDeviceStats(path string) (*DeviceStats, error) {
 	// Use df to get filesystem statistics
@@ -502,6 +598,8 @@ func (m *mounter) GetDeviceStats(path string) (*DeviceStats, error) {
 	_, _ = fmt.Sscanf(fields[4], "%d", &stats.UsedInodes)
 	_, _ = fmt.Sscanf(fields[5], "%d", &stats.AvailableInodes)
 
+	m.checkFilesystemErrors(path, stats)
+
 	return stats, nil
 }
 
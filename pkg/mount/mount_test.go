@@ -1,6 +1,7 @@
 package mount
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -254,7 +255,7 @@ func TestFormat(t *testing.T) {
 				execCommand: mockExecCommand(blkidOutput, "", blkidExitCode),
 			}
 
-			err := m.Format(tt.device, tt.fsType)
+			err := m.Format(tt.device, tt.fsType, "")
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got nil")
 			}
@@ -340,6 +341,60 @@ func TestIsFormatted(t *testing.T) {
 	}
 }
 
+func TestFilesystemUUID(t *testing.T) {
+	tests := []struct {
+		name          string
+		blkidOutput   string
+		blkidExitCode int
+		expectedUUID  string
+		expectError   bool
+	}{
+		{
+			name:         "ext4 UUID",
+			blkidOutput:  "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a\n",
+			expectedUUID: "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a",
+		},
+		{
+			name:         "xfs UUID",
+			blkidOutput:  "c0a9e8a3-0f6b-4f55-9c5e-2b1f4a7d8e90",
+			expectedUUID: "c0a9e8a3-0f6b-4f55-9c5e-2b1f4a7d8e90",
+		},
+		{
+			name:          "no filesystem",
+			blkidExitCode: 2,
+			expectError:   true,
+		},
+		{
+			name:          "device error",
+			blkidExitCode: 1,
+			expectError:   true,
+		},
+		{
+			name:        "empty output",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mounter{
+				execCommand: mockExecCommand(tt.blkidOutput, "", tt.blkidExitCode),
+			}
+
+			uuid, err := m.FilesystemUUID("/dev/nvme0n1")
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if uuid != tt.expectedUUID {
+				t.Errorf("Expected UUID %q, got %q", tt.expectedUUID, uuid)
+			}
+		})
+	}
+}
+
 func TestGetDeviceStats(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -444,7 +499,7 @@ func TestFormatUnsupportedFilesystem(t *testing.T) {
 		execCommand: mockExecCommand("", "", 2),
 	}
 
-	err := m.Format("/dev/test", "unsupported-fs")
+	err := m.Format("/dev/test", "unsupported-fs", "")
 	if err == nil {
 		t.Error("Expected error for unsupported filesystem")
 	}
@@ -453,6 +508,65 @@ func TestFormatUnsupportedFilesystem(t *testing.T) {
 	}
 }
 
+func TestFormatWithUUID(t *testing.T) {
+	const fsUUID = "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a"
+	tests := []struct {
+		fsType   string
+		fsUUID   string
+		wantArgs []string
+	}{
+		{fsType: "ext4", fsUUID: fsUUID, wantArgs: []string{"-F", "-U", fsUUID, "/dev/test"}},
+		{fsType: "ext3", fsUUID: fsUUID, wantArgs: []string{"-F", "-U", fsUUID, "/dev/test"}},
+		{fsType: "xfs", fsUUID: fsUUID, wantArgs: []string{"-f", "-m", "uuid=" + fsUUID, "/dev/test"}},
+		{fsType: "ext4", wantArgs: []string{"-F", "/dev/test"}},
+		{fsType: "xfs", wantArgs: []string{"-f", "/dev/test"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fsType+"/"+tt.fsUUID, func(t *testing.T) {
+			var mkfsArgs []string
+			m := &mounter{
+				execCommand: func(name string, args ...string) *exec.Cmd {
+					if name == "blkid" {
+						return mockExecCommand("", "", 2)(name, args...)
+					}
+					if name != "mkfs."+tt.fsType {
+						t.Errorf("unexpected command %s", name)
+					}
+					mkfsArgs = args
+					return mockExecCommand("", "", 0)(name, args...)
+				},
+			}
+
+			if err := m.Format("/dev/test", tt.fsType, tt.fsUUID); err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if strings.Join(mkfsArgs, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("expected mkfs args %v, got %v", tt.wantArgs, mkfsArgs)
+			}
+		})
+	}
+}
+
+func TestCheckFilesystemUUID(t *testing.T) {
+	m := &mounter{
+		execCommand: mockExecCommand("3E6BE9DE-8139-4BC6-B2B8-9A0D1F9E4C1A\n", "", 0),
+	}
+
+	if err := CheckFilesystemUUID(m, "/dev/test", "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a"); err != nil {
+		t.Errorf("expected the UUID to match regardless of case, got %v", err)
+	}
+
+	err := CheckFilesystemUUID(m, "/dev/test", "c0a9e8a3-0f6b-4f55-9c5e-2b1f4a7d8e90")
+	var mismatchErr *FilesystemMismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("expected *FilesystemMismatchError, got %v", err)
+	}
+	if mismatchErr.Actual != "3E6BE9DE-8139-4BC6-B2B8-9A0D1F9E4C1A" {
+		t.Errorf("expected the actual UUID in the error, got %q", mismatchErr.Actual)
+	}
+}
+
 func TestValidateMountOptions(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -1047,6 +1161,30 @@ func BenchmarkSanitizeMountOptions(b *testing.B) {
 }
 
 // TestMount_ErrorScenarios tests mount error path handling
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		wantErr  bool
+	}{
+		{name: "successful trim", exitCode: 0},
+		{name: "fstrim fails", exitCode: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mounter{
+				execCommand: mockExecCommand("/mnt/staging: 9.5 GiB (10200547328 bytes) trimmed\n", "", tt.exitCode),
+			}
+
+			err := m.Trim("/mnt/staging")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Trim() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestMount_ErrorScenarios(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1210,7 +1348,7 @@ func TestFormat_ErrorScenarios(t *testing.T) {
 				},
 			}
 
-			err := m.Format(tt.device, tt.fsType)
+			err := m.Format(tt.device, tt.fsType, "")
 
 			if tt.expectError {
 				if err == nil {
@@ -1243,7 +1381,7 @@ func TestFormat_BlkidDeviceError(t *testing.T) {
 		},
 	}
 
-	err := m.Format("/dev/nvme0n1", "ext4")
+	err := m.Format("/dev/nvme0n1", "ext4", "")
 	if err == nil {
 		t.Fatal("expected error when blkid exits with status 1, got nil")
 	}
@@ -18,6 +18,9 @@ const (
 
 	// MaxDuplicateMountsPerDevice is the threshold for mount storm detection
 	MaxDuplicateMountsPerDevice = 100
+
+	// procSelfMountInfo is the kernel mount table for this process
+	procSelfMountInfo = "/proc/self/mountinfo"
 )
 
 // MountInfo represents a single mount point entry from /proc/self/mountinfo
@@ -33,6 +36,9 @@ type MountInfo struct {
 
 	// Options are the mount options (field 6)
 	Options string
+
+	// SuperOptions are the per-superblock options (last field)
+	SuperOptions string
 }
 
 // GetMounts parses /proc/self/mountinfo and returns all mount points.
@@ -41,9 +47,14 @@ type MountInfo struct {
 // Format: ID PARENT_ID MAJOR:MINOR ROOT MOUNT_POINT OPTIONS OPTIONAL_FIELDS - FSTYPE SOURCE SUPER_OPTIONS
 // Example: 36 35 0:34 / /sys/fs/cgroup/memory rw,nosuid,nodev,noexec,relatime - cgroup cgroup rw,memory
 func GetMounts() ([]MountInfo, error) {
-	file, err := os.Open("/proc/self/mountinfo")
+	return readMountInfo(procSelfMountInfo)
+}
+
+// readMountInfo parses a mountinfo-format file
+func readMountInfo(path string) ([]MountInfo, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open /proc/self/mountinfo: %w", err)
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
 	}
 	defer file.Close()
 
@@ -64,10 +75,10 @@ func GetMounts() ([]MountInfo, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading /proc/self/mountinfo: %w", err)
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
 	}
 
-	klog.V(5).Infof("Parsed %d mount points from /proc/self/mountinfo", len(mounts))
+	klog.V(5).Infof("Parsed %d mount points from %s", len(mounts), path)
 	return mounts, nil
 }
 
@@ -101,12 +112,17 @@ func parseMountInfoLine(line string) (MountInfo, error) {
 	options := fields[5]
 	fsType := fields[separatorIndex+1]
 	source := unescapePath(fields[separatorIndex+2])
+	superOptions := ""
+	if separatorIndex+3 < len(fields) {
+		superOptions = fields[separatorIndex+3]
+	}
 
 	return MountInfo{
-		Source:  source,
-		Target:  target,
-		FSType:  fsType,
-		Options: options,
+		Source:       source,
+		Target:       target,
+		FSType:       fsType,
+		Options:      options,
+		SuperOptions: superOptions,
 	}, nil
 }
 
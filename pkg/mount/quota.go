@@ -0,0 +1,129 @@
+package mount
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// quotaCommandTimeout bounds an xfs_quota run, which blocks for as long as the
+// filesystem it queries does (a variable for tests)
+var quotaCommandTimeout = 10 * time.Second
+
+// ProjectQuota is the block usage and hard limit of a filesystem project
+type ProjectQuota struct {
+	// UsedBytes is the space used by files of the project
+	UsedBytes int64
+
+	// LimitBytes is the hard block limit (0 when unlimited)
+	LimitBytes int64
+}
+
+// EnableProjectQuota prepares the unmounted filesystem on device for project quotas.
+// ext4 needs the project and quota features, which tune2fs turns on; XFS only needs
+// the prjquota mount option, so there is nothing to do.
+func (m *mounter) EnableProjectQuota(device, fsType string) error {
+	switch fsType {
+	case "ext4":
+		cmd := m.execCommand("tune2fs", "-O", "project", "-Q", "prjquota", device)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("tune2fs failed to enable project quota on %s: %w, output: %s", device, err, string(output))
+		}
+		klog.V(4).Infof("Enabled project quota on %s", device)
+		return nil
+	case "xfs":
+		return nil
+	default:
+		return fmt.Errorf("project quota is not supported on %s", fsType)
+	}
+}
+
+// SetProjectQuota assigns the directory tree at path to projectID, so files created below
+// it inherit the project, and sets the project's hard block limit to limitBytes, rounded
+// up to a KiB. path must be on a filesystem mounted with project quota enabled.
+func (m *mounter) SetProjectQuota(path string, projectID uint32, limitBytes int64) error {
+	if limitBytes <= 0 {
+		return fmt.Errorf("invalid project quota limit %d", limitBytes)
+	}
+	limitKiB := (limitBytes + 1023) / 1024
+
+	for _, command := range []string{
+		fmt.Sprintf("project -s -p %s %d", path, projectID),
+		fmt.Sprintf("limit -p bhard=%dk %d", limitKiB, projectID),
+	} {
+		if _, err := m.xfsQuota(path, command); err != nil {
+			return err
+		}
+	}
+
+	klog.V(2).Infof("Set project quota of %s to %d bytes (project %d)", path, limitKiB*1024, projectID)
+	return nil
+}
+
+// GetProjectQuota returns the usage and limit of projectID on the filesystem of path.
+// A project without usage or limit reports zeros.
+func (m *mounter) GetProjectQuota(path string, projectID uint32) (*ProjectQuota, error) {
+	output, err := m.xfsQuota(path, "report -p -n -N -b")
+	if err != nil {
+		return nil, err
+	}
+	return parseProjectQuotaReport(output, projectID)
+}
+
+// xfsQuota runs an expert xfs_quota command on the filesystem of path. Foreign mode
+// (-f) lets the same commands manage ext4 project quotas. A run that outlasts
+// quotaCommandTimeout is killed and reported as timed out.
+func (m *mounter) xfsQuota(path, command string) (string, error) {
+	var output bytes.Buffer
+	cmd := m.execCommand("xfs_quota", "-x", "-f", "-c", command, path)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("xfs_quota %q failed on %s: %w", command, path, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf("xfs_quota %q failed on %s: %w, output: %s", command, path, err, strings.TrimSpace(output.String()))
+		}
+		return output.String(), nil
+	case <-time.After(quotaCommandTimeout):
+		// A process stuck in the filesystem only dies once it returns from there, so
+		// leave the goroutine to reap it rather than waiting here
+		_ = cmd.Process.Kill()
+		return "", fmt.Errorf("%w: xfs_quota %q on %s did not finish within %v", utils.ErrOperationTimeout, command, path, quotaCommandTimeout)
+	}
+}
+
+// parseProjectQuotaReport extracts projectID from the output of "report -p -n -N -b",
+// lines of "#<id> <used> <soft> <hard> <warn/grace>" with sizes in KiB
+func parseProjectQuotaReport(output string, projectID uint32) (*ProjectQuota, error) {
+	id := "#" + strconv.FormatUint(uint64(projectID), 10)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != id {
+			continue
+		}
+		used, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected usage %q of project %d", fields[1], projectID)
+		}
+		hard, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected hard limit %q of project %d", fields[3], projectID)
+		}
+		return &ProjectQuota{UsedBytes: used * 1024, LimitBytes: hard * 1024}, nil
+	}
+	return &ProjectQuota{}, nil
+}
@@ -0,0 +1,137 @@
+package mount
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// recordExecCommand wraps execCommand and records every command line it runs
+func recordExecCommand(calls *[]string, execCommand func(string, ...string) *exec.Cmd) func(string, ...string) *exec.Cmd {
+	return func(command string, args ...string) *exec.Cmd {
+		*calls = append(*calls, strings.Join(append([]string{command}, args...), " "))
+		return execCommand(command, args...)
+	}
+}
+
+func TestEnableProjectQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		fsType      string
+		exitCode    int
+		wantCalls   []string
+		expectError bool
+	}{
+		{
+			name:      "ext4 turns on project quota",
+			fsType:    "ext4",
+			wantCalls: []string{"tune2fs -O project -Q prjquota /dev/nvme0n1"},
+		},
+		{
+			name:        "ext4 tune2fs fails",
+			fsType:      "ext4",
+			exitCode:    1,
+			wantCalls:   []string{"tune2fs -O project -Q prjquota /dev/nvme0n1"},
+			expectError: true,
+		},
+		{
+			name:   "xfs needs no preparation",
+			fsType: "xfs",
+		},
+		{
+			name:        "ext3 is not supported",
+			fsType:      "ext3",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			m := &mounter{execCommand: recordExecCommand(&calls, mockExecCommand("", "", tt.exitCode))}
+
+			err := m.EnableProjectQuota("/dev/nvme0n1", tt.fsType)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("EnableProjectQuota error = %v, expectError %v", err, tt.expectError)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("commands = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSetProjectQuota(t *testing.T) {
+	var calls []string
+	m := &mounter{execCommand: recordExecCommand(&calls, mockExecCommand("", "", 0))}
+
+	// 1GiB plus a byte rounds up to the next KiB
+	if err := m.SetProjectQuota("/mnt/staging", 1, 1<<30+1); err != nil {
+		t.Fatalf("SetProjectQuota failed: %v", err)
+	}
+	want := []string{
+		"xfs_quota -x -f -c project -s -p /mnt/staging 1 /mnt/staging",
+		"xfs_quota -x -f -c limit -p bhard=1048577k 1 /mnt/staging",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("commands = %q, want %q", calls, want)
+	}
+
+	if err := m.SetProjectQuota("/mnt/staging", 1, 0); err == nil {
+		t.Error("expected a zero limit to be rejected")
+	}
+
+	m = &mounter{execCommand: mockExecCommand("", "xfs_quota: cannot set limits", 1)}
+	err := m.SetProjectQuota("/mnt/staging", 1, 1<<30)
+	if err == nil || !strings.Contains(err.Error(), "cannot set limits") {
+		t.Errorf("expected the xfs_quota error, got %v", err)
+	}
+}
+
+func TestGetProjectQuota(t *testing.T) {
+	report := "#0          20          0          0     00 [--------]\n" +
+		"#1      524288          0    1048576     00 [--------]\n"
+	m := &mounter{execCommand: mockExecCommand(report, "", 0)}
+
+	quota, err := m.GetProjectQuota("/mnt/staging", 1)
+	if err != nil {
+		t.Fatalf("GetProjectQuota failed: %v", err)
+	}
+	if quota.UsedBytes != 512<<20 || quota.LimitBytes != 1<<30 {
+		t.Errorf("quota = %+v, want 512MiB used of 1GiB", quota)
+	}
+
+	quota, err = m.GetProjectQuota("/mnt/staging", 2)
+	if err != nil {
+		t.Fatalf("GetProjectQuota failed: %v", err)
+	}
+	if quota.UsedBytes != 0 || quota.LimitBytes != 0 {
+		t.Errorf("expected zeros for a project without quota, got %+v", quota)
+	}
+
+	if _, err := parseProjectQuotaReport("#1 lots 0 1024 00", 1); err == nil {
+		t.Error("expected an unparsable usage to be rejected")
+	}
+}
+
+func TestGetProjectQuota_Timeout(t *testing.T) {
+	defer func(timeout time.Duration) { quotaCommandTimeout = timeout }(quotaCommandTimeout)
+	quotaCommandTimeout = 100 * time.Millisecond
+
+	// xfs_quota blocks for as long as the filesystem it queries does
+	m := &mounter{execCommand: func(string, ...string) *exec.Cmd { return exec.Command("sleep", "10") }}
+
+	start := time.Now()
+	_, err := m.GetProjectQuota("/mnt/staging", 1)
+	if !errors.Is(err, utils.ErrOperationTimeout) {
+		t.Fatalf("GetProjectQuota error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetProjectQuota returned after %v, want about %v", elapsed, quotaCommandTimeout)
+	}
+}
@@ -71,15 +71,18 @@ func (r *MountRecoverer) SetMetrics(metrics *observability.Metrics) {
 //     a. Try ForceUnmount with NormalUnmountWait timeout
 //     b. If unmount fails with "in use" error: return error (don't retry)
 //     c. If unmount succeeds: resolve new device path and mount
-//     d. If mount succeeds: return success
-//     e. If mount fails: log warning, sleep with exponential backoff, continue
+//     d. If fsUUID is set and the new device does not carry that filesystem: return
+//     the error, a *FilesystemMismatchError for another filesystem (don't retry)
+//     e. If mount succeeds: return success
+//     f. If mount fails: log warning, sleep with exponential backoff, continue
 //  3. If all attempts fail: return result with FinalError
-func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn string, fsType string, mountOptions []string) (*RecoveryResult, error) {
-	klog.V(2).Infof("Starting mount recovery for %s (NQN: %s)", mountPath, nqn)
+func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn string, fsType string, mountOptions []string, fsUUID string) (*RecoveryResult, error) {
+	logger := klog.FromContext(ctx).WithValues("mountPath", mountPath, "nqn", nqn)
+	logger.V(2).Info("Starting mount recovery")
 
 	// If resolver is nil (test environment), we can't do recovery
 	if r.resolver == nil {
-		klog.V(4).Infof("Recovery skipped for %s: resolver not configured (test mode)", mountPath)
+		logger.V(4).Info("Recovery skipped: resolver not configured (test mode)")
 		return &RecoveryResult{
 			Recovered:  false,
 			Attempts:   0,
@@ -103,7 +106,7 @@ func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn stri
 
 	for attempt := 1; attempt <= r.config.MaxAttempts; attempt++ {
 		result.Attempts = attempt
-		klog.V(4).Infof("Mount recovery attempt %d/%d for %s", attempt, r.config.MaxAttempts, mountPath)
+		logger.V(4).Info("Mount recovery attempt", "attempt", attempt, "maxAttempts", r.config.MaxAttempts)
 
 		// Check context cancellation
 		select {
@@ -114,28 +117,28 @@ func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn stri
 		}
 
 		// Step 1: Try to unmount the stale mount
-		klog.V(4).Infof("Attempting ForceUnmount for %s with timeout %v", mountPath, r.config.NormalUnmountWait)
+		logger.V(4).Info("Attempting ForceUnmount", "timeout", r.config.NormalUnmountWait)
 		err := r.mounter.ForceUnmount(mountPath, r.config.NormalUnmountWait)
 		if err != nil {
 			// Check if mount is in use - if so, refuse to retry
 			inUse, pids, checkErr := r.mounter.IsMountInUse(mountPath)
 			if checkErr != nil {
-				klog.V(4).Infof("Failed to check if mount is in use: %v", checkErr)
+				logger.V(4).Info("Failed to check if mount is in use", "err", checkErr)
 			}
 
 			if inUse {
 				result.FinalError = fmt.Errorf("mount is in use by processes %v, refusing to force unmount", pids)
-				klog.Warningf("Recovery failed for %s: mount is in use by processes %v", mountPath, pids)
+				logger.Info("Recovery failed: mount is in use", "pids", pids)
 				return result, result.FinalError
 			}
 
 			// Unmount failed but mount is not in use - may be transient, continue
-			klog.Warningf("ForceUnmount failed for %s (attempt %d/%d): %v", mountPath, attempt, r.config.MaxAttempts, err)
+			logger.Info("ForceUnmount failed", "attempt", attempt, "maxAttempts", r.config.MaxAttempts, "err", err)
 			result.FinalError = fmt.Errorf("unmount failed: %w", err)
 
 			// Sleep before next attempt if not last attempt
 			if attempt < r.config.MaxAttempts {
-				klog.V(4).Infof("Sleeping %v before retry", backoff)
+				logger.V(4).Info("Sleeping before retry", "backoff", backoff)
 				select {
 				case <-ctx.Done():
 					result.FinalError = ctx.Err()
@@ -147,17 +150,17 @@ func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn stri
 			continue
 		}
 
-		klog.V(4).Infof("Successfully unmounted stale mount %s", mountPath)
+		logger.V(4).Info("Successfully unmounted stale mount")
 
 		// Step 2: Resolve new device path from NQN
 		newDevice, err := r.resolver.ResolveDevicePath(nqn)
 		if err != nil {
 			result.FinalError = fmt.Errorf("failed to resolve NQN after unmount: %w", err)
-			klog.Warningf("Failed to resolve NQN %s after unmount (attempt %d/%d): %v", nqn, attempt, r.config.MaxAttempts, err)
+			logger.Info("Failed to resolve NQN after unmount", "attempt", attempt, "maxAttempts", r.config.MaxAttempts, "err", err)
 
 			// Sleep before next attempt if not last attempt
 			if attempt < r.config.MaxAttempts {
-				klog.V(4).Infof("Sleeping %v before retry", backoff)
+				logger.V(4).Info("Sleeping before retry", "backoff", backoff)
 				select {
 				case <-ctx.Done():
 					result.FinalError = ctx.Err()
@@ -170,18 +173,30 @@ func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn stri
 		}
 
 		result.NewDevice = newDevice
-		klog.V(4).Infof("Resolved new device for NQN %s: %s", nqn, newDevice)
+		logger.V(4).Info("Resolved new device", "device", newDevice)
+
+		// The device the NQN resolves to now must still carry the volume's filesystem
+		if fsUUID != "" {
+			if err := CheckFilesystemUUID(r.mounter, newDevice, fsUUID); err != nil {
+				result.FinalError = err
+				logger.Info("Recovery failed: refusing to mount new device", "device", newDevice, "err", err)
+				if r.metrics != nil {
+					r.metrics.RecordStaleRecovery(err)
+				}
+				return result, err
+			}
+		}
 
 		// Step 3: Mount new device to mount path
-		klog.V(4).Infof("Attempting to mount %s to %s with fsType %s", newDevice, mountPath, fsType)
+		logger.V(4).Info("Attempting to mount new device", "device", newDevice, "fsType", fsType)
 		err = r.mounter.Mount(newDevice, mountPath, fsType, mountOptions)
 		if err != nil {
 			result.FinalError = fmt.Errorf("mount failed: %w", err)
-			klog.Warningf("Failed to mount %s to %s (attempt %d/%d): %v", newDevice, mountPath, attempt, r.config.MaxAttempts, err)
+			logger.Info("Failed to mount new device", "device", newDevice, "attempt", attempt, "maxAttempts", r.config.MaxAttempts, "err", err)
 
 			// Sleep before next attempt if not last attempt
 			if attempt < r.config.MaxAttempts {
-				klog.V(4).Infof("Sleeping %v before retry", backoff)
+				logger.V(4).Info("Sleeping before retry", "backoff", backoff)
 				select {
 				case <-ctx.Done():
 					result.FinalError = ctx.Err()
@@ -194,8 +209,7 @@ func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn stri
 		}
 
 		// Success!
-		klog.V(2).Infof("Recovered mount %s (old device: %s, new device: %s) after %d attempt(s)",
-			mountPath, result.OldDevice, result.NewDevice, attempt)
+		logger.V(2).Info("Recovered mount", "oldDevice", result.OldDevice, "newDevice", result.NewDevice, "attempts", attempt)
 		result.Recovered = true
 		result.FinalError = nil
 		// Record successful recovery metric
@@ -206,7 +220,7 @@ func (r *MountRecoverer) Recover(ctx context.Context, mountPath string, nqn stri
 	}
 
 	// All attempts failed
-	klog.Errorf("Mount recovery failed for %s after %d attempts: %v", mountPath, r.config.MaxAttempts, result.FinalError)
+	logger.Error(result.FinalError, "Mount recovery failed", "attempts", r.config.MaxAttempts)
 	// Record failed recovery metric
 	if r.metrics != nil {
 		r.metrics.RecordStaleRecovery(result.FinalError)
@@ -2,6 +2,7 @@ package mount
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -18,6 +19,7 @@ type mockMounter struct {
 	isMountInUseResult bool
 	isMountInUsePids   []int
 	isMountInUseErr    error
+	fsUUID             string
 
 	// Track calls for verification
 	lastMountSource  string
@@ -53,7 +55,7 @@ func (m *mockMounter) IsLikelyMountPoint(path string) (bool, error) {
 	return false, nil
 }
 
-func (m *mockMounter) Format(device, fsType string) error {
+func (m *mockMounter) Format(device, fsType, fsUUID string) error {
 	return nil
 }
 
@@ -61,10 +63,18 @@ func (m *mockMounter) IsFormatted(device string) (bool, error) {
 	return true, nil
 }
 
+func (m *mockMounter) FilesystemUUID(device string) (string, error) {
+	return m.fsUUID, nil
+}
+
 func (m *mockMounter) ResizeFilesystem(device, volumePath string) error {
 	return nil
 }
 
+func (m *mockMounter) Trim(path string) error {
+	return nil
+}
+
 func (m *mockMounter) GetDeviceStats(path string) (*DeviceStats, error) {
 	return nil, nil
 }
@@ -73,6 +83,18 @@ func (m *mockMounter) MakeFile(pathname string) error {
 	return nil
 }
 
+func (m *mockMounter) EnableProjectQuota(device, fsType string) error {
+	return nil
+}
+
+func (m *mockMounter) SetProjectQuota(path string, projectID uint32, limitBytes int64) error {
+	return nil
+}
+
+func (m *mockMounter) GetProjectQuota(path string, projectID uint32) (*ProjectQuota, error) {
+	return &ProjectQuota{}, nil
+}
+
 // TestRecover_SucceedsFirstAttempt tests successful recovery on first try
 func TestRecover_SucceedsFirstAttempt(t *testing.T) {
 	nqn := "nqn.2000-02.com.mikrotik:pvc-test"
@@ -102,7 +124,7 @@ func TestRecover_SucceedsFirstAttempt(t *testing.T) {
 	options := []string{"rw"}
 
 	ctx := context.Background()
-	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options)
+	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options, "")
 
 	// Should succeed
 	if err != nil {
@@ -179,7 +201,7 @@ func TestRecover_SucceedsAfterRetry(t *testing.T) {
 
 	ctx := context.Background()
 	startTime := time.Now()
-	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options)
+	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options, "")
 	duration := time.Since(startTime)
 
 	// Should succeed
@@ -234,11 +256,20 @@ func (m *mockMounterWithRetry) IsMountInUse(path string) (bool, []int, error) {
 
 func (m *mockMounterWithRetry) Unmount(target string) error                      { return nil }
 func (m *mockMounterWithRetry) IsLikelyMountPoint(path string) (bool, error)     { return false, nil }
-func (m *mockMounterWithRetry) Format(device, fsType string) error               { return nil }
+func (m *mockMounterWithRetry) Format(device, fsType, fsUUID string) error       { return nil }
 func (m *mockMounterWithRetry) IsFormatted(device string) (bool, error)          { return true, nil }
+func (m *mockMounterWithRetry) FilesystemUUID(device string) (string, error)     { return "", nil }
 func (m *mockMounterWithRetry) ResizeFilesystem(device, volumePath string) error { return nil }
+func (m *mockMounterWithRetry) Trim(path string) error                           { return nil }
 func (m *mockMounterWithRetry) GetDeviceStats(path string) (*DeviceStats, error) { return nil, nil }
 func (m *mockMounterWithRetry) MakeFile(pathname string) error                   { return nil }
+func (m *mockMounterWithRetry) EnableProjectQuota(device, fsType string) error   { return nil }
+func (m *mockMounterWithRetry) SetProjectQuota(path string, projectID uint32, limitBytes int64) error {
+	return nil
+}
+func (m *mockMounterWithRetry) GetProjectQuota(path string, projectID uint32) (*ProjectQuota, error) {
+	return &ProjectQuota{}, nil
+}
 
 // TestRecover_FailsAllAttempts tests that recovery fails after max attempts
 func TestRecover_FailsAllAttempts(t *testing.T) {
@@ -265,7 +296,7 @@ func TestRecover_FailsAllAttempts(t *testing.T) {
 	options := []string{"rw"}
 
 	ctx := context.Background()
-	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options)
+	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options, "")
 
 	// Should fail
 	if err == nil {
@@ -318,7 +349,7 @@ func TestRecover_RefusesMountInUse(t *testing.T) {
 	options := []string{"rw"}
 
 	ctx := context.Background()
-	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options)
+	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options, "")
 
 	// Should fail immediately
 	if err == nil {
@@ -345,6 +376,44 @@ func TestRecover_RefusesMountInUse(t *testing.T) {
 	}
 }
 
+// TestRecover_RefusesOtherFilesystem tests that a device carrying another filesystem
+// than the volume's is not mounted
+func TestRecover_RefusesOtherFilesystem(t *testing.T) {
+	nqn := "nqn.2000-02.com.mikrotik:pvc-test"
+	newDevice := "/dev/nvme1n1"
+
+	resolver := createMockResolver(t, nqn, newDevice, false)
+	mounter := &mockMounter{fsUUID: "c0a9e8a3-0f6b-4f55-9c5e-2b1f4a7d8e90"}
+	checker := NewStaleMountChecker(resolver)
+	recoverer := NewMountRecoverer(DefaultRecoveryConfig(), mounter, checker, resolver)
+
+	ctx := context.Background()
+	result, err := recoverer.Recover(ctx, "/var/lib/kubelet/pods/test", nqn, "ext4", []string{"rw"}, "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a")
+
+	var mismatchErr *FilesystemMismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("Expected *FilesystemMismatchError, got %v", err)
+	}
+	if mismatchErr.DevicePath != newDevice {
+		t.Errorf("Expected device %s in error, got %s", newDevice, mismatchErr.DevicePath)
+	}
+	if result.Recovered || result.Attempts != 1 {
+		t.Errorf("Expected one failed attempt, got %+v", result)
+	}
+	if mounter.mountCalls != 0 {
+		t.Errorf("Expected 0 Mount calls, got %d", mounter.mountCalls)
+	}
+
+	// The volume's own filesystem is mounted
+	mounter.fsUUID = "3E6BE9DE-8139-4BC6-B2B8-9A0D1F9E4C1A"
+	if _, err := recoverer.Recover(ctx, "/var/lib/kubelet/pods/test", nqn, "ext4", []string{"rw"}, "3e6be9de-8139-4bc6-b2b8-9a0d1f9e4c1a"); err != nil {
+		t.Fatalf("Expected recovery to succeed, got %v", err)
+	}
+	if mounter.mountCalls != 1 {
+		t.Errorf("Expected 1 Mount call, got %d", mounter.mountCalls)
+	}
+}
+
 // TestRecover_RespectsContext tests that recovery respects context cancellation
 func TestRecover_RespectsContext(t *testing.T) {
 	nqn := "nqn.2000-02.com.mikrotik:pvc-test"
@@ -371,7 +440,7 @@ func TestRecover_RespectsContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options)
+	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options, "")
 
 	// Should return context error
 	if err == nil {
@@ -417,7 +486,7 @@ func TestRecover_UnmountFailureRetries(t *testing.T) {
 	options := []string{"rw"}
 
 	ctx := context.Background()
-	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options)
+	result, err := recoverer.Recover(ctx, mountPath, nqn, fsType, options, "")
 
 	// Should fail after retries
 	if err == nil {
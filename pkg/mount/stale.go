@@ -18,12 +18,14 @@ const (
 	StaleReasonMountNotFound     StaleReason = "mount_not_found"
 	StaleReasonDeviceDisappeared StaleReason = "device_disappeared"
 	StaleReasonDeviceMismatch    StaleReason = "device_path_mismatch"
+	StaleReasonNQNMismatch       StaleReason = "nqn_mismatch"
 )
 
 // StaleInfo contains detailed information about a stale mount check
 type StaleInfo struct {
 	MountDevice     string // Device path from /proc/mountinfo
 	ResolvedMount   string // Resolved symlinks for mount device
+	MountNQN        string // NQN of the subsystem backing the mount device, if known
 	CurrentDevice   string // Device path from NQN resolution
 	ResolvedCurrent string // Resolved symlinks for current device
 	IsStale         bool
@@ -55,7 +57,8 @@ func (c *StaleMountChecker) SetMountDeviceFunc(fn func(path string) (string, err
 // A mount is considered stale if:
 // 1. The mount point is not found (mount disappeared)
 // 2. The mount device no longer exists (device disappeared)
-// 3. The mount device path differs from the current NQN-resolved device (device renumbered)
+// 3. The mount device belongs to a different NQN (staging path left over from a deleted volume)
+// 4. The mount device path differs from the current NQN-resolved device (device renumbered)
 func (c *StaleMountChecker) IsMountStale(mountPath string, nqn string) (bool, StaleReason, error) {
 	klog.V(4).Infof("Checking if mount %s is stale (NQN: %s)", mountPath, nqn)
 
@@ -83,13 +86,22 @@ func (c *StaleMountChecker) IsMountStale(mountPath string, nqn string) (bool, St
 
 	klog.V(4).Infof("Resolved mount device %s -> %s", mountDevice, resolvedMount)
 
-	// Step 3: Resolve NQN to current device path
 	// If resolver is nil (test environment), skip staleness check
 	if c.resolver == nil {
 		klog.V(4).Infof("Resolver is nil, skipping staleness check for %s", mountPath)
 		return false, "", nil
 	}
 
+	// Step 3: Check the mount device belongs to the expected NQN. This catches a staging
+	// path reused after the volume was deleted and re-created, even when the new NQN is
+	// not connected and cannot be resolved below.
+	if mountNQN := c.mountDeviceNQN(resolvedMount); mountNQN != "" && mountNQN != nqn {
+		klog.Warningf("Stale mount detected: mount %s device %s belongs to NQN %s, expected %s",
+			mountPath, resolvedMount, mountNQN, nqn)
+		return true, StaleReasonNQNMismatch, nil
+	}
+
+	// Step 4: Resolve NQN to current device path
 	currentDevice, err := c.resolver.ResolveDevicePath(nqn)
 	if err != nil {
 		// Cannot resolve NQN - this is an error, not a stale condition
@@ -98,7 +110,7 @@ func (c *StaleMountChecker) IsMountStale(mountPath string, nqn string) (bool, St
 
 	klog.V(4).Infof("Current device for NQN %s: %s", nqn, currentDevice)
 
-	// Step 4: Resolve current device symlinks to canonical path
+	// Step 5: Resolve current device symlinks to canonical path
 	resolvedCurrent, err := filepath.EvalSymlinks(currentDevice)
 	if err != nil {
 		// Current device should exist since we just resolved it
@@ -108,7 +120,7 @@ func (c *StaleMountChecker) IsMountStale(mountPath string, nqn string) (bool, St
 
 	klog.V(4).Infof("Resolved current device %s -> %s", currentDevice, resolvedCurrent)
 
-	// Step 5: Compare resolved paths
+	// Step 6: Compare resolved paths
 	if resolvedMount != resolvedCurrent {
 		klog.Warningf("Stale mount detected: mount %s device %s (resolved: %s) differs from current NQN %s device %s (resolved: %s)",
 			mountPath, mountDevice, resolvedMount, nqn, currentDevice, resolvedCurrent)
@@ -155,6 +167,14 @@ func (c *StaleMountChecker) GetStaleInfo(mountPath string, nqn string) (*StaleIn
 	}
 	info.ResolvedMount = resolvedMount
 
+	// Check the mount device belongs to the expected NQN
+	info.MountNQN = c.mountDeviceNQN(resolvedMount)
+	if info.MountNQN != "" && info.MountNQN != nqn {
+		info.IsStale = true
+		info.Reason = StaleReasonNQNMismatch
+		return info, nil
+	}
+
 	currentDevice, err := c.resolver.ResolveDevicePath(nqn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve NQN: %w", err)
@@ -179,3 +199,14 @@ func (c *StaleMountChecker) GetStaleInfo(mountPath string, nqn string) (*StaleIn
 
 	return info, nil
 }
+
+// mountDeviceNQN returns the NQN of the subsystem backing device, or "" if it cannot be
+// determined (e.g. the device is not an NVMe namespace)
+func (c *StaleMountChecker) mountDeviceNQN(device string) string {
+	nqn, err := c.resolver.ResolveNQN(device)
+	if err != nil {
+		klog.V(4).Infof("Could not determine NQN of mount device %s: %v", device, err)
+		return ""
+	}
+	return nqn
+}
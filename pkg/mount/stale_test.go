@@ -239,6 +239,49 @@ func TestIsMountStale_DeviceMismatch(t *testing.T) {
 	}
 }
 
+// TestIsMountStale_NQNMismatch tests that a mount backed by another volume's device is
+// stale even when the expected NQN is not connected (PVC deleted and re-created while
+// kubelet reused the staging path)
+func TestIsMountStale_NQNMismatch(t *testing.T) {
+	oldNQN := "nqn.2000-02.com.mikrotik:pvc-old"
+	newNQN := "nqn.2000-02.com.mikrotik:pvc-new"
+	resolver := createMockResolver(t, oldNQN, "/dev/nvme1n1", false)
+
+	tmpDir := t.TempDir()
+	mountDevice := filepath.Join(tmpDir, "nvme1n1")
+	if err := os.WriteFile(mountDevice, []byte{}, 0644); err != nil {
+		t.Fatalf("Failed to create mount device file: %v", err)
+	}
+
+	checker := NewStaleMountChecker(resolver)
+	checker.getMountDev = func(path string) (string, error) {
+		return mountDevice, nil
+	}
+
+	stale, reason, err := checker.IsMountStale("/var/lib/kubelet/staging", newNQN)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !stale || reason != StaleReasonNQNMismatch {
+		t.Errorf("Expected stale with reason %s, got stale=%v reason=%s", StaleReasonNQNMismatch, stale, reason)
+	}
+
+	info, err := checker.GetStaleInfo("/var/lib/kubelet/staging", newNQN)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !info.IsStale || info.Reason != StaleReasonNQNMismatch || info.MountNQN != oldNQN {
+		t.Errorf("Expected NQN mismatch with mount NQN %s, got %+v", oldNQN, info)
+	}
+
+	// The device's own NQN is not a mismatch; the check moves on to resolving the
+	// current device, which fails here because /dev/nvme1n1 does not exist
+	stale, reason, _ = checker.IsMountStale("/var/lib/kubelet/staging", oldNQN)
+	if stale || reason == StaleReasonNQNMismatch {
+		t.Errorf("Expected no NQN mismatch for the device's own NQN, got stale=%v reason=%s", stale, reason)
+	}
+}
+
 // TestIsMountStale_ResolverError tests that resolver errors are propagated
 func TestIsMountStale_ResolverError(t *testing.T) {
 	nqn := "nqn.2000-02.com.mikrotik:pvc-nonexistent"
@@ -0,0 +1,116 @@
+package nvme
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fabricsDevice lists the connect options the kernel's nvme-fabrics module accepts when read
+const fabricsDevice = "/dev/nvme-fabrics"
+
+// dhchapSecretPattern matches a DH-HMAC-CHAP secret in the NVMe-oF representation,
+// DHHC-1:<hash>:<base64 key and CRC>: as generated by nvme gen-dhchap-key
+var dhchapSecretPattern = regexp.MustCompile(`^DHHC-1:0[0-3]:[A-Za-z0-9+/]{44,92}={0,2}:$`)
+
+// redacted replaces secrets in log and error output
+const redacted = "[REDACTED]"
+
+// ValidateDHCHAPSecret checks that secret is a DH-HMAC-CHAP secret. The error never
+// includes the secret.
+func ValidateDHCHAPSecret(secret string) error {
+	if !dhchapSecretPattern.MatchString(secret) {
+		return fmt.Errorf("not a DH-HMAC-CHAP secret (expected DHHC-1:<hash>:<key>: as generated by nvme gen-dhchap-key)")
+	}
+	return nil
+}
+
+// DHCHAPSupported returns why this node cannot authenticate NVMe/TCP connections with
+// DH-HMAC-CHAP, or nil. Both the kernel (5.19+) and nvme-cli (2.0+) need support.
+func DHCHAPSupported() error {
+	options, err := os.ReadFile(fabricsDevice)
+	if err != nil {
+		return fmt.Errorf("cannot read the connect options of the kernel from %s: %w", fabricsDevice, err)
+	}
+	if !strings.Contains(string(options), "dhchap_secret") {
+		return fmt.Errorf("the kernel does not support DH-HMAC-CHAP authentication (needs Linux 5.19 or later)")
+	}
+
+	supported, err := cliSupportsDHCHAP()
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("nvme-cli does not support DH-HMAC-CHAP authentication (needs nvme-cli 2.0 or later)")
+	}
+	return nil
+}
+
+// CLIHelpTimeout is the maximum time to wait for nvme connect --help
+const CLIHelpTimeout = 10 * time.Second
+
+// cliHelp returns the usage of nvme connect (replaced in tests)
+var cliHelp = func(ctx context.Context) []byte {
+	// nvme-cli prints its usage with a non-zero exit status on some versions
+	output, _ := exec.CommandContext(ctx, "nvme", "connect", "--help").CombinedOutput()
+	return output
+}
+
+// cliDHCHAP caches whether nvme-cli supports DH-HMAC-CHAP, which does not change while
+// the plugin runs
+var cliDHCHAP struct {
+	mu        sync.Mutex
+	probed    bool
+	supported bool
+}
+
+// cliSupportsDHCHAP reports whether nvme connect accepts --dhchap-secret. Only the first
+// probe that completes runs nvme-cli; one that times out is not cached.
+func cliSupportsDHCHAP() (bool, error) {
+	cliDHCHAP.mu.Lock()
+	defer cliDHCHAP.mu.Unlock()
+	if cliDHCHAP.probed {
+		return cliDHCHAP.supported, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CLIHelpTimeout)
+	defer cancel()
+	output := cliHelp(ctx)
+	if ctx.Err() != nil {
+		return false, fmt.Errorf("nvme connect --help timed out after %v", CLIHelpTimeout)
+	}
+	cliDHCHAP.probed = true
+	cliDHCHAP.supported = strings.Contains(string(output), "dhchap-secret")
+	return cliDHCHAP.supported, nil
+}
+
+// HasAuth reports whether connections to the target authenticate with DH-HMAC-CHAP
+func (t Target) HasAuth() bool {
+	return t.DHCHAPSecret != ""
+}
+
+// String describes the target without its secrets, so targets can be logged
+func (t Target) String() string {
+	s := fmt.Sprintf("%s://%s/%s", t.Transport, net.JoinHostPort(t.TargetAddress, strconv.Itoa(t.TargetPort)), t.NQN)
+	if t.HasAuth() {
+		s += " (dhchap)"
+	}
+	return s
+}
+
+// redact removes the target's secrets from s, e.g. nvme-cli output echoing its arguments
+func (t Target) redact(s string) string {
+	for _, secret := range []string{t.DHCHAPSecret, t.DHCHAPCtrlSecret} {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
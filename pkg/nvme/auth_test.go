@@ -0,0 +1,159 @@
+package nvme
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const (
+	testHostSecret = "DHHC-1:00:AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyAhIiMk:"
+	testCtrlSecret = "DHHC-1:01:KCkqKywtLi8wMTIzNDU2Nzg5Ojs8PT4/QEFCQ0RFRkdISUpL:"
+)
+
+func TestValidateDHCHAPSecret(t *testing.T) {
+	tests := []struct {
+		secret string
+		valid  bool
+	}{
+		{secret: testHostSecret, valid: true},
+		{secret: testCtrlSecret, valid: true},
+		{secret: "DHHC-1:04:AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyAhIiMk:", valid: false},
+		{secret: "DHHC-1:00:AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyAhIiMk", valid: false},
+		{secret: "DHHC-1:00:short:", valid: false},
+		{secret: "DHHC-1:00:AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyAhIiMk: --help", valid: false},
+		{secret: "hunter2", valid: false},
+	}
+	for _, tt := range tests {
+		err := ValidateDHCHAPSecret(tt.secret)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateDHCHAPSecret(%q) = %v, want valid=%v", tt.secret, err, tt.valid)
+		}
+		if err != nil && strings.Contains(err.Error(), tt.secret) {
+			t.Errorf("error %q contains the secret", err)
+		}
+	}
+}
+
+func TestBuildConnectArgs_DHCHAP(t *testing.T) {
+	target := Target{
+		Transport:     "tcp",
+		NQN:           "nqn.2000-02.com.mikrotik:pvc-test-123",
+		TargetAddress: "10.0.0.1",
+		TargetPort:    4420,
+		DHCHAPSecret:  testHostSecret,
+	}
+	args := strings.Join(BuildConnectArgs(target, DefaultConnectionConfig()), " ")
+	if !strings.Contains(args, "--dhchap-secret="+testHostSecret) || strings.Contains(args, "--dhchap-ctrl-secret") {
+		t.Errorf("unexpected args for host authentication: %s", args)
+	}
+
+	target.DHCHAPCtrlSecret = testCtrlSecret
+	args = strings.Join(BuildConnectArgs(target, DefaultConnectionConfig()), " ")
+	if !strings.Contains(args, "--dhchap-ctrl-secret="+testCtrlSecret) {
+		t.Errorf("expected controller secret in args: %s", args)
+	}
+
+	target.DHCHAPSecret, target.DHCHAPCtrlSecret = "", ""
+	if args := strings.Join(BuildConnectArgs(target, DefaultConnectionConfig()), " "); strings.Contains(args, "dhchap") {
+		t.Errorf("unexpected authentication args: %s", args)
+	}
+}
+
+func TestTargetString_OmitsSecrets(t *testing.T) {
+	target := Target{
+		Transport:        "tcp",
+		NQN:              "nqn.2000-02.com.mikrotik:pvc-test-123",
+		TargetAddress:    "10.0.0.1",
+		TargetPort:       4420,
+		DHCHAPSecret:     testHostSecret,
+		DHCHAPCtrlSecret: testCtrlSecret,
+	}
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		s := fmt.Sprintf(format, target)
+		if strings.Contains(s, testHostSecret) || strings.Contains(s, testCtrlSecret) {
+			t.Errorf("%s of target contains a secret: %s", format, s)
+		}
+	}
+	if s := target.String(); s != "tcp://10.0.0.1:4420/nqn.2000-02.com.mikrotik:pvc-test-123 (dhchap)" {
+		t.Errorf("String() = %q", s)
+	}
+	target.TargetAddress = "fd00::1"
+	if s := target.String(); s != "tcp://[fd00::1]:4420/nqn.2000-02.com.mikrotik:pvc-test-123 (dhchap)" {
+		t.Errorf("String() of an IPv6 target = %q", s)
+	}
+}
+
+func TestCLISupportsDHCHAP_ProbesOnce(t *testing.T) {
+	origHelp := cliHelp
+	t.Cleanup(func() {
+		cliHelp = origHelp
+		cliDHCHAP.probed = false
+	})
+	cliDHCHAP.probed = false
+
+	probes := 0
+	cliHelp = func(ctx context.Context) []byte {
+		probes++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected nvme connect --help to run with a timeout")
+		}
+		return []byte("  --dhchap-secret=<str>, -S <str>  user dhchap secret")
+	}
+	for i := 0; i < 3; i++ {
+		supported, err := cliSupportsDHCHAP()
+		if err != nil || !supported {
+			t.Fatalf("cliSupportsDHCHAP() = %v, %v, want true", supported, err)
+		}
+	}
+	if probes != 1 {
+		t.Errorf("expected nvme connect --help to run once, ran %d times", probes)
+	}
+}
+
+func TestConnectWithConfig_DHCHAP(t *testing.T) {
+	nqn := "nqn.2000-02.com.mikrotik:pvc-test-123"
+	target := Target{Transport: "tcp", NQN: nqn, TargetAddress: "10.0.0.1", TargetPort: 4420, DHCHAPSecret: testHostSecret}
+
+	newConnector := func(calls *[]string, responses ...mockExecResponse) *connector {
+		return &connector{
+			execCommand:      mockExecSequence(calls, responses...),
+			config:           DefaultConfig(),
+			metrics:          &Metrics{},
+			activeOperations: make(map[string]*operationTracker),
+			resolver:         NewDeviceResolver(),
+		}
+	}
+
+	// nvme-cli output echoing the secret is redacted from the error
+	var calls []string
+	c := newConnector(&calls,
+		mockExecResponse{stdout: "No NVMe subsystems"},
+		mockExecResponse{stdout: "Failed to connect with --dhchap-secret=" + testHostSecret + ": Permission denied", exitCode: 1})
+	_, err := c.ConnectWithConfig(t.Context(), target, DefaultConnectionConfig())
+	if err == nil {
+		t.Fatal("expected connect to fail")
+	}
+	if strings.Contains(err.Error(), testHostSecret) || !strings.Contains(err.Error(), redacted) {
+		t.Errorf("secret not redacted from error: %v", err)
+	}
+	if len(calls) != 2 || !strings.Contains(calls[1], "--dhchap-secret="+testHostSecret) {
+		t.Errorf("expected the secret passed to nvme connect, got %v", calls)
+	}
+
+	// Malformed secrets and a controller secret without a host secret never reach nvme-cli
+	for _, bad := range []Target{
+		{Transport: "tcp", NQN: nqn, TargetAddress: "10.0.0.1", TargetPort: 4420, DHCHAPSecret: "hunter2"},
+		{Transport: "tcp", NQN: nqn, TargetAddress: "10.0.0.1", TargetPort: 4420, DHCHAPCtrlSecret: testCtrlSecret},
+	} {
+		calls = nil
+		c := newConnector(&calls, mockExecResponse{stdout: "No NVMe subsystems"})
+		if _, err := c.ConnectWithConfig(t.Context(), bad, DefaultConnectionConfig()); err == nil {
+			t.Errorf("expected connect with %v to be rejected", bad)
+		}
+		if len(calls) != 0 {
+			t.Errorf("expected no commands, got %v", calls)
+		}
+	}
+}
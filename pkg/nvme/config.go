@@ -66,5 +66,13 @@ func BuildConnectArgs(target Target, config ConnectionConfig) []string {
 		args = append(args, "-q", target.HostNQN)
 	}
 
+	// Add DH-HMAC-CHAP secrets if the target authenticates
+	if target.DHCHAPSecret != "" {
+		args = append(args, "--dhchap-secret="+target.DHCHAPSecret)
+		if target.DHCHAPCtrlSecret != "" {
+			args = append(args, "--dhchap-ctrl-secret="+target.DHCHAPCtrlSecret)
+		}
+	}
+
 	return args
 }
@@ -38,6 +38,8 @@ type DeviceUsageResult struct {
 // Returns TimedOut=true if lsof didn't respond within DeviceCheckTimeout (device may be unresponsive).
 // Uses context for cancellation.
 func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult {
+	logger := klog.FromContext(ctx).WithValues("device", devicePath)
+
 	// Create timeout context
 	checkCtx, cancel := context.WithTimeout(ctx, DeviceCheckTimeout)
 	defer cancel()
@@ -48,8 +50,7 @@ func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult
 
 	// Check for timeout
 	if checkCtx.Err() == context.DeadlineExceeded {
-		klog.Warningf("Device busy check timed out for %s after %v (device may be unresponsive)",
-			devicePath, DeviceCheckTimeout)
+		logger.Info("Device busy check timed out (device may be unresponsive)", "timeout", DeviceCheckTimeout)
 		return DeviceUsageResult{
 			InUse:    false, // Treat as not busy - device unresponsive, proceed with disconnect
 			TimedOut: true,
@@ -65,7 +66,7 @@ func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult
 		}
 
 		// Other error (lsof not found, permission denied, etc.)
-		klog.Warningf("lsof command failed for %s: %v", devicePath, err)
+		logger.Info("lsof command failed", "err", err)
 		return DeviceUsageResult{
 			InUse: false, // Can't determine - proceed with caution
 			Error: fmt.Errorf("lsof failed: %w", err),
@@ -80,9 +81,7 @@ func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult
 	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
 
 	// Log raw lsof output for debugging
-	if klog.V(4).Enabled() {
-		klog.Infof("CheckDeviceInUse: lsof output for %s (%d lines):\n%s", devicePath, len(lines), string(out))
-	}
+	logger.V(4).Info("CheckDeviceInUse: lsof output", "lines", len(lines), "output", string(out))
 
 	if len(lines) <= 1 {
 		// Only header line or empty - no processes
@@ -103,15 +102,14 @@ func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult
 			// Parse PID from lsof output
 			pid, err := strconv.Atoi(fields[1])
 			if err != nil {
-				klog.Warningf("Failed to parse PID from lsof line '%s': %v", line, err)
+				logger.Info("Failed to parse PID from lsof line", "line", line, "err", err)
 				continue
 			}
 
 			// Filter out driver's own PID
 			if pid == ownPID {
 				filteredCount++
-				klog.V(4).Infof("CheckDeviceInUse: filtered out driver's own PID %d from device-in-use check (command: %s)",
-					pid, fields[0])
+				logger.V(4).Info("CheckDeviceInUse: filtered out driver's own PID from device-in-use check", "pid", pid, "command", fields[0])
 				continue
 			}
 
@@ -121,12 +119,11 @@ func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult
 	}
 
 	if filteredCount > 0 {
-		klog.V(2).Infof("CheckDeviceInUse: filtered %d driver self-reference(s) from lsof output for %s",
-			filteredCount, devicePath)
+		logger.V(2).Info("CheckDeviceInUse: filtered driver self-references from lsof output", "filtered", filteredCount)
 	}
 
 	if len(processes) > 0 {
-		klog.V(2).Infof("Device %s in use by %d external process(es): %v", devicePath, len(processes), processes)
+		logger.V(2).Info("Device in use by external processes", "processes", processes)
 		return DeviceUsageResult{
 			InUse:            true,
 			Processes:        processes,
@@ -134,7 +131,7 @@ func CheckDeviceInUse(ctx context.Context, devicePath string) DeviceUsageResult
 		}
 	}
 
-	klog.V(4).Infof("Device %s not in use (filtered %d self-references)", devicePath, filteredCount)
+	logger.V(4).Info("Device not in use", "filteredSelfReferences", filteredCount)
 	return DeviceUsageResult{
 		InUse:            false,
 		FilteredSelfPIDs: filteredCount,
@@ -157,7 +154,7 @@ func CheckDeviceInUseWithRetry(ctx context.Context, devicePath string, retries i
 		// If device is not in use, no need to retry
 		if !lastResult.InUse {
 			if attempt > 1 {
-				klog.V(2).Infof("Device %s not in use after %d attempt(s)", devicePath, attempt)
+				klog.FromContext(ctx).V(2).Info("Device not in use", "device", devicePath, "attempts", attempt)
 			}
 			return lastResult
 		}
@@ -169,8 +166,8 @@ func CheckDeviceInUseWithRetry(ctx context.Context, devicePath string, retries i
 
 		// Device reported as in use - retry if we have attempts left
 		if attempt < retries {
-			klog.V(4).Infof("Device %s reported in use (attempt %d/%d), retrying after %v to confirm. Processes: %v",
-				devicePath, attempt, retries, retryDelay, lastResult.Processes)
+			klog.FromContext(ctx).V(4).Info("Device reported in use, retrying to confirm", "device", devicePath,
+				"attempt", attempt, "maxAttempts", retries, "retryDelay", retryDelay, "processes", lastResult.Processes)
 
 			// Wait before retry (check context cancellation)
 			select {
@@ -184,7 +181,6 @@ func CheckDeviceInUseWithRetry(ctx context.Context, devicePath string, retries i
 	}
 
 	// All retries exhausted, device consistently in use
-	klog.V(2).Infof("Device %s confirmed in use after %d attempt(s). Final processes: %v",
-		devicePath, retries, lastResult.Processes)
+	klog.FromContext(ctx).V(2).Info("Device confirmed in use", "device", devicePath, "attempts", retries, "processes", lastResult.Processes)
 	return lastResult
 }
@@ -0,0 +1,32 @@
+package nvme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// TCPModuleName is the kernel module providing the NVMe/TCP transport
+const TCPModuleName = "nvme_tcp"
+
+// ModprobeTimeout is the maximum time to wait for modprobe to load the module
+const ModprobeTimeout = 30 * time.Second
+
+// LoadTCPModule loads the nvme_tcp kernel module with modprobe. This needs
+// CAP_SYS_MODULE and the host's /lib/modules mounted into the node plugin.
+func LoadTCPModule() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ModprobeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "modprobe", TCPModuleName).CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("modprobe %s timed out after %v", TCPModuleName, ModprobeTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("modprobe %s failed: %w (output: %s)", TCPModuleName, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
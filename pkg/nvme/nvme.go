@@ -80,6 +80,18 @@ type Target struct {
 
 	// HostNQN is the NQN of the host initiator (optional)
 	HostNQN string
+
+	// WWID is the expected namespace identifier, e.g. "eui.0025385b71b0a1f2" (optional).
+	// When set, the device is matched on /sys/class/block/<dev>/wwid rather than NQN alone.
+	WWID string
+
+	// DHCHAPSecret is the host's DH-HMAC-CHAP secret (optional). When set, the connection
+	// authenticates to the target. Never log it; String omits it.
+	DHCHAPSecret string
+
+	// DHCHAPCtrlSecret is the controller's DH-HMAC-CHAP secret for bidirectional
+	// authentication (optional, needs DHCHAPSecret)
+	DHCHAPCtrlSecret string
 }
 
 // Config holds configuration for NVMe operations
@@ -517,6 +529,29 @@ func (c *connector) ConnectWithConfig(ctx context.Context, target Target, config
 		}
 	}
 
+	// Register the expected namespace so all later lookups for this NQN use it
+	if target.WWID != "" {
+		if err := utils.ValidateWWID(target.WWID); err != nil {
+			return "", fmt.Errorf("invalid target WWID: %w", err)
+		}
+		c.resolver.SetExpectedWWID(target.NQN, target.WWID)
+	}
+
+	// SECURITY: Secrets are passed to nvme-cli as arguments; only accept the DHHC-1 format
+	if target.DHCHAPSecret != "" {
+		if err := ValidateDHCHAPSecret(target.DHCHAPSecret); err != nil {
+			return "", fmt.Errorf("invalid host DH-HMAC-CHAP secret: %w", err)
+		}
+	}
+	if target.DHCHAPCtrlSecret != "" {
+		if target.DHCHAPSecret == "" {
+			return "", fmt.Errorf("a controller DH-HMAC-CHAP secret needs a host secret")
+		}
+		if err := ValidateDHCHAPSecret(target.DHCHAPCtrlSecret); err != nil {
+			return "", fmt.Errorf("invalid controller DH-HMAC-CHAP secret: %w", err)
+		}
+	}
+
 	// Apply timeout from config if no deadline set
 	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
@@ -548,16 +583,16 @@ func (c *connector) ConnectWithConfig(ctx context.Context, target Target, config
 	}
 
 	if connected {
-		klog.V(2).Infof("Already connected to NQN: %s", target.NQN)
+		klog.FromContext(ctx).V(2).Info("Already connected to NVMe target", "nqn", target.NQN)
 
 		// Check for orphaned subsystem using resolver
 		orphaned, err := c.resolver.IsOrphanedSubsystem(target.NQN)
 		if err != nil {
-			klog.Warningf("Failed to check orphan status for %s: %v", target.NQN, err)
+			klog.FromContext(ctx).Info("Failed to check orphan status", "nqn", target.NQN, "err", err)
 		}
 
 		if orphaned {
-			klog.Warningf("NQN %s is orphaned, forcing disconnect and reconnect", target.NQN)
+			klog.FromContext(ctx).Info("NQN is orphaned, forcing disconnect and reconnect", "nqn", target.NQN)
 			c.resolver.Invalidate(target.NQN)
 			_ = c.DisconnectWithContext(ctx, target.NQN)
 			// Fall through to connect logic below
@@ -591,7 +626,7 @@ func (c *connector) ConnectWithConfig(ctx context.Context, target Target, config
 			c.metrics.mu.Unlock()
 			return "", fmt.Errorf("nvme connect timed out: %w", ctx.Err())
 		}
-		return "", fmt.Errorf("nvme connect failed: %w, output: %s", err, string(output))
+		return "", fmt.Errorf("nvme connect failed: %w, output: %s", err, target.redact(string(output)))
 	}
 
 	// Wait for device with context
@@ -605,7 +640,7 @@ func (c *connector) ConnectWithConfig(ctx context.Context, target Target, config
 		return "", err
 	}
 
-	klog.V(2).Infof("Successfully connected to NVMe target, device: %s", devicePath)
+	klog.FromContext(ctx).V(2).Info("Successfully connected to NVMe target", "nqn", target.NQN, "device", devicePath)
 	return devicePath, nil
 }
 
@@ -620,7 +655,7 @@ func (c *connector) ConnectWithRetry(ctx context.Context, target Target, config
 		path, connectErr := c.ConnectWithConfig(ctx, target, config)
 		if connectErr != nil {
 			lastErr = connectErr
-			klog.V(2).Infof("Connection attempt failed for NQN %s: %v (will retry if transient)", target.NQN, connectErr)
+			klog.FromContext(ctx).V(2).Info("Connection attempt failed (will retry if transient)", "nqn", target.NQN, "err", connectErr)
 			return connectErr
 		}
 		devicePath = path
@@ -648,7 +683,7 @@ func (c *connector) DisconnectWithContext(ctx context.Context, nqn string) error
 	// System volumes (e.g., nixos-*) must never be disconnected by the CSI driver
 	// TODO: Make this prefix configurable via driver flag
 	if !strings.HasPrefix(nqn, "nqn.2000-02.com.mikrotik:pvc-") {
-		klog.Warningf("Refusing to disconnect non-CSI volume: %s (expected pvc-* prefix)", nqn)
+		klog.FromContext(ctx).Info("Refusing to disconnect non-CSI volume (expected pvc-* prefix)", "nqn", nqn)
 		return fmt.Errorf("refusing to disconnect non-CSI volume: %s (only pvc-* volumes are managed by this driver)", nqn)
 	}
 
@@ -679,21 +714,11 @@ func (c *connector) DisconnectWithContext(ctx context.Context, nqn string) error
 	}
 
 	if !connected {
-		klog.V(2).Infof("Not connected to NQN: %s, nothing to disconnect", nqn)
+		klog.FromContext(ctx).V(2).Info("Not connected to NVMe target, nothing to disconnect", "nqn", nqn)
 		return nil
 	}
 
-	// Execute with context
-	// Use execCommand for test mocking if set, otherwise use exec.CommandContext
-	var cmd *exec.Cmd
-	if c.execCommand != nil {
-		// For testing: use the mock execCommand (no context support)
-		cmd = c.execCommand("nvme", "disconnect", "-n", nqn)
-	} else {
-		cmd = exec.CommandContext(ctx, "nvme", "disconnect", "-n", nqn)
-	}
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	if err := c.runDisconnect(ctx, nqn); err != nil {
 		c.metrics.mu.Lock()
 		c.metrics.disconnectErrors++
 		c.metrics.mu.Unlock()
@@ -704,7 +729,7 @@ func (c *connector) DisconnectWithContext(ctx context.Context, nqn string) error
 			c.metrics.mu.Unlock()
 			return fmt.Errorf("nvme disconnect timed out: %w", ctx.Err())
 		}
-		return fmt.Errorf("nvme disconnect failed: %w, output: %s", err, string(output))
+		return err
 	}
 
 	// Invalidate resolver cache after successful disconnect
@@ -715,10 +740,98 @@ func (c *connector) DisconnectWithContext(ctx context.Context, nqn string) error
 		c.promMetrics.RecordNVMeDisconnect()
 	}
 
-	klog.V(2).Infof("Successfully disconnected from NVMe target: %s", nqn)
+	klog.FromContext(ctx).V(2).Info("Successfully disconnected from NVMe target", "nqn", nqn)
 	return nil
 }
 
+// runDisconnect runs nvme disconnect, retrying transient failures up to
+// disconnectAttempts times. A subsystem that is already gone, e.g. after a target
+// reset removed the controller between the connection check and the disconnect,
+// counts as disconnected.
+func (c *connector) runDisconnect(ctx context.Context, nqn string) error {
+	delay := disconnectRetryDelay
+	for attempt := 1; ; attempt++ {
+		// Use execCommand for test mocking if set, otherwise use exec.CommandContext
+		var cmd *exec.Cmd
+		if c.execCommand != nil {
+			// For testing: use the mock execCommand (no context support)
+			cmd = c.execCommand("nvme", "disconnect", "-n", nqn)
+		} else {
+			cmd = exec.CommandContext(ctx, "nvme", "disconnect", "-n", nqn)
+		}
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if isAlreadyDisconnected(string(output)) {
+			klog.FromContext(ctx).V(2).Info("NVMe target already disconnected", "nqn", nqn, "output", strings.TrimSpace(string(output)))
+			return nil
+		}
+
+		err = fmt.Errorf("nvme disconnect failed: %w, output: %s", err, string(output))
+		if !isTransientDisconnectError(string(output)) || attempt == disconnectAttempts {
+			return err
+		}
+		klog.FromContext(ctx).V(2).Info("Disconnect attempt failed, retrying", "nqn", nqn, "attempt", attempt, "retryDelay", delay, "err", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+const (
+	// disconnectAttempts bounds the nvme disconnect attempts for transient failures
+	disconnectAttempts = 3
+
+	// disconnectRetryDelay is the delay before the first disconnect retry, doubled after each
+	disconnectRetryDelay = 500 * time.Millisecond
+)
+
+// alreadyDisconnectedPatterns match nvme-cli output for a subsystem or controller
+// that no longer exists. A missing file only counts for the controller's delete
+// attribute, which is gone once the controller is; other missing files are real failures.
+var alreadyDisconnectedPatterns = []string{
+	"disconnected 0 controller",
+	"no such device",
+	"delete_controller: no such file or directory",
+	"failed to lookup subsystem",
+	"no subsystem",
+}
+
+// transientDisconnectPatterns match nvme-cli output for failures worth retrying
+var transientDisconnectPatterns = []string{
+	"device or resource busy",
+	"resource temporarily unavailable",
+	"interrupted system call",
+	"try again",
+}
+
+// isAlreadyDisconnected reports whether nvme disconnect failed because there was
+// nothing left to disconnect
+func isAlreadyDisconnected(output string) bool {
+	return containsAny(strings.ToLower(output), alreadyDisconnectedPatterns)
+}
+
+// isTransientDisconnectError reports whether a failed nvme disconnect may succeed on retry
+func isTransientDisconnectError(output string) bool {
+	return containsAny(strings.ToLower(output), transientDisconnectPatterns)
+}
+
+func containsAny(s string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(s, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsConnectedWithContext checks connection status with context
 func (c *connector) IsConnectedWithContext(ctx context.Context, nqn string) (bool, error) {
 	// SECURITY: Validate NQN format
@@ -749,7 +862,7 @@ func (c *connector) IsConnectedWithContext(ctx context.Context, nqn string) (boo
 		if ctx.Err() != nil {
 			return false, nil // Timeout is not fatal for this check
 		}
-		klog.V(4).Infof("nvme list-subsys failed (may be normal): %v", err)
+		klog.FromContext(ctx).V(4).Info("nvme list-subsys failed (may be normal)", "err", err)
 		return false, nil
 	}
 
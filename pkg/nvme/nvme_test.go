@@ -134,6 +134,89 @@ func TestDisconnect(t *testing.T) {
 	}
 }
 
+// mockExecResponse is one scripted command result for mockExecSequence
+type mockExecResponse struct {
+	stdout   string
+	exitCode int
+}
+
+// mockExecSequence returns the responses in order, one per command, repeating the last.
+// The commands run are appended to calls.
+func mockExecSequence(calls *[]string, responses ...mockExecResponse) func(string, ...string) *exec.Cmd {
+	return func(command string, args ...string) *exec.Cmd {
+		*calls = append(*calls, strings.Join(append([]string{command}, args...), " "))
+		r := responses[len(responses)-1]
+		if len(*calls) <= len(responses) {
+			r = responses[len(*calls)-1]
+		}
+		return mockExecCommand(r.stdout, "", r.exitCode)(command, args...)
+	}
+}
+
+func TestDisconnectIdempotentAndRetry(t *testing.T) {
+	nqn := "nqn.2000-02.com.mikrotik:pvc-test-123"
+	listed := mockExecResponse{stdout: `{"Subsystems":[{"NQN":"` + nqn + `"}]}`}
+
+	tests := []struct {
+		name          string
+		responses     []mockExecResponse
+		expectError   bool
+		expectCommand int // Commands run, including the list-subsys check
+	}{
+		{
+			name: "already disconnected by target reset",
+			responses: []mockExecResponse{listed,
+				{stdout: "Failed to disconnect by NQN: No such device", exitCode: 1}},
+			expectCommand: 2,
+		},
+		{
+			name: "transient failure then success",
+			responses: []mockExecResponse{listed,
+				{stdout: "Failed to write to delete_controller: Device or resource busy", exitCode: 1},
+				{stdout: "NQN:" + nqn + " disconnected 1 controller(s)"}},
+			expectCommand: 3,
+		},
+		{
+			name: "transient failure exhausts retries",
+			responses: []mockExecResponse{listed,
+				{stdout: "Device or resource busy", exitCode: 1}},
+			expectError:   true,
+			expectCommand: 1 + disconnectAttempts,
+		},
+		{
+			name: "genuine failure is not retried",
+			responses: []mockExecResponse{listed,
+				{stdout: "Failed to disconnect an existing connection: Permission denied", exitCode: 1}},
+			expectError:   true,
+			expectCommand: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			c := &connector{
+				execCommand:      mockExecSequence(&calls, tt.responses...),
+				config:           DefaultConfig(),
+				metrics:          &Metrics{},
+				activeOperations: make(map[string]*operationTracker),
+				resolver:         NewDeviceResolver(),
+			}
+
+			err := c.Disconnect(nqn)
+			if tt.expectError != (err != nil) {
+				t.Errorf("Disconnect() error = %v, expectError %v", err, tt.expectError)
+			}
+			if len(calls) != tt.expectCommand {
+				t.Errorf("expected %d commands, got %d: %v", tt.expectCommand, len(calls), calls)
+			}
+			if errors := c.metrics.disconnectErrors; (errors != 0) != tt.expectError {
+				t.Errorf("disconnect error count = %d", errors)
+			}
+		})
+	}
+}
+
 func TestIsConnected(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -479,3 +562,21 @@ func TestConnectWrapper(t *testing.T) {
 func TestLegacyFunctionsDocumented(t *testing.T) {
 	t.Skip("Legacy functions require specific nvme-cli versions or hardware for testing")
 }
+
+func TestIsAlreadyDisconnected(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"NQN:nqn.2000-02.com.mikrotik:pvc-1 disconnected 0 controller(s)", true},
+		{"Failed to open /sys/class/nvme/nvme3/delete_controller: No such file or directory", true},
+		{"failed to lookup subsystem for controller nvme3", true},
+		{"Failed to open /dev/nvme-fabrics: No such file or directory", false},
+		{"Failed to write to /sys/class/nvme/nvme3/delete_controller: Device or resource busy", false},
+	}
+	for _, tt := range tests {
+		if got := isAlreadyDisconnected(tt.output); got != tt.want {
+			t.Errorf("isAlreadyDisconnected(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
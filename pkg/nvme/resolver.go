@@ -3,6 +3,7 @@ package nvme
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -27,6 +28,7 @@ type DeviceResolver struct {
 	mu            sync.RWMutex
 	ttl           time.Duration
 	isConnectedFn func(nqn string) (bool, error) // Injected for testing and connector integration
+	wwids         map[string]string              // Expected namespace WWID per NQN, protected by mu
 }
 
 // ResolverConfig holds resolver configuration
@@ -62,6 +64,7 @@ func NewDeviceResolverWithConfig(cfg ResolverConfig) *DeviceResolver {
 		scanner: NewSysfsScannerWithRoot(cfg.SysfsRoot),
 		cache:   make(map[string]*cacheEntry),
 		ttl:     cfg.TTL,
+		wwids:   make(map[string]string),
 	}
 }
 
@@ -87,8 +90,15 @@ func (r *DeviceResolver) ResolveDevicePath(nqn string) (string, error) {
 		klog.V(4).Infof("DeviceResolver: cache miss for NQN %s, scanning sysfs", nqn)
 	}
 
-	// Scan sysfs for matching NQN
-	devicePath, err := r.scanner.FindDeviceByNQN(nqn)
+	// Scan sysfs for matching NQN, narrowed to the expected namespace if known
+	wwid := r.ExpectedWWID(nqn)
+	var devicePath string
+	var err error
+	if wwid != "" {
+		devicePath, err = r.scanner.FindDeviceByNQNAndWWID(nqn, wwid)
+	} else {
+		devicePath, err = r.scanner.FindDeviceByNQN(nqn)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -105,6 +115,39 @@ func (r *DeviceResolver) ResolveDevicePath(nqn string) (string, error) {
 	return devicePath, nil
 }
 
+// ResolveNQN returns the NQN of the subsystem backing devicePath (e.g. /dev/nvme1n1).
+// Results are not cached: callers use this to verify a device they already hold.
+func (r *DeviceResolver) ResolveNQN(devicePath string) (string, error) {
+	return r.scanner.FindNQNByDevice(filepath.Base(devicePath))
+}
+
+// SetExpectedWWID records the namespace WWID expected for an NQN, so that later
+// resolutions match /sys/class/block/<dev>/wwid instead of taking the first
+// namespace of the subsystem. An empty wwid reverts to NQN-only resolution.
+func (r *DeviceResolver) SetExpectedWWID(nqn, wwid string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.wwids[nqn] == wwid {
+		return
+	}
+	if wwid == "" {
+		delete(r.wwids, nqn)
+	} else {
+		r.wwids[nqn] = wwid
+	}
+	// A cached path may have been resolved without (or with another) WWID
+	delete(r.cache, nqn)
+	klog.V(4).Infof("DeviceResolver: expected WWID for NQN %s set to %q", nqn, wwid)
+}
+
+// ExpectedWWID returns the WWID registered for an NQN, or "" if none
+func (r *DeviceResolver) ExpectedWWID(nqn string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.wwids[nqn]
+}
+
 // Invalidate removes an NQN from the cache (call on disconnect)
 func (r *DeviceResolver) Invalidate(nqn string) {
 	r.mu.Lock()
@@ -689,3 +689,115 @@ func TestDeviceResolver_MultipleNQNs(t *testing.T) {
 		t.Error("Expected pvc-2 to still be cached")
 	}
 }
+
+// TestResolveDevicePath_WWID tests that an expected WWID selects the matching
+// namespace of a multi-namespace subsystem, falling back to NQN when no wwid is readable
+func TestResolveDevicePath_WWID(t *testing.T) {
+	const nqn = "nqn.2000-02.com.mikrotik:pvc-test-123"
+	multiNamespace := mockController{
+		name:         "nvme0",
+		nqn:          nqn,
+		blockDevices: []string{"nvme0n1", "nvme0n2"},
+		wwids: map[string]string{
+			"nvme0n1": "eui.0000000000000001",
+			"nvme0n2": "eui.0000000000000002",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		controller mockController
+		wwid       string
+		wantPath   string
+		wantErr    bool
+	}{
+		{
+			name:       "no WWID takes first namespace",
+			controller: multiNamespace,
+			wantPath:   "/dev/nvme0n1",
+		},
+		{
+			name:       "WWID selects second namespace",
+			controller: multiNamespace,
+			wwid:       "eui.0000000000000002",
+			wantPath:   "/dev/nvme0n2",
+		},
+		{
+			name:       "WWID match is case-insensitive",
+			controller: multiNamespace,
+			wwid:       "EUI.0000000000000002",
+			wantPath:   "/dev/nvme0n2",
+		},
+		{
+			name:       "WWID not present on subsystem",
+			controller: multiNamespace,
+			wwid:       "eui.00000000000000ff",
+			wantErr:    true,
+		},
+		{
+			name: "no wwid files falls back to NQN",
+			controller: mockController{
+				name:         "nvme0",
+				nqn:          nqn,
+				blockDevices: []string{"nvme0n1"},
+			},
+			wwid:     "eui.0000000000000002",
+			wantPath: "/dev/nvme0n1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := createMockSysfsForResolver(t, []mockController{tt.controller})
+			resolver := NewDeviceResolverWithConfig(ResolverConfig{SysfsRoot: tmpDir})
+			resolver.SetExpectedWWID(nqn, tt.wwid)
+
+			devicePath, err := resolver.ResolveDevicePath(nqn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error, got device %s", devicePath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if devicePath != tt.wantPath {
+				t.Errorf("Expected device path %s, got %s", tt.wantPath, devicePath)
+			}
+		})
+	}
+}
+
+// TestSetExpectedWWID_InvalidatesCache tests that changing the expected WWID
+// forces a rescan instead of returning a path resolved for another namespace
+func TestSetExpectedWWID_InvalidatesCache(t *testing.T) {
+	const nqn = "nqn.2000-02.com.mikrotik:pvc-test-123"
+	tmpDir := createMockSysfsForResolver(t, []mockController{
+		{
+			name:         "nvme0",
+			nqn:          nqn,
+			blockDevices: []string{"nvme0n1", "nvme0n2"},
+			wwids: map[string]string{
+				"nvme0n1": "eui.0000000000000001",
+				"nvme0n2": "eui.0000000000000002",
+			},
+		},
+	})
+	resolver := NewDeviceResolverWithConfig(ResolverConfig{SysfsRoot: tmpDir})
+
+	if _, err := resolver.ResolveDevicePath(nqn); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resolver.IsCached(nqn) {
+		t.Fatal("Expected NQN to be cached")
+	}
+
+	resolver.SetExpectedWWID(nqn, "eui.0000000000000002")
+	if resolver.IsCached(nqn) {
+		t.Error("Expected cache entry to be dropped when WWID changes")
+	}
+	if got := resolver.ExpectedWWID(nqn); got != "eui.0000000000000002" {
+		t.Errorf("Expected WWID to be recorded, got %q", got)
+	}
+}
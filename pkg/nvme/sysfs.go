@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"k8s.io/klog/v2"
@@ -192,3 +193,178 @@ func (s *SysfsScanner) FindDeviceByNQN(nqn string) (string, error) {
 
 	return "", fmt.Errorf("no device found for NQN: %s", nqn)
 }
+
+// FindNQNByDevice returns the NQN of the subsystem that exposes block device deviceName
+// (e.g. "nvme1n1"). This is the reverse of FindDeviceByNQN, used to tell which volume
+// a mount is backed by even when the expected NQN is not connected.
+func (s *SysfsScanner) FindNQNByDevice(deviceName string) (string, error) {
+	// /sys/class/block/<dev>/device links to the controller, or to the subsystem for
+	// native multipath heads; both have a subsysnqn attribute
+	if nqn, err := s.ReadSubsysNQN(filepath.Join(s.Root, "class", "block", deviceName, "device")); err == nil {
+		return nqn, nil
+	}
+
+	controllers, err := s.ScanControllers()
+	if err != nil {
+		return "", err
+	}
+	for _, controller := range controllers {
+		for _, name := range s.namespaceDeviceNames(controller) {
+			if name != deviceName {
+				continue
+			}
+			nqn, err := s.ReadSubsysNQN(controller)
+			if err != nil {
+				return "", fmt.Errorf("found controller for device %s but could not read its NQN: %w", deviceName, err)
+			}
+			klog.V(4).Infof("FindNQNByDevice: resolved device %s -> NQN %s", deviceName, nqn)
+			return nqn, nil
+		}
+	}
+
+	return "", fmt.Errorf("no NVMe controller found for device: %s", deviceName)
+}
+
+// ReadWWID reads the namespace identifier from /sys/class/block/<device>/wwid
+func (s *SysfsScanner) ReadWWID(deviceName string) (string, error) {
+	wwidPath := filepath.Join(s.Root, "class", "block", deviceName, "wwid")
+	data, err := os.ReadFile(wwidPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read wwid from %s: %w", wwidPath, err)
+	}
+
+	wwid := strings.TrimSpace(string(data))
+	if wwid == "" {
+		return "", fmt.Errorf("empty wwid at %s", wwidPath)
+	}
+	return wwid, nil
+}
+
+// ReadInflight returns the I/O requests in flight on a block device, reads and writes,
+// from /sys/class/block/<device>/inflight
+func (s *SysfsScanner) ReadInflight(deviceName string) (int64, error) {
+	inflightPath := filepath.Join(s.Root, "class", "block", deviceName, "inflight")
+	data, err := os.ReadFile(inflightPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read in-flight I/O from %s: %w", inflightPath, err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("unexpected content in %s: %q", inflightPath, strings.TrimSpace(string(data)))
+	}
+	var total int64
+	for _, field := range fields {
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected content in %s: %q", inflightPath, strings.TrimSpace(string(data)))
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// namespaceDeviceNames lists the block device names for a controller's namespaces,
+// subsystem-based names (nvmeXnY) before controller-based ones (nvmeXcYnZ)
+func (s *SysfsScanner) namespaceDeviceNames(controllerPath string) []string {
+	controllerName := filepath.Base(controllerPath)
+	seen := make(map[string]bool)
+	var preferred, fallback []string
+
+	add := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		if strings.Contains(strings.TrimPrefix(name, "nvme"), "c") {
+			fallback = append(fallback, name)
+		} else {
+			preferred = append(preferred, name)
+		}
+	}
+
+	namespaces, _ := filepath.Glob(filepath.Join(controllerPath, "nvme*n*"))
+	for _, ns := range namespaces {
+		nsName := filepath.Base(ns)
+		var subsys, ctrl, namespace int
+		if _, err := fmt.Sscanf(nsName, "nvme%dc%dn%d", &subsys, &ctrl, &namespace); err == nil {
+			add(fmt.Sprintf("nvme%dn%d", subsys, namespace))
+		}
+		add(nsName)
+	}
+
+	blockDevices, _ := filepath.Glob(filepath.Join(s.Root, "class", "block", controllerName+"n*"))
+	for _, blockDev := range blockDevices {
+		add(filepath.Base(blockDev))
+	}
+
+	return append(preferred, fallback...)
+}
+
+// FindDeviceByNQNAndWWID finds the namespace of subsystem nqn whose wwid matches.
+// This is unambiguous when a subsystem exposes several namespaces. Falls back to
+// FindDeviceByNQN only if no candidate has a readable wwid (e.g. older kernels);
+// if wwids are readable but none match, an error is returned rather than guessing.
+func (s *SysfsScanner) FindDeviceByNQNAndWWID(nqn, wwid string) (string, error) {
+	controllers, err := s.ScanControllers()
+	if err != nil {
+		return "", err
+	}
+
+	sawWWID := false
+	var seenWWIDs []string
+	for _, controller := range controllers {
+		controllerNQN, err := s.ReadSubsysNQN(controller)
+		if err != nil || controllerNQN != nqn {
+			continue
+		}
+
+		for _, name := range s.namespaceDeviceNames(controller) {
+			devWWID, err := s.ReadWWID(name)
+			if err != nil {
+				klog.V(5).Infof("FindDeviceByNQNAndWWID: %v", err)
+				continue
+			}
+			sawWWID = true
+			if strings.EqualFold(devWWID, wwid) {
+				devicePath := "/dev/" + name
+				klog.V(4).Infof("FindDeviceByNQNAndWWID: resolved NQN %s WWID %s -> %s", nqn, wwid, devicePath)
+				return devicePath, nil
+			}
+			seenWWIDs = append(seenWWIDs, devWWID)
+		}
+	}
+
+	if sawWWID {
+		return "", fmt.Errorf("no device found for NQN %s with WWID %s (found %v)", nqn, wwid, seenWWIDs)
+	}
+
+	klog.V(4).Infof("FindDeviceByNQNAndWWID: no wwid available for NQN %s, falling back to NQN match", nqn)
+	return s.FindDeviceByNQN(nqn)
+}
+
+// CheckTCPTransport verifies the node can make NVMe/TCP connections: the nvme_tcp
+// kernel module is loaded (or built in) and the nvme-subsystem class is registered.
+// Returns an error describing what is missing.
+func (s *SysfsScanner) CheckTCPTransport() error {
+	if err := s.CheckTCPModule(); err != nil {
+		return err
+	}
+
+	subsysPath := filepath.Join(s.Root, "class", "nvme-subsystem")
+	if _, err := os.Stat(subsysPath); err != nil {
+		return fmt.Errorf("NVMe subsystem path %s not found", subsysPath)
+	}
+
+	return nil
+}
+
+// CheckTCPModule verifies the nvme_tcp kernel module is loaded or built in.
+// Without it every NVMe/TCP connect fails.
+func (s *SysfsScanner) CheckTCPModule() error {
+	modulePath := filepath.Join(s.Root, "module", TCPModuleName)
+	if _, err := os.Stat(modulePath); err != nil {
+		return fmt.Errorf("%s kernel module not loaded (%s not found)", TCPModuleName, modulePath)
+	}
+	return nil
+}
@@ -3,15 +3,17 @@ package nvme
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
 // mockController represents a mock NVMe controller for testing
 type mockController struct {
-	name         string   // e.g., "nvme0"
-	nqn          string   // NQN value
-	namespaces   []string // e.g., ["nvme0n1", "nvme0c1n1"]
-	blockDevices []string // e.g., ["nvme0n1"]
+	name         string            // e.g., "nvme0"
+	nqn          string            // NQN value
+	namespaces   []string          // e.g., ["nvme0n1", "nvme0c1n1"]
+	blockDevices []string          // e.g., ["nvme0n1"]
+	wwids        map[string]string // block device -> wwid file contents, e.g. {"nvme0n1": "eui.00..."}
 }
 
 // createMockSysfs creates a mock sysfs structure in a temp directory
@@ -50,6 +52,17 @@ func createMockSysfs(t *testing.T, controllers []mockController) string {
 				t.Fatalf("Failed to create block device dir: %v", err)
 			}
 		}
+
+		// Write wwid files for block devices
+		for bd, wwid := range ctrl.wwids {
+			bdDir := filepath.Join(tmpDir, "class", "block", bd)
+			if err := os.MkdirAll(bdDir, 0755); err != nil {
+				t.Fatalf("Failed to create block device dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(bdDir, "wwid"), []byte(wwid+"\n"), 0644); err != nil {
+				t.Fatalf("Failed to write wwid: %v", err)
+			}
+		}
 	}
 
 	return tmpDir
@@ -483,7 +496,117 @@ func TestSysfsScanner_FindDeviceByNQN(t *testing.T) {
 	})
 }
 
+// TestSysfsScanner_FindNQNByDevice tests looking up the NQN that backs a block device
+func TestSysfsScanner_FindNQNByDevice(t *testing.T) {
+	tmpDir := createMockSysfs(t, []mockController{
+		{
+			name:         "nvme0",
+			nqn:          "nqn.2000-02.com.mikrotik:pvc-old",
+			blockDevices: []string{"nvme0n1"},
+		},
+		{
+			name:       "nvme1",
+			nqn:        "nqn.2000-02.com.mikrotik:pvc-new",
+			namespaces: []string{"nvme1c1n1"},
+		},
+	})
+	scanner := NewSysfsScannerWithRoot(tmpDir)
+
+	tests := []struct {
+		device  string
+		wantNQN string
+		wantErr bool
+	}{
+		{device: "nvme0n1", wantNQN: "nqn.2000-02.com.mikrotik:pvc-old"},
+		{device: "nvme1c1n1", wantNQN: "nqn.2000-02.com.mikrotik:pvc-new"},
+		{device: "nvme1n1", wantNQN: "nqn.2000-02.com.mikrotik:pvc-new"},
+		{device: "nvme7n1", wantErr: true},
+		{device: "sda", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.device, func(t *testing.T) {
+			nqn, err := scanner.FindNQNByDevice(tt.device)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got NQN %s", nqn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if nqn != tt.wantNQN {
+				t.Errorf("Expected NQN %s, got %s", tt.wantNQN, nqn)
+			}
+		})
+	}
+
+	t.Run("device link to controller", func(t *testing.T) {
+		// On a real system /sys/class/block/<dev>/device links to the controller
+		ctrlDir := filepath.Join(tmpDir, "class", "nvme", "nvme0")
+		link := filepath.Join(tmpDir, "class", "block", "nvme5n1", "device")
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			t.Fatalf("Failed to create block device dir: %v", err)
+		}
+		if err := os.Symlink(ctrlDir, link); err != nil {
+			t.Fatalf("Failed to create device link: %v", err)
+		}
+		nqn, err := scanner.FindNQNByDevice("nvme5n1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if nqn != "nqn.2000-02.com.mikrotik:pvc-old" {
+			t.Errorf("Expected NQN from device link, got %s", nqn)
+		}
+	})
+}
+
 // TestSysfsScanner_NewSysfsScanner tests constructor functions
+func TestSysfsScanner_CheckTCPTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		dirs      []string
+		expectErr string
+	}{
+		{
+			name: "module loaded and subsystem class present",
+			dirs: []string{"module/nvme_tcp", "class/nvme-subsystem"},
+		},
+		{
+			name:      "module not loaded",
+			dirs:      []string{"class/nvme-subsystem"},
+			expectErr: "nvme_tcp kernel module not loaded",
+		},
+		{
+			name:      "subsystem class missing",
+			dirs:      []string{"module/nvme_tcp"},
+			expectErr: "NVMe subsystem path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, dir := range tt.dirs {
+				if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+					t.Fatalf("Failed to create %s: %v", dir, err)
+				}
+			}
+
+			err := NewSysfsScannerWithRoot(root).CheckTCPTransport()
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
 func TestSysfsScanner_NewSysfsScanner(t *testing.T) {
 	t.Run("default scanner", func(t *testing.T) {
 		scanner := NewSysfsScanner()
@@ -506,3 +629,36 @@ func TestSysfsScanner_NewSysfsScanner(t *testing.T) {
 		}
 	})
 }
+
+func TestSysfsScanner_ReadInflight(t *testing.T) {
+	tmpDir := t.TempDir()
+	blockDir := filepath.Join(tmpDir, "class", "block", "nvme0n1")
+	if err := os.MkdirAll(blockDir, 0755); err != nil {
+		t.Fatalf("Failed to create block dir: %v", err)
+	}
+	scanner := NewSysfsScannerWithRoot(tmpDir)
+
+	tests := []struct {
+		content string
+		want    int64
+		wantErr bool
+	}{
+		{content: "       0        0\n", want: 0},
+		{content: "       3       12\n", want: 15},
+		{content: "garbage\n", wantErr: true},
+		{content: "1 x\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(blockDir, "inflight"), []byte(tt.content), 0644); err != nil {
+			t.Fatalf("Failed to write inflight: %v", err)
+		}
+		got, err := scanner.ReadInflight("nvme0n1")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ReadInflight(%q) = %d, %v, want %d (error %v)", tt.content, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if _, err := scanner.ReadInflight("nvme9n9"); err == nil {
+		t.Error("expected an error for a missing device")
+	}
+}
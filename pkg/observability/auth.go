@@ -0,0 +1,45 @@
+package observability
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// BearerTokenHandler wraps next so that requests must carry
+// "Authorization: Bearer <token>". An empty token disables authentication
+// and returns next unchanged.
+func BearerTokenHandler(next http.Handler, token string) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		// Constant-time compare avoids leaking the token through response timing
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LoadBearerToken reads a bearer token from a file (typically a mounted Secret).
+// Surrounding whitespace is trimmed; an empty file is rejected so a missing
+// Secret key cannot silently disable authentication.
+func LoadBearerToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bearer token from %s: %w", path, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("bearer token file %s is empty", path)
+	}
+	return token, nil
+}
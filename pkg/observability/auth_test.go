@@ -0,0 +1,87 @@
+package observability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBearerTokenHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := BearerTokenHandler(next, "s3cret")
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+	}{
+		{name: "missing header", authHeader: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", authHeader: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", authHeader: "Basic s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "token prefix only", authHeader: "Bearer s3cre", wantStatus: http.StatusUnauthorized},
+		{name: "correct token", authHeader: "Bearer s3cret", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header on rejection")
+			}
+		})
+	}
+}
+
+func TestBearerTokenHandler_EmptyTokenDisablesAuth(t *testing.T) {
+	m := NewMetrics()
+	handler := BearerTokenHandler(m.Handler(), "")
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 without auth configured, got %d", rec.Code)
+	}
+}
+
+func TestLoadBearerToken(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "token")
+	if err := os.WriteFile(valid, []byte("  s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := LoadBearerToken(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "s3cret" {
+		t.Errorf("expected trimmed token %q, got %q", "s3cret", token)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBearerToken(empty); err == nil {
+		t.Error("expected error for empty token file")
+	}
+
+	if _, err := LoadBearerToken(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing token file")
+	}
+}
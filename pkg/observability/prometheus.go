@@ -2,12 +2,15 @@
 package observability
 
 import (
+	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 const (
@@ -50,9 +53,19 @@ type HardwareHealthSnapshot struct {
 type Metrics struct {
 	registry *prometheus.Registry
 
+	// Collectors of optional features by feature, registered by the Set* and Enable*
+	// methods and unregistered by the functions they return
+	optionalMu sync.Mutex
+	optional   map[string]*optionalFeature
+
 	// Volume operation metrics
-	volumeOpsTotal    *prometheus.CounterVec
-	volumeOpsDuration *prometheus.HistogramVec
+	volumeOpsTotal     *prometheus.CounterVec
+	volumeOpsDuration  *prometheus.HistogramVec
+	stagePhaseDuration *prometheus.HistogramVec
+
+	// Volume readiness metrics
+	volumeTimeToReady   *prometheus.HistogramVec
+	volumeStageDuration *prometheus.HistogramVec
 
 	// NVMe connection metrics
 	nvmeConnectsTotal   *prometheus.CounterVec
@@ -60,26 +73,52 @@ type Metrics struct {
 	attachmentCountFunc func() int // Callback for active NVMe connections (GaugeFunc)
 
 	// Mount operation metrics
-	mountOpsTotal *prometheus.CounterVec
+	mountOpsTotal           *prometheus.CounterVec
+	unmountBusyRetriesTotal prometheus.Counter
 
 	// Stale mount metrics
 	staleMountsDetectedTotal prometheus.Counter
 	staleRecoveriesTotal     *prometheus.CounterVec
 
+	// Filesystem error metrics
+	filesystemErrorsDetectedTotal *prometheus.CounterVec
+
+	// Volume read probe metrics
+	readProbeFailuresTotal prometheus.Counter
+
+	// NVMe connect rate limit metrics
+	nvmeConnectRateLimitedTotal prometheus.Counter
+
+	// Slow operation metrics
+	slowOperationsTotal *prometheus.CounterVec
+
 	// Orphan cleanup metrics
-	orphansCleanedTotal prometheus.Counter
+	orphansCleanedTotal     prometheus.Counter
+	stagingDirsRemovedTotal prometheus.Counter
 
 	// Kubernetes events metrics
-	eventsPostedTotal *prometheus.CounterVec
+	eventsPostedTotal     *prometheus.CounterVec
+	eventsSuppressedTotal *prometheus.CounterVec
+
+	// Volume expansion metrics
+	volumeExpansionsTotal *prometheus.CounterVec
 
 	// Attachment operation metrics
 	attachmentAttachTotal     *prometheus.CounterVec
 	attachmentDetachTotal     *prometheus.CounterVec
-	attachmentConflictsTotal  prometheus.Counter
+	attachmentConflictsTotal  *prometheus.CounterVec
 	attachmentReconcileTotal  *prometheus.CounterVec
 	attachmentOpDuration      *prometheus.HistogramVec
 	attachmentGracePeriodUsed prometheus.Counter
 	attachmentStaleCleared    prometheus.Counter
+	attachmentDetachStamps    prometheus.Gauge
+	annotationQueueDepth      prometheus.Gauge
+	annotationWritesDropped   *prometheus.CounterVec
+
+	// Metadata size metrics: the largest size recorded per object, guarded by metadataSizeMu
+	metadataSizeMaxBytes *prometheus.GaugeVec
+	metadataSizeMu       sync.Mutex
+	metadataSizeMax      map[string]int
 
 	// Migration operation metrics
 	migrationsTotal   *prometheus.CounterVec
@@ -90,10 +129,29 @@ type Metrics struct {
 	rdsConnectionState   *prometheus.GaugeVec
 	rdsReconnectTotal    *prometheus.CounterVec
 	rdsReconnectDuration prometheus.Histogram
+	rdsRouterOSInfo      *prometheus.GaugeVec
+
+	// RDS command metrics
+	rdsCommandErrorsTotal  *prometheus.CounterVec
+	rdsCommandRetriesTotal *prometheus.CounterVec
+	rdsRetriesRejected     *prometheus.CounterVec
+	rdsCommandQueueDepth   *prometheus.GaugeVec
+
+	// CSI socket watchdog metrics
+	socketRecreationsTotal prometheus.Counter
+	registrationHealthy    prometheus.Gauge
+
+	// Node NVMe/TCP transport metrics
+	nodeNVMeTCPAvailable prometheus.Gauge
+
+	// Storage pool capacity metrics (controller capacity monitor)
+	poolAvailableBytes *prometheus.GaugeVec
+	poolTotalBytes     *prometheus.GaugeVec
 
 	// RDS monitoring callbacks (SSH + SNMP)
 	rdsDiskMetricsFunc     func() (*DiskHealthSnapshot, error)     // Callback for RDS disk performance metrics (SSH)
 	rdsHardwareMetricsFunc func() (*HardwareHealthSnapshot, error) // Callback for RDS hardware health metrics (SNMP)
+	lastDiskSnapshot       atomic.Pointer[DiskHealthSnapshot]      // Disk snapshot fetched by the last scrape
 }
 
 // NewMetrics creates a new Metrics instance with all metrics registered.
@@ -103,6 +161,7 @@ func NewMetrics() *Metrics {
 
 	m := &Metrics{
 		registry: reg,
+		optional: make(map[string]*optionalFeature),
 
 		volumeOpsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -123,6 +182,36 @@ func NewMetrics() *Metrics {
 			[]string{"operation"},
 		),
 
+		stagePhaseDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "stage_phase_duration_seconds",
+				Help:      "Duration of the phases of node volume operations (connect, device_wait, format, mount) in seconds",
+				Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+			},
+			[]string{"phase"},
+		),
+
+		volumeTimeToReady: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "volume_time_to_ready_seconds",
+				Help:      "Time from ControllerPublishVolume completing to NodeStageVolume completing in seconds, by attach kind (new, reattach, block); spans the controller and node clocks",
+				Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+			},
+			[]string{"attach"},
+		),
+
+		volumeStageDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "volume_stage_duration_seconds",
+				Help:      "Duration of successful NodeStageVolume calls in seconds, by attach kind (new, reattach, block)",
+				Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+			},
+			[]string{"attach"},
+		),
+
 		nvmeConnectsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -148,6 +237,12 @@ func NewMetrics() *Metrics {
 			[]string{"operation", "status"},
 		),
 
+		unmountBusyRetriesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "unmount_busy_retries_total",
+			Help:      "Total number of unmounts retried because the target was busy",
+		}),
+
 		staleMountsDetectedTotal: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Name:      "stale_mounts_detected_total",
@@ -169,6 +264,12 @@ func NewMetrics() *Metrics {
 			Help:      "Total number of orphaned NVMe connections cleaned up",
 		}),
 
+		stagingDirsRemovedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "staging_dirs_removed_total",
+			Help:      "Total number of orphaned kubelet staging directories removed by the staging janitor",
+		}),
+
 		eventsPostedTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -178,6 +279,54 @@ func NewMetrics() *Metrics {
 			[]string{"reason"},
 		),
 
+		eventsSuppressedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "events_suppressed_total",
+				Help:      "Total number of Kubernetes events dropped by the per-volume rate limiter, by reason",
+			},
+			[]string{"reason"},
+		),
+
+		filesystemErrorsDetectedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "filesystem_errors_detected_total",
+				Help:      "Total number of volume stats checks that found filesystem errors, by reason (read_only_remount, ext4_errors)",
+			},
+			[]string{"reason"},
+		),
+
+		readProbeFailuresTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "volume_read_probe_failures_total",
+			Help:      "Total number of failed reads of the first 4KiB of a staged volume's device",
+		}),
+
+		nvmeConnectRateLimitedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "nvme_connect_rate_limited_total",
+			Help:      "Total number of NVMe connects refused because no connect token was available within the stage deadline",
+		}),
+
+		slowOperationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "slow_operations_total",
+				Help:      "Total number of CSI calls still running after their soft deadline, by method",
+			},
+			[]string{"method"},
+		),
+
+		volumeExpansionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "volume_expansions_total",
+				Help:      "Total number of volume expansions by phase (pending_node_resize when an attached volume awaits node confirmation, completed when the new size is visible)",
+			},
+			[]string{"phase"},
+		),
+
 		attachmentAttachTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -198,12 +347,15 @@ func NewMetrics() *Metrics {
 			[]string{"status"},
 		),
 
-		attachmentConflictsTotal: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: "attachment",
-			Name:      "conflicts_total",
-			Help:      "Total attachment conflicts (RWO violations)",
-		}),
+		attachmentConflictsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "attachment",
+				Name:      "conflicts_total",
+				Help:      "Total attachment conflicts (RWO violations) by whether the workload holding the volume was identified",
+			},
+			[]string{"holder"},
+		),
 
 		attachmentReconcileTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -240,6 +392,40 @@ func NewMetrics() *Metrics {
 			Help:      "Total stale attachments cleared by reconciler",
 		}),
 
+		attachmentDetachStamps: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "attachment",
+			Name:      "detach_timestamps",
+			Help:      "Number of detach timestamps tracked for grace periods, as of the last reconciliation",
+		}),
+
+		annotationQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "attachment",
+			Name:      "annotation_queue_depth",
+			Help:      "Number of volumes with a PV annotation write queued for background retry",
+		}),
+
+		annotationWritesDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "attachment",
+				Name:      "annotation_writes_dropped_total",
+				Help:      "Total PV annotation writes given up on, by reason",
+			},
+			[]string{"reason"}, // retries_exhausted, queue_full
+		),
+
+		metadataSizeMaxBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "metadata_size_max_bytes",
+				Help:      "Largest size in bytes of metadata the driver stored on Kubernetes objects, by object",
+			},
+			[]string{"object"}, // volume_context, pv_annotations
+		),
+		metadataSizeMax: make(map[string]int),
+
 		migrationsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -292,19 +478,116 @@ func NewMetrics() *Metrics {
 			Help:      "Duration of successful RDS reconnections in seconds",
 			Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
 		}),
+
+		rdsCommandErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "rds",
+				Name:      "command_errors_total",
+				Help:      "Total failed RouterOS commands by command (menu and verb) and error class",
+			},
+			[]string{"command", "error_class"},
+		),
+
+		rdsCommandRetriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "rds",
+				Name:      "command_retries_total",
+				Help:      "Total retries of RouterOS commands by command (menu and verb)",
+			},
+			[]string{"command"},
+		),
+
+		rdsRetriesRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "rds",
+				Name:      "retries_rejected_total",
+				Help:      "Total RouterOS command retries not made because the retry budget was exhausted, by command (menu and verb)",
+			},
+			[]string{"command"},
+		),
+
+		rdsCommandQueueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "rds",
+				Name:      "command_queue_depth",
+				Help:      "RouterOS commands waiting for an SSH session, by RDS address and command class",
+			},
+			[]string{"address", "class"},
+		),
+
+		rdsRouterOSInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "rds",
+				Name:      "routeros_info",
+				Help:      "RouterOS version running on each RDS backend (always 1)",
+			},
+			[]string{"backend", "version"},
+		),
+
+		socketRecreationsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "socket_recreations_total",
+			Help:      "Total number of times the CSI socket was found missing and re-created",
+		}),
+
+		registrationHealthy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "registration_healthy",
+			Help:      "Whether the CSI socket (and registration socket, if checked) is present (1=healthy, 0=unhealthy)",
+		}),
+
+		nodeNVMeTCPAvailable: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "node_nvme_tcp_available",
+			Help:      "Whether the nvme_tcp kernel module is available on this node (1=available, 0=missing)",
+		}),
+
+		poolAvailableBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "pool_available_bytes",
+				Help:      "Free space on the RDS filesystem holding volume files, by backend and volume base path",
+			},
+			[]string{"backend", "basePath"},
+		),
+
+		poolTotalBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "pool_total_bytes",
+				Help:      "Size of the RDS filesystem holding volume files, by backend and volume base path",
+			},
+			[]string{"backend", "basePath"},
+		),
 	}
 
 	// Register all metrics with the custom registry
 	reg.MustRegister(
 		m.volumeOpsTotal,
 		m.volumeOpsDuration,
+		m.stagePhaseDuration,
+		m.volumeTimeToReady,
+		m.volumeStageDuration,
 		m.nvmeConnectsTotal,
 		m.nvmeConnectDuration,
 		m.mountOpsTotal,
+		m.unmountBusyRetriesTotal,
 		m.staleMountsDetectedTotal,
 		m.staleRecoveriesTotal,
+		m.filesystemErrorsDetectedTotal,
+		m.readProbeFailuresTotal,
+		m.nvmeConnectRateLimitedTotal,
+		m.slowOperationsTotal,
 		m.orphansCleanedTotal,
+		m.stagingDirsRemovedTotal,
 		m.eventsPostedTotal,
+		m.eventsSuppressedTotal,
+		m.volumeExpansionsTotal,
 		m.attachmentAttachTotal,
 		m.attachmentDetachTotal,
 		m.attachmentConflictsTotal,
@@ -312,12 +595,21 @@ func NewMetrics() *Metrics {
 		m.attachmentOpDuration,
 		m.attachmentGracePeriodUsed,
 		m.attachmentStaleCleared,
+		m.attachmentDetachStamps,
+		m.annotationQueueDepth,
+		m.annotationWritesDropped,
+		m.metadataSizeMaxBytes,
 		m.migrationsTotal,
 		m.migrationDuration,
 		m.activeMigrations,
 		m.rdsConnectionState,
 		m.rdsReconnectTotal,
 		m.rdsReconnectDuration,
+		m.rdsCommandErrorsTotal,
+		m.rdsCommandRetriesTotal,
+		m.rdsRetriesRejected,
+		m.rdsCommandQueueDepth,
+		m.rdsRouterOSInfo,
 	)
 
 	return m
@@ -331,11 +623,51 @@ func (m *Metrics) Handler() http.Handler {
 	})
 }
 
+// registerOptional registers the collectors of an optional feature, first unregistering
+// any registered for it before. Setting a feature up again, as when the controller and
+// node services of one process share a Metrics or a driver is recreated in-process,
+// therefore replaces its collectors instead of panicking on duplicates.
+//
+// It returns the function unregistering the collectors again, which the component that
+// set the feature up calls when it stops: their callbacks refer to it. The function does
+// nothing once the feature has been set up again, so a component stopping never removes
+// the collectors of another one. Metrics registered by NewMetrics are not affected.
+func (m *Metrics) registerOptional(feature string, collectors ...prometheus.Collector) func() {
+	m.optionalMu.Lock()
+	defer m.optionalMu.Unlock()
+	if previous := m.optional[feature]; previous != nil {
+		for _, c := range previous.collectors {
+			m.registry.Unregister(c)
+		}
+	}
+	m.registry.MustRegister(collectors...)
+	registration := &optionalFeature{collectors: collectors}
+	m.optional[feature] = registration
+
+	return func() {
+		m.optionalMu.Lock()
+		defer m.optionalMu.Unlock()
+		if m.optional[feature] != registration {
+			return
+		}
+		for _, c := range collectors {
+			m.registry.Unregister(c)
+		}
+		delete(m.optional, feature)
+	}
+}
+
+// optionalFeature is one registration of the collectors of an optional feature
+type optionalFeature struct {
+	collectors []prometheus.Collector
+}
+
 // SetAttachmentManager registers a GaugeFunc that derives nvme_connections_active
 // from the attachment manager's current state. This must be called after the
 // AttachmentManager is created. If not called (e.g., node plugin), the metric
 // is not registered and won't appear in scrapes.
-func (m *Metrics) SetAttachmentManager(countFunc func() int) {
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) SetAttachmentManager(countFunc func() int) func() {
 	m.attachmentCountFunc = countFunc
 
 	nvmeConnectionsActive := prometheus.NewGaugeFunc(
@@ -352,7 +684,97 @@ func (m *Metrics) SetAttachmentManager(countFunc func() int) {
 		},
 	)
 
-	m.registry.MustRegister(nvmeConnectionsActive)
+	return m.registerOptional("attachment_manager", nvmeConnectionsActive)
+}
+
+// SetSnapshotRestoresInProgress registers snapshot_restores_in_progress, the number of
+// CreateVolume-from-snapshot copies currently running. DeleteSnapshot is refused for a
+// snapshot while any of its restores are in progress.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) SetSnapshotRestoresInProgress(countFunc func() int) func() {
+	return m.registerOptional("snapshot_restores", prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "snapshot_restores_in_progress",
+			Help:      "Number of volumes currently being restored from a snapshot",
+		},
+		func() float64 {
+			return float64(countFunc())
+		},
+	))
+}
+
+// SetRDSAuditDropped registers rds_csi_rds_audit_entries_dropped_total, the number of
+// RouterOS command audit entries discarded because the audit log could not keep up or
+// its file could not be written. droppedFunc is invoked on each scrape.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) SetRDSAuditDropped(droppedFunc func() uint64) func() {
+	return m.registerOptional("rds_audit", prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "rds",
+			Name:      "audit_entries_dropped_total",
+			Help:      "Total RouterOS command audit entries dropped instead of written",
+		},
+		func() float64 {
+			return float64(droppedFunc())
+		},
+	))
+}
+
+// SetRDSRetryBudget registers rds_csi_rds_retry_budget_consumed_ratio, the share of the
+// RouterOS command retry budget currently used up (1 while retries are refused).
+// consumedFunc is invoked on each scrape.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) SetRDSRetryBudget(consumedFunc func() float64) func() {
+	return m.registerOptional("rds_retry_budget", prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "rds",
+			Name:      "retry_budget_consumed_ratio",
+			Help:      "Share of the RouterOS command retry budget currently used up, from 0 to 1",
+		},
+		consumedFunc,
+	))
+}
+
+// SetRDSNVMeSessions registers rds_csi_rds_nvme_sessions{slot}, the number of
+// NVMe/TCP initiator sessions RDS reports per volume. This is the storage-side
+// counterpart to nvme_connections_active, which is derived from controller state.
+//
+// sessionsFunc is invoked on each scrape. On error no samples are emitted, so the
+// series disappear rather than reporting a misleading zero.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) SetRDSNVMeSessions(sessionsFunc func() (map[string]int, error)) func() {
+	return m.registerOptional("rds_nvme_sessions", &nvmeSessionsCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "rds_nvme_sessions"),
+			"Number of NVMe/TCP initiator sessions RDS reports per volume slot",
+			[]string{"slot"}, nil,
+		),
+		sessionsFunc: sessionsFunc,
+	})
+}
+
+// nvmeSessionsCollector emits one gauge sample per slot. A GaugeFunc cannot be
+// used because the set of slots changes as volumes come and go.
+type nvmeSessionsCollector struct {
+	desc         *prometheus.Desc
+	sessionsFunc func() (map[string]int, error)
+}
+
+func (c *nvmeSessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *nvmeSessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	sessions, err := c.sessionsFunc()
+	if err != nil {
+		return
+	}
+	for slot, count := range sessions {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), slot)
+	}
 }
 
 // SetRDSMonitoring registers GaugeFunc metrics for RDS monitoring (disk performance + hardware health).
@@ -387,7 +809,9 @@ func (m *Metrics) SetAttachmentManager(countFunc func() int) {
 //	  - rds_hardware_psu2_temperature_celsius
 //	  - rds_hardware_disk_pool_size_bytes
 //	  - rds_hardware_disk_pool_used_bytes
-func (m *Metrics) SetRDSMonitoring(slot string, snmpHost string, snmpCommunity string, diskMetricsFunc func() (*DiskHealthSnapshot, error), hardwareMetricsFunc func() (*HardwareHealthSnapshot, error)) {
+//
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) SetRDSMonitoring(slot string, snmpHost string, snmpCommunity string, diskMetricsFunc func() (*DiskHealthSnapshot, error), hardwareMetricsFunc func() (*HardwareHealthSnapshot, error)) func() {
 	m.rdsDiskMetricsFunc = diskMetricsFunc
 	m.rdsHardwareMetricsFunc = hardwareMetricsFunc
 
@@ -418,6 +842,7 @@ func (m *Metrics) SetRDSMonitoring(slot string, snmpHost string, snmpCommunity s
 
 		cachedDiskSnapshot = snapshot
 		diskCacheTime = time.Now()
+		m.lastDiskSnapshot.Store(snapshot)
 		return cachedDiskSnapshot
 	}
 
@@ -445,7 +870,7 @@ func (m *Metrics) SetRDSMonitoring(slot string, snmpHost string, snmpCommunity s
 	diskLabels := prometheus.Labels{"slot": slot}
 
 	// Register all 19 metrics (9 disk + 10 hardware)
-	m.registry.MustRegister(
+	return m.registerOptional("rds_monitoring",
 		// === Disk Performance Metrics (9 metrics via SSH) ===
 		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 			Namespace: "rds", Subsystem: "disk",
@@ -584,6 +1009,33 @@ func (m *Metrics) RecordVolumeOp(operation string, err error, duration time.Dura
 	m.volumeOpsDuration.WithLabelValues(operation).Observe(duration.Seconds())
 }
 
+// RecordStagePhase records how long one phase of a node volume operation took,
+// whether or not it succeeded.
+func (m *Metrics) RecordStagePhase(phase string, duration time.Duration) {
+	m.stagePhaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// Attach kinds for RecordVolumeStageDuration and RecordVolumeTimeToReady
+const (
+	// VolumeAttachNew means NodeStageVolume formatted the volume, i.e. its first use
+	VolumeAttachNew = "new"
+	// VolumeAttachReattach means NodeStageVolume mounted an existing filesystem
+	VolumeAttachReattach = "reattach"
+	// VolumeAttachBlock means a block volume, for which the node cannot tell
+	VolumeAttachBlock = "block"
+)
+
+// RecordVolumeStageDuration records how long a successful NodeStageVolume took on the node.
+func (m *Metrics) RecordVolumeStageDuration(attach string, duration time.Duration) {
+	m.volumeStageDuration.WithLabelValues(attach).Observe(duration.Seconds())
+}
+
+// RecordVolumeTimeToReady records how long after ControllerPublishVolume completed the
+// volume was staged.
+func (m *Metrics) RecordVolumeTimeToReady(attach string, duration time.Duration) {
+	m.volumeTimeToReady.WithLabelValues(attach).Observe(duration.Seconds())
+}
+
 // RecordNVMeConnect records an NVMe connection attempt.
 // On success (err == nil), also records the duration.
 func (m *Metrics) RecordNVMeConnect(err error, duration time.Duration) {
@@ -617,6 +1069,11 @@ func (m *Metrics) RecordMountOp(operation string, err error) {
 	m.mountOpsTotal.WithLabelValues(operation, status).Inc()
 }
 
+// RecordUnmountBusyRetry records that an unmount failed on a busy target and is retried.
+func (m *Metrics) RecordUnmountBusyRetry() {
+	m.unmountBusyRetriesTotal.Inc()
+}
+
 // RecordStaleMountDetected records that a stale mount was detected.
 func (m *Metrics) RecordStaleMountDetected() {
 	m.staleMountsDetectedTotal.Inc()
@@ -631,17 +1088,69 @@ func (m *Metrics) RecordStaleRecovery(err error) {
 	m.staleRecoveriesTotal.WithLabelValues(status).Inc()
 }
 
+// Filesystem error reasons for RecordFilesystemErrorDetected
+const (
+	// FilesystemErrorReadOnlyRemount means the kernel remounted a read-write filesystem read-only
+	FilesystemErrorReadOnlyRemount = "read_only_remount"
+	// FilesystemErrorExt4Errors means ext4 recorded errors in its superblock
+	FilesystemErrorExt4Errors = "ext4_errors"
+)
+
+// RecordFilesystemErrorDetected records that a volume stats check found filesystem errors.
+func (m *Metrics) RecordFilesystemErrorDetected(reason string) {
+	m.filesystemErrorsDetectedTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordReadProbeFailure records that a read probe of a staged volume failed.
+func (m *Metrics) RecordReadProbeFailure() {
+	m.readProbeFailuresTotal.Inc()
+}
+
+// RecordNVMeConnectRateLimited records that an NVMe connect was refused by the node's
+// connect rate limit.
+func (m *Metrics) RecordNVMeConnectRateLimited() {
+	m.nvmeConnectRateLimitedTotal.Inc()
+}
+
+// RecordSlowOperation records that a CSI call exceeded its soft deadline.
+func (m *Metrics) RecordSlowOperation(method string) {
+	m.slowOperationsTotal.WithLabelValues(method).Inc()
+}
+
 // RecordOrphanCleaned records that an orphaned NVMe connection was cleaned up.
 func (m *Metrics) RecordOrphanCleaned() {
 	m.orphansCleanedTotal.Inc()
 }
 
+// RecordStagingDirRemoved records that the staging janitor removed an orphaned staging directory.
+func (m *Metrics) RecordStagingDirRemoved() {
+	m.stagingDirsRemovedTotal.Inc()
+}
+
 // RecordEventPosted records that a Kubernetes event was posted.
 // reason should match the event reason constants (e.g., MountFailure, RecoveryFailed).
 func (m *Metrics) RecordEventPosted(reason string) {
 	m.eventsPostedTotal.WithLabelValues(reason).Inc()
 }
 
+// RecordEventSuppressed records that a Kubernetes event was dropped by rate limiting.
+func (m *Metrics) RecordEventSuppressed(reason string) {
+	m.eventsSuppressedTotal.WithLabelValues(reason).Inc()
+}
+
+// Volume expansion phases for RecordVolumeExpansion
+const (
+	// ExpansionPhasePending means RDS was resized but an attached node has not yet confirmed the new size
+	ExpansionPhasePending = "pending_node_resize"
+	// ExpansionPhaseCompleted means the new size is visible to the consumer
+	ExpansionPhaseCompleted = "completed"
+)
+
+// RecordVolumeExpansion records a volume expansion reaching the given phase.
+func (m *Metrics) RecordVolumeExpansion(phase string) {
+	m.volumeExpansionsTotal.WithLabelValues(phase).Inc()
+}
+
 // RecordAttachmentOp records an attachment or detachment operation with duration.
 // operation should be "attach" or "detach".
 func (m *Metrics) RecordAttachmentOp(operation string, err error, duration time.Duration) {
@@ -660,9 +1169,96 @@ func (m *Metrics) RecordAttachmentOp(operation string, err error, duration time.
 	m.attachmentOpDuration.WithLabelValues(operation).Observe(duration.Seconds())
 }
 
-// RecordAttachmentConflict records an RWO attachment conflict.
-func (m *Metrics) RecordAttachmentConflict() {
-	m.attachmentConflictsTotal.Inc()
+// AttachmentCounters is a point-in-time snapshot of attachment counters.
+type AttachmentCounters struct {
+	AttachSuccess float64
+	DetachSuccess float64
+	Conflicts     float64
+	StaleCleared  float64
+}
+
+// AttachmentSnapshot returns the current attachment counter values.
+// Reads the counters directly so callers avoid a full scrape, which would poll
+// the RDS monitoring callbacks over SSH/SNMP.
+func (m *Metrics) AttachmentSnapshot() AttachmentCounters {
+	conflicts := counterValue(m.attachmentConflictsTotal.WithLabelValues("identified")) +
+		counterValue(m.attachmentConflictsTotal.WithLabelValues("unknown"))
+	return AttachmentCounters{
+		AttachSuccess: counterValue(m.attachmentAttachTotal.WithLabelValues("success")),
+		DetachSuccess: counterValue(m.attachmentDetachTotal.WithLabelValues("success")),
+		Conflicts:     conflicts,
+		StaleCleared:  counterValue(m.attachmentStaleCleared),
+	}
+}
+
+// counterValue reads the current value of a counter.
+func counterValue(c prometheus.Counter) float64 {
+	var metric dto.Metric
+	if err := c.Write(&metric); err != nil {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
+
+// gaugeValue reads the current value of a gauge.
+func gaugeValue(g prometheus.Gauge) float64 {
+	var metric dto.Metric
+	if err := g.Write(&metric); err != nil {
+		return 0
+	}
+	return metric.GetGauge().GetValue()
+}
+
+// MetricsSnapshot holds the last known values of the metrics the driver logs when it
+// stops, so a post-mortem has the final state even if Prometheus missed it.
+type MetricsSnapshot struct {
+	ActiveMigrations      float64
+	NVMeConnectionsActive float64 // -1 without an attachment manager
+	RDSInFlightOps        float64 // -1 if the RDS disk metrics were never scraped
+	Attachments           AttachmentCounters
+}
+
+// Snapshot returns the last known values of the key gauges and counters. Like
+// AttachmentSnapshot it reads them directly; the RDS in-flight operations are those
+// fetched by the last scrape, not polled from RDS again.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{
+		ActiveMigrations:      gaugeValue(m.activeMigrations),
+		NVMeConnectionsActive: -1,
+		RDSInFlightOps:        -1,
+		Attachments:           m.AttachmentSnapshot(),
+	}
+	if m.attachmentCountFunc != nil {
+		snapshot.NVMeConnectionsActive = float64(m.attachmentCountFunc())
+	}
+	if disk := m.lastDiskSnapshot.Load(); disk != nil {
+		snapshot.RDSInFlightOps = disk.InFlightOps
+	}
+	return snapshot
+}
+
+// String formats the snapshot as one line of metric=value pairs, named after the
+// metrics they come from. Values that were never observed are "unknown".
+func (s MetricsSnapshot) String() string {
+	known := func(v float64) string {
+		if v < 0 {
+			return "unknown"
+		}
+		return fmt.Sprintf("%g", v)
+	}
+	return fmt.Sprintf("active_migrations=%g nvme_connections_active=%s rds_disk_in_flight_operations=%s attach_success=%g detach_success=%g attachment_conflicts=%g",
+		s.ActiveMigrations, known(s.NVMeConnectionsActive), known(s.RDSInFlightOps),
+		s.Attachments.AttachSuccess, s.Attachments.DetachSuccess, s.Attachments.Conflicts)
+}
+
+// RecordAttachmentConflict records an RWO attachment conflict, labelled by whether the
+// workload holding the volume was identified.
+func (m *Metrics) RecordAttachmentConflict(holderIdentified bool) {
+	holder := "unknown"
+	if holderIdentified {
+		holder = "identified"
+	}
+	m.attachmentConflictsTotal.WithLabelValues(holder).Inc()
 }
 
 // RecordGracePeriodUsed records when grace period prevented a conflict.
@@ -675,6 +1271,34 @@ func (m *Metrics) RecordStaleAttachmentCleared() {
 	m.attachmentStaleCleared.Inc()
 }
 
+// SetDetachTimestamps records how many detach timestamps the attachment manager tracks.
+func (m *Metrics) SetDetachTimestamps(count int) {
+	m.attachmentDetachStamps.Set(float64(count))
+}
+
+// SetAnnotationQueueDepth records how many volumes have a PV annotation write queued.
+func (m *Metrics) SetAnnotationQueueDepth(depth int) {
+	m.annotationQueueDepth.Set(float64(depth))
+}
+
+// RecordAnnotationWriteDropped records that a queued PV annotation write was given up on.
+// reason should be one of: retries_exhausted, queue_full.
+func (m *Metrics) RecordAnnotationWriteDropped(reason string) {
+	m.annotationWritesDropped.WithLabelValues(reason).Inc()
+}
+
+// RecordMetadataSize records the size of metadata stored on a Kubernetes object,
+// keeping the largest seen. object should be "volume_context" or "pv_annotations".
+func (m *Metrics) RecordMetadataSize(object string, size int) {
+	m.metadataSizeMu.Lock()
+	defer m.metadataSizeMu.Unlock()
+	if size <= m.metadataSizeMax[object] {
+		return
+	}
+	m.metadataSizeMax[object] = size
+	m.metadataSizeMaxBytes.WithLabelValues(object).Set(float64(size))
+}
+
 // RecordReconcileAction records a reconciliation action.
 // action should be "clear_stale" or "sync_annotation".
 func (m *Metrics) RecordReconcileAction(action string) {
@@ -715,3 +1339,99 @@ func (m *Metrics) RecordReconnectAttempt(status string, duration time.Duration)
 		m.rdsReconnectDuration.Observe(duration.Seconds())
 	}
 }
+
+// RDSConnectionCounters is a point-in-time snapshot of the RDS connection metrics.
+type RDSConnectionCounters struct {
+	Connected        float64 // connection_state of the address: 1 connected, 0 disconnected
+	ReconnectSuccess float64
+	ReconnectFailure float64
+}
+
+// RDSConnectionSnapshot returns the connection state of address and the reconnect
+// counters. Like AttachmentSnapshot it reads them directly instead of scraping.
+func (m *Metrics) RDSConnectionSnapshot(address string) RDSConnectionCounters {
+	return RDSConnectionCounters{
+		Connected:        gaugeValue(m.rdsConnectionState.WithLabelValues(address)),
+		ReconnectSuccess: counterValue(m.rdsReconnectTotal.WithLabelValues("success")),
+		ReconnectFailure: counterValue(m.rdsReconnectTotal.WithLabelValues("failure")),
+	}
+}
+
+// RecordRDSCommandError records a failed RouterOS command.
+// command is the menu and verb (e.g. "/disk add"), errorClass one of the rds.CommandError* classes.
+func (m *Metrics) RecordRDSCommandError(command, errorClass string) {
+	m.rdsCommandErrorsTotal.WithLabelValues(command, errorClass).Inc()
+}
+
+// RecordRDSCommandRetry records a retry of a RouterOS command.
+func (m *Metrics) RecordRDSCommandRetry(command string) {
+	m.rdsCommandRetriesTotal.WithLabelValues(command).Inc()
+}
+
+// RecordRDSRetryRejected records a retry of a RouterOS command refused by the retry budget.
+func (m *Metrics) RecordRDSRetryRejected(command string) {
+	m.rdsRetriesRejected.WithLabelValues(command).Inc()
+}
+
+// RecordRDSCommandQueueDepth records the number of commands of a class waiting for an SSH
+// session on the connection to an RDS.
+func (m *Metrics) RecordRDSCommandQueueDepth(address, class string, depth int) {
+	m.rdsCommandQueueDepth.WithLabelValues(address, class).Set(float64(depth))
+}
+
+// RecordRouterOSInfo records the RouterOS version reported by an RDS backend.
+func (m *Metrics) RecordRouterOSInfo(backend, version string) {
+	m.rdsRouterOSInfo.WithLabelValues(backend, version).Set(1)
+}
+
+// EnableSocketWatchdogMetrics registers socket_recreations_total and registration_healthy.
+// Called when the socket watchdog starts, so a driver without one (e.g. on a TCP endpoint)
+// does not report an unhealthy registration. Safe to call repeatedly.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) EnableSocketWatchdogMetrics() func() {
+	return m.registerOptional("socket_watchdog", m.socketRecreationsTotal, m.registrationHealthy)
+}
+
+// RecordSocketRecreation records that the CSI socket was missing and re-created.
+func (m *Metrics) RecordSocketRecreation() {
+	m.socketRecreationsTotal.Inc()
+}
+
+// SetRegistrationHealthy records whether the CSI socket is present for kubelet to reach.
+func (m *Metrics) SetRegistrationHealthy(healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1.0
+	}
+	m.registrationHealthy.Set(value)
+}
+
+// EnableNodeNVMeTCPMetrics registers node_nvme_tcp_available. Called by the node service
+// so a controller-only driver does not report a missing module. Safe to call repeatedly.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) EnableNodeNVMeTCPMetrics() func() {
+	return m.registerOptional("node_nvme_tcp", m.nodeNVMeTCPAvailable)
+}
+
+// SetNodeNVMeTCPAvailable records whether the nvme_tcp kernel module is available.
+func (m *Metrics) SetNodeNVMeTCPAvailable(available bool) {
+	value := 0.0
+	if available {
+		value = 1.0
+	}
+	m.nodeNVMeTCPAvailable.Set(value)
+}
+
+// EnableCapacityMetrics registers pool_available_bytes and pool_total_bytes. Called when
+// the controller's capacity monitor starts. Safe to call repeatedly.
+// The returned function unregisters the metrics again (see registerOptional).
+func (m *Metrics) EnableCapacityMetrics() func() {
+	return m.registerOptional("pool_capacity", m.poolAvailableBytes, m.poolTotalBytes)
+}
+
+// RecordPoolCapacity records the size and free space of the filesystem holding basePath
+// on an RDS backend.
+func (m *Metrics) RecordPoolCapacity(backend, basePath string, totalBytes, availableBytes int64) {
+	m.poolTotalBytes.WithLabelValues(backend, basePath).Set(float64(totalBytes))
+	m.poolAvailableBytes.WithLabelValues(backend, basePath).Set(float64(availableBytes))
+}
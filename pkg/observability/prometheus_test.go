@@ -68,6 +68,25 @@ func TestRecordVolumeOp(t *testing.T) {
 	}
 }
 
+func TestRecordStagePhase(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordStagePhase("connect", 2*time.Second)
+	m.RecordStagePhase("format", 500*time.Millisecond)
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	for _, phase := range []string{"connect", "format"} {
+		if !strings.Contains(body, `rds_csi_stage_phase_duration_seconds_count{phase="`+phase+`"} 1`) {
+			t.Errorf("expected stage_phase_duration_seconds sample for phase %s", phase)
+		}
+	}
+}
+
 func TestRecordVolumeOp_AllOperations(t *testing.T) {
 	operations := []string{"create", "delete", "stage", "unstage", "publish", "unpublish"}
 
@@ -354,6 +373,60 @@ func TestRecordStaleMountDetected(t *testing.T) {
 	}
 }
 
+func TestRecordFilesystemErrorDetected(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordFilesystemErrorDetected(FilesystemErrorReadOnlyRemount)
+	m.RecordFilesystemErrorDetected(FilesystemErrorExt4Errors)
+	m.RecordFilesystemErrorDetected(FilesystemErrorExt4Errors)
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `rds_csi_filesystem_errors_detected_total{reason="read_only_remount"} 1`) {
+		t.Error("expected filesystem_errors_detected_total=1 for read_only_remount")
+	}
+	if !strings.Contains(body, `rds_csi_filesystem_errors_detected_total{reason="ext4_errors"} 2`) {
+		t.Error("expected filesystem_errors_detected_total=2 for ext4_errors")
+	}
+}
+
+func TestRecordReadProbeFailure(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordReadProbeFailure()
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "rds_csi_volume_read_probe_failures_total 1") {
+		t.Errorf("expected volume_read_probe_failures_total to be 1, got:\n%s", body)
+	}
+}
+
+func TestRecordUnmountBusyRetry(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordUnmountBusyRetry()
+	m.RecordUnmountBusyRetry()
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "rds_csi_unmount_busy_retries_total 2") {
+		t.Errorf("expected unmount_busy_retries_total to be 2, got:\n%s", body)
+	}
+}
+
 func TestRecordStaleRecovery(t *testing.T) {
 	m := NewMetrics()
 
@@ -475,6 +548,44 @@ func TestRecordEventPosted_ReasonLabels(t *testing.T) {
 	}
 }
 
+func TestRecordEventSuppressed(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordEventSuppressed("MountFailure")
+	m.RecordEventSuppressed("MountFailure")
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `rds_csi_events_suppressed_total{reason="MountFailure"} 2`) {
+		t.Error("expected events_suppressed_total=2 for MountFailure")
+	}
+}
+
+func TestRecordVolumeExpansion(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordVolumeExpansion(ExpansionPhasePending)
+	m.RecordVolumeExpansion(ExpansionPhaseCompleted)
+	m.RecordVolumeExpansion(ExpansionPhaseCompleted)
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `rds_csi_volume_expansions_total{phase="pending_node_resize"} 1`) {
+		t.Error("expected volume_expansions_total=1 for pending_node_resize")
+	}
+	if !strings.Contains(body, `rds_csi_volume_expansions_total{phase="completed"} 2`) {
+		t.Error("expected volume_expansions_total=2 for completed")
+	}
+}
+
 func TestMetricsNamespace(t *testing.T) {
 	m := NewMetrics()
 
@@ -607,6 +718,52 @@ func TestHistogramBuckets(t *testing.T) {
 	}
 }
 
+func TestAttachmentSnapshot(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordAttachmentOp("attach", nil, 10*time.Millisecond)
+	m.RecordAttachmentOp("attach", nil, 10*time.Millisecond)
+	m.RecordAttachmentOp("attach", errors.New("conflict"), 10*time.Millisecond)
+	m.RecordAttachmentOp("detach", nil, 10*time.Millisecond)
+	m.RecordAttachmentConflict(true)
+	m.RecordAttachmentConflict(false)
+	m.RecordStaleAttachmentCleared()
+	m.RecordStaleAttachmentCleared()
+
+	snap := m.AttachmentSnapshot()
+	if snap.AttachSuccess != 2 {
+		t.Errorf("expected 2 successful attaches, got %v", snap.AttachSuccess)
+	}
+	if snap.DetachSuccess != 1 {
+		t.Errorf("expected 1 successful detach, got %v", snap.DetachSuccess)
+	}
+	if snap.Conflicts != 2 {
+		t.Errorf("expected 2 conflicts, got %v", snap.Conflicts)
+	}
+	if snap.StaleCleared != 2 {
+		t.Errorf("expected 2 stale cleared, got %v", snap.StaleCleared)
+	}
+}
+
+func TestRDSConnectionSnapshot(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordConnectionState("10.42.68.1", true)
+	m.RecordConnectionState("10.42.68.1", false)
+	m.RecordReconnectAttempt("failure", 0)
+	m.RecordReconnectAttempt("failure", 0)
+	m.RecordReconnectAttempt("success", 3*time.Second)
+	m.RecordConnectionState("10.42.68.1", true)
+
+	snap := m.RDSConnectionSnapshot("10.42.68.1")
+	if snap.Connected != 1 {
+		t.Errorf("expected connected state 1, got %v", snap.Connected)
+	}
+	if snap.ReconnectSuccess != 1 || snap.ReconnectFailure != 2 {
+		t.Errorf("expected 1 successful and 2 failed reconnects, got %v and %v", snap.ReconnectSuccess, snap.ReconnectFailure)
+	}
+}
+
 func TestRecordMigrationStarted(t *testing.T) {
 	m := NewMetrics()
 
@@ -813,6 +970,29 @@ func TestRecordConnectionState_MultipleAddresses(t *testing.T) {
 	}
 }
 
+func TestRecordRouterOSInfo(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordRouterOSInfo("default", "7.16")
+	m.RecordRouterOSInfo("array2", "7.18.1")
+
+	handler := m.Handler()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`rds_csi_rds_routeros_info{backend="default",version="7.16"} 1`,
+		`rds_csi_rds_routeros_info{backend="array2",version="7.18.1"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s, got:\n%s", want, body)
+		}
+	}
+}
+
 func TestRecordReconnectAttempt_Success(t *testing.T) {
 	m := NewMetrics()
 
@@ -1047,3 +1227,235 @@ func TestRDSMetrics_NotRegisteredWithoutCall(t *testing.T) {
 		t.Error("rds metrics should not appear without SetRDSMonitoring call")
 	}
 }
+
+func TestRDSNVMeSessions_PerSlot(t *testing.T) {
+	m := NewMetrics()
+	sessions := map[string]int{"pvc-a": 1, "pvc-b": 2}
+	m.SetRDSNVMeSessions(func() (map[string]int, error) {
+		return sessions, nil
+	})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`rds_csi_rds_nvme_sessions{slot="pvc-a"} 1`,
+		`rds_csi_rds_nvme_sessions{slot="pvc-b"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+
+	// Slots that disappear on RDS should drop out of the next scrape
+	sessions = map[string]int{"pvc-b": 1}
+	rec = httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body = rec.Body.String()
+	if strings.Contains(body, `slot="pvc-a"`) {
+		t.Error("expected pvc-a to be removed after it disappears")
+	}
+	if !strings.Contains(body, `rds_csi_rds_nvme_sessions{slot="pvc-b"} 1`) {
+		t.Error("expected pvc-b to be updated to 1")
+	}
+}
+
+func TestRDSNVMeSessions_ErrorEmitsNothing(t *testing.T) {
+	m := NewMetrics()
+	m.SetRDSNVMeSessions(func() (map[string]int, error) {
+		return nil, errors.New("ssh timeout")
+	})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected scrape to succeed, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "rds_csi_rds_nvme_sessions{") {
+		t.Error("expected no session samples when the callback fails")
+	}
+}
+
+func TestSocketWatchdogMetrics(t *testing.T) {
+	m := NewMetrics()
+
+	// Not exported until a watchdog is running
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "rds_csi_registration_healthy") {
+		t.Error("registration_healthy should not be registered before EnableSocketWatchdogMetrics")
+	}
+
+	m.EnableSocketWatchdogMetrics()
+	m.SetRegistrationHealthy(true)
+	m.RecordSocketRecreation()
+
+	rec = httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "rds_csi_socket_recreations_total 1") {
+		t.Errorf("expected socket_recreations_total 1, got:\n%s", body)
+	}
+	if !strings.Contains(body, "rds_csi_registration_healthy 1") {
+		t.Errorf("expected registration_healthy 1, got:\n%s", body)
+	}
+}
+
+func TestNodeNVMeTCPMetrics(t *testing.T) {
+	m := NewMetrics()
+
+	// Not exported by controller-only drivers
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "rds_csi_node_nvme_tcp_available") {
+		t.Error("node_nvme_tcp_available should not be registered before EnableNodeNVMeTCPMetrics")
+	}
+
+	m.EnableNodeNVMeTCPMetrics()
+	m.SetNodeNVMeTCPAvailable(false)
+
+	rec = httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "rds_csi_node_nvme_tcp_available 0") {
+		t.Errorf("expected node_nvme_tcp_available 0, got:\n%s", rec.Body.String())
+	}
+}
+
+// setUpOptionalMetrics sets up every optional feature, as a driver running controller
+// and node services in one process does, with callbacks reporting count. It returns the
+// function unregistering them again, as the driver does when it stops.
+func setUpOptionalMetrics(m *Metrics, count int) func() {
+	unregister := []func(){
+		m.SetAttachmentManager(func() int { return count }),
+		m.SetSnapshotRestoresInProgress(func() int { return count }),
+		m.SetRDSAuditDropped(func() uint64 { return uint64(count) }),
+		m.SetRDSNVMeSessions(func() (map[string]int, error) { return map[string]int{"pvc-a": count}, nil }),
+		m.SetRDSMonitoring("storage-pool", "10.42.68.1", "public",
+			func() (*DiskHealthSnapshot, error) { return &DiskHealthSnapshot{}, nil },
+			func() (*HardwareHealthSnapshot, error) { return &HardwareHealthSnapshot{}, nil }),
+		m.EnableSocketWatchdogMetrics(),
+		m.EnableNodeNVMeTCPMetrics(),
+		m.EnableCapacityMetrics(),
+	}
+	return func() {
+		for _, f := range unregister {
+			f()
+		}
+	}
+}
+
+func TestOptionalMetrics_SetUpTwice(t *testing.T) {
+	m := NewMetrics()
+	setUpOptionalMetrics(m, 1)
+	// A second driver in the same process replaces the first one's callbacks
+	setUpOptionalMetrics(m, 2)
+
+	body := scrapeMetrics(t, m)
+	for _, want := range []string{
+		"rds_csi_nvme_connections_active 2",
+		"rds_csi_snapshot_restores_in_progress 2",
+		`rds_csi_rds_nvme_sessions{slot="pvc-a"} 2`,
+	} {
+		if strings.Count(body, want) != 1 {
+			t.Errorf("expected %q exactly once, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestOptionalMetrics_Unregister(t *testing.T) {
+	m := NewMetrics()
+	unregister := setUpOptionalMetrics(m, 1)
+	m.RecordVolumeOp("stage", nil, time.Second)
+	unregister()
+
+	body := scrapeMetrics(t, m)
+	if strings.Contains(body, "rds_csi_nvme_connections_active") || strings.Contains(body, "rds_disk_read_ops_per_second") {
+		t.Errorf("expected optional metrics unregistered, got:\n%s", body)
+	}
+	if !strings.Contains(body, "rds_csi_volume_operations_total") {
+		t.Errorf("expected metrics from NewMetrics to stay registered, got:\n%s", body)
+	}
+
+	setUpOptionalMetrics(m, 3)
+	if body := scrapeMetrics(t, m); !strings.Contains(body, "rds_csi_nvme_connections_active 3") {
+		t.Errorf("expected optional metrics to register again after unregistering, got:\n%s", body)
+	}
+}
+
+func TestOptionalMetrics_UnregisterKeepsReplacement(t *testing.T) {
+	m := NewMetrics()
+	unregisterFirst := setUpOptionalMetrics(m, 1)
+	setUpOptionalMetrics(m, 2)
+
+	// The first driver stopping leaves the metrics of the one that replaced it
+	unregisterFirst()
+	body := scrapeMetrics(t, m)
+	for _, want := range []string{"rds_csi_nvme_connections_active 2", "rds_csi_registration_healthy"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q to stay registered, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsSnapshot(t *testing.T) {
+	m := NewMetrics()
+	if got := m.Snapshot().String(); !strings.Contains(got, "nvme_connections_active=unknown rds_disk_in_flight_operations=unknown") {
+		t.Errorf("expected unobserved values to be unknown, got %q", got)
+	}
+
+	m.SetAttachmentManager(func() int { return 4 })
+	m.SetRDSMonitoring("storage-pool", "10.42.68.1", "public",
+		func() (*DiskHealthSnapshot, error) { return &DiskHealthSnapshot{InFlightOps: 7}, nil },
+		func() (*HardwareHealthSnapshot, error) { return &HardwareHealthSnapshot{}, nil })
+	m.RecordMigrationStarted()
+	m.RecordAttachmentOp("attach", nil, time.Second)
+	// The in-flight operations come from the last scrape, never from a new RDS poll
+	if got := m.Snapshot().String(); !strings.Contains(got, "rds_disk_in_flight_operations=unknown") {
+		t.Errorf("expected in-flight operations unknown before a scrape, got %q", got)
+	}
+	scrapeMetrics(t, m)
+
+	want := "active_migrations=1 nvme_connections_active=4 rds_disk_in_flight_operations=7 attach_success=1 detach_success=0 attachment_conflicts=0"
+	if got := m.Snapshot().String(); got != want {
+		t.Errorf("expected snapshot %q, got %q", want, got)
+	}
+}
+
+func TestRecordMetadataSize(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordMetadataSize("volume_context", 600)
+	m.RecordMetadataSize("volume_context", 400)
+	m.RecordMetadataSize("pv_annotations", 2048)
+
+	output := scrapeMetrics(t, m)
+	for _, want := range []string{
+		`rds_csi_metadata_size_max_bytes{object="volume_context"} 600`,
+		`rds_csi_metadata_size_max_bytes{object="pv_annotations"} 2048`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %s in output", want)
+		}
+	}
+}
+
+func TestRecordRDSCommandQueueDepth(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRDSCommandQueueDepth("10.42.68.1", "deletion", 12)
+	m.RecordRDSCommandQueueDepth("10.42.68.1", "provisioning", 1)
+	m.RecordRDSCommandQueueDepth("10.42.68.1", "provisioning", 0)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`rds_csi_rds_command_queue_depth{address="10.42.68.1",class="deletion"} 12`,
+		`rds_csi_rds_command_queue_depth{address="10.42.68.1",class="provisioning"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package rds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultAuditMaxSizeBytes is the audit file size at which it is rotated
+	DefaultAuditMaxSizeBytes = 100 * 1024 * 1024
+
+	// DefaultAuditMaxBackups is how many rotated audit files are kept
+	DefaultAuditMaxBackups = 3
+
+	// auditQueueSize bounds the entries waiting to be written; further entries are dropped
+	auditQueueSize = 1024
+)
+
+// AuditConfig configures the RouterOS command audit log
+type AuditConfig struct {
+	Path         string // File the JSON lines are appended to
+	MaxSizeBytes int64  // Rotate once the file would exceed this size (default DefaultAuditMaxSizeBytes)
+	MaxBackups   int    // Rotated files to keep as <path>.1 ... <path>.N (default DefaultAuditMaxBackups)
+
+	// IncludeReadOnly also records print and monitor commands (default: mutating commands only)
+	IncludeReadOnly bool
+}
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Address    string    `json:"address"`             // RDS the command was sent to
+	Operation  string    `json:"operation,omitempty"` // CSI call the command was issued for, e.g. "CreateVolume"
+	VolumeID   string    `json:"volumeID,omitempty"`  // Volume or snapshot that call works on
+	RequestID  string    `json:"requestID,omitempty"` // Correlation ID of that call (x-csi-request-id)
+	Command    string    `json:"command"`             // Full command with secret values redacted
+	DurationMs int64     `json:"durationMs"`          // Time until RouterOS answered
+	Outcome    string    `json:"outcome"`             // "success" or "error"
+	Error      string    `json:"error,omitempty"`     // Error returned for the command
+}
+
+// AuditLog appends an entry for every RouterOS command an RDS client issues, so the
+// commands behind an unexpected volume change can be reconstructed after the fact.
+// Writing happens on a background goroutine: entries that cannot be queued or written
+// (e.g. on a full disk) are dropped and counted rather than delaying the command.
+type AuditLog struct {
+	config  AuditConfig
+	entries chan AuditEntry
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex // Guards closed against concurrent Record and Close
+	closed bool
+
+	// Owned by the writer goroutine
+	file         *os.File // nil while the file could not be opened
+	size         int64
+	rotateFailed bool // The last rotation failed and the current file is written past its limit
+}
+
+// NewAuditLog opens (or creates) the audit file and starts the writer
+func NewAuditLog(config AuditConfig) (*AuditLog, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("audit log path is required")
+	}
+	if config.MaxSizeBytes <= 0 {
+		config.MaxSizeBytes = DefaultAuditMaxSizeBytes
+	}
+	if config.MaxBackups <= 0 {
+		config.MaxBackups = DefaultAuditMaxBackups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	a := &AuditLog{
+		config:  config,
+		entries: make(chan AuditEntry, auditQueueSize),
+		done:    make(chan struct{}),
+	}
+	if err := a.openFile(); err != nil {
+		return nil, err
+	}
+
+	go a.run()
+	return a, nil
+}
+
+// Record queues an entry for writing. It never blocks: if the queue is full the entry is dropped.
+func (a *AuditLog) Record(entry AuditEntry) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return
+	}
+
+	select {
+	case a.entries <- entry:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many entries were discarded because they could not be queued or written
+func (a *AuditLog) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close writes out queued entries and closes the file
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.entries)
+	a.mu.Unlock()
+
+	<-a.done
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// shouldRecord reports whether a command belongs in the audit log
+func (a *AuditLog) shouldRecord(command string) bool {
+	return a.config.IncludeReadOnly || !isReadOnlyCommand(command)
+}
+
+// run writes queued entries until Close
+func (a *AuditLog) run() {
+	defer close(a.done)
+
+	healthy := true
+	for entry := range a.entries {
+		err := a.write(entry)
+		if err != nil {
+			a.dropped.Add(1)
+			// Log once per failure streak, not for every dropped entry
+			if healthy {
+				klog.Warningf("Failed to write RDS audit log %s, dropping entries: %v", a.config.Path, err)
+			}
+		} else if !healthy {
+			klog.Infof("RDS audit log %s is writable again", a.config.Path)
+		}
+		healthy = err == nil
+	}
+}
+
+// write appends one JSON line, rotating the file first if it would grow past MaxSizeBytes
+func (a *AuditLog) write(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if a.file != nil && a.size > 0 && a.size+int64(len(line)) > a.config.MaxSizeBytes {
+		err := a.rotate()
+		switch {
+		case err != nil && a.file == nil:
+			return fmt.Errorf("failed to rotate: %w", err)
+		case err != nil:
+			// Keep appending to the reopened file rather than drop every later entry
+			if !a.rotateFailed {
+				klog.Warningf("Failed to rotate RDS audit log %s, writing past its size limit: %v", a.config.Path, err)
+			}
+			a.rotateFailed = true
+		default:
+			a.rotateFailed = false
+		}
+	}
+	if a.file == nil {
+		if err := a.openFile(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// rotate shifts <path>.N-1 to <path>.N (discarding the oldest), moves the current file to <path>.1
+// and starts a new one. If the files cannot be moved, the current file is opened again, so
+// a.file is only left nil when no file can be opened at all.
+func (a *AuditLog) rotate() error {
+	if err := a.file.Close(); err != nil {
+		klog.V(4).Infof("Error closing audit log before rotation: %v", err)
+	}
+	a.file = nil
+
+	if err := a.shiftBackups(); err != nil {
+		if openErr := a.openFile(); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+	return a.openFile()
+}
+
+// shiftBackups renames the rotated files and the current file one number up
+func (a *AuditLog) shiftBackups() error {
+	for i := a.config.MaxBackups - 1; i > 0; i-- {
+		src := fmt.Sprintf("%s.%d", a.config.Path, i)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, fmt.Sprintf("%s.%d", a.config.Path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.Rename(a.config.Path, a.config.Path+".1")
+}
+
+// openFile opens the audit file for appending and records its current size
+func (a *AuditLog) openFile() error {
+	file, err := os.OpenFile(a.config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	a.file = file
+	a.size = info.Size()
+	return nil
+}
+
+// commandVerbs are the RouterOS command verbs the driver issues, mapped to whether they only read state
+var commandVerbs = map[string]bool{
+	"print":           true,
+	"export":          true,
+	"monitor-traffic": true,
+	"add":             false,
+	"set":             false,
+	"remove":          false,
+	"enable":          false,
+	"disable":         false,
+}
+
+// isReadOnlyCommand reports whether a RouterOS command only reads state, e.g. "/disk print detail".
+// Commands with an unknown verb count as mutating.
+func isReadOnlyCommand(command string) bool {
+	_, verb := splitCommand(command)
+	return commandVerbs[verb]
+}
+
+// splitCommand returns the menu path and verb of a RouterOS command:
+// "/disk set [find slot=x] ..." gives "/disk" and "set", "/interface nvme-tcp connection print detail"
+// gives "/interface nvme-tcp connection" and "print". The verb is empty if none is recognized.
+func splitCommand(command string) (string, string) {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		if _, ok := commandVerbs[field]; ok {
+			return strings.Join(fields[:i], " "), field
+		}
+		if strings.ContainsAny(field, "=[\"") {
+			return strings.Join(fields[:i], " "), ""
+		}
+	}
+	return strings.Join(fields, " "), ""
+}
+
+// newAuditEntry builds the audit entry for a command sent to address for call (zero for
+// commands of background work)
+func newAuditEntry(address string, call Call, command string, started time.Time, err error) AuditEntry {
+	entry := AuditEntry{
+		Time:       started.UTC(),
+		Address:    address,
+		Operation:  call.Operation,
+		VolumeID:   call.VolumeID,
+		RequestID:  call.RequestID,
+		Command:    redactSecrets(command),
+		DurationMs: time.Since(started).Milliseconds(),
+		Outcome:    "success",
+	}
+	if err != nil {
+		entry.Outcome = "error"
+		entry.Error = redactSecrets(err.Error())
+	}
+	return entry
+}
@@ -0,0 +1,201 @@
+package rds
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		menu     string
+		verb     string
+		readOnly bool
+	}{
+		{command: `/disk add type=file file-path=/pool/a.img slot=pvc-a`, menu: "/disk", verb: "add"},
+		{command: `/disk set [find slot=pvc-a] file-size=10G`, menu: "/disk", verb: "set"},
+		{command: `/disk remove [find slot=pvc-a]`, menu: "/disk", verb: "remove"},
+		{command: `/file add type=directory name="pool/.trash"`, menu: "/file", verb: "add"},
+		{command: `/disk print detail where slot=pvc-a`, menu: "/disk", verb: "print", readOnly: true},
+		{command: `/interface nvme-tcp connection print detail`, menu: "/interface nvme-tcp connection", verb: "print", readOnly: true},
+		{command: `/disk monitor-traffic pvc-a once`, menu: "/disk", verb: "monitor-traffic", readOnly: true},
+		{command: `/disk frobnicate slot=pvc-a`, menu: "/disk frobnicate", verb: ""},
+	}
+
+	for _, tt := range tests {
+		menu, verb := splitCommand(tt.command)
+		if menu != tt.menu || verb != tt.verb {
+			t.Errorf("splitCommand(%q) = %q, %q, want %q, %q", tt.command, menu, verb, tt.menu, tt.verb)
+		}
+		if got := isReadOnlyCommand(tt.command); got != tt.readOnly {
+			t.Errorf("isReadOnlyCommand(%q) = %v, want %v", tt.command, got, tt.readOnly)
+		}
+	}
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	started := time.Now().Add(-50 * time.Millisecond)
+	entry := newAuditEntry("10.0.0.1", Call{}, `/system user set admin password="hunter2" comment="pvc"`, started, errors.New("command failed: bad key=abc123"))
+
+	if strings.Contains(entry.Command, "hunter2") || !strings.Contains(entry.Command, `password=<redacted>`) {
+		t.Errorf("password not redacted: %s", entry.Command)
+	}
+	if strings.Contains(entry.Error, "abc123") {
+		t.Errorf("secret not redacted from error: %s", entry.Error)
+	}
+	if entry.Outcome != "error" || entry.DurationMs < 50 {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	// Operation and volume come from the call, not from the command text
+	call := Call{Operation: "DeleteVolume", VolumeID: "pvc-abc", RequestID: "req-1"}
+	entry = newAuditEntry("10.0.0.1", call, `/disk remove [find slot=pvc-other]`, time.Now(), nil)
+	if entry.Operation != "DeleteVolume" || entry.VolumeID != "pvc-abc" || entry.RequestID != "req-1" || entry.Outcome != "success" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestAuditLog_ForCall(t *testing.T) {
+	setupTestBasePaths(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := NewAuditLog(AuditConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+	client, err := NewClient(ClientConfig{Address: "10.42.68.1", User: "admin", Executor: &recordingExecutor{}, AuditLog: audit})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	call := Call{Operation: "DeleteVolume", VolumeID: "pvc-abc", RequestID: "req-1"}
+	if err := ForCall(client, call).DeleteFile("/storage-pool/metal-csi/pvc-abc.img"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if err := client.DeleteFile("/storage-pool/metal-csi/pvc-def.img"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if Unscoped(ForCall(client, call)) != client {
+		t.Error("Unscoped should return the client the view was made from")
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %d:\n%s", len(lines), data)
+	}
+	var scoped, background AuditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &scoped); err != nil {
+		t.Fatalf("invalid entry: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &background); err != nil {
+		t.Fatalf("invalid entry: %v", err)
+	}
+	if scoped.Operation != call.Operation || scoped.VolumeID != call.VolumeID || scoped.RequestID != call.RequestID {
+		t.Errorf("entry of the call view = %+v, want it attributed to %+v", scoped, call)
+	}
+	if background.Operation != "" || background.VolumeID != "" || background.RequestID != "" {
+		t.Errorf("entry of the shared client = %+v, want no call", background)
+	}
+}
+
+func TestAuditLog_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	audit, err := NewAuditLog(AuditConfig{Path: path, MaxSizeBytes: 400, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		audit.Record(newAuditEntry("10.0.0.1", Call{}, `/disk remove [find slot=pvc-abc]`, time.Now(), nil))
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 400 {
+			t.Errorf("%s is %d bytes, larger than the rotation size", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("only 2 backups should be kept, found %s", path+".3")
+	}
+	if audit.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", audit.Dropped())
+	}
+}
+
+func TestAuditLog_RotationFailureKeepsWriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	// A non-empty directory where the rotated file should go makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocker"), 0750); err != nil {
+		t.Fatalf("failed to create blocker: %v", err)
+	}
+	audit, err := NewAuditLog(AuditConfig{Path: path, MaxSizeBytes: 400, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+
+	const entries = 20
+	for i := 0; i < entries; i++ {
+		audit.Record(newAuditEntry("10.0.0.1", Call{}, `/disk remove [find slot=pvc-abc]`, time.Now(), nil))
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if got := strings.Count(string(data), "\n"); got != entries {
+		t.Errorf("audit log has %d entries, want all %d written past the size limit", got, entries)
+	}
+	if audit.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", audit.Dropped())
+	}
+}
+
+func TestAuditLog_FullDiskDoesNotBlock(t *testing.T) {
+	// Writes to /dev/full fail with ENOSPC, like a full log volume
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+	audit, err := NewAuditLog(AuditConfig{Path: "/dev/full"})
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+
+	const entries = 5 * auditQueueSize
+	start := time.Now()
+	for i := 0; i < entries; i++ {
+		audit.Record(AuditEntry{Operation: "/disk add", VolumeID: "pvc-abc"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Record blocked for %v on a full disk", elapsed)
+	}
+
+	// Close returns once the writer has given up on every queued entry
+	_ = audit.Close()
+	if audit.Dropped() != entries {
+		t.Errorf("Dropped() = %d, want %d", audit.Dropped(), entries)
+	}
+
+	// Recording after Close is a no-op rather than a panic
+	audit.Record(AuditEntry{Operation: "/disk add"})
+}
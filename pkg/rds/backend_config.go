@@ -0,0 +1,135 @@
+package rds
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// backendNamePattern allows DNS-label style names, which are safe in StorageClass
+// parameters and VolumeContext values
+var backendNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// BackendsFile is the format of the backends config file (YAML or JSON)
+//
+// Example:
+//
+//	backends:
+//	  array2:
+//	    address: 10.42.241.4
+//	    user: metal-csi
+//	    privateKeyFile: /etc/rds-csi/backends/array2/id_rsa
+//	    hostKeyFile: /etc/rds-csi/backends/array2/host-key
+//	    nvmeAddress: 10.42.68.2
+type BackendsFile struct {
+	Backends map[string]BackendConfig `json:"backends"`
+}
+
+// BackendConfig configures one additional RDS backend
+type BackendConfig struct {
+	Address             string   `json:"address"`
+	Port                int      `json:"port,omitempty"`
+	User                string   `json:"user,omitempty"`
+	PrivateKeyFile      string   `json:"privateKeyFile"`
+	HostKeyFile         string   `json:"hostKeyFile,omitempty"`
+	HostKeyFingerprints []string `json:"hostKeyFingerprints,omitempty"`
+	InsecureSkipVerify  bool     `json:"insecureSkipVerify,omitempty"`
+
+	// NVMEAddress is the NVMe/TCP target address for nodes (default: Address)
+	NVMEAddress string `json:"nvmeAddress,omitempty"`
+}
+
+// ValidateBackendName checks a backend name is usable in StorageClass parameters.
+// "default" is reserved for the backend configured by --rds-address.
+func ValidateBackendName(name string) error {
+	if name == DefaultBackendName {
+		return fmt.Errorf("backend name %q is reserved for the --rds-address backend", name)
+	}
+	if !backendNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid backend name %q: must be lowercase alphanumeric or '-', at most 63 characters", name)
+	}
+	return nil
+}
+
+// LoadBackendsConfig reads and validates a backends config file
+func LoadBackendsConfig(path string) (map[string]BackendConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backends config: %w", err)
+	}
+
+	var file BackendsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse backends config %s: %w", path, err)
+	}
+
+	for name, backend := range file.Backends {
+		if err := ValidateBackendName(name); err != nil {
+			return nil, err
+		}
+		if err := backend.validate(); err != nil {
+			return nil, fmt.Errorf("backend %s: %w", name, err)
+		}
+	}
+	return file.Backends, nil
+}
+
+func (c BackendConfig) validate() error {
+	if err := utils.ValidateIPAddress(c.Address); err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+	if c.NVMEAddress != "" {
+		if err := utils.ValidateIPAddress(c.NVMEAddress); err != nil {
+			return fmt.Errorf("invalid nvmeAddress: %w", err)
+		}
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	if c.PrivateKeyFile == "" {
+		return fmt.Errorf("privateKeyFile is required")
+	}
+	if c.HostKeyFile == "" && len(c.HostKeyFingerprints) == 0 && !c.InsecureSkipVerify {
+		return fmt.Errorf("hostKeyFile or hostKeyFingerprints is required (or insecureSkipVerify for testing)")
+	}
+	return nil
+}
+
+// ClientConfig reads the key files and returns the SSH client configuration
+func (c BackendConfig) ClientConfig() (ClientConfig, error) {
+	privateKey, err := os.ReadFile(c.PrivateKeyFile)
+	if err != nil {
+		return ClientConfig{}, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+
+	var hostKey []byte
+	if c.HostKeyFile != "" {
+		hostKey, err = os.ReadFile(c.HostKeyFile)
+		if err != nil {
+			return ClientConfig{}, fmt.Errorf("failed to read SSH host key: %w", err)
+		}
+	}
+
+	port := c.Port
+	if port == 0 {
+		port = 22
+	}
+	user := c.User
+	if user == "" {
+		user = "admin"
+	}
+
+	return ClientConfig{
+		Address:             c.Address,
+		Port:                port,
+		User:                user,
+		PrivateKey:          privateKey,
+		HostKey:             hostKey,
+		HostKeyFingerprints: c.HostKeyFingerprints,
+		InsecureSkipVerify:  c.InsecureSkipVerify,
+	}, nil
+}
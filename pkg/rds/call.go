@@ -0,0 +1,35 @@
+package rds
+
+// Call identifies the CSI call RDS commands are issued for. Audit log entries of the
+// commands carry it, so they are attributed to the call rather than guessed from the
+// command text.
+type Call struct {
+	Operation string // CSI method, e.g. "CreateVolume"
+	VolumeID  string // Volume or snapshot the call works on
+	RequestID string // Correlation ID of the call (x-csi-request-id)
+}
+
+// callScoped is implemented by clients that can attribute their commands to a call
+type callScoped interface {
+	withCall(call Call) RDSClient
+	unscoped() RDSClient
+}
+
+// ForCall returns a view of client whose commands are attributed to call. The view shares
+// the connection of client and must not be closed. Clients that cannot attribute commands
+// are returned unchanged.
+func ForCall(client RDSClient, call Call) RDSClient {
+	if scoped, ok := client.(callScoped); ok {
+		return scoped.withCall(call)
+	}
+	return client
+}
+
+// Unscoped returns the client a ForCall view was made from, or client itself. Work that
+// serves several calls at once, such as a batch of deletions, runs on it.
+func Unscoped(client RDSClient) RDSClient {
+	if scoped, ok := client.(callScoped); ok {
+		return scoped.unscoped()
+	}
+	return client
+}
@@ -3,6 +3,8 @@ package rds
 import (
 	"fmt"
 	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 )
 
 // RDSClient defines the interface for interacting with MikroTik RDS servers
@@ -16,14 +18,24 @@ type RDSClient interface {
 	// Volume operations
 	CreateVolume(opts CreateVolumeOptions) error
 	DeleteVolume(slot string) error
+	// DeleteVolumes deletes several volumes in one round of commands and returns the outcome of each slot
+	DeleteVolumes(slots []string) map[string]error
+	// TrashVolume removes the disk slot but moves the backing file into .trash/ instead of deleting it
+	TrashVolume(slot string) (string, error)
 	ResizeVolume(slot string, newSizeBytes int64) error
 	GetVolume(slot string) (*VolumeInfo, error)
+	// GetVolumeByFilePath retrieves the volume backed by filePath, whatever its slot is named
+	GetVolumeByFilePath(filePath string) (*VolumeInfo, error)
 	VerifyVolumeExists(slot string) error
 	ListVolumes() ([]VolumeInfo, error)
+	// SetDiskComment replaces the comment on a volume or snapshot disk entry
+	SetDiskComment(slot, comment string) error
 
 	// File operations
 	ListFiles(path string) ([]FileInfo, error)
 	DeleteFile(path string) error
+	// FindUnreferencedFiles lists volume and snapshot backing files under basePath that no disk entry uses
+	FindUnreferencedFiles(basePath string) ([]FileInfo, error)
 
 	// Capacity queries
 	GetCapacity(basePath string) (*CapacityInfo, error)
@@ -36,13 +48,31 @@ type RDSClient interface {
 	DeleteSnapshot(snapshotID string) error
 	GetSnapshot(snapshotID string) (*SnapshotInfo, error)
 	ListSnapshots() ([]SnapshotInfo, error)
+	// ListSnapshotsBySource lists the snapshots of one source volume, filtered on RDS where supported
+	ListSnapshotsBySource(sourceVolume string) ([]SnapshotInfo, error)
+	// VerifySnapshot checks on RDS that the snapshot's backing file exists with sizeBytes
+	VerifySnapshot(snapshotID string, sizeBytes int64) error
 	RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolumeOptions) error
 
 	// Monitoring operations
 	GetDiskMetrics(slot string) (*DiskMetrics, error)
 	GetHardwareHealth(snmpHost string, snmpCommunity string) (*HardwareHealthMetrics, error)
+	GetNVMeSessions() (map[string]int, error)
+	// GetSystemInfo returns the RouterOS version and board details
+	GetSystemInfo() (*SystemInfo, error)
 }
 
+// DefaultCommandTimeout is how long a RouterOS command may run by default before the
+// client gives up on it
+const DefaultCommandTimeout = time.Minute
+
+// DefaultLongCommandTimeout is how long a /disk add creating a volume or copying a disk
+// may run by default. RouterOS allocates or copies the whole file before it returns.
+const DefaultLongCommandTimeout = 30 * time.Minute
+
+// NoTimeout as CommandTimeout or LongCommandTimeout lets commands run without a limit
+const NoTimeout time.Duration = -1
+
 // ClientConfig holds configuration for creating an RDS client
 type ClientConfig struct {
 	Protocol   string        // Protocol to use: "ssh" (default), "api" (future)
@@ -54,10 +84,48 @@ type ClientConfig struct {
 	Timeout    time.Duration // Connection timeout (default 10s)
 	UseTLS     bool          // Use TLS for API protocol (future)
 
+	// CommandTimeout limits each RouterOS command (default DefaultCommandTimeout, NoTimeout
+	// for no limit)
+	CommandTimeout time.Duration
+
+	// LongCommandTimeout limits the /disk add creating a volume and the copy-from of
+	// snapshots and restores (default DefaultLongCommandTimeout, NoTimeout for no limit).
+	// CreateVolumeOptions.CommandTimeout overrides it for one volume.
+	LongCommandTimeout time.Duration
+
+	// VolumeReadyTimeout is how long CreateVolume polls for a new disk to report ready (default 30s)
+	VolumeReadyTimeout time.Duration
+
+	// AuditLog records the commands the client runs (optional, may be shared between clients)
+	AuditLog *AuditLog
+
+	// LogRawIO logs every command and its output at V(5), with secret values redacted.
+	// Off by default so raising verbosity alone never dumps RouterOS output into logs.
+	LogRawIO bool
+
+	// Metrics counts failed commands by error class and retries (optional)
+	Metrics *observability.Metrics
+
+	// RetryBudget caps the command retries of all operations (optional, may be shared
+	// between clients)
+	RetryBudget *RetryBudget
+
+	// MaxSessions limits the commands running at once on the connection, shared fairly
+	// between provisioning, deletion, query and monitoring commands (0 for no limit)
+	MaxSessions int
+
+	// Executor runs the RouterOS commands instead of SSH (optional). The SSH connection and
+	// host key options are then unused.
+	Executor CommandExecutor
+
 	// SSH Security Options
-	HostKey            []byte      // SSH host public key for verification (required for production)
-	HostKeyCallback    interface{} // ssh.HostKeyCallback - custom host key verification (for SSH)
-	InsecureSkipVerify bool        // Skip host key verification (INSECURE - for testing only)
+	HostKey             []byte      // SSH host public key(s) for verification, one per line (required for production)
+	HostKeyFingerprints []string    // Accepted SHA256 host key fingerprints (alternative or addition to HostKey)
+	HostKeyCallback     interface{} // ssh.HostKeyCallback - custom host key verification (for SSH)
+	InsecureSkipVerify  bool        // Skip host key verification (INSECURE - for testing only)
+
+	// Algorithms restricts the ciphers, key exchanges and MACs offered in the handshake (optional)
+	Algorithms SSHAlgorithms
 }
 
 // NewClient creates a new RDS client based on the configuration
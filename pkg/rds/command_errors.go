@@ -0,0 +1,64 @@
+package rds
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// Error classes of failed RouterOS commands, the error_class label of
+// rds_csi_rds_command_errors_total
+const (
+	CommandErrorAuthentication   = "authentication_failed" // SSH login rejected
+	CommandErrorConnection       = "connection_failed"     // Not connected, session or transport failure
+	CommandErrorTimeout          = "timeout"               // Network or operation timeout
+	CommandErrorNotEnoughSpace   = "not_enough_space"      // RouterOS "not enough space"
+	CommandErrorNoSuchItem       = "no_such_item"          // RouterOS "no such item"
+	CommandErrorAlreadyExists    = "already_exists"        // RouterOS "already exists" / "already have"
+	CommandErrorInvalidParameter = "invalid_parameter"     // RouterOS "invalid parameter" / "invalid value"
+	CommandErrorOther            = "other"                 // Any other RouterOS failure
+)
+
+// classifyCommandError returns the error class of a failed command: transport errors by
+// their typed sentinel, RouterOS failures by their failure text
+func classifyCommandError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, utils.ErrAuthenticationFailed):
+		return CommandErrorAuthentication
+	case errors.Is(err, utils.ErrOperationTimeout), errors.As(err, &netErr) && netErr.Timeout():
+		return CommandErrorTimeout
+	case errors.Is(err, utils.ErrConnectionFailed), errors.Is(err, io.EOF):
+		return CommandErrorConnection
+	}
+
+	errStr := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(errStr, "not enough space"):
+		return CommandErrorNotEnoughSpace
+	case strings.Contains(errStr, "no such item"):
+		return CommandErrorNoSuchItem
+	case isAlreadyExistsError(err):
+		return CommandErrorAlreadyExists
+	case strings.Contains(errStr, "invalid parameter"), strings.Contains(errStr, "invalid value"):
+		return CommandErrorInvalidParameter
+	}
+	return CommandErrorOther
+}
+
+// commandLabel returns the menu and verb of a command (e.g. "/disk add"), the bounded
+// command label of the command metrics
+func commandLabel(command string) string {
+	menu, verb := splitCommand(command)
+	return strings.TrimSpace(menu + " " + verb)
+}
+
+// recordCommandError counts a failed command in rds_csi_rds_command_errors_total
+func (c *sshClient) recordCommandError(command string, err error) {
+	if c.metrics != nil {
+		c.metrics.RecordRDSCommandError(commandLabel(command), classifyCommandError(err))
+	}
+}
@@ -0,0 +1,55 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyCommandError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed to connect to 10.42.68.1:22: %w: %w", utils.ErrAuthenticationFailed, errors.New("unable to authenticate")), CommandErrorAuthentication},
+		{fmt.Errorf("not connected to RDS: %w", utils.ErrConnectionFailed), CommandErrorConnection},
+		{fmt.Errorf("failed to run command: %w", io.EOF), CommandErrorConnection},
+		{fmt.Errorf("failed to run command: %w", timeoutError{}), CommandErrorTimeout},
+		{fmt.Errorf("%w: volume not ready", utils.ErrOperationTimeout), CommandErrorTimeout},
+		{errors.New("command failed (exit 1): failure: not enough space"), CommandErrorNotEnoughSpace},
+		{errors.New("command failed (exit 1): failure: no such item"), CommandErrorNoSuchItem},
+		{errors.New("command failed (exit 1): failure: item with such name already exists"), CommandErrorAlreadyExists},
+		{errors.New("command failed (exit 1): failure: already have device with such slot"), CommandErrorAlreadyExists},
+		{errors.New("command failed (exit 1): invalid value for argument file-size"), CommandErrorInvalidParameter},
+		{errors.New("command failed (exit 1): failure: execution error"), CommandErrorOther},
+	}
+
+	for _, tt := range tests {
+		if got := classifyCommandError(tt.err); got != tt.want {
+			t.Errorf("classifyCommandError(%q) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCommandLabel(t *testing.T) {
+	tests := map[string]string{
+		`/disk add type=file file-path=/storage-pool/a.img slot=pvc-a`: "/disk add",
+		`/disk remove [find slot=pvc-a]`:                               "/disk remove",
+		`/disk print detail where slot=pvc-a`:                          "/disk print",
+		`/file remove [find name="storage-pool/a.img"]`:                "/file remove",
+	}
+	for command, want := range tests {
+		if got := commandLabel(command); got != want {
+			t.Errorf("commandLabel(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package rds
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"k8s.io/klog/v2"
 
@@ -19,36 +22,295 @@ func (c *sshClient) CreateVolume(opts CreateVolumeOptions) error {
 	if err := validateCreateVolumeOptions(opts); err != nil {
 		return fmt.Errorf("invalid volume options: %w", err)
 	}
+	defer c.scheduler.beginOperation(opts.Slot, classProvisioning)()
+	defer c.lockSlot(opts.Slot)()
 
-	// Convert size to human-readable format (e.g., "50G", "100G")
-	sizeStr := formatBytes(opts.FileSizeBytes)
+	// IO limits are only accepted by newer RouterOS releases; fail before touching RDS
+	if opts.QoS.IsSet() {
+		if err := c.checkVolumeQoSSupported(); err != nil {
+			return err
+		}
+	}
+
+	// Convert size to human-readable format (e.g., "50G", "100G").
+	// Without file-size, RouterOS exports an existing file as it is.
+	sizeArg := " file-size=" + formatBytes(opts.FileSizeBytes)
+	if opts.AdoptExistingFile {
+		sizeArg = ""
+	}
 
 	// Build /disk add command
 	cmd := fmt.Sprintf(
-		`/disk add type=file file-path=%s file-size=%s slot=%s nvme-tcp-export=yes nvme-tcp-server-port=%d nvme-tcp-server-nqn=%s`,
+		`/disk add type=file file-path=%s%s slot=%s nvme-tcp-export=yes nvme-tcp-server-port=%d nvme-tcp-server-nqn=%s%s%s`,
 		opts.FilePath,
-		sizeStr,
+		sizeArg,
 		opts.Slot,
 		opts.NVMETCPPort,
 		opts.NVMETCPNQN,
+		commentArg(opts.Comment),
+		qosArgs(opts.QoS),
 	)
 
-	// Execute command with retry
-	_, err := c.runCommandWithRetry(cmd, 3)
-	if err != nil {
+	// Execute command with retry. If an earlier attempt reached RDS before its response
+	// was lost, the retry fails with "already exists"; that is fine as long as the
+	// existing volume is the one we asked for.
+	verify := c.volumeCreated(opts)
+	inferred := false
+	_, err := c.runCommandWithRetryTimeout(cmd, 3, c.createTimeout(opts), func() (bool, error) {
+		exists, err := verify()
+		inferred = exists
+		return exists, err
+	})
+	if errors.Is(err, utils.ErrOperationTimeout) {
+		// RouterOS keeps running a command the client gave up on, so look at the slot
+		// before failing: the disk may have been created after all
+		if exists, lookupErr := c.slotExists(opts.Slot); lookupErr == nil && exists {
+			c.logger().Info("Creating volume timed out but the slot exists, checking it", "slot", opts.Slot, "err", err)
+			err, inferred = nil, true
+		}
+	}
+	alreadyExists := err != nil && isAlreadyExistsError(err)
+	if err != nil && !alreadyExists {
+		// After a timeout or lost connection the disk may exist, possibly created by an
+		// earlier call and in use, so only clean up after RouterOS rejected the command
+		if isCommandRejected(err) {
+			c.removeFailedVolume(opts)
+		}
 		return fmt.Errorf("failed to create volume: %w", err)
 	}
+	// The slot is known to be ours only if RouterOS confirmed our /disk add; one found
+	// after a lost connection may predate this call
+	created := err == nil && !inferred
 
-	// Verify volume was created
-	if err := c.VerifyVolumeExists(opts.Slot); err != nil {
+	// RouterOS creates the disk asynchronously: right after /disk add the entry can be
+	// missing or still formatting, so poll until it is ready
+	volume, err := c.waitForVolumeReady(opts.Slot)
+	if err != nil {
+		// Only remove a slot this call created; an existing one may be in use
+		if created {
+			c.removeFailedVolume(opts)
+		}
 		return fmt.Errorf("volume creation verification failed: %w", err)
 	}
 
-	klog.V(2).Infof("Created volume %s", opts.Slot)
-	klog.V(4).Infof("Created volume %s (path=%s, size=%d, nqn=%s)", opts.Slot, opts.FilePath, opts.FileSizeBytes, opts.NVMETCPNQN)
+	if alreadyExists || inferred {
+		if err := checkVolumeMatches(volume, opts); err != nil {
+			return err
+		}
+		c.logger().V(2).Info("Volume already existed with matching parameters", "slot", opts.Slot)
+		return nil
+	}
+
+	c.logger().V(2).Info("Created volume", "slot", opts.Slot, "filePath", opts.FilePath, "sizeBytes", opts.FileSizeBytes, "nqn", opts.NVMETCPNQN)
+	return nil
+}
+
+// createTimeout returns the limit of the /disk add creating the volume of opts
+func (c *sshClient) createTimeout(opts CreateVolumeOptions) time.Duration {
+	if opts.CommandTimeout > 0 {
+		c.logger().V(4).Info("Creating volume with its own command timeout", "slot", opts.Slot, "timeout", opts.CommandTimeout)
+		return opts.CommandTimeout
+	}
+	return c.longCommandTimeout
+}
+
+// lockSlot holds the creation lock of slot until the returned function is called, so that
+// a failed CreateVolume never removes a disk a concurrent one of the same slot created
+func (c *sshClient) lockSlot(slot string) func() {
+	if c.slotLocks == nil {
+		return func() {}
+	}
+	c.slotLocks.Lock(slot)
+	return func() { c.slotLocks.Unlock(slot) }
+}
+
+// isCommandRejected reports whether err is RouterOS refusing a command it received, as
+// opposed to a failure that leaves open whether the command ran or was ever sent
+func isCommandRejected(err error) bool {
+	for _, transport := range []error{
+		ErrCommandNotSent, ErrCommandInterrupted, ErrPoolExhausted, ErrRetryBudgetExhausted,
+		utils.ErrOperationTimeout, utils.ErrConnectionFailed, utils.ErrAuthenticationFailed,
+	} {
+		if errors.Is(err, transport) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeFailedVolume removes what a failed CreateVolume left on RDS: the disk slot and,
+// unless it was adopted, the backing file. A retry under a different name would otherwise
+// leak them until the orphan reconciler runs. It must only be called with the slot lock
+// held and after RouterOS confirmed the outcome of this call's /disk add. Failures are
+// only logged.
+func (c *sshClient) removeFailedVolume(opts CreateVolumeOptions) {
+	c.logger().V(2).Info("Removing volume after failed create", "slot", opts.Slot)
+
+	cmd := fmt.Sprintf(`/disk remove [find slot=%s]`, opts.Slot)
+	if _, err := c.runCommandWithRetry(cmd, 3, c.slotRemoved(opts.Slot)); err != nil && !strings.Contains(err.Error(), "no such item") {
+		c.logger().Info("Failed to remove disk slot of failed volume", "slot", opts.Slot, "err", err)
+		return
+	}
+
+	if opts.AdoptExistingFile {
+		return
+	}
+	if err := c.DeleteFile(opts.FilePath); err != nil {
+		c.logger().Info("Failed to delete backing file of failed volume", "slot", opts.Slot, "filePath", opts.FilePath, "err", err)
+	}
+}
+
+// volumeCreated returns the verifier of a /disk add creating the volume of opts: it took
+// effect if the slot holds a disk with the size, path and NQN opts asks for. A disk that
+// differs is someone else's, and the retry fails with "already exists".
+func (c *sshClient) volumeCreated(opts CreateVolumeOptions) commandVerifier {
+	return func() (bool, error) {
+		volume, err := c.GetVolume(opts.Slot)
+		if errors.Is(err, utils.ErrVolumeNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := checkVolumeMatches(volume, opts); err != nil {
+			c.logger().Info("Slot exists but was not created by this call", "slot", opts.Slot, "err", err)
+			return false, nil
+		}
+		return true, nil
+	}
+}
+
+// snapshotCreated returns the verifier of a /disk add copy-from creating snapshot name: it
+// took effect if the slot holds a disk with filePath and the size of the source volume
+func (c *sshClient) snapshotCreated(name, filePath string, sizeBytes int64) commandVerifier {
+	return func() (bool, error) {
+		snapshot, err := c.GetSnapshot(name)
+		var notFoundErr *SnapshotNotFoundError
+		if errors.As(err, &notFoundErr) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if snapshot.FilePath != "" && snapshot.FilePath != filePath ||
+			snapshot.FileSizeBytes != 0 && snapshot.FileSizeBytes != sizeBytes {
+			c.logger().Info("Slot exists but was not created by this call", "slot", name,
+				"filePath", snapshot.FilePath, "sizeBytes", snapshot.FileSizeBytes, "requestedFilePath", filePath, "requestedSizeBytes", sizeBytes)
+			return false, nil
+		}
+		return true, nil
+	}
+}
+
+// slotRemoved returns the verifier of a /disk remove of slot: it took effect if the slot
+// is gone
+func (c *sshClient) slotRemoved(slot string) commandVerifier {
+	return func() (bool, error) {
+		exists, err := c.slotExists(slot)
+		return !exists, err
+	}
+}
+
+// slotExists reports whether a disk, volume or snapshot, has slot on RDS
+func (c *sshClient) slotExists(slot string) (bool, error) {
+	output, err := c.runCommand(fmt.Sprintf(`/disk print detail where slot=%s`, slot))
+	if err != nil {
+		return false, fmt.Errorf("failed to look up slot %s: %w", slot, err)
+	}
+	return strings.TrimSpace(normalizeRouterOSOutput(output)) != "", nil
+}
+
+const (
+	// defaultVolumeReadyTimeout is how long CreateVolume waits for a new disk to become ready
+	defaultVolumeReadyTimeout = 30 * time.Second
+
+	// Polling interval bounds while waiting for a new disk; slower firmware needs a few seconds
+	volumeReadyInitialPoll = 100 * time.Millisecond
+	volumeReadyMaxPoll     = 2 * time.Second
+)
+
+// waitForVolumeReady polls a newly created volume with backoff until it reports ready.
+// A missing entry or a non-ready status is retried until volumeReadyTimeout passes, which
+// returns utils.ErrOperationTimeout. Other query errors are returned immediately.
+func (c *sshClient) waitForVolumeReady(slot string) (*VolumeInfo, error) {
+	deadline := time.Now().Add(c.volumeReadyTimeout)
+	poll := volumeReadyInitialPoll
+	lastState := "not found"
+
+	for attempt := 1; ; attempt++ {
+		volume, err := c.GetVolume(slot)
+		switch {
+		case err == nil && volume.Status == "ready":
+			if attempt > 1 {
+				c.logger().V(4).Info("Volume ready", "slot", slot, "checks", attempt)
+			}
+			return volume, nil
+		case err == nil:
+			lastState = "status " + volume.Status
+		case errors.Is(err, utils.ErrVolumeNotFound):
+			lastState = "not found"
+		default:
+			return nil, err
+		}
+
+		if time.Now().Add(poll).After(deadline) {
+			return nil, fmt.Errorf("%w: volume %s not ready after %v (%s)",
+				utils.ErrOperationTimeout, slot, c.volumeReadyTimeout, lastState)
+		}
+		c.logger().V(4).Info("Volume not ready yet, checking again", "slot", slot, "state", lastState, "after", poll)
+		time.Sleep(poll)
+		poll = min(poll*2, volumeReadyMaxPoll)
+	}
+}
+
+// checkVolumeMatches verifies that an existing volume has the size, path and NQN that
+// opts asks for. A mismatch means a different volume owns the slot and is reported
+// as utils.ErrVolumeExists.
+func checkVolumeMatches(volume *VolumeInfo, opts CreateVolumeOptions) error {
+	// RouterOS stores the size formatBytes sent, which may be truncated to its unit
+	wantSize := opts.FileSizeBytes / FileSizeGranularity(opts.FileSizeBytes) * FileSizeGranularity(opts.FileSizeBytes)
+
+	var conflicts []string
+	if volume.FileSizeBytes != wantSize {
+		conflicts = append(conflicts, fmt.Sprintf("size %d (requested %d)", volume.FileSizeBytes, wantSize))
+	}
+	if volume.FilePath != "" && volume.FilePath != opts.FilePath {
+		conflicts = append(conflicts, fmt.Sprintf("file path %s (requested %s)", volume.FilePath, opts.FilePath))
+	}
+	if volume.NVMETCPNQN != "" && volume.NVMETCPNQN != opts.NVMETCPNQN {
+		conflicts = append(conflicts, fmt.Sprintf("NQN %s (requested %s)", volume.NVMETCPNQN, opts.NVMETCPNQN))
+	}
+	if volume.QoS != opts.QoS {
+		conflicts = append(conflicts, fmt.Sprintf("IO limits %+v (requested %+v)", volume.QoS, opts.QoS))
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s has %s", utils.ErrVolumeExists, opts.Slot, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// ErrQoSUnsupported indicates the RouterOS version on the RDS cannot enforce per-volume IO limits
+var ErrQoSUnsupported = errors.New("volume IO limits not supported by RouterOS version")
+
+// checkVolumeQoSSupported returns ErrQoSUnsupported unless RDS runs RouterOS VolumeQoSMinVersion or later.
+// The version is queried each time, so a firmware upgrade takes effect without restarting the driver.
+func (c *sshClient) checkVolumeQoSSupported() error {
+	info, err := c.GetSystemInfo()
+	if err != nil {
+		return fmt.Errorf("failed to determine RouterOS version for IO limits: %w", err)
+	}
+	if !SupportsVolumeQoS(info.Version) {
+		return fmt.Errorf("%w: RDS runs RouterOS %s, IO limits need %s or later", ErrQoSUnsupported, info.Version, VolumeQoSMinVersion)
+	}
 	return nil
 }
 
+// isAlreadyExistsError reports whether a RouterOS command failed because the item exists
+func isAlreadyExistsError(err error) bool {
+	errStr := strings.ToLower(err.Error())
+	return strings.Contains(errStr, "already exists") || strings.Contains(errStr, "already have")
+}
+
 // ResizeVolume resizes an existing volume on RDS
 func (c *sshClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	// Validate slot name
@@ -60,6 +322,7 @@ func (c *sshClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	if newSizeBytes <= 0 {
 		return fmt.Errorf("new size must be positive")
 	}
+	defer c.scheduler.beginOperation(slot, classProvisioning)()
 
 	// Get current volume to check it exists and get current size
 	currentVolume, err := c.GetVolume(slot)
@@ -75,7 +338,7 @@ func (c *sshClient) ResizeVolume(slot string, newSizeBytes int64) error {
 
 	// If size is the same, nothing to do
 	if newSizeBytes == currentVolume.FileSizeBytes {
-		klog.V(4).Infof("Volume %s is already at requested size, skipping resize", slot)
+		c.logger().V(4).Info("Volume is already at requested size, skipping resize", "slot", slot)
 		return nil
 	}
 
@@ -86,7 +349,7 @@ func (c *sshClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	cmd := fmt.Sprintf(`/disk set [find slot=%s] file-size=%s`, slot, sizeStr)
 
 	// Execute command with retry
-	_, err = c.runCommandWithRetry(cmd, 3)
+	_, err = c.runCommandWithRetry(cmd, 3, idempotentCommand)
 	if err != nil {
 		return fmt.Errorf("failed to resize volume: %w", err)
 	}
@@ -97,7 +360,28 @@ func (c *sshClient) ResizeVolume(slot string, newSizeBytes int64) error {
 		return fmt.Errorf("failed to verify resize: %w", err)
 	}
 
-	klog.V(2).Infof("Resized volume %s (%d -> %d bytes)", slot, currentVolume.FileSizeBytes, updatedVolume.FileSizeBytes)
+	c.logger().V(2).Info("Resized volume", "slot", slot, "fromBytes", currentVolume.FileSizeBytes, "toBytes", updatedVolume.FileSizeBytes)
+	return nil
+}
+
+// SetDiskComment replaces the comment on a volume or snapshot disk entry.
+// An empty comment clears it.
+func (c *sshClient) SetDiskComment(slot, comment string) error {
+	if err := validateSlotName(slot); err != nil {
+		return err
+	}
+
+	// SECURITY: comments carry user-controlled strings (PVC names)
+	if err := utils.ValidateDiskComment(comment); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf(`/disk set [find slot=%s] comment="%s"`, slot, comment)
+	if _, err := c.runCommandWithRetry(cmd, 3, idempotentCommand); err != nil {
+		return fmt.Errorf("failed to set comment on %s: %w", slot, err)
+	}
+
+	c.logger().V(4).Info("Set disk comment", "slot", slot, "comment", comment)
 	return nil
 }
 
@@ -107,52 +391,207 @@ func (c *sshClient) DeleteVolume(slot string) error {
 	if err := validateSlotName(slot); err != nil {
 		return err
 	}
+	defer c.scheduler.beginOperation(slot, classDeletion)()
 
 	// Get volume info first to find the backing file path
 	volume, err := c.GetVolume(slot)
 	if err != nil {
 		// If volume doesn't exist, that's okay (idempotent)
 		if errors.Is(err, utils.ErrVolumeNotFound) {
-			klog.V(4).Infof("Volume %s already deleted", slot)
+			c.logger().V(4).Info("Volume already deleted", "slot", slot)
 			return nil
 		}
 		return fmt.Errorf("failed to get volume info before deletion: %w", err)
 	}
 
 	filePath := volume.FilePath
-	klog.V(4).Infof("Volume %s has backing file: %s", slot, filePath)
+	c.logger().V(4).Info("Volume has backing file", "slot", slot, "filePath", filePath)
 
 	// Step 1: Remove the disk slot
 	cmd := fmt.Sprintf(`/disk remove [find slot=%s]`, slot)
-	_, err = c.runCommandWithRetry(cmd, 3)
+	_, err = c.runCommandWithRetry(cmd, 3, c.slotRemoved(slot))
 	if err != nil {
 		// If volume doesn't exist, that's okay (idempotent)
 		if strings.Contains(err.Error(), "no such item") {
-			klog.V(4).Infof("Volume %s disk slot does not exist, continuing to file cleanup", slot)
+			c.logger().V(4).Info("Volume disk slot does not exist, continuing to file cleanup", "slot", slot)
 		} else {
 			return fmt.Errorf("failed to remove disk slot: %w", err)
 		}
 	}
-	klog.V(4).Infof("Successfully removed disk slot for volume %s", slot)
+	c.logger().V(4).Info("Removed disk slot of volume", "slot", slot)
 
 	// Step 2: Delete the backing file
 	if filePath != "" {
 		if err := c.DeleteFile(filePath); err != nil {
 			// Log but don't fail - the disk slot is already removed
 			// The orphan reconciler can clean up the file later if needed
-			klog.Warningf("Failed to delete backing file %s for volume %s: %v", filePath, slot, err)
+			c.logger().Info("Failed to delete backing file of volume", "slot", slot, "filePath", filePath, "err", err)
+		} else {
+			c.logger().V(4).Info("Deleted backing file of volume", "slot", slot, "filePath", filePath)
+		}
+	}
+
+	c.logger().V(2).Info("Deleted volume", "slot", slot)
+	return nil
+}
+
+// DeleteVolumes deletes several volumes with one listing, one removal script and one file
+// removal, instead of the three commands per volume DeleteVolume runs. The result holds the
+// outcome of every requested slot: nil when the volume was deleted or did not exist.
+func (c *sshClient) DeleteVolumes(slots []string) map[string]error {
+	results := make(map[string]error, len(slots))
+	var valid []string
+	for _, slot := range slots {
+		if _, seen := results[slot]; seen {
+			continue
+		}
+		results[slot] = validateSlotName(slot)
+		if results[slot] == nil {
+			valid = append(valid, slot)
+		}
+	}
+	if len(valid) == 0 {
+		return results
+	}
+	for _, slot := range valid {
+		defer c.scheduler.beginOperation(slot, classDeletion)()
+	}
+
+	// Step 1: Find the backing files. Slots missing from the listing are already deleted.
+	cmd := fmt.Sprintf(`/disk print detail where %s`, slotFilter(valid))
+	output, err := c.runCommand(cmd)
+	if err != nil {
+		return failSlots(results, valid, fmt.Errorf("failed to get volume info before deletion: %w", err))
+	}
+	volumes, err := parseVolumeList(output)
+	if err != nil {
+		return failSlots(results, valid, fmt.Errorf("failed to parse volume info before deletion: %w", err))
+	}
+	filePaths := make(map[string]string, len(volumes))
+	for _, volume := range volumes {
+		filePaths[volume.Slot] = volume.FilePath
+	}
+	var existing []string
+	for _, slot := range valid {
+		if _, ok := filePaths[slot]; ok {
+			existing = append(existing, slot)
 		} else {
-			klog.V(4).Infof("Successfully deleted backing file %s for volume %s", filePath, slot)
+			c.logger().V(4).Info("Volume already deleted", "slot", slot)
+		}
+	}
+	if len(existing) == 0 {
+		return results
+	}
+
+	// Step 2: Remove the disk slots. Each removal runs in its own :do so one failing slot
+	// does not stop the others, and the script reports the outcome of every slot.
+	// Not retried once sent: a rerun reports slots the first run removed as failed.
+	output, err = c.runCommandWithRetry(batchDiskRemoveCommand(existing), 3, nil)
+	if err != nil {
+		return failSlots(results, existing, fmt.Errorf("failed to remove disk slots: %w", err))
+	}
+	removed, failed := parseBatchRemoveOutput(output)
+	var files []string
+	deleted := 0
+	for _, slot := range existing {
+		switch {
+		case removed[slot]:
+			deleted++
+			if filePaths[slot] != "" {
+				files = append(files, filePaths[slot])
+			}
+		case failed[slot]:
+			results[slot] = fmt.Errorf("failed to remove disk slot %s", slot)
+		default:
+			results[slot] = fmt.Errorf("failed to remove disk slot %s: RDS reported no outcome", slot)
+		}
+	}
+
+	// Step 3: Delete the backing files of the removed slots
+	if len(files) > 0 {
+		if err := c.deleteFiles(files); err != nil {
+			// Log but don't fail - the disk slots are already removed
+			// The orphan reconciler can clean up the files later if needed
+			c.logger().Info("Failed to delete backing files", "files", files, "err", err)
+		}
+	}
+
+	c.logger().V(2).Info("Deleted volumes in one batch", "deleted", deleted, "requested", len(slots), "alreadyGone", len(valid)-len(existing))
+	return results
+}
+
+// deleteFiles removes several files with one /file remove command
+func (c *sshClient) deleteFiles(paths []string) error {
+	conditions := make([]string, 0, len(paths))
+	for _, path := range paths {
+		// SECURITY: Validate path to prevent command injection
+		if err := utils.ValidateFilePath(path); err != nil {
+			return fmt.Errorf("invalid path: %w", err)
 		}
+		conditions = append(conditions, fmt.Sprintf(`name="%s"`, strings.TrimPrefix(path, "/")))
 	}
 
-	klog.V(2).Infof("Deleted volume %s", slot)
+	cmd := fmt.Sprintf(`/file remove [find where %s]`, strings.Join(conditions, " or "))
+	output, err := c.runCommand(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to delete files: %w", err)
+	}
+	if strings.Contains(strings.ToLower(output), "error") || strings.Contains(strings.ToLower(output), "failure") {
+		return fmt.Errorf("error deleting files: %s", output)
+	}
 	return nil
 }
 
+// slotFilter builds a where clause matching any of the given (validated) slots
+func slotFilter(slots []string) string {
+	conditions := make([]string, len(slots))
+	for i, slot := range slots {
+		conditions[i] = "slot=" + slot
+	}
+	return strings.Join(conditions, " or ")
+}
+
+// batchDiskRemoveCommand builds a script that removes each of the given (validated) slots
+// and prints "removed <slot>" or "failed <slot>" for each
+func batchDiskRemoveCommand(slots []string) string {
+	quoted := make([]string, len(slots))
+	for i, slot := range slots {
+		quoted[i] = `"` + slot + `"`
+	}
+	return fmt.Sprintf(`:foreach s in={%s} do={:do {/disk remove [find slot=$s]; :put "removed $s"} on-error={:put "failed $s"}}`,
+		strings.Join(quoted, ";"))
+}
+
+// parseBatchRemoveOutput parses the per-slot outcomes printed by batchDiskRemoveCommand
+func parseBatchRemoveOutput(output string) (removed, failed map[string]bool) {
+	removed = make(map[string]bool)
+	failed = make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "removed":
+			removed[fields[1]] = true
+		case "failed":
+			failed[fields[1]] = true
+		}
+	}
+	return removed, failed
+}
+
+// failSlots records err as the outcome of every given slot
+func failSlots(results map[string]error, slots []string, err error) map[string]error {
+	for _, slot := range slots {
+		results[slot] = err
+	}
+	return results
+}
+
 // GetVolume retrieves information about a specific volume
 func (c *sshClient) GetVolume(slot string) (*VolumeInfo, error) {
-	klog.V(4).Infof("Getting volume info for %s", slot)
+	c.logger().V(4).Info("Getting volume info", "slot", slot)
 
 	// Validate slot name
 	if err := validateSlotName(slot); err != nil {
@@ -188,6 +627,37 @@ func (c *sshClient) GetVolume(slot string) (*VolumeInfo, error) {
 	return volume, nil
 }
 
+// GetVolumeByFilePath retrieves the volume whose backing file is filePath. It finds a
+// volume whose slot was renamed on RDS after it was created.
+func (c *sshClient) GetVolumeByFilePath(filePath string) (*VolumeInfo, error) {
+	c.logger().V(4).Info("Getting volume info by backing file", "filePath", filePath)
+
+	// SECURITY: Validate path to prevent command injection
+	if err := utils.ValidateFilePath(filePath); err != nil {
+		return nil, fmt.Errorf("invalid file path: %w", err)
+	}
+
+	output, err := c.runCommand(fmt.Sprintf(`/disk print detail where file-path="%s"`, filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume info: %w", err)
+	}
+
+	normalized := normalizeRouterOSOutput(output)
+	if strings.TrimSpace(normalized) == "" {
+		return nil, utils.WrapVolumeError(utils.ErrVolumeNotFound, filePath, "")
+	}
+
+	volume, err := parseVolumeInfo(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse volume info: %w", err)
+	}
+	if volume.Slot == "" {
+		return nil, utils.WrapVolumeError(utils.ErrVolumeNotFound, filePath, "")
+	}
+
+	return volume, nil
+}
+
 // VerifyVolumeExists checks if a volume exists and is ready
 func (c *sshClient) VerifyVolumeExists(slot string) error {
 	volume, err := c.GetVolume(slot)
@@ -204,7 +674,7 @@ func (c *sshClient) VerifyVolumeExists(slot string) error {
 
 // GetCapacity queries the available storage capacity on RDS
 func (c *sshClient) GetCapacity(basePath string) (*CapacityInfo, error) {
-	klog.V(4).Infof("Getting capacity for %s", basePath)
+	c.logger().V(4).Info("Getting capacity", "basePath", basePath)
 
 	// SECURITY: Validate base path
 	if basePath != "" {
@@ -220,7 +690,7 @@ func (c *sshClient) GetCapacity(basePath string) (*CapacityInfo, error) {
 	//   /storage-pool/metal-csi/volumes → storage-pool
 	//   /nvme1/kubernetes → nvme1
 	mountPoint := extractMountPoint(basePath)
-	klog.V(4).Infof("Extracted mount point: %s", mountPoint)
+	c.logger().V(4).Info("Extracted mount point", "mountPoint", mountPoint)
 
 	// Query disk capacity using mount point
 	// Use /disk print to get filesystem capacity information
@@ -244,7 +714,7 @@ func (c *sshClient) GetCapacity(basePath string) (*CapacityInfo, error) {
 // ListVolumes lists all volumes on RDS
 // ONLY volumes that are pvc- prefixed are returned
 func (c *sshClient) ListVolumes() ([]VolumeInfo, error) {
-	klog.V(4).Info("Listing all volumes")
+	c.logger().V(4).Info("Listing all volumes")
 
 	// Build /disk print command
 	cmd := `/disk print detail where slot~"pvc"`
@@ -266,7 +736,7 @@ func (c *sshClient) ListVolumes() ([]VolumeInfo, error) {
 
 // ListFiles lists files in a directory on RDS
 func (c *sshClient) ListFiles(path string) ([]FileInfo, error) {
-	klog.V(4).Infof("Listing files in %s", path)
+	c.logger().V(4).Info("Listing files", "path", path)
 
 	// SECURITY: Validate path to prevent command injection
 	if err := utils.ValidateFilePath(path); err != nil {
@@ -296,7 +766,7 @@ func (c *sshClient) ListFiles(path string) ([]FileInfo, error) {
 
 // DeleteFile deletes a file on RDS
 func (c *sshClient) DeleteFile(path string) error {
-	klog.V(4).Infof("Deleting file: %s", path)
+	c.logger().V(4).Info("Deleting file", "path", path)
 
 	// SECURITY: Validate path to prevent command injection
 	if err := utils.ValidateFilePath(path); err != nil {
@@ -320,38 +790,61 @@ func (c *sshClient) DeleteFile(path string) error {
 		return fmt.Errorf("error deleting file: %s", output)
 	}
 
-	klog.V(4).Infof("Successfully deleted file: %s", path)
+	c.logger().V(4).Info("Deleted file", "path", path)
 	return nil
 }
 
+// diskPropertyPatterns match the properties of disk and file print output. They are compiled
+// once: ListVolumes parses every disk on the RDS, which can be thousands of entries.
+var diskPropertyPatterns = struct {
+	slotQuoted, slot, diskType                       *regexp.Regexp
+	filePathQuoted, filePath, filePathSplit          *regexp.Regexp
+	fileSize, rawSize, nvmeExport, nvmePort, nvmeNQN *regexp.Regexp
+	status                                           *regexp.Regexp
+}{
+	slotQuoted:     regexp.MustCompile(`slot="([^"]+)"`),
+	slot:           regexp.MustCompile(`slot=([^\s]+)`),
+	diskType:       regexp.MustCompile(`type="?([^"\s]+)"?`),
+	filePathQuoted: regexp.MustCompile(`file-path="([^"]+)"`),
+	filePath:       regexp.MustCompile(`file-path=(\S+\.img)`),
+	filePathSplit:  regexp.MustCompile(`file-path=(\S+\s+\S+\.img)`),
+	fileSize:       regexp.MustCompile(`file-size=([\d.]+)\s?` + sizeUnitPattern + `?(?:\s|$)`),
+	rawSize:        regexp.MustCompile(`(?:^|\s)size=(\d{1,3}(?: \d{3})+|\d+)(?:\s|$)`),
+	nvmeExport:     regexp.MustCompile(`nvme-tcp-export=(yes|no)`),
+	nvmePort:       regexp.MustCompile(`nvme-tcp-server-port=(\d+)`),
+	nvmeNQN:        regexp.MustCompile(`nvme-tcp-server-nqn="([^"]+)"`),
+	status:         regexp.MustCompile(`status="?([^"\s]+)"?`),
+}
+
 // parseVolumeInfo parses RouterOS disk print output for a single volume
 func parseVolumeInfo(output string) (*VolumeInfo, error) {
+	p := &diskPropertyPatterns
 	volume := &VolumeInfo{}
 
 	// Normalize multi-line output: join continuation lines (lines starting with spaces)
 	normalized := normalizeRouterOSOutput(output)
 
 	// Extract slot
-	if match := regexp.MustCompile(`slot="([^"]+)"`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.slotQuoted.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.Slot = match[1]
-	} else if match := regexp.MustCompile(`slot=([^\s]+)`).FindStringSubmatch(normalized); len(match) > 1 {
+	} else if match := p.slot.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.Slot = match[1]
 	}
 
 	// Extract type
-	if match := regexp.MustCompile(`type="?([^"\s]+)"?`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.diskType.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.Type = match[1]
 	}
 
 	// Extract file-path (can be quoted or span multiple lines after normalization)
 	// RouterOS output often splits long paths across lines, which normalizeRouterOSOutput
 	// joins with spaces. We need to handle: file-path=/path/to/pvc-xxx .img (with space)
-	if match := regexp.MustCompile(`file-path="([^"]+)"`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.filePathQuoted.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.FilePath = match[1]
-	} else if match := regexp.MustCompile(`file-path=(\S+\.img)`).FindStringSubmatch(normalized); len(match) > 1 {
+	} else if match := p.filePath.FindStringSubmatch(normalized); len(match) > 1 {
 		// Simple case: path doesn't have spaces
 		volume.FilePath = match[1]
-	} else if match := regexp.MustCompile(`file-path=(\S+\s+\S+\.img)`).FindStringSubmatch(normalized); len(match) > 1 {
+	} else if match := p.filePathSplit.FindStringSubmatch(normalized); len(match) > 1 {
 		// Path was split across lines and has a space after normalization
 		// Remove the space to reconstruct the original path
 		volume.FilePath = strings.ReplaceAll(match[1], " ", "")
@@ -361,43 +854,55 @@ func parseVolumeInfo(output string) (*VolumeInfo, error) {
 		volume.FilePath = "/" + volume.FilePath
 	}
 
-	// Extract file-size (human-readable format like "50.0GiB" or "1024.0MiB")
-	// This is more reliable than the raw size field with spaces
-	if match := regexp.MustCompile(`file-size=([\d.]+)\s*([KMGT]i?B)`).FindStringSubmatch(normalized); len(match) > 2 {
-		if bytes, err := parseSize(match[1], match[2]); err == nil {
-			volume.FileSizeBytes = bytes
+	// Extract the size: the raw size in bytes (digits grouped by spaces, e.g.
+	// "53 687 091 200") is exact, while file-size is rounded for display ("7.2TiB")
+	if match := p.rawSize.FindStringSubmatch(normalized); len(match) > 1 {
+		sizeStr := strings.ReplaceAll(match[1], " ", "")
+		if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil {
+			volume.FileSizeBytes = size
+		} else {
+			volume.addParseWarning("size=%s: %v", sizeStr, err)
 		}
-	} else {
-		// Fallback: try to parse raw size field (with spaces removed)
-		if match := regexp.MustCompile(`size=([\d\s]+)`).FindStringSubmatch(normalized); len(match) > 1 {
-			// Remove all spaces from the number
-			sizeStr := strings.ReplaceAll(match[1], " ", "")
-			if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil {
-				volume.FileSizeBytes = size
+	}
+	if volume.FileSizeBytes == 0 {
+		if match := p.fileSize.FindStringSubmatch(normalized); len(match) > 2 {
+			if bytes, err := parseSize(match[1], match[2]); err == nil {
+				volume.FileSizeBytes = bytes
+			} else {
+				volume.addParseWarning("file-size=%s%s: %v", match[1], match[2], err)
 			}
 		}
 	}
 
 	// Extract nvme-tcp-export
-	if match := regexp.MustCompile(`nvme-tcp-export=(yes|no)`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.nvmeExport.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.NVMETCPExport = match[1] == "yes"
 	}
 
 	// Extract nvme-tcp-server-port
-	if match := regexp.MustCompile(`nvme-tcp-server-port=(\d+)`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.nvmePort.FindStringSubmatch(normalized); len(match) > 1 {
 		if port, err := strconv.Atoi(match[1]); err == nil {
 			volume.NVMETCPPort = port
+		} else {
+			volume.addParseWarning("nvme-tcp-server-port=%s: %v", match[1], err)
 		}
 	}
 
 	// Extract nvme-tcp-server-nqn
-	if match := regexp.MustCompile(`nvme-tcp-server-nqn="([^"]+)"`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.nvmeNQN.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.NVMETCPNQN = match[1]
 	}
 
+	// Extract namespace identifier (only reported by some RouterOS versions)
+	volume.WWID = parseNamespaceWWID(normalized)
+
+	volume.Comment = parseDiskComment(output)
+
+	volume.QoS = parseVolumeQoS(normalized, volume)
+
 	// Extract status (if available)
 	// Note: Real RouterOS doesn't always provide a status field for file-backed disks
-	if match := regexp.MustCompile(`status="?([^"\s]+)"?`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.status.FindStringSubmatch(normalized); len(match) > 1 {
 		volume.Status = match[1]
 	} else {
 		// For file-backed volumes with nvme-tcp-export=yes, assume "ready"
@@ -411,12 +916,182 @@ func parseVolumeInfo(output string) (*VolumeInfo, error) {
 	return volume, nil
 }
 
+// addParseWarning records a disk property that was present but could not be parsed
+func (v *VolumeInfo) addParseWarning(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	klog.V(4).Infof("Disk %s: could not parse %s", v.Slot, warning)
+	v.ParseWarnings = append(v.ParseWarnings, warning)
+}
+
+// namespaceIDFields are the disk properties holding a namespace identifier, NGUID first
+var namespaceIDFields = []struct {
+	pattern *regexp.Regexp
+	hexLen  int
+}{
+	{regexp.MustCompile(`nguid="?([0-9A-Fa-f:-]+)"?`), 32},
+	{regexp.MustCompile(`eui64="?([0-9A-Fa-f:-]+)"?`), 16},
+}
+
+// namespaceIDSeparators strips the separators RouterOS may print inside identifiers
+var namespaceIDSeparators = strings.NewReplacer(":", "", "-", "")
+
+// parseNamespaceWWID extracts the NVMe namespace identifier from disk print output
+// and renders it the way the Linux kernel reports it in /sys/class/block/<dev>/wwid.
+// The kernel prefers the NGUID over the EUI-64, so this does too. All-zero values
+// mean "not assigned" and are ignored. Returns "" if neither field is present.
+func parseNamespaceWWID(normalized string) string {
+	for _, field := range namespaceIDFields {
+		match := field.pattern.FindStringSubmatch(normalized)
+		if len(match) < 2 {
+			continue
+		}
+		hex := strings.ToLower(namespaceIDSeparators.Replace(match[1]))
+		if len(hex) != field.hexLen || strings.Trim(hex, "0") == "" {
+			continue
+		}
+		return "eui." + hex
+	}
+	return ""
+}
+
+// volumeQoSPatterns match the IO limit properties of a disk entry; numbers may contain spaces
+var volumeQoSPatterns = struct{ readIOPS, writeIOPS, bandwidth *regexp.Regexp }{
+	readIOPS:  regexp.MustCompile(`max-read-iops=(\d[\d ]*)`),
+	writeIOPS: regexp.MustCompile(`max-write-iops=(\d[\d ]*)`),
+	bandwidth: regexp.MustCompile(`max-bandwidth=(\d[\d ]*)`),
+}
+
+// parseVolumeQoS extracts IO limits from normalized disk print output. Missing or
+// unparseable properties (older RouterOS, or no limit set) are left at zero; the
+// unparseable ones are recorded as parse warnings on volume.
+func parseVolumeQoS(normalized string, volume *VolumeInfo) VolumeQoS {
+	parse := func(re *regexp.Regexp) int64 {
+		match := re.FindStringSubmatch(normalized)
+		if len(match) < 2 {
+			return 0
+		}
+		value, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(match[1]), " ", ""), 10, 64)
+		if err != nil {
+			volume.addParseWarning("%s: %v", strings.TrimSpace(match[0]), err)
+			return 0
+		}
+		return value
+	}
+	return VolumeQoS{
+		MaxReadIOPS:       parse(volumeQoSPatterns.readIOPS),
+		MaxWriteIOPS:      parse(volumeQoSPatterns.writeIOPS),
+		MaxBandwidthBytes: parse(volumeQoSPatterns.bandwidth),
+	}
+}
+
+// diskCommentLinePattern matches the ";;; <comment>" line RouterOS prints above an entry's properties
+var diskCommentLinePattern = regexp.MustCompile(`(?m);;;[ \t]*([^\r\n]*)`)
+
+// diskCommentAttrPattern matches a comment="..." property
+var diskCommentAttrPattern = regexp.MustCompile(`comment="([^"]*)"`)
+
+// parseDiskComment extracts a disk entry's comment from raw (un-normalized) print output.
+// RouterOS prints comments on their own ";;; " line; comment="..." is accepted too.
+func parseDiskComment(output string) string {
+	if match := diskCommentLinePattern.FindStringSubmatch(output); len(match) > 1 {
+		return strings.TrimSpace(match[1])
+	}
+	if match := diskCommentAttrPattern.FindStringSubmatch(output); len(match) > 1 {
+		return match[1]
+	}
+	return ""
+}
+
+// commentArg returns the comment= argument for /disk add, or "" for no comment.
+// The comment must already have passed utils.ValidateDiskComment.
+func commentArg(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return fmt.Sprintf(` comment="%s"`, comment)
+}
+
+// qosArgs returns the IO limit arguments appended to /disk add, each with a leading space,
+// or "" if no limit is set. Limits of 0 are left out rather than passed as unlimited, and
+// max-bandwidth is given in bytes per second.
+func qosArgs(qos VolumeQoS) string {
+	var args strings.Builder
+	if qos.MaxReadIOPS > 0 {
+		fmt.Fprintf(&args, " max-read-iops=%d", qos.MaxReadIOPS)
+	}
+	if qos.MaxWriteIOPS > 0 {
+		fmt.Fprintf(&args, " max-write-iops=%d", qos.MaxWriteIOPS)
+	}
+	if qos.MaxBandwidthBytes > 0 {
+		fmt.Fprintf(&args, " max-bandwidth=%d", qos.MaxBandwidthBytes)
+	}
+	return args.String()
+}
+
+// splitPrintEntries splits print output into entries at every line that starts with an item
+// number (e.g. " 0  type=file ..."), dropping the number. Any text before the first item is
+// returned as the first entry. This matches splitting on (?m)^\s*\d+\s+ but runs in a single
+// pass over the output, which matters for /disk print detail on pools with thousands of disks.
+func splitPrintEntries(output string) []string {
+	entries := make([]string, 0, strings.Count(output, "\n")/4+1)
+	start := 0
+	for pos := 0; pos < len(output); {
+		lineEnd := strings.IndexByte(output[pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(output)
+		} else {
+			lineEnd += pos
+		}
+
+		if n := itemNumberLen(output[pos:lineEnd], lineEnd < len(output)); n > 0 {
+			entries = append(entries, output[start:pos])
+			start = pos + n
+		}
+		pos = lineEnd + 1
+	}
+	return append(entries, output[start:])
+}
+
+// itemNumberLen returns the length of the "  12  " item number prefix of line (leading
+// whitespace, digits, then whitespace), or 0 if line does not start with one. hasNewline
+// reports whether a newline follows line, which counts as the whitespace after the digits.
+func itemNumberLen(line string, hasNewline bool) int {
+	i := 0
+	for i < len(line) && isSpaceByte(line[i]) {
+		i++
+	}
+	digits := i
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i == digits {
+		return 0
+	}
+	if i == len(line) {
+		if hasNewline {
+			return len(line) + 1
+		}
+		return 0
+	}
+	if !isSpaceByte(line[i]) {
+		return 0
+	}
+	for i < len(line) && isSpaceByte(line[i]) {
+		i++
+	}
+	return i
+}
+
+// isSpaceByte reports whether b is whitespace as matched by \s in regular expressions
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\f'
+}
+
 // parseVolumeList parses RouterOS disk print output for multiple volumes
 func parseVolumeList(output string) ([]VolumeInfo, error) {
-	var volumes []VolumeInfo
-
 	// Split by volume entries (each starts with a number)
-	entries := regexp.MustCompile(`(?m)^\s*\d+\s+`).Split(output, -1)
+	entries := splitPrintEntries(output)
+	volumes := make([]VolumeInfo, 0, len(entries))
 
 	for _, entry := range entries {
 		if strings.TrimSpace(entry) == "" {
@@ -482,7 +1157,7 @@ func parseFileList(output string) ([]FileInfo, error) {
 	normalized := normalizeRouterOSOutput(output)
 
 	// Split by file entries (each starts with a number)
-	entries := regexp.MustCompile(`(?m)^\s*\d+\s+`).Split(normalized, -1)
+	entries := splitPrintEntries(normalized)
 
 	for _, entry := range entries {
 		if strings.TrimSpace(entry) == "" {
@@ -503,6 +1178,7 @@ func parseFileList(output string) ([]FileInfo, error) {
 
 // parseFileInfo parses RouterOS file print output for a single file
 func parseFileInfo(output string) (*FileInfo, error) {
+	p := &diskPropertyPatterns
 	file := &FileInfo{}
 
 	// Normalize multi-line output
@@ -529,13 +1205,13 @@ func parseFileInfo(output string) (*FileInfo, error) {
 	}
 
 	// Extract type
-	if match := regexp.MustCompile(`type="?([^"\s]+)"?`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.diskType.FindStringSubmatch(normalized); len(match) > 1 {
 		file.Type = match[1]
 	}
 
 	// Extract size from "file size=X.XGiB" (human-readable) or "size=NNN NNN NNN" (raw bytes)
 	// Try human-readable format first (e.g., "file size=10.0GiB")
-	if match := regexp.MustCompile(`file size=([\d.]+)\s*([KMGT]i?B)`).FindStringSubmatch(normalized); len(match) > 2 {
+	if match := fileSizePattern.FindStringSubmatch(normalized); len(match) > 2 {
 		if bytes, err := parseSize(match[1], match[2]); err == nil {
 			file.SizeBytes = bytes
 		}
@@ -583,6 +1259,15 @@ func validateCreateVolumeOptions(opts CreateVolumeOptions) error {
 	if opts.NVMETCPNQN == "" {
 		return fmt.Errorf("NVMe/TCP NQN is required")
 	}
+
+	// SECURITY: comments carry user-controlled strings (PVC names)
+	if err := utils.ValidateDiskComment(opts.Comment); err != nil {
+		return err
+	}
+
+	if opts.QoS.MaxReadIOPS < 0 || opts.QoS.MaxWriteIOPS < 0 || opts.QoS.MaxBandwidthBytes < 0 {
+		return fmt.Errorf("IO limits must not be negative")
+	}
 	return nil
 }
 
@@ -600,7 +1285,8 @@ func validateSlotName(slot string) error {
 	return nil
 }
 
-// formatBytes converts bytes to human-readable format (50G, 100G, 1T)
+// formatBytes converts bytes to human-readable format (50G, 100G, 1T). Sizes of a
+// PiB or more are given in TiB (1024T), the largest unit it uses.
 func formatBytes(bytes int64) string {
 	const (
 		KB = 1024
@@ -623,39 +1309,111 @@ func formatBytes(bytes int64) string {
 	}
 }
 
-// parseSize converts human-readable size to bytes
+// FileSizeGranularity returns the unit formatBytes uses when expressing bytes
+// as a RouterOS file-size (1 for raw bytes, then KiB, MiB, GiB or TiB).
+// Sizes that are not a multiple of this unit are truncated by formatBytes.
+func FileSizeGranularity(bytes int64) int64 {
+	const (
+		KB = 1024
+		MB = 1024 * KB
+		GB = 1024 * MB
+		TB = 1024 * GB
+	)
+
+	switch {
+	case bytes >= TB:
+		return TB
+	case bytes >= GB:
+		return GB
+	case bytes >= MB:
+		return MB
+	case bytes >= KB:
+		return KB
+	default:
+		return 1
+	}
+}
+
+// sizeUnitPattern matches the unit of a RouterOS size: KiB to PiB in print output, K to P
+// in commands, and the KB to PB some RouterOS versions print. An empty unit means bytes.
+const sizeUnitPattern = `([KMGTP]i?B|[kKMGTP]|B)`
+
+// fileSizePattern matches the human-readable size in /file print output ("file size=10.0GiB")
+var fileSizePattern = regexp.MustCompile(`file size=([\d.]+)\s?` + sizeUnitPattern)
+
+// sizeUnitMultipliers are the bytes per size unit, by upper-cased unit. RouterOS sizes are
+// binary throughout: a unit that reads like a decimal one (kB, GB) is still a power of
+// 1024, the same as the G that formatBytes writes.
+var sizeUnitMultipliers = map[string]int64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
+	"P": 1 << 50, "PB": 1 << 50, "PIB": 1 << 50,
+}
+
+// parseSize converts a human-readable size, a decimal number and a unit, to bytes. The
+// conversion is exact, so a fraction of a large unit ("7.23TiB") is not skewed by
+// floating-point error, and the result is rounded to the nearest byte.
 func parseSize(value, unit string) (int64, error) {
-	num, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return 0, err
+	multiplier, ok := sizeUnitMultipliers[strings.ToUpper(unit)]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", unit)
+	}
+	num, ok := new(big.Rat).SetString(value)
+	if !ok || num.Sign() < 0 || strings.ContainsAny(value, "/eE") {
+		return 0, fmt.Errorf("invalid size %q", value)
 	}
 
-	multiplier := int64(1)
-	switch strings.ToUpper(unit) {
-	case "KIB", "KB", "K":
-		multiplier = 1024
-	case "MIB", "MB", "M":
-		multiplier = 1024 * 1024
-	case "GIB", "GB", "G":
-		multiplier = 1024 * 1024 * 1024
-	case "TIB", "TB", "T":
-		multiplier = 1024 * 1024 * 1024 * 1024
+	num.Mul(num, new(big.Rat).SetInt64(multiplier))
+	// Round half up: (2*num + denom) / (2*denom)
+	twice := new(big.Int).Lsh(num.Num(), 1)
+	bytes := new(big.Int).Quo(twice.Add(twice, num.Denom()), new(big.Int).Lsh(num.Denom(), 1))
+	if !bytes.IsInt64() {
+		return 0, fmt.Errorf("size %s%s is too large", value, unit)
 	}
+	return bytes.Int64(), nil
+}
+
+// routerOSWrapWidth is the terminal width at which RouterOS hard-wraps print output
+const routerOSWrapWidth = 80
 
-	return int64(num * float64(multiplier)), nil
+// ansiEscapePattern matches ANSI CSI sequences (colors, cursor movement) and two-byte
+// escapes, which RouterOS emits when console colors are enabled
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b[@-_]`)
+
+// propertyStartPattern matches text starting with a RouterOS property assignment
+var propertyStartPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*=`)
+
+// cleanRouterOSOutput removes terminal decoration from RouterOS output without changing
+// its line structure: ANSI escapes are stripped, CRLF and LFCR line endings become LF,
+// text overwritten by a bare carriage return is dropped, and trailing whitespace is trimmed.
+// runCommand applies it to every command's output before any parser sees it.
+func cleanRouterOSOutput(output string) string {
+	lines := strings.Split(ansiEscapePattern.ReplaceAllString(output, ""), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		// A terminal shows only what follows the last carriage return
+		if idx := strings.LastIndexByte(line, '\r'); idx >= 0 {
+			line = line[idx+1:]
+		}
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
 }
 
 // normalizeRouterOSOutput normalizes multi-line RouterOS output by joining continuation lines
 // RouterOS CLI output often spans multiple lines with properties wrapped across lines.
 // Continuation lines start with whitespace. This function joins them into a single line.
+// A line filled to routerOSWrapWidth was wrapped in the middle of a value, so unless
+// its continuation starts a new property the two are joined without a space.
 func normalizeRouterOSOutput(output string) string {
-	lines := strings.Split(output, "\n")
+	lines := strings.Split(cleanRouterOSOutput(output), "\n")
 	var normalized strings.Builder
+	previousWrapped := false
 
 	for _, line := range lines {
-		// Remove \r if present (Windows-style line endings)
-		line = strings.TrimRight(line, "\r")
-
 		// Skip the "Flags:" header lines
 		if strings.HasPrefix(line, "Flags:") || strings.Contains(line, "disabled") {
 			continue
@@ -663,8 +1421,11 @@ func normalizeRouterOSOutput(output string) string {
 
 		// If line starts with whitespace (continuation line), append to current line with space
 		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
-			normalized.WriteString(" ")
-			normalized.WriteString(strings.TrimSpace(line))
+			continuation := strings.TrimSpace(line)
+			if !previousWrapped || propertyStartPattern.MatchString(continuation) {
+				normalized.WriteString(" ")
+			}
+			normalized.WriteString(continuation)
 		} else {
 			// New entry - add newline before (except for first line)
 			if normalized.Len() > 0 {
@@ -672,6 +1433,7 @@ func normalizeRouterOSOutput(output string) string {
 			}
 			normalized.WriteString(line)
 		}
+		previousWrapped = utf8.RuneCountInString(line) == routerOSWrapWidth
 	}
 
 	return normalized.String()
@@ -743,12 +1505,18 @@ func (c *sshClient) CreateSnapshot(opts CreateSnapshotOptions) (*SnapshotInfo, e
 	if err := utils.ValidateSnapshotID(opts.Name); err != nil {
 		return nil, fmt.Errorf("invalid snapshot name: %w", err)
 	}
+	defer c.scheduler.beginOperation(opts.Name, classProvisioning)()
 	if err := validateSlotName(opts.SourceVolume); err != nil {
 		return nil, fmt.Errorf("invalid source volume: %w", err)
 	}
 	if opts.BasePath == "" {
 		return nil, fmt.Errorf("base path is required for snapshot file placement")
 	}
+	// The comment records the source volume, which RouterOS does not keep for copies
+	comment := SnapshotComment(opts.Comment, opts.SourceVolume)
+	if err := utils.ValidateDiskComment(comment); err != nil {
+		return nil, err
+	}
 
 	// Get source volume info to verify it exists and determine file size
 	sourceVol, err := c.GetVolume(opts.SourceVolume)
@@ -758,20 +1526,24 @@ func (c *sshClient) CreateSnapshot(opts CreateSnapshotOptions) (*SnapshotInfo, e
 
 	// Build snapshot file path: <basePath>/<snapshot-name>.img
 	snapFilePath := fmt.Sprintf("%s/%s.img", opts.BasePath, opts.Name)
+	if err := utils.ValidateFilePath(snapFilePath); err != nil {
+		return nil, fmt.Errorf("security validation failed for snapshot file path: %w", err)
+	}
 
 	// Build /disk add copy-from command.
 	// - Reference source by slot name using [find slot=<name>] (slot is unique and validated).
 	// - Omit file-size: copy-from determines size from source automatically.
 	// - NO nvme-tcp-export, nvme-tcp-server-port, nvme-tcp-server-nqn (snapshots not NVMe-exported).
 	cmd := fmt.Sprintf(
-		`/disk add type=file copy-from=[find slot=%s] file-path=%s slot=%s`,
+		`/disk add type=file copy-from=[find slot=%s] file-path=%s slot=%s%s`,
 		opts.SourceVolume,
 		snapFilePath,
 		opts.Name,
+		commentArg(comment),
 	)
 
-	// Execute command with retry
-	_, err = c.runCommandWithRetry(cmd, 3)
+	// Execute command with retry; copying a large disk takes long
+	_, err = c.runCommandWithRetryTimeout(cmd, 3, c.longCommandTimeout, c.snapshotCreated(opts.Name, snapFilePath, sourceVol.FileSizeBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create snapshot: %w", err)
 	}
@@ -792,8 +1564,7 @@ func (c *sshClient) CreateSnapshot(opts CreateSnapshotOptions) (*SnapshotInfo, e
 		snapshot.FileSizeBytes = sourceVol.FileSizeBytes
 	}
 
-	klog.V(2).Infof("Created snapshot %s from volume %s", opts.Name, opts.SourceVolume)
-	klog.V(4).Infof("Created snapshot %s (source=%s, file=%s, size=%d)", opts.Name, opts.SourceVolume, snapFilePath, snapshot.FileSizeBytes)
+	c.logger().V(2).Info("Created snapshot", "snapshotID", opts.Name, "sourceVolume", opts.SourceVolume, "filePath", snapFilePath, "sizeBytes", snapshot.FileSizeBytes)
 	return snapshot, nil
 }
 
@@ -805,13 +1576,14 @@ func (c *sshClient) DeleteSnapshot(snapshotID string) error {
 	if err := utils.ValidateSnapshotID(snapshotID); err != nil {
 		return err
 	}
+	defer c.scheduler.beginOperation(snapshotID, classDeletion)()
 
 	// Get snapshot info to find the backing file path (for file cleanup)
 	snapshot, err := c.GetSnapshot(snapshotID)
 	if err != nil {
 		var notFoundErr *SnapshotNotFoundError
 		if errors.As(err, &notFoundErr) {
-			klog.V(4).Infof("Snapshot %s already deleted (not found)", snapshotID)
+			c.logger().V(4).Info("Snapshot already deleted", "snapshotID", snapshotID)
 			return nil // Idempotent: not found = success
 		}
 		return fmt.Errorf("failed to check snapshot existence: %w", err)
@@ -821,35 +1593,35 @@ func (c *sshClient) DeleteSnapshot(snapshotID string) error {
 
 	// Step 1: Remove the disk entry
 	cmd := fmt.Sprintf(`/disk remove [find slot=%s]`, snapshotID)
-	_, err = c.runCommandWithRetry(cmd, 3)
+	_, err = c.runCommandWithRetry(cmd, 3, c.slotRemoved(snapshotID))
 	if err != nil {
 		// Idempotent: treat "no such item" as success
 		if strings.Contains(err.Error(), "no such item") {
-			klog.V(4).Infof("Snapshot %s disk entry does not exist, continuing to file cleanup", snapshotID)
+			c.logger().V(4).Info("Snapshot disk entry does not exist, continuing to file cleanup", "snapshotID", snapshotID)
 		} else {
 			return fmt.Errorf("failed to remove snapshot disk entry: %w", err)
 		}
 	}
-	klog.V(4).Infof("Removed disk entry for snapshot %s", snapshotID)
+	c.logger().V(4).Info("Removed disk entry of snapshot", "snapshotID", snapshotID)
 
 	// Step 2: Delete the backing file (belt and suspenders)
 	if filePath != "" {
 		if err := c.DeleteFile(filePath); err != nil {
 			// Log warning but don't fail - disk slot is already removed
 			// Orphan reconciler can clean up the file later if needed
-			klog.Warningf("Failed to delete backing file %s for snapshot %s: %v", filePath, snapshotID, err)
+			c.logger().Info("Failed to delete backing file of snapshot", "snapshotID", snapshotID, "filePath", filePath, "err", err)
 		} else {
-			klog.V(4).Infof("Deleted backing file %s for snapshot %s", filePath, snapshotID)
+			c.logger().V(4).Info("Deleted backing file of snapshot", "snapshotID", snapshotID, "filePath", filePath)
 		}
 	}
 
-	klog.V(2).Infof("Deleted snapshot %s", snapshotID)
+	c.logger().V(2).Info("Deleted snapshot", "snapshotID", snapshotID)
 	return nil
 }
 
 // GetSnapshot retrieves information about a specific snapshot using /disk print.
 func (c *sshClient) GetSnapshot(snapshotID string) (*SnapshotInfo, error) {
-	klog.V(4).Infof("Getting snapshot info for %s", snapshotID)
+	c.logger().V(4).Info("Getting snapshot info", "snapshotID", snapshotID)
 
 	// Validate snapshot ID
 	if err := utils.ValidateSnapshotID(snapshotID); err != nil {
@@ -885,10 +1657,47 @@ func (c *sshClient) GetSnapshot(snapshotID string) (*SnapshotInfo, error) {
 	return snapshot, nil
 }
 
+// VerifySnapshot checks that the backing file of a snapshot exists on RDS with sizeBytes,
+// the size of its source volume. RouterOS exposes no checksum of a file and cannot read one
+// over the CLI, so a truncated or missing copy is what this catches. Returns a
+// *SnapshotVerificationError if the snapshot does not match.
+func (c *sshClient) VerifySnapshot(snapshotID string, sizeBytes int64) error {
+	// Read the disk entry again rather than trusting what CreateSnapshot returned
+	snapshot, err := c.GetSnapshot(snapshotID)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot for verification: %w", err)
+	}
+	if snapshot.FilePath == "" {
+		return &SnapshotVerificationError{Name: snapshotID, Reason: "disk entry has no backing file"}
+	}
+	if snapshot.FileSizeBytes != 0 && snapshot.FileSizeBytes != sizeBytes {
+		return &SnapshotVerificationError{Name: snapshotID,
+			Reason: fmt.Sprintf("disk entry size %d bytes, expected %d", snapshot.FileSizeBytes, sizeBytes)}
+	}
+
+	files, err := c.ListFiles(snapshot.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read backing file of snapshot for verification: %w", err)
+	}
+	filePath := "/" + strings.TrimPrefix(snapshot.FilePath, "/")
+	for _, file := range files {
+		if file.Path != filePath {
+			continue
+		}
+		if file.SizeBytes != sizeBytes {
+			return &SnapshotVerificationError{Name: snapshotID,
+				Reason: fmt.Sprintf("backing file %s is %d bytes, expected %d", filePath, file.SizeBytes, sizeBytes)}
+		}
+		c.logger().V(4).Info("Verified snapshot", "snapshotID", snapshotID, "filePath", filePath, "sizeBytes", sizeBytes)
+		return nil
+	}
+	return &SnapshotVerificationError{Name: snapshotID, Reason: fmt.Sprintf("backing file %s not found", filePath)}
+}
+
 // ListSnapshots lists all CSI-managed snapshots (snap-* prefix) on RDS.
 // Uses /disk print with slot prefix filter to enumerate snapshot disk entries.
 func (c *sshClient) ListSnapshots() ([]SnapshotInfo, error) {
-	klog.V(4).Info("Listing all snapshots")
+	c.logger().V(4).Info("Listing all snapshots")
 
 	// Use slot~ prefix match to find all snap-* entries
 	cmd := `/disk print detail where slot~"snap-"`
@@ -908,8 +1717,53 @@ func (c *sshClient) ListSnapshots() ([]SnapshotInfo, error) {
 	return snapshots, nil
 }
 
+// isSourceFilterRejected reports whether err is RouterOS refusing the source-volume filter
+// of cmd: an unsupported parameter, or a parse error at the position of the filter. Other
+// failures say nothing about the filter and must not turn it off for good.
+func isSourceFilterRejected(cmd string, err error) bool {
+	message := strings.ToLower(err.Error())
+	if strings.Contains(message, "unsupported parameter") {
+		return true
+	}
+	column := strings.Index(cmd, "source-volume") + 1
+	return strings.Contains(message, fmt.Sprintf("expected end of command (line 1 column %d)", column))
+}
+
+// ListSnapshotsBySource lists the snapshots whose source volume is sourceVolume. Snapshot
+// slot names do not embed the source volume, so the query filters on the source-volume
+// property and only matching entries are parsed. If RDS rejects that filter, all snapshots
+// are listed instead, with the source volume read from the snapshot comments; callers
+// must still check SourceVolume on the results.
+func (c *sshClient) ListSnapshotsBySource(sourceVolume string) ([]SnapshotInfo, error) {
+	if err := validateSlotName(sourceVolume); err != nil {
+		return nil, fmt.Errorf("invalid source volume: %w", err)
+	}
+	if c.sourceFilterUnsupported.Load() {
+		return c.ListSnapshots()
+	}
+
+	c.logger().V(4).Info("Listing snapshots of volume", "sourceVolume", sourceVolume)
+	cmd := fmt.Sprintf(`/disk print detail where slot~"snap-" and source-volume="%s"`, sourceVolume)
+	output, err := c.runCommand(cmd)
+	if err != nil {
+		if !isSourceFilterRejected(cmd, err) {
+			return nil, fmt.Errorf("failed to list snapshots of %s: %w", sourceVolume, err)
+		}
+		c.logger().V(2).Info("RDS rejected the source-volume snapshot filter, listing all snapshots instead", "err", err)
+		c.sourceFilterUnsupported.Store(true)
+		return c.ListSnapshots()
+	}
+
+	snapshots, err := parseSnapshotList(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot list: %w", err)
+	}
+	return snapshots, nil
+}
+
 // RestoreSnapshot creates a new NVMe-exported volume from a snapshot using /disk add copy-from.
 // The restored volume is an independent writable copy — modifying it does not affect the snapshot.
+// Its file may be in a different pool than the snapshot's; RDS copies across filesystems.
 func (c *sshClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolumeOptions) error {
 	// Validate snapshot ID
 	if err := utils.ValidateSnapshotID(snapshotID); err != nil {
@@ -920,30 +1774,50 @@ func (c *sshClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolum
 	if err := validateCreateVolumeOptions(newVolumeOpts); err != nil {
 		return fmt.Errorf("invalid volume options: %w", err)
 	}
+	defer c.scheduler.beginOperation(newVolumeOpts.Slot, classProvisioning)()
 
 	// Verify snapshot exists
-	_, err := c.GetSnapshot(snapshotID)
+	snapshot, err := c.GetSnapshot(snapshotID)
 	if err != nil {
 		return fmt.Errorf("snapshot not found: %w", err)
 	}
 
-	klog.V(4).Infof("Restoring snapshot %s to new volume %s", snapshotID, newVolumeOpts.Slot)
+	// The copy reads the snapshot's file, so it must be in an allowed pool too
+	if snapshot.FilePath != "" {
+		if err := utils.ValidateFilePath(snapshot.FilePath); err != nil {
+			return fmt.Errorf("security validation failed for snapshot file path: %w", err)
+		}
+		if filepath.Dir(snapshot.FilePath) != filepath.Dir(newVolumeOpts.FilePath) {
+			c.logger().V(2).Info("Restoring snapshot into another directory", "snapshotID", snapshotID,
+				"from", filepath.Dir(snapshot.FilePath), "into", filepath.Dir(newVolumeOpts.FilePath))
+		}
+	}
+
+	if newVolumeOpts.QoS.IsSet() {
+		if err := c.checkVolumeQoSSupported(); err != nil {
+			return err
+		}
+	}
+
+	c.logger().V(4).Info("Restoring snapshot to new volume", "snapshotID", snapshotID, "slot", newVolumeOpts.Slot)
 
 	// Create new NVMe-exported volume using /disk add copy-from.
 	// This is essentially CreateVolume but with copy-from to populate data from the snapshot.
 	// file-size is included to allow larger-than-snapshot restores (per CSI spec).
 	sizeStr := formatBytes(newVolumeOpts.FileSizeBytes)
 	cmd := fmt.Sprintf(
-		`/disk add type=file copy-from=[find slot=%s] file-path=%s file-size=%s slot=%s nvme-tcp-export=yes nvme-tcp-server-port=%d nvme-tcp-server-nqn=%s`,
+		`/disk add type=file copy-from=[find slot=%s] file-path=%s file-size=%s slot=%s nvme-tcp-export=yes nvme-tcp-server-port=%d nvme-tcp-server-nqn=%s%s%s`,
 		snapshotID,
 		newVolumeOpts.FilePath,
 		sizeStr,
 		newVolumeOpts.Slot,
 		newVolumeOpts.NVMETCPPort,
 		newVolumeOpts.NVMETCPNQN,
+		commentArg(newVolumeOpts.Comment),
+		qosArgs(newVolumeOpts.QoS),
 	)
 
-	_, err = c.runCommandWithRetry(cmd, 3)
+	_, err = c.runCommandWithRetryTimeout(cmd, 3, c.createTimeout(newVolumeOpts), c.volumeCreated(newVolumeOpts))
 	if err != nil {
 		return fmt.Errorf("failed to restore snapshot to new volume: %w", err)
 	}
@@ -953,7 +1827,7 @@ func (c *sshClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolum
 		return fmt.Errorf("restore verification failed: %w", err)
 	}
 
-	klog.V(2).Infof("Restored snapshot %s to new volume %s", snapshotID, newVolumeOpts.Slot)
+	c.logger().V(2).Info("Restored snapshot to new volume", "snapshotID", snapshotID, "slot", newVolumeOpts.Slot)
 	return nil
 }
 
@@ -961,7 +1835,7 @@ func (c *sshClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolum
 // Uses "once" modifier to get a single snapshot instead of continuous stream output.
 // The slot parameter is the disk slot name (e.g., "storage-pool") or disk number.
 func (c *sshClient) GetDiskMetrics(slot string) (*DiskMetrics, error) {
-	klog.V(4).Infof("Getting disk metrics for %s", slot)
+	c.logger().V(4).Info("Getting disk metrics", "slot", slot)
 
 	// Validate slot name to prevent command injection
 	if err := validateSlotName(slot); err != nil {
@@ -1077,6 +1951,7 @@ func convertRateToBytesPerSec(value float64, unit string) float64 {
 // Snapshot entries have the same key=value format as volume entries but WITHOUT nvme-tcp-export
 // fields (snapshots are not NVMe-exported). Source volume lineage is recovered from the slot name.
 func parseSnapshotInfo(output string) (*SnapshotInfo, error) {
+	p := &diskPropertyPatterns
 	snapshot := &SnapshotInfo{}
 
 	// Normalize multi-line output (same as parseVolumeInfo)
@@ -1090,11 +1965,11 @@ func parseSnapshotInfo(output string) (*SnapshotInfo, error) {
 	}
 
 	// Extract file-path (backing file on RDS)
-	if match := regexp.MustCompile(`file-path="([^"]+)"`).FindStringSubmatch(normalized); len(match) > 1 {
+	if match := p.filePathQuoted.FindStringSubmatch(normalized); len(match) > 1 {
 		snapshot.FilePath = match[1]
-	} else if match := regexp.MustCompile(`file-path=(\S+\.img)`).FindStringSubmatch(normalized); len(match) > 1 {
+	} else if match := p.filePath.FindStringSubmatch(normalized); len(match) > 1 {
 		snapshot.FilePath = match[1]
-	} else if match := regexp.MustCompile(`file-path=(\S+\s+\S+\.img)`).FindStringSubmatch(normalized); len(match) > 1 {
+	} else if match := p.filePathSplit.FindStringSubmatch(normalized); len(match) > 1 {
 		// Path was split across lines and joined with space after normalization
 		snapshot.FilePath = strings.ReplaceAll(match[1], " ", "")
 	}
@@ -1103,25 +1978,26 @@ func parseSnapshotInfo(output string) (*SnapshotInfo, error) {
 		snapshot.FilePath = "/" + snapshot.FilePath
 	}
 
-	// Extract file-size (human-readable format like "50.0GiB")
-	if match := regexp.MustCompile(`file-size=([\d.]+)\s*([KMGT]i?B)`).FindStringSubmatch(normalized); len(match) > 2 {
-		if bytes, err := parseSize(match[1], match[2]); err == nil {
-			snapshot.FileSizeBytes = bytes
+	// Extract the size, preferring the exact raw size to the rounded file-size as for volumes
+	if match := p.rawSize.FindStringSubmatch(normalized); len(match) > 1 {
+		if size, err := strconv.ParseInt(strings.ReplaceAll(match[1], " ", ""), 10, 64); err == nil {
+			snapshot.FileSizeBytes = size
 		}
-	} else {
-		// Fallback: try raw size field (with spaces removed)
-		if match := regexp.MustCompile(`file-size=(\d+)`).FindStringSubmatch(normalized); len(match) > 1 {
-			if size, err := strconv.ParseInt(match[1], 10, 64); err == nil {
-				snapshot.FileSizeBytes = size
+	}
+	if snapshot.FileSizeBytes == 0 {
+		if match := p.fileSize.FindStringSubmatch(normalized); len(match) > 2 {
+			if bytes, err := parseSize(match[1], match[2]); err == nil {
+				snapshot.FileSizeBytes = bytes
 			}
 		}
 	}
 
 	// Extract source-volume if present in the output.
-	// The mock server always emits this field; real RouterOS /disk print does not.
+	// The mock server emits this field; real RouterOS /disk print does not.
 	// NOTE: The snapshot slot name (snap-<uuid5-of-csiName>-at-<suffix>) no longer embeds
 	// the source volume UUID — the UUID is derived from the CSI name, not the source.
-	// Therefore the slot name cannot be used to recover the source volume ID.
+	// Therefore the slot name cannot be used to recover the source volume ID; the disk
+	// comment CreateSnapshot writes records it instead (see SnapshotComment).
 	if match := regexp.MustCompile(`source-volume="([^"]+)"`).FindStringSubmatch(normalized); len(match) > 1 {
 		snapshot.SourceVolume = match[1]
 	} else if match := regexp.MustCompile(`source-volume=([^\s]+)`).FindStringSubmatch(normalized); len(match) > 1 {
@@ -1133,6 +2009,11 @@ func parseSnapshotInfo(output string) (*SnapshotInfo, error) {
 	// for deterministic snapshot IDs, so ExtractTimestampFromSnapshotID would fail.
 	snapshot.CreatedAt = parseRouterOSTime(normalized)
 
+	snapshot.Comment = parseDiskComment(output)
+	if snapshot.SourceVolume == "" {
+		snapshot.SourceVolume = SnapshotCommentSource(snapshot.Comment)
+	}
+
 	return snapshot, nil
 }
 
@@ -1142,7 +2023,7 @@ func parseSnapshotList(output string) ([]SnapshotInfo, error) {
 	var snapshots []SnapshotInfo
 
 	// Split by disk entries (each starts with a number like "0  " or " 1  ")
-	entries := regexp.MustCompile(`(?m)^\s*\d+\s+`).Split(output, -1)
+	entries := splitPrintEntries(output)
 
 	for _, entry := range entries {
 		if strings.TrimSpace(entry) == "" {
@@ -1168,3 +2049,136 @@ func parseSnapshotList(output string) ([]SnapshotInfo, error) {
 
 	return snapshots, nil
 }
+
+// ErrCommandUnsupported indicates the RouterOS version on the RDS does not
+// recognise a command (e.g. older releases without NVMe/TCP session listing).
+var ErrCommandUnsupported = errors.New("command not supported by RouterOS version")
+
+// isUnsupportedCommandOutput reports whether RouterOS rejected the command itself,
+// as opposed to the command failing at runtime.
+func isUnsupportedCommandOutput(s string) bool {
+	return strings.Contains(s, "bad command name") ||
+		strings.Contains(s, "no such command") ||
+		strings.Contains(s, "expected end of command")
+}
+
+// GetNVMeSessions returns the number of connected NVMe/TCP initiator sessions per
+// volume slot, as seen by RDS. Returns ErrCommandUnsupported if this RouterOS
+// version cannot list NVMe/TCP connections.
+func (c *sshClient) GetNVMeSessions() (map[string]int, error) {
+	c.logger().V(4).Info("Getting NVMe/TCP sessions")
+
+	output, err := c.runCommand(`/interface nvme-tcp connection print detail`)
+	if isUnsupportedCommandOutput(output) || (err != nil && isUnsupportedCommandOutput(err.Error())) {
+		return nil, ErrCommandUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list NVMe/TCP sessions: %w", err)
+	}
+
+	return parseNVMeSessions(output), nil
+}
+
+// sessionNQNPattern and sessionStatePattern match the fields of an NVMe/TCP session entry
+var (
+	sessionNQNPattern   = regexp.MustCompile(`nqn="?([^"\s]+)"?`)
+	sessionStatePattern = regexp.MustCompile(`state="?([\w-]+)"?`)
+)
+
+// parseNVMeSessions counts connected sessions per slot from
+// /interface nvme-tcp connection print detail output. Expected format:
+//
+//	0  interface="nvme-tcp1" nqn="nqn.2000-02.com.mikrotik:pvc-abc123"
+//	   remote-address=10.42.67.8 state="connected"
+//	1  interface="nvme-tcp1" nqn="nqn.2000-02.com.mikrotik:pvc-abc123"
+//	   remote-address=10.42.67.9 state="connecting"
+//
+// The slot is the NQN suffix after the last ':' (volumes are exported as
+// <prefix>:<slot>). Entries without an NQN, or not in the connected state, are skipped.
+func parseNVMeSessions(output string) map[string]int {
+	sessions := make(map[string]int)
+
+	entries := splitPrintEntries(output)
+
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		nqnMatch := sessionNQNPattern.FindStringSubmatch(entry)
+		if len(nqnMatch) < 2 {
+			klog.V(4).Infof("Skipping NVMe/TCP session entry without NQN: %q", entry)
+			continue
+		}
+
+		// Missing state is treated as connected (listing only shows live sessions on some versions)
+		if stateMatch := sessionStatePattern.FindStringSubmatch(entry); len(stateMatch) > 1 && stateMatch[1] != "connected" {
+			continue
+		}
+
+		nqn := nqnMatch[1]
+		slot := nqn[strings.LastIndex(nqn, ":")+1:]
+		if slot == "" {
+			continue
+		}
+		sessions[slot]++
+	}
+
+	return sessions
+}
+
+// GetSystemInfo returns the RouterOS version and board details of the RDS
+func (c *sshClient) GetSystemInfo() (*SystemInfo, error) {
+	c.logger().V(4).Info("Getting RouterOS system information")
+
+	output, err := c.runCommand(`/system resource print`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system resources: %w", err)
+	}
+
+	info, err := parseSystemInfo(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse system resources: %w", err)
+	}
+	return info, nil
+}
+
+// parseSystemInfo parses /system resource print output. Expected format
+// (keys are right-aligned; values are quoted on some releases):
+//
+//	           uptime: 2w3d4h5m6s
+//	          version: 7.16 (stable)
+//	       board-name: RDS2216
+//	architecture-name: arm64
+//
+// Returns an error if no version is reported.
+func parseSystemInfo(output string) (*SystemInfo, error) {
+	info := &SystemInfo{}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch strings.TrimSpace(key) {
+		case "version":
+			// "7.16 (stable)" -> version "7.16", channel "stable"
+			version, channel, _ := strings.Cut(value, " ")
+			info.Version = version
+			info.Channel = strings.Trim(strings.TrimSpace(channel), "()")
+		case "board-name":
+			info.BoardName = value
+		case "architecture-name":
+			info.ArchitectureName = value
+		case "uptime":
+			info.Uptime = value
+		}
+	}
+
+	if info.Version == "" {
+		return nil, fmt.Errorf("no version in output")
+	}
+	return info, nil
+}
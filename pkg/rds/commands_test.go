@@ -3,6 +3,8 @@ package rds
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -38,11 +40,11 @@ func (t *testableSSHClient) runCommand(command string) (string, error) {
 
 // newTestableSSHClient creates a client for testing
 func newTestableSSHClient(runner mockCommandRunner) *testableSSHClient {
-	base := &sshClient{
+	base := &sshClient{sshState: &sshState{
 		address: "test-rds",
 		port:    22,
 		user:    "admin",
-	}
+	}}
 	return &testableSSHClient{
 		sshClient:  base,
 		mockRunner: runner,
@@ -102,6 +104,138 @@ func TestParseVolumeInfo(t *testing.T) {
 	if volume.Status != "ready" {
 		t.Errorf("Expected status ready, got %s", volume.Status)
 	}
+
+	if len(volume.ParseWarnings) != 0 {
+		t.Errorf("Expected no parse warnings, got %v", volume.ParseWarnings)
+	}
+}
+
+func TestParseVolumeInfo_ParseWarnings(t *testing.T) {
+	output := `type=file slot="pvc-test-123" file-path=/storage-pool/test.img
+               file-size=1.2.3GiB nvme-tcp-export=yes
+               nvme-tcp-server-port=99999999999999999999
+               max-read-iops=5000 max-write-iops=99999999999999999999`
+
+	volume, err := parseVolumeInfo(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Unparseable properties are reported and left at zero; the rest still parses
+	if volume.Slot != "pvc-test-123" || volume.QoS.MaxReadIOPS != 5000 {
+		t.Errorf("Expected other properties to parse, got %+v", volume)
+	}
+	if volume.FileSizeBytes != 0 || volume.NVMETCPPort != 0 || volume.QoS.MaxWriteIOPS != 0 {
+		t.Errorf("Expected unparseable properties to be zero, got %+v", volume)
+	}
+	if len(volume.ParseWarnings) != 3 {
+		t.Fatalf("Expected 3 parse warnings, got %v", volume.ParseWarnings)
+	}
+	for i, prefix := range []string{"file-size=1.2.3GiB:", "nvme-tcp-server-port=99999999999999999999:", "max-write-iops=99999999999999999999:"} {
+		if !strings.HasPrefix(volume.ParseWarnings[i], prefix) {
+			t.Errorf("Warning %d = %q, want prefix %q", i, volume.ParseWarnings[i], prefix)
+		}
+	}
+}
+
+func TestParseVolumeQoS(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected VolumeQoS
+	}{
+		{
+			name: "all limits",
+			output: `type=file slot="pvc-test-123" file-path=/storage-pool/test.img
+               max-read-iops=5000 max-write-iops=2 500
+               max-bandwidth=104 857 600 file-size=50.0GiB`,
+			expected: VolumeQoS{MaxReadIOPS: 5000, MaxWriteIOPS: 2500, MaxBandwidthBytes: 104857600},
+		},
+		{
+			name:     "single limit",
+			output:   `type=file slot="pvc-test-123" max-write-iops=1000 file-size=50.0GiB`,
+			expected: VolumeQoS{MaxWriteIOPS: 1000},
+		},
+		{
+			name:     "no limits (older RouterOS)",
+			output:   `type=file slot="pvc-test-123" file-path=/storage-pool/test.img file-size=50.0GiB`,
+			expected: VolumeQoS{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume, err := parseVolumeInfo(tt.output)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if volume.QoS != tt.expected {
+				t.Errorf("Expected QoS %+v, got %+v", tt.expected, volume.QoS)
+			}
+		})
+	}
+}
+
+func TestQoSArgs(t *testing.T) {
+	tests := []struct {
+		qos      VolumeQoS
+		expected string
+	}{
+		{qos: VolumeQoS{}, expected: ""},
+		{qos: VolumeQoS{MaxReadIOPS: 5000}, expected: " max-read-iops=5000"},
+		{
+			qos:      VolumeQoS{MaxReadIOPS: 5000, MaxWriteIOPS: 2500, MaxBandwidthBytes: 104857600},
+			expected: " max-read-iops=5000 max-write-iops=2500 max-bandwidth=104857600",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := qosArgs(tt.qos); got != tt.expected {
+			t.Errorf("qosArgs(%+v) = %q, expected %q", tt.qos, got, tt.expected)
+		}
+	}
+}
+
+func TestParseNamespaceWWID(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "no identifier reported",
+			output: `slot="pvc-test-123" nvme-tcp-export=yes`,
+			want:   "",
+		},
+		{
+			name:   "EUI-64",
+			output: `slot="pvc-test-123" nvme-tcp-export=yes eui64=00:25:38:5B:71:B0:A1:F2`,
+			want:   "eui.0025385b71b0a1f2",
+		},
+		{
+			name:   "NGUID preferred over EUI-64",
+			output: `slot="pvc-test-123" eui64=0025385b71b0a1f2 nguid="6b1e9a7c-3f2d-4e5a-8b0c-1d2e3f4a5b6c"`,
+			want:   "eui.6b1e9a7c3f2d4e5a8b0c1d2e3f4a5b6c",
+		},
+		{
+			name:   "all-zero NGUID falls through to EUI-64",
+			output: `nguid=00000000000000000000000000000000 eui64=0025385b71b0a1f2`,
+			want:   "eui.0025385b71b0a1f2",
+		},
+		{
+			name:   "wrong length ignored",
+			output: `eui64=0025385b71`,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNamespaceWWID(tt.output); got != tt.want {
+				t.Errorf("parseNamespaceWWID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestParseVolumeList(t *testing.T) {
@@ -145,6 +279,50 @@ func TestParseVolumeList(t *testing.T) {
 	}
 }
 
+func TestParseDiskComment(t *testing.T) {
+	// RouterOS prints comments on a ";;; " line above the entry's properties
+	output := ` 0  ;;; default/data-postgres-0
+      type=file slot="pvc-test-1" file-path=/storage-pool/test1.img file-size=50.0GiB
+      nvme-tcp-export=yes nvme-tcp-server-port=4420
+      nvme-tcp-server-nqn="nqn.2000-02.com.mikrotik:pvc-test-1"
+
+ 1  type=file slot="pvc-test-2" file-path=/storage-pool/test2.img file-size=10.0GiB
+      nvme-tcp-export=yes nvme-tcp-server-port=4420
+      nvme-tcp-server-nqn="nqn.2000-02.com.mikrotik:pvc-test-2"`
+
+	volumes, err := parseVolumeList(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(volumes) != 2 {
+		t.Fatalf("Expected 2 volumes, got %d", len(volumes))
+	}
+	if volumes[0].Slot != "pvc-test-1" || volumes[0].Comment != "default/data-postgres-0" {
+		t.Errorf("Expected pvc-test-1 with comment, got %s %q", volumes[0].Slot, volumes[0].Comment)
+	}
+	if volumes[1].Slot != "pvc-test-2" || volumes[1].Comment != "" {
+		t.Errorf("Expected pvc-test-2 without comment, got %s %q", volumes[1].Slot, volumes[1].Comment)
+	}
+
+	// A comment cannot spoof properties of the entry it labels
+	spoof := ";;; slot=pvc-evil\n     type=file slot=\"pvc-test-3\" nvme-tcp-export=yes"
+	volume, err := parseVolumeInfo(spoof)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if volume.Slot != "pvc-test-3" {
+		t.Errorf("Expected slot pvc-test-3, got %s", volume.Slot)
+	}
+
+	snapshot, err := parseSnapshotInfo(";;; default/nightly\n     slot=\"snap-test\" type=file")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.Comment != "default/nightly" {
+		t.Errorf("Expected snapshot comment default/nightly, got %q", snapshot.Comment)
+	}
+}
+
 func TestParseCapacityInfo(t *testing.T) {
 	// Real RouterOS /file print detail output format with space-separated numbers
 	output := `name=/storage-pool type=directory size=7 681 574 174 720
@@ -224,6 +402,69 @@ func TestFormatBytes(t *testing.T) {
 	}
 }
 
+func TestFormatBytesRoundTrip(t *testing.T) {
+	const (
+		kib = int64(1) << 10
+		mib = int64(1) << 20
+		gib = int64(1) << 30
+		tib = int64(1) << 40
+		pib = int64(1) << 50
+	)
+	tests := []struct {
+		name  string
+		bytes int64
+	}{
+		{"bytes", 512},
+		{"KiB", 3 * kib},
+		{"MiB", 1023 * mib},
+		{"GiB", 50 * gib},
+		{"just below a TiB", 1023 * gib},
+		{"TiB", 7 * tib},
+		{"PiB", pib},
+		{"PiB and a half", 3 * pib / 2},
+		{"largest whole PiB", 8191 * pib},
+	}
+
+	sizePattern := regexp.MustCompile(`^(\d+)([KMGT]?)$`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := formatBytes(tt.bytes)
+			match := sizePattern.FindStringSubmatch(formatted)
+			if match == nil {
+				t.Fatalf("formatBytes(%d) = %q, not a RouterOS size", tt.bytes, formatted)
+			}
+			parsed, err := parseSize(match[1], match[2])
+			if err != nil {
+				t.Fatalf("parseSize(%q) failed: %v", formatted, err)
+			}
+			if parsed != tt.bytes {
+				t.Errorf("formatBytes(%d) = %q parses back as %d", tt.bytes, formatted, parsed)
+			}
+		})
+	}
+}
+
+func TestFileSizeGranularity(t *testing.T) {
+	gib := int64(1024 * 1024 * 1024)
+	tests := []struct {
+		bytes    int64
+		expected int64
+	}{
+		{512, 1},
+		{2048, 1024},
+		{5 * 1024 * 1024, 1024 * 1024},
+		{gib + gib/2, gib},
+		{2048 * gib, 1024 * gib},
+	}
+
+	for _, tt := range tests {
+		result := FileSizeGranularity(tt.bytes)
+		if result != tt.expected {
+			t.Errorf("FileSizeGranularity(%d) = %d, expected %d", tt.bytes, result, tt.expected)
+		}
+	}
+}
+
 func TestParseSize(t *testing.T) {
 	tib := int64(1024 * 1024 * 1024 * 1024)
 	tests := []struct {
@@ -233,9 +474,17 @@ func TestParseSize(t *testing.T) {
 	}{
 		{"50", "G", 50 * 1024 * 1024 * 1024},
 		{"100", "GB", 100 * 1024 * 1024 * 1024},
-		{"7.23", "TiB", int64(7.23 * float64(tib))},
+		{"7.23", "TiB", 7949469068820}, // 7.23 * 2^40 = 7949469068820.48
 		{"1024", "M", 1024 * 1024 * 1024},
 		{"1", "K", 1024},
+		{"2", "kB", 2048},
+		{"1536", "", 1536},
+		{"512", "B", 512},
+		{"0.5", "KiB", 512},
+		{"1.5", "PiB", 3 * tib * 1024 / 2},
+		{"4", "P", 4 * tib * 1024},
+		{"8191", "PB", 8191 * tib * 1024},
+		{"1024", "TiB", tib * 1024},
 	}
 
 	for _, tt := range tests {
@@ -244,18 +493,80 @@ func TestParseSize(t *testing.T) {
 			t.Errorf("parseSize(%s, %s) returned error: %v", tt.value, tt.unit, err)
 			continue
 		}
-
-		// Allow small margin for floating point
-		diff := result - tt.expected
-		if diff < 0 {
-			diff = -diff
-		}
-		if diff > 1024*1024 { // 1 MB tolerance
+		if result != tt.expected {
 			t.Errorf("parseSize(%s, %s) = %d, expected %d", tt.value, tt.unit, result, tt.expected)
 		}
 	}
 }
 
+func TestParseSize_Invalid(t *testing.T) {
+	tests := []struct {
+		value string
+		unit  string
+	}{
+		{"1.2.3", "GiB"},
+		{"", "G"},
+		{"10", "XB"},
+		{"8192", "PiB"}, // 2^63 bytes overflows int64
+		{"1e3", "G"},
+	}
+
+	for _, tt := range tests {
+		if result, err := parseSize(tt.value, tt.unit); err == nil {
+			t.Errorf("parseSize(%s, %s) = %d, expected an error", tt.value, tt.unit, result)
+		}
+	}
+}
+
+func TestParseVolumeInfo_Sizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected int64
+	}{
+		{
+			name:     "raw size is exact where file-size is rounded",
+			output:   `type=file slot="pvc-big" size=7 949 127 950 336 file-path=/storage-pool/big.img file-size=7.2TiB`,
+			expected: 7949127950336,
+		},
+		{
+			name:     "file-size in PiB",
+			output:   `type=file slot="pvc-huge" file-path=/storage-pool/huge.img file-size=1.5PiB status="ready"`,
+			expected: 3 << 49,
+		},
+		{
+			name:     "file-size in bytes",
+			output:   `slot="pvc-raw" type="file" file-path="/storage-pool/raw.img" file-size=1073741824 nvme-tcp-export=yes`,
+			expected: 1 << 30,
+		},
+		{
+			name:     "file-size at the end of the output",
+			output:   `type=file slot="pvc-end" file-path=/storage-pool/end.img file-size=10.0GiB`,
+			expected: 10 << 30,
+		},
+		{
+			name:     "sector-size is not the size",
+			output:   `type=file slot="pvc-sector" sector-size=512 file-path=/storage-pool/sector.img file-size=2TiB`,
+			expected: 2 << 40,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume, err := parseVolumeInfo(tt.output)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if volume.FileSizeBytes != tt.expected {
+				t.Errorf("Expected size %d, got %d", tt.expected, volume.FileSizeBytes)
+			}
+			if len(volume.ParseWarnings) != 0 {
+				t.Errorf("Expected no parse warnings, got %v", volume.ParseWarnings)
+			}
+		})
+	}
+}
+
 func TestParseFileInfo(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -374,6 +685,39 @@ func TestValidateCreateVolumeOptions(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "valid comment",
+			opts: CreateVolumeOptions{
+				Slot:          "pvc-test-123",
+				FilePath:      "/storage-pool/metal-csi/volumes/test.img",
+				FileSizeBytes: 50 * 1024 * 1024 * 1024,
+				NVMETCPNQN:    "nqn.2000-02.com.mikrotik:pvc-test-123",
+				Comment:       "default/data-postgres-0",
+			},
+			expectErr: false,
+		},
+		{
+			name: "comment breaking out of quotes",
+			opts: CreateVolumeOptions{
+				Slot:          "pvc-test-123",
+				FilePath:      "/storage-pool/metal-csi/volumes/test.img",
+				FileSizeBytes: 50 * 1024 * 1024 * 1024,
+				NVMETCPNQN:    "nqn.2000-02.com.mikrotik:pvc-test-123",
+				Comment:       `ns/pvc"; /system reboot; #`,
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative IO limit",
+			opts: CreateVolumeOptions{
+				Slot:          "pvc-test-123",
+				FilePath:      "/storage-pool/metal-csi/volumes/test.img",
+				FileSizeBytes: 50 * 1024 * 1024 * 1024,
+				NVMETCPNQN:    "nqn.2000-02.com.mikrotik:pvc-test-123",
+				QoS:           VolumeQoS{MaxReadIOPS: -1},
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -802,6 +1146,108 @@ func TestNormalizeRouterOSOutputEdgeCases(t *testing.T) {
 	}
 }
 
+func TestCleanRouterOSOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain output unchanged", "type=file slot=a\n  size=1", "type=file slot=a\n  size=1"},
+		{"ANSI colors", "\x1b[36mslot\x1b[m=\x1b[1;33mpvc-a\x1b[0m", "slot=pvc-a"},
+		{"erase and cursor sequences", "\x1b[?25l\x1b[2Kslot=a\x1b[K", "slot=a"},
+		{"CRLF line endings", "line1\r\nline2\r\n", "line1\nline2\n"},
+		{"LFCR line endings", "line1\n\rline2\n\r", "line1\nline2\n"},
+		{"carriage return redraw", "[admin@rds] > \rslot=a", "slot=a"},
+		{"trailing padding", "slot=a     \n  size=1\t", "slot=a\n  size=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := cleanRouterOSOutput(tt.input); result != tt.expected {
+				t.Errorf("cleanRouterOSOutput(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeRouterOSOutput_WrappedLines(t *testing.T) {
+	full := "file-path=/storage-pool/metal-csi/" + strings.Repeat("x", 80-len("file-path=/storage-pool/metal-csi/"))
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "value wrapped at 80 columns",
+			input:    full + "\n      .img file-size=1GiB",
+			expected: full + ".img file-size=1GiB",
+		},
+		{
+			name:     "property boundary at 80 columns",
+			input:    full + "\n      file-size=1GiB",
+			expected: full + " file-size=1GiB",
+		},
+		{
+			name:     "short line",
+			input:    "file-path=/a.img\n      file-size=1GiB",
+			expected: "file-path=/a.img file-size=1GiB",
+		},
+		{
+			name:     "colored line wrapped at 80 visible columns",
+			input:    "\x1b[36mfile-path\x1b[m=" + strings.TrimPrefix(full, "file-path=") + "\r\n      .img\r\n",
+			expected: full + ".img\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := normalizeRouterOSOutput(tt.input); result != tt.expected {
+				t.Errorf("normalizeRouterOSOutput() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseVolumeInfo_DecoratedOutput parses /disk print detail output captured with
+// console colors enabled on an 80-column terminal
+func TestParseVolumeInfo_DecoratedOutput(t *testing.T) {
+	const slot = "pvc-4f1c2a3b-5d6e-4f70-8192-a3b4c5d6e7f8"
+	output := "" +
+		"\x1b[m\x1b[32mFlags:\x1b[m X - DISABLED; B - BLOCK-DEVICE\x1b[K\r\n" +
+		" 0   \x1b[36mtype\x1b[m=file \x1b[36mslot\x1b[m=\"pvc-4f1c2a3b-5d6e-4f70-8192-a3b4c5d6e7f8\" \x1b[36mslot-default\x1b[m=\"\"\x1b[K\r\n" +
+		"      \x1b[36mparent\x1b[m=\"\" \x1b[36mfs\x1b[m=- \x1b[36mmodel\x1b[m=\"/storage-pool/metal-csi/pvc-4f1c2a3b-5d6e-4f70-8192-\x1b[K\r\n" +
+		"      a3b4c5d6e7f8.img\" \x1b[36msize\x1b[m=1 073 741 824\x1b[K\r\n" +
+		"      \x1b[36mmount-filesystem\x1b[m=yes \x1b[36mmount-read-only\x1b[m=no \x1b[36mcompress\x1b[m=no \x1b[36msector-size\x1b[m=512\x1b[K\r\n" +
+		"      \x1b[36mraid-master\x1b[m=none \x1b[36mnvme-tcp-export\x1b[m=yes \x1b[36mnvme-tcp-server-port\x1b[m=4420\x1b[K\r\n" +
+		"      \x1b[36mnvme-tcp-server-nqn\x1b[m=\"nqn.2000-02.com.mikrotik:pvc-4f1c2a3b-5d6e-4f70-8192-\x1b[K\r\n" +
+		"      a3b4c5d6e7f8\" \x1b[36mnvme-tcp-server-allow-host-name\x1b[m=\"\" \x1b[36miscsi-export\x1b[m=no\x1b[K\r\n" +
+		"      \x1b[36mfile-path\x1b[m=/storage-pool/metal-csi/pvc-4f1c2a3b-5d6e-4f70-8192-a3b4c5d6e7f8\x1b[K\r\n" +
+		"      .img \x1b[36mfile-size\x1b[m=1024.0MiB \x1b[36mfile-offset\x1b[m=0\x1b[K\r\n"
+
+	volume, err := parseVolumeInfo(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if volume.Slot != slot {
+		t.Errorf("Expected slot %s, got %q", slot, volume.Slot)
+	}
+	if volume.FilePath != "/storage-pool/metal-csi/"+slot+".img" {
+		t.Errorf("Expected file path of %s, got %q", slot, volume.FilePath)
+	}
+	if volume.FileSizeBytes != 1024*1024*1024 {
+		t.Errorf("Expected size 1GiB, got %d", volume.FileSizeBytes)
+	}
+	if volume.NVMETCPNQN != "nqn.2000-02.com.mikrotik:"+slot {
+		t.Errorf("Expected NQN of %s, got %q", slot, volume.NVMETCPNQN)
+	}
+	if !volume.NVMETCPExport || volume.NVMETCPPort != 4420 {
+		t.Errorf("Expected export on port 4420, got export=%v port=%d", volume.NVMETCPExport, volume.NVMETCPPort)
+	}
+	if len(volume.ParseWarnings) != 0 {
+		t.Errorf("Expected no parse warnings, got %v", volume.ParseWarnings)
+	}
+}
+
 // Snapshot parsing tests
 
 func TestParseSnapshotInfo(t *testing.T) {
@@ -1414,3 +1860,261 @@ func TestConvertRateToBytesPerSec(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNVMeSessions(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected map[string]int
+	}{
+		{
+			name: "multiple sessions across slots",
+			output: `Flags: X - disabled
+ 0  interface="nvme-tcp1" nqn="nqn.2000-02.com.mikrotik:pvc-abc123"
+    remote-address=10.42.67.8 state="connected"
+ 1  interface="nvme-tcp1" nqn="nqn.2000-02.com.mikrotik:pvc-abc123"
+    remote-address=10.42.67.9 state="connected"
+ 2  interface="nvme-tcp2" nqn="nqn.2000-02.com.mikrotik:pvc-def456"
+    remote-address=10.42.67.8 state="connected"`,
+			expected: map[string]int{"pvc-abc123": 2, "pvc-def456": 1},
+		},
+		{
+			name: "non-connected sessions are skipped",
+			output: ` 0  nqn="nqn.2000-02.com.mikrotik:pvc-abc123" remote-address=10.42.67.8 state="connected"
+ 1  nqn="nqn.2000-02.com.mikrotik:pvc-abc123" remote-address=10.42.67.9 state="connecting"`,
+			expected: map[string]int{"pvc-abc123": 1},
+		},
+		{
+			name:     "missing state counts as connected",
+			output:   ` 0  nqn=nqn.2000-02.com.mikrotik:pvc-abc123 remote-address=10.42.67.8`,
+			expected: map[string]int{"pvc-abc123": 1},
+		},
+		{
+			name:     "entries without nqn are skipped",
+			output:   ` 0  interface="nvme-tcp1" remote-address=10.42.67.8 state="connected"`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "empty output",
+			output:   "",
+			expected: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseNVMeSessions(tt.output)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d slots, got %d: %v", len(tt.expected), len(got), got)
+			}
+			for slot, count := range tt.expected {
+				if got[slot] != count {
+					t.Errorf("slot %s: expected %d sessions, got %d", slot, count, got[slot])
+				}
+			}
+		})
+	}
+}
+
+func TestIsUnsupportedCommandOutput(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected bool
+	}{
+		{"bad command name connection (line 1 column 17)", true},
+		{"syntax error (line 1 column 5)\nexpected end of command (line 1 column 17)", true},
+		{" 0  nqn=\"nqn.2000-02.com.mikrotik:pvc-abc\" state=\"connected\"", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isUnsupportedCommandOutput(tt.output); got != tt.expected {
+			t.Errorf("isUnsupportedCommandOutput(%q) = %v, want %v", tt.output, got, tt.expected)
+		}
+	}
+}
+
+func TestParseSystemInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected *SystemInfo
+		wantErr  bool
+	}{
+		{
+			name: "RouterOS 7.16 on RDS",
+			output: `                   uptime: 2w3d4h5m6s
+                  version: 7.16 (stable)
+               build-time: 2024-09-20 13:00:27
+         factory-software: 7.15
+              free-memory: 28.6GiB
+             total-memory: 31.3GiB
+                      cpu: ARM64
+                cpu-count: 16
+               board-name: RDS2216
+        architecture-name: arm64
+                 platform: MikroTik`,
+			expected: &SystemInfo{Version: "7.16", Channel: "stable", BoardName: "RDS2216", ArchitectureName: "arm64", Uptime: "2w3d4h5m6s"},
+		},
+		{
+			name: "long-term 6.x release",
+			output: `             uptime: 41d2h11m
+            version: 6.49.10 (long-term)
+         build-time: Sep/06/2023 11:47:48
+         board-name: CCR2004-16G-2S+
+  architecture-name: arm64`,
+			expected: &SystemInfo{Version: "6.49.10", Channel: "long-term", BoardName: "CCR2004-16G-2S+", ArchitectureName: "arm64", Uptime: "41d2h11m"},
+		},
+		{
+			name:     "quoted values and CRLF line endings",
+			output:   "platform: \"MikroTik\"\r\nboard-name: \"ROSE\"\r\nversion: \"7.17beta4 (testing)\"\r\n",
+			expected: &SystemInfo{Version: "7.17beta4", Channel: "testing", BoardName: "ROSE"},
+		},
+		{
+			name:     "version without channel",
+			output:   "       uptime: 15d2h45m30s\n      version: 7.16\n",
+			expected: &SystemInfo{Version: "7.16", Uptime: "15d2h45m30s"},
+		},
+		{
+			name:    "no version",
+			output:  "bad command name resource (line 1 column 9)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSystemInfo(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *got != *tt.expected {
+				t.Errorf("got %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// largeDiskListOutput returns /disk print detail output for the given number of file-backed volumes,
+// in the multi-line layout RouterOS uses
+func largeDiskListOutput(disks int) string {
+	var b strings.Builder
+	for i := 0; i < disks; i++ {
+		slot := fmt.Sprintf("pvc-%08x-e5f6-7890-abcd-ef1234567890", i)
+		fmt.Fprintf(&b, "%2d  ;;; default/data-%d\n", i, i)
+		fmt.Fprintf(&b, "      type=file slot=\"%s\" size=10 737 418 240\n", slot)
+		fmt.Fprintf(&b, "      file-path=/storage-pool/metal-csi/%s.img file-size=10.0GiB\n", slot)
+		b.WriteString("      nvme-tcp-export=yes nvme-tcp-server-port=4420\n")
+		fmt.Fprintf(&b, "      nvme-tcp-server-nqn=\"nqn.2000-02.com.mikrotik:%s\"\n", slot)
+		fmt.Fprintf(&b, "      nguid=\"%032x\" max-read-iops=1000\n\n", i+1)
+	}
+	return b.String()
+}
+
+func BenchmarkParseVolumeList(b *testing.B) {
+	output := largeDiskListOutput(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseVolumeList(output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSplitPrintEntries(t *testing.T) {
+	itemPattern := regexp.MustCompile(`(?m)^\s*\d+\s+`)
+	outputs := []string{
+		"",
+		"Flags: X - disabled\n 0  type=file slot=\"a\"\n      file-size=1GiB\n\n 1  type=file slot=\"b\"",
+		" 0  \n      type=file slot=\"a\"\n 12  type=file slot=\"b\"\n",
+		"\t3\ttype=file\r\n\r\n 4  slot=b\n5",
+		"no items here\n  but=continued\n",
+		largeDiskListOutput(20),
+	}
+
+	// Entries may differ in surrounding whitespace from a regexp split, never in content
+	nonBlank := func(entries []string) []string {
+		var trimmed []string
+		for _, entry := range entries {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				trimmed = append(trimmed, entry)
+			}
+		}
+		return trimmed
+	}
+	for _, output := range outputs {
+		got := nonBlank(splitPrintEntries(output))
+		want := nonBlank(itemPattern.Split(output, -1))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("splitPrintEntries(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestParseVolumeList_LargePool(t *testing.T) {
+	const disks = 5000
+	output := largeDiskListOutput(disks)
+
+	volumes, err := parseVolumeList(output)
+	if err != nil {
+		t.Fatalf("parseVolumeList failed: %v", err)
+	}
+
+	if len(volumes) != disks {
+		t.Fatalf("parsed %d volumes, want %d", len(volumes), disks)
+	}
+	last := volumes[disks-1]
+	slot := fmt.Sprintf("pvc-%08x-e5f6-7890-abcd-ef1234567890", disks-1)
+	if last.Slot != slot || last.FilePath != "/storage-pool/metal-csi/"+slot+".img" ||
+		last.FileSizeBytes != 10*1024*1024*1024 || !last.NVMETCPExport || last.NVMETCPPort != 4420 ||
+		last.Comment != fmt.Sprintf("default/data-%d", disks-1) || last.QoS.MaxReadIOPS != 1000 ||
+		last.WWID != fmt.Sprintf("eui.%032x", disks) {
+		t.Errorf("unexpected last volume: %+v", last)
+	}
+
+	// Allocations are counted rather than time measured, so the budget holds on loaded CI
+	// runners too. BenchmarkParseVolumeList tracks the time. The race detector adds
+	// allocations of its own, so race builds only check the relative growth below.
+	allocs := testing.AllocsPerRun(1, func() {
+		_, _ = parseVolumeList(output)
+	})
+	if perDisk := allocs / disks; !raceEnabled && perDisk > 40 {
+		t.Errorf("parseVolumeList made %.0f allocations per disk, budget 40", perDisk)
+	}
+
+	// The work per disk must not grow with the pool
+	smallOutput := largeDiskListOutput(disks / 10)
+	smallAllocs := testing.AllocsPerRun(1, func() {
+		_, _ = parseVolumeList(smallOutput)
+	})
+	if perDisk, smallPerDisk := allocs/disks, smallAllocs/(disks/10); perDisk > smallPerDisk*1.5 {
+		t.Errorf("parseVolumeList made %.1f allocations per disk for %d disks but %.1f for %d", perDisk, disks, smallPerDisk, disks/10)
+	}
+}
+
+func TestBatchDiskRemove(t *testing.T) {
+	cmd := batchDiskRemoveCommand([]string{"pvc-1", "pvc-2"})
+	want := `:foreach s in={"pvc-1";"pvc-2"} do={:do {/disk remove [find slot=$s]; :put "removed $s"} on-error={:put "failed $s"}}`
+	if cmd != want {
+		t.Errorf("batchDiskRemoveCommand() = %s, want %s", cmd, want)
+	}
+
+	removed, failed := parseBatchRemoveOutput("removed pvc-1\nfailed pvc-2\n\ninterrupted\nremoved pvc-3\n")
+	if !removed["pvc-1"] || !removed["pvc-3"] || removed["pvc-2"] || len(removed) != 2 {
+		t.Errorf("unexpected removed slots: %v", removed)
+	}
+	if !failed["pvc-2"] || len(failed) != 1 {
+		t.Errorf("unexpected failed slots: %v", failed)
+	}
+
+	if got := slotFilter([]string{"pvc-1", "pvc-2"}); got != "slot=pvc-1 or slot=pvc-2" {
+		t.Errorf("slotFilter() = %s", got)
+	}
+}
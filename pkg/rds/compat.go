@@ -0,0 +1,116 @@
+package rds
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// CompatIssue is a driver feature that is known not to work on a RouterOS release
+type CompatIssue struct {
+	Feature    string // Affected driver feature, e.g. "snapshots"
+	MinVersion string // First RouterOS release where the feature works
+	Reason     string // What goes wrong on older releases
+}
+
+// knownCompatIssues lists driver features with the first RouterOS release they work on.
+// Keep entries ordered by MinVersion; add one whenever a release is found to break a feature.
+// The thresholds come from testing and user reports rather than MikroTik release notes,
+// which is why a match is only ever logged as a warning.
+var knownCompatIssues = []CompatIssue{
+	{
+		Feature:    "nvme-tcp",
+		MinVersion: "7.1",
+		Reason:     "ROSE data server and NVMe/TCP export are not available",
+	},
+	{
+		Feature:    "snapshots",
+		MinVersion: "7.16",
+		Reason:     "/disk add copy-from does not produce usable snapshot copies",
+	},
+}
+
+// VolumeQoSMinVersion is the first RouterOS release whose /disk entries accept IO limits
+const VolumeQoSMinVersion = "7.18"
+
+// routerOSVersionRe matches RouterOS versions such as "7.16", "7.16.2", "6.49.10" and "7.17beta4"
+var routerOSVersionRe = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?(?:(alpha|beta|rc)(\d+))?$`)
+
+// routerOSVersion is a parsed RouterOS version. Pre-releases (alpha, beta, rc) sort before
+// the release they precede.
+type routerOSVersion struct {
+	parts [3]int
+	// stage orders pre-releases: alpha=0, beta=1, rc=2, release=3
+	stage    int
+	stageNum int
+}
+
+func parseRouterOSVersion(version string) (routerOSVersion, error) {
+	match := routerOSVersionRe.FindStringSubmatch(version)
+	if match == nil {
+		return routerOSVersion{}, fmt.Errorf("unrecognized RouterOS version %q", version)
+	}
+
+	var v routerOSVersion
+	for i := 0; i < 3; i++ {
+		if match[i+1] != "" {
+			v.parts[i], _ = strconv.Atoi(match[i+1])
+		}
+	}
+	switch match[4] {
+	case "alpha":
+		v.stage = 0
+	case "beta":
+		v.stage = 1
+	case "rc":
+		v.stage = 2
+	default:
+		v.stage = 3
+	}
+	v.stageNum, _ = strconv.Atoi(match[5])
+	return v, nil
+}
+
+func (v routerOSVersion) less(other routerOSVersion) bool {
+	for i := range v.parts {
+		if v.parts[i] != other.parts[i] {
+			return v.parts[i] < other.parts[i]
+		}
+	}
+	if v.stage != other.stage {
+		return v.stage < other.stage
+	}
+	return v.stageNum < other.stageNum
+}
+
+// CheckCompatibility returns the known issues affecting a RouterOS version (as reported
+// in SystemInfo.Version). Returns an error if the version cannot be parsed.
+func CheckCompatibility(version string) ([]CompatIssue, error) {
+	current, err := parseRouterOSVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []CompatIssue
+	for _, issue := range knownCompatIssues {
+		minVersion, err := parseRouterOSVersion(issue.MinVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compatibility entry %s: %w", issue.Feature, err)
+		}
+		if current.less(minVersion) {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// SupportsVolumeQoS reports whether a RouterOS version (as reported in SystemInfo.Version)
+// can enforce per-volume IO limits. Unrecognized versions are treated as unsupported.
+func SupportsVolumeQoS(version string) bool {
+	current, err := parseRouterOSVersion(version)
+	if err != nil {
+		return false
+	}
+	minVersion, _ := parseRouterOSVersion(VolumeQoSMinVersion)
+	return !current.less(minVersion)
+}
@@ -0,0 +1,73 @@
+package rds
+
+import "testing"
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		version      string
+		wantFeatures []string
+		wantErr      bool
+	}{
+		{version: "7.16", wantFeatures: nil},
+		{version: "7.16.2", wantFeatures: nil},
+		{version: "7.18", wantFeatures: nil},
+		{version: "8.0", wantFeatures: nil},
+		{version: "7.15.3", wantFeatures: []string{"snapshots"}},
+		{version: "7.16rc2", wantFeatures: []string{"snapshots"}},
+		{version: "7.16beta4", wantFeatures: []string{"snapshots"}},
+		{version: "7.1", wantFeatures: []string{"snapshots"}},
+		{version: "6.49.10", wantFeatures: []string{"nvme-tcp", "snapshots"}},
+		{version: "", wantErr: true},
+		{version: "7.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			issues, err := CheckCompatibility(tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", issues)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(issues) != len(tt.wantFeatures) {
+				t.Fatalf("got issues %+v, want features %v", issues, tt.wantFeatures)
+			}
+			for i, feature := range tt.wantFeatures {
+				if issues[i].Feature != feature {
+					t.Errorf("issue %d: got feature %s, want %s", i, issues[i].Feature, feature)
+				}
+			}
+		})
+	}
+}
+
+func TestKnownCompatIssuesParse(t *testing.T) {
+	for _, issue := range knownCompatIssues {
+		if _, err := parseRouterOSVersion(issue.MinVersion); err != nil {
+			t.Errorf("entry %s: %v", issue.Feature, err)
+		}
+	}
+}
+
+func TestSupportsVolumeQoS(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{version: "7.16", want: false},
+		{version: "7.18rc1", want: false},
+		{version: "7.18", want: true},
+		{version: "7.19.2", want: true},
+		{version: "unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := SupportsVolumeQoS(tt.version); got != tt.want {
+			t.Errorf("SupportsVolumeQoS(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
@@ -32,6 +32,9 @@ type ConnectionManagerConfig struct {
 	// RandomizationFactor adds jitter to backoff intervals to prevent thundering herd (default: 0.1)
 	RandomizationFactor float64
 
+	// PollInterval is how often the connection health is checked (default: 5s)
+	PollInterval time.Duration
+
 	// Metrics is optional Prometheus metrics recorder (may be nil)
 	Metrics *observability.Metrics
 
@@ -75,6 +78,9 @@ func NewConnectionManager(config ConnectionManagerConfig) (*ConnectionManager, e
 	if config.RandomizationFactor == 0 {
 		config.RandomizationFactor = 0.1 // Jitter to prevent thundering herd
 	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 5 * time.Second
+	}
 
 	cm := &ConnectionManager{
 		config:    config,
@@ -107,7 +113,7 @@ func (cm *ConnectionManager) GetClient() RDSClient {
 }
 
 // StartMonitor starts the background connection monitoring goroutine.
-// Polls connection health every 5 seconds and attempts reconnection when disconnected.
+// Polls connection health every PollInterval and attempts reconnection when disconnected.
 // Stops when ctx.Done() or Stop() is called.
 func (cm *ConnectionManager) StartMonitor(ctx context.Context) {
 	go cm.monitorLoop(ctx)
@@ -116,7 +122,7 @@ func (cm *ConnectionManager) StartMonitor(ctx context.Context) {
 func (cm *ConnectionManager) monitorLoop(ctx context.Context) {
 	defer close(cm.doneCh)
 
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(cm.config.PollInterval)
 	defer ticker.Stop()
 
 	klog.V(4).Infof("ConnectionManager: Starting monitoring for RDS %s", cm.client.GetAddress())
@@ -3,6 +3,7 @@ package rds
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -39,6 +40,9 @@ func TestNewConnectionManager(t *testing.T) {
 	if cm.config.RandomizationFactor != 0.1 {
 		t.Errorf("expected RandomizationFactor=0.1, got %v", cm.config.RandomizationFactor)
 	}
+	if cm.config.PollInterval != 5*time.Second {
+		t.Errorf("expected PollInterval=5s, got %v", cm.config.PollInterval)
+	}
 }
 
 func TestNewConnectionManager_RequiresClient(t *testing.T) {
@@ -62,6 +66,7 @@ func TestNewConnectionManager_CustomConfig(t *testing.T) {
 		MaxInterval:         8 * time.Second,
 		Multiplier:          1.5,
 		RandomizationFactor: 0.2,
+		PollInterval:        time.Second,
 	})
 
 	if err != nil {
@@ -81,6 +86,9 @@ func TestNewConnectionManager_CustomConfig(t *testing.T) {
 	if cm.config.RandomizationFactor != 0.2 {
 		t.Errorf("expected RandomizationFactor=0.2, got %v", cm.config.RandomizationFactor)
 	}
+	if cm.config.PollInterval != time.Second {
+		t.Errorf("expected PollInterval=1s, got %v", cm.config.PollInterval)
+	}
 }
 
 func TestIsConnected_ReflectsClientState(t *testing.T) {
@@ -176,13 +184,13 @@ func TestReconnection_WithBackoff(t *testing.T) {
 	mockClient := NewMockClient()
 	mockClient.SetConnected(true)
 
-	reconnectCalled := false
+	var reconnectCalled atomic.Bool
 	cm, err := NewConnectionManager(ConnectionManagerConfig{
 		Client:          mockClient,
 		InitialInterval: 100 * time.Millisecond,
 		MaxInterval:     500 * time.Millisecond,
 		OnReconnect: func() {
-			reconnectCalled = true
+			reconnectCalled.Store(true)
 		},
 	})
 	if err != nil {
@@ -214,7 +222,7 @@ func TestReconnection_WithBackoff(t *testing.T) {
 	}
 
 	// OnReconnect callback should be called
-	if !reconnectCalled {
+	if !reconnectCalled.Load() {
 		t.Error("expected OnReconnect callback to be called")
 	}
 
@@ -339,11 +347,11 @@ func TestReconnect_ManualFailure(t *testing.T) {
 func TestOnReconnectCallback(t *testing.T) {
 	mockClient := NewMockClient()
 
-	callbackCalled := false
+	var callbackCalled atomic.Bool
 	cm, err := NewConnectionManager(ConnectionManagerConfig{
 		Client: mockClient,
 		OnReconnect: func() {
-			callbackCalled = true
+			callbackCalled.Store(true)
 		},
 	})
 	if err != nil {
@@ -363,7 +371,7 @@ func TestOnReconnectCallback(t *testing.T) {
 	// Give callback goroutine time to execute
 	time.Sleep(100 * time.Millisecond)
 
-	if !callbackCalled {
+	if !callbackCalled.Load() {
 		t.Error("expected OnReconnect callback to be called")
 	}
 }
@@ -1,5 +1,9 @@
 // Package rds provides SSH client and RouterOS command wrappers for RDS management.
 //
+// The client builds RouterOS CLI commands and parses their output; a CommandExecutor
+// carries them to RDS. SSH is the default executor; ClientConfig.Executor plugs in another
+// transport or a recording fake for tests.
+//
 // # Logging Verbosity Convention
 //
 // This package follows Kubernetes logging conventions for verbosity levels:
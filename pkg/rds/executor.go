@@ -0,0 +1,59 @@
+package rds
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Transport failures of a command, by whether any part of it reached RDS
+var (
+	// ErrCommandNotSent marks a failure before the command was sent, e.g. to open the SSH
+	// session. The command did not run, so it is always safe to retry.
+	ErrCommandNotSent = errors.New("command not sent")
+
+	// ErrCommandInterrupted marks a connection failure after the command was sent and
+	// before RouterOS reported its outcome. The command may have run.
+	ErrCommandInterrupted = errors.New("connection lost after command was sent")
+)
+
+// CommandExecutor runs RouterOS CLI commands on RDS. The client builds commands and parses
+// their output; the executor only carries them, so transports other than SSH (e.g. the
+// REST API of newer RouterOS releases) and recording fakes in tests can be plugged in
+// through ClientConfig.Executor.
+//
+// Run returns the command's output as RouterOS prints it. A command RouterOS rejects
+// returns an error whose message holds the RouterOS failure text (e.g. "failure: not
+// enough space"), which the client matches to classify errors. Transport failures wrap
+// ErrCommandNotSent or ErrCommandInterrupted. An executor that also implements io.Closer
+// is closed with the client.
+type CommandExecutor interface {
+	Run(ctx context.Context, command string) (string, error)
+}
+
+// sshExecutor runs commands over the SSH connection of its client, one session each
+type sshExecutor struct {
+	client *sshClient
+}
+
+// Run executes command over SSH. When ctx is done first, the session is closed and the
+// command reported as timed out; RouterOS may still complete it.
+func (e sshExecutor) Run(ctx context.Context, command string) (string, error) {
+	return e.client.execCommand(ctx, command)
+}
+
+// commandExecutor returns the executor commands run on: the configured one, or SSH
+func (c *sshClient) commandExecutor() CommandExecutor {
+	if c.executor != nil {
+		return c.executor
+	}
+	return sshExecutor{client: c}
+}
+
+// closeExecutor closes a configured executor that holds resources
+func (c *sshClient) closeExecutor() error {
+	if closer, ok := c.executor.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package rds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// recordingExecutor records the commands it runs and answers them from canned outputs.
+// Commands without a canned output succeed with no output; those in errs fail.
+type recordingExecutor struct {
+	outputs  map[string]string
+	errs     map[string]error
+	commands []string
+	closed   bool
+
+	// timeouts holds the time each command had left when it was run (0 for no deadline)
+	timeouts []time.Duration
+}
+
+func (e *recordingExecutor) Run(ctx context.Context, command string) (string, error) {
+	e.commands = append(e.commands, command)
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	e.timeouts = append(e.timeouts, timeout)
+	return e.outputs[command], e.errs[command]
+}
+
+func (e *recordingExecutor) Close() error {
+	e.closed = true
+	return nil
+}
+
+func newExecutorTestClient(t *testing.T, outputs map[string]string) (RDSClient, *recordingExecutor) {
+	t.Helper()
+	setupTestBasePaths(t)
+	executor := &recordingExecutor{outputs: outputs}
+	client, err := NewClient(ClientConfig{Address: "10.42.68.1", User: "admin", Executor: executor})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	return client, executor
+}
+
+const (
+	executorTestSlot     = "pvc-12345678-1234-1234-1234-123456789012"
+	executorTestSnapshot = "snap-12345678-1234-1234-1234-123456789012-at-1739800000"
+)
+
+// executorTestDisk is the /disk print detail output of the test volume
+var executorTestDisk = fmt.Sprintf(`type=file slot="%[1]s" file-path=/storage-pool/metal-csi/%[1]s.img
+               file-size=10.0GiB nvme-tcp-export=yes nvme-tcp-server-port=4420
+               nvme-tcp-server-nqn="nqn.2000-02.com.mikrotik:%[1]s"`, executorTestSlot)
+
+func TestCommandExecutor_CreateVolume(t *testing.T) {
+	client, executor := newExecutorTestClient(t, map[string]string{
+		"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+	})
+
+	err := client.CreateVolume(CreateVolumeOptions{
+		Slot:          executorTestSlot,
+		FilePath:      "/storage-pool/metal-csi/" + executorTestSlot + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + executorTestSlot,
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	want := []string{
+		"/disk add type=file file-path=/storage-pool/metal-csi/" + executorTestSlot + ".img file-size=10G slot=" + executorTestSlot +
+			" nvme-tcp-export=yes nvme-tcp-server-port=4420 nvme-tcp-server-nqn=nqn.2000-02.com.mikrotik:" + executorTestSlot,
+		"/disk print detail where slot=" + executorTestSlot,
+	}
+	if !reflect.DeepEqual(executor.commands, want) {
+		t.Errorf("commands = %q, want %q", executor.commands, want)
+	}
+}
+
+func TestCommandExecutor_CreateVolumeCommandTimeout(t *testing.T) {
+	client, executor := newExecutorTestClient(t, map[string]string{
+		"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+	})
+
+	err := client.CreateVolume(CreateVolumeOptions{
+		Slot:           executorTestSlot,
+		FilePath:       "/storage-pool/metal-csi/" + executorTestSlot + ".img",
+		FileSizeBytes:  10 * 1024 * 1024 * 1024,
+		NVMETCPPort:    4420,
+		NVMETCPNQN:     "nqn.2000-02.com.mikrotik:" + executorTestSlot,
+		CommandTimeout: 15 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	if len(executor.commands) != 2 {
+		t.Fatalf("expected 2 commands, got %q", executor.commands)
+	}
+	// The /disk add gets the extended timeout, the print after it the default one
+	if !strings.HasPrefix(executor.commands[0], "/disk add ") {
+		t.Fatalf("expected /disk add first, got %q", executor.commands[0])
+	}
+	if got := executor.timeouts[0]; got <= 15*time.Minute-time.Second || got > 15*time.Minute {
+		t.Errorf("expected /disk add to have about 15m, got %v", got)
+	}
+	if got := executor.timeouts[1]; got <= 0 || got > DefaultCommandTimeout {
+		t.Errorf("expected /disk print to have at most %v, got %v", DefaultCommandTimeout, got)
+	}
+}
+
+func TestCommandExecutor_LongCommandTimeout(t *testing.T) {
+	opts := CreateVolumeOptions{
+		Slot:          executorTestSlot,
+		FilePath:      "/storage-pool/metal-csi/" + executorTestSlot + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + executorTestSlot,
+	}
+
+	tests := []struct {
+		name        string
+		config      ClientConfig
+		wantAdd     time.Duration
+		wantCommand time.Duration
+	}{
+		{"defaults", ClientConfig{}, DefaultLongCommandTimeout, DefaultCommandTimeout},
+		{"configured", ClientConfig{CommandTimeout: 10 * time.Second, LongCommandTimeout: time.Hour}, time.Hour, 10 * time.Second},
+		{"no limit", ClientConfig{CommandTimeout: NoTimeout, LongCommandTimeout: NoTimeout}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestBasePaths(t)
+			executor := &recordingExecutor{outputs: map[string]string{
+				"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+			}}
+			config := tt.config
+			config.Address, config.User, config.Executor = "10.42.68.1", "admin", executor
+			client, err := NewClient(config)
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+			if err := client.Connect(); err != nil {
+				t.Fatalf("Connect failed: %v", err)
+			}
+
+			if err := client.CreateVolume(opts); err != nil {
+				t.Fatalf("CreateVolume failed: %v", err)
+			}
+			if len(executor.timeouts) != 2 {
+				t.Fatalf("expected 2 commands, got %q", executor.commands)
+			}
+			// Each command sees its limit minus the little time that passed before it ran
+			for i, want := range []time.Duration{tt.wantAdd, tt.wantCommand} {
+				if got := executor.timeouts[i]; got > want || got < want-time.Second {
+					t.Errorf("%q ran with %v left, want about %v", executor.commands[i], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandExecutor_CreateVolumeTimedOutButCreated(t *testing.T) {
+	opts := CreateVolumeOptions{
+		Slot:          executorTestSlot,
+		FilePath:      "/storage-pool/metal-csi/" + executorTestSlot + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + executorTestSlot,
+	}
+	add := "/disk add type=file file-path=" + opts.FilePath + " file-size=10G slot=" + executorTestSlot +
+		" nvme-tcp-export=yes nvme-tcp-server-port=4420 nvme-tcp-server-nqn=" + opts.NVMETCPNQN
+
+	// RouterOS finished the /disk add after the client gave up on it
+	client, executor := newExecutorTestClient(t, map[string]string{
+		"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+	})
+	executor.errs = map[string]error{add: fmt.Errorf("%w: command did not complete", utils.ErrOperationTimeout)}
+	if err := client.CreateVolume(opts); err != nil {
+		t.Fatalf("CreateVolume failed although the volume exists: %v", err)
+	}
+
+	// A disk of another size in the slot is not taken for the requested one
+	opts.FileSizeBytes = 20 * 1024 * 1024 * 1024
+	executor.errs = map[string]error{strings.Replace(add, "file-size=10G", "file-size=20G", 1): fmt.Errorf("%w: command did not complete", utils.ErrOperationTimeout)}
+	if err := client.CreateVolume(opts); !errors.Is(err, utils.ErrVolumeExists) {
+		t.Fatalf("expected ErrVolumeExists, got %v", err)
+	}
+	if slices.Contains(executor.commands, "/disk remove [find slot="+executorTestSlot+"]") {
+		t.Errorf("timed out create removed the slot: %q", executor.commands)
+	}
+}
+
+func TestCommandExecutor_VolumeCreatedComparesDisk(t *testing.T) {
+	client, _ := newExecutorTestClient(t, map[string]string{
+		"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+	})
+	opts := CreateVolumeOptions{
+		Slot:          executorTestSlot,
+		FilePath:      "/storage-pool/metal-csi/" + executorTestSlot + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + executorTestSlot,
+	}
+	ssh := client.(*sshClient)
+
+	if executed, err := ssh.volumeCreated(opts)(); err != nil || !executed {
+		t.Errorf("volumeCreated(matching disk) = %v, %v, want true", executed, err)
+	}
+	// Another disk in the slot does not show that this /disk add ran
+	other := opts
+	other.FileSizeBytes = 20 * 1024 * 1024 * 1024
+	if executed, err := ssh.volumeCreated(other)(); err != nil || executed {
+		t.Errorf("volumeCreated(other size) = %v, %v, want false", executed, err)
+	}
+	other = opts
+	other.FilePath = "/storage-pool/other/" + executorTestSlot + ".img"
+	if executed, err := ssh.volumeCreated(other)(); err != nil || executed {
+		t.Errorf("volumeCreated(other path) = %v, %v, want false", executed, err)
+	}
+}
+
+func TestCommandExecutor_CreateVolumeFailureCleanup(t *testing.T) {
+	opts := CreateVolumeOptions{
+		Slot:          executorTestSlot,
+		FilePath:      "/storage-pool/metal-csi/" + executorTestSlot + ".img",
+		FileSizeBytes: 10 * 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + executorTestSlot,
+	}
+	add := "/disk add type=file file-path=" + opts.FilePath + " file-size=10G slot=" + executorTestSlot +
+		" nvme-tcp-export=yes nvme-tcp-server-port=4420 nvme-tcp-server-nqn=" + opts.NVMETCPNQN
+	remove := "/disk remove [find slot=" + executorTestSlot + "]"
+
+	tests := []struct {
+		name       string
+		err        error
+		wantRemove bool
+	}{
+		{"rejected", errors.New("failure: not enough space"), true},
+		{"timed out", fmt.Errorf("%w: command did not complete", utils.ErrOperationTimeout), false},
+		{"interrupted", fmt.Errorf("%w: EOF", ErrCommandInterrupted), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, executor := newExecutorTestClient(t, nil)
+			executor.errs = map[string]error{add: tt.err}
+
+			if err := client.CreateVolume(opts); err == nil {
+				t.Fatal("expected CreateVolume to fail")
+			}
+			// After an ambiguous failure the disk may be another call's and in use
+			if removed := slices.Contains(executor.commands, remove); removed != tt.wantRemove {
+				t.Errorf("slot removed = %v, want %v (commands %q)", removed, tt.wantRemove, executor.commands)
+			}
+		})
+	}
+}
+
+func TestCommandExecutor_DeleteVolume(t *testing.T) {
+	client, executor := newExecutorTestClient(t, map[string]string{
+		"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+	})
+
+	if err := client.DeleteVolume(executorTestSlot); err != nil {
+		t.Fatalf("DeleteVolume failed: %v", err)
+	}
+
+	want := []string{
+		"/disk print detail where slot=" + executorTestSlot,
+		"/disk remove [find slot=" + executorTestSlot + "]",
+		`/file remove [find name="storage-pool/metal-csi/` + executorTestSlot + `.img"]`,
+	}
+	if !reflect.DeepEqual(executor.commands, want) {
+		t.Errorf("commands = %q, want %q", executor.commands, want)
+	}
+}
+
+func TestCommandExecutor_CreateSnapshot(t *testing.T) {
+	client, executor := newExecutorTestClient(t, map[string]string{
+		"/disk print detail where slot=" + executorTestSlot: executorTestDisk,
+		"/disk print detail where slot=" + executorTestSnapshot: `type=file slot="` + executorTestSnapshot +
+			`" file-path=/storage-pool/metal-csi/` + executorTestSnapshot + `.img file-size=10.0GiB`,
+	})
+
+	snapshot, err := client.CreateSnapshot(CreateSnapshotOptions{
+		Name:         executorTestSnapshot,
+		SourceVolume: executorTestSlot,
+		BasePath:     "/storage-pool/metal-csi",
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	if snapshot.SourceVolume != executorTestSlot {
+		t.Errorf("SourceVolume = %q, want %q", snapshot.SourceVolume, executorTestSlot)
+	}
+
+	want := []string{
+		"/disk print detail where slot=" + executorTestSlot,
+		"/disk add type=file copy-from=[find slot=" + executorTestSlot + "] file-path=/storage-pool/metal-csi/" +
+			executorTestSnapshot + ".img slot=" + executorTestSnapshot + ` comment="` + executorTestSlot + `"`,
+		"/disk print detail where slot=" + executorTestSnapshot,
+	}
+	if !reflect.DeepEqual(executor.commands, want) {
+		t.Errorf("commands = %q, want %q", executor.commands, want)
+	}
+}
+
+func TestCommandExecutor_VerifySnapshot(t *testing.T) {
+	snapshotDisk := `type=file slot="` + executorTestSnapshot + `" file-path=/storage-pool/metal-csi/` + executorTestSnapshot + `.img file-size=10.0GiB`
+	fileList := `/file print detail where name~"` + regexp.QuoteMeta("storage-pool/metal-csi/"+executorTestSnapshot+".img") + `"`
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{
+			name: "complete copy",
+			file: ` 0 name=storage-pool/metal-csi/` + executorTestSnapshot + `.img type=.img file size=10 737 418 240 creation-time=2025-11-12 00:36:13`,
+		},
+		{
+			name:    "truncated copy",
+			file:    ` 0 name=storage-pool/metal-csi/` + executorTestSnapshot + `.img type=.img file size=10 736 369 664 creation-time=2025-11-12 00:36:13`,
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, executor := newExecutorTestClient(t, map[string]string{
+				"/disk print detail where slot=" + executorTestSnapshot: snapshotDisk,
+				fileList: tt.file,
+			})
+
+			err := client.VerifySnapshot(executorTestSnapshot, 10*1024*1024*1024)
+			var verifyErr *SnapshotVerificationError
+			if tt.wantErr != errors.As(err, &verifyErr) {
+				t.Errorf("VerifySnapshot() error = %v, want verification error %v", err, tt.wantErr)
+			}
+			if len(executor.commands) != 2 || executor.commands[1] != fileList {
+				t.Errorf("commands = %q, want the disk entry and %q", executor.commands, fileList)
+			}
+		})
+	}
+}
+
+func TestCommandExecutor_ClosedWithClient(t *testing.T) {
+	client, executor := newExecutorTestClient(t, nil)
+	if !client.IsConnected() {
+		t.Error("expected a client with an executor to report connected")
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !executor.closed {
+		t.Error("expected Close to close the executor")
+	}
+}
@@ -0,0 +1,82 @@
+package rds
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// UnreferencedFiles returns the driver-owned backing files directly under basePath that no
+// disk entry references. Volume and snapshot files are full copies on RDS, so a file is only
+// live while a disk entry points at it; once the entry is gone the file is dead weight.
+//
+// The selection is conservative: only this driver instance's pvc-* files (see
+// utils.SlotNamingStrategy.IsOwnedVolumeSlot) and snap-* .img files directly in basePath are
+// candidates (never .trash or other subdirectories), and a file is kept if any disk entry
+// uses its path or its name as a slot.
+func UnreferencedFiles(basePath string, files []FileInfo, disks []VolumeInfo) []FileInfo {
+	dir := path.Clean("/" + strings.TrimPrefix(basePath, "/"))
+
+	referencedPaths := make(map[string]bool, len(disks))
+	slots := make(map[string]bool, len(disks))
+	for _, disk := range disks {
+		if disk.FilePath != "" {
+			referencedPaths[path.Clean("/"+strings.TrimPrefix(disk.FilePath, "/"))] = true
+		}
+		slots[disk.Slot] = true
+	}
+
+	slotNaming := utils.GetSlotNamingStrategy()
+	var unreferenced []FileInfo
+	for _, file := range files {
+		if file.Type == "directory" || !strings.HasSuffix(file.Name, ".img") {
+			continue
+		}
+		filePath := path.Clean("/" + strings.TrimPrefix(file.Path, "/"))
+		if path.Dir(filePath) != dir {
+			continue
+		}
+
+		id := strings.TrimSuffix(file.Name, ".img")
+		if !slotNaming.IsOwnedVolumeSlot(id) && !strings.HasPrefix(id, utils.SnapshotIDPrefix) {
+			continue
+		}
+		if referencedPaths[filePath] || slots[id] {
+			continue
+		}
+		unreferenced = append(unreferenced, file)
+	}
+	return unreferenced
+}
+
+// FindUnreferencedFiles lists volume and snapshot backing files under basePath that no
+// disk entry references. Nothing is deleted; see UnreferencedFiles for the selection rules.
+func (c *sshClient) FindUnreferencedFiles(basePath string) ([]FileInfo, error) {
+	if err := utils.ValidateFilePath(basePath); err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	files, err := c.ListFiles(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// List every disk entry, not just pvc-* slots: a file is live if anything uses it
+	output, err := c.runCommand(`/disk print detail`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+	disks, err := parseVolumeList(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disk list: %w", err)
+	}
+
+	unreferenced := UnreferencedFiles(basePath, files, disks)
+	klog.V(4).Infof("Found %d unreferenced files in %s (%d files, %d disk entries)",
+		len(unreferenced), basePath, len(files), len(disks))
+	return unreferenced, nil
+}
@@ -0,0 +1,29 @@
+package rds
+
+import "testing"
+
+func TestUnreferencedFiles(t *testing.T) {
+	disks := []VolumeInfo{
+		{Slot: "pvc-live", FilePath: "/storage-pool/metal-csi/pvc-live.img"},
+		{Slot: "snap-live-at-1", FilePath: "storage-pool/metal-csi/snap-live-at-1.img"},
+		// A disk entry whose file lives elsewhere still protects a same-named file
+		{Slot: "pvc-moved", FilePath: "/other-pool/pvc-moved.img"},
+	}
+	files := []FileInfo{
+		{Name: "metal-csi", Path: "/storage-pool/metal-csi", Type: "directory"},
+		{Name: "pvc-live.img", Path: "/storage-pool/metal-csi/pvc-live.img"},
+		{Name: "snap-live-at-1.img", Path: "/storage-pool/metal-csi/snap-live-at-1.img"},
+		{Name: "pvc-moved.img", Path: "/storage-pool/metal-csi/pvc-moved.img"},
+		{Name: "pvc-dead.img", Path: "/storage-pool/metal-csi/pvc-dead.img"},
+		{Name: "snap-dead-at-1.img", Path: "/storage-pool/metal-csi/snap-dead-at-1.img"},
+		{Name: "pvc-dead.qcow2", Path: "/storage-pool/metal-csi/pvc-dead.qcow2"},
+		{Name: "manual.img", Path: "/storage-pool/metal-csi/manual.img"},
+		{Name: "pvc-x-20260102T150405Z.img", Path: "/storage-pool/metal-csi/.trash/pvc-x-20260102T150405Z.img"},
+		{Name: "pvc-other.img", Path: "/storage-pool/metal-csi-2/pvc-other.img"},
+	}
+
+	got := UnreferencedFiles("/storage-pool/metal-csi/", files, disks)
+	if len(got) != 2 || got[0].Name != "pvc-dead.img" || got[1].Name != "snap-dead-at-1.img" {
+		t.Errorf("UnreferencedFiles() = %+v, want pvc-dead.img and snap-dead-at-1.img", got)
+	}
+}
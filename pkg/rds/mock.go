@@ -2,8 +2,11 @@ package rds
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 // MockClient is a mock implementation of RDSClient for testing
@@ -12,20 +15,40 @@ type MockClient struct {
 	volumes        map[string]*VolumeInfo
 	snapshots      map[string]*SnapshotInfo
 	address        string
-	connected      bool                   // Connection state (for testing connection manager)
-	nextError      error                  // Error to return on next operation
-	persistentErr  error                  // Error to return on all operations until cleared
-	diskMetrics    *DiskMetrics           // Configurable disk metrics response (test helper)
-	hardwareHealth *HardwareHealthMetrics // Configurable hardware health response (test helper)
+	connected      bool                    // Connection state (for testing connection manager)
+	nextError      error                   // Error to return on next operation
+	persistentErr  error                   // Error to return on all operations until cleared
+	diskMetrics    *DiskMetrics            // Configurable disk metrics response (test helper)
+	hardwareHealth *HardwareHealthMetrics  // Configurable hardware health response (test helper)
+	nvmeSessions   map[string]int          // Configurable NVMe/TCP session counts (test helper)
+	trashedFiles   map[string]string       // Backing files retained by TrashVolume, by slot
+	files          map[string]*FileInfo    // Files not backing a volume, such as pre-created ones, by path (test helper)
+	systemInfo     *SystemInfo             // Configurable system information response (test helper)
+	capacity       *CapacityInfo           // Configurable capacity response (test helper)
+	restoreHook    func(snapshotID string) // Called before RestoreSnapshot copies, without the lock held (test helper)
+	deleteErrors   map[string]error        // Errors DeleteVolume and DeleteVolumes return for specific slots (test helper)
+	deleteBatches  [][]string              // Slots of each DeleteVolumes call (test helper)
+
+	// truncateSnapshots makes CreateSnapshot copy less than the source volume, as an
+	// interrupted copy would (test helper)
+	truncateSnapshots bool
+
+	// truncatedSnapshots holds the snapshots whose backing file is shorter than their disk
+	// entry, so VerifySnapshot fails on them
+	truncatedSnapshots map[string]bool
 }
 
 // NewMockClient creates a new MockClient for testing
 func NewMockClient() *MockClient {
 	return &MockClient{
-		volumes:   make(map[string]*VolumeInfo),
-		snapshots: make(map[string]*SnapshotInfo),
-		address:   "mock-rds-server",
-		connected: true, // Default to connected
+		volumes:            make(map[string]*VolumeInfo),
+		snapshots:          make(map[string]*SnapshotInfo),
+		truncatedSnapshots: make(map[string]bool),
+		files:              make(map[string]*FileInfo),
+		trashedFiles:       make(map[string]string),
+		deleteErrors:       make(map[string]error),
+		address:            "mock-rds-server",
+		connected:          true, // Default to connected
 	}
 }
 
@@ -43,6 +66,24 @@ func (m *MockClient) RemoveVolume(slot string) {
 	delete(m.volumes, slot)
 }
 
+// RenameVolume renames a volume's slot, as an administrator might on RDS (test helper)
+func (m *MockClient) RenameVolume(slot, newSlot string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if vol, ok := m.volumes[slot]; ok {
+		delete(m.volumes, slot)
+		vol.Slot = newSlot
+		m.volumes[newSlot] = vol
+	}
+}
+
+// AddFile adds a file to the mock, such as a pre-created backing file (test helper)
+func (m *MockClient) AddFile(f *FileInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[f.Path] = f
+}
+
 // AddSnapshot adds a snapshot to the mock (test helper)
 func (m *MockClient) AddSnapshot(s *SnapshotInfo) {
 	m.mu.Lock()
@@ -78,6 +119,26 @@ func (m *MockClient) SetPersistentError(err error) {
 	m.persistentErr = err
 }
 
+// SetDeleteError makes deleting slot, a volume or snapshot, fail with err, or succeed again when err is nil (test helper)
+func (m *MockClient) SetDeleteError(slot string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		delete(m.deleteErrors, slot)
+		return
+	}
+	m.deleteErrors[slot] = err
+}
+
+// DeleteBatches returns the slots passed to each DeleteVolumes call (test helper)
+func (m *MockClient) DeleteBatches() [][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	batches := make([][]string, len(m.deleteBatches))
+	copy(batches, m.deleteBatches)
+	return batches
+}
+
 // ClearError clears any pending error (test helper)
 func (m *MockClient) ClearError() {
 	m.mu.Lock()
@@ -155,8 +216,23 @@ func (m *MockClient) CreateVolume(opts CreateVolumeOptions) error {
 		return err
 	}
 
+	if err := m.checkQoSSupported(opts.QoS); err != nil {
+		return err
+	}
+
 	if _, exists := m.volumes[opts.Slot]; exists {
-		return fmt.Errorf("volume %s already exists", opts.Slot)
+		return fmt.Errorf("%w: %s", utils.ErrVolumeExists, opts.Slot)
+	}
+
+	if opts.AdoptExistingFile {
+		file, exists := m.files[opts.FilePath]
+		if !exists {
+			return fmt.Errorf("file %s does not exist", opts.FilePath)
+		}
+		if file.SizeBytes != opts.FileSizeBytes {
+			return fmt.Errorf("%w: file %s has size %d", utils.ErrVolumeExists, opts.FilePath, file.SizeBytes)
+		}
+		delete(m.files, opts.FilePath)
 	}
 
 	m.volumes[opts.Slot] = &VolumeInfo{
@@ -168,6 +244,8 @@ func (m *MockClient) CreateVolume(opts CreateVolumeOptions) error {
 		NVMETCPPort:   opts.NVMETCPPort,
 		NVMETCPNQN:    opts.NVMETCPNQN,
 		Status:        "ready",
+		Comment:       opts.Comment,
+		QoS:           opts.QoS,
 	}
 	return nil
 }
@@ -182,6 +260,37 @@ func (m *MockClient) DeleteVolume(slot string) error {
 		return err
 	}
 
+	return m.deleteVolumeLocked(slot)
+}
+
+// DeleteVolumes implements RDSClient
+func (m *MockClient) DeleteVolumes(slots []string) map[string]error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deleteBatches = append(m.deleteBatches, append([]string(nil), slots...))
+	results := make(map[string]error, len(slots))
+
+	// A pending error fails the whole batch, as a lost connection would
+	if err := m.checkError(); err != nil {
+		for _, slot := range slots {
+			results[slot] = err
+		}
+		return results
+	}
+
+	for _, slot := range slots {
+		results[slot] = m.deleteVolumeLocked(slot)
+	}
+	return results
+}
+
+// deleteVolumeLocked deletes one volume. Caller must hold m.mu.
+func (m *MockClient) deleteVolumeLocked(slot string) error {
+	if err := m.deleteErrors[slot]; err != nil {
+		return err
+	}
+
 	if _, exists := m.volumes[slot]; !exists {
 		// Idempotent - not an error if doesn't exist
 		return nil
@@ -191,6 +300,39 @@ func (m *MockClient) DeleteVolume(slot string) error {
 	return nil
 }
 
+// TrashVolume implements RDSClient
+func (m *MockClient) TrashVolume(slot string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return "", err
+	}
+
+	vol, exists := m.volumes[slot]
+	if !exists {
+		// Idempotent - not an error if doesn't exist
+		return "", nil
+	}
+
+	delete(m.volumes, slot)
+	if vol.FilePath == "" {
+		return "", nil
+	}
+	trashPath := TrashFilePath(vol.FilePath, slot, time.Now())
+	m.trashedFiles[slot] = trashPath
+	return trashPath, nil
+}
+
+// GetTrashedFile returns the path TrashVolume moved a volume's backing file to (test helper)
+func (m *MockClient) GetTrashedFile(slot string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	trashPath, ok := m.trashedFiles[slot]
+	return trashPath, ok
+}
+
 // ResizeVolume implements RDSClient
 func (m *MockClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	m.mu.Lock()
@@ -210,6 +352,27 @@ func (m *MockClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	return nil
 }
 
+// SetDiskComment implements RDSClient
+func (m *MockClient) SetDiskComment(slot, comment string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return err
+	}
+
+	if vol, exists := m.volumes[slot]; exists {
+		vol.Comment = comment
+		return nil
+	}
+	if snap, exists := m.snapshots[slot]; exists {
+		snap.Comment = comment
+		return nil
+	}
+	return &VolumeNotFoundError{Slot: slot}
+}
+
 // GetVolume implements RDSClient
 func (m *MockClient) GetVolume(slot string) (*VolumeInfo, error) {
 	m.mu.Lock()
@@ -230,6 +393,24 @@ func (m *MockClient) GetVolume(slot string) (*VolumeInfo, error) {
 	return &copy, nil
 }
 
+// GetVolumeByFilePath implements RDSClient
+func (m *MockClient) GetVolumeByFilePath(filePath string) (*VolumeInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkError(); err != nil {
+		return nil, err
+	}
+
+	for _, vol := range m.volumes {
+		if vol.FilePath == filePath {
+			copy := *vol
+			return &copy, nil
+		}
+	}
+	return nil, &VolumeNotFoundError{Slot: filePath}
+}
+
 // VerifyVolumeExists implements RDSClient
 func (m *MockClient) VerifyVolumeExists(slot string) error {
 	m.mu.RLock()
@@ -255,16 +436,53 @@ func (m *MockClient) ListVolumes() ([]VolumeInfo, error) {
 
 // ListFiles implements RDSClient
 func (m *MockClient) ListFiles(path string) ([]FileInfo, error) {
-	return nil, nil
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var files []FileInfo
+	for filePath, f := range m.files {
+		if strings.HasPrefix(filePath, path) {
+			files = append(files, *f)
+		}
+	}
+	return files, nil
 }
 
 // DeleteFile implements RDSClient
 func (m *MockClient) DeleteFile(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, path)
 	return nil
 }
 
+// FindUnreferencedFiles implements RDSClient
+func (m *MockClient) FindUnreferencedFiles(basePath string) ([]FileInfo, error) {
+	return nil, nil
+}
+
+// SetCapacity sets the GetCapacity response for testing (nil restores the default)
+func (m *MockClient) SetCapacity(capacity *CapacityInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.capacity = capacity
+}
+
 // GetCapacity implements RDSClient
 func (m *MockClient) GetCapacity(basePath string) (*CapacityInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return nil, err
+	}
+
+	if m.capacity != nil {
+		copy := *m.capacity
+		return &copy, nil
+	}
+
 	return &CapacityInfo{
 		TotalBytes: 1024 * 1024 * 1024 * 1024, // 1 TiB
 		FreeBytes:  512 * 1024 * 1024 * 1024,  // 512 GiB
@@ -313,7 +531,9 @@ func (m *MockClient) CreateSnapshot(opts CreateSnapshotOptions) (*SnapshotInfo,
 		FileSizeBytes: sourceVol.FileSizeBytes,
 		CreatedAt:     time.Now(),
 		FilePath:      filePath,
+		Comment:       SnapshotComment(opts.Comment, opts.SourceVolume),
 	}
+	m.truncatedSnapshots[opts.Name] = m.truncateSnapshots
 	m.snapshots[opts.Name] = snapshot
 
 	// Return copy to prevent mutation
@@ -331,8 +551,13 @@ func (m *MockClient) DeleteSnapshot(snapshotID string) error {
 		return err
 	}
 
+	if err := m.deleteErrors[snapshotID]; err != nil {
+		return err
+	}
+
 	// Idempotent - not an error if doesn't exist
 	delete(m.snapshots, snapshotID)
+	delete(m.truncatedSnapshots, snapshotID)
 	return nil
 }
 
@@ -356,6 +581,31 @@ func (m *MockClient) GetSnapshot(snapshotID string) (*SnapshotInfo, error) {
 	return &copy, nil
 }
 
+// VerifySnapshot implements RDSClient
+func (m *MockClient) VerifySnapshot(snapshotID string, sizeBytes int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return err
+	}
+
+	snapshot, exists := m.snapshots[snapshotID]
+	if !exists {
+		return &SnapshotNotFoundError{Name: snapshotID}
+	}
+	fileSize := snapshot.FileSizeBytes
+	if m.truncatedSnapshots[snapshotID] {
+		fileSize -= 1024 * 1024
+	}
+	if fileSize != sizeBytes {
+		return &SnapshotVerificationError{Name: snapshotID,
+			Reason: fmt.Sprintf("backing file is %d bytes, expected %d", fileSize, sizeBytes)}
+	}
+	return nil
+}
+
 // ListSnapshots implements RDSClient
 func (m *MockClient) ListSnapshots() ([]SnapshotInfo, error) {
 	m.mu.RLock()
@@ -373,8 +623,34 @@ func (m *MockClient) ListSnapshots() ([]SnapshotInfo, error) {
 	return result, nil
 }
 
+// ListSnapshotsBySource implements RDSClient
+func (m *MockClient) ListSnapshotsBySource(sourceVolume string) ([]SnapshotInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return nil, err
+	}
+
+	result := make([]SnapshotInfo, 0)
+	for _, snapshot := range m.snapshots {
+		if snapshot.SourceVolume == sourceVolume {
+			result = append(result, *snapshot)
+		}
+	}
+	return result, nil
+}
+
 // RestoreSnapshot implements RDSClient
 func (m *MockClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolumeOptions) error {
+	m.mu.RLock()
+	hook := m.restoreHook
+	m.mu.RUnlock()
+	if hook != nil {
+		hook(snapshotID)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -388,6 +664,10 @@ func (m *MockClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolu
 		return &SnapshotNotFoundError{Name: snapshotID}
 	}
 
+	if err := m.checkQoSSupported(newVolumeOpts.QoS); err != nil {
+		return err
+	}
+
 	// Create new volume using provided options (same pattern as CreateVolume)
 	if _, exists := m.volumes[newVolumeOpts.Slot]; exists {
 		return fmt.Errorf("volume %s already exists", newVolumeOpts.Slot)
@@ -402,6 +682,8 @@ func (m *MockClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolu
 		NVMETCPPort:   newVolumeOpts.NVMETCPPort,
 		NVMETCPNQN:    newVolumeOpts.NVMETCPNQN,
 		Status:        "ready",
+		Comment:       newVolumeOpts.Comment,
+		QoS:           newVolumeOpts.QoS,
 	}
 	return nil
 }
@@ -469,3 +751,84 @@ func (m *MockClient) GetHardwareHealth(snmpHost string, snmpCommunity string) (*
 		DiskPoolUsedBytes: 1_600_000_000_000, // 1.6TB (20% used)
 	}, nil
 }
+
+// SetNVMeSessions sets the NVMe/TCP session counts per slot for testing
+func (m *MockClient) SetNVMeSessions(sessions map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nvmeSessions = sessions
+}
+
+// GetNVMeSessions implements RDSClient
+func (m *MockClient) GetNVMeSessions() (map[string]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string]int, len(m.nvmeSessions))
+	for slot, count := range m.nvmeSessions {
+		sessions[slot] = count
+	}
+	return sessions, nil
+}
+
+// SetTruncateSnapshots makes CreateSnapshot record snapshots 1 MiB smaller than their
+// source volume, so VerifySnapshot fails on them (test helper)
+func (m *MockClient) SetTruncateSnapshots(truncate bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.truncateSnapshots = truncate
+}
+
+// SetRestoreHook sets a function RestoreSnapshot calls before copying, e.g. to block and
+// simulate a slow copy (test helper)
+func (m *MockClient) SetRestoreHook(hook func(snapshotID string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restoreHook = hook
+}
+
+// SetSystemInfo sets the system information response for testing
+func (m *MockClient) SetSystemInfo(info *SystemInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.systemInfo = info
+}
+
+// GetSystemInfo implements RDSClient
+func (m *MockClient) GetSystemInfo() (*SystemInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check for pending error
+	if err := m.checkError(); err != nil {
+		return nil, err
+	}
+
+	info := m.currentSystemInfo()
+	return &info, nil
+}
+
+// currentSystemInfo returns the configured system information, defaulting to RouterOS 7.16.
+// Caller must hold m.mu.
+func (m *MockClient) currentSystemInfo() SystemInfo {
+	if m.systemInfo != nil {
+		return *m.systemInfo
+	}
+	return SystemInfo{Version: "7.16", Channel: "stable", BoardName: "RDS2216", ArchitectureName: "arm64"}
+}
+
+// checkQoSSupported mirrors the RouterOS version gate of the SSH client. Caller must hold m.mu.
+func (m *MockClient) checkQoSSupported(qos VolumeQoS) error {
+	if !qos.IsSet() {
+		return nil
+	}
+	if version := m.currentSystemInfo().Version; !SupportsVolumeQoS(version) {
+		return fmt.Errorf("%w: RDS runs RouterOS %s, IO limits need %s or later", ErrQoSUnsupported, version, VolumeQoSMinVersion)
+	}
+	return nil
+}
@@ -15,7 +15,8 @@ var (
 	// ErrPoolClosed is returned when attempting to use a closed pool
 	ErrPoolClosed = errors.New("connection pool is closed")
 
-	// ErrPoolExhausted is returned when the pool has reached max connections
+	// ErrPoolExhausted is returned when the pool has reached max connections, or when
+	// too many commands of a class are already waiting for a session
 	ErrPoolExhausted = errors.New("connection pool exhausted")
 
 	// ErrCircuitOpen is returned when the circuit breaker is open
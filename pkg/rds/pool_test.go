@@ -52,10 +52,22 @@ func (m *mockRDSClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	return nil
 }
 
+func (m *mockRDSClient) SetDiskComment(slot, comment string) error {
+	return nil
+}
+
 func (m *mockRDSClient) GetVolume(slot string) (*VolumeInfo, error) {
 	return nil, nil
 }
 
+func (m *mockRDSClient) DeleteVolumes(slots []string) map[string]error {
+	return nil
+}
+
+func (m *mockRDSClient) TrashVolume(slot string) (string, error) {
+	return "", nil
+}
+
 func (m *mockRDSClient) VerifyVolumeExists(slot string) error {
 	return nil
 }
@@ -72,6 +84,10 @@ func (m *mockRDSClient) DeleteFile(path string) error {
 	return nil
 }
 
+func (m *mockRDSClient) FindUnreferencedFiles(basePath string) ([]FileInfo, error) {
+	return nil, nil
+}
+
 func (m *mockRDSClient) GetCapacity(basePath string) (*CapacityInfo, error) {
 	return nil, nil
 }
@@ -88,10 +104,22 @@ func (m *mockRDSClient) GetSnapshot(snapshotID string) (*SnapshotInfo, error) {
 	return nil, nil
 }
 
+func (m *mockRDSClient) GetVolumeByFilePath(filePath string) (*VolumeInfo, error) {
+	return nil, nil
+}
+
 func (m *mockRDSClient) ListSnapshots() ([]SnapshotInfo, error) {
 	return nil, nil
 }
 
+func (m *mockRDSClient) ListSnapshotsBySource(sourceVolume string) ([]SnapshotInfo, error) {
+	return nil, nil
+}
+
+func (m *mockRDSClient) VerifySnapshot(snapshotID string, sizeBytes int64) error {
+	return nil
+}
+
 func (m *mockRDSClient) RestoreSnapshot(snapshotID string, newVolumeOpts CreateVolumeOptions) error {
 	return nil
 }
@@ -104,6 +132,14 @@ func (m *mockRDSClient) GetHardwareHealth(snmpHost string, snmpCommunity string)
 	return &HardwareHealthMetrics{}, nil
 }
 
+func (m *mockRDSClient) GetNVMeSessions() (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockRDSClient) GetSystemInfo() (*SystemInfo, error) {
+	return &SystemInfo{}, nil
+}
+
 func TestNewConnectionPool(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,7 @@
+//go:build race
+
+package rds
+
+// raceEnabled is true in builds with the race detector, whose instrumentation adds
+// allocations that absolute allocation budgets do not account for
+const raceEnabled = true
@@ -0,0 +1,18 @@
+package rds
+
+import "regexp"
+
+var (
+	// secretArgPattern matches RouterOS arguments and properties whose values are secret
+	secretArgPattern = regexp.MustCompile(`\b((?:password|passphrase|secret|token|[\w-]*key)=)("[^"]*"|\S+)`)
+
+	// privateKeyPattern matches PEM-encoded private keys
+	privateKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// redactSecrets replaces secret values in RouterOS commands, output and errors with
+// "<redacted>" before they are written to the audit log or the debug log
+func redactSecrets(text string) string {
+	text = privateKeyPattern.ReplaceAllString(text, "<redacted private key>")
+	return secretArgPattern.ReplaceAllString(text, "${1}<redacted>")
+}
@@ -0,0 +1,229 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// DefaultBackendName is the backend configured by --rds-address, used when a
+// StorageClass does not select one
+const DefaultBackendName = "default"
+
+// ErrUnknownBackend is returned when a backend name is not configured
+var ErrUnknownBackend = errors.New("unknown RDS backend")
+
+// Backend is a named RDS array the controller can provision on
+type Backend struct {
+	Name   string
+	Client RDSClient
+
+	// NVMEAddress is the NVMe/TCP target address nodes connect to.
+	// Empty means the client's SSH address.
+	NVMEAddress string
+}
+
+// TargetAddress returns the address nodes use for NVMe/TCP connections to this backend
+func (b *Backend) TargetAddress() string {
+	if b.NVMEAddress != "" {
+		return b.NVMEAddress
+	}
+	return b.Client.GetAddress()
+}
+
+// ClientRegistry maps backend names to RDS clients.
+// The default backend always exists; additional backends are registered by name.
+type ClientRegistry struct {
+	mu       sync.RWMutex
+	backends map[string]*Backend
+}
+
+// NewClientRegistry creates a registry whose default backend uses client
+func NewClientRegistry(client RDSClient) *ClientRegistry {
+	return &ClientRegistry{
+		backends: map[string]*Backend{
+			DefaultBackendName: {Name: DefaultBackendName, Client: client},
+		},
+	}
+}
+
+// ForCall returns a registry of the same backends whose clients are ForCall views
+// attributing their commands to call. It must not be closed.
+func (r *ClientRegistry) ForCall(call Call) *ClientRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	view := &ClientRegistry{backends: make(map[string]*Backend, len(r.backends))}
+	for name, backend := range r.backends {
+		view.backends[name] = &Backend{Name: backend.Name, Client: ForCall(backend.Client, call), NVMEAddress: backend.NVMEAddress}
+	}
+	return view
+}
+
+// SetDefault replaces the default backend's client
+func (r *ClientRegistry) SetDefault(client RDSClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[DefaultBackendName].Client = client
+}
+
+// Register adds a named backend. Names must be unique and not "default".
+func (r *ClientRegistry) Register(backend *Backend) error {
+	if backend == nil || backend.Client == nil {
+		return fmt.Errorf("backend must have a client")
+	}
+	if err := ValidateBackendName(backend.Name); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.backends[backend.Name]; exists {
+		return fmt.Errorf("RDS backend %q already registered", backend.Name)
+	}
+	r.backends[backend.Name] = backend
+	return nil
+}
+
+// Get returns the backend with the given name; "" selects the default backend
+func (r *ClientRegistry) Get(name string) (*Backend, error) {
+	if name == "" {
+		name = DefaultBackendName
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	backend, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (configured: %v)", ErrUnknownBackend, name, r.namesLocked())
+	}
+	return backend, nil
+}
+
+// Default returns the default backend
+func (r *ClientRegistry) Default() *Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.backends[DefaultBackendName]
+}
+
+// Backends returns all backends, default first, then by name
+func (r *ClientRegistry) Backends() []*Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	backends := make([]*Backend, 0, len(r.backends))
+	for _, name := range r.namesLocked() {
+		backends = append(backends, r.backends[name])
+	}
+	return backends
+}
+
+// Names returns the configured backend names, default first
+func (r *ClientRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+func (r *ClientRegistry) namesLocked() []string {
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		if name != DefaultBackendName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultBackendName}, names...)
+}
+
+// FindVolume locates the backend holding a volume, for calls that only carry a volume ID.
+// Returns utils.ErrVolumeNotFound if no backend has it. If a backend cannot be queried
+// and no other backend has the volume, that error is returned instead, because the
+// volume may live on the unreachable array.
+func (r *ClientRegistry) FindVolume(volumeID string) (*Backend, *VolumeInfo, error) {
+	var queryErr error
+	for _, backend := range r.Backends() {
+		volume, err := backend.Client.GetVolume(volumeID)
+		if err == nil {
+			return backend, volume, nil
+		}
+		var notFoundErr *VolumeNotFoundError
+		if errors.As(err, &notFoundErr) || errors.Is(err, utils.ErrVolumeNotFound) {
+			continue
+		}
+		klog.V(4).Infof("Failed to query backend %s for volume %s: %v", backend.Name, volumeID, err)
+		if queryErr == nil {
+			queryErr = fmt.Errorf("backend %s: %w", backend.Name, err)
+		}
+	}
+	if queryErr != nil {
+		return nil, nil, queryErr
+	}
+	return nil, nil, &VolumeNotFoundError{Slot: volumeID}
+}
+
+// FindVolumeByFilePath locates the backend and volume backed by filePath, for volumes
+// whose slot no longer matches their volume ID. Error handling matches FindVolume.
+func (r *ClientRegistry) FindVolumeByFilePath(filePath string) (*Backend, *VolumeInfo, error) {
+	var queryErr error
+	for _, backend := range r.Backends() {
+		volume, err := backend.Client.GetVolumeByFilePath(filePath)
+		if err == nil {
+			return backend, volume, nil
+		}
+		var notFoundErr *VolumeNotFoundError
+		if errors.As(err, &notFoundErr) || errors.Is(err, utils.ErrVolumeNotFound) {
+			continue
+		}
+		klog.V(4).Infof("Failed to query backend %s for backing file %s: %v", backend.Name, filePath, err)
+		if queryErr == nil {
+			queryErr = fmt.Errorf("backend %s: %w", backend.Name, err)
+		}
+	}
+	if queryErr != nil {
+		return nil, nil, queryErr
+	}
+	return nil, nil, &VolumeNotFoundError{Slot: filePath}
+}
+
+// FindSnapshot locates the backend holding a snapshot. Error handling matches FindVolume.
+func (r *ClientRegistry) FindSnapshot(snapshotID string) (*Backend, *SnapshotInfo, error) {
+	var queryErr error
+	for _, backend := range r.Backends() {
+		snapshot, err := backend.Client.GetSnapshot(snapshotID)
+		if err == nil {
+			return backend, snapshot, nil
+		}
+		var notFoundErr *SnapshotNotFoundError
+		if errors.As(err, &notFoundErr) {
+			continue
+		}
+		klog.V(4).Infof("Failed to query backend %s for snapshot %s: %v", backend.Name, snapshotID, err)
+		if queryErr == nil {
+			queryErr = fmt.Errorf("backend %s: %w", backend.Name, err)
+		}
+	}
+	if queryErr != nil {
+		return nil, nil, queryErr
+	}
+	return nil, nil, &SnapshotNotFoundError{Name: snapshotID}
+}
+
+// Close closes the clients of all non-default backends.
+// The default client is owned and closed by the driver.
+func (r *ClientRegistry) Close() {
+	for _, backend := range r.Backends() {
+		if backend.Name == DefaultBackendName {
+			continue
+		}
+		if err := backend.Client.Close(); err != nil {
+			klog.Errorf("Error closing RDS client for backend %s: %v", backend.Name, err)
+		}
+	}
+}
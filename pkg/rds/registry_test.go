@@ -0,0 +1,162 @@
+package rds
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+func newTestRegistry(t *testing.T) (*ClientRegistry, *MockClient, *MockClient) {
+	t.Helper()
+	defaultClient := NewMockClient()
+	defaultClient.SetAddress("10.0.0.1")
+	array2 := NewMockClient()
+	array2.SetAddress("10.0.0.2")
+
+	registry := NewClientRegistry(defaultClient)
+	if err := registry.Register(&Backend{Name: "array2", Client: array2}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	return registry, defaultClient, array2
+}
+
+func TestClientRegistry_Get(t *testing.T) {
+	registry, defaultClient, array2 := newTestRegistry(t)
+
+	backend, err := registry.Get("")
+	if err != nil || backend.Client != defaultClient {
+		t.Errorf("Get(\"\") = %v, %v, want default backend", backend, err)
+	}
+
+	backend, err = registry.Get("array2")
+	if err != nil || backend.Client != array2 {
+		t.Errorf("Get(array2) = %v, %v, want array2", backend, err)
+	}
+	if backend.TargetAddress() != "10.0.0.2" {
+		t.Errorf("TargetAddress() = %q, want client address", backend.TargetAddress())
+	}
+
+	_, err = registry.Get("array3")
+	if !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("Get(array3) error = %v, want ErrUnknownBackend", err)
+	}
+
+	if got := registry.Names(); len(got) != 2 || got[0] != DefaultBackendName || got[1] != "array2" {
+		t.Errorf("Names() = %v, want [default array2]", got)
+	}
+}
+
+func TestClientRegistry_Register(t *testing.T) {
+	registry, _, _ := newTestRegistry(t)
+
+	tests := []struct {
+		name    string
+		backend *Backend
+	}{
+		{name: "duplicate name", backend: &Backend{Name: "array2", Client: NewMockClient()}},
+		{name: "reserved default name", backend: &Backend{Name: DefaultBackendName, Client: NewMockClient()}},
+		{name: "invalid name", backend: &Backend{Name: "Array_3", Client: NewMockClient()}},
+		{name: "missing client", backend: &Backend{Name: "array3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := registry.Register(tt.backend); err == nil {
+				t.Error("expected Register to fail")
+			}
+		})
+	}
+}
+
+func TestClientRegistry_FindVolume(t *testing.T) {
+	registry, defaultClient, array2 := newTestRegistry(t)
+	array2.AddVolume(&VolumeInfo{Slot: "pvc-on-array2"})
+
+	backend, volume, err := registry.FindVolume("pvc-on-array2")
+	if err != nil {
+		t.Fatalf("FindVolume failed: %v", err)
+	}
+	if backend.Name != "array2" || volume.Slot != "pvc-on-array2" {
+		t.Errorf("FindVolume = %s/%s, want array2/pvc-on-array2", backend.Name, volume.Slot)
+	}
+
+	var notFoundErr *VolumeNotFoundError
+	if _, _, err := registry.FindVolume("pvc-missing"); !errors.As(err, &notFoundErr) {
+		t.Errorf("FindVolume(missing) error = %v, want VolumeNotFoundError", err)
+	}
+
+	// An unreachable backend might hold the volume, so "not found" cannot be claimed
+	defaultClient.SetPersistentError(utils.ErrConnectionFailed)
+	if _, _, err := registry.FindVolume("pvc-missing"); !errors.Is(err, utils.ErrConnectionFailed) {
+		t.Errorf("FindVolume with unreachable backend error = %v, want ErrConnectionFailed", err)
+	}
+
+	// A volume on a reachable backend is still found
+	if backend, _, err := registry.FindVolume("pvc-on-array2"); err != nil || backend.Name != "array2" {
+		t.Errorf("FindVolume with unreachable default = %v, %v, want array2", backend, err)
+	}
+}
+
+func TestLoadBackendsConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "backends.yaml")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		return path
+	}
+
+	backends, err := LoadBackendsConfig(write(`
+backends:
+  array2:
+    address: 10.42.241.4
+    privateKeyFile: /etc/rds-csi/backends/array2/id_rsa
+    hostKeyFingerprints: ["SHA256:abc"]
+    nvmeAddress: 10.42.68.2
+`))
+	if err != nil {
+		t.Fatalf("LoadBackendsConfig failed: %v", err)
+	}
+	if b, ok := backends["array2"]; !ok || b.Address != "10.42.241.4" || b.NVMEAddress != "10.42.68.2" {
+		t.Errorf("unexpected backends: %+v", backends)
+	}
+
+	invalid := []struct {
+		name      string
+		content   string
+		expectErr string
+	}{
+		{
+			name:      "reserved name",
+			content:   "backends:\n  default:\n    address: 10.0.0.1\n    privateKeyFile: /k\n    insecureSkipVerify: true\n",
+			expectErr: "reserved",
+		},
+		{
+			name:      "invalid address",
+			content:   "backends:\n  array2:\n    address: rds.local\n    privateKeyFile: /k\n    insecureSkipVerify: true\n",
+			expectErr: "invalid address",
+		},
+		{
+			name:      "missing host key",
+			content:   "backends:\n  array2:\n    address: 10.0.0.2\n    privateKeyFile: /k\n",
+			expectErr: "hostKeyFile or hostKeyFingerprints",
+		},
+		{
+			name:      "unknown field",
+			content:   "backends:\n  array2:\n    adress: 10.0.0.2\n",
+			expectErr: "adress",
+		},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBackendsConfig(write(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package rds
+
+import (
+	"errors"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRetryBudgetRate is the default number of command retries per second all
+	// operations together may make
+	DefaultRetryBudgetRate = 1.0
+
+	// DefaultRetryBudgetBurst is the default number of retries that may be made at once
+	// before the rate applies
+	DefaultRetryBudgetBurst = 10
+)
+
+// ErrRetryBudgetExhausted is returned instead of retrying a failed command once the retry
+// budget is used up
+var ErrRetryBudgetExhausted = errors.New("RDS retry budget exhausted")
+
+// RetryBudget caps the rate of command retries across all operations, so that when RDS
+// is degraded many concurrent operations failing at once do not add to its load with
+// their retries. Unlike the per-command retry count it bounds the retry pressure of the
+// whole driver: once the budget is used up, failed commands are not retried until it
+// refills. It may be shared between clients; a nil RetryBudget never runs out.
+type RetryBudget struct {
+	limiter *rate.Limiter
+	burst   int
+}
+
+// NewRetryBudget returns a budget refilling perSecond retries per second up to burst,
+// or nil (unlimited retries) if perSecond is 0 or less
+func NewRetryBudget(perSecond float64, burst int) *RetryBudget {
+	if perSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RetryBudget{limiter: rate.NewLimiter(rate.Limit(perSecond), burst), burst: burst}
+}
+
+// allow takes a retry from the budget, reporting false if none is left
+func (b *RetryBudget) allow() bool {
+	if b == nil {
+		return true
+	}
+	return b.limiter.Allow()
+}
+
+// Consumed returns the share of the budget currently used up, from 0 (full) to 1 (exhausted)
+func (b *RetryBudget) Consumed() float64 {
+	if b == nil {
+		return 0
+	}
+	consumed := 1 - b.limiter.Tokens()/float64(b.burst)
+	return min(max(consumed, 0), 1)
+}
@@ -0,0 +1,78 @@
+package rds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingExecutor fails every command it runs as if the connection had dropped
+type failingExecutor struct {
+	runs atomic.Int32
+}
+
+func (e *failingExecutor) Run(ctx context.Context, command string) (string, error) {
+	e.runs.Add(1)
+	return "", fmt.Errorf("failed to create SSH session: %w", ErrCommandNotSent)
+}
+
+func TestRetryBudget_ExhaustedFailsFast(t *testing.T) {
+	executor := &failingExecutor{}
+	// Practically no refill, so only the burst of 2 retries is available
+	budget := NewRetryBudget(0.001, 2)
+	client := &sshClient{sshState: &sshState{address: "10.42.68.1", executor: executor, retryBudget: budget}}
+
+	// A burst of 5 failing operations allowed one retry each: 2 get to retry
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.runCommandWithRetry("/disk print", 2, idempotentCommand)
+		}(i)
+	}
+	wg.Wait()
+
+	rejected := 0
+	for _, err := range errs {
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrCommandNotSent)
+		if errors.Is(err, ErrRetryBudgetExhausted) {
+			rejected++
+		}
+	}
+	assert.Equal(t, 3, rejected, "operations refused a retry")
+	assert.Equal(t, int32(7), executor.runs.Load(), "5 attempts and 2 retries")
+	assert.InDelta(t, 1, budget.Consumed(), 0.01)
+
+	// Once exhausted, a failing command is not retried and does not wait for a backoff
+	runsBefore := executor.runs.Load()
+	start := time.Now()
+	_, err := client.runCommandWithRetry("/disk print", 3, idempotentCommand)
+	assert.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	assert.Equal(t, runsBefore+1, executor.runs.Load(), "command must run once")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRetryBudget_Disabled(t *testing.T) {
+	assert.Nil(t, NewRetryBudget(0, 10))
+
+	var budget *RetryBudget
+	for range 100 {
+		assert.True(t, budget.allow())
+	}
+	assert.Zero(t, budget.Consumed())
+
+	budget = NewRetryBudget(1, 4)
+	assert.Zero(t, budget.Consumed())
+	assert.True(t, budget.allow())
+	assert.InDelta(t, 0.25, budget.Consumed(), 0.01)
+}
@@ -0,0 +1,303 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+const (
+	// DefaultMaxSessions is the default number of RouterOS commands run at once on the
+	// connection to one RDS
+	DefaultMaxSessions = 8
+
+	// commandQueueLength is how many commands of one class may wait for a session; more
+	// fail with ErrPoolExhausted
+	commandQueueLength = 64
+
+	// monitoringSessions is the most sessions monitoring commands may hold at once
+	monitoringSessions = 1
+)
+
+// commandClass is the scheduling class of a RouterOS command
+type commandClass int
+
+const (
+	classProvisioning commandClass = iota // Creating and resizing disks
+	classDeletion                         // Removing disks and files
+	classQuery                            // Reading state
+	classMonitoring                       // Polled by metrics scrapes
+	numCommandClasses
+)
+
+// commandClassWeights are the shares of the sessions each class gets while all classes
+// have commands waiting
+var commandClassWeights = [numCommandClasses]int{
+	classProvisioning: 4,
+	classDeletion:     2,
+	classQuery:        2,
+	classMonitoring:   1,
+}
+
+// String returns the class name, the class label of the queue depth metric
+func (c commandClass) String() string {
+	switch c {
+	case classProvisioning:
+		return "provisioning"
+	case classDeletion:
+		return "deletion"
+	case classQuery:
+		return "query"
+	case classMonitoring:
+		return "monitoring"
+	}
+	return "unknown"
+}
+
+// classifyCommand returns the scheduling class of a command by its menu and verb. The
+// only writes to the /file menu are the moves of deleted volumes to the trash. A script
+// (":foreach ... do={...}") has the class of the first command it runs.
+func classifyCommand(command string) commandClass {
+	if inner, ok := scriptCommand(command); ok {
+		return classifyCommand(inner)
+	}
+	menu, verb := splitCommand(command)
+	switch {
+	case menu == "/disk" && verb == "monitor-traffic", menu == "/interface nvme-tcp connection":
+		return classMonitoring
+	case verb == "remove", menu == "/file" && (verb == "add" || verb == "set"):
+		return classDeletion
+	case verb == "add", verb == "set", verb == "enable", verb == "disable":
+		return classProvisioning
+	}
+	return classQuery
+}
+
+// scriptCommand returns the first menu command in the do={...} body of a script, such as
+// "/disk remove [find slot=$s]" of the batched deletion
+func scriptCommand(command string) (string, bool) {
+	if !strings.HasPrefix(command, ":") {
+		return "", false
+	}
+	_, body, ok := strings.Cut(command, "do={")
+	if !ok {
+		return "", false
+	}
+	start := strings.Index(body, "/")
+	if start < 0 {
+		return "", false
+	}
+	inner, _, _ := strings.Cut(body[start:], ";")
+	return strings.TrimRight(inner, "}"), true
+}
+
+// commandScheduler limits the RouterOS commands running at once on a connection and
+// shares the sessions fairly between command classes, so that a burst of one kind of
+// operation, such as the deletions of a namespace cleanup, does not hold up the others.
+// Commands wait in a bounded queue per class; when a session frees up, the next class
+// is picked by smooth weighted round-robin among the classes with commands waiting.
+// Monitoring commands get the smallest weight and at most monitoringSessions sessions,
+// and only while no provisioning command is waiting. A nil scheduler does not limit.
+type commandScheduler struct {
+	address string
+	metrics *observability.Metrics
+
+	mu               sync.Mutex
+	free             int
+	queues           [numCommandClasses][]*commandWaiter
+	current          [numCommandClasses]int // Smooth weighted round-robin state
+	monitoringActive int
+
+	// operations holds the class of the operation working on each slot
+	operations map[string]commandClass
+}
+
+// commandWaiter is a command waiting for a session
+type commandWaiter struct {
+	class   commandClass
+	ready   chan struct{} // Closed when the session is granted
+	granted bool
+}
+
+// newCommandScheduler returns a scheduler running up to maxSessions commands at once on
+// the connection to address, or nil (no limit) if maxSessions is 0 or less
+func newCommandScheduler(address string, maxSessions int, metrics *observability.Metrics) *commandScheduler {
+	if maxSessions <= 0 {
+		return nil
+	}
+	return &commandScheduler{
+		address:    address,
+		metrics:    metrics,
+		free:       maxSessions,
+		operations: make(map[string]commandClass),
+	}
+}
+
+// beginOperation schedules the commands targeting slot in class until the returned function
+// is called, so that the lookups of an operation share its class: the disk queries of a
+// deletion count as deletion and those of a volume creation as provisioning. RDS clients
+// take no context, so the slot is what ties a command to its operation. Monitoring
+// commands keep their class.
+func (s *commandScheduler) beginOperation(slot string, class commandClass) func() {
+	if s == nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, nested := s.operations[slot]
+	s.operations[slot] = class
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if nested {
+			s.operations[slot] = previous
+		} else {
+			delete(s.operations, slot)
+		}
+	}
+}
+
+// commandSlotPattern matches the disk slot a command targets ("slot=x" or "[find slot=x]").
+// A script variable ("slot=$s") names no slot.
+var commandSlotPattern = regexp.MustCompile(`\bslot=\"?([^\s\"\]$][^\s\"\]]*)`)
+
+// classify returns the class of command: that of the operation working on the slot it
+// targets, or else that of the command itself (must be called with mu held)
+func (s *commandScheduler) classify(command string) commandClass {
+	class := classifyCommand(command)
+	if class == classMonitoring {
+		return class
+	}
+	if match := commandSlotPattern.FindStringSubmatch(command); len(match) > 1 {
+		if operation, ok := s.operations[match[1]]; ok {
+			return operation
+		}
+	}
+	return class
+}
+
+// acquire waits until command may run and returns the function releasing its session.
+// A command whose class queue is full fails at once with ErrPoolExhausted; one still
+// waiting when ctx is done fails with utils.ErrOperationTimeout. Neither was sent.
+func (s *commandScheduler) acquire(ctx context.Context, command string) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	s.mu.Lock()
+	class := s.classify(command)
+	if len(s.queues[class]) >= commandQueueLength {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d %s commands already waiting for a session: %w",
+			ErrPoolExhausted, commandQueueLength, class, ErrCommandNotSent)
+	}
+	waiter := &commandWaiter{class: class, ready: make(chan struct{})}
+	s.queues[class] = append(s.queues[class], waiter)
+	s.recordDepth(class)
+	s.dispatch()
+	s.mu.Unlock()
+
+	release := func() { s.release(class) }
+	started := time.Now()
+	select {
+	case <-waiter.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if waiter.granted {
+		// Granted as the wait ended
+		return release, nil
+	}
+	queue := s.queues[class]
+	for i := range queue {
+		if queue[i] == waiter {
+			s.queues[class] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	s.recordDepth(class)
+	return nil, fmt.Errorf("%w: waited %s for an SSH session for %s: %w",
+		utils.ErrOperationTimeout, time.Since(started).Round(time.Millisecond), commandLabel(command), ErrCommandNotSent)
+}
+
+// release frees the session of a command of class and hands it to the next waiting command
+func (s *commandScheduler) release(class commandClass) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.free++
+	if class == classMonitoring {
+		s.monitoringActive--
+	}
+	s.dispatch()
+}
+
+// dispatch grants free sessions to waiting commands (must be called with mu held)
+func (s *commandScheduler) dispatch() {
+	for s.free > 0 {
+		class, ok := s.pick()
+		if !ok {
+			return
+		}
+		waiter := s.queues[class][0]
+		s.queues[class] = s.queues[class][1:]
+		s.recordDepth(class)
+
+		s.free--
+		if class == classMonitoring {
+			s.monitoringActive++
+		}
+		waiter.granted = true
+		close(waiter.ready)
+	}
+}
+
+// pick returns the class to grant the next session to by smooth weighted round-robin
+// over the classes that may run a command, or false if none may (must be called with
+// mu held)
+func (s *commandScheduler) pick() (commandClass, bool) {
+	best := commandClass(-1)
+	total := 0
+	for class := commandClass(0); class < numCommandClasses; class++ {
+		if !s.eligible(class) {
+			continue
+		}
+		s.current[class] += commandClassWeights[class]
+		total += commandClassWeights[class]
+		if best < 0 || s.current[class] > s.current[best] {
+			best = class
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	s.current[best] -= total
+	return best, true
+}
+
+// eligible reports whether a command of class may be granted a session now (must be
+// called with mu held)
+func (s *commandScheduler) eligible(class commandClass) bool {
+	if len(s.queues[class]) == 0 {
+		return false
+	}
+	if class == classMonitoring {
+		return s.monitoringActive < monitoringSessions && len(s.queues[classProvisioning]) == 0
+	}
+	return true
+}
+
+// recordDepth updates the queue depth metric of class (must be called with mu held)
+func (s *commandScheduler) recordDepth(class commandClass) {
+	if s.metrics != nil {
+		s.metrics.RecordRDSCommandQueueDepth(s.address, class.String(), len(s.queues[class]))
+	}
+}
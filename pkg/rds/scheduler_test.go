@@ -0,0 +1,311 @@
+package rds
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+func TestClassifyCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    commandClass
+	}{
+		{`/disk add type=file file-path=/storage-pool/metal-csi/pvc-1.img file-size=10G slot=pvc-1`, classProvisioning},
+		{`/disk set [find slot=pvc-1] file-size=20G`, classProvisioning},
+		{`/disk remove [find slot=pvc-1]`, classDeletion},
+		{`/file remove [find name="storage-pool/metal-csi/pvc-1.img"]`, classDeletion},
+		{`/file add name=storage-pool/metal-csi/.trash type=directory`, classDeletion},
+		{`/file set [find name="storage-pool/metal-csi/pvc-1.img"] name=storage-pool/metal-csi/.trash/pvc-1.img`, classDeletion},
+		{`/disk print detail where slot=pvc-1`, classQuery},
+		{`/file print detail where name~"pvc-1"`, classQuery},
+		{`/system resource print`, classQuery},
+		{`/disk monitor-traffic pvc-1 once`, classMonitoring},
+		{`/interface nvme-tcp connection print detail`, classMonitoring},
+		{batchDiskRemoveCommand([]string{"pvc-1", "pvc-2"}), classDeletion},
+		{`:put [/system resource get uptime]`, classQuery},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, classifyCommand(tt.command), tt.command)
+	}
+}
+
+func TestCommandScheduler_OperationClass(t *testing.T) {
+	s := newCommandScheduler("10.42.68.1", 1, nil)
+	lookup := `/disk print detail where slot=pvc-1`
+
+	endDelete := s.beginOperation("pvc-1", classDeletion)
+	assert.Equal(t, classDeletion, s.classify(lookup))
+	assert.Equal(t, classQuery, s.classify(`/disk print detail where slot=pvc-2`))
+	assert.Equal(t, classMonitoring, s.classify(`/interface nvme-tcp connection print detail where slot=pvc-1`))
+	// A script variable is not a slot of an operation
+	endScript := s.beginOperation("$s", classQuery)
+	assert.Equal(t, classDeletion, s.classify(batchDiskRemoveCommand([]string{"pvc-1"})))
+	endScript()
+
+	// A nested operation on the slot hands it back when done
+	endCreate := s.beginOperation("pvc-1", classProvisioning)
+	assert.Equal(t, classProvisioning, s.classify(lookup))
+	endCreate()
+	assert.Equal(t, classDeletion, s.classify(lookup))
+	endDelete()
+	assert.Equal(t, classQuery, s.classify(lookup))
+	assert.Empty(t, s.operations)
+
+	var disabled *commandScheduler
+	disabled.beginOperation("pvc-1", classDeletion)()
+}
+
+// queueWaiters adds count waiting commands of class to the scheduler queue
+func queueWaiters(s *commandScheduler, class commandClass, count int) []*commandWaiter {
+	waiters := make([]*commandWaiter, count)
+	for i := range waiters {
+		waiters[i] = &commandWaiter{class: class, ready: make(chan struct{})}
+	}
+	s.queues[class] = append(s.queues[class], waiters...)
+	return waiters
+}
+
+// grantedByClass counts the granted waiters of each class
+func grantedByClass(waiters ...[]*commandWaiter) map[commandClass]int {
+	granted := make(map[commandClass]int)
+	for _, list := range waiters {
+		for _, w := range list {
+			if w.granted {
+				granted[w.class]++
+			}
+		}
+	}
+	return granted
+}
+
+func TestCommandScheduler_WeightedRoundRobin(t *testing.T) {
+	s := newCommandScheduler("10.42.68.1", 8, nil)
+	provisioning := queueWaiters(s, classProvisioning, 20)
+	deletion := queueWaiters(s, classDeletion, 20)
+	query := queueWaiters(s, classQuery, 20)
+	monitoring := queueWaiters(s, classMonitoring, 20)
+
+	// Eight free sessions go out by weight; monitoring waits while provisioning does
+	s.mu.Lock()
+	s.dispatch()
+	s.mu.Unlock()
+	assert.Equal(t, map[commandClass]int{classProvisioning: 4, classDeletion: 2, classQuery: 2},
+		grantedByClass(provisioning, deletion, query, monitoring))
+
+	// Without provisioning, monitoring gets at most its budget however many sessions free up
+	s.mu.Lock()
+	s.queues[classProvisioning] = nil
+	for range 8 {
+		s.free++
+		s.dispatch()
+	}
+	s.mu.Unlock()
+	granted := grantedByClass(deletion, query, monitoring)
+	assert.Equal(t, monitoringSessions, granted[classMonitoring])
+	assert.Equal(t, 12, granted[classDeletion]+granted[classQuery]+granted[classMonitoring])
+	assert.InDelta(t, granted[classDeletion], granted[classQuery], 1, "deletion and query share equally")
+
+	// Releasing the monitoring session lets the next monitoring command run
+	s.mu.Lock()
+	s.queues[classDeletion], s.queues[classQuery] = nil, nil
+	s.mu.Unlock()
+	s.release(classMonitoring)
+	assert.Equal(t, monitoringSessions+1, grantedByClass(monitoring)[classMonitoring])
+}
+
+func TestCommandScheduler_OneClassAlone(t *testing.T) {
+	// A class with no competition gets every free session
+	s := newCommandScheduler("10.42.68.1", 4, nil)
+	deletion := queueWaiters(s, classDeletion, 10)
+	s.mu.Lock()
+	s.dispatch()
+	s.mu.Unlock()
+	assert.Equal(t, 4, grantedByClass(deletion)[classDeletion])
+	assert.Zero(t, s.free)
+	assert.Len(t, s.queues[classDeletion], 6)
+}
+
+func TestCommandScheduler_QueueFull(t *testing.T) {
+	s := newCommandScheduler("10.42.68.1", 1, nil)
+	release, err := s.acquire(context.Background(), "/disk print")
+	require.NoError(t, err)
+	defer release()
+	queueWaiters(s, classDeletion, commandQueueLength)
+
+	start := time.Now()
+	_, err = s.acquire(context.Background(), "/disk remove [find slot=pvc-1]")
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+	assert.ErrorIs(t, err, ErrCommandNotSent)
+	assert.False(t, isRetryableError(err), "a full queue must not be retried")
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// Other classes still queue
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.acquire(ctx, "/disk add slot=pvc-2")
+	assert.ErrorIs(t, err, utils.ErrOperationTimeout)
+}
+
+func TestCommandScheduler_ContextDone(t *testing.T) {
+	s := newCommandScheduler("10.42.68.1", 1, nil)
+	release, err := s.acquire(context.Background(), "/disk print")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = s.acquire(ctx, "/disk add slot=pvc-1")
+	assert.ErrorIs(t, err, utils.ErrOperationTimeout)
+	assert.ErrorIs(t, err, ErrCommandNotSent)
+	assert.Empty(t, s.queues[classProvisioning], "a timed out command leaves the queue")
+
+	// The session is not lost
+	release()
+	release, err = s.acquire(context.Background(), "/disk add slot=pvc-1")
+	require.NoError(t, err)
+	release()
+	assert.Equal(t, 1, s.free)
+}
+
+func TestCommandScheduler_Disabled(t *testing.T) {
+	assert.Nil(t, newCommandScheduler("10.42.68.1", 0, nil))
+
+	var s *commandScheduler
+	release, err := s.acquire(context.Background(), "/disk print")
+	require.NoError(t, err)
+	release()
+}
+
+// slowExecutor takes delay to run a command and tracks the monitoring commands running
+type slowExecutor struct {
+	delay         time.Duration
+	monitoring    atomic.Int32
+	maxMonitoring atomic.Int32
+}
+
+func (e *slowExecutor) Run(ctx context.Context, command string) (string, error) {
+	if classifyCommand(command) == classMonitoring {
+		running := e.monitoring.Add(1)
+		defer e.monitoring.Add(-1)
+		for {
+			highest := e.maxMonitoring.Load()
+			if running <= highest || e.maxMonitoring.CompareAndSwap(highest, running) {
+				break
+			}
+		}
+	}
+	select {
+	case <-time.After(e.delay):
+		return "", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestCommandScheduler_MonitoringFloodDoesNotDelayProvisioning(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	executor := &slowExecutor{delay: delay}
+	client := &sshClient{sshState: &sshState{
+		address:   "10.42.68.1",
+		executor:  executor,
+		scheduler: newCommandScheduler("10.42.68.1", 2, nil),
+	}}
+
+	// Flood with metrics scrape commands, as many slow scrapes piling up would
+	var wg sync.WaitGroup
+	for range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = client.runCommand("/disk monitor-traffic pvc-1 once")
+		}()
+	}
+	time.Sleep(delay / 2)
+
+	// Provisioning commands have the second session to themselves
+	start := time.Now()
+	for range 5 {
+		_, err := client.runCommand("/disk add slot=pvc-2")
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), 5*delay+3*delay, "provisioning waited behind monitoring")
+	wg.Wait()
+	assert.Equal(t, int32(monitoringSessions), executor.maxMonitoring.Load())
+}
+
+// classRecordingExecutor records the scheduling class each command ran in
+type classRecordingExecutor struct {
+	scheduler *commandScheduler
+	outputs   map[string]string
+	classes   map[string]commandClass
+}
+
+func (e *classRecordingExecutor) Run(_ context.Context, command string) (string, error) {
+	e.scheduler.mu.Lock()
+	e.classes[command] = e.scheduler.classify(command)
+	e.scheduler.mu.Unlock()
+	return e.outputs[command], nil
+}
+
+func TestCommandScheduler_DeleteVolumesClass(t *testing.T) {
+	setupTestBasePaths(t)
+	scheduler := newCommandScheduler("10.42.68.1", 2, nil)
+	listing := "/disk print detail where slot=" + executorTestSlot
+	executor := &classRecordingExecutor{
+		scheduler: scheduler,
+		outputs:   map[string]string{listing: executorTestDisk},
+		classes:   make(map[string]commandClass),
+	}
+	client := &sshClient{sshState: &sshState{
+		address:   "10.42.68.1",
+		executor:  executor,
+		scheduler: scheduler,
+	}}
+
+	client.DeleteVolumes([]string{executorTestSlot})
+
+	// The lookup of a batched deletion runs in the deletion class, not as a query, and
+	// so does the script removing the disks
+	assert.Equal(t, classDeletion, executor.classes[listing])
+	assert.Equal(t, classDeletion, executor.classes[batchDiskRemoveCommand([]string{executorTestSlot})])
+}
+
+// deadlineExecutor reports the time left until its context's deadline
+type deadlineExecutor struct {
+	left chan time.Duration
+}
+
+func (e *deadlineExecutor) Run(ctx context.Context, _ string) (string, error) {
+	deadline, _ := ctx.Deadline()
+	e.left <- time.Until(deadline)
+	return "", nil
+}
+
+func TestCommandScheduler_WaitDoesNotShortenTimeout(t *testing.T) {
+	scheduler := newCommandScheduler("10.42.68.1", 1, nil)
+	executor := &deadlineExecutor{left: make(chan time.Duration, 1)}
+	client := &sshClient{sshState: &sshState{
+		address:   "10.42.68.1",
+		executor:  executor,
+		scheduler: scheduler,
+	}}
+
+	// Another command holds the only session for most of the timeout
+	release, err := scheduler.acquire(context.Background(), "/disk print")
+	require.NoError(t, err)
+	time.AfterFunc(300*time.Millisecond, release)
+
+	const timeout = 500 * time.Millisecond
+	_, err = client.runCommandTimeout("/disk print detail", timeout)
+	require.NoError(t, err)
+	if left := <-executor.left; left < timeout-100*time.Millisecond {
+		t.Errorf("command ran with %v of its %v timeout left after waiting for a session", left, timeout)
+	}
+}
@@ -0,0 +1,45 @@
+package rds
+
+import (
+	"strings"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+// RouterOS keeps no record of the disk a copy-from entry was copied from, and snapshot IDs
+// are derived from the CSI snapshot name alone, so a snapshot's disk comment records its
+// source volume: "<namespace>/<name>/<source>" when the snapshot has a label, "<source>"
+// when it does not. Snapshots of older releases have a "<namespace>/<name>" comment or
+// none, and their source is only known where RDS prints a source-volume property.
+
+// SnapshotComment returns the disk comment of a snapshot of sourceVolume labelled with
+// label ("<namespace>/<name>", may be empty). The label is shortened if needed so the
+// source volume always fits utils.MaxDiskCommentLength.
+func SnapshotComment(label, sourceVolume string) string {
+	if label == "" {
+		return sourceVolume
+	}
+	if maxLabel := utils.MaxDiskCommentLength - len(sourceVolume) - 1; len(label) > maxLabel {
+		label = label[:maxLabel]
+	}
+	return label + "/" + sourceVolume
+}
+
+// SnapshotCommentSource returns the source volume recorded in a snapshot disk comment,
+// or "" if the comment records none
+func SnapshotCommentSource(comment string) string {
+	parts := strings.Split(comment, "/")
+	var source string
+	switch len(parts) {
+	case 1:
+		source = parts[0]
+	case 3:
+		source = parts[2]
+	default:
+		return ""
+	}
+	if utils.ValidateVolumeID(source) != nil {
+		return ""
+	}
+	return source
+}
@@ -0,0 +1,86 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+const snapshotSourceTestVolume = "pvc-a1b2c3d4-e5f6-7890-abcd-ef1234567890"
+
+func TestSnapshotComment(t *testing.T) {
+	if got := SnapshotComment("databases/nightly", snapshotSourceTestVolume); got != "databases/nightly/"+snapshotSourceTestVolume {
+		t.Errorf("SnapshotComment with label = %q", got)
+	}
+	if got := SnapshotComment("", snapshotSourceTestVolume); got != snapshotSourceTestVolume {
+		t.Errorf("SnapshotComment without label = %q", got)
+	}
+
+	// A long label is shortened, never the source volume
+	long := SnapshotComment("databases/"+strings.Repeat("n", utils.MaxDiskCommentLength), snapshotSourceTestVolume)
+	if len(long) != utils.MaxDiskCommentLength {
+		t.Errorf("expected comment of %d characters, got %d", utils.MaxDiskCommentLength, len(long))
+	}
+	if got := SnapshotCommentSource(long); got != snapshotSourceTestVolume {
+		t.Errorf("source of shortened comment = %q, want %q", got, snapshotSourceTestVolume)
+	}
+}
+
+// TestParseSnapshotInfo_SourceVolume tests where the source volume of a snapshot read
+// from RDS comes from, for snapshots of this and of older releases
+func TestParseSnapshotInfo_SourceVolume(t *testing.T) {
+	const entry = `type=file slot="snap-11111111-2222-3333-4444-555555555555-at-3a9f8c02d1" ` +
+		`file-path=/storage-pool/metal-csi/snap-11111111-2222-3333-4444-555555555555-at-3a9f8c02d1.img file-size=10.0GiB`
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "labelled comment", output: ";;; databases/nightly/" + snapshotSourceTestVolume + "\n" + entry, want: snapshotSourceTestVolume},
+		{name: "unlabelled comment", output: ";;; " + snapshotSourceTestVolume + "\n" + entry, want: snapshotSourceTestVolume},
+		{name: "source-volume property wins", output: ";;; " + snapshotSourceTestVolume + "\n" + entry + " source-volume=pvc-99999999-8888-7777-6666-555555555555", want: "pvc-99999999-8888-7777-6666-555555555555"},
+		{name: "legacy labelled comment", output: ";;; databases/nightly\n" + entry, want: ""},
+		{name: "legacy without comment", output: entry, want: ""},
+		{name: "comment set by hand", output: ";;; nightly backup\n" + entry, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot, err := parseSnapshotInfo(tt.output)
+			if err != nil {
+				t.Fatalf("parseSnapshotInfo failed: %v", err)
+			}
+			if snapshot.SourceVolume != tt.want {
+				t.Errorf("SourceVolume = %q, want %q", snapshot.SourceVolume, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSourceFilterRejected(t *testing.T) {
+	cmd := `/disk print detail where slot~"snap-" and source-volume="` + snapshotSourceTestVolume + `"`
+	column := strings.Index(cmd, "source-volume") + 1
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unsupported parameter", errors.New("command failed (exit 1): unsupported parameter"), true},
+		{"parse error at the filter", fmt.Errorf("command failed (exit 1): expected end of command (line 1 column %d)", column), true},
+		{"parse error elsewhere", errors.New("command failed (exit 1): expected end of command (line 1 column 3)"), false},
+		{"other command failure", errors.New("command failed (exit 1): failure: interrupted"), false},
+		{"timeout", utils.ErrOperationTimeout, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSourceFilterRejected(cmd, tt.err); got != tt.want {
+				t.Errorf("isSourceFilterRejected(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
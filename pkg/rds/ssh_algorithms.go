@@ -0,0 +1,84 @@
+package rds
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHAlgorithms restricts the algorithms the client offers in the SSH handshake, e.g. to a
+// FIPS-approved set. An empty list keeps the x/crypto/ssh defaults for that kind.
+type SSHAlgorithms struct {
+	Ciphers      []string
+	KeyExchanges []string
+	MACs         []string
+}
+
+// ParseSSHAlgorithmList splits a comma-separated list of algorithm names, dropping empty entries
+func ParseSSHAlgorithmList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate returns an error naming every entry x/crypto/ssh does not implement
+func (a SSHAlgorithms) Validate() error {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+
+	var problems []string
+	check := func(kind string, names, known, knownInsecure []string) {
+		var unsupported []string
+		for _, name := range names {
+			if !slices.Contains(known, name) && !slices.Contains(knownInsecure, name) {
+				unsupported = append(unsupported, name)
+			}
+		}
+		if len(unsupported) > 0 {
+			problems = append(problems, fmt.Sprintf("%s %s (supported: %s)",
+				kind, strings.Join(unsupported, ", "), strings.Join(known, ", ")))
+		}
+	}
+	check("ciphers", a.Ciphers, supported.Ciphers, insecure.Ciphers)
+	check("key exchanges", a.KeyExchanges, supported.KeyExchanges, insecure.KeyExchanges)
+	check("MACs", a.MACs, supported.MACs, insecure.MACs)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unsupported SSH algorithms: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// apply sets the restricted algorithms on config
+func (a SSHAlgorithms) apply(config *ssh.ClientConfig) {
+	if len(a.Ciphers) > 0 {
+		config.Ciphers = a.Ciphers
+	}
+	if len(a.KeyExchanges) > 0 {
+		config.KeyExchanges = a.KeyExchanges
+	}
+	if len(a.MACs) > 0 {
+		config.MACs = a.MACs
+	}
+}
+
+// describeNegotiatedAlgorithms formats the algorithms agreed for conn, or "" if the
+// connection does not report them
+func describeNegotiatedAlgorithms(conn ssh.Conn) string {
+	meta, ok := conn.(ssh.AlgorithmsConnMetadata)
+	if !ok {
+		return ""
+	}
+	algs := meta.Algorithms()
+	mac := algs.Write.MAC
+	if mac == "" {
+		mac = "implicit"
+	}
+	return fmt.Sprintf("kex=%s host-key=%s cipher=%s mac=%s", algs.KeyExchange, algs.HostKey, algs.Write.Cipher, mac)
+}
@@ -0,0 +1,54 @@
+package rds
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSSHAlgorithmList(t *testing.T) {
+	got := ParseSSHAlgorithmList(" aes128-gcm@openssh.com, ,aes256-ctr ")
+	want := []string{"aes128-gcm@openssh.com", "aes256-ctr"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := ParseSSHAlgorithmList(""); got != nil {
+		t.Errorf("expected no algorithms for an empty list, got %v", got)
+	}
+}
+
+func TestSSHAlgorithmsValidate(t *testing.T) {
+	valid := SSHAlgorithms{
+		Ciphers:      []string{"aes128-gcm@openssh.com", "aes256-ctr"},
+		KeyExchanges: []string{"ecdh-sha2-nistp256", "diffie-hellman-group14-sha256"},
+		MACs:         []string{"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected a valid algorithm set, got %v", err)
+	}
+	if err := (SSHAlgorithms{}).Validate(); err != nil {
+		t.Errorf("expected the defaults to be valid, got %v", err)
+	}
+
+	invalid := SSHAlgorithms{
+		Ciphers:      []string{"aes128-gcm@openssh.com", "blowfish-cbc"},
+		KeyExchanges: []string{"sntrup761x25519-sha512@openssh.com"},
+		MACs:         []string{"hmac-sha2-256"},
+	}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("expected unsupported algorithms to be rejected")
+	}
+	for _, want := range []string{"ciphers blowfish-cbc", "key exchanges sntrup761x25519-sha512@openssh.com"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to name %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "MACs") {
+		t.Errorf("expected supported MACs not to be named, got %v", err)
+	}
+
+	if _, err := newSSHClient(ClientConfig{Address: "10.42.68.1", User: "admin", Algorithms: invalid}); err == nil {
+		t.Error("expected the client to refuse unsupported algorithms")
+	}
+}
@@ -2,33 +2,113 @@ package rds
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	"k8s.io/klog/v2"
 
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/security"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
-// sshClient implements RDSClient using SSH protocol to connect to RouterOS
+// sshClient implements RDSClient using SSH protocol to connect to RouterOS. The views
+// ForCall returns share the connection state and differ only in the call they work for.
 type sshClient struct {
+	*sshState
+
+	// call is the CSI call the commands are issued for (zero for background work)
+	call Call
+}
+
+// withCall returns a view of the client whose commands are attributed to call
+func (c *sshClient) withCall(call Call) RDSClient {
+	return &sshClient{sshState: c.sshState, call: call}
+}
+
+// logger returns the logger of the client's commands, which carries the request ID of
+// the call they are issued for
+func (c *sshClient) logger() klog.Logger {
+	logger := klog.Background()
+	if c.call.RequestID != "" {
+		logger = logger.WithValues("requestID", c.call.RequestID)
+	}
+	return logger
+}
+
+// unscoped returns the client the views were made from
+func (c *sshClient) unscoped() RDSClient {
+	if c.base == nil {
+		return c
+	}
+	return c.base
+}
+
+// sshState is the connection and configuration of an sshClient
+type sshState struct {
+	base *sshClient // The client newSSHClient returned, which views are made from
+
 	address            string // RDS IP address
 	port               int
 	user               string
 	privateKey         []byte
 	hostKey            []byte // Expected SSH host public key
 	timeout            time.Duration
-	sshClient          *ssh.Client
+	sshClient          atomic.Pointer[ssh.Client] // Replaced by Connect while commands run, so accessed atomically
 	hostKeyCallback    ssh.HostKeyCallback
 	insecureSkipVerify bool
+	algorithms         SSHAlgorithms
 	sessionMu          sync.Mutex // Protects concurrent session creation
+
+	// executor runs commands instead of the SSH connection when set (ClientConfig.Executor)
+	executor CommandExecutor
+
+	// commandTimeout limits each command unless the caller sets another (0 for no limit)
+	commandTimeout time.Duration
+
+	// longCommandTimeout limits commands creating or copying a disk (0 for no limit)
+	longCommandTimeout time.Duration
+
+	// volumeReadyTimeout bounds how long CreateVolume waits for RouterOS to finish creating a disk
+	volumeReadyTimeout time.Duration
+
+	// audit records executed commands (nil when the audit log is disabled)
+	audit *AuditLog
+
+	// metrics counts command errors and retries (nil when metrics are disabled)
+	metrics *observability.Metrics
+
+	// retryBudget caps the retries of all operations (nil for no cap)
+	retryBudget *RetryBudget
+
+	// scheduler limits and shares the sessions running commands (nil for no limit)
+	scheduler *commandScheduler
+
+	// slotLocks serializes the volume creations of each slot (nil for no locking)
+	slotLocks *attachment.VolumeLockManager
+
+	// logRawIO enables V(5) logging of commands and their output
+	logRawIO bool
+
+	// sourceFilterUnsupported is set once RDS rejected a snapshot query filtered on
+	// source-volume, so later listings go straight to the unfiltered query
+	sourceFilterUnsupported atomic.Bool
 }
 
 // newSSHClient creates a new SSH-based RDS client
@@ -48,6 +128,25 @@ func newSSHClient(config ClientConfig) (*sshClient, error) {
 	if config.Timeout == 0 {
 		config.Timeout = 10 * time.Second
 	}
+	switch {
+	case config.CommandTimeout == 0:
+		config.CommandTimeout = DefaultCommandTimeout
+	case config.CommandTimeout < 0:
+		config.CommandTimeout = 0
+	}
+	switch {
+	case config.LongCommandTimeout == 0:
+		config.LongCommandTimeout = DefaultLongCommandTimeout
+	case config.LongCommandTimeout < 0:
+		config.LongCommandTimeout = 0
+	}
+	if config.VolumeReadyTimeout == 0 {
+		config.VolumeReadyTimeout = defaultVolumeReadyTimeout
+	}
+
+	if err := config.Algorithms.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Handle host key callback
 	var hostKeyCallback ssh.HostKeyCallback
@@ -58,25 +157,51 @@ func newSSHClient(config ClientConfig) (*sshClient, error) {
 		} else {
 			return nil, fmt.Errorf("HostKeyCallback must be of type ssh.HostKeyCallback")
 		}
-	} else if len(config.HostKey) > 0 {
-		// Create host key callback from provided public key
-		expectedKey, err := parseHostKey(config.HostKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse host key: %w", err)
+	} else if len(config.HostKey) > 0 || len(config.HostKeyFingerprints) > 0 {
+		// Build the accepted fingerprint set from host keys and/or explicit fingerprints
+		var accepted []string
+		if len(config.HostKey) > 0 {
+			keys, err := parseHostKeys(config.HostKey, config.Address, config.Port)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse host key: %w", err)
+			}
+			for _, key := range keys {
+				accepted = append(accepted, ssh.FingerprintSHA256(key))
+			}
+		}
+		for _, fp := range config.HostKeyFingerprints {
+			normalized, err := normalizeFingerprint(fp)
+			if err != nil {
+				return nil, err
+			}
+			accepted = append(accepted, normalized)
 		}
-		hostKeyCallback = createHostKeyCallback(expectedKey, config.Address)
+		hostKeyCallback = createHostKeyCallback(accepted, config.Address)
 	}
 
-	return &sshClient{
+	client := &sshClient{sshState: &sshState{
 		address:            config.Address,
 		port:               config.Port,
 		user:               config.User,
 		privateKey:         config.PrivateKey,
 		hostKey:            config.HostKey,
 		timeout:            config.Timeout,
+		commandTimeout:     config.CommandTimeout,
+		longCommandTimeout: config.LongCommandTimeout,
 		hostKeyCallback:    hostKeyCallback,
 		insecureSkipVerify: config.InsecureSkipVerify,
-	}, nil
+		algorithms:         config.Algorithms,
+		volumeReadyTimeout: config.VolumeReadyTimeout,
+		audit:              config.AuditLog,
+		logRawIO:           config.LogRawIO,
+		executor:           config.Executor,
+		slotLocks:          attachment.NewVolumeLockManager(),
+		metrics:            config.Metrics,
+		retryBudget:        config.RetryBudget,
+		scheduler:          newCommandScheduler(config.Address, config.MaxSessions, config.Metrics),
+	}}
+	client.base = client
+	return client, nil
 }
 
 // GetAddress returns the RDS server address
@@ -84,8 +209,12 @@ func (c *sshClient) GetAddress() string {
 	return c.address
 }
 
-// Connect establishes SSH connection to RDS
+// Connect establishes SSH connection to RDS. With a configured executor there is no SSH
+// connection to establish.
 func (c *sshClient) Connect() error {
+	if c.executor != nil {
+		return nil
+	}
 	klog.V(4).Infof("Connecting to RDS at %s:%d as user %s", c.address, c.port, c.user)
 
 	// Log authentication attempt
@@ -110,6 +239,7 @@ func (c *sshClient) Connect() error {
 		HostKeyCallback: hostKeyCallback,
 		Timeout:         c.timeout,
 	}
+	c.algorithms.apply(sshConfig)
 
 	// Add authentication if private key is provided
 	if len(c.privateKey) > 0 {
@@ -127,34 +257,48 @@ func (c *sshClient) Connect() error {
 	}
 
 	// Establish connection
-	addr := fmt.Sprintf("%s:%d", c.address, c.port)
+	addr := net.JoinHostPort(c.address, strconv.Itoa(c.port))
 	client, err := ssh.Dial("tcp", addr, sshConfig)
 	if err != nil {
 		// Log authentication failure
 		secLogger.LogSSHConnectionFailure(c.user, c.address, err)
-		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return fmt.Errorf("failed to connect to %s: %w: %w", addr, utils.ErrAuthenticationFailed, err)
+		}
+		return fmt.Errorf("failed to connect to %s: %w: %w", addr, utils.ErrConnectionFailed, err)
 	}
 
-	c.sshClient = client
+	c.sshClient.Store(client)
 	klog.V(4).Infof("Successfully connected to RDS at %s:%d", c.address, c.port)
+	if negotiated := describeNegotiatedAlgorithms(client.Conn); negotiated != "" {
+		klog.V(1).Infof("Negotiated SSH algorithms with RDS at %s:%d: %s", c.address, c.port, negotiated)
+	}
 
 	// Log successful authentication
 	secLogger.LogSSHConnectionSuccess(c.user, c.address)
 	return nil
 }
 
-// Close closes the SSH connection
+// Close closes the SSH connection, or the configured executor
 func (c *sshClient) Close() error {
-	if c.sshClient != nil {
+	if c.executor != nil {
+		return c.closeExecutor()
+	}
+	if client := c.sshClient.Load(); client != nil {
 		klog.V(4).Infof("Closing SSH connection to RDS")
-		return c.sshClient.Close()
+		return client.Close()
 	}
 	return nil
 }
 
-// IsConnected returns true if SSH connection is active
+// IsConnected returns true if SSH connection is active. A configured executor manages its
+// own transport and counts as connected.
 func (c *sshClient) IsConnected() bool {
-	if c.sshClient == nil {
+	if c.executor != nil {
+		return true
+	}
+	client := c.sshClient.Load()
+	if client == nil {
 		return false
 	}
 
@@ -162,7 +306,7 @@ func (c *sshClient) IsConnected() bool {
 	// RouterOS may not support keepalive requests, so use session creation as test
 	// Serialize session creation to prevent concurrent calls
 	c.sessionMu.Lock()
-	session, err := c.sshClient.NewSession()
+	session, err := client.NewSession()
 	c.sessionMu.Unlock()
 	if err != nil {
 		return false
@@ -171,21 +315,70 @@ func (c *sshClient) IsConnected() bool {
 	return true
 }
 
-// runCommand executes a RouterOS CLI command via SSH
+// runCommand executes a RouterOS CLI command on the client's executor (SSH by default) and
+// records it in the audit log. Terminal decoration (colors, CRLF) is removed from the output
+// before it is returned.
 func (c *sshClient) runCommand(command string) (string, error) {
-	if c.sshClient == nil {
-		return "", fmt.Errorf("not connected to RDS")
+	return c.runCommandTimeout(command, c.commandTimeout)
+}
+
+// runCommandTimeout is runCommand giving up after timeout (0 for no limit) with
+// utils.ErrOperationTimeout
+func (c *sshClient) runCommandTimeout(command string, timeout time.Duration) (string, error) {
+	started := time.Now()
+	output, err := c.runScheduled(command, timeout)
+	if c.audit != nil && c.audit.shouldRecord(command) {
+		c.audit.Record(newAuditEntry(c.address, c.call, command, started, err))
+	}
+	if err != nil {
+		c.recordCommandError(command, err)
+	}
+	return cleanRouterOSOutput(output), err
+}
+
+// runScheduled runs command once the scheduler grants it a session. Waiting for the
+// session and running the command are each limited to timeout, so a command that queued
+// behind others still gets its full timeout on RDS.
+func (c *sshClient) runScheduled(command string, timeout time.Duration) (string, error) {
+	waitCtx, cancelWait := commandContext(timeout)
+	release, err := c.scheduler.acquire(waitCtx, command)
+	cancelWait()
+	if err != nil {
+		return "", err
 	}
+	defer release()
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+	return c.commandExecutor().Run(ctx, command)
+}
 
-	klog.V(5).Infof("Executing RouterOS command: %s", command)
+// commandContext returns a context done after timeout, or never if timeout is 0
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// execCommand runs a single command over a new SSH session, giving up when ctx is done
+func (c *sshClient) execCommand(ctx context.Context, command string) (string, error) {
+	client := c.sshClient.Load()
+	if client == nil {
+		return "", fmt.Errorf("not connected to RDS: %w: %w", utils.ErrConnectionFailed, ErrCommandNotSent)
+	}
+
+	if c.logRawIO {
+		c.logger().V(5).Info("Executing RouterOS command", "command", redactSecrets(command))
+	}
 
 	// Serialize session creation to prevent concurrent NewSession() calls
 	// which can cause RouterOS to block or fail (session limits per connection)
 	c.sessionMu.Lock()
-	session, err := c.sshClient.NewSession()
+	session, err := client.NewSession()
 	c.sessionMu.Unlock()
 	if err != nil {
-		return "", fmt.Errorf("failed to create SSH session: %w", err)
+		return "", fmt.Errorf("failed to create SSH session: %w: %w: %w", utils.ErrConnectionFailed, ErrCommandNotSent, err)
 	}
 	defer func() { _ = session.Close() }()
 
@@ -194,42 +387,104 @@ func (c *sshClient) runCommand(command string) (string, error) {
 	session.Stdout = &stdout
 	session.Stderr = &stderr
 
-	// Run command
-	if err := session.Run(command); err != nil {
+	// Run command. After a timeout the buffers are left to the session goroutine.
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+	var runErr error
+	select {
+	case runErr = <-done:
+	case <-ctx.Done():
+		_ = session.Close()
+		return "", fmt.Errorf("%w: command did not complete: %w", utils.ErrOperationTimeout, ctx.Err())
+	}
+	if err := runErr; err != nil {
 		// Check if it's an exit error (command failed)
 		var exitErr *ssh.ExitError
 		if errors.As(err, &exitErr) {
-			return stdout.String(), fmt.Errorf("command failed (exit %d): %s", exitErr.ExitStatus(), stderr.String())
+			// RouterOS prints "failure: ..." on stdout, so fall back to it when stderr is empty
+			message := strings.TrimSpace(cleanRouterOSOutput(stderr.String()))
+			if message == "" {
+				message = strings.TrimSpace(cleanRouterOSOutput(stdout.String()))
+			}
+			return stdout.String(), fmt.Errorf("command failed (exit %d): %s", exitErr.ExitStatus(), message)
 		}
-		return "", fmt.Errorf("failed to run command: %w", err)
+		return "", fmt.Errorf("failed to run command: %w: %w", ErrCommandInterrupted, err)
 	}
 
 	output := stdout.String()
-	klog.V(5).Infof("Command output: %s", output)
+	if c.logRawIO {
+		c.logger().V(5).Info("Command output", "output", redactSecrets(output))
+	}
 	return output, nil
 }
 
+// commandVerifier reports whether a command took effect on RDS. It is called when the
+// connection failed after the command was sent, which leaves open whether RouterOS ran it.
+type commandVerifier func() (bool, error)
+
+// idempotentCommand is the verifier of commands that may run twice with the same result,
+// such as setting a property to an absolute value: the command is simply retried
+func idempotentCommand() (bool, error) {
+	return false, nil
+}
+
 // runCommandWithRetry executes a command with retry logic for transient errors
-func (c *sshClient) runCommandWithRetry(command string, maxRetries int) (string, error) {
+func (c *sshClient) runCommandWithRetry(command string, maxRetries int, verify commandVerifier) (string, error) {
+	return c.runCommandWithRetryTimeout(command, maxRetries, c.commandTimeout, verify)
+}
+
+// runCommandWithRetryTimeout is runCommandWithRetry with each attempt limited to timeout.
+// An attempt that times out is not retried.
+//
+// A command that failed before it was sent (ErrCommandNotSent) is retried as is. One whose
+// connection failed after it was sent (ErrCommandInterrupted) may have run: before
+// retrying it, verify is called once reconnected. If it reports the command took effect, the command counts as
+// succeeded and its output is empty; if it fails, so does the command. With a nil
+// verify such a command is not retried.
+func (c *sshClient) runCommandWithRetryTimeout(command string, maxRetries int, timeout time.Duration, verify commandVerifier) (string, error) {
 	var lastErr error
+	unverified := false
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		if attempt > 0 {
+			if !c.retryBudget.allow() {
+				c.logger().V(2).Info("Not retrying command: retry budget exhausted", "command", commandLabel(command))
+				if c.metrics != nil {
+					c.metrics.RecordRDSRetryRejected(commandLabel(command))
+				}
+				return "", fmt.Errorf("%w, not retried after attempt %d/%d: %w", ErrRetryBudgetExhausted, attempt, maxRetries, lastErr)
+			}
 			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			klog.V(4).Infof("Retrying command after %v (attempt %d/%d)", backoff, attempt+1, maxRetries)
+			c.logger().V(4).Info("Retrying command", "after", backoff, "attempt", attempt+1, "maxRetries", maxRetries)
 			time.Sleep(backoff)
+			if c.metrics != nil {
+				c.metrics.RecordRDSCommandRetry(commandLabel(command))
+			}
 		}
 
 		// Reconnect if connection is lost
 		if !c.IsConnected() {
-			klog.V(4).Info("Reconnecting to RDS before retry")
+			c.logger().V(4).Info("Reconnecting to RDS before retry")
 			if err := c.Connect(); err != nil {
+				c.recordCommandError(command, err)
 				lastErr = err
 				continue
 			}
 		}
 
-		output, err := c.runCommand(command)
+		if unverified {
+			executed, err := verify()
+			if err != nil {
+				return "", fmt.Errorf("%w (failed to check whether RDS ran the command: %v)", lastErr, err)
+			}
+			if executed {
+				c.logger().V(2).Info("Command took effect on RDS before its connection failed", "command", commandLabel(command))
+				return "", nil
+			}
+			unverified = false
+		}
+
+		output, err := c.runCommandTimeout(command, timeout)
 		if err == nil {
 			return output, nil
 		}
@@ -238,7 +493,7 @@ func (c *sshClient) runCommandWithRetry(command string, maxRetries int) (string,
 
 		// Check if error is retryable
 		if !isRetryableError(err) {
-			klog.V(4).Infof("Non-retryable error: %v", err)
+			c.logger().V(4).Info("Non-retryable error", "err", err)
 			// Wrap with sentinel if it's a known error type
 			errStr := lastErr.Error()
 			if strings.Contains(errStr, "not enough space") {
@@ -247,18 +502,52 @@ func (c *sshClient) runCommandWithRetry(command string, maxRetries int) (string,
 			return "", lastErr
 		}
 
-		klog.V(4).Infof("Retryable error: %v", err)
+		if errors.Is(err, ErrCommandInterrupted) {
+			if verify == nil {
+				return "", fmt.Errorf("%w (not retried: RDS may have run the command)", err)
+			}
+			unverified = true
+		}
+
+		c.logger().V(4).Info("Retryable error", "err", err)
 	}
 
+	// The last attempt may have run as well
+	if unverified {
+		executed, err := c.verifyAfterReconnect(verify)
+		if err != nil {
+			return "", fmt.Errorf("max retries (%d) exceeded: %w (failed to check whether RDS ran the command: %v)", maxRetries, lastErr, err)
+		}
+		if executed {
+			c.logger().V(2).Info("Command took effect on RDS before its connection failed", "command", commandLabel(command))
+			return "", nil
+		}
+	}
 	return "", fmt.Errorf("max retries (%d) exceeded: %w", maxRetries, lastErr)
 }
 
+// verifyAfterReconnect calls verify, reconnecting first if the connection is lost
+func (c *sshClient) verifyAfterReconnect(verify commandVerifier) (bool, error) {
+	if !c.IsConnected() {
+		if err := c.Connect(); err != nil {
+			return false, err
+		}
+	}
+	return verify()
+}
+
 // isRetryableError determines if an error is worth retrying
 func isRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
+	// A command that ran out of time would most likely do so again, and retrying a
+	// command turned away by a full queue would only add to the queue
+	if errors.Is(err, utils.ErrOperationTimeout) || errors.Is(err, ErrPoolExhausted) {
+		return false
+	}
+
 	// Network errors are retryable
 	var netErr net.Error
 	if errors.As(err, &netErr) && netErr.Timeout() {
@@ -278,6 +567,8 @@ func isRetryableError(err error) bool {
 		"invalid parameter",
 		"no such item",
 		"authentication failed",
+		"already exists",
+		"already have",
 	}
 
 	for _, pattern := range nonRetryablePatterns {
@@ -305,55 +596,151 @@ func indexString(s, substr string) int {
 	return -1
 }
 
-// parseHostKey parses an SSH public key from various formats
-// Supports: OpenSSH authorized_keys format, SSH known_hosts format, raw public key
-func parseHostKey(keyData []byte) (ssh.PublicKey, error) {
-	// Try parsing as OpenSSH public key (ssh-rsa AAAA..., ssh-ed25519 AAAA..., etc.)
-	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(keyData)
-	if err == nil {
-		return pubKey, nil
+// parseHostKeys parses one or more SSH public keys, one per line, so that host
+// keys can be rotated by listing old and new keys together. Each line may be in
+// authorized_keys format ("ssh-ed25519 AAAA... comment") or known_hosts format
+// ("host1,host2 ssh-ed25519 AAAA..."), where only lines whose host patterns match
+// host and port are used. Blank lines and # comments are ignored; @revoked and
+// @cert-authority entries are skipped. A single raw wire-format key is also
+// accepted for backward compatibility.
+func parseHostKeys(keyData []byte, host string, port int) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	otherHosts := 0
+
+	for i, line := range strings.Split(string(keyData), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// A known_hosts line would also parse as an authorized key with its hosts taken
+		// for options, so it is tried first
+		marker, hosts, pubKey, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			authorizedKey, _, _, _, authErr := ssh.ParseAuthorizedKey([]byte(line))
+			if authErr != nil {
+				return nil, fmt.Errorf("failed to parse host key on line %d", i+1)
+			}
+			keys = append(keys, authorizedKey)
+			continue
+		}
+		if marker != "" {
+			klog.Warningf("Ignoring @%s host key entry on line %d", marker, i+1)
+			continue
+		}
+		if !knownHostsMatch(hosts, host, port) {
+			klog.V(2).Infof("Ignoring host key on line %d: its hosts %v do not match %s", i+1, hosts, host)
+			otherHosts++
+			continue
+		}
+		keys = append(keys, pubKey)
 	}
 
-	// Try parsing as raw public key
-	pubKey, err = ssh.ParsePublicKey(keyData)
-	if err == nil {
-		return pubKey, nil
+	if len(keys) == 0 && otherHosts > 0 {
+		return nil, fmt.Errorf("none of the %d known_hosts entries matches %s", otherHosts, host)
+	}
+	if len(keys) == 0 {
+		// Fall back to a single raw public key
+		pubKey, err := ssh.ParsePublicKey(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host key in any supported format")
+		}
+		keys = append(keys, pubKey)
 	}
 
-	return nil, fmt.Errorf("failed to parse host key in any supported format")
+	return keys, nil
 }
 
-// createHostKeyCallback creates an SSH host key callback that verifies against expected key
-func createHostKeyCallback(expectedKey ssh.PublicKey, hostname string) ssh.HostKeyCallback {
-	return func(h string, remote net.Addr, key ssh.PublicKey) error {
-		// Compare the key type first
-		if key.Type() != expectedKey.Type() {
-			return fmt.Errorf("SSH host key type mismatch for %s: expected %s, got %s", hostname, expectedKey.Type(), key.Type())
+// knownHostsMatch reports whether the host patterns of a known_hosts line match host
+// on port, as sshd(8) describes them: a pattern may be hashed (|1|salt|hash), use *
+// and ? wildcards, name a non-default port as [host]:port, and be negated with !,
+// which excludes the host even if another pattern matches.
+func knownHostsMatch(patterns []string, host string, port int) bool {
+	if port <= 0 {
+		port = 22
+	}
+	target := strings.ToLower(knownhosts.Normalize(net.JoinHostPort(host, strconv.Itoa(port))))
+
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if !knownHostsPatternMatch(strings.TrimPrefix(pattern, "!"), target) {
+			continue
+		}
+		if negated {
+			return false
 		}
+		matched = true
+	}
+	return matched
+}
 
-		// Compare the key fingerprints
-		expectedFingerprint := ssh.FingerprintSHA256(expectedKey)
-		actualFingerprint := ssh.FingerprintSHA256(key)
+// knownHostsPatternMatch matches a single known_hosts host pattern against a
+// normalized host
+func knownHostsPatternMatch(pattern, target string) bool {
+	if hashed, ok := strings.CutPrefix(pattern, "|1|"); ok {
+		salt64, hash64, ok := strings.Cut(hashed, "|")
+		if !ok {
+			return false
+		}
+		salt, saltErr := base64.StdEncoding.DecodeString(salt64)
+		hash, hashErr := base64.StdEncoding.DecodeString(hash64)
+		if saltErr != nil || hashErr != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(target))
+		return hmac.Equal(mac.Sum(nil), hash)
+	}
+
+	// Brackets delimit a port, not a character class
+	pattern = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(strings.ToLower(pattern))
+	matched, err := path.Match(pattern, target)
+	return err == nil && matched
+}
+
+// normalizeFingerprint validates a SHA256 host key fingerprint and returns it in
+// the "SHA256:<base64>" form produced by ssh.FingerprintSHA256. The prefix is optional on input.
+func normalizeFingerprint(fp string) (string, error) {
+	fp = strings.TrimSpace(fp)
+	raw := strings.TrimPrefix(fp, "SHA256:")
+	decoded, err := base64.RawStdEncoding.DecodeString(raw)
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid SHA256 host key fingerprint %q", fp)
+	}
+	return "SHA256:" + raw, nil
+}
 
-		if expectedFingerprint != actualFingerprint {
-			klog.Errorf("SSH HOST KEY VERIFICATION FAILED for %s!", hostname)
-			klog.Errorf("Expected fingerprint: %s", expectedFingerprint)
-			klog.Errorf("Actual fingerprint:   %s", actualFingerprint)
-			klog.Errorf("This could indicate a man-in-the-middle attack or host key change!")
+// createHostKeyCallback creates an SSH host key callback that accepts any of the
+// given SHA256 fingerprints. Multiple fingerprints allow a rotation window where
+// both the old and new RDS host keys are trusted.
+func createHostKeyCallback(acceptedFingerprints []string, hostname string) ssh.HostKeyCallback {
+	return func(h string, remote net.Addr, key ssh.PublicKey) error {
+		actualFingerprint := ssh.FingerprintSHA256(key)
 
-			// Log critical security event
-			secLogger := security.GetLogger()
-			secLogger.LogSSHHostKeyMismatch(hostname, expectedFingerprint, actualFingerprint)
+		for i, expected := range acceptedFingerprints {
+			if actualFingerprint == expected {
+				klog.V(2).Infof("SSH host key for %s matched accepted key %d of %d (%s %s)",
+					hostname, i+1, len(acceptedFingerprints), key.Type(), actualFingerprint)
 
-			return fmt.Errorf("SSH host key verification failed for %s: fingerprint mismatch (expected %s, got %s)",
-				hostname, expectedFingerprint, actualFingerprint)
+				// Log successful host key verification
+				secLogger := security.GetLogger()
+				secLogger.LogSSHHostKeyVerified(hostname, actualFingerprint)
+				return nil
+			}
 		}
 
-		klog.V(4).Infof("SSH host key verified for %s: %s", hostname, actualFingerprint)
+		expected := strings.Join(acceptedFingerprints, ", ")
+		klog.Errorf("SSH HOST KEY VERIFICATION FAILED for %s!", hostname)
+		klog.Errorf("Accepted fingerprints: %s", expected)
+		klog.Errorf("Presented fingerprint: %s (%s)", actualFingerprint, key.Type())
+		klog.Errorf("This could indicate a man-in-the-middle attack or host key change!")
 
-		// Log successful host key verification
+		// Log critical security event
 		secLogger := security.GetLogger()
-		secLogger.LogSSHHostKeyVerified(hostname, actualFingerprint)
-		return nil
+		secLogger.LogSSHHostKeyMismatch(hostname, expected, actualFingerprint)
+
+		return fmt.Errorf("SSH host key verification failed for %s: presented %s key %s matches none of %d accepted keys (%s)",
+			hostname, key.Type(), actualFingerprint, len(acceptedFingerprints), expected)
 	}
 }
@@ -1,17 +1,24 @@
 package rds
 
 import (
+	"bytes"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"k8s.io/klog/v2"
 )
 
 // ============================================================================
@@ -174,6 +181,11 @@ func TestIsRetryableError(t *testing.T) {
 			err:       errors.New("authentication failed"),
 			retryable: false,
 		},
+		{
+			name:      "already exists returns false",
+			err:       errors.New("command failed (exit 1): failure: volume already exists"),
+			retryable: false,
+		},
 		{
 			name:      "generic error returns true (retryable by default)",
 			err:       errors.New("connection reset by peer"),
@@ -214,16 +226,16 @@ func TestParseHostKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			key, err := parseHostKey(tt.keyData)
+			keys, err := parseHostKeys(tt.keyData, "10.42.241.3", 22)
 
 			if tt.expectErr {
 				require.Error(t, err)
-				assert.Nil(t, key)
+				assert.Nil(t, keys)
 				return
 			}
 
 			require.NoError(t, err)
-			assert.NotNil(t, key)
+			assert.Len(t, keys, 1)
 		})
 	}
 }
@@ -328,6 +340,189 @@ func TestIndexString(t *testing.T) {
 	}
 }
 
+func TestParseHostKeys_MultipleFormats(t *testing.T) {
+	oldKey, err := generateTestHostKey()
+	require.NoError(t, err)
+	newKey, err := generateTestHostKey()
+	require.NoError(t, err)
+
+	oldLine := string(ssh.MarshalAuthorizedKey(oldKey.PublicKey()))
+	newLine := "10.42.241.3,rds.local " + string(ssh.MarshalAuthorizedKey(newKey.PublicKey()))
+
+	data := "# RDS host keys (rotation window)\n\n" + oldLine + newLine
+
+	keys, err := parseHostKeys([]byte(data), "10.42.241.3", 22)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, ssh.FingerprintSHA256(oldKey.PublicKey()), ssh.FingerprintSHA256(keys[0]))
+	assert.Equal(t, ssh.FingerprintSHA256(newKey.PublicKey()), ssh.FingerprintSHA256(keys[1]))
+}
+
+func TestParseHostKeys_SkipsRevoked(t *testing.T) {
+	key, err := generateTestHostKey()
+	require.NoError(t, err)
+	revoked, err := generateTestHostKey()
+	require.NoError(t, err)
+
+	data := "@revoked * " + string(ssh.MarshalAuthorizedKey(revoked.PublicKey())) +
+		string(ssh.MarshalAuthorizedKey(key.PublicKey()))
+
+	keys, err := parseHostKeys([]byte(data), "10.42.241.3", 22)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, ssh.FingerprintSHA256(key.PublicKey()), ssh.FingerprintSHA256(keys[0]))
+}
+
+func TestParseHostKeys_KnownHostsPatterns(t *testing.T) {
+	key, err := generateTestHostKey()
+	require.NoError(t, err)
+	other, err := generateTestHostKey()
+	require.NoError(t, err)
+
+	data := "rds.local,10.42.241.* " + string(ssh.MarshalAuthorizedKey(key.PublicKey())) +
+		"10.42.99.1 " + string(ssh.MarshalAuthorizedKey(other.PublicKey()))
+
+	keys, err := parseHostKeys([]byte(data), "10.42.241.3", 22)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, ssh.FingerprintSHA256(key.PublicKey()), ssh.FingerprintSHA256(keys[0]))
+
+	_, err = parseHostKeys([]byte(data), "10.42.50.1", 22)
+	assert.Error(t, err, "no entry matches the host")
+}
+
+func TestKnownHostsMatch(t *testing.T) {
+	hashed := knownhosts.HashHostname("10.42.241.3")
+	tests := []struct {
+		patterns []string
+		host     string
+		port     int
+		want     bool
+	}{
+		{[]string{"10.42.241.3"}, "10.42.241.3", 22, true},
+		{[]string{"RDS.local"}, "rds.local", 22, true},
+		{[]string{"10.42.241.3"}, "10.42.241.4", 22, false},
+		{[]string{"10.42.241.?"}, "10.42.241.3", 22, true},
+		{[]string{"*.local"}, "rds.local", 22, true},
+		{[]string{"10.42.241.3"}, "10.42.241.3", 2222, false},
+		{[]string{"[10.42.241.3]:2222"}, "10.42.241.3", 2222, true},
+		{[]string{"*", "!10.42.241.3"}, "10.42.241.3", 22, false},
+		{[]string{"*", "!10.42.241.3"}, "10.42.241.4", 22, true},
+		{[]string{hashed}, "10.42.241.3", 22, true},
+		{[]string{hashed}, "10.42.241.4", 22, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, knownHostsMatch(tt.patterns, tt.host, tt.port), "%v against %s:%d", tt.patterns, tt.host, tt.port)
+	}
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	key, err := generateTestHostKey()
+	require.NoError(t, err)
+	fp := ssh.FingerprintSHA256(key.PublicKey())
+
+	got, err := normalizeFingerprint(fp)
+	require.NoError(t, err)
+	assert.Equal(t, fp, got)
+
+	got, err = normalizeFingerprint(strings.TrimPrefix(fp, "SHA256:"))
+	require.NoError(t, err)
+	assert.Equal(t, fp, got, "prefix should be optional")
+
+	_, err = normalizeFingerprint("SHA256:not-base64!")
+	assert.Error(t, err)
+	_, err = normalizeFingerprint("SHA256:AAAA")
+	assert.Error(t, err, "wrong length should be rejected")
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	oldKey, err := generateTestHostKey()
+	require.NoError(t, err)
+	newKey, err := generateTestHostKey()
+	require.NoError(t, err)
+	otherKey, err := generateTestHostKey()
+	require.NoError(t, err)
+
+	oldFP := ssh.FingerprintSHA256(oldKey.PublicKey())
+	newFP := ssh.FingerprintSHA256(newKey.PublicKey())
+	otherFP := ssh.FingerprintSHA256(otherKey.PublicKey())
+
+	tests := []struct {
+		name      string
+		config    ClientConfig
+		presented ssh.PublicKey
+		expectErr bool
+	}{
+		{
+			name:      "single key match",
+			config:    ClientConfig{HostKey: ssh.MarshalAuthorizedKey(oldKey.PublicKey())},
+			presented: oldKey.PublicKey(),
+		},
+		{
+			name: "rotation overlap accepts old key",
+			config: ClientConfig{HostKey: append(ssh.MarshalAuthorizedKey(oldKey.PublicKey()),
+				ssh.MarshalAuthorizedKey(newKey.PublicKey())...)},
+			presented: oldKey.PublicKey(),
+		},
+		{
+			name: "rotation overlap accepts new key",
+			config: ClientConfig{HostKey: append(ssh.MarshalAuthorizedKey(oldKey.PublicKey()),
+				ssh.MarshalAuthorizedKey(newKey.PublicKey())...)},
+			presented: newKey.PublicKey(),
+		},
+		{
+			name:      "fingerprint only",
+			config:    ClientConfig{HostKeyFingerprints: []string{newFP}},
+			presented: newKey.PublicKey(),
+		},
+		{
+			name: "host key and fingerprint combined",
+			config: ClientConfig{
+				HostKey:             ssh.MarshalAuthorizedKey(oldKey.PublicKey()),
+				HostKeyFingerprints: []string{newFP},
+			},
+			presented: newKey.PublicKey(),
+		},
+		{
+			name: "mismatch",
+			config: ClientConfig{HostKey: append(ssh.MarshalAuthorizedKey(oldKey.PublicKey()),
+				ssh.MarshalAuthorizedKey(newKey.PublicKey())...)},
+			presented: otherKey.PublicKey(),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Address = "10.42.241.3"
+			tt.config.User = "admin"
+			client, err := newSSHClient(tt.config)
+			require.NoError(t, err)
+			require.NotNil(t, client.hostKeyCallback)
+
+			err = client.hostKeyCallback("10.42.241.3:22", nil, tt.presented)
+			if !tt.expectErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), otherFP, "error should include the presented fingerprint")
+			assert.Contains(t, err.Error(), oldFP)
+			assert.Contains(t, err.Error(), newFP)
+		})
+	}
+}
+
+func TestNewSSHClient_InvalidFingerprint(t *testing.T) {
+	_, err := newSSHClient(ClientConfig{
+		Address:             "10.42.241.3",
+		User:                "admin",
+		HostKeyFingerprints: []string{"md5:aa:bb"},
+	})
+	assert.Error(t, err)
+}
+
 // ============================================================================
 // Part B: SSH mock server tests for Connect/runCommand/runCommandWithRetry
 // ============================================================================
@@ -614,7 +809,7 @@ func TestSSHClientRunCommandWithRetry(t *testing.T) {
 
 		client := createConnectedTestClient(t, srv)
 
-		output, err := client.runCommandWithRetry("/disk print", 3)
+		output, err := client.runCommandWithRetry("/disk print", 3, idempotentCommand)
 		require.NoError(t, err)
 		assert.Contains(t, output, "success")
 		assert.Equal(t, 2, attemptCount, "should succeed on second attempt")
@@ -636,7 +831,7 @@ func TestSSHClientRunCommandWithRetry(t *testing.T) {
 
 		client := createConnectedTestClient(t, srv)
 
-		_, err := client.runCommandWithRetry("/disk add", 3)
+		_, err := client.runCommandWithRetry("/disk add", 3, nil)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "not enough space")
 	})
@@ -662,19 +857,91 @@ func TestSSHClientRunCommandWithRetry(t *testing.T) {
 
 		client := createConnectedTestClient(t, srv)
 
-		_, err := client.runCommandWithRetry("/disk print", 3)
+		_, err := client.runCommandWithRetry("/disk print", 3, idempotentCommand)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "max retries")
 		assert.Equal(t, 3, attemptCount, "should attempt exactly 3 times")
 	})
 }
 
+// scriptedExecutor fails the commands it runs with errs in turn, then succeeds
+type scriptedExecutor struct {
+	errs []error
+	runs int
+}
+
+func (e *scriptedExecutor) Run(ctx context.Context, command string) (string, error) {
+	e.runs++
+	if e.runs <= len(e.errs) {
+		return "", e.errs[e.runs-1]
+	}
+	return "ok", nil
+}
+
+func TestRunCommandWithRetry_Interrupted(t *testing.T) {
+	notSent := fmt.Errorf("failed to create SSH session: %w", ErrCommandNotSent)
+	interrupted := fmt.Errorf("failed to run command: %w: %w", ErrCommandInterrupted, io.EOF)
+	verifyErr := errors.New("lookup failed")
+	// executedOnCall reports the command executed from the nth check on
+	executedOnCall := func(n int) commandVerifier {
+		calls := 0
+		return func() (bool, error) {
+			calls++
+			return calls >= n, nil
+		}
+	}
+
+	tests := []struct {
+		name       string
+		errs       []error
+		verify     commandVerifier
+		wantRuns   int
+		wantVerify int
+		wantOutput string
+		wantErr    bool
+	}{
+		{name: "not sent is retried", errs: []error{notSent}, wantRuns: 2, wantOutput: "ok"},
+		{name: "interrupted without verifier is not retried", errs: []error{interrupted}, wantRuns: 1, wantErr: true},
+		{name: "interrupted and executed", errs: []error{interrupted}, verify: func() (bool, error) { return true, nil }, wantRuns: 1, wantVerify: 1},
+		{name: "interrupted and not executed", errs: []error{interrupted}, verify: func() (bool, error) { return false, nil }, wantRuns: 2, wantVerify: 1, wantOutput: "ok"},
+		{name: "verifier fails", errs: []error{interrupted}, verify: func() (bool, error) { return false, verifyErr }, wantRuns: 1, wantVerify: 1, wantErr: true},
+		{name: "last attempt interrupted and executed", errs: []error{interrupted, interrupted, interrupted}, verify: executedOnCall(3), wantRuns: 3, wantVerify: 3},
+		{name: "last attempt interrupted and not executed", errs: []error{interrupted, interrupted, interrupted}, verify: func() (bool, error) { return false, nil }, wantRuns: 3, wantVerify: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &scriptedExecutor{errs: tt.errs}
+			client := &sshClient{sshState: &sshState{address: "10.42.68.1", executor: executor}}
+			verifies := 0
+			var verify commandVerifier
+			if tt.verify != nil {
+				verify = func() (bool, error) {
+					verifies++
+					return tt.verify()
+				}
+			}
+
+			output, err := client.runCommandWithRetry("/disk add", 3, verify)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrCommandInterrupted)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantOutput, output)
+			assert.Equal(t, tt.wantRuns, executor.runs, "command runs")
+			assert.Equal(t, tt.wantVerify, verifies, "verifier calls")
+		})
+	}
+}
+
 func TestSSHClientNotConnected(t *testing.T) {
-	client := &sshClient{
+	client := &sshClient{sshState: &sshState{
 		address: "10.42.68.1",
 		port:    22,
 		user:    "admin",
-	}
+	}}
 
 	// runCommand should fail when not connected
 	_, err := client.runCommand("/disk print")
@@ -700,3 +967,97 @@ func TestSSHClientConnectFailure(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to connect")
 }
+
+// captureKlog sets klog verbosity to v and returns a buffer receiving all log output.
+// The previous settings are restored when the test ends.
+func captureKlog(t *testing.T, v string) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	require.NoError(t, flags.Set("v", v))
+	require.NoError(t, flags.Set("logtostderr", "false"))
+	require.NoError(t, flags.Set("alsologtostderr", "false"))
+
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		klog.SetOutput(io.Discard)
+		_ = flags.Set("v", "0")
+		_ = flags.Set("logtostderr", "true")
+	})
+	return &buf
+}
+
+func TestSSHClientRunCommand_RawIOLogging(t *testing.T) {
+	const command = `/interface nvme-tcp set nvme1 dhchap-key="s3cr3t-token" comment=pvc-a`
+	const output = `name="nvme1" dhchap-key="s3cr3t-token" password=hunter2 comment=pvc-a`
+
+	received := make(chan string, 2)
+	srv := startMockSSHServer(t, func(channel ssh.Channel, requests <-chan *ssh.Request) {
+		defer func() { _ = channel.Close() }()
+		for req := range requests {
+			if req.Type == "exec" {
+				_ = req.Reply(true, nil)
+				received <- string(req.Payload[4:])
+				_, _ = channel.Write([]byte(output))
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(&struct{ Status uint32 }{0}))
+				return
+			}
+		}
+	})
+
+	for _, logRawIO := range []bool{false, true} {
+		t.Run(fmt.Sprintf("logRawIO=%v", logRawIO), func(t *testing.T) {
+			logs := captureKlog(t, "5")
+			client := createConnectedTestClient(t, srv)
+			client.logRawIO = logRawIO
+
+			got, err := client.runCommand(command)
+			require.NoError(t, err)
+			klog.Flush()
+
+			// RDS receives the command unchanged and the caller gets the real output
+			assert.Equal(t, command, <-received)
+			assert.Equal(t, output, got)
+
+			logged := logs.String()
+			assert.NotContains(t, logged, "s3cr3t-token")
+			assert.NotContains(t, logged, "hunter2")
+			if logRawIO {
+				assert.Contains(t, logged, `"Executing RouterOS command" command="/interface nvme-tcp set nvme1 dhchap-key=<redacted> comment=pvc-a"`)
+				assert.Contains(t, logged, `password=<redacted>`)
+			} else {
+				assert.NotContains(t, logged, "Executing RouterOS command")
+				assert.NotContains(t, logged, "Command output")
+			}
+		})
+	}
+}
+
+func TestSSHClientRunCommand_LogsRequestID(t *testing.T) {
+	srv := startMockSSHServer(t, func(channel ssh.Channel, requests <-chan *ssh.Request) {
+		defer func() { _ = channel.Close() }()
+		for req := range requests {
+			if req.Type == "exec" {
+				_ = req.Reply(true, nil)
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(&struct{ Status uint32 }{0}))
+				return
+			}
+		}
+	})
+	logs := captureKlog(t, "5")
+	client := createConnectedTestClient(t, srv)
+	client.logRawIO = true
+
+	view := client.withCall(Call{Operation: "DeleteVolume", VolumeID: "pvc-a", RequestID: "kubelet-7f3a"}).(*sshClient)
+	_, err := view.runCommand("/disk print")
+	require.NoError(t, err)
+	_, err = client.runCommand("/disk print")
+	require.NoError(t, err)
+	klog.Flush()
+
+	// Only the view's commands belong to the call
+	assert.Equal(t, 2, strings.Count(logs.String(), `"Executing RouterOS command"`))
+	assert.Equal(t, 1, strings.Count(logs.String(), `"Executing RouterOS command" requestID="kubelet-7f3a" command="/disk print"`))
+}
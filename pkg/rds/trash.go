@@ -0,0 +1,126 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
+)
+
+const (
+	// TrashDirName is the directory, next to the volume files, that retained backing files are moved into
+	TrashDirName = ".trash"
+
+	// trashTimestampFormat is embedded in trashed file names so age survives renames
+	trashTimestampFormat = "20060102T150405Z"
+)
+
+// TrashFilePath returns where the backing file of volumeID is moved when it is trashed at the given time.
+// Example: /storage-pool/metal-csi/pvc-1.img -> /storage-pool/metal-csi/.trash/pvc-1-20260102T150405Z.img
+func TrashFilePath(filePath, volumeID string, trashedAt time.Time) string {
+	name := fmt.Sprintf("%s-%s.img", volumeID, trashedAt.UTC().Format(trashTimestampFormat))
+	return path.Join(path.Dir(filePath), TrashDirName, name)
+}
+
+// ParseTrashedAt extracts the trash timestamp from a file name produced by TrashFilePath.
+// Returns false if the name does not carry one.
+func ParseTrashedAt(fileName string) (time.Time, bool) {
+	base := strings.TrimSuffix(fileName, ".img")
+	idx := strings.LastIndex(base, "-")
+	if idx < 0 {
+		return time.Time{}, false
+	}
+	trashedAt, err := time.Parse(trashTimestampFormat, base[idx+1:])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return trashedAt, true
+}
+
+// TrashVolume removes a volume's disk slot (and with it the NVMe export) but keeps the
+// backing file, moving it into the .trash directory for later recovery.
+// Returns the trashed file path, or "" if the volume did not exist or had no backing file.
+func (c *sshClient) TrashVolume(slot string) (string, error) {
+	if err := validateSlotName(slot); err != nil {
+		return "", err
+	}
+
+	volume, err := c.GetVolume(slot)
+	if err != nil {
+		if errors.Is(err, utils.ErrVolumeNotFound) {
+			c.logger().V(4).Info("Volume already deleted", "slot", slot)
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get volume info before deletion: %w", err)
+	}
+
+	filePath := volume.FilePath
+	trashPath := ""
+	if filePath != "" {
+		trashPath = TrashFilePath(filePath, slot, time.Now())
+		if err := utils.ValidateFilePath(trashPath); err != nil {
+			return "", fmt.Errorf("invalid trash path: %w", err)
+		}
+	}
+
+	// Step 1: Remove the disk slot (stops the NVMe export)
+	cmd := fmt.Sprintf(`/disk remove [find slot=%s]`, slot)
+	if _, err := c.runCommandWithRetry(cmd, 3, c.slotRemoved(slot)); err != nil {
+		if strings.Contains(err.Error(), "no such item") {
+			c.logger().V(4).Info("Volume disk slot does not exist, continuing to move backing file", "slot", slot)
+		} else {
+			return "", fmt.Errorf("failed to remove disk slot: %w", err)
+		}
+	}
+
+	if filePath == "" {
+		c.logger().V(2).Info("Deleted volume, no backing file to retain", "slot", slot)
+		return "", nil
+	}
+
+	// Step 2: Move the backing file into the trash directory. Unlike DeleteVolume this
+	// failure is returned: the file is left at its original path, where the orphan
+	// reconciler would treat it as an orphan.
+	c.ensureDirectory(path.Dir(trashPath))
+	if err := c.moveFile(filePath, trashPath); err != nil {
+		return "", fmt.Errorf("disk slot removed but failed to move backing file %s to trash: %w", filePath, err)
+	}
+
+	c.logger().V(2).Info("Deleted volume, backing file retained", "slot", slot, "trashPath", trashPath)
+	return trashPath, nil
+}
+
+// ensureDirectory creates a directory on RDS. Best-effort: the command fails if the
+// directory already exists, and a real failure surfaces from the following move.
+func (c *sshClient) ensureDirectory(dirPath string) {
+	cmd := fmt.Sprintf(`/file add type=directory name="%s"`, strings.TrimPrefix(dirPath, "/"))
+	if output, err := c.runCommand(cmd); err != nil {
+		c.logger().V(4).Info("Could not create directory, it may already exist", "path", dirPath, "err", err, "output", output)
+	}
+}
+
+// moveFile renames a file on RDS. Both paths must be under an allowed base path.
+func (c *sshClient) moveFile(srcPath, dstPath string) error {
+	if err := utils.ValidateFilePath(srcPath); err != nil {
+		return fmt.Errorf("invalid source path: %w", err)
+	}
+	if err := utils.ValidateFilePath(dstPath); err != nil {
+		return fmt.Errorf("invalid destination path: %w", err)
+	}
+
+	cmd := fmt.Sprintf(`/file set [find name="%s"] name="%s"`,
+		strings.TrimPrefix(srcPath, "/"), strings.TrimPrefix(dstPath, "/"))
+	output, err := c.runCommand(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	if strings.Contains(strings.ToLower(output), "error") || strings.Contains(strings.ToLower(output), "failure") {
+		return fmt.Errorf("error moving file: %s", output)
+	}
+
+	c.logger().V(4).Info("Moved file", "from", srcPath, "to", dstPath)
+	return nil
+}
@@ -0,0 +1,29 @@
+package rds
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashFilePath(t *testing.T) {
+	trashedAt := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	got := TrashFilePath("/storage-pool/metal-csi/pvc-123.img", "pvc-123", trashedAt)
+	want := "/storage-pool/metal-csi/.trash/pvc-123-20260102T150405Z.img"
+	if got != want {
+		t.Errorf("TrashFilePath() = %q, want %q", got, want)
+	}
+
+	parsed, ok := ParseTrashedAt("pvc-123-20260102T150405Z.img")
+	if !ok || !parsed.Equal(trashedAt) {
+		t.Errorf("ParseTrashedAt() = %v, %v, want %v", parsed, ok, trashedAt)
+	}
+}
+
+func TestParseTrashedAt_NoTimestamp(t *testing.T) {
+	for _, name := range []string{"pvc-123.img", "manual.img", "pvc-123-notatime.img"} {
+		if _, ok := ParseTrashedAt(name); ok {
+			t.Errorf("ParseTrashedAt(%q) should not find a timestamp", name)
+		}
+	}
+}
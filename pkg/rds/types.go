@@ -14,7 +14,28 @@ type VolumeInfo struct {
 	NVMETCPExport bool   // Whether NVMe/TCP export is enabled
 	NVMETCPPort   int    // NVMe/TCP server port
 	NVMETCPNQN    string // NVMe Qualified Name
+	WWID          string // Namespace identifier as Linux reports it ("eui.<hex>"), empty if RDS doesn't report one
 	Status        string // "ready", "formatting", "error"
+	Comment       string // Disk comment, e.g. "<namespace>/<pvc-name>"; empty if unset
+
+	// QoS holds the IO limits RDS reports for the disk (zero fields are unlimited or not reported)
+	QoS VolumeQoS
+
+	// ParseWarnings lists properties present in the disk entry that could not be parsed
+	ParseWarnings []string
+}
+
+// VolumeQoS holds per-volume IO limits enforced by RDS. Zero fields mean unlimited.
+// Only supported on RouterOS VolumeQoSMinVersion and later.
+type VolumeQoS struct {
+	MaxReadIOPS       int64 // Maximum read operations per second
+	MaxWriteIOPS      int64 // Maximum write operations per second
+	MaxBandwidthBytes int64 // Maximum combined read and write throughput in bytes per second
+}
+
+// IsSet reports whether any limit is configured
+func (q VolumeQoS) IsSet() bool {
+	return q.MaxReadIOPS != 0 || q.MaxWriteIOPS != 0 || q.MaxBandwidthBytes != 0
 }
 
 // CapacityInfo represents filesystem capacity information
@@ -32,6 +53,18 @@ type CreateVolumeOptions struct {
 	FileSizeBytes int64  // Size in bytes
 	NVMETCPPort   int    // NVMe/TCP port (default 4420)
 	NVMETCPNQN    string // NVMe Qualified Name
+	Comment       string // Optional disk comment (see utils.SanitizeDiskComment)
+
+	// QoS sets optional IO limits; creation fails with ErrQoSUnsupported if RouterOS cannot enforce them
+	QoS VolumeQoS
+
+	// AdoptExistingFile exports the file already at FilePath instead of creating it.
+	// The caller must have checked that the file has FileSizeBytes.
+	AdoptExistingFile bool
+
+	// CommandTimeout limits the /disk add of this volume instead of the client's long
+	// command timeout (0 for the client's)
+	CommandTimeout time.Duration
 }
 
 // FileInfo represents a file on the RDS filesystem
@@ -59,6 +92,7 @@ type SnapshotInfo struct {
 	FileSizeBytes int64     // Size of snapshot (copied from source volume)
 	CreatedAt     time.Time // Creation timestamp (parsed from slot name or RDS output)
 	FilePath      string    // Backing file path on RDS (e.g., /storage-pool/metal-csi/snap-xxx.img)
+	Comment       string    // Disk comment, e.g. "<namespace>/<volumesnapshot-name>"; empty if unset
 }
 
 // CreateSnapshotOptions contains parameters for creating a snapshot
@@ -66,6 +100,7 @@ type CreateSnapshotOptions struct {
 	Name         string // snap-<source-uuid>-at-<timestamp>
 	SourceVolume string // pvc-<uuid> (source volume slot)
 	BasePath     string // Base directory for snapshot files (e.g., /storage-pool/metal-csi)
+	Comment      string // Optional disk comment (see utils.SanitizeDiskComment)
 }
 
 // SnapshotNotFoundError is returned when a snapshot is not found
@@ -77,6 +112,17 @@ func (e *SnapshotNotFoundError) Error() string {
 	return fmt.Sprintf("snapshot not found: %s", e.Name)
 }
 
+// SnapshotVerificationError is returned when a snapshot's backing file on RDS does not match
+// its source volume
+type SnapshotVerificationError struct {
+	Name   string
+	Reason string
+}
+
+func (e *SnapshotVerificationError) Error() string {
+	return fmt.Sprintf("snapshot %s failed verification: %s", e.Name, e.Reason)
+}
+
 // DiskMetrics represents real-time disk performance metrics from /disk monitor-traffic
 type DiskMetrics struct {
 	Slot              string  // Disk slot name (e.g., "storage-pool")
@@ -104,3 +150,12 @@ type HardwareHealthMetrics struct {
 	DiskPoolSizeBytes float64 // RAID6 pool total size in bytes
 	DiskPoolUsedBytes float64 // RAID6 pool used space in bytes
 }
+
+// SystemInfo represents RouterOS system information from /system resource print
+type SystemInfo struct {
+	Version          string // RouterOS version without channel, e.g. "7.16" or "7.17beta4"
+	Channel          string // Release channel, e.g. "stable", "long-term", "testing"; empty if not reported
+	BoardName        string // Board model, e.g. "RDS2216"
+	ArchitectureName string // CPU architecture, e.g. "arm64"
+	Uptime           string // Uptime as reported by RouterOS, e.g. "2w3d4h5m6s"
+}
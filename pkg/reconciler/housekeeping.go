@@ -0,0 +1,143 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+// FileHousekeeperConfig contains configuration for the file housekeeper
+type FileHousekeeperConfig struct {
+	// RDSClient is the RDS client for listing and deleting files
+	RDSClient rds.RDSClient
+
+	// K8sClient is used to protect files of volumes that still have a PV
+	K8sClient kubernetes.Interface
+
+	// BasePath is the directory on RDS holding volume and snapshot files
+	BasePath string
+
+	// GracePeriod is the minimum file age before it can be removed (default: DefaultOrphanGracePeriod)
+	GracePeriod time.Duration
+}
+
+// FileHousekeeper removes volume and snapshot backing files that nothing references any more.
+// Snapshots on RDS are full copies, so repeated snapshot/restore/delete cycles can leave
+// backing files behind whose disk entries are gone. Unlike the orphan reconciler it runs
+// only on demand, from the admin endpoint.
+type FileHousekeeper struct {
+	config FileHousekeeperConfig
+
+	// mu serializes runs; a second request waits rather than racing deletions
+	mu sync.Mutex
+}
+
+// HousekeepingReport describes one housekeeping run
+type HousekeepingReport struct {
+	DryRun   bool               `json:"dryRun"`
+	BasePath string             `json:"basePath"`
+	Files    []HousekeepingFile `json:"files"`
+	// BytesReclaimed is the total size of removed files, or of removable files in a dry run
+	BytesReclaimed int64 `json:"bytesReclaimed"`
+	// Skipped counts unreferenced files kept because they are too young or still have a PV
+	Skipped int `json:"skipped"`
+}
+
+// HousekeepingFile is a file removed (or, in a dry run, to be removed) by housekeeping
+type HousekeepingFile struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"sizeBytes"`
+	Removed   bool   `json:"removed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// NewFileHousekeeper creates a new file housekeeper
+func NewFileHousekeeper(config FileHousekeeperConfig) (*FileHousekeeper, error) {
+	if config.RDSClient == nil {
+		return nil, fmt.Errorf("RDSClient is required")
+	}
+	if config.K8sClient == nil {
+		return nil, fmt.Errorf("K8sClient is required")
+	}
+	if config.BasePath == "" {
+		return nil, fmt.Errorf("BasePath is required")
+	}
+	if config.GracePeriod == 0 {
+		config.GracePeriod = DefaultOrphanGracePeriod
+	}
+	return &FileHousekeeper{config: config}, nil
+}
+
+// Run finds unreferenced backing files and, unless dryRun is set, deletes them.
+// A file is kept if a PV still names it or it is younger than the grace period
+// (or its age is unknown). Failures to delete individual files are reported per file.
+func (h *FileHousekeeper) Run(ctx context.Context, dryRun bool) (*HousekeepingReport, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	klog.V(2).Infof("Starting file housekeeping in %s (dry_run=%v)", h.config.BasePath, dryRun)
+	start := time.Now()
+
+	// Check PVs first, so a volume created during the scan has a disk entry by the time files are listed
+	pvList, err := h.config.K8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Kubernetes PVs: %w", err)
+	}
+	activeVolumeIDs := make(map[string]bool)
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == "rds.csi.srvlab.io" {
+			activeVolumeIDs[pv.Spec.CSI.VolumeHandle] = true
+		}
+	}
+
+	candidates, err := h.config.RDSClient.FindUnreferencedFiles(h.config.BasePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unreferenced files: %w", err)
+	}
+
+	report := &HousekeepingReport{
+		DryRun:   dryRun,
+		BasePath: h.config.BasePath,
+		Files:    []HousekeepingFile{},
+	}
+	for _, file := range candidates {
+		volumeID := strings.TrimSuffix(file.Name, ".img")
+		if activeVolumeIDs[volumeID] {
+			klog.Warningf("File %s has no disk entry but PV %s still exists - keeping", file.Path, volumeID)
+			report.Skipped++
+			continue
+		}
+		if file.CreatedAt.IsZero() || time.Since(file.CreatedAt) < h.config.GracePeriod {
+			klog.V(4).Infof("File %s is too young or has unknown age (created=%v, grace=%v) - keeping",
+				file.Path, file.CreatedAt, h.config.GracePeriod)
+			report.Skipped++
+			continue
+		}
+
+		result := HousekeepingFile{Path: file.Path, SizeBytes: file.SizeBytes}
+		if dryRun {
+			klog.Infof("[DRY-RUN] Would delete unreferenced file: %s (%d bytes)", file.Path, file.SizeBytes)
+			report.BytesReclaimed += file.SizeBytes
+		} else if err := h.config.RDSClient.DeleteFile(file.Path); err != nil {
+			klog.Errorf("Failed to delete unreferenced file %s: %v", file.Path, err)
+			result.Error = err.Error()
+		} else {
+			klog.Infof("Deleted unreferenced file: %s (%d bytes)", file.Path, file.SizeBytes)
+			result.Removed = true
+			report.BytesReclaimed += file.SizeBytes
+		}
+		report.Files = append(report.Files, result)
+	}
+
+	klog.V(2).Infof("File housekeeping complete (duration=%v, files=%d, skipped=%d, bytes_reclaimed=%d, dry_run=%v)",
+		time.Since(start), len(report.Files), report.Skipped, report.BytesReclaimed, dryRun)
+	return report, nil
+}
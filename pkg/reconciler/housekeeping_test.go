@@ -0,0 +1,134 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+)
+
+func newTestHousekeeper(t *testing.T, mockRDS *mockRDSClient, pvVolumeIDs ...string) *FileHousekeeper {
+	t.Helper()
+	k8sClient := fake.NewSimpleClientset()
+	for _, volumeID := range pvVolumeIDs {
+		pv := &v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + volumeID},
+			Spec: v1.PersistentVolumeSpec{
+				PersistentVolumeSource: v1.PersistentVolumeSource{
+					CSI: &v1.CSIPersistentVolumeSource{Driver: "rds.csi.srvlab.io", VolumeHandle: volumeID},
+				},
+			},
+		}
+		if _, err := k8sClient.CoreV1().PersistentVolumes().Create(context.Background(), pv, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create test PV: %v", err)
+		}
+	}
+
+	housekeeper, err := NewFileHousekeeper(FileHousekeeperConfig{
+		RDSClient:   mockRDS,
+		K8sClient:   k8sClient,
+		BasePath:    "/storage-pool/metal-csi",
+		GracePeriod: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewFileHousekeeper() failed: %v", err)
+	}
+	return housekeeper
+}
+
+func housekeepingTestFiles() *mockRDSClient {
+	old := time.Now().Add(-24 * time.Hour)
+	return &mockRDSClient{
+		volumes: []rds.VolumeInfo{
+			{Slot: "pvc-live", FilePath: "/storage-pool/metal-csi/pvc-live.img"},
+			{Slot: "snap-live-at-1", FilePath: "/storage-pool/metal-csi/snap-live-at-1.img"},
+		},
+		files: []rds.FileInfo{
+			{Name: "pvc-live.img", Path: "/storage-pool/metal-csi/pvc-live.img", SizeBytes: 100, CreatedAt: old},
+			{Name: "snap-live-at-1.img", Path: "/storage-pool/metal-csi/snap-live-at-1.img", SizeBytes: 100, CreatedAt: old},
+			{Name: "pvc-dead.img", Path: "/storage-pool/metal-csi/pvc-dead.img", SizeBytes: 1000, CreatedAt: old},
+			{Name: "snap-dead-at-1.img", Path: "/storage-pool/metal-csi/snap-dead-at-1.img", SizeBytes: 2000, CreatedAt: old},
+			{Name: "pvc-new.img", Path: "/storage-pool/metal-csi/pvc-new.img", SizeBytes: 10, CreatedAt: time.Now()},
+			{Name: "pvc-bound.img", Path: "/storage-pool/metal-csi/pvc-bound.img", SizeBytes: 10, CreatedAt: old},
+			{Name: "manual.img", Path: "/storage-pool/metal-csi/manual.img", SizeBytes: 10, CreatedAt: old},
+			{Name: "pvc-x-20260102T150405Z.img", Path: "/storage-pool/metal-csi/.trash/pvc-x-20260102T150405Z.img", SizeBytes: 10, CreatedAt: old},
+		},
+	}
+}
+
+func TestFileHousekeeper_DryRun(t *testing.T) {
+	mockRDS := housekeepingTestFiles()
+	housekeeper := newTestHousekeeper(t, mockRDS, "pvc-bound")
+
+	report, err := housekeeper.Run(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	if len(mockRDS.deletedFiles) != 0 {
+		t.Errorf("dry run deleted files: %v", mockRDS.deletedFiles)
+	}
+	if !report.DryRun || report.BytesReclaimed != 3000 {
+		t.Errorf("report = %+v, want dry run reclaiming 3000 bytes", report)
+	}
+	if len(report.Files) != 2 || report.Files[0].Removed || report.Files[1].Removed {
+		t.Errorf("expected 2 unremoved candidates, got %+v", report.Files)
+	}
+	// pvc-new is within the grace period, pvc-bound still has a PV
+	if report.Skipped != 2 {
+		t.Errorf("Skipped = %d, want 2", report.Skipped)
+	}
+}
+
+func TestFileHousekeeper_Delete(t *testing.T) {
+	mockRDS := housekeepingTestFiles()
+	housekeeper := newTestHousekeeper(t, mockRDS, "pvc-bound")
+
+	report, err := housekeeper.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	want := map[string]bool{
+		"/storage-pool/metal-csi/pvc-dead.img":       true,
+		"/storage-pool/metal-csi/snap-dead-at-1.img": true,
+	}
+	if len(mockRDS.deletedFiles) != len(want) {
+		t.Fatalf("deleted %v, want %v", mockRDS.deletedFiles, want)
+	}
+	for _, deleted := range mockRDS.deletedFiles {
+		if !want[deleted] {
+			t.Errorf("deleted file %s that is referenced, protected or not driver-owned", deleted)
+		}
+	}
+	if report.BytesReclaimed != 3000 {
+		t.Errorf("BytesReclaimed = %d, want 3000", report.BytesReclaimed)
+	}
+	for _, file := range report.Files {
+		if !file.Removed {
+			t.Errorf("file %s not reported as removed", file.Path)
+		}
+	}
+}
+
+func TestFileHousekeeper_UnknownAgeIsKept(t *testing.T) {
+	mockRDS := &mockRDSClient{
+		files: []rds.FileInfo{
+			{Name: "pvc-dead.img", Path: "/storage-pool/metal-csi/pvc-dead.img", SizeBytes: 1000},
+		},
+	}
+	housekeeper := newTestHousekeeper(t, mockRDS)
+
+	report, err := housekeeper.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if len(mockRDS.deletedFiles) != 0 || report.Skipped != 1 {
+		t.Errorf("file without creation time should be kept: deleted=%v report=%+v", mockRDS.deletedFiles, report)
+	}
+}
@@ -3,6 +3,7 @@ package reconciler
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 const (
@@ -23,8 +25,11 @@ const (
 	// This prevents premature cleanup of volumes that are still being provisioned
 	DefaultOrphanGracePeriod = 5 * time.Minute
 
-	// VolumeIDPrefix is the expected prefix for CSI-managed volumes
-	VolumeIDPrefix = "pvc-"
+	// DefaultTrashRetention is how long backing files retained by DeleteVolume are kept
+	DefaultTrashRetention = 7 * 24 * time.Hour
+
+	// slotAnnotation is the PV annotation the driver records a renamed slot in
+	slotAnnotation = "rds.csi.srvlab.io/slot"
 )
 
 // OrphanReconcilerConfig contains configuration for the orphan reconciler
@@ -50,6 +55,10 @@ type OrphanReconcilerConfig struct {
 	// BasePath is the directory path on RDS where volume files are stored
 	// Example: /storage-pool/metal-csi
 	BasePath string
+
+	// TrashRetention is how long files in BasePath/.trash are kept before being purged.
+	// Zero disables the trash sweeper.
+	TrashRetention time.Duration
 }
 
 // OrphanReconciler periodically checks for orphaned volumes and cleans them up
@@ -67,6 +76,13 @@ type OrphanedVolume struct {
 	CreatedAt time.Time
 }
 
+// TrashedFile represents a backing file retained by DeleteVolume in the trash directory
+type TrashedFile struct {
+	FilePath  string
+	SizeBytes int64
+	TrashedAt time.Time
+}
+
 // OrphanedFile represents a file that exists on the filesystem but has no disk object
 type OrphanedFile struct {
 	FileName  string
@@ -170,14 +186,22 @@ func (r *OrphanReconciler) reconcile(ctx context.Context) error {
 		return fmt.Errorf("failed to list Kubernetes PVs: %w", err)
 	}
 
-	// Build a map of active volume IDs from Kubernetes PVs
+	// Build maps of active volume IDs and backing files from Kubernetes PVs. A volume whose
+	// slot was renamed on RDS no longer matches its volume ID, but still matches its file.
 	activeVolumeIDs := make(map[string]bool)
+	activeFilePaths := make(map[string]bool)
 	klog.V(4).Infof("Scanning %d PersistentVolumes in Kubernetes", len(pvList.Items))
 	for _, pv := range pvList.Items {
 		// Only consider PVs from this CSI driver
 		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == "rds.csi.srvlab.io" {
 			volumeID := pv.Spec.CSI.VolumeHandle
 			activeVolumeIDs[volumeID] = true
+			if slot := pv.Annotations[slotAnnotation]; slot != "" {
+				activeVolumeIDs[slot] = true
+			}
+			if filePath := pvBackingFile(&pv); filePath != "" {
+				activeFilePaths[filePath] = true
+			}
 			klog.V(4).Infof("  Found active PV: %s → VolumeHandle=%s, Phase=%s, ClaimRef=%s/%s",
 				pv.Name, volumeID, pv.Status.Phase,
 				getNamespace(pv.Spec.ClaimRef), getName(pv.Spec.ClaimRef))
@@ -188,15 +212,15 @@ func (r *OrphanReconciler) reconcile(ctx context.Context) error {
 
 	// Log all RDS volumes for visibility
 	for _, vol := range rdsVolumes {
-		if strings.HasPrefix(vol.Slot, VolumeIDPrefix) {
-			hasActivePV := activeVolumeIDs[vol.Slot]
+		if utils.IsManagedSlot(vol.Slot) {
+			hasActivePV := activeVolumeIDs[vol.Slot] || activeFilePaths[vol.FilePath]
 			klog.V(4).Infof("  RDS volume: %s (size=%d bytes, path=%s, hasActivePV=%v)",
 				vol.Slot, vol.FileSizeBytes, vol.FilePath, hasActivePV)
 		}
 	}
 
 	// Reconcile orphaned disk objects (volumes without PVs)
-	diskOrphans := r.reconcileOrphanedDisks(rdsVolumes, activeVolumeIDs)
+	diskOrphans := r.reconcileOrphanedDisks(rdsVolumes, activeVolumeIDs, activeFilePaths)
 
 	// Reconcile orphaned files (files without disk objects)
 	fileOrphans := []OrphanedFile{}
@@ -207,23 +231,47 @@ func (r *OrphanReconciler) reconcile(ctx context.Context) error {
 		}
 	}
 
+	// Purge retained backing files past their retention window
+	expiredTrash := []TrashedFile{}
+	if r.config.BasePath != "" && r.config.TrashRetention > 0 {
+		expiredTrash, err = r.purgeExpiredTrash()
+		if err != nil {
+			klog.Errorf("Failed to purge trash: %v", err)
+		}
+	}
+
 	totalOrphans := len(diskOrphans) + len(fileOrphans)
-	klog.V(2).Infof("Orphan reconciliation cycle complete (duration=%v, disk_orphans=%d, file_orphans=%d, total=%d)",
-		time.Since(start), len(diskOrphans), len(fileOrphans), totalOrphans)
+	klog.V(2).Infof("Orphan reconciliation cycle complete (duration=%v, disk_orphans=%d, file_orphans=%d, total=%d, expired_trash=%d)",
+		time.Since(start), len(diskOrphans), len(fileOrphans), totalOrphans, len(expiredTrash))
 
 	return nil
 }
 
 // reconcileOrphanedDisks identifies and cleans up orphaned disk objects
-func (r *OrphanReconciler) reconcileOrphanedDisks(rdsVolumes []rds.VolumeInfo, activeVolumeIDs map[string]bool) []OrphanedVolume {
+func (r *OrphanReconciler) reconcileOrphanedDisks(rdsVolumes []rds.VolumeInfo, activeVolumeIDs, activeFilePaths map[string]bool) []OrphanedVolume {
 	orphans := []OrphanedVolume{}
 
-	klog.V(4).Infof("Checking %d RDS volumes for orphans (CSI-managed volumes must start with '%s')", len(rdsVolumes), VolumeIDPrefix)
+	slotNaming := utils.GetSlotNamingStrategy()
+	klog.V(4).Infof("Checking %d RDS volumes for orphans (CSI-managed volumes must start with one of %v)", len(rdsVolumes), slotNaming.Prefixes())
 
 	for _, vol := range rdsVolumes {
 		// Skip volumes that don't match our CSI-managed pattern
-		if !strings.HasPrefix(vol.Slot, VolumeIDPrefix) {
-			klog.V(5).Infof("  Skipping non-CSI volume: %s (does not start with '%s')", vol.Slot, VolumeIDPrefix)
+		if !slotNaming.IsManagedSlot(vol.Slot) {
+			klog.V(5).Infof("  Skipping non-CSI volume: %s (does not match %v)", vol.Slot, slotNaming.Prefixes())
+			continue
+		}
+
+		// Volumes under the extra slot prefix are created outside Kubernetes and
+		// never have a PV, so they are never orphan candidates
+		if !slotNaming.IsPVCSlot(vol.Slot) {
+			klog.V(5).Infof("  Skipping non-PVC volume: %s (slot prefix %s)", vol.Slot, slotNaming.ExtraPrefix())
+			continue
+		}
+
+		// Volumes of another driver instance sharing this RDS have no PV in our cluster;
+		// they are never ours to delete
+		if !slotNaming.IsOwnedVolumeSlot(vol.Slot) {
+			klog.V(5).Infof("  Skipping volume of another driver instance: %s (volume name prefix %q)", vol.Slot, slotNaming.VolumeNamePrefix())
 			continue
 		}
 
@@ -233,6 +281,12 @@ func (r *OrphanReconciler) reconcileOrphanedDisks(rdsVolumes []rds.VolumeInfo, a
 			continue
 		}
 
+		// A slot renamed on RDS is still in use if a PV records its backing file
+		if vol.FilePath != "" && activeFilePaths[vol.FilePath] {
+			klog.V(4).Infof("  Volume %s: backing file %s HAS active PV (renamed slot) - keeping", vol.Slot, vol.FilePath)
+			continue
+		}
+
 		klog.V(4).Infof("  Volume %s: NO active PV - marking as orphan candidate", vol.Slot)
 
 		// Volume appears to be orphaned
@@ -311,6 +365,11 @@ func (r *OrphanReconciler) reconcileOrphanedFiles(rdsVolumes []rds.VolumeInfo, a
 			continue
 		}
 
+		// Retained files are handled by the trash sweeper, never as orphans
+		if isTrashPath(file.Path) {
+			continue
+		}
+
 		// Skip if this file has a corresponding disk object
 		if diskFilePaths[file.Path] {
 			klog.V(5).Infof("File %s has disk object", file.Path)
@@ -320,6 +379,13 @@ func (r *OrphanReconciler) reconcileOrphanedFiles(rdsVolumes []rds.VolumeInfo, a
 		// Extract volume ID from file name (e.g., "pvc-xxx.img" -> "pvc-xxx")
 		volumeID := strings.TrimSuffix(file.Name, ".img")
 
+		// Only this driver instance's PVC volume files can be orphans; anything else,
+		// including another cluster's pvc-*.img in a shared base path, is not ours to delete
+		if !utils.GetSlotNamingStrategy().IsOwnedVolumeSlot(volumeID) {
+			klog.V(5).Infof("File %s is not a volume file of this driver instance - skipping", file.Path)
+			continue
+		}
+
 		// Skip if this file is referenced by an active PV
 		if activeVolumeIDs[volumeID] {
 			klog.V(5).Infof("File %s is referenced by active PV %s (missing disk object)", file.Path, volumeID)
@@ -367,6 +433,68 @@ func (r *OrphanReconciler) reconcileOrphanedFiles(rdsVolumes []rds.VolumeInfo, a
 	return orphans, nil
 }
 
+// purgeExpiredTrash permanently deletes files in BasePath/.trash older than TrashRetention.
+// Age comes from the timestamp DeleteVolume put in the file name, falling back to the
+// file's creation time.
+func (r *OrphanReconciler) purgeExpiredTrash() ([]TrashedFile, error) {
+	trashDir := path.Join(r.config.BasePath, rds.TrashDirName)
+	klog.V(4).Infof("Checking for expired files in %s (retention=%v)", trashDir, r.config.TrashRetention)
+
+	files, err := r.config.RDSClient.ListFiles(trashDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash files: %w", err)
+	}
+
+	expired := []TrashedFile{}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name, ".img") || path.Dir(file.Path) != trashDir {
+			continue
+		}
+
+		trashedAt, ok := rds.ParseTrashedAt(file.Name)
+		if !ok {
+			trashedAt = file.CreatedAt
+		}
+		if trashedAt.IsZero() {
+			klog.V(4).Infof("Trashed file %s has no timestamp, skipping", file.Path)
+			continue
+		}
+
+		age := time.Since(trashedAt)
+		if age < r.config.TrashRetention {
+			klog.V(5).Infof("Trashed file %s is within retention (age=%v)", file.Path, age)
+			continue
+		}
+
+		expired = append(expired, TrashedFile{
+			FilePath:  file.Path,
+			SizeBytes: file.SizeBytes,
+			TrashedAt: trashedAt,
+		})
+	}
+
+	for _, file := range expired {
+		if r.config.DryRun {
+			klog.Infof("[DRY-RUN] Would purge trashed file: %s (trashed=%v)", file.FilePath, file.TrashedAt)
+			continue
+		}
+
+		if err := r.config.RDSClient.DeleteFile(file.FilePath); err != nil {
+			klog.Errorf("Failed to purge trashed file %s: %v", file.FilePath, err)
+			continue
+		}
+
+		klog.Infof("Purged trashed file %s (trashed=%v, size=%d bytes)", file.FilePath, file.TrashedAt, file.SizeBytes)
+	}
+
+	return expired, nil
+}
+
+// isTrashPath reports whether filePath is inside a trash directory
+func isTrashPath(filePath string) bool {
+	return strings.Contains(filePath, "/"+rds.TrashDirName+"/")
+}
+
 // deleteOrphanedVolume deletes an orphaned volume from RDS
 func (r *OrphanReconciler) deleteOrphanedVolume(orphan OrphanedVolume) error {
 	klog.V(2).Infof("Deleting orphaned volume: %s", orphan.VolumeID)
@@ -386,6 +514,15 @@ func (r *OrphanReconciler) TriggerReconciliation(ctx context.Context) error {
 	return r.reconcile(ctx)
 }
 
+// pvBackingFile returns the backing file recorded in a PV's volume attributes: filePath,
+// or volumePath, which holds the same path on PVs created before filePath existed
+func pvBackingFile(pv *v1.PersistentVolume) string {
+	if filePath := pv.Spec.CSI.VolumeAttributes["filePath"]; filePath != "" {
+		return filePath
+	}
+	return pv.Spec.CSI.VolumeAttributes["volumePath"]
+}
+
 // Helper functions for safe access to ObjectReference fields
 func getNamespace(ref *v1.ObjectReference) string {
 	if ref == nil {
@@ -2,6 +2,9 @@ package reconciler
 
 import (
 	"context"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
 
 // mockRDSClient implements rds.RDSClient for testing
@@ -29,10 +33,24 @@ func (m *mockRDSClient) DeleteVolume(slot string) error {
 	return nil
 }
 
+func (m *mockRDSClient) DeleteVolumes(slots []string) map[string]error {
+	m.deletedVolumes = append(m.deletedVolumes, slots...)
+	return make(map[string]error)
+}
+
+func (m *mockRDSClient) TrashVolume(slot string) (string, error) {
+	m.deletedVolumes = append(m.deletedVolumes, slot)
+	return "", nil
+}
+
 func (m *mockRDSClient) ResizeVolume(slot string, newSizeBytes int64) error {
 	return nil
 }
 
+func (m *mockRDSClient) SetDiskComment(slot, comment string) error {
+	return nil
+}
+
 func (m *mockRDSClient) GetVolume(slot string) (*rds.VolumeInfo, error) {
 	for _, vol := range m.volumes {
 		if vol.Slot == slot {
@@ -60,7 +78,13 @@ func (m *mockRDSClient) ListVolumes() ([]rds.VolumeInfo, error) {
 }
 
 func (m *mockRDSClient) ListFiles(path string) ([]rds.FileInfo, error) {
-	return m.files, nil
+	var files []rds.FileInfo
+	for _, f := range m.files {
+		if strings.HasPrefix(f.Path, path) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
 }
 
 func (m *mockRDSClient) DeleteFile(path string) error {
@@ -68,6 +92,10 @@ func (m *mockRDSClient) DeleteFile(path string) error {
 	return nil
 }
 
+func (m *mockRDSClient) FindUnreferencedFiles(basePath string) ([]rds.FileInfo, error) {
+	return rds.UnreferencedFiles(basePath, m.files, m.volumes), nil
+}
+
 func (m *mockRDSClient) Connect() error {
 	return nil
 }
@@ -96,10 +124,22 @@ func (m *mockRDSClient) GetSnapshot(snapshotID string) (*rds.SnapshotInfo, error
 	return nil, nil
 }
 
+func (m *mockRDSClient) GetVolumeByFilePath(filePath string) (*rds.VolumeInfo, error) {
+	return nil, nil
+}
+
 func (m *mockRDSClient) ListSnapshots() ([]rds.SnapshotInfo, error) {
 	return nil, nil
 }
 
+func (m *mockRDSClient) ListSnapshotsBySource(sourceVolume string) ([]rds.SnapshotInfo, error) {
+	return nil, nil
+}
+
+func (m *mockRDSClient) VerifySnapshot(snapshotID string, sizeBytes int64) error {
+	return nil
+}
+
 func (m *mockRDSClient) RestoreSnapshot(snapshotID string, newVolumeOpts rds.CreateVolumeOptions) error {
 	return nil
 }
@@ -112,6 +152,14 @@ func (m *mockRDSClient) GetHardwareHealth(snmpHost string, snmpCommunity string)
 	return nil, nil
 }
 
+func (m *mockRDSClient) GetNVMeSessions() (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockRDSClient) GetSystemInfo() (*rds.SystemInfo, error) {
+	return nil, nil
+}
+
 func TestNewOrphanReconciler(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -440,3 +488,222 @@ func TestOrphanReconciler_OrphanedFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestOrphanReconciler_MixedSlotPrefixes(t *testing.T) {
+	if err := utils.SetSlotPrefix("infra-"); err != nil {
+		t.Fatalf("SetSlotPrefix() failed: %v", err)
+	}
+	t.Cleanup(utils.ResetSlotNaming)
+
+	basePath := "/storage-pool/metal-csi"
+	mockRDS := &mockRDSClient{
+		volumes: []rds.VolumeInfo{
+			{Slot: "pvc-123", FilePath: basePath + "/pvc-123.img", FileSizeBytes: 10737418240},
+			{Slot: "pvc-orphan", FilePath: basePath + "/pvc-orphan.img", FileSizeBytes: 10737418240},
+			{Slot: "infra-backup", FilePath: basePath + "/infra-backup.img", FileSizeBytes: 10737418240},
+			{Slot: "manual-volume", FilePath: basePath + "/manual-volume.img", FileSizeBytes: 10737418240},
+		},
+		files: []rds.FileInfo{
+			{Name: "pvc-123.img", Path: basePath + "/pvc-123.img", SizeBytes: 10737418240, Type: "file"},
+			{Name: "pvc-stray.img", Path: basePath + "/pvc-stray.img", SizeBytes: 10737418240, Type: "file"},
+			{Name: "infra-stray.img", Path: basePath + "/infra-stray.img", SizeBytes: 10737418240, Type: "file"},
+			{Name: "manual-stray.img", Path: basePath + "/manual-stray.img", SizeBytes: 10737418240, Type: "file"},
+		},
+		deletedVolumes: []string{},
+	}
+
+	k8sClient := fake.NewSimpleClientset()
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-123"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       "rds.csi.srvlab.io",
+					VolumeHandle: "pvc-123",
+				},
+			},
+		},
+	}
+	if _, err := k8sClient.CoreV1().PersistentVolumes().Create(context.Background(), pv, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create test PV: %v", err)
+	}
+
+	reconciler, err := NewOrphanReconciler(OrphanReconcilerConfig{
+		RDSClient:     mockRDS,
+		K8sClient:     k8sClient,
+		CheckInterval: 1 * time.Hour,
+		GracePeriod:   1 * time.Second,
+		DryRun:        false,
+		Enabled:       true,
+		BasePath:      basePath,
+	})
+	if err != nil {
+		t.Fatalf("NewOrphanReconciler() failed: %v", err)
+	}
+
+	if err := reconciler.reconcile(context.Background()); err != nil {
+		t.Fatalf("reconcile() failed: %v", err)
+	}
+
+	// Only the PVC volume without a PV is an orphan; infra- volumes have no PV by
+	// design and unprefixed volumes are not ours
+	if len(mockRDS.deletedVolumes) != 1 || mockRDS.deletedVolumes[0] != "pvc-orphan" {
+		t.Errorf("Expected only pvc-orphan to be deleted, got: %v", mockRDS.deletedVolumes)
+	}
+	if len(mockRDS.deletedFiles) != 1 || mockRDS.deletedFiles[0] != basePath+"/pvc-stray.img" {
+		t.Errorf("Expected only pvc-stray.img to be deleted, got: %v", mockRDS.deletedFiles)
+	}
+}
+
+func TestOrphanReconciler_ForeignVolumeNamePrefix(t *testing.T) {
+	const (
+		uuidA = "a1b2c3d4-e5f6-7890-abcd-ef1234567890"
+		uuidB = "b1b2c3d4-e5f6-7890-abcd-ef1234567890"
+		uuidC = "c1b2c3d4-e5f6-7890-abcd-ef1234567890"
+	)
+	basePath := "/storage-pool/shared"
+
+	tests := []struct {
+		name        string
+		prefix      string
+		wantVolumes []string
+		wantFiles   []string
+	}{
+		{
+			// Without a prefix, volumes of prefixed instances are still never candidates
+			name:        "no prefix",
+			prefix:      "",
+			wantVolumes: []string{"pvc-" + uuidA},
+			wantFiles:   []string{basePath + "/pvc-" + uuidB + ".img"},
+		},
+		{
+			// With a prefix, only our own prefixed volumes are candidates; unprefixed
+			// volumes may belong to any instance
+			name:        "own prefix",
+			prefix:      "alpha",
+			wantVolumes: []string{"pvc-alpha-" + uuidA},
+			wantFiles:   []string{basePath + "/pvc-alpha-" + uuidB + ".img"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := utils.SetVolumeNamePrefix(tt.prefix); err != nil {
+				t.Fatalf("SetVolumeNamePrefix() failed: %v", err)
+			}
+			t.Cleanup(utils.ResetSlotNaming)
+
+			var volumes []rds.VolumeInfo
+			var files []rds.FileInfo
+			for _, slot := range []string{"pvc-" + uuidA, "pvc-alpha-" + uuidA, "pvc-beta-" + uuidA} {
+				volumes = append(volumes, rds.VolumeInfo{Slot: slot, FilePath: basePath + "/" + slot + ".img"})
+			}
+			for _, slot := range []string{"pvc-" + uuidB, "pvc-alpha-" + uuidB, "pvc-beta-" + uuidB, "pvc-beta-" + uuidC} {
+				files = append(files, rds.FileInfo{Name: slot + ".img", Path: basePath + "/" + slot + ".img", Type: "file"})
+			}
+			mockRDS := &mockRDSClient{volumes: volumes, files: files, deletedVolumes: []string{}}
+
+			reconciler, err := NewOrphanReconciler(OrphanReconcilerConfig{
+				RDSClient:   mockRDS,
+				K8sClient:   fake.NewSimpleClientset(),
+				GracePeriod: 1 * time.Second,
+				Enabled:     true,
+				BasePath:    basePath,
+			})
+			if err != nil {
+				t.Fatalf("NewOrphanReconciler() failed: %v", err)
+			}
+			if err := reconciler.reconcile(context.Background()); err != nil {
+				t.Fatalf("reconcile() failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(mockRDS.deletedVolumes, tt.wantVolumes) {
+				t.Errorf("deleted volumes = %v, want %v", mockRDS.deletedVolumes, tt.wantVolumes)
+			}
+			if !reflect.DeepEqual(mockRDS.deletedFiles, tt.wantFiles) {
+				t.Errorf("deleted files = %v, want %v", mockRDS.deletedFiles, tt.wantFiles)
+			}
+			for _, deleted := range append(mockRDS.deletedVolumes, mockRDS.deletedFiles...) {
+				if strings.Contains(deleted, "pvc-beta-") {
+					t.Errorf("volume of another instance was deleted: %s", deleted)
+				}
+			}
+		})
+	}
+}
+
+func TestOrphanReconciler_PurgeExpiredTrash(t *testing.T) {
+	now := time.Now()
+	basePath := "/storage-pool/metal-csi"
+	oldTrash := rds.TrashFilePath(basePath+"/pvc-old.img", "pvc-old", now.Add(-8*24*time.Hour))
+	newTrash := rds.TrashFilePath(basePath+"/pvc-new.img", "pvc-new", now.Add(-1*time.Hour))
+
+	mockRDS := &mockRDSClient{
+		files: []rds.FileInfo{
+			{Name: path.Base(oldTrash), Path: oldTrash, SizeBytes: 1 << 30, Type: "file"},
+			{Name: path.Base(newTrash), Path: newTrash, SizeBytes: 1 << 30, Type: "file"},
+			// Untimestamped file falls back to creation time
+			{Name: "manual.img", Path: basePath + "/.trash/manual.img", Type: "file", CreatedAt: now.Add(-30 * 24 * time.Hour)},
+		},
+	}
+
+	reconciler, err := NewOrphanReconciler(OrphanReconcilerConfig{
+		RDSClient:      mockRDS,
+		K8sClient:      fake.NewSimpleClientset(),
+		Enabled:        true,
+		BasePath:       basePath,
+		TrashRetention: 7 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewOrphanReconciler() failed: %v", err)
+	}
+
+	if err := reconciler.reconcile(context.Background()); err != nil {
+		t.Fatalf("reconcile() failed: %v", err)
+	}
+
+	// Trashed pvc- files must only be purged by age, never as orphaned files
+	want := map[string]bool{oldTrash: true, basePath + "/.trash/manual.img": true}
+	if len(mockRDS.deletedFiles) != len(want) {
+		t.Fatalf("expected %d purged files, got %v", len(want), mockRDS.deletedFiles)
+	}
+	for _, f := range mockRDS.deletedFiles {
+		if !want[f] {
+			t.Errorf("unexpected file deleted: %s", f)
+		}
+	}
+}
+
+func TestOrphanReconciler_TrashDryRun(t *testing.T) {
+	basePath := "/storage-pool/metal-csi"
+	oldTrash := rds.TrashFilePath(basePath+"/pvc-old.img", "pvc-old", time.Now().Add(-8*24*time.Hour))
+
+	mockRDS := &mockRDSClient{
+		files: []rds.FileInfo{
+			{Name: path.Base(oldTrash), Path: oldTrash, Type: "file"},
+		},
+	}
+
+	reconciler, err := NewOrphanReconciler(OrphanReconcilerConfig{
+		RDSClient:      mockRDS,
+		K8sClient:      fake.NewSimpleClientset(),
+		Enabled:        true,
+		DryRun:         true,
+		BasePath:       basePath,
+		TrashRetention: 7 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewOrphanReconciler() failed: %v", err)
+	}
+
+	expired, err := reconciler.purgeExpiredTrash()
+	if err != nil {
+		t.Fatalf("purgeExpiredTrash() failed: %v", err)
+	}
+	if len(expired) != 1 {
+		t.Errorf("expected 1 expired file, got %d", len(expired))
+	}
+	if len(mockRDS.deletedFiles) != 0 {
+		t.Errorf("dry-run must not delete, got %v", mockRDS.deletedFiles)
+	}
+}
@@ -145,6 +145,7 @@ type SecurityEvent struct {
 	NQN        string `json:"nqn,omitempty"`
 
 	// Operation details
+	RequestID string            `json:"request_id,omitempty"`
 	Operation string            `json:"operation,omitempty"`
 	Duration  time.Duration     `json:"duration_ms,omitempty"`
 	Error     string            `json:"error,omitempty"`
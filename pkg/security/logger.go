@@ -11,7 +11,8 @@ import (
 
 // Logger provides centralized security event logging
 type Logger struct {
-	metrics *SecurityMetrics
+	metrics   *SecurityMetrics
+	requestID string // Set on every event logged, for loggers scoped to one CSI request
 }
 
 // globalLogger is the global security logger instance
@@ -37,6 +38,15 @@ func NewLogger() *Logger {
 	}
 }
 
+// WithRequestID returns a logger that tags the events it logs with the ID of the CSI
+// request they belong to (x-csi-request-id)
+func (l *Logger) WithRequestID(requestID string) *Logger {
+	return &Logger{
+		metrics:   l.metrics,
+		requestID: requestID,
+	}
+}
+
 // severityMapping defines how a severity level maps to klog behavior
 type severityMapping struct {
 	verbosity klog.Level
@@ -54,6 +64,10 @@ var severityMap = map[EventSeverity]severityMapping{
 
 // LogEvent logs a security event with structured logging
 func (l *Logger) LogEvent(event *SecurityEvent) {
+	if event.RequestID == "" {
+		event.RequestID = l.requestID
+	}
+
 	// Record in metrics
 	l.metrics.RecordEvent(event)
 
@@ -124,6 +138,9 @@ func (l *Logger) formatLogMessage(event *SecurityEvent) string {
 	}
 
 	// Add operation details
+	if event.RequestID != "" {
+		msg += fmt.Sprintf(" request_id=%s", event.RequestID)
+	}
 	if event.Operation != "" {
 		msg += fmt.Sprintf(" operation=%s", event.Operation)
 	}
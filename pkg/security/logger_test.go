@@ -691,3 +691,23 @@ func TestLogEventSeverityMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestLogger_WithRequestID(t *testing.T) {
+	logger := NewLogger().WithRequestID("req-123")
+	event := NewSecurityEvent(EventVolumeStageRequest, CategoryVolumeOperation, SeverityInfo, "Volume staging requested")
+
+	logger.LogEvent(event)
+	if event.RequestID != "req-123" {
+		t.Errorf("RequestID = %q, want req-123", event.RequestID)
+	}
+	if msg := logger.formatLogMessage(event); !strings.Contains(msg, " request_id=req-123") {
+		t.Errorf("request ID missing from %s", msg)
+	}
+
+	// Loggers without a request leave it out
+	plain := NewSecurityEvent(EventVolumeStageRequest, CategoryVolumeOperation, SeverityInfo, "Volume staging requested")
+	NewLogger().LogEvent(plain)
+	if msg := NewLogger().formatLogMessage(plain); strings.Contains(msg, "request_id=") {
+		t.Errorf("unexpected request ID in %s", msg)
+	}
+}
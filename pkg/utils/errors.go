@@ -34,6 +34,9 @@ var (
 	// ErrConnectionFailed indicates a connection failure (SSH, API, etc.)
 	ErrConnectionFailed = errors.New("connection failed")
 
+	// ErrAuthenticationFailed indicates the server rejected our credentials (SSH key, user)
+	ErrAuthenticationFailed = errors.New("authentication failed")
+
 	// ErrDeviceNotFound indicates NVMe device was not found
 	ErrDeviceNotFound = errors.New("device not found")
 
@@ -45,6 +48,9 @@ var (
 
 	// ErrUnmountFailed indicates an unmount operation failed
 	ErrUnmountFailed = errors.New("unmount failed")
+
+	// ErrPathNotAllowed indicates a file path outside the allowed volume base paths
+	ErrPathNotAllowed = errors.New("file path not in allowed base paths")
 )
 
 // ErrorType classifies errors for sanitization purposes
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Default limits for metadata the driver stores on Kubernetes objects, in bytes. The API
+// server rejects objects whose annotations total more than 256 KiB, and other controllers
+// add their own, so the hard limit stays well below that.
+const (
+	DefaultMetadataSoftLimit = 32 * 1024
+	DefaultMetadataHardLimit = 128 * 1024
+)
+
+// ErrMetadataTooLarge indicates metadata is still above the hard limit after trimming
+var ErrMetadataTooLarge = errors.New("metadata too large")
+
+// MetadataLimits bounds the size of a string map the driver stores on a Kubernetes
+// object, such as a volume context or the annotations of a PV. Zero values use the
+// defaults.
+type MetadataLimits struct {
+	Soft int // size above which a warning is logged
+	Hard int // size above which optional keys are dropped, and then the write refused
+}
+
+// SoftLimit returns the soft limit, or DefaultMetadataSoftLimit if unset
+func (l MetadataLimits) SoftLimit() int {
+	if l.Soft > 0 {
+		return l.Soft
+	}
+	return DefaultMetadataSoftLimit
+}
+
+// HardLimit returns the hard limit, or DefaultMetadataHardLimit if unset
+func (l MetadataLimits) HardLimit() int {
+	if l.Hard > 0 {
+		return l.Hard
+	}
+	return DefaultMetadataHardLimit
+}
+
+// Validate checks the limits are not negative and the soft limit is not above the hard limit
+func (l MetadataLimits) Validate() error {
+	if l.Soft < 0 || l.Hard < 0 {
+		return fmt.Errorf("%w: metadata size limits must not be negative", ErrInvalidParameter)
+	}
+	if l.SoftLimit() > l.HardLimit() {
+		return fmt.Errorf("%w: metadata soft limit %d exceeds hard limit %d", ErrInvalidParameter, l.SoftLimit(), l.HardLimit())
+	}
+	return nil
+}
+
+// MetadataSize returns the size of m as the API server counts annotations: the total
+// length of its keys and values
+func MetadataSize(m map[string]string) int {
+	size := 0
+	for k, v := range m {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// FitMetadata deletes the optional keys of m, in order, until m is within the hard limit.
+// It returns the keys it deleted and the final size. If m is still above the hard limit
+// once every optional key is gone, the error wraps ErrMetadataTooLarge.
+func FitMetadata(m map[string]string, limits MetadataLimits, optional []string) (trimmed []string, size int, err error) {
+	hard := limits.HardLimit()
+	size = MetadataSize(m)
+	for _, key := range optional {
+		if size <= hard {
+			break
+		}
+		value, ok := m[key]
+		if !ok {
+			continue
+		}
+		delete(m, key)
+		size -= len(key) + len(value)
+		trimmed = append(trimmed, key)
+	}
+	if size > hard {
+		return trimmed, size, fmt.Errorf("%w: %d bytes exceeds the hard limit of %d bytes", ErrMetadataTooLarge, size, hard)
+	}
+	return trimmed, size, nil
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFitMetadata(t *testing.T) {
+	// base is 20 bytes and each optional key adds 10
+	base := func() map[string]string {
+		return map[string]string{
+			"nqn":     "0123456789abcdefg", // 20 bytes
+			"comment": "xxx",               // 10 bytes
+			"hint":    "xxxxxx",            // 10 bytes
+			"wwid":    "xxxxxx",            // 10 bytes
+		}
+	}
+	optional := []string{"comment", "missing", "hint", "wwid"}
+
+	tests := []struct {
+		name        string
+		hard        int
+		wantTrimmed []string
+		wantSize    int
+		wantErr     bool
+	}{
+		{name: "within limit", hard: 50, wantSize: 50},
+		{name: "comment dropped first", hard: 45, wantTrimmed: []string{"comment"}, wantSize: 40},
+		{name: "hints dropped in order", hard: 25, wantTrimmed: []string{"comment", "hint", "wwid"}, wantSize: 20},
+		{name: "required keys over the hard limit", hard: 15, wantTrimmed: []string{"comment", "hint", "wwid"}, wantSize: 20, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := base()
+			trimmed, size, err := FitMetadata(m, MetadataLimits{Soft: 1, Hard: tt.hard}, optional)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FitMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrMetadataTooLarge) {
+				t.Errorf("expected ErrMetadataTooLarge, got %v", err)
+			}
+			if !reflect.DeepEqual(trimmed, tt.wantTrimmed) {
+				t.Errorf("trimmed = %v, want %v", trimmed, tt.wantTrimmed)
+			}
+			if size != tt.wantSize || MetadataSize(m) != tt.wantSize {
+				t.Errorf("size = %d (map %d), want %d", size, MetadataSize(m), tt.wantSize)
+			}
+			for _, key := range tt.wantTrimmed {
+				if _, ok := m[key]; ok {
+					t.Errorf("expected %s to be deleted", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMetadataLimits(t *testing.T) {
+	var limits MetadataLimits
+	if limits.SoftLimit() != DefaultMetadataSoftLimit || limits.HardLimit() != DefaultMetadataHardLimit {
+		t.Errorf("expected default limits, got soft=%d hard=%d", limits.SoftLimit(), limits.HardLimit())
+	}
+	if err := limits.Validate(); err != nil {
+		t.Errorf("default limits invalid: %v", err)
+	}
+
+	err := MetadataLimits{Soft: 2048, Hard: 1024}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "exceeds hard limit") {
+		t.Errorf("expected soft above hard to be rejected, got %v", err)
+	}
+	if err := (MetadataLimits{Hard: -1}).Validate(); err == nil {
+		t.Error("expected negative limit to be rejected")
+	}
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// slotPrefixPattern matches operator-supplied slot prefixes: lowercase (NQNs are
+// lowercase), starting with a letter and ending with a hyphen so that the prefix
+// cannot swallow unrelated slot names (e.g. "s" matching every "scratch" disk).
+var slotPrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,30}-$`)
+
+// volumeNamePrefixPattern matches --volume-name-prefix values. Hyphens are not allowed so the
+// prefix can always be told apart from the UUID in "pvc-<prefix>-<uuid>".
+var volumeNamePrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9]{0,15}$`)
+
+// uuidSuffixPattern matches a Kubernetes volume name, capturing its UUID
+var uuidSuffixPattern = regexp.MustCompile(`^pvc-([a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})$`)
+
+// prefixedVolumeIDPattern matches a volume ID with an embedded volume name prefix, capturing the prefix
+var prefixedVolumeIDPattern = regexp.MustCompile(`^pvc-([a-z][a-z0-9]{0,15})-[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)
+
+// SlotNamingStrategy decides which RDS disk slot names belong to this driver.
+// Kubernetes volumes always use the "pvc-<uuid>" form. An operator may accept one
+// additional prefix (--slot-prefix) for volumes created outside Kubernetes.
+// Slots matching neither are never listed, validated as ours, or deleted.
+//
+// When several clusters share an RDS base path, each driver instance can embed its own
+// volume name prefix (--volume-name-prefix) in the volumes it creates ("pvc-<prefix>-<uuid>"),
+// and only volumes carrying that prefix are ever treated as orphans.
+type SlotNamingStrategy struct {
+	extraPrefix      string
+	volumeNamePrefix string
+}
+
+// slotNaming is the strategy consulted by volume ID validation and slot filtering.
+// It defaults to PVC-only and is replaced via SetSlotPrefix() during driver startup.
+var slotNaming = &SlotNamingStrategy{}
+
+// NewSlotNamingStrategy creates a strategy accepting "pvc-" plus an optional extra prefix
+func NewSlotNamingStrategy(extraPrefix string) (*SlotNamingStrategy, error) {
+	if extraPrefix == "" {
+		return &SlotNamingStrategy{}, nil
+	}
+	if !slotPrefixPattern.MatchString(extraPrefix) {
+		return nil, fmt.Errorf("invalid slot prefix %q (lowercase alphanumeric and hyphen, must start with a letter and end with '-', max 32 characters)", extraPrefix)
+	}
+	if strings.HasPrefix(extraPrefix, VolumeIDPrefix) {
+		return nil, fmt.Errorf("invalid slot prefix %q (must not overlap the %q prefix reserved for Kubernetes volumes)", extraPrefix, VolumeIDPrefix)
+	}
+	return &SlotNamingStrategy{extraPrefix: extraPrefix}, nil
+}
+
+// SetSlotPrefix installs a strategy accepting the given extra slot prefix.
+// This should be called during driver initialization.
+func SetSlotPrefix(prefix string) error {
+	strategy, err := NewSlotNamingStrategy(prefix)
+	if err != nil {
+		return err
+	}
+	strategy.volumeNamePrefix = slotNaming.volumeNamePrefix
+	slotNaming = strategy
+	return nil
+}
+
+// SetVolumeNamePrefix installs the prefix embedded in the slot names of new volumes.
+// This should be called during driver initialization.
+func SetVolumeNamePrefix(prefix string) error {
+	if prefix != "" && !volumeNamePrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("invalid volume name prefix %q (lowercase alphanumeric, must start with a letter, max 16 characters)", prefix)
+	}
+	strategy := *slotNaming
+	strategy.volumeNamePrefix = prefix
+	slotNaming = &strategy
+	return nil
+}
+
+// ResetSlotNaming restores the default PVC-only strategy without a volume name prefix.
+// This is primarily for testing to ensure test isolation.
+func ResetSlotNaming() {
+	slotNaming = &SlotNamingStrategy{}
+}
+
+// GetSlotNamingStrategy returns the active slot naming strategy
+func GetSlotNamingStrategy() *SlotNamingStrategy {
+	return slotNaming
+}
+
+// Prefixes returns all accepted slot prefixes, "pvc-" first
+func (s *SlotNamingStrategy) Prefixes() []string {
+	if s.extraPrefix == "" {
+		return []string{VolumeIDPrefix}
+	}
+	return []string{VolumeIDPrefix, s.extraPrefix}
+}
+
+// ExtraPrefix returns the operator-configured prefix, or "" if none
+func (s *SlotNamingStrategy) ExtraPrefix() string {
+	return s.extraPrefix
+}
+
+// VolumeNamePrefix returns the prefix embedded in new volume slot names, or "" if none
+func (s *SlotNamingStrategy) VolumeNamePrefix() string {
+	return s.volumeNamePrefix
+}
+
+// VolumeIDForName returns the slot name for a volume requested as name. A Kubernetes
+// volume name "pvc-<uuid>" becomes "pvc-<prefix>-<uuid>" when a volume name prefix is
+// configured; any other name is used unchanged.
+func (s *SlotNamingStrategy) VolumeIDForName(name string) string {
+	if s.volumeNamePrefix == "" {
+		return name
+	}
+	match := uuidSuffixPattern.FindStringSubmatch(name)
+	if match == nil {
+		return name
+	}
+	return VolumeIDPrefix + s.volumeNamePrefix + "-" + match[1]
+}
+
+// IsOwnedVolumeSlot reports whether slot is a Kubernetes volume created by this driver
+// instance, and so may be considered an orphan when it has no PersistentVolume.
+// With a volume name prefix only "pvc-<prefix>-<uuid>" slots qualify. Without one, any
+// "pvc-" slot qualifies except those carrying another instance's prefix.
+func (s *SlotNamingStrategy) IsOwnedVolumeSlot(slot string) bool {
+	if !s.IsPVCSlot(slot) {
+		return false
+	}
+	match := prefixedVolumeIDPattern.FindStringSubmatch(slot)
+	if s.volumeNamePrefix == "" {
+		return match == nil
+	}
+	return match != nil && match[1] == s.volumeNamePrefix
+}
+
+// IsPVCSlot reports whether slot uses the Kubernetes "pvc-" prefix.
+// Only these slots are expected to have a PersistentVolume.
+func (s *SlotNamingStrategy) IsPVCSlot(slot string) bool {
+	return strings.HasPrefix(slot, VolumeIDPrefix)
+}
+
+// IsManagedSlot reports whether slot matches any accepted prefix
+func (s *SlotNamingStrategy) IsManagedSlot(slot string) bool {
+	if s.IsPVCSlot(slot) {
+		return true
+	}
+	return s.hasExtraPrefix(slot)
+}
+
+// hasExtraPrefix reports whether slot is the extra prefix followed by a non-empty name
+func (s *SlotNamingStrategy) hasExtraPrefix(slot string) bool {
+	return s.extraPrefix != "" && len(slot) > len(s.extraPrefix) && strings.HasPrefix(slot, s.extraPrefix)
+}
+
+// IsManagedSlot reports whether slot matches any prefix accepted by the active strategy
+func IsManagedSlot(slot string) bool {
+	return slotNaming.IsManagedSlot(slot)
+}
+
+// VolumeIDForName returns the slot name for a volume requested as name under the active strategy
+func VolumeIDForName(name string) string {
+	return slotNaming.VolumeIDForName(name)
+}
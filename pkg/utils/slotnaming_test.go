@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewSlotNamingStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefix    string
+		expectErr bool
+	}{
+		{name: "empty prefix - pvc only", prefix: ""},
+		{name: "simple prefix", prefix: "infra-"},
+		{name: "prefix with digits", prefix: "lab2-vm-"},
+		{name: "missing trailing hyphen", prefix: "infra", expectErr: true},
+		{name: "uppercase", prefix: "Infra-", expectErr: true},
+		{name: "leading digit", prefix: "2infra-", expectErr: true},
+		{name: "special characters", prefix: "in;fra-", expectErr: true},
+		{name: "reserved pvc prefix", prefix: "pvc-", expectErr: true},
+		{name: "overlaps pvc prefix", prefix: "pvc-extra-", expectErr: true},
+		{name: "too long", prefix: "abcdefghijklmnopqrstuvwxyzabcdefg-", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSlotNamingStrategy(tt.prefix)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("NewSlotNamingStrategy(%q) error = %v, expectErr %v", tt.prefix, err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestSlotNamingStrategy_IsManagedSlot(t *testing.T) {
+	defaultStrategy, _ := NewSlotNamingStrategy("")
+	infraStrategy, _ := NewSlotNamingStrategy("infra-")
+
+	slots := []string{
+		"pvc-a1b2c3d4-e5f6-7890-abcd-ef1234567890",
+		"infra-backup",
+		"infra-",
+		"infrastructure",
+		"manual-volume",
+		"system-disk",
+	}
+
+	tests := []struct {
+		name     string
+		strategy *SlotNamingStrategy
+		want     []string
+	}{
+		{
+			name:     "default accepts only pvc",
+			strategy: defaultStrategy,
+			want:     []string{"pvc-a1b2c3d4-e5f6-7890-abcd-ef1234567890"},
+		},
+		{
+			name:     "extra prefix accepts pvc and prefixed names",
+			strategy: infraStrategy,
+			want:     []string{"pvc-a1b2c3d4-e5f6-7890-abcd-ef1234567890", "infra-backup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, slot := range slots {
+				if tt.strategy.IsManagedSlot(slot) {
+					got = append(got, slot)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("managed slots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if infraStrategy.IsPVCSlot("infra-backup") {
+		t.Error("infra-backup should not be treated as a PVC slot")
+	}
+	if got := infraStrategy.Prefixes(); !reflect.DeepEqual(got, []string{"pvc-", "infra-"}) {
+		t.Errorf("Prefixes() = %v", got)
+	}
+}
+
+func TestSetSlotPrefix_Validation(t *testing.T) {
+	if err := SetSlotPrefix("infra-"); err != nil {
+		t.Fatalf("SetSlotPrefix() failed: %v", err)
+	}
+	t.Cleanup(ResetSlotNaming)
+
+	if !IsManagedSlot("infra-backup") {
+		t.Error("expected infra-backup to be managed after SetSlotPrefix")
+	}
+
+	tests := []struct {
+		name      string
+		volumeID  string
+		expectErr bool
+	}{
+		{name: "pvc volume", volumeID: "pvc-a1b2c3d4-e5f6-7890-abcd-ef1234567890"},
+		{name: "prefixed volume", volumeID: "infra-backup"},
+		{name: "prefix without name", volumeID: "infra-", expectErr: true},
+		{name: "prefixed volume with special characters", volumeID: "infra-backup;ls", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVolumeID(tt.volumeID)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ValidateVolumeID(%q) error = %v, expectErr %v", tt.volumeID, err, tt.expectErr)
+			}
+		})
+	}
+
+	nqn, err := VolumeIDToNQN("infra-backup")
+	if err != nil {
+		t.Fatalf("VolumeIDToNQN() failed: %v", err)
+	}
+	if nqn != NQNPrefix+":infra-backup" {
+		t.Errorf("VolumeIDToNQN() = %s", nqn)
+	}
+
+	// A rejected prefix leaves the active strategy unchanged
+	if err := SetSlotPrefix("Bad"); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+	if GetSlotNamingStrategy().ExtraPrefix() != "infra-" {
+		t.Errorf("strategy changed after invalid SetSlotPrefix: %q", GetSlotNamingStrategy().ExtraPrefix())
+	}
+}
+
+func TestSlotNamingStrategy_VolumeNamePrefix(t *testing.T) {
+	t.Cleanup(ResetSlotNaming)
+
+	const uuid = "a1b2c3d4-e5f6-7890-abcd-ef1234567890"
+
+	for _, prefix := range []string{"Prod", "prod-", "1prod", "abcdefghijklmnopq", "pr;od"} {
+		if err := SetVolumeNamePrefix(prefix); err == nil {
+			t.Errorf("SetVolumeNamePrefix(%q) succeeded, want error", prefix)
+		}
+	}
+
+	// Without a prefix names are used unchanged and foreign-prefixed volumes are not ours
+	if got := VolumeIDForName("pvc-" + uuid); got != "pvc-"+uuid {
+		t.Errorf("VolumeIDForName() = %q, want unchanged name", got)
+	}
+	strategy := GetSlotNamingStrategy()
+	if !strategy.IsOwnedVolumeSlot("pvc-"+uuid) || strategy.IsOwnedVolumeSlot("pvc-prod-"+uuid) {
+		t.Error("without a prefix only unprefixed pvc volumes should be owned")
+	}
+
+	if err := SetVolumeNamePrefix("prod"); err != nil {
+		t.Fatalf("SetVolumeNamePrefix() failed: %v", err)
+	}
+	// The volume name prefix survives a later SetSlotPrefix
+	if err := SetSlotPrefix("infra-"); err != nil {
+		t.Fatalf("SetSlotPrefix() failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "kubernetes volume name", input: "pvc-" + uuid, want: "pvc-prod-" + uuid},
+		{name: "non-uuid name unchanged", input: "sanity-volume", want: "sanity-volume"},
+		{name: "already prefixed unchanged", input: "pvc-prod-" + uuid, want: "pvc-prod-" + uuid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VolumeIDForName(tt.input); got != tt.want {
+				t.Errorf("VolumeIDForName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	strategy = GetSlotNamingStrategy()
+	owned := map[string]bool{
+		"pvc-prod-" + uuid:    true,
+		"pvc-" + uuid:         false,
+		"pvc-staging-" + uuid: false,
+		"pvc-prodx-" + uuid:   false,
+		"pvc-prod-orphan":     false,
+		"infra-backup":        false,
+	}
+	for slot, want := range owned {
+		if got := strategy.IsOwnedVolumeSlot(slot); got != want {
+			t.Errorf("IsOwnedVolumeSlot(%q) = %v, want %v", slot, got, want)
+		}
+	}
+
+	if err := ValidateVolumeID("pvc-prod-" + uuid); err != nil {
+		t.Errorf("ValidateVolumeID() rejected prefixed volume ID: %v", err)
+	}
+}
@@ -43,6 +43,10 @@ var (
 // The sourceVolumeID parameter is accepted for backward compatibility but not used in
 // ID generation. Source volume verification is the caller's responsibility.
 //
+// The ID is always 55 characters: names of any length are hashed, so long VolumeSnapshot
+// names cannot push the slot name past RouterOS limits. The first 41 characters are the
+// legacy ID SnapshotNameToID(csiName), by which snapshots of older releases are found.
+//
 // Use this function in CreateSnapshot to generate stable, idempotent snapshot IDs.
 func GenerateSnapshotID(csiName string, sourceVolumeID string) string {
 	// Generate a deterministic UUID from the CSI snapshot name using UUID v5 (SHA1).
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSnapshotID(t *testing.T) {
+	source := "pvc-12345678-1234-1234-1234-123456789012"
+	names := []string{
+		"snapshot-1",
+		"",
+		"snapshot-" + strings.Repeat("x", 244), // 253 characters, the Kubernetes object name limit
+		"snapshot with spaces; and $(shell) characters",
+	}
+
+	for _, name := range names {
+		id := GenerateSnapshotID(name, source)
+		if len(id) != 55 {
+			t.Errorf("GenerateSnapshotID(%q) = %s, want 55 characters, got %d", name, id, len(id))
+		}
+		if err := ValidateSnapshotID(id); err != nil {
+			t.Errorf("GenerateSnapshotID(%q) = %s does not validate: %v", name, id, err)
+		}
+		if again := GenerateSnapshotID(name, source); again != id {
+			t.Errorf("GenerateSnapshotID(%q) not deterministic: %s then %s", name, id, again)
+		}
+		if other := GenerateSnapshotID(name, "pvc-87654321-4321-4321-4321-210987654321"); other != id {
+			t.Errorf("GenerateSnapshotID(%q) depends on the source volume: %s vs %s", name, id, other)
+		}
+		if legacy := SnapshotNameToID(name); !strings.HasPrefix(id, legacy+"-at-") {
+			t.Errorf("GenerateSnapshotID(%q) = %s does not extend the legacy ID %s", name, id, legacy)
+		}
+		if err := ValidateSnapshotID(SnapshotNameToID(name)); err != nil {
+			t.Errorf("legacy ID of %q does not validate: %v", name, err)
+		}
+	}
+}
+
+func TestGenerateSnapshotID_NoCollisions(t *testing.T) {
+	source := "pvc-12345678-1234-1234-1234-123456789012"
+	long := strings.Repeat("a", 250)
+	seen := make(map[string]string)
+	for i := 0; i < 10000; i++ {
+		// Long names differing only at the end must not collide once hashed
+		for _, name := range []string{fmt.Sprintf("snapshot-%d", i), fmt.Sprintf("%s-%d", long, i)} {
+			id := GenerateSnapshotID(name, source)
+			if previous, ok := seen[id]; ok {
+				t.Fatalf("names %q and %q both produce %s", previous, name, id)
+			}
+			seen[id] = name
+		}
+	}
+}
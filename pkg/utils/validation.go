@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -120,7 +121,7 @@ func ValidateFilePath(path string) error {
 	}
 
 	if !allowed {
-		return fmt.Errorf("file path not in allowed base paths: %s (allowed: %v)", cleanPath, AllowedBasePaths)
+		return fmt.Errorf("%w: %s (allowed: %v)", ErrPathNotAllowed, cleanPath, AllowedBasePaths)
 	}
 
 	// Additional check: ensure no double slashes (can sometimes bypass filters)
@@ -208,3 +209,37 @@ func ValidateCreateVolumeOptions(filePath string, sizeBytes int64, slot string)
 func IsPathSafe(path string) bool {
 	return ValidateFilePath(path) == nil
 }
+
+// MaxDiskCommentLength bounds the comment written on RDS disk entries
+const MaxDiskCommentLength = 128
+
+var (
+	// diskCommentUnsafeChars matches characters not allowed in RDS disk comments.
+	// Kubernetes object names only use [a-z0-9.-], so nothing useful is lost.
+	diskCommentUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._/-]`)
+
+	// diskCommentPattern matches comments that are safe to embed in a RouterOS command
+	diskCommentPattern = regexp.MustCompile(`^[a-zA-Z0-9._/-]*$`)
+)
+
+// SanitizeDiskComment makes a user-controlled string (e.g. a PVC name) safe to use as an
+// RDS disk comment. Disallowed characters are replaced with '_' and the result is
+// truncated to MaxDiskCommentLength.
+func SanitizeDiskComment(comment string) string {
+	comment = diskCommentUnsafeChars.ReplaceAllString(comment, "_")
+	if len(comment) > MaxDiskCommentLength {
+		comment = comment[:MaxDiskCommentLength]
+	}
+	return comment
+}
+
+// ValidateDiskComment rejects comments that could break out of a RouterOS comment= value
+func ValidateDiskComment(comment string) error {
+	if len(comment) > MaxDiskCommentLength {
+		return fmt.Errorf("disk comment too long: %d characters (max %d)", len(comment), MaxDiskCommentLength)
+	}
+	if !diskCommentPattern.MatchString(comment) {
+		return fmt.Errorf("invalid disk comment %q (only alphanumeric, '.', '_', '/' and '-' allowed)", comment)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -417,6 +418,44 @@ func TestIsPathSafe(t *testing.T) {
 	}
 }
 
+func TestSanitizeDiskComment(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "namespace and PVC name", input: "default/data-postgres-0", want: "default/data-postgres-0"},
+		{name: "quote breakout", input: `ns/pvc" slot=evil`, want: "ns/pvc__slot_evil"},
+		{name: "command separator", input: "ns/pvc; /system reboot", want: "ns/pvc__/system_reboot"},
+		{name: "newline", input: "ns/pvc\n/disk remove 0", want: "ns/pvc_/disk_remove_0"},
+		{name: "RouterOS variable expansion", input: "ns/$(x)[find]", want: "ns/__x__find_"},
+		{name: "truncated", input: strings.Repeat("a", 200), want: strings.Repeat("a", MaxDiskCommentLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeDiskComment(tt.input)
+			if got != tt.want {
+				t.Errorf("SanitizeDiskComment(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if err := ValidateDiskComment(got); err != nil {
+				t.Errorf("sanitized comment rejected: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateDiskComment(t *testing.T) {
+	for _, comment := range []string{`a"b`, "a b", "a;b", "a\\b", "a\nb", "a=b", strings.Repeat("a", MaxDiskCommentLength+1)} {
+		if err := ValidateDiskComment(comment); err == nil {
+			t.Errorf("ValidateDiskComment(%q) should fail", comment)
+		}
+	}
+	if err := ValidateDiskComment(""); err != nil {
+		t.Errorf("empty comment should be valid: %v", err)
+	}
+}
+
 // Benchmark tests
 func BenchmarkValidateFilePath(b *testing.B) {
 	ResetAllowedBasePaths()
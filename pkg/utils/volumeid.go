@@ -19,10 +19,10 @@ const (
 )
 
 var (
-	// volumeIDPattern matches strict UUID format with pvc- prefix
-	// Format: pvc-<lowercase-uuid>
-	// Example: pvc-12345678-1234-1234-1234-123456789abc
-	volumeIDPattern = regexp.MustCompile(`^pvc-[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)
+	// volumeIDPattern matches strict UUID format with pvc- prefix and an optional volume name prefix
+	// Format: pvc-<lowercase-uuid> or pvc-<prefix>-<lowercase-uuid>
+	// Example: pvc-12345678-1234-1234-1234-123456789abc, pvc-prod-12345678-1234-1234-1234-123456789abc
+	volumeIDPattern = regexp.MustCompile(`^pvc-(?:[a-z][a-z0-9]{0,15}-)?[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)
 
 	// safeSlotPattern matches safe slot names (alphanumeric and hyphen only)
 	safeSlotPattern = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
@@ -33,6 +33,10 @@ var (
 	// SECURITY: This strict pattern prevents command injection via NQN parameter
 	nqnPattern = regexp.MustCompile(`^nqn\.[0-9]{4}-[0-9]{2}\.[a-z0-9.-]+:[a-z0-9._-]+$`)
 
+	// wwidPattern matches NVMe namespace identifiers as the kernel reports them in
+	// /sys/class/block/<dev>/wwid: eui.<hex> (EUI-64 or NGUID), uuid.<uuid>, nvme.<hex-fields>
+	wwidPattern = regexp.MustCompile(`^(eui|uuid|nvme)\.[0-9a-fA-F-]{1,200}$`)
+
 	// Namespace UUID for generating deterministic volume IDs
 	volumeNamespace = uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8") // DNS namespace UUID
 )
@@ -56,7 +60,8 @@ func VolumeNameToID(name string) string {
 }
 
 // ValidateVolumeID validates that a volume ID is safe for use in commands
-// For production volume IDs: must match "pvc-<lowercase-uuid>" format
+// For production volume IDs: must match "pvc-<lowercase-uuid>" or "pvc-<prefix>-<lowercase-uuid>" format
+// For the configured slot prefix (see SetSlotPrefix): a name must follow the prefix
 // For CSI sanity tests: accepts alphanumeric with hyphens (safe pattern) but not UUID-like strings
 // SECURITY: Prevents command injection by restricting to safe characters only
 func ValidateVolumeID(volumeID string) error {
@@ -74,6 +79,13 @@ func ValidateVolumeID(volumeID string) error {
 		return fmt.Errorf("invalid volume ID format: %s (only alphanumeric and hyphen allowed)", volumeID)
 	}
 
+	// Accept the operator-configured slot prefix for non-PVC consumers
+	if prefix := slotNaming.ExtraPrefix(); prefix != "" && strings.HasPrefix(volumeID, prefix) {
+		if !slotNaming.hasExtraPrefix(volumeID) {
+			return fmt.Errorf("invalid volume ID format: %s (missing name after slot prefix %s)", volumeID, prefix)
+		}
+	}
+
 	// Reject if it starts with "pvc-" but doesn't match UUID format
 	// This catches malformed production IDs like "pvc-invalid" or "pvc-UPPERCASE"
 	if strings.HasPrefix(volumeID, VolumeIDPrefix) {
@@ -135,6 +147,17 @@ func ValidateNQN(nqn string) error {
 	return nil
 }
 
+// ValidateWWID validates an NVMe namespace identifier carried in VolumeContext
+func ValidateWWID(wwid string) error {
+	if wwid == "" {
+		return fmt.Errorf("WWID cannot be empty")
+	}
+	if !wwidPattern.MatchString(wwid) {
+		return fmt.Errorf("invalid WWID format: %s (expected eui.<hex>, uuid.<uuid>, or nvme.<id>)", wwid)
+	}
+	return nil
+}
+
 // VolumeIDToNQN converts a volume ID to an NVMe Qualified Name
 func VolumeIDToNQN(volumeID string) (string, error) {
 	// Validate volume ID is safe (prevents command injection)
@@ -203,6 +226,26 @@ func ValidateIPAddress(address string) error {
 	return nil
 }
 
+// DiscoveryScheme prefixes an NVMe address naming a DNS SRV record instead of an IP,
+// e.g. discovery://_nvme-tcp._tcp.storage.example.com
+const DiscoveryScheme = "discovery://"
+
+// discoveryNamePattern matches an SRV record name: _service._proto. followed by a domain
+var discoveryNamePattern = regexp.MustCompile(`^_[a-z0-9-]+\._(tcp|udp)\.([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.?$`)
+
+// ParseDiscoveryAddress returns the SRV record name of a discovery:// address. ok is false
+// for addresses without the scheme; err is set if the record name after it is invalid.
+func ParseDiscoveryAddress(address string) (name string, ok bool, err error) {
+	name, ok = strings.CutPrefix(address, DiscoveryScheme)
+	if !ok {
+		return "", false, nil
+	}
+	if len(name) > 253 || !discoveryNamePattern.MatchString(strings.ToLower(name)) {
+		return "", true, fmt.Errorf("invalid discovery record %q: expected _<service>._tcp.<domain>", name)
+	}
+	return name, true, nil
+}
+
 // ValidatePort validates that a port number is in valid range
 // Optionally checks against privileged port range (< 1024)
 func ValidatePort(port int, allowPrivileged bool) error {
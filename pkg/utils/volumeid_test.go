@@ -478,6 +478,36 @@ func TestValidateIPAddress(t *testing.T) {
 	}
 }
 
+func TestParseDiscoveryAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		wantName  string
+		wantOK    bool
+		expectErr bool
+	}{
+		{name: "IP address", address: "10.42.68.1"},
+		{name: "SRV record", address: "discovery://_nvme-tcp._tcp.storage.example.com", wantName: "_nvme-tcp._tcp.storage.example.com", wantOK: true},
+		{name: "fully qualified", address: "discovery://_nvme-tcp._tcp.storage.example.com.", wantName: "_nvme-tcp._tcp.storage.example.com.", wantOK: true},
+		{name: "empty record", address: "discovery://", wantOK: true, expectErr: true},
+		{name: "missing service labels", address: "discovery://storage.example.com", wantOK: true, expectErr: true},
+		{name: "IP instead of record", address: "discovery://10.42.68.1", wantOK: true, expectErr: true},
+		{name: "shell metacharacters", address: "discovery://_nvme-tcp._tcp.example.com;reboot", wantOK: true, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok, err := ParseDiscoveryAddress(tt.address)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseDiscoveryAddress() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if ok != tt.wantOK || name != tt.wantName {
+				t.Errorf("ParseDiscoveryAddress() = %q, %v, want %q, %v", name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestValidatePort(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -751,3 +781,31 @@ func BenchmarkValidateNVMETargetContext(b *testing.B) {
 		_ = ValidateNVMETargetContext("nqn.2000-02.com.mikrotik:pvc-123", "10.42.68.1", 4420, "")
 	}
 }
+
+func TestValidateWWID(t *testing.T) {
+	tests := []struct {
+		name      string
+		wwid      string
+		expectErr bool
+	}{
+		{"EUI-64", "eui.0025385b71b0a1f2", false},
+		{"NGUID", "eui.6b1e9a7c3f2d4e5a8b0c1d2e3f4a5b6c", false},
+		{"uppercase hex", "eui.0025385B71B0A1F2", false},
+		{"UUID", "uuid.a1b2c3d4-e5f6-7890-abcd-ef1234567890", false},
+		{"vendor fallback", "nvme.1b36-6465616462656566-51454d55-00000001", false},
+		{"empty", "", true},
+		{"missing type", "0025385b71b0a1f2", true},
+		{"unknown type", "naa.5000c500a1b2c3d4", true},
+		{"shell metacharacter", "eui.0025;rm", true},
+		{"path traversal", "eui.../../etc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWWID(tt.wwid)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ValidateWWID(%q) error = %v, expectErr %v", tt.wwid, err, tt.expectErr)
+			}
+		})
+	}
+}
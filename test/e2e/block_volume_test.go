@@ -4,6 +4,8 @@ import (
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 )
 
@@ -123,6 +125,7 @@ var _ = Describe("Block Volume [E2E-02]", func() {
 		createResp, err := controllerClient.CreateVolume(ctx, &csi.CreateVolumeRequest{
 			Name:          volumeName,
 			CapacityRange: &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+			Parameters:    map[string]string{"acknowledgeSharedBlockRisk": "true"},
 			VolumeCapabilities: []*csi.VolumeCapability{{
 				AccessMode: &csi.VolumeCapability_AccessMode{
 					Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
@@ -142,6 +145,26 @@ var _ = Describe("Block Volume [E2E-02]", func() {
 		klog.Infof("Successfully created RWX block volume for KubeVirt: %s", volumeID)
 	})
 
+	It("should reject RWX block volumes without acknowledging the shared block risk", func() {
+		volumeName := testVolumeName("block-rwx-unacked")
+
+		By("Attempting to create RWX block volume without acknowledgeSharedBlockRisk")
+		_, err := controllerClient.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:          volumeName,
+			CapacityRange: &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+			VolumeCapabilities: []*csi.VolumeCapability{{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+				},
+				AccessType: &csi.VolumeCapability_Block{
+					Block: &csi.VolumeCapability_BlockVolume{},
+				},
+			}},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument), "RWX without acknowledgment should be rejected")
+		Expect(err.Error()).To(ContainSubstring("acknowledgeSharedBlockRisk"))
+	})
+
 	It("should reject RWX access mode for filesystem volumes", func() {
 		volumeName := testVolumeName("fs-rwx")
 
@@ -0,0 +1,264 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/driver"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/test/mock"
+)
+
+// simulatedNode bundles a node server with its own mock NVMe connector and mounter
+// so each simulated node has independent connection and mount state.
+type simulatedNode struct {
+	name      string
+	server    *driver.NodeServer
+	connector *mock.MockNVMEConnector
+	mounter   *mock.MockMounter
+}
+
+// newSimulatedNode creates a node server with its own mock connector and mounter.
+// NewNodeServer captures the injected dependencies, so they are swapped on the
+// driver immediately before construction.
+func newSimulatedNode(drv *driver.Driver, name string) *simulatedNode {
+	connector := mock.NewMockNVMEConnector()
+	mounter := mock.NewMockMounter()
+	drv.SetNVMEConnector(connector)
+	drv.SetMounter(mounter)
+	drv.SetGetMountDevFunc(mounter.GetMountDevice)
+
+	return &simulatedNode{
+		name:      name,
+		server:    driver.NewNodeServer(drv, name, nil),
+		connector: connector,
+		mounter:   mounter,
+	}
+}
+
+var _ = Describe("Concurrent Multi-Volume Lifecycle [E2E-09]", func() {
+	const (
+		numVolumes = 20
+		numNodes   = 3
+	)
+
+	var (
+		lifecycleRDS *mock.MockRDSServer
+		controller   *driver.ControllerServer
+		nodes        []*simulatedNode
+		metrics      *observability.Metrics
+		drv          *driver.Driver
+	)
+
+	BeforeEach(func() {
+		By("Starting mock RDS server with realistic timing")
+		config := mock.LoadConfigFromEnv()
+		config.RealisticTiming = true
+		config.SSHLatencyMs = 20
+		config.SSHLatencyJitterMs = 10
+		config.DiskAddDelayMs = 50
+		config.DiskRemoveDelayMs = 30
+
+		var err error
+		lifecycleRDS, err = mock.NewMockRDSServerWithConfig(0, config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lifecycleRDS.Start()).To(Succeed())
+		DeferCleanup(func() {
+			_ = lifecycleRDS.Stop()
+		})
+
+		By(fmt.Sprintf("Creating driver with %d simulated nodes", numNodes))
+		var k8sObjects []runtime.Object
+		for i := 0; i < numNodes; i++ {
+			k8sObjects = append(k8sObjects, &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-node-%d", testRunID, i)},
+			})
+		}
+
+		metrics = observability.NewMetrics()
+		drv, err = driver.NewDriver(driver.DriverConfig{
+			DriverName:            "rds.csi.srvlab.io",
+			Version:               "test",
+			NodeID:                fmt.Sprintf("%s-node-0", testRunID),
+			RDSAddress:            lifecycleRDS.Address(),
+			RDSPort:               lifecycleRDS.Port(),
+			RDSUser:               "admin",
+			RDSPrivateKey:         []byte(testSSHPrivateKey),
+			RDSInsecureSkipVerify: true,
+			RDSVolumeBasePath:     testVolumeBasePath,
+			ManagedNQNPrefix:      "nqn.2000-02.com.mikrotik:",
+			EnableController:      true,
+			EnableNode:            true,
+			K8sClient:             fake.NewSimpleClientset(k8sObjects...),
+			Metrics:               metrics,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			drv.Stop()
+		})
+
+		controller = driver.NewControllerServer(drv)
+
+		nodes = make([]*simulatedNode, numNodes)
+		for i := 0; i < numNodes; i++ {
+			nodes[i] = newSimulatedNode(drv, fmt.Sprintf("%s-node-%d", testRunID, i))
+		}
+	})
+
+	It("should run full lifecycles for 20 volumes across 3 nodes without interference", func() {
+		baseDir := GinkgoT().TempDir()
+
+		var wg sync.WaitGroup
+		errChan := make(chan error, numVolumes)
+
+		By(fmt.Sprintf("Running %d volume lifecycles concurrently", numVolumes))
+		for i := 0; i < numVolumes; i++ {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				defer GinkgoRecover()
+
+				node := nodes[idx%numNodes]
+				if err := runVolumeLifecycle(ctx, controller, node, drv, baseDir, idx); err != nil {
+					errChan <- fmt.Errorf("volume %d on %s: %w", idx, node.name, err)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+		close(errChan)
+
+		var errors []error
+		for err := range errChan {
+			errors = append(errors, err)
+		}
+		Expect(errors).To(BeEmpty(), "All concurrent lifecycles should succeed")
+
+		By("Verifying no attachments remain")
+		Expect(drv.GetAttachmentManager().ListAttachments()).To(BeEmpty(),
+			"Attachment manager should have no tracked volumes")
+
+		By("Verifying attach/detach metrics are consistent")
+		counters := metrics.AttachmentSnapshot()
+		Expect(counters.AttachSuccess).To(Equal(float64(numVolumes)), "Each volume should be attached exactly once")
+		Expect(counters.DetachSuccess).To(Equal(counters.AttachSuccess), "attach_total should equal detach_total")
+
+		By("Verifying no NVMe connections or mounts remain on any node")
+		for _, node := range nodes {
+			for _, target := range node.connector.GetConnectCalls() {
+				Expect(node.connector.IsConnectedNQN(target.NQN)).To(BeFalse(),
+					"Node %s should have disconnected %s", node.name, target.NQN)
+			}
+			for _, call := range node.mounter.GetMountCalls() {
+				Expect(node.mounter.IsMounted(call.Target)).To(BeFalse(),
+					"Node %s should have unmounted %s", node.name, call.Target)
+			}
+		}
+
+		By("Verifying zero leftover state on mock RDS")
+		Expect(lifecycleRDS.ListVolumes()).To(BeEmpty(), "Mock RDS should have no volumes")
+		Expect(lifecycleRDS.ListFiles()).To(BeEmpty(), "Mock RDS should have no backing files")
+
+		klog.Infof("Concurrent lifecycle test passed: %d volumes across %d nodes", numVolumes, numNodes)
+	})
+})
+
+// runVolumeLifecycle drives a single volume through create, attach, stage, publish
+// and the full unwind, checking at each step that state belongs to this volume only.
+func runVolumeLifecycle(ctx context.Context, controller *driver.ControllerServer, node *simulatedNode, drv *driver.Driver, baseDir string, idx int) error {
+	volCap := mountVolumeCapability("ext4")
+
+	createResp, err := controller.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               testVolumeName(fmt.Sprintf("lifecycle-concurrent-%d", idx)),
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+		VolumeCapabilities: []*csi.VolumeCapability{volCap},
+	})
+	if err != nil {
+		return fmt.Errorf("CreateVolume failed: %w", err)
+	}
+	volumeID := createResp.Volume.VolumeId
+	volumeContext := createResp.Volume.VolumeContext
+
+	if _, err := controller.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+		VolumeId:         volumeID,
+		NodeId:           node.name,
+		VolumeCapability: volCap,
+		VolumeContext:    volumeContext,
+	}); err != nil {
+		return fmt.Errorf("ControllerPublishVolume failed: %w", err)
+	}
+
+	// Cross-volume interference check: attachment must point at our node only
+	state, ok := drv.GetAttachmentManager().GetAttachment(volumeID)
+	if !ok {
+		return fmt.Errorf("volume %s not tracked after publish", volumeID)
+	}
+	if state.NodeID != node.name || len(state.Nodes) != 1 {
+		return fmt.Errorf("volume %s attached to %v, expected only %s", volumeID, state.GetNodeIDs(), node.name)
+	}
+
+	stagePath := filepath.Join(baseDir, "staging", volumeID)
+	targetPath := filepath.Join(baseDir, "publish", volumeID)
+
+	if _, err := node.server.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: stagePath,
+		VolumeCapability:  volCap,
+		VolumeContext:     volumeContext,
+	}); err != nil {
+		return fmt.Errorf("NodeStageVolume failed: %w", err)
+	}
+
+	nqn := volumeContext["nqn"]
+	if !node.connector.IsConnectedNQN(nqn) {
+		return fmt.Errorf("node %s not connected to %s after stage", node.name, nqn)
+	}
+
+	if _, err := node.server.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: stagePath,
+		TargetPath:        targetPath,
+		VolumeCapability:  volCap,
+		VolumeContext:     volumeContext,
+	}); err != nil {
+		return fmt.Errorf("NodePublishVolume failed: %w", err)
+	}
+
+	if _, err := node.server.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{
+		VolumeId:   volumeID,
+		TargetPath: targetPath,
+	}); err != nil {
+		return fmt.Errorf("NodeUnpublishVolume failed: %w", err)
+	}
+
+	if _, err := node.server.NodeUnstageVolume(ctx, &csi.NodeUnstageVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: stagePath,
+	}); err != nil {
+		return fmt.Errorf("NodeUnstageVolume failed: %w", err)
+	}
+
+	if _, err := controller.ControllerUnpublishVolume(ctx, &csi.ControllerUnpublishVolumeRequest{
+		VolumeId: volumeID,
+		NodeId:   node.name,
+	}); err != nil {
+		return fmt.Errorf("ControllerUnpublishVolume failed: %w", err)
+	}
+
+	if _, err := controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID}); err != nil {
+		return fmt.Errorf("DeleteVolume failed: %w", err)
+	}
+
+	return nil
+}
@@ -71,6 +71,7 @@ var _ = BeforeSuite(func() {
 		RDSInsecureSkipVerify: true,
 		RDSVolumeBasePath:     testVolumeBasePath,
 		ManagedNQNPrefix:      "nqn.2000-02.com.mikrotik:",
+		SlotPrefix:            "e2e-", // Test volumes are named <testRunID>-<name>
 		EnableController:      true,
 		EnableNode:            true,
 		K8sClient:             nil,
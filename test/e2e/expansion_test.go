@@ -113,7 +113,7 @@ var _ = Describe("Volume Expansion [E2E-03]", func() {
 		klog.Infof("NodeExpansionRequired test passed for %s", volumeID)
 	})
 
-	It("should expand block volume without NodeExpansionRequired", func() {
+	It("should require node expansion for block volumes", func() {
 		volumeName := testVolumeName("expansion-block")
 
 		By("Creating block volume")
@@ -137,9 +137,9 @@ var _ = Describe("Volume Expansion [E2E-03]", func() {
 			VolumeCapability: blockVolumeCapability(),
 		})
 		Expect(err).NotTo(HaveOccurred())
-		// Block volumes don't need filesystem expansion
-		Expect(expandResp.NodeExpansionRequired).To(BeFalse(),
-			"Block volume expansion should not require node expansion")
+		// Block volumes have no filesystem to grow, but the node confirms the new device size
+		Expect(expandResp.NodeExpansionRequired).To(BeTrue(),
+			"Block volume expansion should require node expansion to confirm the device size")
 
 		klog.Infof("Block volume expansion test passed for %s", volumeID)
 	})
@@ -0,0 +1,294 @@
+package e2e
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/attachment"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/driver"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/nvme"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/test/mock"
+)
+
+// readyNode returns a Node object with a Ready condition set to the given status.
+func readyNode(name string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready},
+			},
+		},
+	}
+}
+
+var _ = Describe("Node Failure Recovery [E2E-10]", func() {
+	const (
+		gracePeriod  = 30 * time.Second
+		pvcNamespace = "default"
+	)
+
+	var (
+		failureRDS *mock.MockRDSServer
+		k8sClient  *fake.Clientset
+		controller *driver.ControllerServer
+		reconciler *attachment.AttachmentReconciler
+		nodeA      *simulatedNode
+		nodeB      *simulatedNode
+		metrics    *observability.Metrics
+		fakeClock  *clocktesting.FakeClock
+		drv        *driver.Driver
+		pvcName    string
+	)
+
+	BeforeEach(func() {
+		By("Starting mock RDS server")
+		var err error
+		failureRDS, err = mock.NewMockRDSServerWithConfig(0, mock.LoadConfigFromEnv())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failureRDS.Start()).To(Succeed())
+		DeferCleanup(func() {
+			_ = failureRDS.Stop()
+		})
+
+		By("Creating driver with two Ready nodes and an injectable clock")
+		nodeAName := fmt.Sprintf("%s-node-a", testRunID)
+		nodeBName := fmt.Sprintf("%s-node-b", testRunID)
+		pvcName = fmt.Sprintf("%s-node-failure", testRunID)
+
+		k8sClient = fake.NewSimpleClientset(
+			readyNode(nodeAName, corev1.ConditionTrue),
+			readyNode(nodeBName, corev1.ConditionTrue),
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: pvcNamespace},
+			},
+		)
+
+		metrics = observability.NewMetrics()
+		drv, err = driver.NewDriver(driver.DriverConfig{
+			DriverName:            "rds.csi.srvlab.io",
+			Version:               "test",
+			NodeID:                nodeAName,
+			RDSAddress:            failureRDS.Address(),
+			RDSPort:               failureRDS.Port(),
+			RDSUser:               "admin",
+			RDSPrivateKey:         []byte(testSSHPrivateKey),
+			RDSInsecureSkipVerify: true,
+			RDSVolumeBasePath:     testVolumeBasePath,
+			ManagedNQNPrefix:      "nqn.2000-02.com.mikrotik:",
+			EnableController:      true,
+			EnableNode:            true,
+			K8sClient:             k8sClient,
+			Metrics:               metrics,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			drv.Stop()
+		})
+
+		fakeClock = clocktesting.NewFakeClock(time.Now())
+		drv.GetAttachmentManager().SetClock(fakeClock)
+
+		controller = driver.NewControllerServer(drv)
+		nodeA = newSimulatedNode(drv, nodeAName)
+		nodeB = newSimulatedNode(drv, nodeBName)
+
+		By("Starting attachment reconciler with node watcher")
+		factory := informers.NewSharedInformerFactory(k8sClient, 0)
+		nodeInformer := factory.Core().V1().Nodes()
+		pvInformer := factory.Core().V1().PersistentVolumes()
+
+		eventPoster := driver.NewEventPoster(k8sClient)
+		eventPoster.SetMetrics(metrics)
+
+		reconciler, err = attachment.NewAttachmentReconciler(attachment.ReconcilerConfig{
+			Manager:     drv.GetAttachmentManager(),
+			K8sClient:   k8sClient,
+			NodeLister:  nodeInformer.Lister(),
+			PVLister:    pvInformer.Lister(),
+			Interval:    time.Hour, // Only event-driven passes during the test
+			GracePeriod: gracePeriod,
+			Metrics:     metrics,
+			EventPoster: eventPoster,
+			Clock:       fakeClock,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = nodeInformer.Informer().AddEventHandler(attachment.NewNodeWatcher(reconciler, metrics).GetEventHandlers())
+		Expect(err).NotTo(HaveOccurred())
+
+		stopCh := make(chan struct{})
+		factory.Start(stopCh)
+		Expect(cache.WaitForCacheSync(stopCh,
+			nodeInformer.Informer().HasSynced,
+			pvInformer.Informer().HasSynced,
+		)).To(BeTrue())
+		DeferCleanup(func() {
+			close(stopCh)
+			factory.Shutdown()
+		})
+
+		Expect(reconciler.Start(ctx)).To(Succeed())
+		DeferCleanup(reconciler.Stop)
+	})
+
+	It("should clear the stale attachment and reattach to a healthy node", func() {
+		volCap := mountVolumeCapability("ext4")
+		baseDir := GinkgoT().TempDir()
+
+		By("Creating volume and binding it to a PV")
+		createResp, err := controller.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:               testVolumeName("node-failure"),
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+			VolumeCapabilities: []*csi.VolumeCapability{volCap},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		volumeID := createResp.Volume.VolumeId
+		DeferCleanup(func() {
+			_, _ = controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID})
+		})
+
+		_, err = k8sClient.CoreV1().PersistentVolumes().Create(ctx, &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: volumeID},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: pvcNamespace, Name: pvcName},
+			},
+		}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// external-attacher passes PVC identity through the volume context
+		volumeContext := map[string]string{
+			"csi.storage.k8s.io/pvc/namespace": pvcNamespace,
+			"csi.storage.k8s.io/pvc/name":      pvcName,
+		}
+		for k, v := range createResp.Volume.VolumeContext {
+			volumeContext[k] = v
+		}
+
+		By("Attaching and staging the volume on node-A")
+		_, err = controller.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+			VolumeId:         volumeID,
+			NodeId:           nodeA.name,
+			VolumeCapability: volCap,
+			VolumeContext:    volumeContext,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = nodeA.server.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+			VolumeId:          volumeID,
+			StagingTargetPath: filepath.Join(baseDir, "node-a", "staging", volumeID),
+			VolumeCapability:  volCap,
+			VolumeContext:     volumeContext,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeA.connector.IsConnectedNQN(volumeContext["nqn"])).To(BeTrue())
+
+		By("Simulating node-A vanishing without cleanup")
+		nodeA.connector.Reset()
+		nodeA.mounter.Reset()
+		_, err = k8sClient.CoreV1().Nodes().UpdateStatus(ctx, readyNode(nodeA.name, corev1.ConditionFalse), metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Verifying attach to node-B is rejected while node-A is merely NotReady")
+		_, err = controller.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+			VolumeId:         volumeID,
+			NodeId:           nodeB.name,
+			VolumeCapability: volCap,
+			VolumeContext:    volumeContext,
+		})
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		Expect(metrics.AttachmentSnapshot().Conflicts).To(Equal(float64(1)))
+
+		By("Marking node-A out-of-service")
+		tainted := readyNode(nodeA.name, corev1.ConditionFalse)
+		tainted.Spec.Taints = []corev1.Taint{{
+			Key:       corev1.TaintNodeOutOfService,
+			Value:     "nodeshutdown",
+			Effect:    corev1.TaintEffectNoExecute,
+			TimeAdded: &metav1.Time{Time: fakeClock.Now()},
+		}}
+		_, err = k8sClient.CoreV1().Nodes().Update(ctx, tainted, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// The taint triggers a reconcile, but the grace period has not elapsed yet
+		Consistently(func() bool {
+			_, ok := drv.GetAttachmentManager().GetAttachment(volumeID)
+			return ok
+		}, time.Second, 100*time.Millisecond).Should(BeTrue(), "Attachment should survive within the grace period")
+
+		By("Advancing the clock past the grace period")
+		fakeClock.Step(gracePeriod + time.Second)
+		reconciler.TriggerReconcile()
+
+		Eventually(func() bool {
+			_, ok := drv.GetAttachmentManager().GetAttachment(volumeID)
+			return ok
+		}, 10*time.Second, 100*time.Millisecond).Should(BeFalse(), "Stale attachment should be cleared")
+		Expect(metrics.AttachmentSnapshot().StaleCleared).To(Equal(float64(1)))
+
+		By("Reattaching the volume to node-B")
+		_, err = controller.ControllerPublishVolume(ctx, &csi.ControllerPublishVolumeRequest{
+			VolumeId:         volumeID,
+			NodeId:           nodeB.name,
+			VolumeCapability: volCap,
+			VolumeContext:    volumeContext,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		state, ok := drv.GetAttachmentManager().GetAttachment(volumeID)
+		Expect(ok).To(BeTrue())
+		Expect(state.GetNodeIDs()).To(ConsistOf(nodeB.name))
+
+		// The filesystem node-A created lives on the RDS volume, so node-B finds it on its
+		// device and the filesystem UUID assigned by the controller matches
+		Expect(volumeContext["fsUUID"]).NotTo(BeEmpty())
+		devicePath, err := nodeB.connector.Connect(nvme.Target{NQN: volumeContext["nqn"]})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeB.mounter.Format(devicePath, "ext4", volumeContext["fsUUID"])).To(Succeed())
+
+		_, err = nodeB.server.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+			VolumeId:          volumeID,
+			StagingTargetPath: filepath.Join(baseDir, "node-b", "staging", volumeID),
+			VolumeCapability:  volCap,
+			VolumeContext:     volumeContext,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeB.connector.IsConnectedNQN(volumeContext["nqn"])).To(BeTrue())
+
+		By("Verifying RDS still holds exactly one volume")
+		Expect(failureRDS.ListVolumes()).To(HaveLen(1))
+
+		By("Verifying conflict and stale-clear events were posted to the PVC")
+		Eventually(func() []string {
+			events, err := k8sClient.CoreV1().Events(pvcNamespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil
+			}
+			var reasons []string
+			for _, e := range events.Items {
+				reasons = append(reasons, e.Reason)
+			}
+			return reasons
+		}, 10*time.Second, 100*time.Millisecond).Should(ContainElements(
+			driver.EventReasonAttachmentConflict,
+			driver.EventReasonStaleAttachmentCleared,
+		))
+
+		klog.Infof("Node failure recovery test passed: volume %s moved from %s to %s", volumeID, nodeA.name, nodeB.name)
+	})
+})
@@ -0,0 +1,223 @@
+package e2e
+
+import (
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/driver"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/test/mock"
+)
+
+// retryableCodes are the codes a controller operation may fail with while RDS is down:
+// the CSI sidecars retry them and the retry must then succeed
+var retryableCodes = []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.Aborted}
+
+var _ = Describe("RDS Restart [E2E-11]", func() {
+	const downtime = 2 * time.Second
+
+	var (
+		restartRDS *mock.MockRDSServer
+		controller *driver.ControllerServer
+		metrics    *observability.Metrics
+	)
+
+	BeforeEach(func() {
+		By("Starting mock RDS server with slow disk operations")
+		config := mock.LoadConfigFromEnv()
+		config.RealisticTiming = true
+		config.DiskAddDelayMs = 300
+		config.DiskRemoveDelayMs = 300
+
+		var err error
+		restartRDS, err = mock.NewMockRDSServerWithConfig(0, config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restartRDS.Start()).To(Succeed())
+		DeferCleanup(func() {
+			_ = restartRDS.Stop()
+		})
+
+		By("Creating controller with a fast polling connection manager")
+		metrics = observability.NewMetrics()
+		drv, err := driver.NewDriver(driver.DriverConfig{
+			DriverName:            "rds.csi.srvlab.io",
+			Version:               "test",
+			NodeID:                testRunID + "-node",
+			RDSAddress:            restartRDS.Address(),
+			RDSPort:               restartRDS.Port(),
+			RDSUser:               "admin",
+			RDSPrivateKey:         []byte(testSSHPrivateKey),
+			RDSInsecureSkipVerify: true,
+			RDSVolumeBasePath:     testVolumeBasePath,
+			ManagedNQNPrefix:      "nqn.2000-02.com.mikrotik:",
+			EnableController:      true,
+			Metrics:               metrics,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			drv.Stop()
+		})
+		controller = driver.NewControllerServer(drv)
+
+		// The driver only runs the connection manager next to the attachment reconciler,
+		// which needs Kubernetes; poll much faster than the default 5s so the restart
+		// is noticed while RDS is still down
+		cm, err := rds.NewConnectionManager(rds.ConnectionManagerConfig{
+			Client:          drv.GetRDSClient(),
+			Metrics:         metrics,
+			PollInterval:    100 * time.Millisecond,
+			InitialInterval: 200 * time.Millisecond,
+			MaxInterval:     time.Second,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		cm.StartMonitor(ctx)
+		DeferCleanup(cm.Stop)
+		Expect(metrics.RDSConnectionSnapshot(restartRDS.Address()).Connected).To(Equal(1.0))
+	})
+
+	It("should finish create, snapshot and delete across an RDS reboot without leftovers", func() {
+		volCap := mountVolumeCapability("ext4")
+
+		By("Creating the volumes to snapshot and to delete")
+		sourceResp, err := controller.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:               testVolumeName("restart-source"),
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+			VolumeCapabilities: []*csi.VolumeCapability{volCap},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		sourceID := sourceResp.Volume.VolumeId
+
+		doomedResp, err := controller.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:               testVolumeName("restart-doomed"),
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+			VolumeCapabilities: []*csi.VolumeCapability{volCap},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		doomedID := doomedResp.Volume.VolumeId
+
+		By("Recording connection_state while RDS restarts")
+		var (
+			statesMu sync.Mutex
+			states   = []float64{1}
+		)
+		stopWatch := make(chan struct{})
+		watchDone := make(chan struct{})
+		go func() {
+			defer close(watchDone)
+			for {
+				select {
+				case <-stopWatch:
+					return
+				case <-time.After(20 * time.Millisecond):
+				}
+				state := metrics.RDSConnectionSnapshot(restartRDS.Address()).Connected
+				statesMu.Lock()
+				if state != states[len(states)-1] {
+					states = append(states, state)
+				}
+				statesMu.Unlock()
+			}
+		}()
+		reconnectsBefore := metrics.RDSConnectionSnapshot(restartRDS.Address()).ReconnectSuccess
+
+		createReq := &csi.CreateVolumeRequest{
+			Name:               testVolumeName("restart-new"),
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: smallVolumeSize},
+			VolumeCapabilities: []*csi.VolumeCapability{volCap},
+		}
+		snapshotReq := &csi.CreateSnapshotRequest{
+			Name:           testSnapshotName("restart"),
+			SourceVolumeId: sourceID,
+		}
+		deleteReq := &csi.DeleteVolumeRequest{VolumeId: doomedID}
+		operations := map[string]func() error{
+			"CreateVolume": func() error {
+				_, err := controller.CreateVolume(ctx, createReq)
+				return err
+			},
+			"CreateSnapshot": func() error {
+				_, err := controller.CreateSnapshot(ctx, snapshotReq)
+				return err
+			},
+			"DeleteVolume": func() error {
+				_, err := controller.DeleteVolume(ctx, deleteReq)
+				return err
+			},
+		}
+
+		By("Starting the operations and restarting RDS while they run")
+		var wg sync.WaitGroup
+		errs := make(map[string]error, len(operations))
+		var errsMu sync.Mutex
+		for name, op := range operations {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := op()
+				errsMu.Lock()
+				errs[name] = err
+				errsMu.Unlock()
+			}()
+		}
+		time.Sleep(100 * time.Millisecond)
+		Expect(restartRDS.Restart(downtime)).To(Succeed())
+		wg.Wait()
+
+		By("Checking that failed operations failed with retryable codes")
+		for name, err := range errs {
+			if err == nil {
+				klog.Infof("E2E-11: %s succeeded across the restart", name)
+				continue
+			}
+			klog.Infof("E2E-11: %s failed across the restart: %v", name, err)
+			Expect(status.Code(err)).To(BeElementOf(retryableCodes), "%s failed with %v", name, err)
+		}
+
+		By("Retrying the operations as the CSI sidecars would")
+		for name, op := range operations {
+			Eventually(op, 30*time.Second, 500*time.Millisecond).Should(Succeed(), "%s did not succeed after RDS came back", name)
+		}
+
+		By("Checking that connection_state flapped and the reconnect was counted")
+		Eventually(func() float64 {
+			return metrics.RDSConnectionSnapshot(restartRDS.Address()).Connected
+		}, 10*time.Second, pollInterval).Should(Equal(1.0))
+		close(stopWatch)
+		<-watchDone
+		statesMu.Lock()
+		Expect(states).To(ContainElement(0.0), "connection_state never dropped to 0: %v", states)
+		Expect(states[len(states)-1]).To(Equal(1.0), "connection_state did not return to 1: %v", states)
+		statesMu.Unlock()
+		Expect(metrics.RDSConnectionSnapshot(restartRDS.Address()).ReconnectSuccess).To(BeNumerically(">", reconnectsBefore))
+
+		By("Checking that RDS holds exactly the expected disks and files")
+		newResp, err := controller.CreateVolume(ctx, createReq)
+		Expect(err).NotTo(HaveOccurred())
+		newID := newResp.Volume.VolumeId
+
+		var slots []string
+		for _, vol := range restartRDS.ListVolumes() {
+			slots = append(slots, vol.Slot)
+			Expect(vol.Exported).To(BeTrue(), "volume %s is not exported", vol.Slot)
+		}
+		Expect(slots).To(ConsistOf(sourceID, newID))
+
+		snapshots := restartRDS.ListSnapshots()
+		Expect(snapshots).To(HaveLen(1), "expected exactly one snapshot")
+		Expect(snapshots[0].SourceVolume).To(Equal(sourceID))
+
+		var files []string
+		for _, file := range restartRDS.ListFiles() {
+			files = append(files, file.Path)
+		}
+		Expect(files).To(HaveLen(3), "expected the files of two volumes and a snapshot, got %v", files)
+	})
+})
@@ -90,7 +90,7 @@ var _ = Describe("Resilience Regression", func() {
 	// RESIL-02: RDS unavailability simulation (error injection approach)
 	// Validates that after RDS is simulated as unavailable (all commands fail),
 	// controller operations resume when error injection is cleared.
-	// CRITICAL: Uses SetErrorMode only — NOT Stop()/Start() (restart is not supported).
+	// Uses SetErrorMode only; a real restart of RDS is simulated by E2E-11.
 	Describe("RESIL-02: RDS Unavailability Recovery (Error Injection)", func() {
 		It("should resume volume operations after RDS simulated unavailability is cleared", func() {
 			volumeName := testVolumeName("resil-02-baseline")
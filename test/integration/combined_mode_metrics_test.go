@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/driver"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
+	"git.srvlab.io/whiskey/rds-csi-driver/test/mock"
+)
+
+// TestCombinedModeDriverRecreated tests that a driver running controller and node in one
+// process can be created twice against the same Metrics, as after a config reload,
+// without a duplicate collector registration panicking
+func TestCombinedModeDriverRecreated(t *testing.T) {
+	mockRDS, err := mock.NewMockRDSServer(0)
+	if err != nil {
+		t.Fatalf("Failed to create mock RDS server: %v", err)
+	}
+	if err := mockRDS.Start(); err != nil {
+		t.Fatalf("Failed to start mock RDS server: %v", err)
+	}
+	defer func() { _ = mockRDS.Stop() }()
+	time.Sleep(100 * time.Millisecond)
+
+	metrics := observability.NewMetrics()
+	newDriver := func() *driver.Driver {
+		t.Helper()
+		drv, err := driver.NewDriver(driver.DriverConfig{
+			DriverName:            "rds.csi.srvlab.io",
+			Version:               "test",
+			NodeID:                "test-node-1",
+			RDSAddress:            mockRDS.Address(),
+			RDSPort:               mockRDS.Port(),
+			RDSUser:               "admin",
+			RDSInsecureSkipVerify: true,
+			ManagedNQNPrefix:      "nqn.2000-02.com.mikrotik:",
+			K8sClient:             fake.NewSimpleClientset(),
+			Metrics:               metrics,
+			EnableController:      true,
+			EnableNode:            true,
+		})
+		if err != nil {
+			t.Fatalf("NewDriver failed: %v", err)
+		}
+		return drv
+	}
+
+	// Without Stop in between, the second driver's registrations replace the first's
+	first := newDriver()
+	second := newDriver()
+	first.Stop()
+	second.Stop()
+
+	// After Stop, a new driver registers its metrics again. The registry is not
+	// scraped here: the RDS monitoring gauges would query SNMP on a real address.
+	newDriver().Stop()
+}
@@ -439,4 +439,181 @@ func TestControllerIntegrationWithMockRDS(t *testing.T) {
 
 		t.Log("✅ Invalid capabilities correctly rejected")
 	})
+
+	t.Run("RenamedSlot_FoundByBackingFile", func(t *testing.T) {
+		capability := &csi.VolumeCapability{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+			AccessType: &csi.VolumeCapability_Mount{
+				Mount: &csi.VolumeCapability_MountVolume{},
+			},
+		}
+		createResp, err := cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+			Name:               "pvc-44444444-4444-4444-4444-444444444444",
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: 1073741824},
+			VolumeCapabilities: []*csi.VolumeCapability{capability},
+		})
+		if err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		volumeID := createResp.Volume.VolumeId
+
+		// Rename the slot on RDS mid-lifecycle
+		if !mockRDS.RenameVolume(volumeID, "pvc-archived-44444444") {
+			t.Fatal("Failed to rename volume on mock RDS")
+		}
+
+		// The volume context records the backing file, which still finds the volume
+		_, err = cs.ValidateVolumeCapabilities(context.Background(), &csi.ValidateVolumeCapabilitiesRequest{
+			VolumeId:           volumeID,
+			VolumeContext:      createResp.Volume.VolumeContext,
+			VolumeCapabilities: []*csi.VolumeCapability{capability},
+		})
+		if err != nil {
+			t.Fatalf("Expected renamed volume to be found by its backing file, got %v", err)
+		}
+
+		t.Logf("✅ Found renamed volume %s by its backing file", volumeID)
+	})
+
+	t.Run("RestoreSnapshot_DifferentPool", func(t *testing.T) {
+		if err := utils.AddAllowedBasePath("/bulk-pool/metal-csi"); err != nil {
+			t.Fatalf("Failed to add allowed base path: %v", err)
+		}
+		ctx := context.Background()
+		mountCaps := []*csi.VolumeCapability{
+			{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+				},
+				AccessType: &csi.VolumeCapability_Mount{
+					Mount: &csi.VolumeCapability_MountVolume{},
+				},
+			},
+		}
+
+		source, err := cs.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name:               "pvc-aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+			CapacityRange:      &csi.CapacityRange{RequiredBytes: 1073741824},
+			VolumeCapabilities: mountCaps,
+			Parameters:         map[string]string{"volumePath": "/storage-pool/metal-csi"},
+		})
+		if err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		snapshot, err := cs.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+			Name:           "snapshot-fast-pool",
+			SourceVolumeId: source.Volume.VolumeId,
+			Parameters:     map[string]string{"volumePath": "/storage-pool/metal-csi"},
+		})
+		if err != nil {
+			t.Fatalf("CreateSnapshot failed: %v", err)
+		}
+
+		restoreRequest := func(name, volumePath string) *csi.CreateVolumeRequest {
+			return &csi.CreateVolumeRequest{
+				Name:               name,
+				CapacityRange:      &csi.CapacityRange{RequiredBytes: 1073741824},
+				VolumeCapabilities: mountCaps,
+				VolumeContentSource: &csi.VolumeContentSource{
+					Type: &csi.VolumeContentSource_Snapshot{
+						Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapshot.Snapshot.SnapshotId},
+					},
+				},
+				Parameters: map[string]string{"volumePath": volumePath},
+			}
+		}
+
+		restored, err := cs.CreateVolume(ctx, restoreRequest("pvc-bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb", "/bulk-pool/metal-csi"))
+		if err != nil {
+			t.Fatalf("restore into another pool failed: %v", err)
+		}
+		vol, err := rdsClient.GetVolume(restored.Volume.VolumeId)
+		if err != nil {
+			t.Fatalf("restored volume not found on RDS: %v", err)
+		}
+		if want := "/bulk-pool/metal-csi/" + restored.Volume.VolumeId + ".img"; vol.FilePath != want {
+			t.Errorf("restored file = %s, want %s", vol.FilePath, want)
+		}
+
+		// Pools outside the allow-list are rejected before anything is copied
+		_, err = cs.CreateVolume(ctx, restoreRequest("pvc-cccccccc-cccc-cccc-cccc-cccccccccccc", "/other-pool/metal-csi"))
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for a pool outside the allow-list, got %v", err)
+		}
+		if _, err := rdsClient.GetVolume("pvc-cccccccc-cccc-cccc-cccc-cccccccccccc"); err == nil {
+			t.Error("volume restored into a pool outside the allow-list")
+		}
+
+		t.Logf("✅ Restored snapshot from /storage-pool into %s", vol.FilePath)
+	})
+
+	t.Run("Size_ReportedFromRDS", func(t *testing.T) {
+		ctx := context.Background()
+		const volumeID = "pvc-dddddddd-dddd-dddd-dddd-dddddddddddd"
+		const extra = 4 * 1024 * 1024
+		// RDS allocating more than the file-size it was sent
+		mockRDS.SetFileSizeAllocation(func(requested int64) int64 { return requested + extra })
+		defer mockRDS.SetFileSizeAllocation(nil)
+
+		createReq := &csi.CreateVolumeRequest{
+			Name:          volumeID,
+			CapacityRange: &csi.CapacityRange{RequiredBytes: 1073741824},
+			VolumeCapabilities: []*csi.VolumeCapability{
+				{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+					},
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{},
+					},
+				},
+			},
+		}
+		created, err := cs.CreateVolume(ctx, createReq)
+		if err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		vol, _ := mockRDS.GetVolume(volumeID)
+		if created.Volume.CapacityBytes != vol.FileSizeBytes || vol.FileSizeBytes != 1073741824+extra {
+			t.Errorf("CreateVolume reported %d bytes, RDS has %d", created.Volume.CapacityBytes, vol.FileSizeBytes)
+		}
+
+		// A retried CreateVolume finds the larger volume compatible with the request
+		retried, err := cs.CreateVolume(ctx, createReq)
+		if err != nil {
+			t.Fatalf("retried CreateVolume failed: %v", err)
+		}
+		if retried.Volume.CapacityBytes != vol.FileSizeBytes {
+			t.Errorf("retried CreateVolume reported %d bytes, RDS has %d", retried.Volume.CapacityBytes, vol.FileSizeBytes)
+		}
+
+		expanded, err := cs.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+			VolumeId:      volumeID,
+			CapacityRange: &csi.CapacityRange{RequiredBytes: 2147483648},
+		})
+		if err != nil {
+			t.Fatalf("ControllerExpandVolume failed: %v", err)
+		}
+		vol, _ = mockRDS.GetVolume(volumeID)
+		if expanded.CapacityBytes != vol.FileSizeBytes || vol.FileSizeBytes != 2147483648+extra {
+			t.Errorf("ControllerExpandVolume reported %d bytes, RDS has %d", expanded.CapacityBytes, vol.FileSizeBytes)
+		}
+
+		// RDS allocating less than requested fails the call
+		mockRDS.SetFileSizeAllocation(func(requested int64) int64 { return requested - extra })
+		createReq.Name = "pvc-eeeeeeee-eeee-eeee-eeee-eeeeeeeeeeee"
+		if _, err := cs.CreateVolume(ctx, createReq); status.Code(err) != codes.Internal {
+			t.Errorf("expected Internal for a volume smaller than requested, got %v", err)
+		}
+		// The undersized volume is removed, so the retry creates it again
+		if _, ok := mockRDS.GetVolume(createReq.Name); ok {
+			t.Error("volume smaller than requested was left on RDS")
+		}
+		mockRDS.SetFileSizeAllocation(nil)
+		if _, err := cs.CreateVolume(ctx, createReq); err != nil {
+			t.Errorf("retried CreateVolume failed: %v", err)
+		}
+	})
 }
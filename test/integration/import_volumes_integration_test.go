@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/driver"
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/test/mock"
+)
+
+// TestImportVolumes_WithMockRDS tests that the manifests printed by -import-volumes
+// reference the slots and NQNs of the unreferenced volumes on the mock RDS server
+func TestImportVolumes_WithMockRDS(t *testing.T) {
+	mockRDS, err := mock.NewMockRDSServer(0)
+	if err != nil {
+		t.Fatalf("Failed to create mock RDS server: %v", err)
+	}
+	if err := mockRDS.Start(); err != nil {
+		t.Fatalf("Failed to start mock RDS server: %v", err)
+	}
+	defer func() { _ = mockRDS.Stop() }()
+
+	const (
+		adopted  = "pvc-11111111-1111-1111-1111-111111111111"
+		imported = "pvc-22222222-2222-2222-2222-222222222222"
+	)
+	for _, slot := range []string{adopted, imported} {
+		filePath := "/storage-pool/metal-csi/" + slot + ".img"
+		mockRDS.CreateOrphanedFile(filePath, 5*1024*1024*1024)
+		mockRDS.CreateOrphanedVolume(slot, filePath, 5*1024*1024*1024)
+	}
+
+	rdsClient, err := rds.NewClient(rds.ClientConfig{
+		Address:            mockRDS.Address(),
+		Port:               mockRDS.Port(),
+		User:               "admin",
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create RDS client: %v", err)
+	}
+	if err := rdsClient.Connect(); err != nil {
+		t.Fatalf("Failed to connect to mock RDS: %v", err)
+	}
+	defer func() { _ = rdsClient.Close() }()
+
+	k8sClient := fake.NewSimpleClientset(&v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-adopted"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: driver.DriverName, VolumeHandle: adopted},
+			},
+		},
+	})
+	commandsBefore := len(mockRDS.GetCommandHistory())
+
+	report, err := driver.ImportVolumes(context.Background(), rdsClient, k8sClient, driver.ImportConfig{
+		DriverName: driver.DriverName,
+		BasePath:   "/storage-pool/metal-csi",
+		RDSAddress: mockRDS.Address(),
+	})
+	if err != nil {
+		t.Fatalf("ImportVolumes failed: %v", err)
+	}
+	var out bytes.Buffer
+	if err := report.WriteYAML(&out); err != nil {
+		t.Fatalf("WriteYAML failed: %v", err)
+	}
+
+	documents := strings.Split(out.String(), "---\n")[1:]
+	if len(documents) != 1 {
+		t.Fatalf("expected one manifest for %s, got:\n%s", imported, out.String())
+	}
+	var pv v1.PersistentVolume
+	if err := yaml.UnmarshalStrict([]byte(documents[0]), &pv); err != nil {
+		t.Fatalf("manifest does not parse: %v", err)
+	}
+	if pv.Spec.CSI.VolumeHandle != imported {
+		t.Errorf("volumeHandle = %s, want %s", pv.Spec.CSI.VolumeHandle, imported)
+	}
+	if nqn := pv.Spec.CSI.VolumeAttributes["nqn"]; nqn != "nqn.2000-02.com.mikrotik:"+imported {
+		t.Errorf("nqn = %s, want the NQN of %s", nqn, imported)
+	}
+	if port := pv.Spec.CSI.VolumeAttributes["nvmePort"]; port != "4420" {
+		t.Errorf("nvmePort = %s, want 4420", port)
+	}
+	if got := pv.Spec.Capacity[v1.ResourceStorage]; got.String() != "5Gi" {
+		t.Errorf("capacity = %s, want 5Gi", got.String())
+	}
+
+	// The import only reads
+	for _, cmd := range mockRDS.GetCommandHistory()[commandsBefore:] {
+		if !strings.Contains(cmd.Command, " print") {
+			t.Errorf("import ran a mutating command: %s", cmd.Command)
+		}
+	}
+}
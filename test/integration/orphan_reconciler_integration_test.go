@@ -347,6 +347,59 @@ func TestOrphanReconciler_WithMockRDS(t *testing.T) {
 		t.Log("✅ File sizes parsed correctly")
 	})
 
+	t.Run("RenamedSlot_KeptByBackingFile", func(t *testing.T) {
+		// Setup: Create a volume with a PV, then rename its slot on RDS
+		mockRDS.CreateOrphanedFile("/storage-pool/metal-csi/pvc-test-renamed.img", 10*1024*1024*1024)
+		mockRDS.CreateOrphanedVolume("pvc-test-renamed", "/storage-pool/metal-csi/pvc-test-renamed.img", 10*1024*1024*1024)
+
+		k8sClient := fake.NewSimpleClientset()
+		pv := &v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc-test-renamed"},
+			Spec: v1.PersistentVolumeSpec{
+				PersistentVolumeSource: v1.PersistentVolumeSource{
+					CSI: &v1.CSIPersistentVolumeSource{
+						Driver:           "rds.csi.srvlab.io",
+						VolumeHandle:     "pvc-test-renamed",
+						VolumeAttributes: map[string]string{"filePath": "/storage-pool/metal-csi/pvc-test-renamed.img"},
+					},
+				},
+			},
+		}
+		if _, err := k8sClient.CoreV1().PersistentVolumes().Create(context.Background(), pv, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create test PV: %v", err)
+		}
+		if !mockRDS.RenameVolume("pvc-test-renamed", "pvc-archived-renamed") {
+			t.Fatal("Failed to rename volume on mock RDS")
+		}
+
+		rec, err := reconciler.NewOrphanReconciler(reconciler.OrphanReconcilerConfig{
+			RDSClient:     rdsClient,
+			K8sClient:     k8sClient,
+			CheckInterval: 1 * time.Hour,
+			GracePeriod:   1 * time.Second,
+			DryRun:        false,
+			Enabled:       true,
+			BasePath:      "/storage-pool/metal-csi",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create reconciler: %v", err)
+		}
+
+		if err := rec.TriggerReconciliation(context.Background()); err != nil {
+			t.Fatalf("Reconciliation failed: %v", err)
+		}
+
+		// The renamed slot no longer matches the volume handle, but its backing file does
+		if _, exists := mockRDS.GetVolume("pvc-archived-renamed"); !exists {
+			t.Error("Renamed volume should not be deleted (its PV records the backing file)")
+		}
+		if _, exists := mockRDS.GetFile("/storage-pool/metal-csi/pvc-test-renamed.img"); !exists {
+			t.Error("Renamed volume's file should not be deleted")
+		}
+
+		t.Log("✅ Renamed volume kept by its backing file")
+	})
+
 	t.Run("NonCSIVolumes_Ignored", func(t *testing.T) {
 		// Setup: Create non-CSI volumes (don't start with "pvc-")
 		mockRDS.CreateOrphanedFile("/storage-pool/metal-csi/manual-volume.img", 50*1024*1024*1024)
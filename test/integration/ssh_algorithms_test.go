@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
+	"git.srvlab.io/whiskey/rds-csi-driver/test/mock"
+)
+
+// fipsAlgorithms is a FIPS-approved algorithm set as a security team would configure it
+var fipsAlgorithms = rds.SSHAlgorithms{
+	Ciphers:      []string{ssh.CipherAES128GCM, ssh.CipherAES256GCM},
+	KeyExchanges: []string{ssh.KeyExchangeECDHP256, ssh.KeyExchangeECDHP384},
+	MACs:         []string{ssh.HMACSHA256ETM, ssh.HMACSHA512ETM},
+}
+
+// TestSSHAlgorithmRestrictions checks that the configured algorithms are the only ones the
+// client offers: a server without any of them fails the handshake
+func TestSSHAlgorithmRestrictions(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverKex     []string
+		serverCiphers []string
+		serverMACs    []string
+		wantConnected bool
+	}{
+		{
+			name:          "server defaults",
+			wantConnected: true,
+		},
+		{
+			name:          "server offering only allowed algorithms",
+			serverKex:     []string{ssh.KeyExchangeECDHP384},
+			serverCiphers: []string{ssh.CipherAES256GCM},
+			serverMACs:    []string{ssh.HMACSHA512ETM},
+			wantConnected: true,
+		},
+		{
+			name:          "server without an allowed cipher",
+			serverCiphers: []string{ssh.CipherChaCha20Poly1305},
+		},
+		{
+			name:      "server without an allowed key exchange",
+			serverKex: []string{ssh.KeyExchangeCurve25519},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRDS, err := mock.NewMockRDSServer(0)
+			if err != nil {
+				t.Fatalf("Failed to create mock RDS server: %v", err)
+			}
+			mockRDS.SetSSHAlgorithms(tt.serverKex, tt.serverCiphers, tt.serverMACs)
+			if err := mockRDS.Start(); err != nil {
+				t.Fatalf("Failed to start mock RDS server: %v", err)
+			}
+			defer func() { _ = mockRDS.Stop() }()
+
+			client, err := rds.NewClient(rds.ClientConfig{
+				Address:            mockRDS.Address(),
+				Port:               mockRDS.Port(),
+				User:               "admin",
+				InsecureSkipVerify: true,
+				Algorithms:         fipsAlgorithms,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create RDS client: %v", err)
+			}
+			defer func() { _ = client.Close() }()
+
+			err = client.Connect()
+			if tt.wantConnected && err != nil {
+				t.Fatalf("Expected the handshake to succeed, got %v", err)
+			}
+			if !tt.wantConnected && err == nil {
+				t.Fatal("Expected the handshake to fail without a common algorithm")
+			}
+		})
+	}
+}
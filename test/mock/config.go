@@ -8,15 +8,20 @@
 //   - MOCK_RDS_SSH_LATENCY_JITTER_MS: Latency jitter range in ms (default: 50)
 //   - MOCK_RDS_DISK_ADD_DELAY_MS: Disk add operation delay in ms (default: 500)
 //   - MOCK_RDS_DISK_REMOVE_DELAY_MS: Disk remove operation delay in ms (default: 300)
+//   - MOCK_RDS_CREATION_SETTLE_MS: Time after /disk add before a new disk reports ready (default: 0)
+//   - MOCK_RDS_CREATION_HIDDEN: Omit unsettled disks from /disk print instead of reporting "formatting" (default: false)
 //
 // Error Injection:
-//   - MOCK_RDS_ERROR_MODE: Error injection mode (none|disk_full|ssh_timeout|command_fail)
+//   - MOCK_RDS_ERROR_MODE: Error injection mode (none|disk_full|ssh_timeout|command_fail|drop_after_command)
 //   - MOCK_RDS_ERROR_AFTER_N: Fail after N operations (default: 0 = immediate)
 //
 // Observability:
 //   - MOCK_RDS_ENABLE_HISTORY: Enable command history tracking (default: true)
 //   - MOCK_RDS_HISTORY_DEPTH: Maximum history entries (default: 100)
 //   - MOCK_RDS_ROUTEROS_VERSION: RouterOS version to simulate (default: "7.16")
+//
+// Output Format:
+//   - MOCK_RDS_DECORATED_OUTPUT: Emit output like a colored 80-column console: ANSI colors, CRLF, wrapped lines (default: false)
 package mock
 
 import (
@@ -33,14 +38,21 @@ type MockRDSConfig struct {
 	DiskAddDelayMs     int  // MOCK_RDS_DISK_ADD_DELAY_MS (default: 500)
 	DiskRemoveDelayMs  int  // MOCK_RDS_DISK_REMOVE_DELAY_MS (default: 300)
 
+	// Asynchronous disk creation, as seen on slower RouterOS firmware
+	CreationSettleMs int  // MOCK_RDS_CREATION_SETTLE_MS (default: 0, ready immediately)
+	CreationHidden   bool // MOCK_RDS_CREATION_HIDDEN (default: false, report status "formatting")
+
 	// Error injection
-	ErrorMode   string // MOCK_RDS_ERROR_MODE (none|disk_full|ssh_timeout|command_fail)
+	ErrorMode   string // MOCK_RDS_ERROR_MODE (none|disk_full|ssh_timeout|command_fail|drop_after_command)
 	ErrorAfterN int    // MOCK_RDS_ERROR_AFTER_N (fail after N operations, default: 0 = immediate)
 
 	// Observability
 	EnableHistory   bool   // MOCK_RDS_ENABLE_HISTORY (default: true for backward compat)
 	HistoryDepth    int    // MOCK_RDS_HISTORY_DEPTH (default: 100)
 	RouterOSVersion string // MOCK_RDS_ROUTEROS_VERSION (default: "7.16")
+
+	// Output format
+	DecoratedOutput bool // MOCK_RDS_DECORATED_OUTPUT (default: false, plain output)
 }
 
 // LoadConfigFromEnv loads mock RDS configuration from environment variables
@@ -51,11 +63,14 @@ func LoadConfigFromEnv() MockRDSConfig {
 		SSHLatencyJitterMs: getEnvInt("MOCK_RDS_SSH_LATENCY_JITTER_MS", 50),
 		DiskAddDelayMs:     getEnvInt("MOCK_RDS_DISK_ADD_DELAY_MS", 500),
 		DiskRemoveDelayMs:  getEnvInt("MOCK_RDS_DISK_REMOVE_DELAY_MS", 300),
+		CreationSettleMs:   getEnvInt("MOCK_RDS_CREATION_SETTLE_MS", 0),
+		CreationHidden:     getEnvBool("MOCK_RDS_CREATION_HIDDEN", false),
 		ErrorMode:          getEnvString("MOCK_RDS_ERROR_MODE", "none"),
 		ErrorAfterN:        getEnvInt("MOCK_RDS_ERROR_AFTER_N", 0),
 		EnableHistory:      getEnvBool("MOCK_RDS_ENABLE_HISTORY", true),
 		HistoryDepth:       getEnvInt("MOCK_RDS_HISTORY_DEPTH", 100),
 		RouterOSVersion:    getEnvString("MOCK_RDS_ROUTEROS_VERSION", "7.16"),
+		DecoratedOutput:    getEnvBool("MOCK_RDS_DECORATED_OUTPUT", false),
 	}
 }
 
@@ -0,0 +1,65 @@
+package mock
+
+import (
+	"regexp"
+	"strings"
+)
+
+// decoratedWrapWidth is the terminal width RouterOS wraps print output at
+const decoratedWrapWidth = 80
+
+// decoratedIndent prefixes continuation lines of wrapped output
+const decoratedIndent = "     "
+
+// ANSI sequences used by RouterOS with console colors enabled
+const (
+	ansiPropertyColor = "\x1b[36m"
+	ansiCommentColor  = "\x1b[34m"
+	ansiReset         = "\x1b[m"
+	ansiEraseLine     = "\x1b[K"
+)
+
+// decoratedPropertyPattern matches a property name followed by "="
+var decoratedPropertyPattern = regexp.MustCompile(`([a-z][a-z0-9-]*)=`)
+
+// decorateOutput renders plain command output the way a colored RouterOS console on an
+// 80-column terminal does: lines are wrapped at 80 columns (between properties where
+// possible, otherwise in the middle of a value) onto indented continuation lines,
+// property names and comments are colored, and lines end with CRLF.
+func decorateOutput(output string) string {
+	if output == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		// Comments keep their own line so the parser's ";;;" match stays intact
+		if strings.HasPrefix(line, ";;;") {
+			b.WriteString(ansiCommentColor + line + ansiReset + ansiEraseLine + "\r\n")
+			continue
+		}
+		for _, physical := range wrapLine(line) {
+			b.WriteString(decoratedPropertyPattern.ReplaceAllString(physical, ansiPropertyColor+"$1"+ansiReset+"="))
+			b.WriteString(ansiEraseLine + "\r\n")
+		}
+	}
+	return b.String()
+}
+
+// wrapLine splits line into physical lines of at most decoratedWrapWidth columns
+func wrapLine(line string) []string {
+	var lines []string
+	for len(line) > decoratedWrapWidth {
+		// Break at the last space that keeps the line within the width, unless that
+		// space is part of the indentation; otherwise break in the middle of the value
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		cut := strings.LastIndexByte(line[:decoratedWrapWidth+1], ' ')
+		next := cut + 1
+		if cut <= indent {
+			cut, next = decoratedWrapWidth, decoratedWrapWidth
+		}
+		lines = append(lines, line[:cut])
+		line = decoratedIndent + line[next:]
+	}
+	return append(lines, line)
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"strings"
 	"sync"
 
 	"k8s.io/klog/v2"
@@ -18,6 +19,9 @@ const (
 	ErrorModeSSHTimeout
 	// ErrorModeCommandFail simulates command execution failure
 	ErrorModeCommandFail
+	// ErrorModeDropAfterCommand drops the SSH connection once, after a command that
+	// changes state ran but before its outcome was reported
+	ErrorModeDropAfterCommand
 )
 
 // ErrorInjector manages error injection for testing
@@ -46,6 +50,8 @@ func ParseErrorMode(s string) ErrorMode {
 		return ErrorModeSSHTimeout
 	case "command_fail":
 		return ErrorModeCommandFail
+	case "drop_after_command":
+		return ErrorModeDropAfterCommand
 	case "none", "":
 		return ErrorModeNone
 	default:
@@ -109,6 +115,21 @@ func (e *ErrorInjector) ShouldFailDiskRemove() (bool, string) {
 	return true, "failure: execution error\n"
 }
 
+// ShouldDropAfterCommand returns true if the connection should be dropped after command
+// ran. Only the first state-changing command after ErrorAfterN others is dropped; prints
+// are not counted, so the client can check what happened.
+func (e *ErrorInjector) ShouldDropAfterCommand(command string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.mode != ErrorModeDropAfterCommand || strings.Contains(command, " print") {
+		return false
+	}
+
+	e.operationNum++
+	return e.operationNum == e.triggerAfter+1
+}
+
 // Reset resets the operation counter for test isolation
 func (e *ErrorInjector) Reset() {
 	e.mu.Lock()
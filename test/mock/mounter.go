@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"sync"
@@ -19,15 +20,25 @@ type MockMounter struct {
 	// Formatted devices: device path -> filesystem type
 	formatted map[string]string
 
+	// Filesystem UUIDs given to Format: device path -> UUID
+	fsUUIDs map[string]string
+
 	// Error injection
 	mountErr   error
 	unmountErr error
 	formatErr  error
 
+	// Simulated filesystem error state: target path -> state reported by GetDeviceStats
+	fsErrors map[string]fsErrorState
+
+	// Project quota limits: path -> limit in bytes
+	quotas map[string]int64
+
 	// Call tracking
 	mountCalls   []MountCall
 	unmountCalls []string
 	formatCalls  []FormatCall
+	trimCalls    []string
 }
 
 // MountCall tracks a Mount operation
@@ -38,10 +49,17 @@ type MountCall struct {
 	Options []string
 }
 
+// fsErrorState is a simulated filesystem error condition for one mount
+type fsErrorState struct {
+	readOnlyRemount bool
+	errorCount      int64
+}
+
 // FormatCall tracks a Format operation
 type FormatCall struct {
 	Device string
 	FSType string
+	FSUUID string
 }
 
 // NewMockMounter creates a new mock mounter
@@ -49,6 +67,8 @@ func NewMockMounter() *MockMounter {
 	return &MockMounter{
 		mounted:   make(map[string]string),
 		formatted: make(map[string]string),
+		fsUUIDs:   make(map[string]string),
+		fsErrors:  make(map[string]fsErrorState),
 	}
 }
 
@@ -110,7 +130,7 @@ func (m *MockMounter) IsLikelyMountPoint(path string) (bool, error) {
 }
 
 // Format implements mount.Mounter
-func (m *MockMounter) Format(device, fsType string) error {
+func (m *MockMounter) Format(device, fsType, fsUUID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -118,6 +138,7 @@ func (m *MockMounter) Format(device, fsType string) error {
 	m.formatCalls = append(m.formatCalls, FormatCall{
 		Device: device,
 		FSType: fsType,
+		FSUUID: fsUUID,
 	})
 
 	// Check for error injection
@@ -127,6 +148,11 @@ func (m *MockMounter) Format(device, fsType string) error {
 
 	// Record formatted device
 	m.formatted[device] = fsType
+	if fsUUID != "" {
+		m.fsUUIDs[device] = fsUUID
+	} else {
+		delete(m.fsUUIDs, device)
+	}
 
 	return nil
 }
@@ -140,12 +166,37 @@ func (m *MockMounter) IsFormatted(device string) (bool, error) {
 	return formatted, nil
 }
 
+// FilesystemUUID implements mount.Mounter. It is the UUID given to Format, or else derived
+// from the device path, so it stays the same for as long as the device is formatted.
+func (m *MockMounter) FilesystemUUID(device string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, formatted := m.formatted[device]; !formatted {
+		return "", fmt.Errorf("no filesystem UUID on %s", device)
+	}
+	if fsUUID, ok := m.fsUUIDs[device]; ok {
+		return fsUUID, nil
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(device)))[:32], nil
+}
+
 // ResizeFilesystem implements mount.Mounter
 func (m *MockMounter) ResizeFilesystem(device, volumePath string) error {
 	// Mock implementation - just return success
 	return nil
 }
 
+// Trim implements mount.Mounter
+func (m *MockMounter) Trim(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Track call
+	m.trimCalls = append(m.trimCalls, path)
+	return nil
+}
+
 // GetDeviceStats implements mount.Mounter
 func (m *MockMounter) GetDeviceStats(path string) (*mount.DeviceStats, error) {
 	m.mu.RLock()
@@ -157,13 +208,16 @@ func (m *MockMounter) GetDeviceStats(path string) (*mount.DeviceStats, error) {
 	}
 
 	// Return fake stats
+	fsErr := m.fsErrors[path]
 	return &mount.DeviceStats{
-		TotalBytes:      10 * 1024 * 1024 * 1024, // 10 GiB
-		UsedBytes:       1 * 1024 * 1024 * 1024,  // 1 GiB
-		AvailableBytes:  9 * 1024 * 1024 * 1024,  // 9 GiB
-		TotalInodes:     1000000,
-		UsedInodes:      100000,
-		AvailableInodes: 900000,
+		TotalBytes:       10 * 1024 * 1024 * 1024, // 10 GiB
+		UsedBytes:        1 * 1024 * 1024 * 1024,  // 1 GiB
+		AvailableBytes:   9 * 1024 * 1024 * 1024,  // 9 GiB
+		TotalInodes:      1000000,
+		UsedInodes:       100000,
+		AvailableInodes:  900000,
+		ReadOnlyRemount:  fsErr.readOnlyRemount,
+		FilesystemErrors: fsErr.errorCount,
 	}, nil
 }
 
@@ -189,6 +243,32 @@ func (m *MockMounter) MakeFile(pathname string) error {
 	return f.Close()
 }
 
+// EnableProjectQuota implements mount.Mounter
+func (m *MockMounter) EnableProjectQuota(device, fsType string) error {
+	if fsType != "ext4" && fsType != "xfs" {
+		return fmt.Errorf("project quota is not supported on %s", fsType)
+	}
+	return nil
+}
+
+// SetProjectQuota implements mount.Mounter
+func (m *MockMounter) SetProjectQuota(path string, projectID uint32, limitBytes int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.quotas == nil {
+		m.quotas = make(map[string]int64)
+	}
+	m.quotas[path] = limitBytes
+	return nil
+}
+
+// GetProjectQuota implements mount.Mounter
+func (m *MockMounter) GetProjectQuota(path string, projectID uint32) (*mount.ProjectQuota, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &mount.ProjectQuota{LimitBytes: m.quotas[path]}, nil
+}
+
 // Test helper methods
 
 // SetMountError sets an error to return on Mount operations
@@ -212,6 +292,14 @@ func (m *MockMounter) SetFormatError(err error) {
 	m.formatErr = err
 }
 
+// SetFilesystemErrors simulates the kernel remounting the filesystem at path
+// read-only and/or ext4 recording errorCount errors
+func (m *MockMounter) SetFilesystemErrors(path string, readOnlyRemount bool, errorCount int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fsErrors[path] = fsErrorState{readOnlyRemount: readOnlyRemount, errorCount: errorCount}
+}
+
 // ClearErrors clears all error injection
 func (m *MockMounter) ClearErrors() {
 	m.mu.Lock()
@@ -219,6 +307,7 @@ func (m *MockMounter) ClearErrors() {
 	m.mountErr = nil
 	m.unmountErr = nil
 	m.formatErr = nil
+	m.fsErrors = make(map[string]fsErrorState)
 }
 
 // GetMountCalls returns the history of Mount calls
@@ -239,6 +328,15 @@ func (m *MockMounter) GetUnmountCalls() []string {
 	return calls
 }
 
+// GetTrimCalls returns the history of Trim calls
+func (m *MockMounter) GetTrimCalls() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	calls := make([]string, len(m.trimCalls))
+	copy(calls, m.trimCalls)
+	return calls
+}
+
 // IsMounted checks if a path is currently mounted
 func (m *MockMounter) IsMounted(path string) bool {
 	m.mu.RLock()
@@ -266,8 +364,14 @@ func (m *MockMounter) Reset() {
 	defer m.mu.Unlock()
 	m.mounted = make(map[string]string)
 	m.formatted = make(map[string]string)
+	m.fsUUIDs = make(map[string]string)
 	m.mountCalls = nil
 	m.unmountCalls = nil
 	m.formatCalls = nil
-	m.ClearErrors()
+	m.trimCalls = nil
+	m.mountErr = nil
+	m.unmountErr = nil
+	m.formatErr = nil
+	m.fsErrors = make(map[string]fsErrorState)
+	m.quotas = nil
 }
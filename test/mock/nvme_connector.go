@@ -121,7 +121,10 @@ func (m *MockNVMEConnector) Reset() {
 	m.deviceCounter = 0
 	m.connectCalls = nil
 	m.disconnectCalls = nil
-	m.ClearErrors()
+	m.connectErr = nil
+	m.disconnectErr = nil
+	m.getDevicePathErr = nil
+	m.persistentErr = nil
 }
 
 // checkError checks for pending errors (internal helper)
@@ -5,6 +5,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net"
 	"regexp"
@@ -14,23 +15,32 @@ import (
 
 	"golang.org/x/crypto/ssh"
 	"k8s.io/klog/v2"
+
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
 )
 
 // MockRDSServer simulates a MikroTik RDS server for testing
 type MockRDSServer struct {
-	address        string
-	port           int
-	listener       net.Listener
-	sshConfig      *ssh.ServerConfig
-	config         MockRDSConfig
-	timing         *TimingSimulator
-	errorInjector  *ErrorInjector
-	volumes        map[string]*MockVolume   // Disk objects indexed by slot
-	snapshots      map[string]*MockSnapshot // Snapshot disk entries indexed by slot
-	files          map[string]*MockFile     // Files indexed by path
-	commandHistory []CommandLog             // Command execution history for debugging
-	mu             sync.RWMutex
-	shutdown       chan struct{}
+	address         string
+	port            int
+	listener        net.Listener
+	sshConfig       *ssh.ServerConfig
+	config          MockRDSConfig
+	timing          *TimingSimulator
+	errorInjector   *ErrorInjector
+	volumes         map[string]*MockVolume   // Disk objects indexed by slot
+	snapshots       map[string]*MockSnapshot // Snapshot disk entries indexed by slot
+	files           map[string]*MockFile     // Files indexed by path
+	failRemoveSlots map[string]bool          // Slots whose /disk remove fails (test hook)
+	noSourceFilter  bool                     // Reject source-volume= in /disk print where (test hook)
+	allocate        func(int64) int64        // Size allocated for a requested file-size (test hook)
+	commandHistory  []CommandLog             // Command execution history for debugging
+	mu              sync.RWMutex
+	shutdown        chan struct{}
+
+	// connMu guards listener and conns, the open client connections Restart drops
+	connMu sync.Mutex
+	conns  map[net.Conn]struct{}
 }
 
 // CommandLog represents a single command execution record
@@ -49,6 +59,13 @@ type MockVolume struct {
 	NVMETCPPort   int
 	NVMETCPNQN    string
 	Exported      bool
+	Comment       string
+	ReadyAt       time.Time // Until then the disk is still being created (see MockRDSConfig.CreationSettleMs)
+
+	// IO limits in operations and bytes per second (0 = unlimited)
+	MaxReadIOPS  int64
+	MaxWriteIOPS int64
+	MaxBandwidth int64
 }
 
 // MockFile represents a file on the mock RDS filesystem
@@ -68,13 +85,18 @@ type MockSnapshot struct {
 	FilePath      string    // Backing file path (e.g., /storage-pool/metal-csi/snap-xxx.img)
 	FileSizeBytes int64     // Size copied from source volume
 	CreatedAt     time.Time // Creation timestamp
+	Comment       string    // Disk comment (set via comment="...")
 }
 
 // NewMockRDSServer creates a new mock RDS server for testing
 func NewMockRDSServer(port int) (*MockRDSServer, error) {
 	// Load configuration from environment
-	config := LoadConfigFromEnv()
+	return NewMockRDSServerWithConfig(port, LoadConfigFromEnv())
+}
 
+// NewMockRDSServerWithConfig creates a new mock RDS server with explicit configuration.
+// Use this when a test needs specific timing or error behavior regardless of environment.
+func NewMockRDSServerWithConfig(port int, config MockRDSConfig) (*MockRDSServer, error) {
 	// Create SSH server config
 	sshConfig := &ssh.ServerConfig{
 		NoClientAuth: true, // Simplified for testing
@@ -88,17 +110,19 @@ func NewMockRDSServer(port int) (*MockRDSServer, error) {
 	sshConfig.AddHostKey(hostKey)
 
 	server := &MockRDSServer{
-		address:        "127.0.0.1",
-		port:           port,
-		sshConfig:      sshConfig,
-		config:         config,
-		timing:         NewTimingSimulator(config),
-		errorInjector:  NewErrorInjector(config),
-		volumes:        make(map[string]*MockVolume),
-		snapshots:      make(map[string]*MockSnapshot),
-		files:          make(map[string]*MockFile),
-		commandHistory: make([]CommandLog, 0),
-		shutdown:       make(chan struct{}),
+		address:         "127.0.0.1",
+		port:            port,
+		sshConfig:       sshConfig,
+		config:          config,
+		timing:          NewTimingSimulator(config),
+		errorInjector:   NewErrorInjector(config),
+		volumes:         make(map[string]*MockVolume),
+		snapshots:       make(map[string]*MockSnapshot),
+		files:           make(map[string]*MockFile),
+		failRemoveSlots: make(map[string]bool),
+		commandHistory:  make([]CommandLog, 0),
+		shutdown:        make(chan struct{}),
+		conns:           make(map[net.Conn]struct{}),
 	}
 
 	return server, nil
@@ -112,7 +136,9 @@ func (s *MockRDSServer) Start() error {
 		return fmt.Errorf("failed to listen on %s: %w", addr, err)
 	}
 
+	s.connMu.Lock()
 	s.listener = listener
+	s.connMu.Unlock()
 
 	// Update port if it was 0 (random port assignment)
 	if s.port == 0 {
@@ -123,7 +149,7 @@ func (s *MockRDSServer) Start() error {
 
 	klog.Infof("Mock RDS server listening on %s:%d", s.address, s.port)
 
-	go s.acceptConnections()
+	go s.acceptConnections(listener)
 
 	return nil
 }
@@ -131,12 +157,53 @@ func (s *MockRDSServer) Start() error {
 // Stop stops the mock RDS server
 func (s *MockRDSServer) Stop() error {
 	close(s.shutdown)
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
 	if s.listener != nil {
 		return s.listener.Close()
 	}
 	return nil
 }
 
+// Restart simulates an RDS reboot, e.g. for a firmware upgrade: it drops all SSH
+// connections, refuses new ones for downtime and then listens on the same port again.
+// Volumes, snapshots and files survive the restart like the disks of a real RDS; a
+// command still running when its connection drops completes without a response.
+// Restart returns once the server accepts connections again.
+func (s *MockRDSServer) Restart(downtime time.Duration) error {
+	s.connMu.Lock()
+	listener := s.listener
+	s.listener = nil
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connMu.Unlock()
+	if listener == nil {
+		return fmt.Errorf("mock RDS server is not running")
+	}
+	_ = listener.Close()
+	klog.Infof("Mock RDS server restarting, down for %s", downtime)
+
+	select {
+	case <-s.shutdown:
+		return fmt.Errorf("mock RDS server stopped during restart")
+	case <-time.After(downtime):
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.address, s.port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s after restart: %w", addr, err)
+	}
+	s.connMu.Lock()
+	s.listener = listener
+	s.connMu.Unlock()
+
+	klog.Infof("Mock RDS server back up on %s", addr)
+	go s.acceptConnections(listener)
+	return nil
+}
+
 // Address returns the server address
 func (s *MockRDSServer) Address() string {
 	return s.address
@@ -204,6 +271,84 @@ func (s *MockRDSServer) ListSnapshots() []*MockSnapshot {
 	return snapshots
 }
 
+// SetCreationSettle makes disks created by /disk add report ready only after delay.
+// Until then they show status "formatting", or are missing from /disk print if hidden is set.
+func (s *MockRDSServer) SetCreationSettle(delay time.Duration, hidden bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.CreationSettleMs = int(delay / time.Millisecond)
+	s.config.CreationHidden = hidden
+}
+
+// SetDiskRemoveFailure makes /disk remove of slot fail, or succeed again when fail is false
+func (s *MockRDSServer) SetDiskRemoveFailure(slot string, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fail {
+		s.failRemoveSlots[slot] = true
+	} else {
+		delete(s.failRemoveSlots, slot)
+	}
+}
+
+// SetSourceVolumeFilterSupported controls whether /disk print where accepts source-volume=
+// and print detail shows it, to simulate RouterOS versions without the property
+func (s *MockRDSServer) SetSourceVolumeFilterSupported(supported bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noSourceFilter = !supported
+}
+
+// SetFileSizeAllocation makes /disk add and /disk set give files allocate(requested) bytes
+// instead of the file-size they were asked for, as an allocator rounding sizes would; nil
+// allocates the requested size again
+func (s *MockRDSServer) SetFileSizeAllocation(allocate func(requestedBytes int64) int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allocate = allocate
+}
+
+// allocatedSize returns the size a file requested with size bytes gets. Caller must hold s.mu.
+func (s *MockRDSServer) allocatedSize(size int64) int64 {
+	if s.allocate == nil {
+		return size
+	}
+	return s.allocate(size)
+}
+
+// SetSSHAlgorithms restricts the key exchanges, ciphers and MACs the server accepts in
+// the handshake; nil keeps the x/crypto/ssh defaults. Must be called before Start.
+func (s *MockRDSServer) SetSSHAlgorithms(keyExchanges, ciphers, macs []string) {
+	s.sshConfig.KeyExchanges = keyExchanges
+	s.sshConfig.Ciphers = ciphers
+	s.sshConfig.MACs = macs
+}
+
+// SetRouterOSVersion sets the version reported by /system resource print
+func (s *MockRDSServer) SetRouterOSVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.RouterOSVersion = version
+}
+
+// SetDecoratedOutput makes command output look like a colored RouterOS console on an
+// 80-column terminal (ANSI colors, CRLF line endings, wrapped lines)
+func (s *MockRDSServer) SetDecoratedOutput(decorated bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.DecoratedOutput = decorated
+}
+
+// volumeSettling reports whether a volume is still being created. Caller must hold s.mu.
+func (s *MockRDSServer) volumeSettling(vol *MockVolume) bool {
+	return time.Now().Before(vol.ReadyAt)
+}
+
+// volumeHidden reports whether a volume is omitted from /disk print. Caller must hold s.mu.
+func (s *MockRDSServer) volumeHidden(vol *MockVolume) bool {
+	return s.config.CreationHidden && s.volumeSettling(vol)
+}
+
 // CreateOrphanedFile creates a file without a corresponding disk object (for testing)
 func (s *MockRDSServer) CreateOrphanedFile(path string, sizeBytes int64) {
 	s.mu.Lock()
@@ -216,6 +361,20 @@ func (s *MockRDSServer) CreateOrphanedFile(path string, sizeBytes int64) {
 	}
 }
 
+// RenameVolume renames a volume's slot, as an administrator might on RDS (for testing)
+func (s *MockRDSServer) RenameVolume(slot, newSlot string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vol, ok := s.volumes[slot]
+	if !ok {
+		return false
+	}
+	delete(s.volumes, slot)
+	vol.Slot = newSlot
+	s.volumes[newSlot] = vol
+	return true
+}
+
 // CreateOrphanedVolume creates a disk object without a file (for testing)
 func (s *MockRDSServer) CreateOrphanedVolume(slot, filePath string, sizeBytes int64) {
 	s.mu.Lock()
@@ -271,30 +430,49 @@ func (s *MockRDSServer) SetErrorMode(mode ErrorMode) {
 	s.errorInjector.SetErrorMode(mode)
 }
 
-func (s *MockRDSServer) acceptConnections() {
+func (s *MockRDSServer) acceptConnections(listener net.Listener) {
 	for {
 		select {
 		case <-s.shutdown:
 			return
 		default:
-			conn, err := s.listener.Accept()
+			conn, err := listener.Accept()
 			if err != nil {
 				select {
 				case <-s.shutdown:
 					return
 				default:
+					if errors.Is(err, net.ErrClosed) {
+						// Closed by Restart
+						return
+					}
 					klog.Errorf("Failed to accept connection: %v", err)
 					continue
 				}
 			}
 
+			s.connMu.Lock()
+			if s.listener != listener {
+				// Accepted while Restart was closing the listener
+				s.connMu.Unlock()
+				_ = conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.connMu.Unlock()
+
 			go s.handleConnection(conn)
 		}
 	}
 }
 
 func (s *MockRDSServer) handleConnection(conn net.Conn) {
-	defer func() { _ = conn.Close() }()
+	defer func() {
+		s.connMu.Lock()
+		delete(s.conns, conn)
+		s.connMu.Unlock()
+		_ = conn.Close()
+	}()
 
 	// Perform SSH handshake
 	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.sshConfig)
@@ -322,11 +500,11 @@ func (s *MockRDSServer) handleConnection(conn net.Conn) {
 			continue
 		}
 
-		go s.handleSession(channel, requests)
+		go s.handleSession(channel, requests, sshConn)
 	}
 }
 
-func (s *MockRDSServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+func (s *MockRDSServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request, conn ssh.Conn) {
 	defer func() { _ = channel.Close() }()
 
 	// Simulate SSH latency at session start
@@ -350,6 +528,17 @@ func (s *MockRDSServer) handleSession(channel ssh.Channel, requests <-chan *ssh.
 
 					// Execute the command and get response
 					response, exitStatus := s.executeCommand(command)
+					if s.errorInjector.ShouldDropAfterCommand(command) {
+						klog.Infof("Mock RDS dropping connection after command: %s", command)
+						_ = conn.Close()
+						return
+					}
+					s.mu.RLock()
+					decorated := s.config.DecoratedOutput
+					s.mu.RUnlock()
+					if decorated {
+						response = decorateOutput(response)
+					}
 
 					// Send response
 					if response != "" {
@@ -396,6 +585,10 @@ func (s *MockRDSServer) executeCommand(command string) (string, int) {
 		// Parse /disk remove command
 		output, exitCode = s.handleDiskRemove(command)
 		klog.V(3).Infof("Mock RDS /disk remove returned code %d", exitCode)
+	} else if strings.HasPrefix(command, ":foreach") {
+		// Parse the batch /disk remove script
+		output, exitCode = s.handleDiskRemoveBatch(command)
+		klog.V(3).Infof("Mock RDS batch /disk remove returned code %d", exitCode)
 	} else if strings.HasPrefix(command, "/disk print detail") {
 		// Parse /disk print detail command
 		output, exitCode = s.handleDiskPrintDetail(command)
@@ -408,6 +601,9 @@ func (s *MockRDSServer) executeCommand(command string) (string, int) {
 		// Parse /file remove command
 		output, exitCode = s.handleFileRemove(command)
 		klog.V(3).Infof("Mock RDS /file remove returned code %d", exitCode)
+	} else if command == "/system resource print" {
+		output, exitCode = s.handleSystemResourcePrint()
+		klog.V(3).Infof("Mock RDS /system resource print returned code %d", exitCode)
 	} else {
 		klog.Warningf("Mock RDS: Unrecognized command: %s", command)
 		output = fmt.Sprintf("bad command name %s\n", command)
@@ -462,15 +658,33 @@ func (s *MockRDSServer) handleDiskAdd(command string) (string, int) {
 	fileSizeStr := extractParam(command, "file-size")
 	nvmePortStr := extractParam(command, "nvme-tcp-server-port")
 	nqn := extractParam(command, "nvme-tcp-server-nqn")
+	comment := extractQuotedParam(command, "comment")
+	qos, qosErr := s.extractQoS(command)
 
-	if slot == "" || filePath == "" || fileSizeStr == "" {
+	if slot == "" || filePath == "" {
 		return "failure: missing required parameters\n", 1
 	}
+	if qosErr != "" {
+		return qosErr, 1
+	}
 
-	// Parse file size (supports formats like "1G", "50G", "1T", or raw bytes)
-	fileSize, err := parseSize(fileSizeStr)
-	if err != nil {
-		return fmt.Sprintf("failure: invalid file size %s: %v\n", fileSizeStr, err), 1
+	// Without file-size, RouterOS exports an existing file as it is
+	var fileSize int64
+	if fileSizeStr == "" {
+		s.mu.Lock()
+		existing, exists := s.files[filePath]
+		s.mu.Unlock()
+		if !exists {
+			return "failure: missing required parameters\n", 1
+		}
+		fileSize = existing.SizeBytes
+	} else {
+		// Parse file size (supports formats like "1G", "50G", "1T", or raw bytes)
+		parsed, err := parseSize(fileSizeStr)
+		if err != nil {
+			return fmt.Sprintf("failure: invalid file size %s: %v\n", fileSizeStr, err), 1
+		}
+		fileSize = parsed
 	}
 
 	var nvmePort int
@@ -490,6 +704,9 @@ func (s *MockRDSServer) handleDiskAdd(command string) (string, int) {
 	if _, exists := s.volumes[slot]; exists {
 		return "failure: volume already exists\n", 1
 	}
+	if fileSizeStr != "" {
+		fileSize = s.allocatedSize(fileSize)
+	}
 
 	// Create volume
 	s.volumes[slot] = &MockVolume{
@@ -499,14 +716,21 @@ func (s *MockRDSServer) handleDiskAdd(command string) (string, int) {
 		NVMETCPPort:   nvmePort,
 		NVMETCPNQN:    nqn,
 		Exported:      true,
+		Comment:       comment,
+		ReadyAt:       time.Now().Add(time.Duration(s.config.CreationSettleMs) * time.Millisecond),
+		MaxReadIOPS:   qos.MaxReadIOPS,
+		MaxWriteIOPS:  qos.MaxWriteIOPS,
+		MaxBandwidth:  qos.MaxBandwidthBytes,
 	}
 
 	// Also create the backing file (simulating real RDS behavior)
-	s.files[filePath] = &MockFile{
-		Path:      filePath,
-		SizeBytes: fileSize,
-		Type:      ".img",
-		CreatedAt: "2025-11-11 12:00:00",
+	if fileSizeStr != "" {
+		s.files[filePath] = &MockFile{
+			Path:      filePath,
+			SizeBytes: fileSize,
+			Type:      ".img",
+			CreatedAt: "2025-11-11 12:00:00",
+		}
 	}
 
 	klog.V(2).Infof("Mock RDS: Created volume %s with backing file %s", slot, filePath)
@@ -546,10 +770,15 @@ func (s *MockRDSServer) handleDiskAddCopyFrom(command string) (string, int) {
 	}
 
 	filePath := extractParam(command, "file-path")
+	comment := extractQuotedParam(command, "comment")
+	qos, qosErr := s.extractQoS(command)
 
 	if slot == "" || filePath == "" {
 		return "failure: missing required parameters\n", 1
 	}
+	if qosErr != "" {
+		return qosErr, 1
+	}
 
 	// Simulate disk operation delay BEFORE state modification
 	s.timing.SimulateDiskOperation("add")
@@ -601,6 +830,10 @@ func (s *MockRDSServer) handleDiskAddCopyFrom(command string) (string, int) {
 			NVMETCPPort:   nvmePort,
 			NVMETCPNQN:    nqn,
 			Exported:      true,
+			Comment:       comment,
+			MaxReadIOPS:   qos.MaxReadIOPS,
+			MaxWriteIOPS:  qos.MaxWriteIOPS,
+			MaxBandwidth:  qos.MaxBandwidthBytes,
 		}
 		s.files[filePath] = &MockFile{
 			Path:      filePath,
@@ -624,6 +857,7 @@ func (s *MockRDSServer) handleDiskAddCopyFrom(command string) (string, int) {
 		FilePath:      filePath,
 		FileSizeBytes: sourceSize,
 		CreatedAt:     time.Now(),
+		Comment:       comment,
 	}
 
 	// Also create backing file entry
@@ -648,6 +882,17 @@ func (s *MockRDSServer) handleDiskSet(command string) (string, int) {
 	}
 
 	slot := matches[1]
+
+	// Parse: /disk set [find slot=pvc-123] comment="ns/pvc-name"
+	if strings.Contains(command, "comment=") {
+		return s.handleDiskSetComment(slot, extractQuotedParam(command, "comment"))
+	}
+
+	// Parse: /disk set [find slot=pvc-123] max-read-iops=5000 max-bandwidth=104857600
+	if strings.Contains(command, " max-") {
+		return s.handleDiskSetQoS(slot, command)
+	}
+
 	fileSizeStr := extractParam(command, "file-size")
 
 	if fileSizeStr == "" {
@@ -673,6 +918,7 @@ func (s *MockRDSServer) handleDiskSet(command string) (string, int) {
 
 	// Update volume size
 	oldSize := vol.FileSizeBytes
+	newSize = s.allocatedSize(newSize)
 	vol.FileSizeBytes = newSize
 
 	// Also update backing file size if it exists
@@ -686,6 +932,80 @@ func (s *MockRDSServer) handleDiskSet(command string) (string, int) {
 	return "", 0
 }
 
+// handleDiskSetQoS changes the IO limits of a volume disk entry
+func (s *MockRDSServer) handleDiskSetQoS(slot, command string) (string, int) {
+	qos, qosErr := s.extractQoS(command)
+	if qosErr != "" {
+		return qosErr, 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vol, exists := s.volumes[slot]
+	if !exists {
+		return "failure: no such item\n", 1
+	}
+	// Only the limits given on the command line change
+	if strings.Contains(command, "max-read-iops=") {
+		vol.MaxReadIOPS = qos.MaxReadIOPS
+	}
+	if strings.Contains(command, "max-write-iops=") {
+		vol.MaxWriteIOPS = qos.MaxWriteIOPS
+	}
+	if strings.Contains(command, "max-bandwidth=") {
+		vol.MaxBandwidth = qos.MaxBandwidthBytes
+	}
+	return "", 0
+}
+
+// extractQoS parses the max-read-iops, max-write-iops and max-bandwidth arguments. When
+// simulating a RouterOS version without IO limits, it fails like RouterOS does on an
+// unknown property and returns the error output.
+func (s *MockRDSServer) extractQoS(command string) (rds.VolumeQoS, string) {
+	var qos rds.VolumeQoS
+	for param, value := range map[string]*int64{
+		"max-read-iops":  &qos.MaxReadIOPS,
+		"max-write-iops": &qos.MaxWriteIOPS,
+		"max-bandwidth":  &qos.MaxBandwidthBytes,
+	} {
+		raw := extractParam(command, param)
+		if raw == "" {
+			continue
+		}
+		if _, err := fmt.Sscanf(raw, "%d", value); err != nil {
+			return qos, fmt.Sprintf("failure: invalid value for argument %s\n", param)
+		}
+	}
+	if !qos.IsSet() {
+		return qos, ""
+	}
+
+	s.mu.RLock()
+	version := s.config.RouterOSVersion
+	s.mu.RUnlock()
+	if !rds.SupportsVolumeQoS(version) {
+		return qos, fmt.Sprintf("expected end of command (line 1 column %d)\n", strings.Index(command, " max-")+2)
+	}
+	return qos, ""
+}
+
+// handleDiskSetComment sets the comment on a volume or snapshot disk entry
+func (s *MockRDSServer) handleDiskSetComment(slot, comment string) (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if vol, exists := s.volumes[slot]; exists {
+		vol.Comment = comment
+		return "", 0
+	}
+	if snap, exists := s.snapshots[slot]; exists {
+		snap.Comment = comment
+		return "", 0
+	}
+	return "failure: no such item\n", 1
+}
+
 func (s *MockRDSServer) handleDiskRemove(command string) (string, int) {
 	// Check error injection BEFORE normal processing
 	if shouldFail, errMsg := s.errorInjector.ShouldFailDiskRemove(); shouldFail {
@@ -709,6 +1029,49 @@ func (s *MockRDSServer) handleDiskRemove(command string) (string, int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.failRemoveSlots[slot] {
+		return "failure: execution error\n", 1
+	}
+	s.removeSlotLocked(slot)
+	return "", 0
+}
+
+// handleDiskRemoveBatch runs the script DeleteVolumes uses to remove several slots:
+// :foreach s in={"pvc-1";"pvc-2"} do={:do {/disk remove [find slot=$s]; :put "removed $s"} on-error={:put "failed $s"}}
+func (s *MockRDSServer) handleDiskRemoveBatch(command string) (string, int) {
+	re := regexp.MustCompile(`^:foreach s in=\{([^}]*)\} do=\{:do \{/disk remove \[find slot=\$s\]`)
+	matches := re.FindStringSubmatch(command)
+	if len(matches) < 2 {
+		return "failure: invalid command format\n", 1
+	}
+
+	var output strings.Builder
+	for _, quoted := range strings.Split(matches[1], ";") {
+		slot := strings.Trim(quoted, `"`)
+
+		// Each slot fails on its own, like the :do on-error in the script
+		if shouldFail, errMsg := s.errorInjector.ShouldFailDiskRemove(); shouldFail {
+			klog.V(2).Infof("MOCK ERROR INJECTION: Disk remove of %s failed - %s", slot, strings.TrimSpace(errMsg))
+			fmt.Fprintf(&output, "failed %s\n", slot)
+			continue
+		}
+		s.timing.SimulateDiskOperation("remove")
+
+		s.mu.Lock()
+		if s.failRemoveSlots[slot] {
+			fmt.Fprintf(&output, "failed %s\n", slot)
+		} else {
+			s.removeSlotLocked(slot)
+			fmt.Fprintf(&output, "removed %s\n", slot)
+		}
+		s.mu.Unlock()
+	}
+	return output.String(), 0
+}
+
+// removeSlotLocked removes a volume or snapshot disk and its backing file. Removing a
+// slot that does not exist is a no-op, as /disk remove [find ...] is. Caller must hold s.mu.
+func (s *MockRDSServer) removeSlotLocked(slot string) {
 	// Check volumes map first
 	if vol, exists := s.volumes[slot]; exists {
 		delete(s.volumes, slot)
@@ -722,7 +1085,7 @@ func (s *MockRDSServer) handleDiskRemove(command string) (string, int) {
 		} else {
 			klog.V(2).Infof("Mock RDS: Deleted volume %s (no backing file)", slot)
 		}
-		return "", 0
+		return
 	}
 
 	// Check snapshots map
@@ -736,11 +1099,10 @@ func (s *MockRDSServer) handleDiskRemove(command string) (string, int) {
 		} else {
 			klog.V(2).Infof("Mock RDS: Deleted snapshot %s (no backing file)", slot)
 		}
-		return "", 0
+		return
 	}
 
 	// Idempotent - not an error if slot exists in neither volumes nor snapshots
-	return "", 0
 }
 
 func (s *MockRDSServer) handleDiskPrintDetail(command string) (string, int) {
@@ -756,21 +1118,29 @@ func (s *MockRDSServer) handleDiskPrintDetail(command string) (string, int) {
 	defer s.mu.RUnlock()
 
 	// Check for slot~ pattern query (prefix/substring match, RouterOS wildcard syntax)
-	// Format: slot~"prefix" — matches slots that contain the pattern
+	// Format: slot~"prefix" — matches slots that contain the pattern, optionally
+	// narrowed with: and source-volume="<slot>" (only snapshots carry a source volume)
 	if strings.Contains(command, "slot~") {
 		slotPatternRe := regexp.MustCompile(`slot~"([^"]+)"`)
 		if matches := slotPatternRe.FindStringSubmatch(command); len(matches) >= 2 {
 			pattern := matches[1]
+			source, bySource := "", false
+			if m := regexp.MustCompile(`and source-volume="([^"]*)"`).FindStringSubmatch(command); m != nil {
+				if s.noSourceFilter {
+					return fmt.Sprintf("expected end of command (line 1 column %d)\n", strings.Index(command, "source-volume")+1), 1
+				}
+				source, bySource = m[1], true
+			}
 			var output strings.Builder
 			i := 0
 			for _, vol := range s.volumes {
-				if strings.Contains(vol.Slot, pattern) {
+				if strings.Contains(vol.Slot, pattern) && !s.volumeHidden(vol) && !bySource {
 					output.WriteString(fmt.Sprintf("%2d %s\n", i, s.formatDiskDetail(vol)))
 					i++
 				}
 			}
 			for _, snap := range s.snapshots {
-				if strings.Contains(snap.Slot, pattern) {
+				if strings.Contains(snap.Slot, pattern) && (!bySource || snap.SourceVolume == source) {
 					output.WriteString(fmt.Sprintf("%2d %s\n", i, s.formatSnapshotDetail(snap)))
 					i++
 				}
@@ -779,19 +1149,43 @@ func (s *MockRDSServer) handleDiskPrintDetail(command string) (string, int) {
 		}
 	}
 
-	// Check for exact slot= query
+	// Check for a file-path= query, which matches whatever slot the file is attached as
+	if m := regexp.MustCompile(`file-path="([^"]+)"`).FindStringSubmatch(command); m != nil {
+		for _, vol := range s.volumes {
+			if vol.FilePath == m[1] && !s.volumeHidden(vol) {
+				return s.formatDiskDetail(vol), 0
+			}
+		}
+		return "", 0
+	}
+
+	// Check for exact slot= query, or several joined with "or"
 	slot := ""
 	if strings.Contains(command, "slot=") {
 		re := regexp.MustCompile(`slot=([^\s]+)`)
-		matches := re.FindStringSubmatch(command)
-		if len(matches) >= 2 {
-			slot = matches[1]
+		matches := re.FindAllStringSubmatch(command, -1)
+		if len(matches) > 1 {
+			var output strings.Builder
+			i := 0
+			for _, match := range matches {
+				if vol, exists := s.volumes[match[1]]; exists && !s.volumeHidden(vol) {
+					output.WriteString(fmt.Sprintf("%2d %s\n", i, s.formatDiskDetail(vol)))
+					i++
+				} else if snap, exists := s.snapshots[match[1]]; exists {
+					output.WriteString(fmt.Sprintf("%2d %s\n", i, s.formatSnapshotDetail(snap)))
+					i++
+				}
+			}
+			return output.String(), 0
+		}
+		if len(matches) == 1 {
+			slot = matches[0][1]
 		}
 	}
 
 	if slot != "" {
 		// Check volumes first
-		if vol, exists := s.volumes[slot]; exists {
+		if vol, exists := s.volumes[slot]; exists && !s.volumeHidden(vol) {
 			return s.formatDiskDetail(vol), 0
 		}
 		// Check snapshots
@@ -805,6 +1199,9 @@ func (s *MockRDSServer) handleDiskPrintDetail(command string) (string, int) {
 	var output strings.Builder
 	i := 0
 	for _, vol := range s.volumes {
+		if s.volumeHidden(vol) {
+			continue
+		}
 		// RouterOS formats list output with line numbers
 		output.WriteString(fmt.Sprintf("%2d %s\n", i, s.formatDiskDetail(vol)))
 		i++
@@ -821,24 +1218,53 @@ func (s *MockRDSServer) formatDiskDetail(vol *MockVolume) string {
 	if vol.Exported {
 		exported = "yes"
 	}
+	status := "ready"
+	if s.volumeSettling(vol) {
+		status = "formatting"
+	}
+
+	// IO limits are only printed when set, like other optional RouterOS properties
+	var limits strings.Builder
+	if vol.MaxReadIOPS > 0 {
+		fmt.Fprintf(&limits, " max-read-iops=%d", vol.MaxReadIOPS)
+	}
+	if vol.MaxWriteIOPS > 0 {
+		fmt.Fprintf(&limits, " max-write-iops=%d", vol.MaxWriteIOPS)
+	}
+	if vol.MaxBandwidth > 0 {
+		fmt.Fprintf(&limits, " max-bandwidth=%d", vol.MaxBandwidth)
+	}
 
 	// Format as RouterOS key="value" pairs on a single line
-	return fmt.Sprintf(`slot="%s" type="file" file-path="%s" file-size=%d nvme-tcp-export=%s nvme-tcp-server-port=%d nvme-tcp-server-nqn="%s" status="ready"`,
-		vol.Slot, vol.FilePath, vol.FileSizeBytes, exported, vol.NVMETCPPort, vol.NVMETCPNQN)
+	return formatDiskComment(vol.Comment) + fmt.Sprintf(`slot="%s" type="file" file-path="%s" file-size=%d nvme-tcp-export=%s nvme-tcp-server-port=%d nvme-tcp-server-nqn="%s"%s status="%s"`,
+		vol.Slot, vol.FilePath, vol.FileSizeBytes, exported, vol.NVMETCPPort, vol.NVMETCPNQN, limits.String(), status)
+}
+
+// formatDiskComment renders a comment the way RouterOS print detail does: a ";;; "
+// line above the entry's properties, which continue on an indented line
+func formatDiskComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return fmt.Sprintf(";;; %s\n     ", comment)
 }
 
 // formatSnapshotDetail formats a snapshot disk entry for /disk print detail output.
 // Snapshots are NOT NVMe-exported — nvme-tcp-export, nvme-tcp-server-port, and
 // nvme-tcp-server-nqn fields are intentionally omitted.
-// source-volume is included so that parseSnapshotInfo can recover the original source
-// volume ID for CSI idempotency checks (without this, the parser would fall back to
-// extracting a hashed UUID from the slot name, which doesn't match the original ID).
+// source-volume is omitted when the source-volume filter is unsupported, like RouterOS
+// versions that have no such property; the client then reads the source volume from the
+// snapshot comment.
 func (s *MockRDSServer) formatSnapshotDetail(snap *MockSnapshot) string {
 	// Format creation time as RouterOS month/day/year format with lowercase month abbreviation.
 	// parseRouterOSTime expects e.g. "jan/02/2026 14:30:00" — title-cases the month internally.
 	creationTime := strings.ToLower(snap.CreatedAt.Format("Jan/02/2006 15:04:05"))
-	return fmt.Sprintf(`slot="%s" type="file" file-path="%s" file-size=%d source-volume="%s" creation-time=%s status="ready"`,
-		snap.Slot, snap.FilePath, snap.FileSizeBytes, snap.SourceVolume, creationTime)
+	sourceVolume := ""
+	if !s.noSourceFilter {
+		sourceVolume = fmt.Sprintf(` source-volume="%s"`, snap.SourceVolume)
+	}
+	return formatDiskComment(snap.Comment) + fmt.Sprintf(`slot="%s" type="file" file-path="%s" file-size=%d%s creation-time=%s status="ready"`,
+		snap.Slot, snap.FilePath, snap.FileSizeBytes, sourceVolume, creationTime)
 }
 
 func (s *MockRDSServer) handleFilePrintDetail(command string) (string, int) {
@@ -912,32 +1338,51 @@ func formatSizeWithUnits(bytes int64) string {
 	}
 }
 
+// handleSystemResourcePrint reports the configured RouterOS version in the RDS2216 layout
+func (s *MockRDSServer) handleSystemResourcePrint() (string, int) {
+	s.mu.RLock()
+	version := s.config.RouterOSVersion
+	s.mu.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%25s: %s\n", "uptime", "3d4h5m6s")
+	fmt.Fprintf(&b, "%25s: %s (stable)\n", "version", version)
+	fmt.Fprintf(&b, "%25s: %s\n", "cpu-count", "16")
+	fmt.Fprintf(&b, "%25s: %s\n", "board-name", "RDS2216")
+	fmt.Fprintf(&b, "%25s: %s\n", "architecture-name", "arm64")
+	fmt.Fprintf(&b, "%25s: %s\n", "platform", "MikroTik")
+	return b.String(), 0
+}
+
 func (s *MockRDSServer) handleFileRemove(command string) (string, int) {
 	// Parse: /file remove [find name="storage-pool/metal-csi/pvc-123.img"]
+	// or several names: /file remove [find where name="..." or name="..."]
 	re := regexp.MustCompile(`name="([^"]+)"`)
-	matches := re.FindStringSubmatch(command)
+	matches := re.FindAllStringSubmatch(command, -1)
 
-	if len(matches) < 2 {
+	if len(matches) == 0 {
 		return "failure: invalid command format\n", 1
 	}
 
-	// RouterOS file paths don't have leading slash, but we normalize to have it
-	filePath := matches[1]
-	if !strings.HasPrefix(filePath, "/") {
-		filePath = "/" + filePath
-	}
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.files[filePath]; !exists {
-		// Idempotent - not an error if file doesn't exist
-		klog.V(3).Infof("Mock RDS: File %s not found (idempotent)", filePath)
-		return "", 0
-	}
+	for _, match := range matches {
+		// RouterOS file paths don't have leading slash, but we normalize to have it
+		filePath := match[1]
+		if !strings.HasPrefix(filePath, "/") {
+			filePath = "/" + filePath
+		}
+
+		if _, exists := s.files[filePath]; !exists {
+			// Idempotent - not an error if file doesn't exist
+			klog.V(3).Infof("Mock RDS: File %s not found (idempotent)", filePath)
+			continue
+		}
 
-	delete(s.files, filePath)
-	klog.V(2).Infof("Mock RDS: Deleted file %s", filePath)
+		delete(s.files, filePath)
+		klog.V(2).Infof("Mock RDS: Deleted file %s", filePath)
+	}
 	return "", 0
 }
 
@@ -958,6 +1403,15 @@ func extractParam(command, param string) string {
 	return ""
 }
 
+// extractQuotedParam extracts a param="value" argument, falling back to an unquoted value
+func extractQuotedParam(command, param string) string {
+	re := regexp.MustCompile(param + `="([^"]*)"`)
+	if matches := re.FindStringSubmatch(command); len(matches) >= 2 {
+		return matches[1]
+	}
+	return extractParam(command, param)
+}
+
 // parseSize parses human-readable size strings like "1G", "50G", "1T" or raw bytes
 func parseSize(sizeStr string) (int64, error) {
 	// Parse with suffix (K, M, G, T) first
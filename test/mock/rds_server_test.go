@@ -1,12 +1,20 @@
 package mock
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"git.srvlab.io/whiskey/rds-csi-driver/pkg/observability"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/rds"
 	"git.srvlab.io/whiskey/rds-csi-driver/pkg/utils"
 )
@@ -24,6 +32,7 @@ func TestLoadConfigFromEnv_Defaults(t *testing.T) {
 	os.Unsetenv("MOCK_RDS_ENABLE_HISTORY")
 	os.Unsetenv("MOCK_RDS_HISTORY_DEPTH")
 	os.Unsetenv("MOCK_RDS_ROUTEROS_VERSION")
+	os.Unsetenv("MOCK_RDS_DECORATED_OUTPUT")
 
 	config := LoadConfigFromEnv()
 
@@ -58,6 +67,9 @@ func TestLoadConfigFromEnv_Defaults(t *testing.T) {
 	if config.RouterOSVersion != "7.16" {
 		t.Errorf("expected RouterOSVersion=7.16, got %s", config.RouterOSVersion)
 	}
+	if config.DecoratedOutput != false {
+		t.Errorf("expected DecoratedOutput=false, got %v", config.DecoratedOutput)
+	}
 }
 
 // TestLoadConfigFromEnv_RealisticTiming tests realistic timing configuration
@@ -366,6 +378,7 @@ func TestParseErrorMode(t *testing.T) {
 		{"disk_full", ErrorModeDiskFull},
 		{"ssh_timeout", ErrorModeSSHTimeout},
 		{"command_fail", ErrorModeCommandFail},
+		{"drop_after_command", ErrorModeDropAfterCommand},
 		{"invalid", ErrorModeNone}, // Unknown defaults to none
 		{"INVALID", ErrorModeNone}, // Case sensitive
 	}
@@ -761,3 +774,889 @@ func TestMockRDS_SnapshotCopyFrom(t *testing.T) {
 		}
 	})
 }
+
+// TestMockRDS_DiskComments tests that disk comments round-trip through the SSH client and mock server
+func TestMockRDS_DiskComments(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	const slot = "pvc-c0c0c0c0-0000-0000-0000-000000000001"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+		Comment:       "databases/data-postgres-0",
+	}
+	if err := client.CreateVolume(opts); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	if mockVol, ok := server.GetVolume(slot); !ok || mockVol.Comment != opts.Comment {
+		t.Fatalf("expected server to store comment %q, got %+v", opts.Comment, mockVol)
+	}
+	volume, err := client.GetVolume(slot)
+	if err != nil {
+		t.Fatalf("GetVolume failed: %v", err)
+	}
+	if volume.Comment != opts.Comment || volume.Slot != slot || volume.NVMETCPNQN != opts.NVMETCPNQN {
+		t.Errorf("unexpected volume after round-trip: %+v", volume)
+	}
+
+	volumes, err := client.ListVolumes()
+	if err != nil {
+		t.Fatalf("ListVolumes failed: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].Comment != opts.Comment {
+		t.Errorf("ListVolumes did not return the comment: %+v", volumes)
+	}
+
+	if err := client.SetDiskComment(slot, "databases/data-postgres-1"); err != nil {
+		t.Fatalf("SetDiskComment failed: %v", err)
+	}
+	if volume, _ := client.GetVolume(slot); volume.Comment != "databases/data-postgres-1" {
+		t.Errorf("expected updated comment, got %q", volume.Comment)
+	}
+
+	// Comments that could break out of comment="..." never reach RDS
+	server.ClearCommandHistory()
+	for _, comment := range []string{`x" slot=evil`, "x; /system reboot", "x\n/disk remove 0", "$(x)"} {
+		if err := client.SetDiskComment(slot, comment); err == nil {
+			t.Errorf("SetDiskComment(%q) should fail", comment)
+		}
+		injected := opts
+		injected.Slot = "pvc-c0c0c0c0-0000-0000-0000-000000000002"
+		injected.Comment = comment
+		if err := client.CreateVolume(injected); err == nil {
+			t.Errorf("CreateVolume with comment %q should fail", comment)
+		}
+	}
+	if history := server.GetCommandHistory(); len(history) != 0 {
+		t.Errorf("expected no commands sent for unsafe comments, got %d", len(history))
+	}
+}
+
+func TestMockRDS_GetSystemInfo(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	info, err := client.GetSystemInfo()
+	if err != nil {
+		t.Fatalf("GetSystemInfo failed: %v", err)
+	}
+	if info.Version != "7.16" || info.Channel != "stable" || info.BoardName != "RDS2216" {
+		t.Errorf("unexpected system info: %+v", info)
+	}
+
+	server.SetRouterOSVersion("7.15.3")
+	info, err = client.GetSystemInfo()
+	if err != nil {
+		t.Fatalf("GetSystemInfo failed: %v", err)
+	}
+	issues, err := rds.CheckCompatibility(info.Version)
+	if err != nil {
+		t.Fatalf("CheckCompatibility(%q) failed: %v", info.Version, err)
+	}
+	if len(issues) == 0 {
+		t.Errorf("expected compatibility issues for RouterOS %s", info.Version)
+	}
+}
+
+func TestMockRDS_CreateVolumeQoS(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	const slot = "pvc-e0e0e0e0-0000-0000-0000-000000000001"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+		QoS:           rds.VolumeQoS{MaxReadIOPS: 5000, MaxBandwidthBytes: 100 * 1024 * 1024},
+	}
+
+	// The default simulated RouterOS 7.16 cannot enforce IO limits
+	err := client.CreateVolume(opts)
+	if !errors.Is(err, rds.ErrQoSUnsupported) {
+		t.Fatalf("expected ErrQoSUnsupported on RouterOS 7.16, got %v", err)
+	}
+	if _, exists := server.GetVolume(slot); exists {
+		t.Fatal("volume was created although IO limits are unsupported")
+	}
+
+	server.SetRouterOSVersion(rds.VolumeQoSMinVersion)
+	if err := client.CreateVolume(opts); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	volume, err := client.GetVolume(slot)
+	if err != nil {
+		t.Fatalf("GetVolume failed: %v", err)
+	}
+	if volume.QoS != opts.QoS {
+		t.Errorf("QoS = %+v, want %+v", volume.QoS, opts.QoS)
+	}
+
+	// A retry with the same limits is idempotent
+	if err := client.CreateVolume(opts); err != nil {
+		t.Errorf("retried CreateVolume failed: %v", err)
+	}
+}
+
+func TestMockRDS_AuditLog(t *testing.T) {
+	server, _, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := rds.NewAuditLog(rds.AuditConfig{Path: auditPath})
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+	client, err := rds.NewClient(rds.ClientConfig{
+		Address:            server.Address(),
+		Port:               server.Port(),
+		User:               "admin",
+		InsecureSkipVerify: true,
+		AuditLog:           audit,
+	})
+	if err != nil {
+		t.Fatalf("failed to create rds client: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect rds client: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	const slot = "pvc-f0f0f0f0-0000-0000-0000-000000000001"
+	filePath := fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot)
+	if err := client.CreateVolume(rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      filePath,
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+	}); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	call := rds.Call{Operation: "DeleteVolume", VolumeID: slot, RequestID: "req-delete"}
+	if err := rds.ForCall(client, call).DeleteVolume(slot); err != nil {
+		t.Fatalf("DeleteVolume failed: %v", err)
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	var entries []rds.AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry rds.AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid audit line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	// Only the mutating commands are recorded, not the verification and lookup prints.
+	// All commands issued through the call's view carry the call, the others none.
+	want := []struct{ command, operation, volumeID, requestID string }{
+		{"/disk add", "", "", ""},
+		{"/disk remove", "DeleteVolume", slot, "req-delete"},
+		{"/file remove", "DeleteVolume", slot, "req-delete"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d audit entries, got %d:\n%s", len(want), len(entries), data)
+	}
+	for i, w := range want {
+		entry := entries[i]
+		if !strings.HasPrefix(entry.Command, w.command) || entry.Operation != w.operation || entry.VolumeID != w.volumeID || entry.RequestID != w.requestID {
+			t.Errorf("entry %d = %q %s %q %q, want %s %s %q %q", i, entry.Command, entry.Operation, entry.VolumeID, entry.RequestID,
+				w.command, w.operation, w.volumeID, w.requestID)
+		}
+		if entry.Outcome != "success" || entry.Address != server.Address() || entry.Time.IsZero() {
+			t.Errorf("entry %d has unexpected fields: %+v", i, entry)
+		}
+	}
+	if !strings.Contains(entries[0].Command, "file-path="+filePath) {
+		t.Errorf("expected the full /disk add command, got %q", entries[0].Command)
+	}
+}
+
+func TestMockRDS_FindUnreferencedFiles(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	const slot = "pvc-d0d0d0d0-0000-0000-0000-000000000001"
+	if err := client.CreateVolume(rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+	}); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	snapName := utils.GenerateSnapshotID("housekeeping-snap", slot)
+	if _, err := client.CreateSnapshot(rds.CreateSnapshotOptions{
+		Name:         snapName,
+		SourceVolume: slot,
+		BasePath:     "/storage-pool/metal-csi",
+	}); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	// Leftovers of deleted volumes and snapshots, plus files housekeeping must never touch
+	server.CreateOrphanedFile("/storage-pool/metal-csi/pvc-d0d0d0d0-0000-0000-0000-000000000002.img", 2*1024*1024*1024)
+	server.CreateOrphanedFile("/storage-pool/metal-csi/snap-d0d0d0d0-0000-0000-0000-000000000003-at-1700000000.img", 1024*1024*1024)
+	server.CreateOrphanedFile("/storage-pool/metal-csi/.trash/pvc-d0d0d0d0-0000-0000-0000-000000000004-20260102T150405Z.img", 1024)
+	server.CreateOrphanedFile("/storage-pool/metal-csi/manual-backup.img", 1024)
+
+	files, err := client.FindUnreferencedFiles("/storage-pool/metal-csi")
+	if err != nil {
+		t.Fatalf("FindUnreferencedFiles failed: %v", err)
+	}
+
+	found := map[string]int64{}
+	for _, file := range files {
+		found[file.Path] = file.SizeBytes
+	}
+	want := map[string]int64{
+		"/storage-pool/metal-csi/pvc-d0d0d0d0-0000-0000-0000-000000000002.img":                2 * 1024 * 1024 * 1024,
+		"/storage-pool/metal-csi/snap-d0d0d0d0-0000-0000-0000-000000000003-at-1700000000.img": 1024 * 1024 * 1024,
+	}
+	if len(found) != len(want) {
+		t.Fatalf("FindUnreferencedFiles = %v, want %v", found, want)
+	}
+	for path, size := range want {
+		if found[path] != size {
+			t.Errorf("expected %s (%d bytes) to be unreferenced, got %v", path, size, found)
+		}
+	}
+
+	// Removing the reported files leaves the live volume and snapshot intact
+	for _, file := range files {
+		if err := client.DeleteFile(file.Path); err != nil {
+			t.Fatalf("DeleteFile(%s) failed: %v", file.Path, err)
+		}
+	}
+	if _, ok := server.GetFile(fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot)); !ok {
+		t.Error("volume backing file was removed")
+	}
+	snap, ok := server.GetSnapshot(snapName)
+	if !ok {
+		t.Fatal("snapshot disappeared")
+	}
+	if _, ok := server.GetFile(snap.FilePath); !ok {
+		t.Error("snapshot backing file was removed")
+	}
+	if remaining, err := client.FindUnreferencedFiles("/storage-pool/metal-csi"); err != nil || len(remaining) != 0 {
+		t.Errorf("expected no unreferenced files after cleanup, got %v, %v", remaining, err)
+	}
+}
+
+func TestMockRDS_CreateVolumeWaitsForAsyncCreation(t *testing.T) {
+	const slot = "pvc-e0e0e0e0-0000-0000-0000-000000000001"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+	}
+
+	for _, hidden := range []bool{false, true} {
+		t.Run(fmt.Sprintf("hidden=%v", hidden), func(t *testing.T) {
+			server, client, cleanup := setupSnapshotTestClient(t)
+			defer cleanup()
+			server.SetCreationSettle(300*time.Millisecond, hidden)
+
+			if err := client.CreateVolume(opts); err != nil {
+				t.Fatalf("CreateVolume failed: %v", err)
+			}
+
+			checks := 0
+			for _, entry := range server.GetCommandHistory() {
+				if strings.HasPrefix(entry.Command, "/disk print detail where slot="+slot) {
+					checks++
+				}
+			}
+			if checks < 2 {
+				t.Errorf("expected CreateVolume to poll until ready, got %d checks", checks)
+			}
+		})
+	}
+
+	t.Run("deadline", func(t *testing.T) {
+		server, _, cleanup := setupSnapshotTestClient(t)
+		defer cleanup()
+		server.SetCreationSettle(time.Minute, false)
+
+		client, err := rds.NewClient(rds.ClientConfig{
+			Address:            server.Address(),
+			Port:               server.Port(),
+			User:               "admin",
+			InsecureSkipVerify: true,
+			VolumeReadyTimeout: 500 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("failed to create rds client: %v", err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatalf("failed to connect rds client: %v", err)
+		}
+		defer func() { _ = client.Close() }()
+
+		err = client.CreateVolume(opts)
+		if !errors.Is(err, utils.ErrOperationTimeout) {
+			t.Errorf("expected ErrOperationTimeout, got %v", err)
+		}
+
+		// The slot created by the failed call is removed rather than leaked
+		if _, exists := server.GetVolume(slot); exists {
+			t.Error("slot of failed create was not removed")
+		}
+		if _, exists := server.GetFile(opts.FilePath); exists {
+			t.Error("backing file of failed create was not removed")
+		}
+	})
+}
+
+func TestMockRDS_CreateVolumeRetryAfterCreation(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	const slot = "pvc-e0e0e0e0-0000-0000-0000-000000000002"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+	}
+	// Simulates an earlier attempt whose /disk add reached RDS but whose response was lost
+	if err := client.CreateVolume(opts); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	// Retrying with the same parameters succeeds
+	if err := client.CreateVolume(opts); err != nil {
+		t.Errorf("retry with matching parameters should succeed, got %v", err)
+	}
+
+	// A retry that disagrees with the existing volume is a genuine conflict
+	conflicting := opts
+	conflicting.FileSizeBytes = 2 * 1024 * 1024 * 1024
+	err := client.CreateVolume(conflicting)
+	if !errors.Is(err, utils.ErrVolumeExists) {
+		t.Fatalf("expected ErrVolumeExists for conflicting retry, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "size") {
+		t.Errorf("expected conflict to name the size, got %v", err)
+	}
+	if vol, _ := server.GetVolume(slot); vol.FileSizeBytes != opts.FileSizeBytes {
+		t.Errorf("existing volume was modified: %+v", vol)
+	}
+
+	// "already exists" is not retried: one /disk add per CreateVolume call
+	adds := 0
+	for _, entry := range server.GetCommandHistory() {
+		if strings.HasPrefix(entry.Command, "/disk add") {
+			adds++
+		}
+	}
+	if adds != 3 {
+		t.Errorf("expected 3 /disk add commands, got %d", adds)
+	}
+}
+
+// countCommands returns how many commands of the server's history start with prefix
+func countCommands(server *MockRDSServer, prefix string) int {
+	count := 0
+	for _, entry := range server.GetCommandHistory() {
+		if strings.HasPrefix(entry.Command, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// TestMockRDS_DropAfterCommand tests that a command whose connection drops after it ran
+// is verified rather than blindly retried
+func TestMockRDS_DropAfterCommand(t *testing.T) {
+	const slot = "pvc-d0d0d0d0-0000-0000-0000-000000000001"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+	}
+
+	t.Run("create volume", func(t *testing.T) {
+		server, client, cleanup := setupSnapshotTestClient(t)
+		defer cleanup()
+		server.SetErrorMode(ErrorModeDropAfterCommand)
+
+		if err := client.CreateVolume(opts); err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		if _, exists := server.GetVolume(slot); !exists {
+			t.Error("volume was not created")
+		}
+		if adds := countCommands(server, "/disk add"); adds != 1 {
+			t.Errorf("expected the dropped /disk add to be verified, not rerun; got %d adds", adds)
+		}
+	})
+
+	t.Run("create snapshot", func(t *testing.T) {
+		server, client, cleanup := setupSnapshotTestClient(t)
+		defer cleanup()
+		if err := client.CreateVolume(opts); err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		server.SetErrorMode(ErrorModeDropAfterCommand)
+
+		snapName := utils.GenerateSnapshotID("drop-snap", slot)
+		if _, err := client.CreateSnapshot(rds.CreateSnapshotOptions{
+			Name:         snapName,
+			SourceVolume: slot,
+			BasePath:     "/storage-pool/metal-csi",
+		}); err != nil {
+			t.Fatalf("CreateSnapshot failed: %v", err)
+		}
+		if adds := countCommands(server, "/disk add type=file copy-from"); adds != 1 {
+			t.Errorf("expected one copy-from, got %d", adds)
+		}
+	})
+
+	t.Run("delete volume", func(t *testing.T) {
+		server, client, cleanup := setupSnapshotTestClient(t)
+		defer cleanup()
+		if err := client.CreateVolume(opts); err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		server.SetErrorMode(ErrorModeDropAfterCommand)
+
+		if err := client.DeleteVolume(slot); err != nil {
+			t.Fatalf("DeleteVolume failed: %v", err)
+		}
+		if _, exists := server.GetVolume(slot); exists {
+			t.Error("volume was not deleted")
+		}
+		if _, exists := server.GetFile(opts.FilePath); exists {
+			t.Error("backing file was not deleted")
+		}
+		if removes := countCommands(server, "/disk remove"); removes != 1 {
+			t.Errorf("expected one /disk remove, got %d", removes)
+		}
+	})
+
+	t.Run("idempotent command", func(t *testing.T) {
+		server, client, cleanup := setupSnapshotTestClient(t)
+		defer cleanup()
+		if err := client.CreateVolume(opts); err != nil {
+			t.Fatalf("CreateVolume failed: %v", err)
+		}
+		server.SetErrorMode(ErrorModeDropAfterCommand)
+
+		// Setting the comment again has the same result, so it is rerun without a check
+		if err := client.SetDiskComment(slot, "default/data"); err != nil {
+			t.Fatalf("SetDiskComment failed: %v", err)
+		}
+		if sets := countCommands(server, "/disk set"); sets != 2 {
+			t.Errorf("expected the dropped /disk set to be retried, got %d sets", sets)
+		}
+	})
+}
+
+func TestDecorateOutput(t *testing.T) {
+	plain := ";;; databases/data-postgres-0\n     slot=\"pvc-a\" type=\"file\" file-path=\"/storage-pool/metal-csi/" +
+		strings.Repeat("x", 60) + ".img\" file-size=1073741824 status=\"ready\"\n"
+	decorated := decorateOutput(plain)
+
+	if !strings.Contains(decorated, "\x1b[") {
+		t.Error("expected ANSI escapes in decorated output")
+	}
+	lines := strings.Split(strings.TrimSuffix(decorated, "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("expected the long line to wrap, got %q", decorated)
+	}
+	ansi := regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	var visible strings.Builder
+	for i, line := range lines {
+		line = ansi.ReplaceAllString(line, "")
+		if len(line) > decoratedWrapWidth {
+			t.Errorf("line %d is %d columns wide: %q", i, len(line), line)
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("continuation line %d is not indented: %q", i, line)
+		}
+		visible.WriteString(line)
+	}
+	// Only whitespace is added: wrapped text reads the same once indentation is removed
+	squash := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	if squash(visible.String()) != squash(plain) {
+		t.Errorf("decorated output changed the text:\n%q\n%q", visible.String(), plain)
+	}
+	if decorateOutput("") != "" {
+		t.Error("empty output should stay empty")
+	}
+}
+
+// TestMockRDS_DecoratedOutput runs the client against output with console colors,
+// CRLF line endings and 80-column wrapping
+func TestMockRDS_DecoratedOutput(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+	server.SetDecoratedOutput(true)
+
+	const slot = "pvc-d0d0d0d0-0000-0000-0000-000000000001"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+		Comment:       "databases/data-postgres-0",
+	}
+	if err := client.CreateVolume(opts); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	volume, err := client.GetVolume(slot)
+	if err != nil {
+		t.Fatalf("GetVolume failed: %v", err)
+	}
+	if volume.Slot != slot || volume.FilePath != opts.FilePath || volume.FileSizeBytes != opts.FileSizeBytes ||
+		volume.NVMETCPNQN != opts.NVMETCPNQN || volume.Comment != opts.Comment || len(volume.ParseWarnings) != 0 {
+		t.Errorf("unexpected volume parsed from decorated output: %+v", volume)
+	}
+
+	volumes, err := client.ListVolumes()
+	if err != nil {
+		t.Fatalf("ListVolumes failed: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].Slot != slot || volumes[0].FilePath != opts.FilePath {
+		t.Errorf("unexpected ListVolumes result: %+v", volumes)
+	}
+
+	if err := client.ResizeVolume(slot, 2*opts.FileSizeBytes); err != nil {
+		t.Fatalf("ResizeVolume failed: %v", err)
+	}
+	if volume, err := client.GetVolume(slot); err != nil || volume.FileSizeBytes != 2*opts.FileSizeBytes {
+		t.Errorf("expected resized volume, got %+v (err %v)", volume, err)
+	}
+
+	info, err := client.GetSystemInfo()
+	if err != nil || info.Version != "7.16" {
+		t.Errorf("unexpected system info: %+v (err %v)", info, err)
+	}
+
+	if err := client.DeleteVolume(slot); err != nil {
+		t.Fatalf("DeleteVolume failed: %v", err)
+	}
+	if _, err := client.GetVolume(slot); !errors.Is(err, utils.ErrVolumeNotFound) {
+		t.Errorf("expected ErrVolumeNotFound after delete, got %v", err)
+	}
+}
+
+func TestMockRDS_DeleteVolumesBatch(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	slotName := func(n int) string { return fmt.Sprintf("pvc-d0d0d0d0-0000-0000-0000-00000000000%d", n) }
+	for n := 1; n <= 3; n++ {
+		opts := rds.CreateVolumeOptions{
+			Slot:          slotName(n),
+			FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slotName(n)),
+			FileSizeBytes: 1024 * 1024 * 1024,
+			NVMETCPPort:   4420,
+			NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slotName(n)),
+		}
+		if err := client.CreateVolume(opts); err != nil {
+			t.Fatalf("CreateVolume(%s) failed: %v", opts.Slot, err)
+		}
+	}
+	server.SetDiskRemoveFailure(slotName(2), true)
+	server.ClearCommandHistory()
+
+	missing := slotName(9)
+	results := client.DeleteVolumes([]string{slotName(1), slotName(2), missing, slotName(3), "bad;slot"})
+
+	for _, slot := range []string{slotName(1), slotName(3)} {
+		if err := results[slot]; err != nil {
+			t.Errorf("%s: expected success, got %v", slot, err)
+		}
+		if _, exists := server.GetVolume(slot); exists {
+			t.Errorf("%s: volume still exists", slot)
+		}
+		if _, exists := server.GetFile(fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot)); exists {
+			t.Errorf("%s: backing file still exists", slot)
+		}
+	}
+	if err := results[missing]; err != nil {
+		t.Errorf("missing volume should be treated as deleted, got %v", err)
+	}
+	if err := results[slotName(2)]; err == nil || !strings.Contains(err.Error(), slotName(2)) {
+		t.Errorf("expected failure attributed to %s, got %v", slotName(2), err)
+	}
+	if _, exists := server.GetVolume(slotName(2)); !exists {
+		t.Error("volume whose removal failed should remain")
+	}
+	if results["bad;slot"] == nil {
+		t.Error("invalid slot name should fail")
+	}
+	if len(results) != 5 {
+		t.Errorf("expected an outcome for every slot, got %v", results)
+	}
+
+	// One listing, one removal script and one file removal for the whole batch
+	if history := server.GetCommandHistory(); len(history) != 3 {
+		t.Errorf("expected 3 commands, got %d: %+v", len(history), history)
+	}
+
+	// The failed slot deletes once RDS lets it
+	server.SetDiskRemoveFailure(slotName(2), false)
+	if err := client.DeleteVolumes([]string{slotName(2)})[slotName(2)]; err != nil {
+		t.Errorf("retry of %s failed: %v", slotName(2), err)
+	}
+	if _, exists := server.GetVolume(slotName(2)); exists {
+		t.Error("volume still exists after retry")
+	}
+}
+
+func TestMockRDS_ListSnapshotsBySource(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	busy := "pvc-b0b0b0b0-0000-0000-0000-000000000001"
+	quiet := "pvc-b0b0b0b0-0000-0000-0000-000000000002"
+	counts := map[string]int{busy: 30, quiet: 3}
+	for slot, count := range counts {
+		if err := client.CreateVolume(rds.CreateVolumeOptions{
+			Slot:          slot,
+			FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+			FileSizeBytes: 1024 * 1024 * 1024,
+			NVMETCPPort:   4420,
+			NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+		}); err != nil {
+			t.Fatalf("CreateVolume(%s) failed: %v", slot, err)
+		}
+		for i := 0; i < count; i++ {
+			if _, err := client.CreateSnapshot(rds.CreateSnapshotOptions{
+				Name:         utils.GenerateSnapshotID(fmt.Sprintf("%s-%d", slot, i), slot),
+				SourceVolume: slot,
+				BasePath:     "/storage-pool/metal-csi",
+			}); err != nil {
+				t.Fatalf("CreateSnapshot failed: %v", err)
+			}
+		}
+	}
+
+	// parsedRecords counts the disk entries RDS returned for the last command
+	parsedRecords := func() int {
+		history := server.GetCommandHistory()
+		return strings.Count(history[len(history)-1].Response, `slot="`)
+	}
+
+	all, err := client.ListSnapshots()
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	unfiltered := parsedRecords()
+
+	snapshots, err := client.ListSnapshotsBySource(quiet)
+	if err != nil {
+		t.Fatalf("ListSnapshotsBySource failed: %v", err)
+	}
+	filtered := parsedRecords()
+	t.Logf("records parsed: %d unfiltered, %d filtered by source", unfiltered, filtered)
+
+	if len(all) != 33 || unfiltered != 33 {
+		t.Errorf("expected 33 snapshots listed unfiltered, got %d (%d records)", len(all), unfiltered)
+	}
+	if len(snapshots) != counts[quiet] || filtered != counts[quiet] {
+		t.Errorf("expected %d snapshots and records for %s, got %d (%d records)", counts[quiet], quiet, len(snapshots), filtered)
+	}
+	for _, snap := range snapshots {
+		if snap.SourceVolume != quiet {
+			t.Errorf("snapshot %s of %s returned for %s", snap.Name, snap.SourceVolume, quiet)
+		}
+	}
+
+	// RouterOS without the filter: the client falls back to the full listing once, then
+	// skips the filtered query
+	server.SetSourceVolumeFilterSupported(false)
+	server.ClearCommandHistory()
+	for i := 0; i < 2; i++ {
+		snapshots, err := client.ListSnapshotsBySource(quiet)
+		if err != nil {
+			t.Fatalf("ListSnapshotsBySource without filter support failed: %v", err)
+		}
+		if len(snapshots) != 33 {
+			t.Errorf("expected the unfiltered listing as fallback, got %d snapshots", len(snapshots))
+		}
+		// Without the source-volume property the source comes from the snapshot comment
+		matching := 0
+		for _, snap := range snapshots {
+			if snap.SourceVolume == quiet {
+				matching++
+			}
+		}
+		if matching != counts[quiet] {
+			t.Errorf("expected %d snapshots of %s in the fallback listing, got %d", counts[quiet], quiet, matching)
+		}
+	}
+	if history := server.GetCommandHistory(); len(history) != 3 {
+		t.Errorf("expected a rejected query and two full listings, got %d commands: %+v", len(history), history)
+	}
+}
+
+// TestMockRDS_CommandErrorMetrics checks the error class and retries recorded for the
+// failures the mock server injects
+func TestMockRDS_CommandErrorMetrics(t *testing.T) {
+	const slot = "pvc-c0ffee00-0000-0000-0000-000000000001"
+	opts := rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      "/storage-pool/metal-csi/" + slot + ".img",
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    "nqn.2000-02.com.mikrotik:" + slot,
+	}
+
+	tests := []struct {
+		name  string
+		run   func(t *testing.T, server *MockRDSServer, client rds.RDSClient)
+		wants []string
+	}{
+		{
+			name: "not enough space",
+			run: func(t *testing.T, server *MockRDSServer, client rds.RDSClient) {
+				server.SetErrorMode(ErrorModeDiskFull)
+				_ = client.CreateVolume(opts)
+			},
+			wants: []string{
+				`rds_csi_rds_command_errors_total{command="/disk add",error_class="not_enough_space"} 1`,
+			},
+		},
+		{
+			name: "execution error is retried",
+			run: func(t *testing.T, server *MockRDSServer, client rds.RDSClient) {
+				server.SetErrorMode(ErrorModeCommandFail)
+				_ = client.CreateVolume(opts)
+			},
+			wants: []string{
+				`rds_csi_rds_command_errors_total{command="/disk add",error_class="other"} 3`,
+				`rds_csi_rds_command_retries_total{command="/disk add"} 2`,
+			},
+		},
+		{
+			name: "no such item",
+			run: func(t *testing.T, server *MockRDSServer, client rds.RDSClient) {
+				_ = client.SetDiskComment(slot, "default/data")
+			},
+			wants: []string{
+				`rds_csi_rds_command_errors_total{command="/disk set",error_class="no_such_item"} 1`,
+			},
+		},
+		{
+			name: "already exists",
+			run: func(t *testing.T, server *MockRDSServer, client rds.RDSClient) {
+				if err := client.CreateVolume(opts); err != nil {
+					t.Fatalf("CreateVolume failed: %v", err)
+				}
+				if err := client.CreateVolume(opts); err != nil {
+					t.Fatalf("repeated CreateVolume failed: %v", err)
+				}
+			},
+			wants: []string{
+				`rds_csi_rds_command_errors_total{command="/disk add",error_class="already_exists"} 1`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _, cleanup := setupSnapshotTestClient(t)
+			defer cleanup()
+
+			metrics := observability.NewMetrics()
+			client, err := rds.NewClient(rds.ClientConfig{
+				Address:            server.Address(),
+				Port:               server.Port(),
+				User:               "admin",
+				InsecureSkipVerify: true,
+				Metrics:            metrics,
+			})
+			if err != nil {
+				t.Fatalf("failed to create rds client: %v", err)
+			}
+			if err := client.Connect(); err != nil {
+				t.Fatalf("failed to connect rds client: %v", err)
+			}
+			defer func() { _ = client.Close() }()
+
+			tt.run(t, server, client)
+
+			rec := httptest.NewRecorder()
+			metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			for _, want := range tt.wants {
+				if !strings.Contains(rec.Body.String(), want+"\n") {
+					t.Errorf("metrics missing %s", want)
+				}
+			}
+		})
+	}
+}
+
+// TestMockRDS_Restart tests that a restart drops the client's connection, refuses
+// connections while the server is down and keeps the volumes
+func TestMockRDS_Restart(t *testing.T) {
+	server, client, cleanup := setupSnapshotTestClient(t)
+	defer cleanup()
+
+	const slot = "pvc-e0e0e0e0-0000-0000-0000-000000000001"
+	if err := client.CreateVolume(rds.CreateVolumeOptions{
+		Slot:          slot,
+		FilePath:      fmt.Sprintf("/storage-pool/metal-csi/%s.img", slot),
+		FileSizeBytes: 1024 * 1024 * 1024,
+		NVMETCPPort:   4420,
+		NVMETCPNQN:    fmt.Sprintf("nqn.2000-02.com.mikrotik:%s", slot),
+	}); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	restarted := make(chan error, 1)
+	go func() { restarted <- server.Restart(500 * time.Millisecond) }()
+
+	addr := net.JoinHostPort(server.Address(), strconv.Itoa(server.Port()))
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for client.IsConnected() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if client.IsConnected() {
+		t.Fatal("client still connected after the restart began")
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		_ = conn.Close()
+		t.Fatal("server accepted a connection while down")
+	}
+
+	if err := <-restarted; err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	_ = client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to reconnect after restart: %v", err)
+	}
+	vol, err := client.GetVolume(slot)
+	if err != nil {
+		t.Fatalf("GetVolume after restart failed: %v", err)
+	}
+	if vol.Slot != slot || !vol.NVMETCPExport {
+		t.Errorf("volume changed across the restart: %+v", vol)
+	}
+}
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -14,6 +15,7 @@ type TimingSimulator struct {
 	sshLatencyJitter time.Duration
 	diskAddDelay     time.Duration
 	diskRemoveDelay  time.Duration
+	rngMu            sync.Mutex // rand.Rand is not safe for concurrent sessions
 	rng              *rand.Rand
 }
 
@@ -40,7 +42,9 @@ func (t *TimingSimulator) SimulateSSHLatency() {
 	jitter := time.Duration(0)
 	if t.sshLatencyJitter > 0 {
 		// Random jitter in range [-jitter, +jitter]
+		t.rngMu.Lock()
 		jitter = time.Duration(t.rng.Int63n(int64(t.sshLatencyJitter*2))) - t.sshLatencyJitter
+		t.rngMu.Unlock()
 	}
 
 	delay := t.sshLatency + jitter
@@ -114,6 +114,7 @@ func TestCSISanity(t *testing.T) {
 		RDSInsecureSkipVerify: true,                      // Skip host key verification for mock
 		RDSVolumeBasePath:     testVolumeBasePath,
 		ManagedNQNPrefix:      "nqn.2000-02.com.mikrotik:", // Required for node service (NVMe format requires colon)
+		SlotPrefix:            "sanity-",                   // csi-sanity names volumes sanity-<test>-<id>
 		EnableController:      true,
 		EnableNode:            true, // Enable node service with mock NVMe connector
 		K8sClient:             nil,  // Not needed for basic sanity tests
@@ -133,6 +134,9 @@ func TestCSISanity(t *testing.T) {
 	// The mock mounter tracks mounts but they're not in /proc/mountinfo
 	drv.SetGetMountDevFunc(mockMounter.GetMountDevice)
 
+	// The test host need not have nvme_tcp loaded; NVMe is mocked
+	drv.SetNodeReadinessCheck(func() error { return nil })
+
 	// Remove old socket if exists
 	_ = os.Remove(testSocketPath)
 